@@ -0,0 +1,220 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package autodiscovery implements 'agent autodiscovery'.
+package autodiscovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/providers"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// cliParams are the command-line arguments for this subcommand
+type cliParams struct {
+	*command.GlobalParams
+
+	service       string
+	templateFiles []string
+	checkName     string
+	jsonOutput    bool
+	verbose       bool
+}
+
+// Commands returns a slice of subcommands for the 'agent' command.
+func Commands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &cliParams{
+		GlobalParams: globalParams,
+	}
+
+	autodiscoveryCmd := &cobra.Command{
+		Use:   "autodiscovery",
+		Short: "Inspect the autodiscovery state of a running agent",
+	}
+
+	explainCmd := &cobra.Command{
+		Use:   "explain [service-or-container]",
+		Short: "Explain why the autodiscovery templates did or did not match a service",
+		Long: `Show the candidate templates of the services of a running agent, with their AD identifiers
+and the result of their resolution, including the template variables that could not be resolved.
+
+The service can be given as a service ID (docker://<id>), a container ID or ID prefix, or a
+container or pod name. All services are explained when it's omitted.
+
+Template files given with --template are resolved against the live services without being
+scheduled, to check a template before deploying it.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				cliParams.service = args[0]
+			}
+			return fxutil.OneShot(explain,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams(globalParams.ConfFilePath, config.WithExtraConfFiles(globalParams.ExtraConfFilePath), config.WithFleetPoliciesDirPath(globalParams.FleetPoliciesDirPath)),
+					LogParams:    log.ForOneShot(command.LoggerName, "off", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+	explainCmd.Flags().StringSliceVarP(&cliParams.templateFiles, "template", "t", nil, "Simulate the templates of this configuration file against the live services (can be repeated).")
+	explainCmd.Flags().StringVar(&cliParams.checkName, "check-name", "", "Check name of the simulated templates, guessed from the file path by default.")
+	explainCmd.Flags().BoolVarP(&cliParams.jsonOutput, "json", "j", false, "Print the explanation as JSON.")
+	explainCmd.Flags().BoolVarP(&cliParams.verbose, "verbose", "v", false, "Print the resolved configurations.")
+	autodiscoveryCmd.AddCommand(explainCmd)
+
+	return []*cobra.Command{autodiscoveryCmd}
+}
+
+func explain(config config.Component, cliParams *cliParams, _ log.Component) error {
+	req := integration.ExplainRequest{Service: cliParams.service}
+	for _, path := range cliParams.templateFiles {
+		tpl, err := readTemplate(path, cliParams.checkName)
+		if err != nil {
+			return err
+		}
+		req.Templates = append(req.Templates, tpl)
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	ipcAddress, err := pkgconfig.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	if err = util.SetAuthToken(config); err != nil {
+		return err
+	}
+	urlstr := fmt.Sprintf("https://%v:%v/agent/autodiscovery-explain", ipcAddress, config.GetInt("cmd_port"))
+	res, err := util.DoPost(util.GetClient(false), urlstr, "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		if len(res) > 0 {
+			return fmt.Errorf("the agent ran into an error while explaining autodiscovery: %s", res)
+		}
+		return fmt.Errorf("could not reach the agent: %v", err)
+	}
+
+	var resp integration.ExplainResponse
+	if err = json.Unmarshal(res, &resp); err != nil {
+		return fmt.Errorf("unable to parse the explain response: %v", err)
+	}
+
+	if cliParams.jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(resp)
+	}
+
+	var b bytes.Buffer
+	color.Output = &b
+	printExplanations(color.Output, resp, cliParams.verbose)
+	fmt.Print(b.String())
+	return nil
+}
+
+// readTemplate reads a template file the same way the file config provider does
+func readTemplate(path, checkName string) (integration.Config, error) {
+	if checkName == "" {
+		checkName = checkNameFromPath(path)
+	}
+	tpl, err := providers.GetIntegrationConfigFromFile(checkName, path)
+	if err != nil {
+		return tpl, fmt.Errorf("could not read the template %s: %w", path, err)
+	}
+	if !tpl.IsTemplate() {
+		return tpl, fmt.Errorf("%s is not a template: it doesn't have any ad_identifiers", path)
+	}
+	return tpl, nil
+}
+
+// checkNameFromPath returns the check name of a configuration file, following
+// the conf.d layout: conf.d/<check>.d/<file>.yaml or conf.d/<check>.yaml
+func checkNameFromPath(path string) string {
+	if dir := filepath.Base(filepath.Dir(path)); strings.HasSuffix(dir, ".d") && dir != "conf.d" {
+		return strings.TrimSuffix(dir, ".d")
+	}
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func printExplanations(w io.Writer, resp integration.ExplainResponse, verbose bool) {
+	if w != color.Output {
+		color.NoColor = true
+	}
+
+	if len(resp.Services) == 0 {
+		fmt.Fprintln(w, "No service matches.")
+		return
+	}
+
+	for _, svc := range resp.Services {
+		fmt.Fprintf(w, "\n=== Service %s ===\n", color.GreenString(svc.ServiceID))
+		fmt.Fprintf(w, "%s: %s\n", color.BlueString("Auto-discovery IDs"), strings.Join(svc.ADIdentifiers, ", "))
+		if len(svc.Templates) == 0 {
+			fmt.Fprintln(w, "No template has any of these AD identifiers.")
+			continue
+		}
+
+		for _, tpl := range svc.Templates {
+			source := tpl.Template.Source
+			if tpl.Simulated {
+				source = "simulated " + source
+			}
+			fmt.Fprintf(w, "\n* %s (%s): %s\n", color.CyanString(tpl.Template.Name), source, statusString(tpl.Status))
+			fmt.Fprintf(w, "  %s: %s\n", color.BlueString("Auto-discovery IDs"), strings.Join(tpl.Template.ADIdentifiers, ", "))
+			for _, condition := range tpl.Template.ADConditions {
+				fmt.Fprintf(w, "  %s: %s\n", color.BlueString("Condition"), condition)
+			}
+			for _, err := range tpl.Errors {
+				fmt.Fprintf(w, "  %s: %s\n", color.RedString("Error"), err)
+			}
+			if verbose && tpl.Resolved != nil {
+				for _, inst := range tpl.Resolved.Instances {
+					fmt.Fprintf(w, "  %s:\n", color.BlueString("Resolved instance"))
+					fmt.Fprintln(w, indent(string(inst), "    "))
+				}
+				if len(tpl.Resolved.LogsConfig) > 0 {
+					fmt.Fprintf(w, "  %s:\n", color.BlueString("Resolved logs config"))
+					fmt.Fprintln(w, indent(string(tpl.Resolved.LogsConfig), "    "))
+				}
+			}
+		}
+	}
+}
+
+func statusString(status integration.TemplateStatus) string {
+	switch status {
+	case integration.TemplateScheduled, integration.TemplateResolved:
+		return color.GreenString(string(status))
+	case integration.TemplateFiltered, integration.TemplateADIdentifiersMismatch, integration.TemplateConditionsNotMatched:
+		return color.YellowString(string(status))
+	}
+	return color.RedString(string(status))
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n"+prefix)
+}
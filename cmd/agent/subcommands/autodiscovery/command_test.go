@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package autodiscovery
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestExplainCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"autodiscovery", "explain", "redis-0", "--template", "auto_conf.yaml", "-v"},
+		explain,
+		func(cliParams *cliParams, _ core.BundleParams) {
+			require.Equal(t, "redis-0", cliParams.service)
+			require.Equal(t, []string{"auto_conf.yaml"}, cliParams.templateFiles)
+			require.True(t, cliParams.verbose)
+		})
+}
+
+func TestReadTemplate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "redisdb.d")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	path := filepath.Join(dir, "auto_conf.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("ad_identifiers: [redis]\ninit_config:\ninstances:\n  - host: '%%host%%'\n"), 0644))
+
+	tpl, err := readTemplate(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "redisdb", tpl.Name)
+	assert.Equal(t, []string{"redis"}, tpl.ADIdentifiers)
+
+	tpl, err = readTemplate(path, "redis")
+	require.NoError(t, err)
+	assert.Equal(t, "redis", tpl.Name)
+
+	require.NoError(t, os.WriteFile(path, []byte("init_config:\ninstances:\n  - host: localhost\n"), 0644))
+	_, err = readTemplate(path, "")
+	assert.ErrorContains(t, err, "is not a template")
+
+	assert.Equal(t, "http_check", checkNameFromPath("conf.d/http_check.yaml"))
+}
+
+func TestPrintExplanations(t *testing.T) {
+	resolved := integration.Config{Instances: []integration.Data{integration.Data("host: 10.0.0.2\n")}}
+	resp := integration.ExplainResponse{Services: []integration.ServiceExplanation{{
+		ServiceID:     "docker://abcdef",
+		ADIdentifiers: []string{"redis"},
+		Templates: []integration.TemplateExplanation{
+			{Template: integration.Config{Name: "redisdb", Source: "file:/etc/redisdb.d/auto_conf.yaml", ADIdentifiers: []string{"redis"}}, Status: integration.TemplateScheduled, Resolved: &resolved},
+			{Template: integration.Config{Name: "nginx", Source: "nginx.yaml", ADIdentifiers: []string{"redis"}}, Simulated: true, Status: integration.TemplateResolutionFailed, Errors: []string{"no port found"}},
+		},
+	}}}
+
+	var b bytes.Buffer
+	printExplanations(&b, resp, true)
+	assert.Equal(t, `
+=== Service docker://abcdef ===
+Auto-discovery IDs: redis
+
+* redisdb (file:/etc/redisdb.d/auto_conf.yaml): scheduled
+  Auto-discovery IDs: redis
+  Resolved instance:
+    host: 10.0.0.2
+
+* nginx (simulated nginx.yaml): resolution_failed
+  Auto-discovery IDs: redis
+  Error: no port found
+`, b.String())
+}
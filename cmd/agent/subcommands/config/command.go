@@ -16,7 +16,7 @@ import (
 
 // Commands returns a slice of subcommands for the 'agent' command.
 func Commands(globalParams *command.GlobalParams) []*cobra.Command {
-	cmd := configcmd.MakeCommand(func() configcmd.GlobalParams {
+	globalParamsGetter := func() configcmd.GlobalParams {
 		return configcmd.GlobalParams{
 			ConfFilePath:       globalParams.ConfFilePath,
 			ExtraConfFilePaths: globalParams.ExtraConfFilePath,
@@ -24,7 +24,9 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 			LoggerName:         command.LoggerName,
 			SettingsClient:     common.NewSettingsClient,
 		}
-	})
+	}
+	cmd := configcmd.MakeCommand(globalParamsGetter)
+	cmd.AddCommand(configcmd.MakeLintCommand(globalParamsGetter))
 
 	return []*cobra.Command{cmd}
 }
@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package dogstatsdinspect implements 'agent dogstatsd-inspect'.
+package dogstatsdinspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	replay "github.com/DataDog/datadog-agent/comp/dogstatsd/replay/impl"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+const (
+	defaultTop = 10
+)
+
+// cliParams are the command-line arguments for this subcommand
+type cliParams struct {
+	*command.GlobalParams
+
+	// subcommand-specific flags
+
+	dsdCaptureFilePath string
+	dsdMmap            bool
+	top                int
+	jsonOutput         bool
+
+	metricNames  []string
+	pids         []int32
+	containerIDs []string
+	since        string
+	until        string
+
+	exportFilePath string
+	exportFormat   string
+	outputFilePath string
+}
+
+// Commands returns a slice of subcommands for the 'agent' command.
+func Commands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &cliParams{
+		GlobalParams: globalParams,
+	}
+
+	dogstatsdInspectCmd := &cobra.Command{
+		Use:   "dogstatsd-inspect",
+		Short: "Inspect, export or filter a dogstatsd traffic capture offline",
+		Long: `Read a capture file written by dogstatsd-capture and print statistics about its contents.
+
+Filters restrict the packets and metrics taken into account. The matching traffic can be exported
+to JSON or CSV with --export, or written to a new capture file with --output so that it can be
+replayed with dogstatsd-replay.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			// inspection runs offline, the agent configuration is not required
+			return fxutil.OneShot(dogstatsdInspect,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams(globalParams.ConfFilePath, config.WithConfigMissingOK(true), config.WithExtraConfFiles(globalParams.ExtraConfFilePath), config.WithFleetPoliciesDirPath(globalParams.FleetPoliciesDirPath)),
+					LogParams:    log.ForOneShot(command.LoggerName, "off", true),
+				}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	flags := dogstatsdInspectCmd.Flags()
+	flags.StringVarP(&cliParams.dsdCaptureFilePath, "file", "f", "", "Input file with traffic captured with dogstatsd-capture.")
+	flags.BoolVarP(&cliParams.dsdMmap, "mmap", "m", true, "Mmap file for inspection. Set to false to load the entire file into memory instead")
+	flags.IntVar(&cliParams.top, "top", defaultTop, "Number of entries to print for metric names, tag keys and senders, 0 to print all of them.")
+	flags.BoolVarP(&cliParams.jsonOutput, "json", "j", false, "Print statistics as JSON.")
+	flags.StringSliceVar(&cliParams.metricNames, "metric", nil, "Only keep metrics whose name matches this pattern (shell glob, can be repeated).")
+	flags.Int32SliceVar(&cliParams.pids, "pid", nil, "Only keep packets sent by this PID (can be repeated).")
+	flags.StringSliceVar(&cliParams.containerIDs, "container-id", nil, "Only keep packets sent from this container ID (can be repeated).")
+	flags.StringVar(&cliParams.since, "since", "", "Only keep packets received at or after this RFC3339 time.")
+	flags.StringVar(&cliParams.until, "until", "", "Only keep packets received at or before this RFC3339 time.")
+	flags.StringVar(&cliParams.exportFilePath, "export", "", "Export the matching messages to this file.")
+	flags.StringVar(&cliParams.exportFormat, "export-format", replay.ExportFormatJSON, "Format of the export, json or csv.")
+	flags.StringVarP(&cliParams.outputFilePath, "output", "o", "", "Write the matching packets to this file as a new capture that can be replayed.")
+	dogstatsdInspectCmd.MarkFlagRequired("file") //nolint:errcheck
+
+	return []*cobra.Command{dogstatsdInspectCmd}
+}
+
+func dogstatsdInspect(_ log.Component, cliParams *cliParams) error {
+	filter, err := buildFilter(cliParams)
+	if err != nil {
+		return err
+	}
+
+	reader, err := replay.NewTrafficCaptureReader(cliParams.dsdCaptureFilePath, 0, cliParams.dsdMmap)
+	if reader != nil {
+		defer reader.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", cliParams.dsdCaptureFilePath, err)
+	}
+
+	if cliParams.exportFilePath != "" {
+		if err := writeToFile(cliParams.exportFilePath, func(w io.Writer) error {
+			return replay.ExportCapture(reader, filter, w, cliParams.exportFormat)
+		}); err != nil {
+			return fmt.Errorf("unable to export capture: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Exported matching messages to %s\n", cliParams.exportFilePath)
+	}
+
+	if cliParams.outputFilePath != "" {
+		var written int
+		if err := writeToFile(cliParams.outputFilePath, func(w io.Writer) error {
+			written, err = replay.WriteFilteredCapture(reader, filter, w)
+			return err
+		}); err != nil {
+			return fmt.Errorf("unable to write filtered capture: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d packets to %s\n", written, cliParams.outputFilePath)
+	}
+
+	stats, err := replay.ComputeCaptureStats(reader, filter, cliParams.top)
+	if err != nil {
+		return err
+	}
+
+	if cliParams.jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	printStats(os.Stdout, stats)
+	return nil
+}
+
+func buildFilter(cliParams *cliParams) (replay.CaptureFilter, error) {
+	filter := replay.CaptureFilter{
+		MetricNames:  cliParams.metricNames,
+		Pids:         cliParams.pids,
+		ContainerIDs: cliParams.containerIDs,
+	}
+
+	var err error
+	if cliParams.since != "" {
+		if filter.From, err = time.Parse(time.RFC3339, cliParams.since); err != nil {
+			return filter, fmt.Errorf("invalid --since value: %w", err)
+		}
+	}
+	if cliParams.until != "" {
+		if filter.To, err = time.Parse(time.RFC3339, cliParams.until); err != nil {
+			return filter, fmt.Errorf("invalid --until value: %w", err)
+		}
+	}
+	return filter, nil
+}
+
+func writeToFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func printStats(w io.Writer, stats *replay.CaptureStats) {
+	fmt.Fprintf(w, "Capture file version: %d\n", stats.Version)
+	if stats.Packets == 0 {
+		fmt.Fprintln(w, "No packet matching the filters.")
+		return
+	}
+	fmt.Fprintf(w, "Time window: %s - %s (%s)\n", stats.Start.Format(time.RFC3339), stats.End.Format(time.RFC3339), stats.End.Sub(stats.Start))
+	fmt.Fprintf(w, "Packets: %d, Bytes: %d\n", stats.Packets, stats.Bytes)
+	fmt.Fprintf(w, "Metrics: %d (%d contexts), Events: %d, Service checks: %d\n", stats.Metrics, stats.Contexts, stats.Events, stats.ServiceChecks)
+
+	fmt.Fprintf(w, "\nPacket sizes: min %d, avg %d, max %d bytes\n", stats.PacketSizes.Min, stats.PacketSizes.Avg, stats.PacketSizes.Max)
+	buckets := make([]string, 0, len(stats.PacketSizes.Buckets))
+	for bucket := range stats.PacketSizes.Buckets {
+		buckets = append(buckets, bucket)
+	}
+	bound := func(bucket string) int {
+		// every bucket is a number but the last one, "+Inf"
+		if b, err := strconv.Atoi(bucket); err == nil {
+			return b
+		}
+		return math.MaxInt
+	}
+	sort.Slice(buckets, func(i, j int) bool { return bound(buckets[i]) < bound(buckets[j]) })
+	for _, bucket := range buckets {
+		fmt.Fprintf(w, "  <= %-6s %d\n", bucket, stats.PacketSizes.Buckets[bucket])
+	}
+
+	fmt.Fprintln(w, "\nTop metric names:")
+	for _, m := range stats.TopMetrics {
+		fmt.Fprintf(w, "  %-60s %d\n", m.Name, m.Count)
+	}
+
+	fmt.Fprintln(w, "\nTop tag keys by cardinality:")
+	for _, t := range stats.TopTagKeys {
+		fmt.Fprintf(w, "  %-60s %d\n", t.Name, t.Count)
+	}
+
+	fmt.Fprintln(w, "\nTop senders:")
+	for _, s := range stats.TopSenders {
+		containerID := s.ContainerID
+		if containerID == "" {
+			containerID = "-"
+		}
+		fmt.Fprintf(w, "  pid %-8d %-80s %d packets, %d bytes\n", s.Pid, containerID, s.Packets, s.Bytes)
+	}
+}
@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsdinspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"dogstatsd-inspect", "-f", "capture.dog", "--metric", "foo.*", "--pid", "12", "--since", "2024-01-02T15:04:05Z", "--export-format", "csv"},
+		dogstatsdInspect,
+		func(cliParams *cliParams, _ core.BundleParams) {
+			require.Equal(t, "capture.dog", cliParams.dsdCaptureFilePath)
+			require.Equal(t, []string{"foo.*"}, cliParams.metricNames)
+			require.Equal(t, []int32{12}, cliParams.pids)
+			require.Equal(t, "csv", cliParams.exportFormat)
+
+			filter, err := buildFilter(cliParams)
+			require.NoError(t, err)
+			require.Equal(t, 2024, filter.From.Year())
+			require.True(t, filter.To.IsZero())
+		})
+}
@@ -7,12 +7,18 @@
 package flare
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/fatih/color"
@@ -33,6 +39,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	"github.com/DataDog/datadog-agent/comp/core/flare"
 	"github.com/DataDog/datadog-agent/comp/core/flare/helpers"
+	flaretypes "github.com/DataDog/datadog-agent/comp/core/flare/types"
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
 	"github.com/DataDog/datadog-agent/comp/core/secrets"
 	"github.com/DataDog/datadog-agent/comp/core/sysprobeconfig"
@@ -76,6 +83,10 @@ type cliParams struct {
 	profileBlocking      bool
 	profileBlockingRate  int
 	withStreamLogs       time.Duration
+	preview              bool
+	include              []string
+	exclude              []string
+	encryptTo            string
 }
 
 // Commands returns a slice of subcommands for the 'agent' command.
@@ -158,6 +169,10 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	flareCmd.Flags().BoolVarP(&cliParams.profileBlocking, "profile-blocking", "B", false, "Add gorouting blocking profile to the performance data in the flare")
 	flareCmd.Flags().IntVarP(&cliParams.profileBlockingRate, "profile-blocking-rate", "", 10000, "Set the fraction of goroutine blocking events that are reported in the blocking profile")
 	flareCmd.Flags().DurationVarP(&cliParams.withStreamLogs, "with-stream-logs", "L", 0*time.Second, "Add stream-logs data to the flare. It will collect logs for the amount of seconds passed to the flag")
+	flareCmd.Flags().BoolVarP(&cliParams.preview, "preview", "", false, "Create the flare and list its files, the provider that added them and the scrubber replacements made, without sending it")
+	flareCmd.Flags().StringSliceVarP(&cliParams.include, "include", "", nil, "Only include the flare providers matching these names (e.g. 'core/config', 'core' or 'config')")
+	flareCmd.Flags().StringSliceVarP(&cliParams.exclude, "exclude", "", nil, "Exclude the flare providers matching these names (e.g. 'logs/agent', 'logs' or 'agent')")
+	flareCmd.Flags().StringVarP(&cliParams.encryptTo, "encrypt-to", "", "", "Encrypt the flare archive to this age recipient ('age1...'). Encrypted flares are kept locally and never sent")
 	flareCmd.SetArgs([]string{"caseID"})
 
 	return []*cobra.Command{flareCmd}
@@ -310,8 +325,25 @@ func makeFlare(flareComp flare.Component,
 		caseID = cliParams.args[0]
 	}
 
+	flareArgs := flaretypes.FlareArgs{
+		Include:   cliParams.include,
+		Exclude:   cliParams.exclude,
+		EncryptTo: cliParams.encryptTo,
+	}
+	if flareArgs.EncryptTo != "" {
+		if cliParams.preview {
+			return errors.New("--preview can't be used with --encrypt-to: the preview needs to read the archive")
+		}
+		// Fail early on invalid keys instead of after collecting the profiles and logs
+		if _, err := helpers.ParseRecipient(flareArgs.EncryptTo); err != nil {
+			return err
+		}
+	}
+	// Previewed and encrypted flares are never sent
+	sendFlare := !cliParams.preview && flareArgs.EncryptTo == ""
+
 	customerEmail := cliParams.customerEmail
-	if customerEmail == "" {
+	if customerEmail == "" && sendFlare {
 		customerEmail, err = input.AskForEmail()
 		if err != nil {
 			fmt.Println("Error reading email, please retry or contact support")
@@ -355,9 +387,9 @@ func makeFlare(flareComp flare.Component,
 
 	var filePath string
 	if cliParams.forceLocal {
-		filePath, err = createArchive(flareComp, profile, nil)
+		filePath, err = createArchive(flareComp, flareArgs, profile, nil)
 	} else {
-		filePath, err = requestArchive(flareComp, profile)
+		filePath, err = requestArchive(flareComp, flareArgs, profile)
 	}
 
 	if err != nil {
@@ -370,6 +402,24 @@ func makeFlare(flareComp flare.Component,
 		return err
 	}
 
+	if flareArgs.EncryptTo != "" {
+		if !strings.HasSuffix(filePath, helpers.EncryptedArchiveExt) {
+			// An agent that doesn't support encryption ignores the parameter: don't leave a clear archive behind.
+			_ = os.Remove(filePath)
+			return fmt.Errorf("the flare archive was not encrypted, the agent might not support flare encryption: try the '--local' option")
+		}
+		fmt.Fprintf(color.Output, "The encrypted flare archive is available at %s. It will not be sent to Datadog.\n", color.YellowString(filePath))
+		return nil
+	}
+
+	if cliParams.preview {
+		if err := printManifest(color.Output, filePath); err != nil {
+			return err
+		}
+		fmt.Fprintf(color.Output, "The flare was not sent. You can review it at %s\n", color.YellowString(filePath))
+		return nil
+	}
+
 	fmt.Fprintf(color.Output, "%s is going to be uploaded to Datadog\n", color.YellowString(filePath))
 	if !cliParams.autoconfirm {
 		confirmation := input.AskForConfirmation("Are you sure you want to upload a flare? [y/N]")
@@ -387,21 +437,35 @@ func makeFlare(flareComp flare.Component,
 	return nil
 }
 
-func requestArchive(flareComp flare.Component, pdata flare.ProfileData) (string, error) {
+func requestArchive(flareComp flare.Component, flareArgs flaretypes.FlareArgs, pdata flare.ProfileData) (string, error) {
 	fmt.Fprintln(color.Output, color.BlueString("Asking the agent to build the flare archive."))
 	c := util.GetClient(false) // FIX: get certificates right then make this true
 	ipcAddress, err := pkgconfig.GetIPCAddress()
 	if err != nil {
 		fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("Error getting IPC address for the agent: %s", err)))
-		return createArchive(flareComp, pdata, err)
+		return createArchive(flareComp, flareArgs, pdata, err)
+	}
+
+	query := url.Values{}
+	for _, include := range flareArgs.Include {
+		query.Add("include", include)
+	}
+	for _, exclude := range flareArgs.Exclude {
+		query.Add("exclude", exclude)
+	}
+	if flareArgs.EncryptTo != "" {
+		query.Set("encrypt_to", flareArgs.EncryptTo)
 	}
 
 	urlstr := fmt.Sprintf("https://%v:%v/agent/flare", ipcAddress, pkgconfig.Datadog().GetInt("cmd_port"))
+	if len(query) > 0 {
+		urlstr += "?" + query.Encode()
+	}
 
 	// Set session token
 	if err = util.SetAuthToken(pkgconfig.Datadog()); err != nil {
 		fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("Error: %s", err)))
-		return createArchive(flareComp, pdata, err)
+		return createArchive(flareComp, flareArgs, pdata, err)
 	}
 
 	p, err := json.Marshal(pdata)
@@ -419,15 +483,15 @@ func requestArchive(flareComp flare.Component, pdata flare.ProfileData) (string,
 			fmt.Fprintln(color.Output, color.RedString("The agent was unable to make the flare. (is it running?)"))
 			err = fmt.Errorf("Error getting flare from running agent: %w", err)
 		}
-		return createArchive(flareComp, pdata, err)
+		return createArchive(flareComp, flareArgs, pdata, err)
 	}
 
 	return string(r), nil
 }
 
-func createArchive(flareComp flare.Component, pdata flare.ProfileData, ipcError error) (string, error) {
+func createArchive(flareComp flare.Component, flareArgs flaretypes.FlareArgs, pdata flare.ProfileData, ipcError error) (string, error) {
 	fmt.Fprintln(color.Output, color.YellowString("Initiating flare locally."))
-	filePath, err := flareComp.Create(pdata, ipcError)
+	filePath, err := flareComp.CreateWithArgs(flareArgs, pdata, ipcError)
 	if err != nil {
 		fmt.Printf("The flare zipfile failed to be created: %s\n", err)
 		return "", err
@@ -435,3 +499,64 @@ func createArchive(flareComp flare.Component, pdata flare.ProfileData, ipcError
 
 	return filePath, nil
 }
+
+// printManifest prints the manifest of the given flare archive: every file with the provider that added it and the
+// scrubber replacements made.
+func printManifest(w io.Writer, archivePath string) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("could not open the flare archive: %w", err)
+	}
+	defer reader.Close()
+
+	var manifest helpers.Manifest
+	found := false
+	for _, file := range reader.File {
+		if file.Name != helpers.ManifestFileName && !strings.HasSuffix(file.Name, "/"+helpers.ManifestFileName) {
+			continue
+		}
+		f, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("could not read the flare manifest: %w", err)
+		}
+		err = json.NewDecoder(f).Decode(&manifest)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("could not read the flare manifest: %w", err)
+		}
+		found = true
+		break
+	}
+	if !found {
+		return fmt.Errorf("the flare archive %s doesn't contain a manifest", archivePath)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tPROVIDER\tSIZE\tSCRUBBED\tREPLACEMENTS")
+	for _, file := range manifest.Files {
+		provider := file.Provider
+		if provider == "" {
+			provider = "-"
+		}
+
+		rules := make([]string, 0, len(file.Replacements))
+		for rule, count := range file.Replacements {
+			rules = append(rules, fmt.Sprintf("%s=%d", rule, count))
+		}
+		sort.Strings(rules)
+		replacements := strings.Join(rules, ",")
+		if replacements == "" {
+			replacements = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%t\t%s\n", file.Path, provider, file.Size, file.Scrubbed, replacements)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(manifest.ExcludedProviders) > 0 {
+		fmt.Fprintf(w, "\nExcluded providers: %s\n", strings.Join(manifest.ExcludedProviders, ", "))
+	}
+	return nil
+}
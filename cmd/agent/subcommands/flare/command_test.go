@@ -6,12 +6,17 @@
 package flare
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
 	"maps"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"path"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -21,10 +26,12 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/agent/command"
 	"github.com/DataDog/datadog-agent/comp/core"
 	"github.com/DataDog/datadog-agent/comp/core/flare"
+	"github.com/DataDog/datadog-agent/comp/core/flare/helpers"
 	"github.com/DataDog/datadog-agent/comp/core/secrets"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
 type commandTestSuite struct {
@@ -244,3 +251,54 @@ func (c *commandTestSuite) TestCommand() {
 			require.Equal(t, true, secretParams.Enabled)
 		})
 }
+
+func (c *commandTestSuite) TestCommandFlareArgs() {
+	t := c.T()
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"flare", "--preview", "--include", "core,logs/agent", "--exclude", "core/status", "--encrypt-to", "age1key"},
+		makeFlare,
+		func(cliParams *cliParams, _ core.BundleParams, _ secrets.Params) {
+			require.True(t, cliParams.preview)
+			require.Equal(t, []string{"core", "logs/agent"}, cliParams.include)
+			require.Equal(t, []string{"core/status"}, cliParams.exclude)
+			require.Equal(t, "age1key", cliParams.encryptTo)
+		})
+}
+
+func TestPrintManifest(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "flare.zip")
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	w := zip.NewWriter(f)
+	manifestFile, err := w.Create("hostname/" + helpers.ManifestFileName)
+	require.NoError(t, err)
+	require.NoError(t, json.NewEncoder(manifestFile).Encode(helpers.Manifest{
+		Files: []helpers.ManifestFile{
+			{Path: "flare_creation.log", Size: 12},
+			{Path: "runtime_config_dump.yaml", Provider: "core/config", Size: 2048, Scrubbed: true, Replacements: scrubber.Replacements{"password": 2, "api_key": 1}},
+		},
+		ExcludedProviders: []string{"logs/agent"},
+	}))
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	var out bytes.Buffer
+	require.NoError(t, printManifest(&out, archivePath))
+	require.Equal(t, `FILE                      PROVIDER     SIZE  SCRUBBED  REPLACEMENTS
+flare_creation.log        -            12    false     -
+runtime_config_dump.yaml  core/config  2048  true      api_key=1,password=2
+
+Excluded providers: logs/agent
+`, out.String())
+}
+
+func TestPrintManifestMissing(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "flare.zip")
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	require.NoError(t, zip.NewWriter(f).Close())
+	require.NoError(t, f.Close())
+
+	require.ErrorContains(t, printManifest(&bytes.Buffer{}, archivePath), "doesn't contain a manifest")
+}
@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package netflow implements 'agent netflow'.
+package netflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/comp/netflow/flowaggregator"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// cliParams are the command-line arguments for this subcommand
+type cliParams struct {
+	*command.GlobalParams
+
+	dimensions []string
+	current    bool
+	jsonOutput bool
+}
+
+// Commands returns a slice of subcommands for the 'agent' command.
+func Commands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &cliParams{
+		GlobalParams: globalParams,
+	}
+
+	netflowCmd := &cobra.Command{
+		Use:   "netflow",
+		Short: "Inspect the NetFlow collector of a running agent",
+	}
+
+	topCmd := &cobra.Command{
+		Use:   "top",
+		Short: "Print the top talkers of the flows received by a running agent",
+		Long: `Print the sources, destinations, ports, interfaces and applications with the most bytes during the last
+complete time window of network_devices.netflow.top_talkers.interval seconds. Bytes and packets are estimated from
+the sampling rate of the flows. The top talkers are computed when network_devices.netflow.top_talkers.enabled is true.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fxutil.OneShot(top,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams(globalParams.ConfFilePath, config.WithExtraConfFiles(globalParams.ExtraConfFilePath), config.WithFleetPoliciesDirPath(globalParams.FleetPoliciesDirPath)),
+					LogParams:    log.ForOneShot(command.LoggerName, "off", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+	topCmd.Flags().StringSliceVarP(&cliParams.dimensions, "dimension", "d", nil, "Only print the top talkers of these dimensions (source, destination, port, interface, application).")
+	topCmd.Flags().BoolVar(&cliParams.current, "current", false, "Print the top talkers of the time window in progress instead of the last complete one.")
+	topCmd.Flags().BoolVarP(&cliParams.jsonOutput, "json", "j", false, "Print the top talkers as JSON.")
+	netflowCmd.AddCommand(topCmd)
+
+	return []*cobra.Command{netflowCmd}
+}
+
+func top(config config.Component, cliParams *cliParams, _ log.Component) error {
+	ipcAddress, err := pkgconfig.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	if err = util.SetAuthToken(config); err != nil {
+		return err
+	}
+	urlstr := fmt.Sprintf("https://%v:%v/agent/netflow/top-talkers", ipcAddress, config.GetInt("cmd_port"))
+	res, err := util.DoGet(util.GetClient(false), urlstr, util.LeaveConnectionOpen)
+	if err != nil {
+		if len(res) > 0 {
+			return fmt.Errorf("the agent ran into an error while getting the top talkers: %s", res)
+		}
+		return fmt.Errorf("could not reach the agent: %v", err)
+	}
+
+	var resp flowaggregator.TopTalkersResponse
+	if err = json.Unmarshal(res, &resp); err != nil {
+		return fmt.Errorf("unable to parse the top talkers response: %v", err)
+	}
+	if !resp.Enabled {
+		return fmt.Errorf("the top talkers are not enabled: set network_devices.netflow.enabled and network_devices.netflow.top_talkers.enabled to true")
+	}
+
+	topTalkers := resp.Last
+	if cliParams.current {
+		topTalkers = resp.Current
+	}
+	if topTalkers == nil {
+		fmt.Println("No complete time window yet, use --current to print the time window in progress.")
+		return nil
+	}
+	filterDimensions(topTalkers, cliParams.dimensions)
+
+	if cliParams.jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(topTalkers)
+	}
+	return printTopTalkers(os.Stdout, topTalkers)
+}
+
+// filterDimensions removes the dimensions that are not in dimensions, if it's not empty
+func filterDimensions(topTalkers *flowaggregator.TopTalkers, dimensions []string) {
+	if len(dimensions) == 0 {
+		return
+	}
+	kept := make(map[string][]flowaggregator.Talker, len(dimensions))
+	for _, dimension := range dimensions {
+		if talkers, ok := topTalkers.Dimensions[dimension]; ok {
+			kept[dimension] = talkers
+		}
+	}
+	topTalkers.Dimensions = kept
+}
+
+// printTopTalkers prints a table of the top talkers per dimension
+func printTopTalkers(w io.Writer, topTalkers *flowaggregator.TopTalkers) error {
+	fmt.Fprintf(w, "Top talkers from %s to %s\n",
+		time.Unix(topTalkers.Start, 0).UTC().Format(time.RFC3339),
+		time.Unix(topTalkers.End, 0).UTC().Format(time.RFC3339))
+
+	dimensions := make([]string, 0, len(topTalkers.Dimensions))
+	for dimension := range topTalkers.Dimensions {
+		dimensions = append(dimensions, dimension)
+	}
+	sort.Strings(dimensions)
+
+	for _, dimension := range dimensions {
+		fmt.Fprintf(w, "\n%s\n", dimension)
+		talkers := topTalkers.Dimensions[dimension]
+		if len(talkers) == 0 {
+			fmt.Fprintln(w, "  no flows")
+			continue
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  RANK\tNAMESPACE\tTALKER\tBYTES\tPACKETS\tFLOWS")
+		for i, talker := range talkers {
+			fmt.Fprintf(tw, "  %d\t%s\t%s\t%d\t%d\t%d\n", i+1, talker.Namespace, talker.Key, talker.Bytes, talker.Packets, talker.Flows)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package netflow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/netflow/flowaggregator"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestTopCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"netflow", "top", "-d", "source,port", "--current", "--json"},
+		top,
+		func(cliParams *cliParams, _ core.BundleParams) {
+			require.Equal(t, []string{"source", "port"}, cliParams.dimensions)
+			require.True(t, cliParams.current)
+			require.True(t, cliParams.jsonOutput)
+		})
+}
+
+func TestPrintTopTalkers(t *testing.T) {
+	topTalkers := &flowaggregator.TopTalkers{
+		Start: 1700000000,
+		End:   1700000300,
+		Dimensions: map[string][]flowaggregator.Talker{
+			"source": {
+				{Namespace: "default", Key: "10.0.0.1", Bytes: 3000, Packets: 30, Flows: 3},
+				{Namespace: "default", Key: "10.0.0.2", Bytes: 1000, Packets: 10, Flows: 1},
+			},
+			"port":        {{Namespace: "default", Key: "443/tcp", Bytes: 4000, Packets: 40, Flows: 4}},
+			"application": {},
+		},
+	}
+	filterDimensions(topTalkers, []string{"source", "application", "interface"})
+
+	var b bytes.Buffer
+	require.NoError(t, printTopTalkers(&b, topTalkers))
+	assert.Equal(t, `Top talkers from 2023-11-14T22:13:20Z to 2023-11-14T22:18:20Z
+
+application
+  no flows
+
+source
+  RANK  NAMESPACE  TALKER    BYTES  PACKETS  FLOWS
+  1     default    10.0.0.1  3000   30       3
+  2     default    10.0.0.2  1000   10       1
+`, b.String())
+}
@@ -231,6 +231,10 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	snmpScanCmd.Flags().IntVarP(&connParams.Timeout, "timeout", "t", defaultTimeout, "Set the request timeout (in seconds)")
 	snmpScanCmd.Flags().BoolVar(&connParams.UseUnconnectedUDPSocket, "use-unconnected-udp-socket", defaultUseUnconnectedUDPSocket, "If specified, changes net connection to be unconnected UDP socket")
 
+	snmpCmd.AddCommand(makeTopologyCommand(globalParams))
+	snmpCmd.AddCommand(makeProfileCommand(globalParams))
+	snmpCmd.AddCommand(makeTrapsDBCommand(globalParams))
+
 	// This command does nothing until the backend supports it, so it isn't enabled yet.
 	// snmpCmd.AddCommand(snmpScanCmd)
 
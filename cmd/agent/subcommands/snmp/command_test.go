@@ -6,12 +6,16 @@
 package snmp
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/metadata"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/topology"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
 
@@ -63,3 +67,90 @@ func TestSplitIP(t *testing.T) {
 		assert.Equal(t, tc.hasPort, hasPort)
 	}
 }
+
+func TestTopologyCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"snmp", "topology", "--format", "graphml", "-o", "/tmp/topology.graphml", "--namespace", "dc1", "--max-age", "10m"},
+		exportTopology,
+		func(params *topologyParams) {
+			require.Equal(t, "graphml", params.format)
+			require.Equal(t, "/tmp/topology.graphml", params.output)
+			require.Equal(t, "dc1", params.namespace)
+			require.Equal(t, 10*time.Minute, params.maxAge)
+		})
+}
+
+func TestProfileCommands(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"snmp", "profile", "generate", "device.snmprec", "--mib", "mibs/,ACME-MIB.txt", "-o", "/tmp/device.yaml"},
+		generateProfile,
+		func(params *profileParams) {
+			require.Equal(t, []string{"device.snmprec"}, params.args)
+			require.Equal(t, []string{"mibs/", "ACME-MIB.txt"}, params.mibs)
+			require.Equal(t, "/tmp/device.yaml", params.output)
+			require.False(t, params.noKnown)
+		})
+
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"snmp", "profile", "validate", "device.yaml", "device.snmprec", "--json"},
+		validateProfile,
+		func(params *profileParams) {
+			require.Equal(t, []string{"device.yaml", "device.snmprec"}, params.args)
+			require.True(t, params.jsonOutput)
+		})
+
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"snmp", "profile", "diff", "old.yaml", "new.yaml", "device.snmprec"},
+		diffProfiles,
+		func(params *profileParams) {
+			require.Equal(t, []string{"old.yaml", "new.yaml", "device.snmprec"}, params.args)
+			require.False(t, params.jsonOutput)
+		})
+
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"snmp", "profile", "mib", "mibs/", "ACME-MIB.txt"},
+		compileMIBs,
+		func(params *profileParams) {
+			require.Equal(t, []string{"mibs/", "ACME-MIB.txt"}, params.args)
+		})
+}
+
+func TestTrapsDBCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"snmp", "trapsdb", "mibs/", "ACME-MIB.txt", "-o", "/tmp/acme.yaml"},
+		generateTrapsDB,
+		func(params *trapsDBParams) {
+			require.Equal(t, []string{"mibs/", "ACME-MIB.txt"}, params.args)
+			require.Equal(t, "/tmp/acme.yaml", params.output)
+			require.False(t, params.jsonOutput)
+		})
+}
+
+func TestLoadTopologySnapshots(t *testing.T) {
+	now := time.Now()
+	store := topology.NewStore(t.TempDir())
+	for _, deviceID := range []string{"dc1:10.0.0.1", "dc2:10.0.0.1", "dc1:10.0.0.2"} {
+		namespace := strings.SplitN(deviceID, ":", 2)[0]
+		require.NoError(t, store.Save(topology.DeviceSnapshot{
+			Namespace:        namespace,
+			Device:           metadata.DeviceMetadata{ID: deviceID},
+			CollectTimestamp: now.Unix(),
+		}))
+	}
+
+	snapshots, err := loadTopologySnapshots(store, now, time.Hour, "")
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 3)
+
+	snapshots, err = loadTopologySnapshots(store, now, time.Hour, "dc1")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "dc1:10.0.0.1", snapshots[0].Device.ID)
+	assert.Equal(t, "dc1:10.0.0.2", snapshots[1].Device.ID)
+}
@@ -0,0 +1,221 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package snmp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/profiletool"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// profileParams are the command-line arguments of the profile subcommands
+type profileParams struct {
+	args []string
+	// generate
+	mibs    []string
+	noKnown bool
+	output  string
+	// validate and diff
+	jsonOutput bool
+	verbose    bool
+}
+
+func makeProfileCommand(globalParams *command.GlobalParams) *cobra.Command {
+	profileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Author SNMP profiles offline, against recorded walks of the devices.",
+		Long: `Author SNMP profiles offline: draft a profile from a walk, check which metrics, tags and metadata of a profile
+resolve against a walk, compare the output of two versions of a profile and compile MIB files. Walks are snmprec files or
+the output of snmpwalk -On or agent snmp walk.`,
+	}
+
+	oneShot := func(params *profileParams, fn interface{}) error {
+		return fxutil.OneShot(fn,
+			fx.Supply(params),
+			fx.Supply(core.BundleParams{
+				ConfigParams: config.NewAgentParams(globalParams.ConfFilePath, config.WithExtraConfFiles(globalParams.ExtraConfFilePath), config.WithFleetPoliciesDirPath(globalParams.FleetPoliciesDirPath)),
+				SecretParams: secrets.NewDisabledParams(),
+				LogParams:    log.ForOneShot(command.LoggerName, "off", true)}),
+			core.Bundle(),
+		)
+	}
+
+	generateParams := &profileParams{}
+	generateCmd := &cobra.Command{
+		Use:   "generate <walk-file>",
+		Short: "Draft a profile for the device of a walk.",
+		Long: `Draft a profile for the device of a walk. The metrics, tags and metadata of the known profiles whose OIDs are
+in the walk are reused, then the tables and scalars of the given MIBs found in the walk are added. The draft must
+be reviewed before use.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			generateParams.args = args
+			return oneShot(generateParams, generateProfile)
+		},
+	}
+	generateCmd.Flags().StringSliceVarP(&generateParams.mibs, "mib", "m", nil, "MIB files or directories of MIB files to match the tables of the walk against")
+	generateCmd.Flags().BoolVar(&generateParams.noKnown, "no-known-profiles", false, "Don't reuse the known profiles, only use the MIBs")
+	generateCmd.Flags().StringVarP(&generateParams.output, "output", "o", "", "Write the profile to this file instead of the standard output")
+
+	validateParams := &profileParams{}
+	validateCmd := &cobra.Command{
+		Use:   "validate <profile-file> <walk-file>",
+		Short: "Show which metrics, tags and metadata of a profile resolve against a walk.",
+		Long: `Collect the device of a walk with a profile, like the SNMP check does, and show which metrics, tags and
+metadata fields of the profile resolve. The profiles extended by the profile are read from its directory, then from
+the profile directories of the agent.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			validateParams.args = args
+			return oneShot(validateParams, validateProfile)
+		},
+	}
+	validateCmd.Flags().BoolVarP(&validateParams.jsonOutput, "json", "j", false, "Print the report as JSON")
+	validateCmd.Flags().BoolVarP(&validateParams.verbose, "verbose", "v", false, "Also print the submitted metrics")
+
+	diffParams := &profileParams{}
+	diffCmd := &cobra.Command{
+		Use:   "diff <old-profile-file> <new-profile-file> <walk-file>",
+		Short: "Compare the output of two versions of a profile against a walk.",
+		Long: `Collect the device of a walk with two versions of a profile and show the metrics and device metadata that
+are added, removed or changed by the new version.`,
+		Args: cobra.ExactArgs(3),
+		RunE: func(_ *cobra.Command, args []string) error {
+			diffParams.args = args
+			return oneShot(diffParams, diffProfiles)
+		},
+	}
+	diffCmd.Flags().BoolVarP(&diffParams.jsonOutput, "json", "j", false, "Print the difference as JSON")
+
+	mibParams := &profileParams{}
+	mibCmd := &cobra.Command{
+		Use:   "mib <mib-file-or-dir>...",
+		Short: "Compile MIB files and print their objects.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			mibParams.args = args
+			return oneShot(mibParams, compileMIBs)
+		},
+	}
+	mibCmd.Flags().BoolVarP(&mibParams.jsonOutput, "json", "j", false, "Print the objects as JSON")
+
+	profileCmd.AddCommand(generateCmd, validateCmd, diffCmd, mibCmd)
+	return profileCmd
+}
+
+func generateProfile(params *profileParams, _ config.Component) error {
+	walk, err := profiletool.ReadWalkFile(params.args[0])
+	if err != nil {
+		return err
+	}
+
+	var mib *profiletool.MIB
+	if len(params.mibs) > 0 {
+		mib, err = profiletool.CompileMIBs(params.mibs...)
+		if err != nil {
+			// the objects of the valid MIBs are still used
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+		}
+	}
+	var knownProfiles map[string]profiledefinition.ProfileDefinition
+	if !params.noKnown {
+		knownProfiles, err = profiletool.LoadProfiles()
+		if err != nil {
+			return fmt.Errorf("failed to load the known profiles: %w", err)
+		}
+	}
+
+	definition := profiletool.Generate(walk, knownProfiles, mib)
+	if len(definition.Metrics) == 0 {
+		fmt.Fprintln(os.Stderr, "Warning: no metric of the known profiles or of the MIBs was found in the walk")
+	}
+	w := io.Writer(os.Stdout)
+	if params.output != "" {
+		f, err := os.Create(params.output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return profiletool.WriteProfile(w, definition)
+}
+
+func validateProfile(params *profileParams, _ config.Component) error {
+	profileFile, walkFile := params.args[0], params.args[1]
+	report, err := replayProfile(profiletool.ProfileName(profileFile), profileFile, walkFile)
+	if err != nil {
+		return err
+	}
+	if params.jsonOutput {
+		return printJSON(report)
+	}
+	return profiletool.WriteReport(os.Stdout, report, params.verbose)
+}
+
+func diffProfiles(params *profileParams, _ config.Component) error {
+	oldFile, newFile, walkFile := params.args[0], params.args[1], params.args[2]
+	// the name of the profile is one of the tags of the metrics, both versions use the same
+	name := profiletool.ProfileName(newFile)
+	oldReport, err := replayProfile(name, oldFile, walkFile)
+	if err != nil {
+		return err
+	}
+	newReport, err := replayProfile(name, newFile, walkFile)
+	if err != nil {
+		return err
+	}
+	diff := profiletool.Diff(oldReport, newReport)
+	if params.jsonOutput {
+		return printJSON(diff)
+	}
+	return profiletool.WriteDiff(os.Stdout, diff)
+}
+
+func compileMIBs(params *profileParams, _ config.Component) error {
+	mib, err := profiletool.CompileMIBs(params.args...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+	if params.jsonOutput {
+		return printJSON(mib.Objects)
+	}
+	return profiletool.WriteMIB(os.Stdout, mib)
+}
+
+func replayProfile(name string, profileFile string, walkFile string) (*profiletool.Report, error) {
+	definition, err := profiletool.ReadProfile(profileFile)
+	if err != nil {
+		return nil, err
+	}
+	walk, err := profiletool.ReadWalkFile(walkFile)
+	if err != nil {
+		return nil, err
+	}
+	return profiletool.Validate(name, definition, walk)
+}
+
+func printJSON(value interface{}) error {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(content))
+	return nil
+}
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package snmp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/topology"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+const defaultTopologyMaxAge = time.Hour
+
+// topologyParams are the command-line arguments of the topology subcommand
+type topologyParams struct {
+	format    string
+	output    string
+	namespace string
+	maxAge    time.Duration
+}
+
+func makeTopologyCommand(globalParams *command.GlobalParams) *cobra.Command {
+	params := &topologyParams{}
+	cmd := &cobra.Command{
+		Use:   "topology",
+		Short: "Export the network topology built from the LLDP/CDP neighbors of the monitored devices.",
+		Long: `Export the device/interface topology graph of the devices monitored by the SNMP check, built from their
+LLDP and CDP neighbors. Links reported by both of their ends are merged. The SNMP check saves the topology of the
+devices when both collect_topology and network_devices.topology.export.enabled are enabled.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fxutil.OneShot(exportTopology,
+				fx.Supply(params),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams(globalParams.ConfFilePath, config.WithExtraConfFiles(globalParams.ExtraConfFilePath), config.WithFleetPoliciesDirPath(globalParams.FleetPoliciesDirPath)),
+					SecretParams: secrets.NewDisabledParams(),
+					LogParams:    log.ForOneShot(command.LoggerName, "off", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+	cmd.Flags().StringVarP(&params.format, "format", "f", "json", "Output format (json, graphml)")
+	cmd.Flags().StringVarP(&params.output, "output", "o", "", "Write the topology to this file instead of the standard output")
+	cmd.Flags().StringVarP(&params.namespace, "namespace", "n", "", "Only export the devices of this namespace")
+	cmd.Flags().DurationVar(&params.maxAge, "max-age", defaultTopologyMaxAge, "Ignore the devices whose topology was collected longer ago than this duration (0 to keep all devices)")
+	return cmd
+}
+
+func exportTopology(params *topologyParams, conf config.Component) error {
+	var write func(io.Writer, topology.Graph) error
+	switch params.format {
+	case "json":
+		write = topology.WriteJSON
+	case "graphml":
+		write = topology.WriteGraphML
+	default:
+		return fmt.Errorf("unknown format %q, must be json or graphml", params.format)
+	}
+
+	dir := topology.DefaultDir(conf.GetString("run_path"))
+	snapshots, err := loadTopologySnapshots(topology.NewStore(dir), time.Now(), params.maxAge, params.namespace)
+	if err != nil {
+		// snapshots that can't be read are skipped, the topology of the other devices is still exported
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+	if len(snapshots) == 0 {
+		return fmt.Errorf("no topology found in %s: the SNMP check saves the topology of the devices when network_devices.topology.export.enabled is true", dir)
+	}
+
+	w := io.Writer(os.Stdout)
+	if params.output != "" {
+		f, err := os.Create(params.output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return write(w, topology.Build(snapshots))
+}
+
+// loadTopologySnapshots returns the snapshots of the store, only keeping the ones of namespace if it's not empty
+func loadTopologySnapshots(store *topology.Store, now time.Time, maxAge time.Duration, namespace string) ([]topology.DeviceSnapshot, error) {
+	snapshots, err := store.Load(now, maxAge)
+	if namespace == "" {
+		return snapshots, err
+	}
+	filtered := snapshots[:0]
+	for _, snapshot := range snapshots {
+		if snapshot.Namespace == namespace {
+			filtered = append(filtered, snapshot)
+		}
+	}
+	return filtered, err
+}
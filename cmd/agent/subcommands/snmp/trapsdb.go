@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package snmp
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/profiletool"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// trapsDBParams are the command-line arguments of the trapsdb subcommand
+type trapsDBParams struct {
+	args       []string
+	output     string
+	jsonOutput bool
+}
+
+func makeTrapsDBCommand(globalParams *command.GlobalParams) *cobra.Command {
+	params := &trapsDBParams{}
+	cmd := &cobra.Command{
+		Use:   "trapsdb <mib-file-or-dir>...",
+		Short: "Generate a trap database from MIB files.",
+		Long: `Generate a trap database from the notifications of MIB files, with the objects of the notifications as
+variables. Put the output in the snmp.d/traps_db folder of the configuration directory to resolve the names of the
+traps, the names of their variables and the values of their enumerations.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			params.args = args
+			return fxutil.OneShot(generateTrapsDB,
+				fx.Supply(params),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams(globalParams.ConfFilePath, config.WithExtraConfFiles(globalParams.ExtraConfFilePath), config.WithFleetPoliciesDirPath(globalParams.FleetPoliciesDirPath)),
+					SecretParams: secrets.NewDisabledParams(),
+					LogParams:    log.ForOneShot(command.LoggerName, "off", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+	cmd.Flags().StringVarP(&params.output, "output", "o", "", "Write the trap database to this file instead of the standard output")
+	cmd.Flags().BoolVarP(&params.jsonOutput, "json", "j", false, "Write the trap database as JSON instead of YAML")
+	return cmd
+}
+
+func generateTrapsDB(params *trapsDBParams, _ config.Component) error {
+	mib, err := profiletool.CompileMIBs(params.args...)
+	if err != nil {
+		// the notifications of the valid MIBs are still used
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+	content, missing := profiletool.GenerateTrapsDB(mib)
+	for _, object := range missing {
+		fmt.Fprintf(os.Stderr, "Warning: unknown notification object %s\n", object)
+	}
+	if len(content.Traps) == 0 {
+		return fmt.Errorf("no notification found in %v", params.args)
+	}
+
+	w := io.Writer(os.Stdout)
+	if params.output != "" {
+		f, err := os.Create(params.output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return profiletool.WriteTrapsDB(w, content, params.jsonOutput)
+}
@@ -127,6 +127,15 @@ func parseParams(req *http.Request) (tracerouteutil.Config, error) {
 		return tracerouteutil.Config{}, fmt.Errorf("invalid timeout: %s", err)
 	}
 	protocol := req.URL.Query().Get("protocol")
+	tcpMethod := req.URL.Query().Get("tcp_method")
+	numPaths, err := parseUint(req, "num_paths", 16)
+	if err != nil {
+		return tracerouteutil.Config{}, fmt.Errorf("invalid num_paths: %s", err)
+	}
+	probesPerHop, err := parseUint(req, "probes_per_hop", 8)
+	if err != nil {
+		return tracerouteutil.Config{}, fmt.Errorf("invalid probes_per_hop: %s", err)
+	}
 
 	return tracerouteutil.Config{
 		DestHostname: host,
@@ -134,6 +143,9 @@ func parseParams(req *http.Request) (tracerouteutil.Config, error) {
 		MaxTTL:       uint8(maxTTL),
 		TimeoutMs:    uint(timeout),
 		Protocol:     payload.Protocol(protocol),
+		TCPMethod:    payload.TCPMethod(tcpMethod),
+		NumPaths:     uint16(numPaths),
+		ProbesPerHop: uint8(probesPerHop),
 	}, nil
 }
 
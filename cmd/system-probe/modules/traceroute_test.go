@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
 	tracerouteutil "github.com/DataDog/datadog-agent/pkg/networkpath/traceroute"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
@@ -38,17 +39,33 @@ func TestParseParams(t *testing.T) {
 			name: "all config",
 			host: "1.2.3.4",
 			params: map[string]string{
-				"port":    "42",
-				"max_ttl": "35",
-				"timeout": "1000",
+				"port":           "42",
+				"max_ttl":        "35",
+				"timeout":        "1000",
+				"protocol":       "TCP",
+				"tcp_method":     "sack",
+				"num_paths":      "8",
+				"probes_per_hop": "3",
 			},
 			expectedConfig: tracerouteutil.Config{
 				DestHostname: "1.2.3.4",
 				DestPort:     42,
 				MaxTTL:       35,
 				TimeoutMs:    1000,
+				Protocol:     payload.ProtocolTCP,
+				TCPMethod:    payload.TCPMethodSACK,
+				NumPaths:     8,
+				ProbesPerHop: 3,
 			},
 		},
+		{
+			name: "invalid probes per hop",
+			host: "1.2.3.4",
+			params: map[string]string{
+				"probes_per_hop": "300",
+			},
+			expectedError: `invalid probes_per_hop: strconv.ParseUint: parsing "300": value out of range`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(_ *testing.T) {
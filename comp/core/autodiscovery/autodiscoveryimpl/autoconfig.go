@@ -97,11 +97,12 @@ type AutoConfig struct {
 type provides struct {
 	fx.Out
 
-	Comp           autodiscovery.Component
-	StatusProvider status.InformationProvider
-	Endpoint       api.AgentEndpointProvider
-	EndpointRaw    api.AgentEndpointProvider
-	FlareProvider  flaretypes.Provider
+	Comp            autodiscovery.Component
+	StatusProvider  status.InformationProvider
+	Endpoint        api.AgentEndpointProvider
+	EndpointRaw     api.AgentEndpointProvider
+	ExplainEndpoint api.AgentEndpointProvider
+	FlareProvider   flaretypes.Provider
 }
 
 // Module defines the fx options for this component.
@@ -118,8 +119,9 @@ func newProvides(deps dependencies) provides {
 		Comp:           c,
 		StatusProvider: status.NewInformationProvider(autodiscoveryStatus.GetProvider(c)),
 
-		Endpoint:      api.NewAgentEndpointProvider(c.(*AutoConfig).writeConfigCheck, "/config-check", "GET"),
-		FlareProvider: flaretypes.NewProvider(c.(*AutoConfig).fillFlare),
+		Endpoint:        api.NewAgentEndpointProvider(c.(*AutoConfig).writeConfigCheck, "/config-check", "GET"),
+		ExplainEndpoint: api.NewAgentEndpointProvider(c.(*AutoConfig).writeExplain, "/autodiscovery-explain", "POST"),
+		FlareProvider:   flaretypes.NewProvider(c.(*AutoConfig).fillFlare),
 	}
 }
 
@@ -174,6 +176,9 @@ func createNewAutoConfig(schedulerController *scheduler.Controller, secretResolv
 		logs:                     logs,
 		telemetryStore:           acTelemetry.NewStore(telemetryComp),
 	}
+	if secretResolver != nil {
+		secretResolver.SubscribeToRefresh(ac.processSecretRefresh)
+	}
 	return ac
 }
 
@@ -582,6 +587,20 @@ func (ac *AutoConfig) processRemovedConfigs(configs []integration.Config) {
 	ac.deleteMappingsOfCheckIDsWithSecrets(changes.Unschedule)
 }
 
+// processSecretRefresh reschedules the configs using secrets whose value changed
+// when the secrets were refreshed.
+func (ac *AutoConfig) processSecretRefresh(handles []string) {
+	changes, changedIDsOfSecretsWithConfigs := ac.cfgMgr.processSecretRefresh(handles)
+	if changes.IsEmpty() {
+		return
+	}
+
+	log.Infof("Secrets %v were rotated, rescheduling %d configurations", handles, len(changes.Schedule))
+	ac.applyChanges(changes)
+	ac.deleteMappingsOfCheckIDsWithSecrets(changes.Unschedule)
+	ac.store.setIDsOfChecksWithSecrets(changedIDsOfSecretsWithConfigs)
+}
+
 // MapOverLoadedConfigs calls the given function with the map of all
 // loaded configs (those that would be returned from LoadedConfigs).
 //
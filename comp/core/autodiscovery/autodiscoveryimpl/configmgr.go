@@ -46,6 +46,15 @@ type configManager interface {
 	// The call is made with the manager's lock held, so callers should perform
 	// minimal work within f.
 	mapOverLoadedConfigs(func(map[string]integration.Config))
+
+	// processSecretRefresh resolves again the configs using any of the given
+	// secret handles, rescheduling those whose resolved version changed.
+	processSecretRefresh(handles []string) (integration.ConfigChanges, map[checkid.ID]checkid.ID)
+
+	// explain explains the resolution of the candidate templates, and of the
+	// given simulated templates, for the services matching the query.  It
+	// doesn't schedule anything.
+	explain(query string, simulated []integration.Config) []integration.ServiceExplanation
 }
 
 // serviceAndADIDs bundles a service and its associated AD identifiers.
@@ -98,6 +107,11 @@ type reconcilingConfigManager struct {
 	// methods correspond exactly to changes in this map.
 	scheduledConfigs map[string]integration.Config
 
+	// nonTemplateResolutions maps the digest of a non-template config to the
+	// digest of its scheduled version, which differs when the config contains
+	// secrets.
+	nonTemplateResolutions map[string]string
+
 	secretResolver secrets.Component
 }
 
@@ -106,13 +120,14 @@ var _ configManager = &reconcilingConfigManager{}
 // newReconcilingConfigManager creates a new, empty reconcilingConfigManager.
 func newReconcilingConfigManager(secretResolver secrets.Component) configManager {
 	return &reconcilingConfigManager{
-		activeConfigs:      map[string]integration.Config{},
-		activeServices:     map[string]serviceAndADIDs{},
-		templatesByADID:    newMultimap(),
-		servicesByADID:     newMultimap(),
-		serviceResolutions: map[string]map[string]string{},
-		scheduledConfigs:   map[string]integration.Config{},
-		secretResolver:     secretResolver,
+		activeConfigs:          map[string]integration.Config{},
+		activeServices:         map[string]serviceAndADIDs{},
+		templatesByADID:        newMultimap(),
+		servicesByADID:         newMultimap(),
+		serviceResolutions:     map[string]map[string]string{},
+		scheduledConfigs:       map[string]integration.Config{},
+		nonTemplateResolutions: map[string]string{},
+		secretResolver:         secretResolver,
 	}
 }
 
@@ -227,6 +242,7 @@ func (cm *reconcilingConfigManager) processNewConfig(config integration.Config)
 			changedIDsOfSecretsWithConfigs = changedCheckIDs(config, decryptedConfig)
 		}
 
+		cm.nonTemplateResolutions[digest] = decryptedConfig.Digest()
 		changes.ScheduleConfig(decryptedConfig)
 	}
 
@@ -268,14 +284,21 @@ func (cm *reconcilingConfigManager) processDelConfigs(configs []integration.Conf
 				changes.Merge(cm.reconcileService(svcID))
 			}
 		} else {
-			// Secrets need to be resolved before being unscheduled as otherwise
-			// the computed hashes can be different from the ones computed at schedule time.
-			config, err := decryptConfig(config, cm.secretResolver)
-			if err != nil {
-				log.Errorf("Unable to resolve secrets for config '%s', check may not be unscheduled properly, err: %s", config.Name, err.Error())
+			// Unschedule the version of the config that was scheduled, its
+			// secrets may have been rotated since then.
+			scheduledConfig, found := cm.scheduledConfigs[cm.nonTemplateResolutions[digest]]
+			delete(cm.nonTemplateResolutions, digest)
+			if !found {
+				// Secrets need to be resolved before being unscheduled as otherwise
+				// the computed hashes can be different from the ones computed at schedule time.
+				var err error
+				scheduledConfig, err = decryptConfig(config, cm.secretResolver)
+				if err != nil {
+					log.Errorf("Unable to resolve secrets for config '%s', check may not be unscheduled properly, err: %s", config.Name, err.Error())
+				}
 			}
 
-			changes.UnscheduleConfig(config)
+			changes.UnscheduleConfig(scheduledConfig)
 		}
 
 		//  4. update scheduledConfigs
@@ -292,6 +315,63 @@ func (cm *reconcilingConfigManager) mapOverLoadedConfigs(f func(map[string]integ
 	f(cm.scheduledConfigs)
 }
 
+// processSecretRefresh implements configManager#processSecretRefresh.
+func (cm *reconcilingConfigManager) processSecretRefresh(handles []string) (integration.ConfigChanges, map[checkid.ID]checkid.ID) {
+	cm.m.Lock()
+	defer cm.m.Unlock()
+
+	var changes integration.ConfigChanges
+	changedIDsOfSecretsWithConfigs := make(map[checkid.ID]checkid.ID)
+
+	for digest, config := range cm.activeConfigs {
+		if !usesSecretHandles(config, handles) {
+			continue
+		}
+
+		if config.IsTemplate() {
+			// re-resolve the template for each service it was resolved for
+			for svcID, resolutions := range cm.serviceResolutions {
+				resolvedDigest, found := resolutions[digest]
+				if !found {
+					continue
+				}
+				resolved, ok := cm.resolveTemplateForService(config, cm.activeServices[svcID].svc)
+				if !ok || resolved.Digest() == resolvedDigest {
+					continue
+				}
+				changes.UnscheduleConfig(cm.scheduledConfigs[resolvedDigest])
+				changes.ScheduleConfig(resolved)
+				resolutions[digest] = resolved.Digest()
+			}
+			continue
+		}
+
+		scheduledDigest, found := cm.nonTemplateResolutions[digest]
+		if !found {
+			continue
+		}
+		decryptedConfig, err := decryptConfig(config, cm.secretResolver)
+		if err != nil {
+			// keep the config running with its previous secrets
+			log.Errorf("Unable to resolve rotated secrets for config '%s', keeping the scheduled one, err: %s", config.Name, err.Error())
+			continue
+		}
+		if decryptedConfig.Digest() == scheduledDigest {
+			continue
+		}
+		if config.Provider == names.ClusterChecks {
+			for newID, originalID := range changedCheckIDs(config, decryptedConfig) {
+				changedIDsOfSecretsWithConfigs[newID] = originalID
+			}
+		}
+		changes.UnscheduleConfig(cm.scheduledConfigs[scheduledDigest])
+		changes.ScheduleConfig(decryptedConfig)
+		cm.nonTemplateResolutions[digest] = decryptedConfig.Digest()
+	}
+
+	return cm.applyChanges(changes), changedIDsOfSecretsWithConfigs
+}
+
 // reconcileService calculates the current set of resolved templates for the
 // given service and calculates the difference from what is currently recorded
 // in cm.serviceResolutions.  It updates cm.serviceResolutions and returns the
@@ -328,6 +408,7 @@ func (cm *reconcilingConfigManager) reconcileService(svcID string) integration.C
 	// the service, in which case no resolutions are expected.
 	if svc != nil {
 		svc.FilterTemplates(expectedResolutions)
+		cm.filterTemplatesConditions(expectedResolutions, svc)
 	}
 
 	// compare existing to expected, generating changes and modifying
@@ -361,6 +442,23 @@ func (cm *reconcilingConfigManager) reconcileService(svcID string) integration.C
 	return changes
 }
 
+// filterTemplatesConditions drops the templates whose AD conditions don't match
+// the service, updating errorStats for invalid conditions.
+func (cm *reconcilingConfigManager) filterTemplatesConditions(templates map[string]integration.Config, svc listeners.Service) {
+	for digest, tpl := range templates {
+		matched, err := configresolver.MatchConditions(tpl, svc)
+		if err != nil {
+			msg := fmt.Sprintf("error matching the conditions of template %s for service %s: %v", tpl.Name, svc.GetServiceID(), err)
+			errorStats.setResolveWarning(tpl.Name, msg)
+		} else if !matched {
+			log.Debugf("Ignoring template %s from %s: the service %s doesn't match its conditions", tpl.Name, tpl.Source, svc.GetServiceID())
+		}
+		if !matched {
+			delete(templates, digest)
+		}
+	}
+}
+
 // resolveTemplateForService resolves a template config for the given service,
 // updating errorStats in the process.  If the resolution fails, this method
 // returns false.
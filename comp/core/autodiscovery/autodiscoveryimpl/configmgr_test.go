@@ -176,6 +176,84 @@ func (suite *ConfigManagerSuite) TestNewNonTemplateWithSecretsScheduled() {
 	require.True(suite.T(), strings.Contains(string(changes.Unschedule[0].Instances[0]), "barDecoded"))
 }
 
+// Configs using a rotated secret are resolved again and rescheduled
+func (suite *ConfigManagerSuite) TestSecretRefreshReschedules() {
+	mockResolver := MockSecretResolver{suite.T(), []mockSecretScenario{
+		{
+			expectedData:   []byte("foo: ENC[bar]"),
+			expectedOrigin: nonTemplateConfigWithSecrets.Name,
+			returnedData:   []byte("foo: barDecoded"),
+		},
+		{
+			expectedData:   []byte{},
+			expectedOrigin: nonTemplateConfigWithSecrets.Name,
+			returnedData:   []byte{},
+		},
+		{
+			expectedData:   []byte("password: ENC[bar]\nsource: myhost\n"),
+			expectedOrigin: "template-with-secrets",
+			returnedData:   []byte("password: barDecoded\nsource: myhost\n"),
+		},
+		{
+			expectedData:   []byte{},
+			expectedOrigin: "template-with-secrets",
+			returnedData:   []byte{},
+		},
+		{
+			expectedData:   []byte{},
+			expectedOrigin: nonTemplateConfig.Name,
+			returnedData:   []byte{},
+		},
+	}}
+	cm := suite.cm.(*reconcilingConfigManager)
+	cm.secretResolver = &mockResolver
+
+	templateWithSecrets := integration.Config{Name: "template-with-secrets", LogsConfig: []byte("source: %%host%%\npassword: ENC[bar]"), ADIdentifiers: []string{"my-service"}}
+	suite.cm.processNewConfig(deepcopy.Copy(nonTemplateConfigWithSecrets).(integration.Config))
+	suite.cm.processNewConfig(templateWithSecrets)
+	suite.cm.processNewConfig(nonTemplateConfig)
+	suite.cm.processNewService(myService.ADIdentifiers, myService)
+	assertLoadedConfigsMatch(suite.T(), suite.cm,
+		matchName(nonTemplateConfig.Name),
+		matchName(nonTemplateConfigWithSecrets.Name),
+		matchLogsConfig("password: barDecoded\nsource: myhost\n"),
+	)
+
+	// the value of the secret didn't change: nothing to do
+	changes, _ := suite.cm.processSecretRefresh([]string{"bar"})
+	assert.True(suite.T(), changes.IsEmpty())
+
+	// an unrelated secret was rotated: nothing to do
+	mockResolver.scenarios[0].returnedData = []byte("foo: rotated")
+	mockResolver.scenarios[2].returnedData = []byte("password: rotated\nsource: myhost\n")
+	changes, _ = suite.cm.processSecretRefresh([]string{"other"})
+	assert.True(suite.T(), changes.IsEmpty())
+
+	changes, _ = suite.cm.processSecretRefresh([]string{"bar"})
+	assertConfigsMatch(suite.T(), changes.Unschedule,
+		matchName(nonTemplateConfigWithSecrets.Name),
+		matchLogsConfig("password: barDecoded\nsource: myhost\n"),
+	)
+	assertConfigsMatch(suite.T(), changes.Schedule,
+		matchName(nonTemplateConfigWithSecrets.Name),
+		matchLogsConfig("password: rotated\nsource: myhost\n"),
+	)
+	assertLoadedConfigsMatch(suite.T(), suite.cm,
+		matchName(nonTemplateConfig.Name),
+		func(c integration.Config) bool { return len(c.Instances) == 1 && string(c.Instances[0]) == "foo: rotated" },
+		matchLogsConfig("password: rotated\nsource: myhost\n"),
+	)
+
+	// the rotated configs are unscheduled when removed
+	mockResolver.scenarios[0].returnedData = []byte("foo: rotated-again")
+	changes = suite.cm.processDelConfigs([]integration.Config{nonTemplateConfigWithSecrets, templateWithSecrets})
+	assertConfigsMatch(suite.T(), changes.Unschedule,
+		func(c integration.Config) bool { return len(c.Instances) == 1 && string(c.Instances[0]) == "foo: rotated" },
+		matchLogsConfig("password: rotated\nsource: myhost\n"),
+	)
+	assertLoadedConfigsMatch(suite.T(), suite.cm, matchName(nonTemplateConfig.Name))
+}
+
 func (suite *ConfigManagerSuite) TestNewClusterCheckWithSecretsScheduled() {
 	mockResolver := MockSecretResolver{suite.T(), []mockSecretScenario{
 		{
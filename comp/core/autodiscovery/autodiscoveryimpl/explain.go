@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package autodiscoveryimpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/configresolver"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/listeners"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+)
+
+// explain implements configManager#explain.
+func (cm *reconcilingConfigManager) explain(query string, simulated []integration.Config) []integration.ServiceExplanation {
+	cm.m.Lock()
+	defer cm.m.Unlock()
+
+	explanations := []integration.ServiceExplanation{}
+	for svcID, svcAndADIDs := range cm.activeServices {
+		if !serviceMatchesQuery(svcAndADIDs.svc, query) {
+			continue
+		}
+
+		explanation := integration.ServiceExplanation{
+			ServiceID:     svcID,
+			ADIdentifiers: svcAndADIDs.adIDs,
+			Templates:     []integration.TemplateExplanation{},
+		}
+
+		// the candidate templates are those sharing an AD identifier with the
+		// service, as in reconcileService
+		candidates := map[string]struct{}{}
+		for _, adID := range svcAndADIDs.adIDs {
+			for _, digest := range cm.templatesByADID.get(adID) {
+				candidates[digest] = struct{}{}
+			}
+		}
+		for digest := range candidates {
+			explanation.Templates = append(explanation.Templates, cm.explainTemplate(svcAndADIDs, cm.activeConfigs[digest], false))
+		}
+		for _, tpl := range simulated {
+			explanation.Templates = append(explanation.Templates, cm.explainTemplate(svcAndADIDs, tpl, true))
+		}
+
+		sort.SliceStable(explanation.Templates, func(i, j int) bool {
+			ti, tj := explanation.Templates[i].Template, explanation.Templates[j].Template
+			if ti.Name != tj.Name {
+				return ti.Name < tj.Name
+			}
+			return ti.Source < tj.Source
+		})
+		explanations = append(explanations, explanation)
+	}
+
+	sort.Slice(explanations, func(i, j int) bool {
+		return explanations[i].ServiceID < explanations[j].ServiceID
+	})
+	return explanations
+}
+
+// explainTemplate goes through the same steps as reconcileService for a single
+// template and service, without modifying the state of the config manager.
+//
+// This method must be called with cm.m locked.
+func (cm *reconcilingConfigManager) explainTemplate(svcAndADIDs serviceAndADIDs, tpl integration.Config, simulated bool) integration.TemplateExplanation {
+	svc := svcAndADIDs.svc
+	explanation := integration.TemplateExplanation{
+		Template:  tpl,
+		Simulated: simulated,
+	}
+
+	if !sharesADIdentifier(tpl.ADIdentifiers, svcAndADIDs.adIDs) {
+		explanation.Status = integration.TemplateADIdentifiersMismatch
+		return explanation
+	}
+
+	digest := tpl.Digest()
+	templates := map[string]integration.Config{digest: tpl}
+	svc.FilterTemplates(templates)
+	if _, found := templates[digest]; !found {
+		explanation.Status = integration.TemplateFiltered
+		return explanation
+	}
+
+	matched, err := configresolver.MatchConditions(tpl, svc)
+	if err != nil || !matched {
+		explanation.Status = integration.TemplateConditionsNotMatched
+		if err != nil {
+			explanation.Errors = []string{err.Error()}
+		}
+		return explanation
+	}
+
+	if !simulated {
+		if resolvedDigest, found := cm.serviceResolutions[svc.GetServiceID()][digest]; found {
+			resolved := cm.scheduledConfigs[resolvedDigest]
+			explanation.Status = integration.TemplateScheduled
+			explanation.Resolved = &resolved
+			return explanation
+		}
+	}
+
+	// secrets aren't decrypted: the resolution must not call the secret backend
+	resolved, err := configresolver.Resolve(tpl, svc)
+	if err != nil {
+		explanation.Status = integration.TemplateResolutionFailed
+		explanation.Errors = []string{err.Error()}
+		return explanation
+	}
+	explanation.Resolved = &resolved
+	if simulated {
+		explanation.Status = integration.TemplateResolved
+	} else {
+		explanation.Status = integration.TemplateNotScheduled
+		explanation.Errors = errorStats.getResolveWarnings()[tpl.Name]
+	}
+	return explanation
+}
+
+// sharesADIdentifier returns whether the two lists of AD identifiers have one in common
+func sharesADIdentifier(tplADIDs, svcADIDs []string) bool {
+	for _, tplADID := range tplADIDs {
+		for _, svcADID := range svcADIDs {
+			if tplADID == svcADID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// serviceMatchesQuery returns whether the service is selected by the query: its
+// service ID, the prefix of its entity ID, or the name of its container or pod.
+// All services match an empty query.
+func serviceMatchesQuery(svc listeners.Service, query string) bool {
+	if query == "" {
+		return true
+	}
+
+	svcID := svc.GetServiceID()
+	if svcID == query {
+		return true
+	}
+	if _, entityID, found := strings.Cut(svcID, "://"); found && strings.HasPrefix(entityID, query) {
+		return true
+	}
+
+	wsvc, ok := svc.(listeners.WorkloadService)
+	if !ok {
+		return false
+	}
+	switch entity := wsvc.GetEntity().(type) {
+	case *workloadmeta.Container:
+		return entity.Name == query
+	case *workloadmeta.KubernetesPod:
+		return entity.Name == query || entity.Namespace+"/"+entity.Name == query
+	}
+	return false
+}
+
+// Explain explains the resolution of the templates for the services selected
+// by the request, simulating the templates of the request.  The returned
+// configs are scrubbed.
+func (ac *AutoConfig) Explain(req integration.ExplainRequest) integration.ExplainResponse {
+	explanations := ac.cfgMgr.explain(req.Service, req.Templates)
+	for i := range explanations {
+		for j := range explanations[i].Templates {
+			tplExplanation := &explanations[i].Templates[j]
+			tplExplanation.Template = ac.scrubConfigs([]integration.Config{tplExplanation.Template})[0]
+			if tplExplanation.Resolved != nil {
+				scrubbed := ac.scrubConfigs([]integration.Config{*tplExplanation.Resolved})[0]
+				tplExplanation.Resolved = &scrubbed
+			}
+		}
+	}
+	return integration.ExplainResponse{Services: explanations}
+}
+
+func (ac *AutoConfig) writeExplain(w http.ResponseWriter, r *http.Request) {
+	var req integration.ExplainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.SetJSONError(w, fmt.Errorf("invalid explain request: %w", err), 400)
+		return
+	}
+
+	for _, tpl := range req.Templates {
+		if !tpl.IsTemplate() {
+			httputils.SetJSONError(w, fmt.Errorf("the %s config from %s is not a template: it doesn't have any AD identifier", tpl.Name, tpl.Source), 400)
+			return
+		}
+	}
+
+	jsonExplain, err := json.Marshal(ac.Explain(req))
+	if err != nil {
+		httputils.SetJSONError(w, err, 500)
+		return
+	}
+
+	w.Write(jsonExplain)
+}
@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package autodiscoveryimpl
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+)
+
+func TestExplain(t *testing.T) {
+	cm := newReconcilingConfigManager(&MockSecretResolver{})
+
+	redisSvc := &dummyService{ID: "docker://abcdef", ADIdentifiers: []string{"redis"}, Hosts: map[string]string{"bridge": "10.0.0.2"}}
+	redisSvc.filterTemplates = func(configs map[string]integration.Config) {
+		for digest, config := range configs {
+			if config.Name == "overridden" {
+				delete(configs, digest)
+			}
+		}
+	}
+	nginxSvc := &dummyService{ID: "docker://123456", ADIdentifiers: []string{"nginx"}}
+	cm.processNewService(redisSvc.ADIdentifiers, redisSvc)
+	cm.processNewService(nginxSvc.ADIdentifiers, nginxSvc)
+
+	cm.processNewConfig(integration.Config{Name: "redisdb", ADIdentifiers: []string{"redis"}, Instances: []integration.Data{integration.Data("host: '%%host%%'")}})
+	cm.processNewConfig(integration.Config{Name: "overridden", ADIdentifiers: []string{"redis"}})
+	cm.processNewConfig(integration.Config{Name: "conditional", ADIdentifiers: []string{"redis"}, ADConditions: []string{"%%host%% == 10.0.0.3"}})
+	cm.processNewConfig(integration.Config{Name: "no-port", ADIdentifiers: []string{"redis"}, Instances: []integration.Data{integration.Data("port: %%port%%")}})
+	cm.processNewConfig(integration.Config{Name: "nginx", ADIdentifiers: []string{"nginx"}})
+
+	simulated := []integration.Config{
+		{Name: "sim", ADIdentifiers: []string{"redis"}, Instances: []integration.Data{integration.Data("url: 'http://%%host%%'")}},
+		{Name: "sim-nginx", ADIdentifiers: []string{"nginx"}},
+	}
+	explanations := cm.explain("abc", simulated)
+	require.Len(t, explanations, 1)
+	assert.Equal(t, "docker://abcdef", explanations[0].ServiceID)
+	assert.Equal(t, []string{"redis"}, explanations[0].ADIdentifiers)
+
+	statuses := map[string]integration.TemplateStatus{}
+	for _, tpl := range explanations[0].Templates {
+		statuses[tpl.Template.Name] = tpl.Status
+		switch tpl.Template.Name {
+		case "redisdb":
+			require.NotNil(t, tpl.Resolved)
+			assert.Equal(t, "host: 10.0.0.2\n", string(tpl.Resolved.Instances[0]))
+		case "no-port":
+			assert.Equal(t, []string{"no port found for container docker://abcdef - ignoring it"}, tpl.Errors)
+		case "sim":
+			assert.True(t, tpl.Simulated)
+			require.NotNil(t, tpl.Resolved)
+			assert.Equal(t, "url: http://10.0.0.2\n", string(tpl.Resolved.Instances[0]))
+		}
+	}
+	assert.Equal(t, map[string]integration.TemplateStatus{
+		"conditional": integration.TemplateConditionsNotMatched,
+		"no-port":     integration.TemplateResolutionFailed,
+		"overridden":  integration.TemplateFiltered,
+		"redisdb":     integration.TemplateScheduled,
+		"sim":         integration.TemplateResolved,
+		"sim-nginx":   integration.TemplateADIdentifiersMismatch,
+	}, statuses)
+
+	// explaining doesn't schedule the simulated templates
+	assertLoadedConfigsMatch(t, cm, matchName("redisdb"), matchName("nginx"))
+
+	assert.Len(t, cm.explain("", nil), 2)
+	assert.Empty(t, cm.explain("docker://abc", nil))
+}
+
+func TestWriteExplain(t *testing.T) {
+	ac := &AutoConfig{cfgMgr: newReconcilingConfigManager(&MockSecretResolver{})}
+	svc := &dummyService{ID: "docker://abcdef", ADIdentifiers: []string{"redis"}}
+	ac.cfgMgr.processNewService(svc.ADIdentifiers, svc)
+
+	body, err := json.Marshal(integration.ExplainRequest{
+		Service:   "docker://abcdef",
+		Templates: []integration.Config{{Name: "redisdb", ADIdentifiers: []string{"redis"}, Instances: []integration.Data{integration.Data("password: hunter2")}}},
+	})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	ac.writeExplain(w, httptest.NewRequest(http.MethodPost, "/autodiscovery-explain", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp integration.ExplainResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Services, 1)
+	require.Len(t, resp.Services[0].Templates, 1)
+	explanation := resp.Services[0].Templates[0]
+	assert.Equal(t, integration.TemplateResolved, explanation.Status)
+	assert.Equal(t, `password: "********"`, string(explanation.Resolved.Instances[0]))
+	assert.Equal(t, `password: "********"`, string(explanation.Template.Instances[0]))
+
+	body, err = json.Marshal(integration.ExplainRequest{Templates: []integration.Config{{Name: "redisdb", Source: "redisdb.yaml"}}})
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	ac.writeExplain(w, httptest.NewRequest(http.MethodPost, "/autodiscovery-explain", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "the redisdb config from redisdb.yaml is not a template")
+}
@@ -6,6 +6,7 @@
 package autodiscoveryimpl
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
@@ -54,3 +55,23 @@ func decryptConfig(conf integration.Config, secretResolver secrets.Component) (i
 
 	return conf, nil
 }
+
+// usesSecretHandles returns whether the config contains any of the given
+// secret handles, in the `ENC[handle]` format.
+func usesSecretHandles(conf integration.Config, handles []string) bool {
+	data := make([][]byte, 0, len(conf.Instances)+3)
+	data = append(data, conf.InitConfig, conf.MetricConfig, conf.LogsConfig)
+	for _, instance := range conf.Instances {
+		data = append(data, instance)
+	}
+
+	for _, handle := range handles {
+		encoded := []byte("ENC[" + handle + "]")
+		for _, d := range data {
+			if bytes.Contains(d, encoded) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -55,6 +55,9 @@ func (m *MockSecretResolver) Resolve(data []byte, origin string) ([]byte, error)
 func (m *MockSecretResolver) SubscribeToChanges(_ secrets.SecretChangeCallback) {
 }
 
+func (m *MockSecretResolver) SubscribeToRefresh(_ secrets.SecretRefreshCallback) {
+}
+
 func (m *MockSecretResolver) Refresh() (string, error) {
 	return "", nil
 }
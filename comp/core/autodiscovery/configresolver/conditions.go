@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package configresolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/listeners"
+	"github.com/DataDog/datadog-agent/pkg/util/tmplvar"
+)
+
+// conditionPattern matches the `%%variable%% <operator> <value>` conditions, the
+// operator and the value being optional.
+var conditionPattern = regexp.MustCompile(`^\s*%%(.+?)%%\s*(?:(==|!=|=~|!~)\s*(.*?))?\s*$`)
+
+// condition is a parsed AD condition
+type condition struct {
+	expr     tmplvar.Expression
+	name     string
+	key      string
+	operator string
+	expected string
+	re       *regexp.Regexp
+}
+
+// parsedConditions caches the conditions parsed by ValidateConditions, so that they are
+// parsed once per template and not on every evaluation
+var parsedConditions sync.Map // string -> *condition
+
+// ValidateConditions parses the AD conditions of a template, it returns an error if one
+// of them is invalid. Providers call it when loading templates, to reject invalid ones.
+func ValidateConditions(conditions []string) error {
+	for _, c := range conditions {
+		if _, err := getCondition(c); err != nil {
+			return fmt.Errorf("invalid AD condition %q: %w", c, err)
+		}
+	}
+	return nil
+}
+
+// MatchConditions returns whether the service matches all the AD conditions of the
+// template. A condition is a template variable, optionally with filters, compared to
+// a value:
+//   - `%%label_team%%` matches when the variable is resolved to a non-empty value
+//   - `%%label_app%% == web` and `%%label_app%% != web` compare it to a value
+//   - `%%annotation_tier%% =~ ^(gold|silver)$` and `!~` match it against a regular expression
+//
+// Variables that can't be resolved are compared as empty values.
+func MatchConditions(tpl integration.Config, svc listeners.Service) (bool, error) {
+	for _, c := range tpl.ADConditions {
+		cond, err := getCondition(c)
+		if err != nil {
+			return false, fmt.Errorf("invalid AD condition %q: %w", c, err)
+		}
+		matched, err := cond.match(context.TODO(), svc)
+		if err != nil {
+			return false, fmt.Errorf("invalid AD condition %q: %w", c, err)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func getCondition(c string) (*condition, error) {
+	if cond, ok := parsedConditions.Load(c); ok {
+		return cond.(*condition), nil
+	}
+	cond, err := parseCondition(c)
+	if err != nil {
+		return nil, err
+	}
+	parsedConditions.Store(c, cond)
+	return cond, nil
+}
+
+func parseCondition(c string) (*condition, error) {
+	match := conditionPattern.FindStringSubmatch(c)
+	if match == nil {
+		return nil, errors.New("conditions must have the `%%variable%% <operator> <value>` format")
+	}
+	cond := &condition{operator: match[2], expected: unquote(match[3])}
+
+	expr, err := tmplvar.ParseExpression(match[1])
+	if err != nil {
+		return nil, err
+	}
+	name, key, found := splitVariable(expr.Variable, templateVariables)
+	if !found {
+		return nil, fmt.Errorf("unknown variable %q", expr.Variable)
+	}
+	cond.expr, cond.name, cond.key = expr, name, key
+
+	if cond.operator == "=~" || cond.operator == "!~" {
+		if cond.re, err = regexp.Compile(cond.expected); err != nil {
+			return nil, err
+		}
+	}
+	return cond, nil
+}
+
+func (c *condition) match(ctx context.Context, svc listeners.Service) (bool, error) {
+	value, err := templateVariables[c.name](ctx, c.key, svc)
+	var noServiceErr *NoServiceError
+	if errors.As(err, &noServiceErr) {
+		return false, err
+	}
+	if value, err = c.expr.Apply(value, err); err != nil {
+		value = ""
+	}
+
+	switch c.operator {
+	case "":
+		return value != "", nil
+	case "==":
+		return value == c.expected, nil
+	case "!=":
+		return value != c.expected, nil
+	}
+	return c.re.MatchString(value) == (c.operator == "=~"), nil
+}
+
+// unquote removes the quotes surrounding a value, if any
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
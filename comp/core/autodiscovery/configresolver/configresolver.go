@@ -25,6 +25,7 @@ import (
 	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/tmplvar"
 
 	yaml "gopkg.in/yaml.v2"
 )
@@ -32,13 +33,17 @@ import (
 type variableGetter func(ctx context.Context, key string, svc listeners.Service) (string, error)
 
 var templateVariables = map[string]variableGetter{
-	"host":     getHost,
-	"pid":      getPid,
-	"port":     getPort,
-	"hostname": getHostname,
-	"env":      getEnvvar,
-	"extra":    getAdditionalTplVariables,
-	"kube":     getAdditionalTplVariables,
+	"host":          getHost,
+	"pid":           getPid,
+	"port":          getPort,
+	"hostname":      getHostname,
+	"env":           getEnvvar,
+	"extra":         getAdditionalTplVariables,
+	"kube":          getAdditionalTplVariables,
+	"label":         getLabel,
+	"annotation":    getAnnotation,
+	"container_env": getContainerEnvvar,
+	"entity":        getEntityField,
 }
 
 // NoServiceError represents an error that indicates that there's a problem with a service
@@ -377,10 +382,28 @@ func resolveStringWithTemplateVars(ctx context.Context, in string, svc listeners
 	return resolvedStringWithIPv6, err
 }
 
-var varPattern = regexp.MustCompile(`‰(.+?)(?:_(.+?))?‰`)
+var varPattern = regexp.MustCompile(`‰(.+?)‰`)
+
+// splitVariable splits a template variable in its name and its key, the name
+// being the longest variable getter name the variable starts with, so that
+// names can contain underscores like `container_env_PATH`.
+func splitVariable(variable string, templateVariables map[string]variableGetter) (name, key string, found bool) {
+	for candidate := range templateVariables {
+		if len(candidate) <= len(name) {
+			continue
+		}
+		if variable == candidate {
+			name, key, found = candidate, "", true
+		} else if strings.HasPrefix(variable, candidate+"_") {
+			name, key, found = candidate, variable[len(candidate)+1:], true
+		}
+	}
+	return name, key, found
+}
 
 // resolveStringWithAdHocTemplateVars takes a string as input and replaces all the `‰var_param‰` patterns by the value returned by the appropriate variable getter.
 // The variable getters are passed as last parameter.
+// Variables can be followed by filters transforming their value, like `‰label_app|default:web|upper‰`.
 // If the input string is composed of *only* a `‰var_param‰` pattern and the result of the substitution is a boolean or a number, then the function returns a boolean or a number instead of a string.
 func resolveStringWithAdHocTemplateVars(ctx context.Context, in string, svc listeners.Service, templateVariables map[string]variableGetter) (out interface{}, err error) {
 	varIndexes := varPattern.FindAllStringSubmatchIndex(in, -1)
@@ -397,29 +420,31 @@ func resolveStringWithAdHocTemplateVars(ctx context.Context, in string, svc list
 			sb.WriteString(in[varIndexes[i-1][1]:varIndexes[i][0]])
 		}
 
-		varName := in[varIndexes[i][2]:varIndexes[i][3]]
-		varKey := ""
-		if varIndexes[i][4] != -1 {
-			varKey = in[varIndexes[i][4]:varIndexes[i][5]]
+		raw := in[varIndexes[i][2]:varIndexes[i][3]]
+		expr, e := tmplvar.ParseExpression(raw)
+		if e != nil {
+			return out, invalidTagError(svc, fmt.Errorf("invalid %%%%%s%%%% tag: %w", raw, e))
+		}
+		varName, varKey, found := splitVariable(expr.Variable, templateVariables)
+		if !found {
+			return out, invalidTagError(svc, fmt.Errorf("invalid %%%%%s%%%% tag", expr.Variable))
 		}
 
-		if f, found := templateVariables[varName]; found {
-			resolvedVar, e := f(ctx, varKey, svc)
-			if e != nil {
-				err = e
-			}
-			sb.WriteString(resolvedVar)
-		} else {
-			endTagIdx := varIndexes[i][5]
-			if endTagIdx == -1 {
-				endTagIdx = varIndexes[i][3]
-			}
-			err := fmt.Errorf("invalid %%%%%s%%%% tag", in[varIndexes[i][2]:endTagIdx])
-			if svc != nil {
-				err = fmt.Errorf("unable to add tags for service '%s', err: %w", svc.GetServiceID(), err)
-			}
-			return out, err
+		resolvedVar, e := templateVariables[varName](ctx, varKey, svc)
+		var noServiceErr *NoServiceError
+		switch {
+		case errors.As(e, &noServiceErr):
+			// without service, the default filter must not replace the variables of templates
+		case strings.Contains(resolvedVar, "‰"):
+			// placeholder of the IPv6 processing, the filters are applied when it's resolved
+			resolvedVar = in[varIndexes[i][0]:varIndexes[i][1]]
+		default:
+			resolvedVar, e = expr.Apply(resolvedVar, e)
+		}
+		if e != nil {
+			err = e
 		}
+		sb.WriteString(resolvedVar)
 	}
 	sb.WriteString(in[varIndexes[len(varIndexes)-1][1]:])
 
@@ -440,6 +465,13 @@ func resolveStringWithAdHocTemplateVars(ctx context.Context, in string, svc list
 	return
 }
 
+func invalidTagError(svc listeners.Service, err error) error {
+	if svc != nil {
+		return fmt.Errorf("unable to add tags for service '%s', err: %w", svc.GetServiceID(), err)
+	}
+	return err
+}
+
 func tagsAdder(tags []string) func(interface{}) error {
 	return func(tree interface{}) error {
 		if len(tags) == 0 {
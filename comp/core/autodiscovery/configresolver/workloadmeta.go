@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package configresolver
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/listeners"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
+)
+
+// workloadService returns the service as a listeners.WorkloadService, if it's backed by a workloadmeta entity
+func workloadService(svc listeners.Service, tplVar string) (listeners.WorkloadService, error) {
+	if svc == nil {
+		return nil, NewNoServiceError(fmt.Sprintf("No service. %%%%%s_*%%%% is not allowed", tplVar))
+	}
+	wsvc, ok := svc.(listeners.WorkloadService)
+	if !ok || wsvc.GetEntity() == nil {
+		return nil, fmt.Errorf("%%%%%s_*%%%% is not supported for service %s", tplVar, svc.GetServiceID())
+	}
+	return wsvc, nil
+}
+
+// getLabel returns a label of the service's container, falling back on the labels of its pod
+func getLabel(_ context.Context, name string, svc listeners.Service) (string, error) {
+	wsvc, err := workloadService(svc, "label")
+	if err != nil {
+		return "", err
+	}
+
+	if container, ok := wsvc.GetEntity().(*workloadmeta.Container); ok {
+		if value, found := container.Labels[name]; found {
+			return value, nil
+		}
+	}
+	if pod := wsvc.GetPod(); pod != nil {
+		if value, found := pod.Labels[name]; found {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("label %q not found for service %s", name, svc.GetServiceID())
+}
+
+// getAnnotation returns an annotation of the service's pod
+func getAnnotation(_ context.Context, name string, svc listeners.Service) (string, error) {
+	wsvc, err := workloadService(svc, "annotation")
+	if err != nil {
+		return "", err
+	}
+
+	if pod := wsvc.GetPod(); pod != nil {
+		if value, found := pod.Annotations[name]; found {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("annotation %q not found for service %s", name, svc.GetServiceID())
+}
+
+// getContainerEnvvar returns an environment variable of the service's container.
+// Only the variables collected by workloadmeta are available.
+func getContainerEnvvar(_ context.Context, name string, svc listeners.Service) (string, error) {
+	wsvc, err := workloadService(svc, "container_env")
+	if err != nil {
+		return "", err
+	}
+
+	if container, ok := wsvc.GetEntity().(*workloadmeta.Container); ok {
+		if value, found := container.EnvVars[name]; found {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("container envvar %q not found for service %s", name, svc.GetServiceID())
+}
+
+// getEntityField returns a field of the service's workloadmeta entity. The path is made of
+// the snake_case names of the fields, map keys and slice indexes, separated by dots, for
+// example `image.short_name` or `owners.0.name`.
+func getEntityField(_ context.Context, path string, svc listeners.Service) (string, error) {
+	wsvc, err := workloadService(svc, "entity")
+	if err != nil {
+		return "", err
+	}
+
+	value, err := lookupField(reflect.ValueOf(wsvc.GetEntity()), path)
+	if err != nil {
+		return "", fmt.Errorf("entity field %q not found for service %s: %s", path, svc.GetServiceID(), err)
+	}
+	return value, nil
+}
+
+// lookupField walks the value along the dot separated path and formats the scalar value found
+func lookupField(v reflect.Value, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("missing field name")
+	}
+
+	for _, part := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return "", fmt.Errorf("%q is not set", part)
+			}
+			v = v.Elem()
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			field, found := v.Type().FieldByNameFunc(func(name string) bool { return toSnakeCase(name) == part })
+			if !found || !field.IsExported() {
+				return "", fmt.Errorf("unknown field %q", part)
+			}
+			v = v.FieldByIndex(field.Index)
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return "", fmt.Errorf("unsupported map key type for %q", part)
+			}
+			v = v.MapIndex(reflect.ValueOf(part).Convert(v.Type().Key()))
+			if !v.IsValid() {
+				return "", fmt.Errorf("unknown key %q", part)
+			}
+		case reflect.Slice, reflect.Array:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= v.Len() {
+				return "", fmt.Errorf("invalid index %q", part)
+			}
+			v = v.Index(idx)
+		default:
+			return "", fmt.Errorf("%q is not a map, a list or an object", part)
+		}
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return stringer.String(), nil
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return "", fmt.Errorf("%q is not a scalar value", path)
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
+
+// toSnakeCase converts a Go field name to snake case, keeping acronyms together
+// (ShortName -> short_name, PID -> pid, IPAddress -> ip_address, NetworkIPs -> network_ips)
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			// the plural of an acronym isn't the start of a new word
+			plural := i+1 < len(runes) && runes[i+1] == 's' && (i+2 == len(runes) || unicode.IsUpper(runes[i+2]))
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1]) && !plural
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
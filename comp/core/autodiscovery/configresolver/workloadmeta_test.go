@@ -0,0 +1,189 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package configresolver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/listeners"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
+)
+
+type dummyWorkloadService struct {
+	*dummyService
+	entity workloadmeta.Entity
+	pod    *workloadmeta.KubernetesPod
+}
+
+func (s *dummyWorkloadService) GetEntity() workloadmeta.Entity {
+	return s.entity
+}
+
+func (s *dummyWorkloadService) GetPod() *workloadmeta.KubernetesPod {
+	return s.pod
+}
+
+func newDummyWorkloadService(hosts map[string]string) *dummyWorkloadService {
+	pod := &workloadmeta.KubernetesPod{
+		EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindKubernetesPod, ID: "pod-uid"},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name:        "redis-0",
+			Namespace:   "cache",
+			Labels:      map[string]string{"app": "redis", "team": "storage"},
+			Annotations: map[string]string{"example.com/endpoints": "redis-0.svc,redis-1.svc"},
+		},
+		Owners: []workloadmeta.KubernetesPodOwner{{Kind: "StatefulSet", Name: "redis"}},
+	}
+	container := &workloadmeta.Container{
+		EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "abc"},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name:   "redis",
+			Labels: map[string]string{"app": "redis-server", "tier": "Gold"},
+		},
+		EnvVars: map[string]string{"REDIS_PORT": "6380"},
+		Image:   workloadmeta.ContainerImage{ShortName: "redis", Tag: "7.2"},
+		PID:     42,
+	}
+	return &dummyWorkloadService{
+		dummyService: &dummyService{ID: "docker://abc", ADIdentifiers: []string{"redis"}, Hosts: hosts},
+		entity:       container,
+		pod:          pod,
+	}
+}
+
+func TestResolveWorkloadTemplateVariables(t *testing.T) {
+	svc := newDummyWorkloadService(map[string]string{"pod": "10.0.0.1"})
+
+	for _, tc := range []struct {
+		instance string
+		expected string
+		err      string
+	}{
+		{instance: "app: %%label_app%%", expected: "app: redis-server\n"},
+		{instance: "team: %%label_team%%", expected: "team: storage\n"},
+		{instance: "tier: %%label_tier|lower%%", expected: "tier: gold\n"},
+		{instance: "env: %%label_env|default:prod%%", expected: "env: prod\n"},
+		{instance: "endpoint: %%annotation_example.com/endpoints|index:1%%", expected: "endpoint: redis-1.svc\n"},
+		{instance: "port: %%container_env_REDIS_PORT%%", expected: "port: 6380\n"},
+		{instance: "url: redis://%%host%%:%%container_env_REDIS_PASSWORD|default:6379%%", expected: "url: redis://10.0.0.1:6379\n"},
+		{instance: "image: %%entity_image.short_name%%:%%entity_image.tag%%", expected: "image: redis:7.2\n"},
+		{instance: "pid: %%entity_pid%%", expected: "pid: 42\n"},
+		{instance: "name: %%entity_name|replace:redis:cache%%", expected: "name: cache\n"},
+		{instance: "kind: %%entity_kind%%", expected: "kind: container\n"},
+		{instance: "app: %%label_missing%%", err: `label "missing" not found for service docker://abc`},
+		{instance: "x: %%entity_image.nope%%", err: `entity field "image.nope" not found for service docker://abc: unknown field "nope"`},
+		{instance: "x: %%entity_image%%", err: `entity field "image" not found for service docker://abc: "image" is not a scalar value`},
+		{instance: "x: %%label_app|unknown%%", err: `unable to add tags for service 'docker://abc', err: invalid %%label_app|unknown%% tag: unknown filter "unknown" in "label_app|unknown"`},
+	} {
+		tpl := integration.Config{
+			Name:                    "redisdb",
+			ADIdentifiers:           []string{"redis"},
+			Instances:               []integration.Data{integration.Data(tc.instance)},
+			IgnoreAutodiscoveryTags: true,
+		}
+		resolved, err := Resolve(tpl, svc)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, tc.instance)
+			continue
+		}
+		require.NoError(t, err, tc.instance)
+		assert.Equal(t, tc.expected, string(resolved.Instances[0]), tc.instance)
+	}
+
+	// services not backed by a workloadmeta entity don't have labels
+	_, err := Resolve(integration.Config{Name: "redisdb", Instances: []integration.Data{integration.Data("app: %%label_app|default:x%%")}}, svc.dummyService)
+	assert.NoError(t, err)
+	_, err = Resolve(integration.Config{Name: "redisdb", Instances: []integration.Data{integration.Data("app: %%label_app%%")}}, svc.dummyService)
+	assert.EqualError(t, err, "%%label_*%% is not supported for service docker://abc")
+}
+
+func TestResolveIPv6HostWithFilters(t *testing.T) {
+	svc := newDummyWorkloadService(map[string]string{"pod": "fd00::1"})
+	tpl := integration.Config{
+		Name:                    "redisdb",
+		Instances:               []integration.Data{integration.Data("url: http://%%host|upper%%:%%container_env_REDIS_PORT%%/")},
+		IgnoreAutodiscoveryTags: true,
+	}
+	resolved, err := Resolve(tpl, svc)
+	require.NoError(t, err)
+	assert.Equal(t, "url: http://[FD00::1]:6380/\n", string(resolved.Instances[0]))
+}
+
+func TestSubstituteTemplateEnvVarsKeepsWorkloadVariables(t *testing.T) {
+	t.Setenv("REDIS_HOST", "redis.local")
+	conf := integration.Config{
+		Name: "redisdb",
+		Instances: []integration.Data{
+			integration.Data("app: \"%%label_app|default:x%%\"\n"),
+			integration.Data("host: %%env_REDIS_HOST%%\nport: %%env_REDIS_PORT|default:6379%%\n"),
+		},
+	}
+	err := SubstituteTemplateEnvVars(&conf)
+	assert.IsType(t, &NoServiceError{}, err)
+	assert.Equal(t, "app: \"%%label_app|default:x%%\"\n", string(conf.Instances[0]))
+
+	conf.Instances = conf.Instances[1:]
+	require.NoError(t, SubstituteTemplateEnvVars(&conf))
+	assert.Equal(t, "host: redis.local\nport: 6379\n", string(conf.Instances[0]))
+}
+
+func TestMatchConditions(t *testing.T) {
+	var svc listeners.Service = newDummyWorkloadService(map[string]string{"pod": "10.0.0.1"})
+
+	for _, tc := range []struct {
+		conditions []string
+		matched    bool
+		err        string
+	}{
+		{conditions: nil, matched: true},
+		{conditions: []string{"%%label_app%% == redis-server"}, matched: true},
+		{conditions: []string{"%%label_app%% == 'redis-server'", "%%label_team%%"}, matched: true},
+		{conditions: []string{"%%label_app%% == redis-server", "%%label_missing%%"}, matched: false},
+		{conditions: []string{"%%label_tier|lower%% == gold"}, matched: true},
+		{conditions: []string{"%%label_env%% != prod"}, matched: true},
+		{conditions: []string{"%%annotation_example.com/endpoints%% =~ ^redis-[0-9]\\.svc"}, matched: true},
+		{conditions: []string{"%%entity_name%% !~ ^redis$"}, matched: false},
+		{conditions: []string{"%%kube_namespace%% == cache"}, matched: false},
+		{conditions: []string{"label_app == redis"}, err: "invalid AD condition \"label_app == redis\": conditions must have the `%%variable%% <operator> <value>` format"},
+		{conditions: []string{"%%unknown_app%%"}, err: `invalid AD condition "%%unknown_app%%": unknown variable "unknown_app"`},
+		{conditions: []string{"%%label_app%% =~ ("}, err: "invalid AD condition \"%%label_app%% =~ (\": error parsing regexp: missing closing ): `(`"},
+	} {
+		matched, err := MatchConditions(integration.Config{ADConditions: tc.conditions}, svc)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err)
+			continue
+		}
+		require.NoError(t, err, tc.conditions)
+		assert.Equal(t, tc.matched, matched, tc.conditions)
+	}
+}
+
+func TestValidateConditions(t *testing.T) {
+	assert.NoError(t, ValidateConditions(nil))
+	assert.NoError(t, ValidateConditions([]string{"%%label_app%% == redis", "%%annotation_tier%% =~ ^(gold|silver)$"}))
+	assert.EqualError(t, ValidateConditions([]string{"%%label_app%%", "%%label_app|unknown%%"}),
+		`invalid AD condition "%%label_app|unknown%%": unknown filter "unknown" in "label_app|unknown"`)
+	assert.EqualError(t, ValidateConditions([]string{"%%label_app%% !~ [a-"}),
+		"invalid AD condition \"%%label_app%% !~ [a-\": error parsing regexp: missing closing ]: `[a-`")
+}
+
+func TestToSnakeCase(t *testing.T) {
+	for in, expected := range map[string]string{
+		"Name":        "name",
+		"ShortName":   "short_name",
+		"PID":         "pid",
+		"IPAddress":   "ip_address",
+		"NetworkIPs":  "network_ips",
+		"EnvVars":     "env_vars",
+		"CgroupPath2": "cgroup_path2",
+	} {
+		assert.Equal(t, expected, toSnakeCase(in), in)
+	}
+}
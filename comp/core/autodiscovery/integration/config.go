@@ -73,6 +73,10 @@ type Config struct {
 	// see ADIdentifiers.  (optional)
 	AdvancedADIdentifiers []AdvancedADIdentifier `json:"advanced_ad_identifiers"` // (include in digest: false)
 
+	// ADConditions is the list of conditions a service must match for this
+	// template to be resolved against it, like `%%label_app%% == web`. (optional)
+	ADConditions []string `json:"ad_conditions,omitempty"` // (include in digest: true)
+
 	// Provider is the name of the config provider that issued the config.  If
 	// this is "", then the config is a service config, representing a service
 	// discovered by a listener.
@@ -411,6 +415,9 @@ func (c *Config) IntDigest() uint64 {
 	for _, i := range c.ADIdentifiers {
 		_, _ = h.Write([]byte(i))
 	}
+	for _, i := range c.ADConditions {
+		_, _ = h.Write([]byte(i))
+	}
 	_, _ = h.Write([]byte(c.NodeName))
 	_, _ = h.Write([]byte(c.LogsConfig))
 	_, _ = h.Write([]byte(c.ServiceID))
@@ -434,6 +441,9 @@ func (c *Config) FastDigest() uint64 {
 	for _, i := range c.ADIdentifiers {
 		_, _ = h.Write([]byte(i))
 	}
+	for _, i := range c.ADConditions {
+		_, _ = h.Write([]byte(i))
+	}
 	_, _ = h.Write([]byte(c.NodeName))
 	_, _ = h.Write([]byte(c.LogsConfig))
 	_, _ = h.Write([]byte(c.ServiceID))
@@ -475,6 +485,7 @@ func (c *Config) Dump(multiline bool) string {
 	fmt.Fprintf(&b, ws("LogsConfig: %s,"), dataField(c.LogsConfig))
 	fmt.Fprintf(&b, ws("ADIdentifiers: %#v,"), c.ADIdentifiers)
 	fmt.Fprintf(&b, ws("AdvancedADIdentifiers: %#v,"), c.AdvancedADIdentifiers)
+	fmt.Fprintf(&b, ws("ADConditions: %#v,"), c.ADConditions)
 	fmt.Fprintf(&b, ws("Provider: %#v,"), c.Provider)
 	fmt.Fprintf(&b, ws("ServiceID: %#v,"), c.ServiceID)
 	fmt.Fprintf(&b, ws("TaggerEntity: %#v,"), c.TaggerEntity)
@@ -12,3 +12,56 @@ type ConfigCheckResponse struct {
 	ConfigErrors    map[string]string   `json:"config_errors"`
 	Unresolved      map[string][]Config `json:"unresolved"`
 }
+
+// ExplainRequest holds the autodiscovery explain request
+type ExplainRequest struct {
+	// Service selects the services to explain: a service ID, a container ID
+	// or ID prefix, or a container or pod name. All services are explained
+	// when it's empty.
+	Service string `json:"service"`
+	// Templates are simulated against the services without being scheduled
+	Templates []Config `json:"templates"`
+}
+
+// ExplainResponse holds the autodiscovery explain response
+type ExplainResponse struct {
+	Services []ServiceExplanation `json:"services"`
+}
+
+// ServiceExplanation explains the resolution of the candidate templates of a service
+type ServiceExplanation struct {
+	ServiceID     string                `json:"service_id"`
+	ADIdentifiers []string              `json:"ad_identifiers"`
+	Templates     []TemplateExplanation `json:"templates"`
+}
+
+// TemplateStatus is the outcome of the resolution of a template for a service
+type TemplateStatus string
+
+const (
+	// TemplateScheduled means the template was resolved and scheduled for the service
+	TemplateScheduled TemplateStatus = "scheduled"
+	// TemplateResolved means the simulated template would be scheduled for the service
+	TemplateResolved TemplateStatus = "resolved"
+	// TemplateNotScheduled means the template can be resolved but wasn't scheduled,
+	// usually because its secrets couldn't be decrypted
+	TemplateNotScheduled TemplateStatus = "not_scheduled"
+	// TemplateADIdentifiersMismatch means the template doesn't share any AD identifier with the service
+	TemplateADIdentifiersMismatch TemplateStatus = "ad_identifiers_mismatch"
+	// TemplateFiltered means the template was filtered out by the service, for example
+	// because a check of the same name is configured in its labels or annotations
+	TemplateFiltered TemplateStatus = "filtered"
+	// TemplateConditionsNotMatched means the service doesn't match the AD conditions of the template
+	TemplateConditionsNotMatched TemplateStatus = "conditions_not_matched"
+	// TemplateResolutionFailed means the template variables couldn't be resolved for the service
+	TemplateResolutionFailed TemplateStatus = "resolution_failed"
+)
+
+// TemplateExplanation explains the resolution of a template for a service
+type TemplateExplanation struct {
+	Template  Config         `json:"template"`
+	Simulated bool           `json:"simulated"`
+	Status    TemplateStatus `json:"status"`
+	Errors    []string       `json:"errors,omitempty"`
+	Resolved  *Config        `json:"resolved,omitempty"`
+}
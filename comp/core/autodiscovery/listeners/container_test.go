@@ -254,6 +254,7 @@ func TestCreateContainerService(t *testing.T) {
 			expectedServices: map[string]wlmListenerSvc{
 				"container://foo": {
 					service: &service{
+						pod:    pod,
 						entity: kubernetesContainer,
 						adIdentifiers: []string{
 							"docker://foo",
@@ -93,6 +93,7 @@ func (l *KubeletListener) createPodService(
 	entity := kubelet.PodUIDToEntityName(pod.ID)
 	svc := &service{
 		entity:        pod,
+		pod:           pod,
 		tagsHash:      tagger.GetEntityHash(kubelet.PodUIDToTaggerEntityName(pod.ID), tagger.ChecksCardinality()),
 		adIdentifiers: []string{entity},
 		hosts:         map[string]string{"pod": pod.IP},
@@ -155,6 +156,7 @@ func (l *KubeletListener) createContainerService(
 	entity := containers.BuildEntityName(string(container.Runtime), container.ID)
 	svc := &service{
 		entity:   container,
+		pod:      pod,
 		tagsHash: tagger.GetEntityHash(containers.BuildTaggerEntityName(container.ID), tagger.ChecksCardinality()),
 		ready:    pod.Ready,
 		ports:    ports,
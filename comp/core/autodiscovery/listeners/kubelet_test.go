@@ -66,6 +66,7 @@ func TestKubeletCreatePodService(t *testing.T) {
 			expectedServices: map[string]wlmListenerSvc{
 				"kubernetes_pod://foobar": {
 					service: &service{
+						pod:           pod,
 						entity:        pod,
 						adIdentifiers: []string{"kubernetes_pod://foobar"},
 						ports: []ContainerPort{
@@ -239,6 +240,7 @@ func TestKubeletCreateContainerService(t *testing.T) {
 				"container://foobarquux": {
 					parent: "kubernetes_pod://foobar",
 					service: &service{
+						pod:    pod,
 						entity: basicContainer,
 						adIdentifiers: []string{
 							"docker://foobarquux",
@@ -271,6 +273,7 @@ func TestKubeletCreateContainerService(t *testing.T) {
 				"container://foobarquux": {
 					parent: "kubernetes_pod://foobar",
 					service: &service{
+						pod:    pod,
 						entity: recentlyStoppedContainer,
 						adIdentifiers: []string{
 							"docker://foobarquux",
@@ -326,6 +329,7 @@ func TestKubeletCreateContainerService(t *testing.T) {
 				"container://foobarquux": {
 					parent: "kubernetes_pod://foobar",
 					service: &service{
+						pod:    pod,
 						entity: runningContainerWithFinishedAtTime,
 						adIdentifiers: []string{
 							"docker://foobarquux",
@@ -357,6 +361,7 @@ func TestKubeletCreateContainerService(t *testing.T) {
 				"container://foobarquux": {
 					parent: "kubernetes_pod://foobar",
 					service: &service{
+						pod:    pod,
 						entity: multiplePortsContainer,
 						adIdentifiers: []string{
 							"docker://foobarquux",
@@ -397,6 +402,7 @@ func TestKubeletCreateContainerService(t *testing.T) {
 				"container://foobarquux": {
 					parent: "kubernetes_pod://foobar",
 					service: &service{
+						pod:    podWithAnnotations,
 						entity: customIDsContainer,
 						adIdentifiers: []string{
 							"customid",
@@ -441,6 +447,7 @@ func TestKubeletCreateContainerService(t *testing.T) {
 				"container://foobarquux": {
 					parent: "kubernetes_pod://foobar",
 					service: &service{
+						pod:    podWithMetricsExcludeAnnotation,
 						entity: customIDsContainer,
 						adIdentifiers: []string{
 							"customid",
@@ -475,6 +482,7 @@ func TestKubeletCreateContainerService(t *testing.T) {
 				"container://foobarquux": {
 					parent: "kubernetes_pod://foobar",
 					service: &service{
+						pod:    podWithLogsExcludeAnnotation,
 						entity: customIDsContainer,
 						adIdentifiers: []string{
 							"customid",
@@ -24,6 +24,7 @@ import (
 // workloadmeta.Store.
 type service struct {
 	entity          workloadmeta.Entity
+	pod             *workloadmeta.KubernetesPod
 	tagsHash        string
 	adIdentifiers   []string
 	hosts           map[string]string
@@ -37,7 +38,7 @@ type service struct {
 	logsExcluded    bool
 }
 
-var _ Service = &service{}
+var _ WorkloadService = &service{}
 
 // Equal returns whether the two service are equal
 func (s *service) Equal(o Service) bool {
@@ -71,6 +72,16 @@ func (s *service) GetServiceID() string {
 	}
 }
 
+// GetEntity returns the workloadmeta entity of the service.
+func (s *service) GetEntity() workloadmeta.Entity {
+	return s.entity
+}
+
+// GetPod returns the pod of the service, if any.
+func (s *service) GetPod() *workloadmeta.KubernetesPod {
+	return s.pod
+}
+
 // GetADIdentifiers returns the service's AD identifiers.
 func (s *service) GetADIdentifiers(_ context.Context) ([]string, error) {
 	return s.adIdentifiers, nil
@@ -8,6 +8,7 @@ package listeners
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"strconv"
@@ -17,8 +18,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/telemetry"
-	"github.com/DataDog/datadog-agent/pkg/persistentcache"
 	"github.com/DataDog/datadog-agent/pkg/snmp"
+	"github.com/DataDog/datadog-agent/pkg/snmp/snmpdiscovery"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -30,6 +31,8 @@ const (
 	tagSeparator             = ","
 )
 
+var snmpTimeNow = time.Now
+
 // SNMPListener implements SNMP discovery
 type SNMPListener struct {
 	sync.RWMutex
@@ -45,7 +48,10 @@ type SNMPService struct {
 	adIdentifier string
 	entityID     string
 	deviceIP     string
-	config       snmp.Config
+	// config uses the credentials that worked for the device
+	config snmp.Config
+	// profile is the profile selected from the fingerprint of the device
+	profile string
 }
 
 // Make sure SNMPService implements the Service interface
@@ -59,11 +65,28 @@ type snmpSubnet struct {
 	cacheKey       string
 	devices        map[string]string
 	deviceFailures map[string]int
+	// credentialsFailures counts, per device, the consecutive probes that only succeeded with other credentials
+	credentialsFailures map[string]int
+
+	// discovery is how the candidate devices of the network are found and probed
+	discovery snmpdiscovery.Config
+	// credentialSets are the configs for the credentials of the subnet and its discovery credentials
+	credentialSets []snmp.Config
+	// inventory contains the discovered devices with the service entity ID as map key
+	inventory map[string]snmpdiscovery.Device
 }
 
 type snmpJob struct {
 	subnet    *snmpSubnet
 	currentIP net.IP
+	source    snmpdiscovery.Source
+	// candidates is set when discovering neighbors, the neighbors of the device are added to it
+	candidates *snmpdiscovery.CandidateQueue
+}
+
+// snmpParamsError is returned when the SNMP params of a device can't be built
+type snmpParamsError struct {
+	error
 }
 
 // NewSNMPListener creates a SNMPListener
@@ -89,40 +112,28 @@ func (l *SNMPListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
 }
 
 func (l *SNMPListener) loadCache(subnet *snmpSubnet) {
-	cacheValue, err := persistentcache.Read(subnet.cacheKey)
+	devices, err := snmpdiscovery.ReadInventory(subnet.cacheKey)
 	if err != nil {
-		log.Errorf("Couldn't read cache for %s: %s", subnet.cacheKey, err)
+		log.Errorf("%s", err)
 		return
 	}
-	if cacheValue == "" {
-		return
-	}
-	var devices []net.IP
-	if err = json.Unmarshal([]byte(cacheValue), &devices); err != nil {
-		log.Errorf("Couldn't unmarshal cache for %s: %s", subnet.cacheKey, err)
-		return
-	}
-	for _, deviceIP := range devices {
-		entityID := subnet.config.Digest(deviceIP.String())
-		l.createService(entityID, subnet, deviceIP.String(), false)
+	for _, device := range devices {
+		entityID := subnet.config.Digest(device.IP)
+		l.createService(entityID, subnet, device, false)
 	}
 }
 
 func (l *SNMPListener) writeCache(subnet *snmpSubnet) {
 	// We don't lock the subnet for now, because the listener ought to be already locked
-	devices := make([]string, 0, len(subnet.devices))
-	for _, v := range subnet.devices {
-		devices = append(devices, v)
-	}
-
-	cacheValue, err := json.Marshal(devices)
-	if err != nil {
-		log.Errorf("Couldn't marshal cache: %s", err)
-		return
+	devices := make([]snmpdiscovery.Device, 0, len(subnet.devices))
+	for entityID, deviceIP := range subnet.devices {
+		device := subnet.inventory[entityID]
+		device.IP = deviceIP
+		devices = append(devices, device)
 	}
 
-	if err = persistentcache.Write(subnet.cacheKey, string(cacheValue)); err != nil {
-		log.Errorf("Couldn't write cache: %s", err)
+	if err := snmpdiscovery.WriteInventory(subnet.cacheKey, devices); err != nil {
+		log.Errorf("%s", err)
 	}
 }
 
@@ -136,38 +147,146 @@ var worker = func(l *SNMPListener, jobs <-chan snmpJob) {
 		case job := <-jobs:
 			log.Debugf("Handling IP %s", job.currentIP.String())
 			l.checkDevice(job)
+			if job.candidates != nil {
+				job.candidates.Done()
+			}
 		}
 	}
 }
 
 func (l *SNMPListener) checkDevice(job snmpJob) {
 	deviceIP := job.currentIP.String()
-	params, err := job.subnet.config.BuildSNMPParams(deviceIP)
-	if err != nil {
-		log.Errorf("Error building params for device %s: %v", deviceIP, err)
-		return
-	}
 	entityID := job.subnet.config.Digest(deviceIP)
-	if err := params.Connect(); err != nil {
-		log.Debugf("SNMP connect to %s error: %v", deviceIP, err)
-		l.deleteService(entityID, job.subnet)
-	} else {
+	rules := job.subnet.discovery.Fingerprints
+
+	// the credentials that worked last time are tried first, so that the device keeps them while they work
+	l.RLock()
+	previous := job.subnet.inventory[entityID]
+	l.RUnlock()
+	order := snmpdiscovery.ProbeOrder(len(job.subnet.credentialSets), previous.Credentials)
+
+	// all the credentials sets are tried concurrently, the first one in order that works is used
+	i, fingerprint, err := snmpdiscovery.Probe(len(order), func(i int) (*snmpdiscovery.Fingerprint, error) {
+		params, err := job.subnet.credentialSets[order[i]].BuildSNMPParams(deviceIP)
+		if err != nil {
+			return nil, snmpParamsError{fmt.Errorf("Error building params for device %s: %v", deviceIP, err)}
+		}
+		if err := params.Connect(); err != nil {
+			return nil, fmt.Errorf("SNMP connect to %s error: %v", deviceIP, err)
+		}
 		defer params.Conn.Close()
 
 		// Since `params<GoSNMP>.ContextEngineID` is empty
 		// `params.GetNext` might lead to multiple SNMP GET calls when using SNMP v3
 		value, err := params.GetNext([]string{snmp.DeviceReachableGetNextOid})
 		if err != nil {
-			log.Debugf("SNMP get to %s error: %v", deviceIP, err)
-			l.deleteService(entityID, job.subnet)
+			return nil, fmt.Errorf("SNMP get to %s error: %v", deviceIP, err)
 		} else if len(value.Variables) < 1 || value.Variables[0].Value == nil {
-			log.Debugf("SNMP get to %s no data", deviceIP)
-			l.deleteService(entityID, job.subnet)
-		} else {
-			log.Debugf("SNMP get to %s success: %v", deviceIP, value.Variables[0].Value)
-			l.createService(entityID, job.subnet, deviceIP, true)
+			return nil, fmt.Errorf("SNMP get to %s no data", deviceIP)
+		}
+		log.Debugf("SNMP get to %s success: %v", deviceIP, value.Variables[0].Value)
+
+		// the device is reachable, the fingerprint is only needed to select a profile and is best effort
+		if len(rules) == 0 {
+			return nil, nil
+		}
+		fingerprint, err := snmpdiscovery.FetchFingerprint(snmpdiscovery.NewClient(params), snmpdiscovery.UsesSysDescr(rules))
+		if err != nil {
+			log.Debugf("Couldn't fetch the fingerprint of %s: %v", deviceIP, err)
+			return nil, nil
 		}
+		return &fingerprint, nil
+	})
+	if err != nil {
+		if errors.As(err, &snmpParamsError{}) {
+			log.Errorf("%s", err)
+			return
+		}
+		log.Debugf("%s", err)
+		l.deleteService(entityID, job.subnet)
+		return
 	}
+	credentials := order[i]
+
+	if job.candidates != nil {
+		l.addNeighbors(job, job.subnet.credentialSets[credentials])
+	}
+
+	now := snmpTimeNow()
+	device := l.probedDevice(entityID, job.subnet, snmpdiscovery.Device{
+		IP:          deviceIP,
+		Credentials: credentials,
+		Source:      job.source,
+		FirstSeen:   now,
+		LastSeen:    now,
+	}, fingerprint)
+	l.createService(entityID, job.subnet, device, true)
+}
+
+// probedDevice completes the device that answered a probe with its fingerprint, nil if it couldn't be fetched.
+//
+// A probe can fail transiently, so when the fingerprint couldn't be fetched the previous one is kept, and the
+// previous credentials are kept until they failed for allowedCredentialsFailures consecutive probes while other
+// credentials answered. The service is then only replaced when the profile or credentials actually changed.
+func (l *SNMPListener) probedDevice(entityID string, subnet *snmpSubnet, device snmpdiscovery.Device, fingerprint *snmpdiscovery.Fingerprint) snmpdiscovery.Device {
+	l.Lock()
+	defer l.Unlock()
+	previous, known := subnet.inventory[entityID]
+	rules := subnet.discovery.Fingerprints
+	if fingerprint != nil {
+		device.SysObjectID = fingerprint.SysObjectID
+		device.SysDescr = fingerprint.SysDescr
+		device.SysName = fingerprint.SysName
+		device.Profile, _ = snmpdiscovery.MatchFingerprint(rules, *fingerprint)
+	} else if known && len(rules) > 0 {
+		device.SysObjectID = previous.SysObjectID
+		device.SysDescr = previous.SysDescr
+		device.SysName = previous.SysName
+		device.Profile = previous.Profile
+	}
+
+	if !known || device.Credentials == previous.Credentials {
+		delete(subnet.credentialsFailures, entityID)
+		return device
+	}
+	subnet.credentialsFailures[entityID]++
+	if subnet.credentialsFailures[entityID] < l.allowedCredentialsFailures() {
+		log.Debugf("SNMP device %s didn't answer with credentials %d, keeping them until they fail %d times", device.IP, previous.Credentials, l.allowedCredentialsFailures())
+		device.Credentials = previous.Credentials
+		return device
+	}
+	delete(subnet.credentialsFailures, entityID)
+	return device
+}
+
+// allowedCredentialsFailures returns how many probes in a row the previous credentials of a device can fail
+// while other credentials answer before they are replaced
+func (l *SNMPListener) allowedCredentialsFailures() int {
+	return snmpdiscovery.CredentialsAllowedFailures(l.config.AllowedFailures)
+}
+
+// addNeighbors adds the neighbors found in the tables of the device to the candidates
+func (l *SNMPListener) addNeighbors(job snmpJob, config snmp.Config) {
+	deviceIP := job.currentIP.String()
+	params, err := config.BuildSNMPParams(deviceIP)
+	if err != nil {
+		log.Debugf("Error building params for device %s: %v", deviceIP, err)
+		return
+	}
+	if err := params.Connect(); err != nil {
+		log.Debugf("SNMP connect to %s error: %v", deviceIP, err)
+		return
+	}
+	defer params.Conn.Close()
+
+	added := 0
+	neighbors := snmpdiscovery.FetchNeighbors(snmpdiscovery.NewClient(params), snmpdiscovery.DefaultMaxNeighborRows)
+	for _, neighbor := range neighbors {
+		if job.candidates.Add(neighbor.IP, neighbor.Source) {
+			added++
+		}
+	}
+	log.Debugf("Found %d neighbors of %s, %d new candidates", len(neighbors), deviceIP, added)
 }
 
 func (l *SNMPListener) checkDevices() {
@@ -181,6 +300,17 @@ func (l *SNMPListener) checkDevices() {
 
 		startingIP := ipAddr.Mask(ipNet.Mask)
 
+		discovery, err := snmpdiscovery.Settings{
+			Mode:         config.DiscoveryMode,
+			Seeds:        config.DiscoverySeeds,
+			Credentials:  config.DiscoveryCredentials,
+			Fingerprints: config.DiscoveryFingerprints,
+		}.Parse()
+		if err != nil {
+			log.Errorf("Couldn't parse SNMP discovery config of %s: %s", config.Network, err)
+			continue
+		}
+
 		configHash := config.Digest(config.Network)
 		cacheKey := fmt.Sprintf("snmp:%s", configHash)
 		adIdentifier := config.ADIdentifier
@@ -189,13 +319,17 @@ func (l *SNMPListener) checkDevices() {
 		}
 
 		subnet := snmpSubnet{
-			adIdentifier:   adIdentifier,
-			config:         config,
-			startingIP:     startingIP,
-			network:        *ipNet,
-			cacheKey:       cacheKey,
-			devices:        map[string]string{},
-			deviceFailures: map[string]int{},
+			adIdentifier:        adIdentifier,
+			config:              config,
+			startingIP:          startingIP,
+			network:             *ipNet,
+			cacheKey:            cacheKey,
+			devices:             map[string]string{},
+			deviceFailures:      map[string]int{},
+			credentialsFailures: map[string]int{},
+			discovery:           discovery,
+			credentialSets:      config.CredentialSets(),
+			inventory:           map[string]snmpdiscovery.Device{},
 		}
 		subnets = append(subnets, subnet)
 
@@ -222,32 +356,40 @@ func (l *SNMPListener) checkDevices() {
 	discoveryTicker := time.NewTicker(time.Duration(l.config.DiscoveryInterval) * time.Second)
 	defer discoveryTicker.Stop()
 	for {
-		var subnet *snmpSubnet
 		for i := range subnets {
 			// Use `&subnets[i]` to pass the correct pointer address to snmpJob{}
-			subnet = &subnets[i]
-			startingIP := make(net.IP, len(subnet.startingIP))
-			copy(startingIP, subnet.startingIP)
-			for currentIP := startingIP; subnet.network.Contains(currentIP); incrementIP(currentIP) {
-
-				if ignored := subnet.config.IsIPIgnored(currentIP); ignored {
-					continue
-				}
-
-				jobIP := make(net.IP, len(currentIP))
-				copy(jobIP, currentIP)
-				job := snmpJob{
-					subnet:    subnet,
-					currentIP: jobIP,
+			subnet := &subnets[i]
+			run := snmpdiscovery.Run{
+				Network:   subnet.network,
+				IsIgnored: subnet.config.IsIPIgnored,
+				Config:    subnet.discovery,
+				Known:     l.knownDevices(subnet),
+			}
+			scheduled := snmpdiscovery.ScheduleCandidates(run, l.stop, func(candidate snmpdiscovery.Candidate, candidates *snmpdiscovery.CandidateQueue) bool {
+				jobs <- snmpJob{
+					subnet:     subnet,
+					currentIP:  candidate.IP,
+					source:     candidate.Source,
+					candidates: candidates,
 				}
-				jobs <- job
 
 				select {
 				case <-l.stop:
-					return
+					return false
 				default:
+					return true
 				}
+			})
+			if !scheduled {
+				return
+			}
+
+			// persist when the devices were last seen
+			l.Lock()
+			if len(subnet.devices) > 0 {
+				l.writeCache(subnet)
 			}
+			l.Unlock()
 		}
 
 		select {
@@ -258,21 +400,55 @@ func (l *SNMPListener) checkDevices() {
 	}
 }
 
-func (l *SNMPListener) createService(entityID string, subnet *snmpSubnet, deviceIP string, writeCache bool) {
+// knownDevices returns the IPs of the devices discovered in the subnet
+func (l *SNMPListener) knownDevices(subnet *snmpSubnet) []net.IP {
+	l.RLock()
+	defer l.RUnlock()
+	ips := make([]net.IP, 0, len(subnet.devices))
+	for _, deviceIP := range subnet.devices {
+		if ip := net.ParseIP(deviceIP); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+func (l *SNMPListener) createService(entityID string, subnet *snmpSubnet, device snmpdiscovery.Device, writeCache bool) {
 	l.Lock()
 	defer l.Unlock()
-	if _, present := l.services[entityID]; present {
-		return
+	if svc, present := l.services[entityID]; present {
+		previous := subnet.inventory[entityID]
+		// keep when the device was first seen and how it was found
+		if !previous.FirstSeen.IsZero() {
+			device.FirstSeen = previous.FirstSeen
+		}
+		if previous.Source != "" {
+			device.Source = previous.Source
+		}
+		subnet.inventory[entityID] = device
+		if device.Profile == previous.Profile && device.Credentials == previous.Credentials {
+			return
+		}
+		// the service is replaced to use the new profile or credentials
+		log.Infof("SNMP device %s changed, using profile `%s` and credentials %d", device.IP, device.Profile, device.Credentials)
+		l.delService <- svc
+		delete(l.services, entityID)
+	}
+	config := subnet.config
+	if device.Credentials > 0 && device.Credentials < len(subnet.credentialSets) {
+		config = subnet.credentialSets[device.Credentials]
 	}
 	svc := &SNMPService{
 		adIdentifier: subnet.adIdentifier,
 		entityID:     entityID,
-		deviceIP:     deviceIP,
-		config:       subnet.config,
+		deviceIP:     device.IP,
+		config:       config,
+		profile:      device.Profile,
 	}
 	l.services[entityID] = svc
-	subnet.devices[entityID] = deviceIP
+	subnet.devices[entityID] = device.IP
 	subnet.deviceFailures[entityID] = 0
+	subnet.inventory[entityID] = device
 	if writeCache {
 		l.writeCache(subnet)
 	}
@@ -296,20 +472,13 @@ func (l *SNMPListener) deleteService(entityID string, subnet *snmpSubnet) {
 			l.delService <- svc
 			delete(l.services, entityID)
 			delete(subnet.devices, entityID)
+			delete(subnet.inventory, entityID)
+			delete(subnet.credentialsFailures, entityID)
 			l.writeCache(subnet)
 		}
 	}
 }
 
-func incrementIP(ip net.IP) {
-	for i := len(ip) - 1; i >= 0; i-- {
-		ip[i]++
-		if ip[i] > 0 {
-			break
-		}
-	}
-}
-
 // Stop queues a shutdown of SNMPListener
 func (l *SNMPListener) Stop() {
 	l.stop <- true
@@ -325,7 +494,8 @@ func (s *SNMPService) Equal(o Service) bool {
 	return s.entityID == s2.entityID &&
 		s.deviceIP == s2.deviceIP &&
 		s.config.Port == s2.config.Port &&
-		s.adIdentifier == s2.adIdentifier
+		s.adIdentifier == s2.adIdentifier &&
+		s.profile == s2.profile
 }
 
 // GetServiceID returns the unique entity ID linked to that service
@@ -410,6 +580,8 @@ func (s *SNMPService) GetExtraConfig(key string) (string, error) {
 		return s.config.Network, nil
 	case "loader":
 		return s.config.Loader, nil
+	case "profile":
+		return s.profile, nil
 	case "namespace":
 		return s.config.Namespace, nil
 	case "collect_device_metadata":
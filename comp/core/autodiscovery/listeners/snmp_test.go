@@ -7,12 +7,15 @@ package listeners
 
 import (
 	"fmt"
+	"net"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
 	"github.com/DataDog/datadog-agent/pkg/snmp"
+	"github.com/DataDog/datadog-agent/pkg/snmp/snmpdiscovery"
 	"github.com/DataDog/datadog-agent/pkg/snmp/snmpintegration"
 
 	"github.com/stretchr/testify/assert"
@@ -157,6 +160,170 @@ func TestSNMPListenerIgnoredAdresses(t *testing.T) {
 	assert.Equal(t, "192.168.0.0", job.subnet.startingIP.String())
 }
 
+func TestSNMPListenerNeighbors(t *testing.T) {
+	newSvc := make(chan Service, 10)
+	delSvc := make(chan Service, 10)
+	testChan := make(chan snmpJob, 10)
+
+	snmpConfig := snmp.Config{
+		Network:        "192.168.0.0/29",
+		Community:      "public",
+		DiscoveryMode:  "hybrid",
+		DiscoverySeeds: []string{"192.168.0.5"},
+	}
+	listenerConfig := snmp.ListenerConfig{
+		Configs: []snmp.Config{snmpConfig},
+		Workers: 1,
+	}
+
+	mockConfig := configmock.New(t)
+	mockConfig.SetWithoutSource("network_devices.autodiscovery", listenerConfig)
+
+	worker = func(_ *SNMPListener, jobs <-chan snmpJob) {
+		for {
+			job := <-jobs
+			testChan <- job
+		}
+	}
+
+	l, err := NewSNMPListener(&config.Listeners{}, nil)
+	assert.Equal(t, nil, err)
+	l.Listen(newSvc, delSvc)
+
+	// the seed is probed first, and its neighbors
+	job := <-testChan
+	assert.Equal(t, "192.168.0.5", job.currentIP.String())
+	assert.Equal(t, snmpdiscovery.SourceSeed, job.source)
+	assert.True(t, job.candidates.Add(net.ParseIP("192.168.0.3"), snmpdiscovery.SourceLLDP))
+	job.candidates.Done()
+
+	job = <-testChan
+	assert.Equal(t, "192.168.0.3", job.currentIP.String())
+	assert.Equal(t, snmpdiscovery.SourceLLDP, job.source)
+	job.candidates.Done()
+
+	// then the IPs of the subnet that weren't probed
+	var sweptIPs []string
+	for i := 0; i < 6; i++ {
+		job = <-testChan
+		assert.Nil(t, job.candidates)
+		assert.Equal(t, snmpdiscovery.SourceSweep, job.source)
+		sweptIPs = append(sweptIPs, job.currentIP.String())
+	}
+	assert.Equal(t, []string{"192.168.0.0", "192.168.0.1", "192.168.0.2", "192.168.0.4", "192.168.0.6", "192.168.0.7"}, sweptIPs)
+}
+
+func TestSNMPListenerCreateService(t *testing.T) {
+	mockConfig := configmock.New(t)
+	mockConfig.SetWithoutSource("run_path", t.TempDir())
+
+	newSvc := make(chan Service, 10)
+	delSvc := make(chan Service, 10)
+	l := &SNMPListener{
+		services:   map[string]Service{},
+		newService: newSvc,
+		delService: delSvc,
+	}
+	snmpConfig := snmp.Config{
+		Network:              "192.168.0.0/24",
+		Community:            "public",
+		DiscoveryCredentials: []snmpdiscovery.Credentials{{Community: "private"}},
+	}
+	subnet := &snmpSubnet{
+		adIdentifier:   "snmp",
+		config:         snmpConfig,
+		cacheKey:       "snmp:TestSNMPListenerCreateService",
+		devices:        map[string]string{},
+		deviceFailures: map[string]int{},
+		credentialSets: snmpConfig.CredentialSets(),
+		inventory:      map[string]snmpdiscovery.Device{},
+	}
+	entityID := snmpConfig.Digest("192.168.0.1")
+	firstSeen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	l.createService(entityID, subnet, snmpdiscovery.Device{IP: "192.168.0.1", SysObjectID: "1.3.6.1.4.1.9.1.1745", Source: snmpdiscovery.SourceSweep, FirstSeen: firstSeen, LastSeen: firstSeen}, true)
+	svc := (<-newSvc).(*SNMPService)
+	profile, err := svc.GetExtraConfig("profile")
+	assert.NoError(t, err)
+	assert.Equal(t, "", profile)
+
+	// seen again, only the inventory is updated
+	lastSeen := firstSeen.Add(time.Hour)
+	l.createService(entityID, subnet, snmpdiscovery.Device{IP: "192.168.0.1", SysObjectID: "1.3.6.1.4.1.9.1.1745", Source: snmpdiscovery.SourceARP, FirstSeen: lastSeen, LastSeen: lastSeen}, true)
+	assert.Empty(t, newSvc)
+	assert.Equal(t, firstSeen, subnet.inventory[entityID].FirstSeen)
+	assert.Equal(t, lastSeen, subnet.inventory[entityID].LastSeen)
+	assert.Equal(t, snmpdiscovery.SourceSweep, subnet.inventory[entityID].Source)
+
+	// the profile and credentials changed, the service is replaced
+	l.createService(entityID, subnet, snmpdiscovery.Device{IP: "192.168.0.1", SysObjectID: "1.3.6.1.4.1.9.1.1745", Profile: "cisco", Credentials: 1, LastSeen: lastSeen}, true)
+	assert.Equal(t, svc, <-delSvc)
+	svc = (<-newSvc).(*SNMPService)
+	profile, err = svc.GetExtraConfig("profile")
+	assert.NoError(t, err)
+	assert.Equal(t, "cisco", profile)
+	community, err := svc.GetExtraConfig("community")
+	assert.NoError(t, err)
+	assert.Equal(t, "private", community)
+
+	devices, err := snmpdiscovery.ReadInventory(subnet.cacheKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []snmpdiscovery.Device{{
+		IP:          "192.168.0.1",
+		SysObjectID: "1.3.6.1.4.1.9.1.1745",
+		Profile:     "cisco",
+		Credentials: 1,
+		Source:      snmpdiscovery.SourceSweep,
+		FirstSeen:   firstSeen,
+		LastSeen:    lastSeen,
+	}}, devices)
+}
+
+func TestSNMPListenerProbedDevice(t *testing.T) {
+	l := &SNMPListener{config: snmp.ListenerConfig{AllowedFailures: 2}}
+	discovery, err := snmpdiscovery.Settings{
+		Fingerprints: []snmpdiscovery.FingerprintRule{{SysObjectID: "1.3.6.1.4.1.9.*", Profile: "cisco"}},
+	}.Parse()
+	assert.NoError(t, err)
+	subnet := &snmpSubnet{
+		discovery:           discovery,
+		credentialsFailures: map[string]int{},
+		inventory:           map[string]snmpdiscovery.Device{},
+	}
+	entityID := "device"
+	cisco := &snmpdiscovery.Fingerprint{SysObjectID: "1.3.6.1.4.1.9.1.1745"}
+
+	device := l.probedDevice(entityID, subnet, snmpdiscovery.Device{IP: "192.168.0.1", Credentials: 1}, cisco)
+	assert.Equal(t, "cisco", device.Profile)
+	assert.Equal(t, 1, device.Credentials)
+	subnet.inventory[entityID] = device
+
+	// the fingerprint couldn't be fetched, the previous one is kept
+	device = l.probedDevice(entityID, subnet, snmpdiscovery.Device{IP: "192.168.0.1", Credentials: 1}, nil)
+	assert.Equal(t, "cisco", device.Profile)
+	assert.Equal(t, "1.3.6.1.4.1.9.1.1745", device.SysObjectID)
+
+	// the previous credentials failed once, they are kept
+	device = l.probedDevice(entityID, subnet, snmpdiscovery.Device{IP: "192.168.0.1", Credentials: 0}, cisco)
+	assert.Equal(t, 1, device.Credentials)
+
+	// they work again, the failures are reset
+	device = l.probedDevice(entityID, subnet, snmpdiscovery.Device{IP: "192.168.0.1", Credentials: 1}, cisco)
+	assert.Equal(t, 1, device.Credentials)
+	assert.Empty(t, subnet.credentialsFailures)
+
+	// they failed for the allowed failures, the credentials that answered are used
+	device = l.probedDevice(entityID, subnet, snmpdiscovery.Device{IP: "192.168.0.1", Credentials: 0}, cisco)
+	assert.Equal(t, 1, device.Credentials)
+	device = l.probedDevice(entityID, subnet, snmpdiscovery.Device{IP: "192.168.0.1", Credentials: 0}, cisco)
+	assert.Equal(t, 0, device.Credentials)
+
+	// a successful fingerprint changes the profile
+	device = l.probedDevice(entityID, subnet, snmpdiscovery.Device{IP: "192.168.0.1"}, &snmpdiscovery.Fingerprint{SysObjectID: "1.3.6.1.4.1.2636.1"})
+	assert.Equal(t, "", device.Profile)
+	assert.Equal(t, "1.3.6.1.4.1.2636.1", device.SysObjectID)
+}
+
 func TestExtraConfig(t *testing.T) {
 	truePtr := true
 	fivePtr := 5
@@ -11,6 +11,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/telemetry"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -47,6 +48,19 @@ type Service interface {
 	FilterTemplates(map[string]integration.Config)
 }
 
+// WorkloadService is implemented by the services backed by a workloadmeta
+// entity. Template variables use it to read the labels, annotations,
+// environment variables and fields of the entity.
+type WorkloadService interface {
+	Service
+
+	// GetEntity returns the workloadmeta entity of the service.
+	GetEntity() workloadmeta.Entity
+	// GetPod returns the pod of the service: the pod itself or the pod its
+	// container belongs to, nil if there is none.
+	GetPod() *workloadmeta.KubernetesPod
+}
+
 // ServiceListener monitors running services and triggers check (un)scheduling
 //
 // It holds a cache of running services, listens to new/killed services and
@@ -28,6 +28,7 @@ import (
 type configFormat struct {
 	ADIdentifiers           []string                           `yaml:"ad_identifiers"`
 	AdvancedADIdentifiers   []integration.AdvancedADIdentifier `yaml:"advanced_ad_identifiers"`
+	ADConditions            []string                           `yaml:"ad_conditions"`
 	ClusterCheck            bool                               `yaml:"cluster_check"`
 	InitConfig              interface{}                        `yaml:"init_config"`
 	MetricConfig            interface{}                        `yaml:"jmx_metrics"`
@@ -354,15 +355,26 @@ const emptyFileError = "empty file"
 
 // GetIntegrationConfigFromFile returns an instance of integration.Config if `fpath` points to a valid config file
 func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error) {
-	cf := configFormat{}
-	conf := integration.Config{Name: name}
-
 	// Read file contents
 	// FIXME: ReadFile reads the entire file, possible security implications
 	yamlFile, err := os.ReadFile(fpath)
+	if err != nil {
+		return integration.Config{Name: name}, err
+	}
+
+	conf, err := parseIntegrationConfig(name, fpath, yamlFile)
 	if err != nil {
 		return conf, err
 	}
+	conf.Source = "file:" + fpath
+	return conf, nil
+}
+
+// parseIntegrationConfig parses the content of a conf.d style configuration file,
+// location is only used in logs to identify where the content comes from.
+func parseIntegrationConfig(name, location string, yamlFile []byte) (integration.Config, error) {
+	cf := configFormat{}
+	conf := integration.Config{Name: name}
 
 	// Check for empty file and return special error if so
 	if len(yamlFile) == 0 {
@@ -375,7 +387,7 @@ func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error
 		if err := yaml.Unmarshal(yamlFile, &cf); err != nil {
 			return conf, err
 		}
-		log.Warnf("reading config file %v: %v\n", fpath, strictErr)
+		log.Warnf("reading config file %v: %v\n", location, strictErr)
 	}
 
 	// If no valid instances were found & this is neither a metrics file, nor a logs file
@@ -401,7 +413,7 @@ func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error
 			tags := configUtils.GetConfiguredTags(config.Datadog(), false)
 			err := dataConf.MergeAdditionalTags(tags)
 			if err != nil {
-				log.Debugf("Could not add agent-level tags to instance of %v: %v", fpath, err)
+				log.Debugf("Could not add agent-level tags to instance of %v: %v", location, err)
 			}
 		}
 		conf.Instances = append(conf.Instances, dataConf)
@@ -423,6 +435,10 @@ func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error
 	// Copy auto discovery identifiers
 	conf.ADIdentifiers = cf.ADIdentifiers
 	conf.AdvancedADIdentifiers = cf.AdvancedADIdentifiers
+	conf.ADConditions = cf.ADConditions
+	if err := configresolver.ValidateConditions(conf.ADConditions); err != nil {
+		return conf, err
+	}
 
 	// Copy cluster_check status
 	conf.ClusterCheck = cf.ClusterCheck
@@ -444,9 +460,7 @@ func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error
 		}
 	}
 
-	conf.Source = "file:" + fpath
-
-	return conf, err
+	return conf, nil
 }
 
 func containsString(slice []string, str string) bool {
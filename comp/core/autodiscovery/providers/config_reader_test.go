@@ -80,6 +80,13 @@ func TestGetIntegrationConfig(t *testing.T) {
 	require.Nil(t, err)
 	assert.Equal(t, config.AdvancedADIdentifiers, []integration.AdvancedADIdentifier{{KubeService: integration.KubeNamespacedName{Name: "svc-name", Namespace: "svc-ns"}}})
 
+	// autodiscovery conditions are validated when loading the template
+	config, err = GetIntegrationConfigFromFile("foo", "tests/ad_conditions.yaml")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"%%label_team%% == storage"}, config.ADConditions)
+	_, err = GetIntegrationConfigFromFile("foo", "tests/ad_invalid_conditions.yaml")
+	assert.EqualError(t, err, `invalid AD condition "%%label_team%% =~ (": error parsing regexp: missing closing ): `+"`(`")
+
 	// autodiscovery: check if we correctly refuse to load if a 'docker_images' section is present
 	config, err = GetIntegrationConfigFromFile("foo", "tests/ad_deprecated.yaml")
 	assert.NotNil(t, err)
@@ -98,8 +105,8 @@ func TestReadConfigFiles(t *testing.T) {
 
 	configs, errors, err := ReadConfigFiles(GetAll)
 	require.Nil(t, err)
-	require.Equal(t, 19, len(configs))
-	require.Equal(t, 4, len(errors))
+	require.Equal(t, 20, len(configs))
+	require.Equal(t, 5, len(errors))
 
 	for _, c := range configs {
 		if c.Name == "empty" {
@@ -109,7 +116,7 @@ func TestReadConfigFiles(t *testing.T) {
 
 	configs, _, err = ReadConfigFiles(WithoutAdvancedAD)
 	require.Nil(t, err)
-	require.Equal(t, 18, len(configs))
+	require.Equal(t, 19, len(configs))
 
 	configs, _, err = ReadConfigFiles(WithAdvancedADOnly)
 	require.Nil(t, err)
@@ -0,0 +1,253 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const gitCommandTimeout = 2 * time.Minute
+
+// GitConfigProvider implements the ConfigProvider interface.
+// It keeps a shallow clone of a Git repository up to date and reads the conf.d
+// style configuration files found in its template_dir subdirectory, with the
+// same layout and rules as the file config provider.
+type GitConfigProvider struct {
+	repoURL        string
+	branch         string
+	localPath      string
+	templateDir    string
+	env            []string
+	telemetryStore *telemetry.Store
+
+	sync.Mutex
+	fetchedCommit   string
+	collectedCommit string
+	configErrors    map[string]ErrorMsgSet
+}
+
+// NewGitConfigProvider creates a new GitConfigProvider cloning the template_url repository
+func NewGitConfigProvider(providerConfig *config.ConfigurationProviders, telemetryStore *telemetry.Store) (ConfigProvider, error) {
+	if providerConfig == nil {
+		providerConfig = &config.ConfigurationProviders{}
+	}
+	if providerConfig.TemplateURL == "" {
+		return nil, errors.New("the git config provider requires a template_url")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("the git config provider requires the git binary: %s", err)
+	}
+
+	templateDir := filepath.Clean(filepath.FromSlash(providerConfig.TemplateDir))
+	if filepath.IsAbs(templateDir) || templateDir == ".." || strings.HasPrefix(templateDir, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("the template_dir of the git config provider must be a path relative to the repository root, got '%s'", providerConfig.TemplateDir)
+	}
+
+	// each repository and branch gets its own clone
+	digest := sha256.Sum256([]byte(providerConfig.TemplateURL + "#" + providerConfig.Branch))
+	localPath := filepath.Join(config.Datadog().GetString("run_path"), "config_providers", "git", hex.EncodeToString(digest[:8]))
+
+	return &GitConfigProvider{
+		repoURL:        providerConfig.TemplateURL,
+		branch:         providerConfig.Branch,
+		localPath:      localPath,
+		templateDir:    templateDir,
+		env:            gitEnv(providerConfig),
+		telemetryStore: telemetryStore,
+		configErrors:   make(map[string]ErrorMsgSet),
+	}, nil
+}
+
+// gitEnv returns the environment of the git commands. The credentials and TLS settings
+// are passed as environment config entries to keep them out of the process arguments.
+func gitEnv(providerConfig *config.ConfigurationProviders) []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var gitConfig [][2]string
+	if providerConfig.Token != "" {
+		gitConfig = append(gitConfig, [2]string{"http.extraHeader", "Authorization: Bearer " + providerConfig.Token})
+	} else if providerConfig.Username != "" && providerConfig.Password != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(providerConfig.Username + ":" + providerConfig.Password))
+		gitConfig = append(gitConfig, [2]string{"http.extraHeader", "Authorization: Basic " + credentials})
+	}
+	if providerConfig.CAFile != "" {
+		gitConfig = append(gitConfig, [2]string{"http.sslCAInfo", providerConfig.CAFile})
+	}
+	if providerConfig.CAPath != "" {
+		gitConfig = append(gitConfig, [2]string{"http.sslCAPath", providerConfig.CAPath})
+	}
+	if providerConfig.CertFile != "" {
+		gitConfig = append(gitConfig, [2]string{"http.sslCert", providerConfig.CertFile})
+	}
+	if providerConfig.KeyFile != "" {
+		gitConfig = append(gitConfig, [2]string{"http.sslKey", providerConfig.KeyFile})
+	}
+
+	env = append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(gitConfig)))
+	for i, kv := range gitConfig {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, kv[0]), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, kv[1]))
+	}
+	return env
+}
+
+// String returns a string representation of the GitConfigProvider
+func (p *GitConfigProvider) String() string {
+	return names.Git
+}
+
+// IsUpToDate fetches the repository and checks whether its head moved since the last Collect call
+func (p *GitConfigProvider) IsUpToDate(ctx context.Context) (bool, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	commit, err := p.sync(ctx)
+	if err != nil {
+		return false, err
+	}
+	return commit == p.collectedCommit, nil
+}
+
+// Collect reads the configuration files of the repository, fetching it first
+// unless IsUpToDate just did
+func (p *GitConfigProvider) Collect(ctx context.Context) ([]integration.Config, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	commit := p.fetchedCommit
+	if commit == "" || commit == p.collectedCommit {
+		var err error
+		if commit, err = p.sync(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	dir := filepath.Join(p.localPath, p.templateDir)
+	if _, err := os.Stat(dir); err != nil {
+		p.fetchFailed("template_dir")
+		return nil, fmt.Errorf("could not read the configs of %s: %s", p.repoURL, err)
+	}
+	configs, integrationErrors := (&configFilesReader{paths: []string{dir}}).read(WithoutAdvancedAD)
+	for idx := range configs {
+		rel, err := filepath.Rel(p.localPath, strings.TrimPrefix(configs[idx].Source, "file:"))
+		if err == nil {
+			configs[idx].Source = "git:" + filepath.ToSlash(rel)
+		}
+	}
+
+	configErrors := make(map[string]ErrorMsgSet, len(integrationErrors))
+	for name, err := range integrationErrors {
+		configErrors[name] = ErrorMsgSet{err: struct{}{}}
+	}
+	p.configErrors = configErrors
+	p.collectedCommit = commit
+	if p.telemetryStore != nil {
+		p.telemetryStore.Errors.Set(float64(len(configErrors)), names.Git)
+	}
+	log.Debugf("Collected %d configs from %s at commit %s", len(configs), p.repoURL, commit)
+	return configs, nil
+}
+
+// sync clones the repository or fetches its latest commit, and returns the checked out commit
+func (p *GitConfigProvider) sync(ctx context.Context) (string, error) {
+	if _, err := os.Stat(filepath.Join(p.localPath, ".git")); err != nil {
+		if err := p.clone(ctx); err != nil {
+			p.fetchFailed("clone")
+			return "", err
+		}
+	} else {
+		ref := "HEAD"
+		if p.branch != "" {
+			ref = p.branch
+		}
+		if _, err := p.git(ctx, p.localPath, "fetch", "--depth", "1", "origin", ref); err != nil {
+			p.fetchFailed("fetch")
+			return "", err
+		}
+		// the clone is owned by the agent, local changes are discarded
+		if _, err := p.git(ctx, p.localPath, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			p.fetchFailed("fetch")
+			return "", err
+		}
+	}
+
+	commit, err := p.git(ctx, p.localPath, "rev-parse", "HEAD")
+	if err != nil {
+		p.fetchFailed("fetch")
+		return "", err
+	}
+	if commit != p.fetchedCommit && p.telemetryStore != nil {
+		p.telemetryStore.LastFetchTimestamp.Set(float64(time.Now().Unix()), names.Git)
+	}
+	p.fetchedCommit = commit
+	return commit, nil
+}
+
+// clone makes a new shallow clone of the repository, replacing a broken one if any
+func (p *GitConfigProvider) clone(ctx context.Context) error {
+	if err := os.RemoveAll(p.localPath); err != nil {
+		return fmt.Errorf("could not remove the previous clone of %s: %s", p.repoURL, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p.localPath), 0700); err != nil {
+		return fmt.Errorf("could not create the directory of the clone of %s: %s", p.repoURL, err)
+	}
+
+	args := []string{"clone", "--depth", "1", "--single-branch", "--no-tags"}
+	if p.branch != "" {
+		args = append(args, "--branch", p.branch)
+	}
+	args = append(args, "--", p.repoURL, p.localPath)
+	log.Infof("Cloning %s in %s", p.repoURL, p.localPath)
+	_, err := p.git(ctx, "", args...)
+	return err
+}
+
+// git runs a git command and returns its trimmed output
+func (p *GitConfigProvider) git(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = p.env
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed for %s: %s: %s", args[0], p.repoURL, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (p *GitConfigProvider) fetchFailed(reason string) {
+	if p.telemetryStore != nil {
+		p.telemetryStore.FetchErrors.Inc(names.Git, reason)
+	}
+}
+
+// GetConfigErrors returns the errors of the configuration files read on the last Collect call
+func (p *GitConfigProvider) GetConfigErrors() map[string]ErrorMsgSet {
+	p.Lock()
+	defer p.Unlock()
+	return p.configErrors
+}
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func writeRepoFile(t *testing.T, repo, path, content string) {
+	path = filepath.Join(repo, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestGitConfigProvider(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "--initial-branch", "main")
+	writeRepoFile(t, repo, "checks/redisdb.d/conf.yaml", "ad_identifiers: [redis]\ninstances:\n  - host: '%%host%%'\n")
+	writeRepoFile(t, repo, "checks/nginx.yaml", "instances: [{}]\n")
+	writeRepoFile(t, repo, "checks/broken.yaml", "init_config: {}\n")
+	writeRepoFile(t, repo, "README.md", "configs\n")
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "-m", "initial configs")
+
+	config.Datadog().SetWithoutSource("run_path", t.TempDir())
+	provider, err := NewGitConfigProvider(&config.ConfigurationProviders{TemplateURL: repo, TemplateDir: "checks", Branch: "main"}, nil)
+	require.NoError(t, err)
+	p := provider.(*GitConfigProvider)
+
+	configs, err := p.Collect(context.Background())
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+	sources := map[string]string{}
+	for _, c := range configs {
+		sources[c.Name] = c.Source
+	}
+	assert.Equal(t, map[string]string{"nginx": "git:checks/nginx.yaml", "redisdb": "git:checks/redisdb.d/conf.yaml"}, sources)
+	assert.Contains(t, p.GetConfigErrors(), "broken")
+
+	upToDate, err := p.IsUpToDate(context.Background())
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+
+	// new commits are fetched and replace the local files
+	require.NoError(t, os.Remove(filepath.Join(repo, "checks/nginx.yaml")))
+	writeRepoFile(t, repo, "checks/broken.yaml", "instances: [{}]\n")
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "-m", "update configs")
+
+	upToDate, err = p.IsUpToDate(context.Background())
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+	configs, err = p.Collect(context.Background())
+	require.NoError(t, err)
+	names := []string{}
+	for _, c := range configs {
+		names = append(names, c.Name)
+	}
+	assert.ElementsMatch(t, []string{"broken", "redisdb"}, names)
+	assert.Empty(t, p.GetConfigErrors())
+
+	// a broken clone is replaced by a new one
+	require.NoError(t, os.RemoveAll(filepath.Join(p.localPath, ".git")))
+	configs, err = p.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, configs, 2)
+}
+
+func TestNewGitConfigProvider(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	_, err := NewGitConfigProvider(&config.ConfigurationProviders{}, nil)
+	assert.EqualError(t, err, "the git config provider requires a template_url")
+
+	_, err = NewGitConfigProvider(&config.ConfigurationProviders{TemplateURL: "https://example.com/repo.git", TemplateDir: "../etc"}, nil)
+	assert.EqualError(t, err, "the template_dir of the git config provider must be a path relative to the repository root, got '../etc'")
+
+	provider, err := NewGitConfigProvider(&config.ConfigurationProviders{TemplateURL: "https://example.com/repo.git", Username: "user", Password: "pass"}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, provider.(*GitConfigProvider).env, "GIT_CONFIG_KEY_0=http.extraHeader")
+	assert.Contains(t, provider.(*GitConfigProvider).env, "GIT_CONFIG_VALUE_0=Authorization: Basic dXNlcjpwYXNz")
+}
@@ -0,0 +1,284 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// maxHTTPConfigSize is the max size of the document served by the config URL
+	maxHTTPConfigSize  = 10 * 1024 * 1024
+	httpRequestTimeout = 30 * time.Second
+)
+
+// HTTPConfigProvider implements the ConfigProvider interface.
+// It polls a URL serving a YAML or JSON document indexed by check name, each
+// check having one or a list of conf.d style configurations:
+//
+//	redisdb:
+//	  ad_identifiers: [redis]
+//	  instances: [{host: "%%host%%"}]
+//	http_check:
+//	  - instances: [{url: "https://example.com"}]
+//	  - instances: [{url: "https://example.org"}]
+//
+// The ETag of the response is sent back in the If-None-Match header of the
+// following polls, so that unchanged documents aren't downloaded and parsed again.
+type HTTPConfigProvider struct {
+	url            string
+	client         *http.Client
+	username       string
+	password       string
+	token          string
+	telemetryStore *telemetry.Store
+
+	sync.Mutex
+	etag         string
+	digest       [sha256.Size]byte
+	pendingBody  []byte
+	pendingEtag  string
+	configErrors map[string]ErrorMsgSet
+}
+
+// NewHTTPConfigProvider creates a new HTTPConfigProvider polling the template_url
+func NewHTTPConfigProvider(providerConfig *config.ConfigurationProviders, telemetryStore *telemetry.Store) (ConfigProvider, error) {
+	if providerConfig == nil {
+		providerConfig = &config.ConfigurationProviders{}
+	}
+
+	u, err := url.Parse(providerConfig.TemplateURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("the template_url of the http config provider must be an http or https URL, got '%s'", providerConfig.TemplateURL)
+	}
+
+	tlsConfig, err := buildProviderTLSConfig(providerConfig)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &HTTPConfigProvider{
+		url:            providerConfig.TemplateURL,
+		client:         &http.Client{Transport: transport, Timeout: httpRequestTimeout},
+		username:       providerConfig.Username,
+		password:       providerConfig.Password,
+		token:          providerConfig.Token,
+		telemetryStore: telemetryStore,
+		configErrors:   make(map[string]ErrorMsgSet),
+	}, nil
+}
+
+// buildProviderTLSConfig returns the TLS configuration matching the CA and client certificate of a provider config
+func buildProviderTLSConfig(providerConfig *config.ConfigurationProviders) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if providerConfig.CAFile != "" {
+		pem, err := os.ReadFile(providerConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the CA file: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in '%s'", providerConfig.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if providerConfig.CertFile != "" || providerConfig.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(providerConfig.CertFile, providerConfig.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// String returns a string representation of the HTTPConfigProvider
+func (p *HTTPConfigProvider) String() string {
+	return names.HTTP
+}
+
+// IsUpToDate sends a conditional request to the config URL. A changed document is
+// kept to be parsed by the next Collect call.
+func (p *HTTPConfigProvider) IsUpToDate(ctx context.Context) (bool, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	body, etag, notModified, err := p.fetch(ctx, p.etag)
+	if err != nil {
+		return false, err
+	}
+	// servers not supporting ETags send the whole document again
+	if notModified || sha256.Sum256(body) == p.digest {
+		return true, nil
+	}
+	p.pendingBody, p.pendingEtag = body, etag
+	return false, nil
+}
+
+// Collect parses the document served by the config URL
+func (p *HTTPConfigProvider) Collect(ctx context.Context) ([]integration.Config, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	body, etag := p.pendingBody, p.pendingEtag
+	p.pendingBody, p.pendingEtag = nil, ""
+	if body == nil {
+		var err error
+		if body, etag, _, err = p.fetch(ctx, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	configs, configErrors, err := parseHTTPConfigs(body)
+	if err != nil {
+		p.fetchFailed("parse")
+		return nil, fmt.Errorf("could not parse the configs served by %s: %s", p.url, err)
+	}
+
+	p.etag = etag
+	p.digest = sha256.Sum256(body)
+	p.configErrors = configErrors
+	if p.telemetryStore != nil {
+		p.telemetryStore.Errors.Set(float64(len(configErrors)), names.HTTP)
+		p.telemetryStore.LastFetchTimestamp.Set(float64(time.Now().Unix()), names.HTTP)
+	}
+	return configs, nil
+}
+
+// fetch downloads the document served by the config URL, notModified being true
+// when the server answers that it still matches the given etag
+func (p *HTTPConfigProvider) fetch(ctx context.Context, etag string) (body []byte, newEtag string, notModified bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	req.Header.Set("Accept", "application/yaml, application/json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	} else if p.username != "" && p.password != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		p.fetchFailed("request")
+		return nil, "", false, fmt.Errorf("could not fetch the configs from %s: %s", p.url, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, true, nil
+	case http.StatusOK:
+	default:
+		p.fetchFailed("status")
+		return nil, "", false, fmt.Errorf("could not fetch the configs from %s: unexpected status code %d", p.url, resp.StatusCode)
+	}
+
+	body, err = io.ReadAll(io.LimitReader(resp.Body, maxHTTPConfigSize+1))
+	if err != nil {
+		p.fetchFailed("request")
+		return nil, "", false, fmt.Errorf("could not read the configs from %s: %s", p.url, err)
+	}
+	if len(body) > maxHTTPConfigSize {
+		p.fetchFailed("size")
+		return nil, "", false, fmt.Errorf("the configs served by %s exceed the max size of %d bytes", p.url, maxHTTPConfigSize)
+	}
+	return body, resp.Header.Get("ETag"), false, nil
+}
+
+func (p *HTTPConfigProvider) fetchFailed(reason string) {
+	if p.telemetryStore != nil {
+		p.telemetryStore.FetchErrors.Inc(names.HTTP, reason)
+	}
+}
+
+// parseHTTPConfigs parses a document indexed by check name, returning the valid configs
+// and the errors of the invalid ones indexed by check name
+func parseHTTPConfigs(body []byte) ([]integration.Config, map[string]ErrorMsgSet, error) {
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(body, &document); err != nil {
+		return nil, nil, err
+	}
+
+	checkNames := make([]string, 0, len(document))
+	for name := range document {
+		checkNames = append(checkNames, name)
+	}
+	sort.Strings(checkNames)
+
+	configs := []integration.Config{}
+	configErrors := make(map[string]ErrorMsgSet)
+	for _, name := range checkNames {
+		var entries []interface{}
+		switch entry := document[name].(type) {
+		case []interface{}:
+			entries = entry
+		case map[interface{}]interface{}:
+			entries = []interface{}{entry}
+		default:
+			configErrors[name] = ErrorMsgSet{"the configuration must be an object or a list of objects": struct{}{}}
+			continue
+		}
+
+		for idx, entry := range entries {
+			// at this point the document was already parsed, no need to check the error
+			raw, _ := yaml.Marshal(entry)
+			conf, err := parseIntegrationConfig(name, fmt.Sprintf("%s[%d]", name, idx), raw)
+			if err != nil {
+				if _, found := configErrors[name]; !found {
+					configErrors[name] = ErrorMsgSet{}
+				}
+				configErrors[name][fmt.Sprintf("config %d: %s", idx, err)] = struct{}{}
+				continue
+			}
+			conf.Source = fmt.Sprintf("http:%s[%d]", name, idx)
+			configs = append(configs, conf)
+		}
+	}
+
+	if len(configs) == 0 && len(configErrors) > 0 {
+		log.Warnf("No valid config found in the document served to the %s config provider", names.HTTP)
+	}
+	return configs, configErrors, nil
+}
+
+// GetConfigErrors returns the errors of the configs served on the last Collect call
+func (p *HTTPConfigProvider) GetConfigErrors() map[string]ErrorMsgSet {
+	p.Lock()
+	defer p.Unlock()
+	return p.configErrors
+}
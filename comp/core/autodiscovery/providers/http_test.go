@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	acTelemetry "github.com/DataDog/datadog-agent/comp/core/autodiscovery/telemetry"
+	"github.com/DataDog/datadog-agent/comp/core/telemetry"
+	"github.com/DataDog/datadog-agent/comp/core/telemetry/telemetryimpl"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+const testHTTPConfigs = `
+redisdb:
+  ad_identifiers: [redis]
+  init_config:
+  instances:
+    - host: "%%host%%"
+http_check:
+  - instances: [{url: "https://example.com"}]
+  - instances: [{url: "https://example.org"}]
+  - init_config: {}
+broken: 42
+`
+
+func TestHTTPConfigProvider(t *testing.T) {
+	var body atomic.Value
+	body.Store(testHTTPConfigs)
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		digest := sha256.Sum256([]byte(body.Load().(string)))
+		etag := `"` + hex.EncodeToString(digest[:8]) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	telemetryStore := acTelemetry.NewStore(fxutil.Test[telemetry.Component](t, telemetryimpl.MockModule()))
+	provider, err := NewHTTPConfigProvider(&config.ConfigurationProviders{TemplateURL: server.URL, Token: "s3cr3t"}, telemetryStore)
+	require.NoError(t, err)
+	p := provider.(*HTTPConfigProvider)
+
+	configs, err := p.Collect(context.Background())
+	require.NoError(t, err)
+	require.Len(t, configs, 3)
+	assert.Equal(t, "http_check", configs[0].Name)
+	assert.Equal(t, "http:http_check[0]", configs[0].Source)
+	assert.Equal(t, "url: https://example.com\n", string(configs[0].Instances[0]))
+	assert.Equal(t, "http_check", configs[1].Name)
+	assert.Equal(t, "redisdb", configs[2].Name)
+	assert.Equal(t, []string{"redis"}, configs[2].ADIdentifiers)
+	assert.Equal(t, "host: '%%host%%'\n", string(configs[2].Instances[0]))
+
+	errors := p.GetConfigErrors()
+	assert.Len(t, errors, 2)
+	assert.Contains(t, errors["broken"], "the configuration must be an object or a list of objects")
+	assert.Contains(t, errors["http_check"], "config 2: Configuration file contains no valid instances")
+
+	// the ETag is sent back so that the server doesn't send the same document again
+	upToDate, err := p.IsUpToDate(context.Background())
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+	assert.EqualValues(t, 1, notModified.Load())
+
+	// a changed document is fetched once by IsUpToDate and parsed by Collect
+	body.Store("nginx:\n  instances: [{}]\n")
+	upToDate, err = p.IsUpToDate(context.Background())
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+	configs, err = p.Collect(context.Background())
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, "nginx", configs[0].Name)
+	assert.Empty(t, p.GetConfigErrors())
+	assert.EqualValues(t, 3, requests.Load())
+
+	body.Store("{not yaml")
+	upToDate, err = p.IsUpToDate(context.Background())
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+	_, err = p.Collect(context.Background())
+	assert.ErrorContains(t, err, "could not parse the configs served by")
+
+	p.token = "wrong"
+	_, err = p.IsUpToDate(context.Background())
+	assert.ErrorContains(t, err, "unexpected status code 401")
+}
+
+func TestHTTPConfigProviderWithoutETag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "pass", password)
+		assert.Empty(t, r.Header.Get("If-None-Match"))
+		w.Write([]byte(`{"nginx": {"instances": [{"nginx_status_url": "http://localhost/status"}]}}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewHTTPConfigProvider(&config.ConfigurationProviders{TemplateURL: server.URL, Username: "user", Password: "pass"}, nil)
+	require.NoError(t, err)
+
+	configs, err := provider.(CollectingConfigProvider).Collect(context.Background())
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, "nginx_status_url: http://localhost/status\n", string(configs[0].Instances[0]))
+
+	// the unchanged document is detected with its digest
+	upToDate, err := provider.(CollectingConfigProvider).IsUpToDate(context.Background())
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+}
+
+func TestNewHTTPConfigProvider(t *testing.T) {
+	_, err := NewHTTPConfigProvider(&config.ConfigurationProviders{TemplateURL: "ftp://example.com"}, nil)
+	assert.EqualError(t, err, "the template_url of the http config provider must be an http or https URL, got 'ftp://example.com'")
+
+	_, err = NewHTTPConfigProvider(&config.ConfigurationProviders{TemplateURL: "https://example.com", CAFile: "/does/not/exist"}, nil)
+	assert.ErrorContains(t, err, "could not read the CA file")
+}
@@ -51,6 +51,8 @@ func RegisterProviders(providerCatalog map[string]ConfigProviderFactory) {
 	RegisterProviderWithComponents(names.KubeContainer, NewContainerConfigProvider, providerCatalog)
 	RegisterProvider(names.EndpointsChecksRegisterName, NewEndpointsChecksConfigProvider, providerCatalog)
 	RegisterProvider(names.EtcdRegisterName, NewEtcdConfigProvider, providerCatalog)
+	RegisterProvider(names.GitRegisterName, NewGitConfigProvider, providerCatalog)
+	RegisterProvider(names.HTTPRegisterName, NewHTTPConfigProvider, providerCatalog)
 	RegisterProvider(names.KubeEndpointsFileRegisterName, NewKubeEndpointsFileConfigProvider, providerCatalog)
 	RegisterProvider(names.KubeEndpointsRegisterName, NewKubeEndpointsConfigProvider, providerCatalog)
 	RegisterProvider(names.KubeServicesFileRegisterName, NewKubeServiceFileConfigProvider, providerCatalog)
@@ -35,6 +35,10 @@ type Store struct {
 	Errors telemetry.Gauge
 	// PollDuration tracks the configs poll duration by AD providers.
 	PollDuration telemetry.Histogram
+	// FetchErrors tracks the number of failed fetches of remote configs by AD providers.
+	FetchErrors telemetry.Counter
+	// LastFetchTimestamp tracks the last time AD providers fetched updated remote configs.
+	LastFetchTimestamp telemetry.Gauge
 }
 
 // NewStore returns a new Store.
@@ -70,5 +74,19 @@ func NewStore(telemetryComp telemetry.Component) *Store {
 			prometheus.DefBuckets,
 			telemetry.Options{NoDoubleUnderscoreSep: true},
 		),
+		FetchErrors: telemetryComp.NewCounterWithOpts(
+			subsystem,
+			"fetch_errors",
+			[]string{"provider", "reason"},
+			"Number of failed fetches of remote configs by config provider and reason.",
+			commonOpts,
+		),
+		LastFetchTimestamp: telemetryComp.NewGaugeWithOpts(
+			subsystem,
+			"last_fetch_timestamp",
+			[]string{"provider"},
+			"Unix timestamp of the last fetch of updated remote configs by config provider.",
+			commonOpts,
+		),
 	}
 }
@@ -11,8 +11,6 @@ import (
 	"io"
 	"net/http"
 	"path/filepath"
-	"reflect"
-	"runtime"
 	"time"
 
 	"go.uber.org/fx"
@@ -126,6 +124,15 @@ func (f *flare) createAndReturnFlarePath(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// The include/exclude selectors and the encryption recipient are passed as query parameters to stay compatible
+	// with clients only sending profiles in the body.
+	query := r.URL.Query()
+	flareArgs := types.FlareArgs{
+		Include:   query["include"],
+		Exclude:   query["exclude"],
+		EncryptTo: query.Get("encrypt_to"),
+	}
+
 	// Reset the `server_timeout` deadline for this connection as creating a flare can take some time
 	conn := apiutils.GetConnection(r)
 	_ = conn.SetDeadline(time.Time{})
@@ -133,7 +140,7 @@ func (f *flare) createAndReturnFlarePath(w http.ResponseWriter, r *http.Request)
 	var filePath string
 	var err error
 	f.log.Infof("Making a flare")
-	filePath, err = f.Create(profile, nil)
+	filePath, err = f.CreateWithArgs(flareArgs, profile, nil)
 
 	if err != nil || filePath == "" {
 		if err != nil {
@@ -155,10 +162,16 @@ func (f *flare) Send(flarePath string, caseID string, email string, source helpe
 
 // Create creates a new flare and returns the path to the final archive file.
 func (f *flare) Create(pdata ProfileData, ipcError error) (string, error) {
-	fb, err := helpers.NewFlareBuilder(f.params.local)
+	return f.CreateWithArgs(types.FlareArgs{}, pdata, ipcError)
+}
+
+// CreateWithArgs creates a new flare with the given arguments and returns the path to the final archive file.
+func (f *flare) CreateWithArgs(flareArgs types.FlareArgs, pdata ProfileData, ipcError error) (string, error) {
+	fb, err := helpers.NewFlareBuilder(f.params.local, flareArgs)
 	if err != nil {
 		return "", err
 	}
+	manifestBuilder, _ := fb.(helpers.ManifestBuilder)
 
 	fb.Logf("Flare creation time: %s", time.Now().Format(time.RFC3339)) //nolint:errcheck
 	if fb.IsLocal() {
@@ -171,26 +184,50 @@ func (f *flare) Create(pdata ProfileData, ipcError error) (string, error) {
 		fb.AddFile("local", []byte(msg)) //nolint:errcheck
 	}
 
-	for name, data := range pdata {
-		fb.AddFileWithoutScrubbing(filepath.Join("profiles", name), data) //nolint:errcheck
+	providers := []namedProvider{
+		{
+			name: "flare/profiles",
+			callback: func(fb types.FlareBuilder) error {
+				for name, data := range pdata {
+					fb.AddFileWithoutScrubbing(filepath.Join("profiles", name), data) //nolint:errcheck
+				}
+				return nil
+			},
+		},
+	}
+	for _, p := range f.providers {
+		providers = append(providers, namedProvider{name: providerName(p), callback: p})
 	}
-
 	// Adding legacy and internal providers. Registering then as Provider through FX create cycle dependencies.
-	providers := append(
-		f.providers,
-		func(fb types.FlareBuilder) error {
-			return pkgFlare.CompleteFlare(fb, f.diagnoseDeps)
+	providers = append(providers,
+		namedProvider{
+			name: "flare/agent",
+			callback: func(fb types.FlareBuilder) error {
+				return pkgFlare.CompleteFlare(fb, f.diagnoseDeps)
+			},
 		},
-		f.collectLogsFiles,
-		f.collectConfigFiles,
+		namedProvider{name: "flare/logs", callback: f.collectLogsFiles},
+		namedProvider{name: "flare/config-files", callback: f.collectConfigFiles},
 	)
 
+	providers, excluded, unknownSelectors := selectProviders(providers, flareArgs)
+	for _, selector := range unknownSelectors {
+		fb.Logf("Flare provider selector '%s' didn't match any provider", selector) //nolint:errcheck
+	}
+	for _, name := range excluded {
+		fb.Logf("Flare provider '%s' was excluded", name) //nolint:errcheck
+		if manifestBuilder != nil {
+			manifestBuilder.SkipProvider(name)
+		}
+	}
+
 	for _, p := range providers {
-		err = p(fb)
+		if manifestBuilder != nil {
+			manifestBuilder.StartProvider(p.name)
+		}
+		err = p.callback(fb)
 		if err != nil {
-			f.log.Errorf("error calling '%s' for flare creation: %s",
-				runtime.FuncForPC(reflect.ValueOf(p).Pointer()).Name(), // reflect p.Callback function name
-				err)
+			f.log.Errorf("error calling '%s' (%s) for flare creation: %s", p.name, funcName(p.callback), err)
 		}
 	}
 
@@ -14,6 +14,7 @@ import (
 	api "github.com/DataDog/datadog-agent/comp/api/api/def"
 	"github.com/DataDog/datadog-agent/comp/core/flare"
 	"github.com/DataDog/datadog-agent/comp/core/flare/helpers"
+	"github.com/DataDog/datadog-agent/comp/core/flare/types"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 	"go.uber.org/fx"
 )
@@ -46,6 +47,11 @@ func (fc *MockFlare) Create(_ flare.ProfileData, _ error) (string, error) {
 	return "a string", nil
 }
 
+// CreateWithArgs mocks the flare create with args function
+func (fc *MockFlare) CreateWithArgs(_ types.FlareArgs, _ flare.ProfileData, _ error) (string, error) {
+	return "a string", nil
+}
+
 // Send mocks the flare send function
 func (fc *MockFlare) Send(_ string, _ string, _ string, _ helpers.FlareSource) (string, error) {
 	return "a string", nil
@@ -15,6 +15,8 @@ import (
 	"strings"
 	"time"
 
+	"filippo.io/age"
+
 	"github.com/DataDog/datadog-agent/comp/core/flare/types"
 	"github.com/DataDog/datadog-agent/pkg/util/archive"
 	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
@@ -30,9 +32,10 @@ const (
 
 func newBuilder(root string, hostname string, localFlare bool) (*builder, error) {
 	fb := &builder{
-		tmpDir:     root,
-		permsInfos: permissionsInfos{},
-		isLocal:    localFlare,
+		tmpDir:        root,
+		permsInfos:    permissionsInfos{},
+		isLocal:       localFlare,
+		manifestFiles: map[string]*ManifestFile{},
 	}
 
 	fb.flareDir = filepath.Join(fb.tmpDir, hostname)
@@ -55,6 +58,7 @@ func newBuilder(root string, hostname string, localFlare bool) (*builder, error)
 	// replacer for api_key.
 	otherAPIKeysRx := regexp.MustCompile(`api_key\s*:\s*[a-zA-Z0-9\\\/\^\]\[\(\){}!|%:;"~><=#@$_\-\+]{2,}`)
 	fb.scrubber.AddReplacer(scrubber.SingleLine, scrubber.Replacer{
+		Name:  "other_api_key",
 		Regex: otherAPIKeysRx,
 		ReplFunc: func(_ []byte) []byte {
 			return []byte("api_key: \"********\"")
@@ -78,7 +82,17 @@ func newBuilder(root string, hostname string, localFlare bool) (*builder, error)
 // NewFlareBuilder returns a new FlareBuilder ready to be used. You need to call the Save method to archive all the data
 // pushed to the flare as well as cleanup the temporary directories created. Not calling 'Save' after NewFlareBuilder
 // will leave temporary directory on the file system.
-func NewFlareBuilder(localFlare bool) (types.FlareBuilder, error) {
+//
+// The archive is encrypted to flareArgs.EncryptTo when set.
+func NewFlareBuilder(localFlare bool, flareArgs types.FlareArgs) (types.FlareBuilder, error) {
+	var recipient *age.X25519Recipient
+	if flareArgs.EncryptTo != "" {
+		var err error
+		if recipient, err = ParseRecipient(flareArgs.EncryptTo); err != nil {
+			return nil, err
+		}
+	}
+
 	tmpDir, err := os.MkdirTemp("", "")
 	if err != nil {
 		return nil, fmt.Errorf("Could not create temp dir for flare: %s", err)
@@ -101,7 +115,12 @@ func NewFlareBuilder(localFlare bool) (types.FlareBuilder, error) {
 		return nil, err
 	}
 
-	return newBuilder(tmpDir, hostname, localFlare)
+	fb, err := newBuilder(tmpDir, hostname, localFlare)
+	if err != nil {
+		return nil, err
+	}
+	fb.recipient = recipient
+	return fb, nil
 }
 
 // builder implements the FlareBuilder interface
@@ -119,6 +138,15 @@ type builder struct {
 	scrubber *scrubber.Scrubber
 
 	logFile *os.File
+
+	// provider is the name of the provider currently adding files to the flare
+	provider string
+	// manifestFiles records the provider and the scrubbing of the files added to the flare, by path
+	manifestFiles map[string]*ManifestFile
+	// excludedProviders are the providers excluded from the flare
+	excludedProviders []string
+	// recipient is the public key the archive is encrypted to. The archive isn't encrypted when nil.
+	recipient *age.X25519Recipient
 }
 
 func getArchiveName() string {
@@ -137,9 +165,14 @@ func getArchiveName() string {
 func (fb *builder) Save() (string, error) {
 	defer fb.clean()
 
+	fb.provider = ""
 	_ = fb.AddFileFromFunc("permissions.log", fb.permsInfos.commit)
 	_ = fb.logFile.Close()
 
+	if err := fb.writeManifest(); err != nil {
+		return "", fmt.Errorf("Could not write the flare manifest: %s", err)
+	}
+
 	archiveName := getArchiveName()
 	archiveTmpPath := filepath.Join(fb.tmpDir, archiveName)
 	archiveFinalPath := filepath.Join(os.TempDir(), archiveName)
@@ -161,6 +194,18 @@ func (fb *builder) Save() (string, error) {
 		return "", err
 	}
 
+	if fb.recipient != nil {
+		encryptedTmpPath := archiveTmpPath + EncryptedArchiveExt
+		if err := encryptFile(archiveTmpPath, encryptedTmpPath, fb.recipient); err != nil {
+			return "", fmt.Errorf("Could not encrypt the flare archive: %s", err)
+		}
+		if err := fperm.RemoveAccessToOtherUsers(encryptedTmpPath); err != nil {
+			return "", err
+		}
+		archiveTmpPath = encryptedTmpPath
+		archiveFinalPath += EncryptedArchiveExt
+	}
+
 	return archiveFinalPath, os.Rename(archiveTmpPath, archiveFinalPath)
 }
 
@@ -192,14 +237,15 @@ func (fb *builder) AddFileFromFunc(destFile string, cb func() ([]byte, error)) e
 }
 
 func (fb *builder) addFile(shouldScrub bool, destFile string, content []byte) error {
+	var replacements scrubber.Replacements
 	if shouldScrub {
 		var err error
 
 		// We use the YAML scrubber when needed. This handles nested keys, list, maps and such.
 		if strings.Contains(destFile, ".yaml") {
-			content, err = fb.scrubber.ScrubYaml(content)
+			content, replacements, err = fb.scrubber.ScrubYamlWithReport(content)
 		} else {
-			content, err = fb.scrubber.ScrubBytes(content)
+			content, replacements, err = fb.scrubber.ScrubBytesWithReport(content)
 		}
 
 		if err != nil {
@@ -215,6 +261,7 @@ func (fb *builder) addFile(shouldScrub bool, destFile string, content []byte) er
 	if err := os.WriteFile(f, content, filePerm); err != nil {
 		return fb.logError("error writing data to '%s': %s", destFile, err)
 	}
+	fb.recordScrubbing(destFile, shouldScrub, replacements)
 	return nil
 }
 
@@ -239,14 +286,15 @@ func (fb *builder) copyFileTo(shouldScrub bool, srcFile string, destFile string)
 		return fb.logError("error reading file '%s' to be copy to '%s': %s", srcFile, destFile, err)
 	}
 
+	var replacements scrubber.Replacements
 	if shouldScrub {
 		var err error
 
 		// We use the YAML scrubber when needed. This handles nested keys, list, maps and such.
 		if strings.Contains(srcFile, ".yaml") || strings.Contains(destFile, ".yaml") {
-			content, err = fb.scrubber.ScrubYaml(content)
+			content, replacements, err = fb.scrubber.ScrubYamlWithReport(content)
 		} else {
-			content, err = fb.scrubber.ScrubBytes(content)
+			content, replacements, err = fb.scrubber.ScrubBytesWithReport(content)
 		}
 		if err != nil {
 			return fb.logError("error scrubbing content for file '%s': %s", destFile, err)
@@ -257,6 +305,7 @@ func (fb *builder) copyFileTo(shouldScrub bool, srcFile string, destFile string)
 	if err != nil {
 		return fb.logError("error writing file '%s': %s", destFile, err)
 	}
+	fb.recordScrubbing(destFile, shouldScrub, replacements)
 
 	return nil
 }
@@ -313,6 +362,7 @@ func (fb *builder) PrepareFilePath(path string) (string, error) {
 	if err != nil {
 		return "", fb.logError("error creating directory for file '%s': %s", path, err)
 	}
+	fb.recordFile(path)
 	return p, nil
 }
 
@@ -6,18 +6,26 @@
 package helpers
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"filippo.io/age"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/DataDog/datadog-agent/comp/core/flare/types"
 	"github.com/DataDog/datadog-agent/pkg/util/archive"
 	"github.com/DataDog/datadog-agent/pkg/util/hostname"
 	"github.com/DataDog/datadog-agent/pkg/util/hostname/validate"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
 var FromSlash = filepath.FromSlash
@@ -49,7 +57,7 @@ func assertFileContent(t *testing.T, fb *builder, expected string, path string)
 }
 
 func getNewBuilder(t *testing.T) *builder {
-	f, err := NewFlareBuilder(false)
+	f, err := NewFlareBuilder(false, types.FlareArgs{})
 	require.NotNil(t, f)
 	require.NoError(t, err)
 
@@ -320,3 +328,71 @@ func TestRegisterDirPerm(t *testing.T) {
 		assert.Contains(t, fb.permsInfos, path)
 	}
 }
+
+func TestManifest(t *testing.T) {
+	fb := getNewBuilder(t)
+	defer fb.clean()
+
+	fb.StartProvider("core/config")
+	fb.AddFile("runtime_config_dump.yaml", []byte("api_key: aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\nsite: datadoghq.com"))
+	fb.AddFileWithoutScrubbing("version.txt", []byte("7.0.0"))
+	fb.StartProvider("core/status")
+	path, err := fb.PrepareFilePath("status.log")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("status"), filePerm))
+	fb.SkipProvider("logs/agent")
+
+	require.NoError(t, fb.writeManifest())
+
+	content, err := os.ReadFile(filepath.Join(fb.flareDir, ManifestFileName))
+	require.NoError(t, err)
+	var manifest Manifest
+	require.NoError(t, json.Unmarshal(content, &manifest))
+
+	files := map[string]ManifestFile{}
+	for _, file := range manifest.Files {
+		files[file.Path] = file
+	}
+	assert.Contains(t, files, "flare_creation.log")
+	assert.Equal(t, ManifestFile{Path: "runtime_config_dump.yaml", Provider: "core/config", Size: files["runtime_config_dump.yaml"].Size, Scrubbed: true, Replacements: scrubber.Replacements{"api_key": 1}}, files["runtime_config_dump.yaml"])
+	assert.Equal(t, ManifestFile{Path: "version.txt", Provider: "core/config", Size: 5}, files["version.txt"])
+	assert.Equal(t, ManifestFile{Path: "status.log", Provider: "core/status", Size: 6}, files["status.log"])
+	assert.Equal(t, []string{"logs/agent"}, manifest.ExcludedProviders)
+}
+
+func TestSaveEncrypted(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	f, err := NewFlareBuilder(false, types.FlareArgs{EncryptTo: identity.Recipient().String()})
+	require.NoError(t, err)
+	f.AddFile("test.data", []byte("some data"))
+
+	archivePath, err := f.Save()
+	require.NoError(t, err)
+	defer os.RemoveAll(archivePath)
+	assert.True(t, strings.HasSuffix(archivePath, ".zip"+EncryptedArchiveExt))
+	assert.NoFileExists(t, strings.TrimSuffix(archivePath, EncryptedArchiveExt))
+
+	encrypted, err := os.Open(archivePath)
+	require.NoError(t, err)
+	defer encrypted.Close()
+	r, err := age.Decrypt(encrypted, identity)
+	require.NoError(t, err)
+	decrypted, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	reader, err := zip.NewReader(bytes.NewReader(decrypted), int64(len(decrypted)))
+	require.NoError(t, err)
+	names := []string{}
+	for _, file := range reader.File {
+		names = append(names, filepath.Base(file.Name))
+	}
+	assert.Contains(t, names, "test.data")
+	assert.Contains(t, names, ManifestFileName)
+}
+
+func TestNewFlareBuilderInvalidRecipient(t *testing.T) {
+	_, err := NewFlareBuilder(false, types.FlareArgs{EncryptTo: "age1invalid"})
+	assert.ErrorContains(t, err, "invalid recipient")
+}
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package helpers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// Flare archives are encrypted with age (https://age-encryption.org) to a single X25519 recipient.
+// The result can be decrypted with any age implementation, for example: `age -d -i key.txt flare.zip.age > flare.zip`.
+
+// EncryptedArchiveExt is the extension appended to the name of encrypted flare archives
+const EncryptedArchiveExt = ".age"
+
+// ParseRecipient parses an age X25519 recipient ("age1...")
+func ParseRecipient(recipient string) (*age.X25519Recipient, error) {
+	parsed, err := age.ParseX25519Recipient(strings.TrimSpace(recipient))
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %v", recipient, err)
+	}
+	return parsed, nil
+}
+
+// encryptFile encrypts srcPath to destPath for the given recipient
+func encryptFile(srcPath string, destPath string, recipient age.Recipient) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dest, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, filePerm)
+	if err != nil {
+		return err
+	}
+
+	if err := encrypt(dest, src, recipient); err != nil {
+		dest.Close()
+		return err
+	}
+	return dest.Close()
+}
+
+// encrypt writes the age encryption of src to dest for the given recipient
+func encrypt(dest io.Writer, src io.Reader, recipient age.Recipient) error {
+	w, err := age.Encrypt(dest, recipient)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return err
+	}
+	// closing writes the last chunk
+	return w.Close()
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package helpers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1, 64 * 1024, 64*1024 + 1} {
+		t.Run(fmt.Sprintf("%d bytes", size), func(t *testing.T) {
+			plaintext := make([]byte, size)
+			_, err := rand.Read(plaintext)
+			require.NoError(t, err)
+
+			var encrypted bytes.Buffer
+			require.NoError(t, encrypt(&encrypted, bytes.NewReader(plaintext), identity.Recipient()))
+			assert.True(t, strings.HasPrefix(encrypted.String(), "age-encryption.org/v1\n-> X25519 "))
+
+			r, err := age.Decrypt(&encrypted, identity)
+			require.NoError(t, err)
+			decrypted, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+		})
+	}
+}
+
+func TestDecryptWithWrongIdentity(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	var encrypted bytes.Buffer
+	require.NoError(t, encrypt(&encrypted, strings.NewReader("some data"), identity.Recipient()))
+	_, err = age.Decrypt(&encrypted, other)
+	assert.Error(t, err)
+}
+
+func TestParseRecipient(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	recipient := identity.Recipient().String()
+
+	parsed, err := ParseRecipient(" " + recipient + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, recipient, parsed.String())
+
+	for _, recipient := range []string{
+		"",
+		"age1invalid",
+		recipient[:len(recipient)-1] + "q",
+		identity.String(),
+	} {
+		_, err := ParseRecipient(recipient)
+		assert.ErrorContains(t, err, "invalid recipient", recipient)
+	}
+}
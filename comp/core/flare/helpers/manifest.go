@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package helpers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
+)
+
+// ManifestFileName is the name of the file listing the content of a flare at the root of the archive
+const ManifestFileName = "flare_manifest.json"
+
+// Manifest lists the content of a flare archive, which provider added each file and how it was scrubbed.
+type Manifest struct {
+	Files []ManifestFile `json:"files"`
+	// ExcludedProviders are the providers that were not called because of the flare include/exclude selectors
+	ExcludedProviders []string `json:"excluded_providers,omitempty"`
+}
+
+// ManifestFile describes a file of a flare archive
+type ManifestFile struct {
+	// Path is the path of the file in the archive, relative to the hostname directory
+	Path string `json:"path"`
+	// Provider is the name of the flare provider that added the file. It is empty for the files added by the flare
+	// builder itself.
+	Provider string `json:"provider,omitempty"`
+	Size     int64  `json:"size"`
+	Scrubbed bool   `json:"scrubbed"`
+	// Replacements counts the values replaced in the file by each scrubber rule
+	Replacements scrubber.Replacements `json:"replacements,omitempty"`
+}
+
+// ManifestBuilder is implemented by the FlareBuilder returned by NewFlareBuilder. It lets the flare component attribute
+// the files of the archive to the providers adding them.
+type ManifestBuilder interface {
+	// StartProvider attributes the files added to the flare from now on to the given provider
+	StartProvider(name string)
+	// SkipProvider records that the given provider was excluded from the flare
+	SkipProvider(name string)
+}
+
+func (fb *builder) StartProvider(name string) {
+	fb.provider = name
+}
+
+func (fb *builder) SkipProvider(name string) {
+	fb.excludedProviders = append(fb.excludedProviders, name)
+}
+
+// recordFile records the provider of the given file, if not already known
+func (fb *builder) recordFile(path string) *ManifestFile {
+	path = filepath.ToSlash(filepath.Clean(path))
+	if file, ok := fb.manifestFiles[path]; ok {
+		return file
+	}
+	file := &ManifestFile{Path: path, Provider: fb.provider}
+	fb.manifestFiles[path] = file
+	return file
+}
+
+// recordScrubbing records how the given file was scrubbed
+func (fb *builder) recordScrubbing(path string, scrubbed bool, replacements scrubber.Replacements) {
+	file := fb.recordFile(path)
+	file.Scrubbed = scrubbed
+	if len(replacements) > 0 {
+		file.Replacements = replacements
+	} else {
+		file.Replacements = nil
+	}
+}
+
+// writeManifest lists every file of the flare directory in the manifest file
+func (fb *builder) writeManifest() error {
+	manifest := Manifest{
+		Files:             []ManifestFile{},
+		ExcludedProviders: fb.excludedProviders,
+	}
+
+	err := filepath.Walk(fb.flareDir, func(path string, f os.FileInfo, err error) error {
+		if err != nil || f.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(fb.flareDir, path)
+		if err != nil {
+			return err
+		}
+
+		file := ManifestFile{Path: filepath.ToSlash(rel)}
+		if recorded, ok := fb.manifestFiles[file.Path]; ok {
+			file = *recorded
+		}
+		file.Size = f.Size()
+		manifest.Files = append(manifest.Files, file)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(fb.flareDir, ManifestFileName), content, filePerm)
+}
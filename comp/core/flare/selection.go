@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package flare
+
+import (
+	"path"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/comp/core/flare/types"
+)
+
+const agentModulePath = "github.com/DataDog/datadog-agent/"
+
+// namedProvider is a flare provider with the name used to select it with the include/exclude selectors
+type namedProvider struct {
+	name     string
+	callback types.FlareCallback
+}
+
+// funcName returns the fully qualified name of the given callback
+func funcName(callback types.FlareCallback) string {
+	return runtime.FuncForPC(reflect.ValueOf(callback).Pointer()).Name()
+}
+
+// providerName returns the name of a provider from the package it comes from: the package path relative to the
+// components folder, without the implementation and internal packages. For example a provider from
+// "comp/metadata/host/hostimpl" is named "metadata/host".
+func providerName(callback types.FlareCallback) string {
+	return providerNameFromFunc(funcName(callback))
+}
+
+// providerNameFromFunc returns the provider name from the fully qualified name of its callback
+func providerNameFromFunc(name string) string {
+	// Function names look like "<package path>.<function>" where the package path can contain dots before its last
+	// slash.
+	pkgPath := name
+	lastSlash := max(strings.LastIndex(pkgPath, "/"), 0)
+	if dot := strings.Index(pkgPath[lastSlash:], "."); dot != -1 {
+		pkgPath = pkgPath[:lastSlash+dot]
+	}
+	pkgPath = strings.TrimPrefix(pkgPath, agentModulePath)
+	pkgPath = strings.TrimPrefix(pkgPath, "comp/")
+
+	elements := []string{}
+	for _, elem := range strings.Split(pkgPath, "/") {
+		if elem == "internal" || elem == "util" || elem == "def" || strings.HasSuffix(elem, "impl") || strings.HasPrefix(elem, "impl-") {
+			continue
+		}
+		elements = append(elements, elem)
+	}
+	if len(elements) == 0 {
+		return name
+	}
+	return strings.Join(elements, "/")
+}
+
+// selectorMatches returns whether the selector matches the provider name. A selector matches a provider by its full
+// name ("core/config"), one of its parents ("core") or its last element ("config").
+func selectorMatches(selector string, name string) bool {
+	selector = strings.Trim(strings.TrimSpace(selector), "/")
+	return selector != "" && (selector == name || strings.HasPrefix(name, selector+"/") || path.Base(name) == selector)
+}
+
+// selectProviders splits the providers between the ones to call and the ones excluded by the flare args selectors. It
+// also returns the selectors that didn't match any provider.
+func selectProviders(providers []namedProvider, flareArgs types.FlareArgs) (selected []namedProvider, excluded []string, unknownSelectors []string) {
+	matchesAny := func(selectors []string, name string) bool {
+		return slices.ContainsFunc(selectors, func(selector string) bool { return selectorMatches(selector, name) })
+	}
+
+	for _, p := range providers {
+		if (len(flareArgs.Include) > 0 && !matchesAny(flareArgs.Include, p.name)) || matchesAny(flareArgs.Exclude, p.name) {
+			if !slices.Contains(excluded, p.name) {
+				excluded = append(excluded, p.name)
+			}
+			continue
+		}
+		selected = append(selected, p)
+	}
+
+	for _, selector := range append(slices.Clone(flareArgs.Include), flareArgs.Exclude...) {
+		if !slices.ContainsFunc(providers, func(p namedProvider) bool { return selectorMatches(selector, p.name) }) {
+			unknownSelectors = append(unknownSelectors, selector)
+		}
+	}
+	return selected, excluded, unknownSelectors
+}
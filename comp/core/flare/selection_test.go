@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package flare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/comp/core/flare/types"
+)
+
+func TestProviderName(t *testing.T) {
+	assert.Equal(t, "core/flare", providerName(func(_ types.FlareBuilder) error { return nil }))
+
+	for funcName, expected := range map[string]string{
+		"github.com/DataDog/datadog-agent/comp/metadata/host/hostimpl.(*host).fillFlare-fm":                 "metadata/host",
+		"github.com/DataDog/datadog-agent/comp/metadata/internal/util.(*InventoryPayload).fillFlare-fm":     "metadata",
+		"github.com/DataDog/datadog-agent/comp/core/workloadmeta/impl.(*workloadmeta).sbomFlareProvider-fm": "core/workloadmeta",
+		"github.com/DataDog/datadog-agent/comp/otelcol/collector/impl-pipeline.(*collector).fillFlare-fm":   "otelcol/collector",
+		"github.com/DataDog/datadog-agent/pkg/gohai.flareProvider":                                          "pkg/gohai",
+		"main.provider.func1": "main",
+	} {
+		assert.Equal(t, expected, providerNameFromFunc(funcName), funcName)
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	assert.True(t, selectorMatches("core/config", "core/config"))
+	assert.True(t, selectorMatches("core", "core/config"))
+	assert.True(t, selectorMatches("config", "core/config"))
+	assert.True(t, selectorMatches(" /core/ ", "core/config"))
+	assert.False(t, selectorMatches("co", "core/config"))
+	assert.False(t, selectorMatches("", "core/config"))
+	assert.False(t, selectorMatches("core/status", "core/config"))
+}
+
+func TestSelectProviders(t *testing.T) {
+	providers := []namedProvider{
+		{name: "flare/profiles"},
+		{name: "core/config"},
+		{name: "core/status"},
+		{name: "logs/agent"},
+		{name: "flare/logs"},
+	}
+	names := func(providers []namedProvider) []string {
+		res := []string{}
+		for _, p := range providers {
+			res = append(res, p.name)
+		}
+		return res
+	}
+
+	selected, excluded, unknown := selectProviders(providers, types.FlareArgs{})
+	assert.Equal(t, names(providers), names(selected))
+	assert.Empty(t, excluded)
+	assert.Empty(t, unknown)
+
+	selected, excluded, unknown = selectProviders(providers, types.FlareArgs{Include: []string{"core", "logs"}, Exclude: []string{"status", "network"}})
+	assert.Equal(t, []string{"core/config", "logs/agent", "flare/logs"}, names(selected))
+	assert.Equal(t, []string{"flare/profiles", "core/status"}, excluded)
+	assert.Equal(t, []string{"network"}, unknown)
+}
@@ -33,3 +33,15 @@ func NewProvider(callback flarebuilder.FlareCallback) Provider {
 		Callback: callback,
 	}
 }
+
+// FlareArgs contains the arguments used to create a flare
+type FlareArgs struct {
+	// Include restricts the flare to the providers matching one of these selectors. Every provider is included
+	// when empty.
+	Include []string
+	// Exclude removes the providers matching one of these selectors from the flare
+	Exclude []string
+	// EncryptTo is the age recipient ("age1...") the archive is encrypted to.
+	// The archive isn't encrypted when empty.
+	EncryptTo string
+}
@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secrets
+
+import "context"
+
+// Backend fetches secrets natively from the Agent process, without running a secret_backend_command.
+type Backend interface {
+	// GetSecrets returns the value, or the error message, of each requested handle
+	GetSecrets(ctx context.Context, handles []string) map[string]SecretVal
+}
+
+// BackendFactory creates a Backend from the content of `secret_backend_config`
+type BackendFactory func(config map[string]interface{}) (Backend, error)
@@ -23,6 +23,10 @@ type ConfigParams struct {
 	RemoveLinebreak  bool
 	RunPath          string
 	AuditFileMaxSize int
+	// Type selects a native secret backend, used instead of Command when set
+	Type string
+	// Config holds the settings of the native secret backend
+	Config map[string]interface{}
 }
 
 // Component is the component type.
@@ -35,6 +39,9 @@ type Component interface {
 	Resolve(data []byte, origin string) ([]byte, error)
 	// SubscribeToChanges registers a callback to be invoked whenever secrets are resolved or refreshed
 	SubscribeToChanges(callback SecretChangeCallback)
+	// SubscribeToRefresh registers a callback to be invoked with the handles whose value changed when secrets are
+	// refreshed, wherever the handles are used
+	SubscribeToRefresh(callback SecretRefreshCallback)
 	// Refresh will resolve secret handles again, notifying any subscribers of changed values
 	Refresh() (string, error)
 }
@@ -0,0 +1,230 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+var (
+	backendFactoriesLock sync.Mutex
+	backendFactories     = map[string]secrets.BackendFactory{
+		"directory":  newDirectoryBackend,
+		"json":       newJSONFileBackend,
+		"yaml":       newYAMLFileBackend,
+		"vault":      newVaultBackend,
+		"kubernetes": newKubernetesBackend,
+	}
+)
+
+// RegisterBackend makes a native secret backend available under the given `secret_backend_type`.
+// It must be called before the secrets component is configured, typically from an init function.
+func RegisterBackend(backendType string, factory secrets.BackendFactory) {
+	backendFactoriesLock.Lock()
+	defer backendFactoriesLock.Unlock()
+	backendFactories[backendType] = factory
+}
+
+// registeredBackends returns the sorted list of the available backend types
+func registeredBackends() []string {
+	backendFactoriesLock.Lock()
+	defer backendFactoriesLock.Unlock()
+	types := make([]string, 0, len(backendFactories))
+	for backendType := range backendFactories {
+		types = append(types, backendType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// newBackend creates the native backend of the given type, wrapped in a cache when `cache_ttl` is set
+func newBackend(backendType string, config map[string]interface{}) (secrets.Backend, error) {
+	backendFactoriesLock.Lock()
+	factory, ok := backendFactories[backendType]
+	backendFactoriesLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown secret_backend_type '%s', available types are: %s", backendType, strings.Join(registeredBackends(), ", "))
+	}
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+
+	backend, err := factory(config)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the '%s' secret backend: %s", backendType, err)
+	}
+
+	ttl, err := configInt(config, "cache_ttl", 0)
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		backend = newCachingBackend(backend, time.Duration(ttl)*time.Second)
+	}
+	return backend, nil
+}
+
+type cachedSecret struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// cachingBackend keeps the secrets returned by a backend for a given time so that refreshing
+// secrets more often than the TTL doesn't query the backend
+type cachingBackend struct {
+	backend secrets.Backend
+	ttl     time.Duration
+	now     func() time.Time
+
+	lock  sync.Mutex
+	cache map[string]cachedSecret
+}
+
+func newCachingBackend(backend secrets.Backend, ttl time.Duration) *cachingBackend {
+	return &cachingBackend{
+		backend: backend,
+		ttl:     ttl,
+		now:     time.Now,
+		cache:   map[string]cachedSecret{},
+	}
+}
+
+// GetSecrets returns the cached value of the handles fetched less than a TTL ago and fetches the others
+func (c *cachingBackend) GetSecrets(ctx context.Context, handles []string) map[string]secrets.SecretVal {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	res := make(map[string]secrets.SecretVal, len(handles))
+	var expired []string
+	for _, handle := range handles {
+		if cached, ok := c.cache[handle]; ok && now.Sub(cached.fetchedAt) < c.ttl {
+			res[handle] = secrets.SecretVal{Value: cached.value}
+			continue
+		}
+		expired = append(expired, handle)
+	}
+	if len(expired) == 0 {
+		return res
+	}
+
+	for handle, secret := range c.backend.GetSecrets(ctx, expired) {
+		if secret.ErrorMsg == "" {
+			c.cache[handle] = cachedSecret{value: secret.Value, fetchedAt: now}
+		}
+		res[handle] = secret
+	}
+	return res
+}
+
+// fetchSecretFromBackend fetches the given handles from the native backend, with the same checks
+// as the ones applied to the output of a secret_backend_command
+func (r *secretResolver) fetchSecretFromBackend(secretsHandle []string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.backendTimeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	secretValues := r.backend.GetSecrets(ctx, secretsHandle)
+	elapsed := time.Since(start)
+
+	status := "0"
+	if ctx.Err() == context.DeadlineExceeded {
+		status = "timeout"
+	}
+	r.tlmSecretBackendElapsed.Add(float64(elapsed.Milliseconds()), r.backendType, status)
+	if status == "timeout" {
+		return nil, fmt.Errorf("error while fetching secrets from the '%s' secret backend: timeout", r.backendType)
+	}
+
+	res := map[string]string{}
+	for _, sec := range secretsHandle {
+		v, ok := secretValues[sec]
+		if !ok {
+			r.tlmSecretResolveError.Inc("missing", sec)
+			return nil, fmt.Errorf("secret handle '%s' was not resolved by the '%s' secret backend", sec, r.backendType)
+		}
+
+		if v.ErrorMsg != "" {
+			r.tlmSecretResolveError.Inc("error", sec)
+			return nil, fmt.Errorf("an error occurred while resolving '%s': %s", sec, v.ErrorMsg)
+		}
+
+		if r.removeTrailingLinebreak {
+			v.Value = strings.TrimRight(v.Value, "\r\n")
+		}
+
+		if v.Value == "" {
+			r.tlmSecretResolveError.Inc("empty", sec)
+			return nil, fmt.Errorf("resolved secret for '%s' is empty", sec)
+		}
+		res[sec] = v.Value
+	}
+	return res, nil
+}
+
+// configString returns the string setting of a backend configuration
+func configString(config map[string]interface{}, key string, defaultValue string) (string, error) {
+	value, ok := config[key]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("'%s' must be a string, not %T", key, value)
+	}
+	return str, nil
+}
+
+// configInt returns the integer setting of a backend configuration, accepting strings for
+// values coming from environment variables
+func configInt(config map[string]interface{}, key string, defaultValue int) (int, error) {
+	value, ok := config[key]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case string:
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' must be an integer: %s", key, err)
+		}
+		return i, nil
+	}
+	return 0, fmt.Errorf("'%s' must be an integer, not %T", key, value)
+}
+
+// configBool returns the boolean setting of a backend configuration
+func configBool(config map[string]interface{}, key string, defaultValue bool) (bool, error) {
+	value, ok := config[key]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("'%s' must be a boolean: %s", key, err)
+		}
+		return b, nil
+	}
+	return false, fmt.Errorf("'%s' must be a boolean, not %T", key, value)
+}
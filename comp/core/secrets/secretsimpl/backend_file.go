@@ -0,0 +1,196 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+const (
+	// maxSecretFileSize is the maximum size of a file read by the directory backend
+	maxSecretFileSize = 8192
+	// maxSecretsFileSize is the maximum size of a JSON or YAML file holding several secrets
+	maxSecretsFileSize = 1024 * 1024
+)
+
+// directoryBackend reads each secret from its own file in a directory, the handle being the path
+// of the file relative to the directory. This matches how Kubernetes and Docker mount secrets.
+type directoryBackend struct {
+	root string
+}
+
+func newDirectoryBackend(config map[string]interface{}) (secrets.Backend, error) {
+	root, err := configString(config, "path", "")
+	if err != nil {
+		return nil, err
+	}
+	if root == "" {
+		return nil, errors.New("'path' is required")
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	return &directoryBackend{root: root}, nil
+}
+
+// GetSecrets reads the file of each handle
+func (b *directoryBackend) GetSecrets(_ context.Context, handles []string) map[string]secrets.SecretVal {
+	res := make(map[string]secrets.SecretVal, len(handles))
+	for _, handle := range handles {
+		value, err := b.readSecret(handle)
+		if err != nil {
+			res[handle] = secrets.SecretVal{ErrorMsg: err.Error()}
+			continue
+		}
+		res[handle] = secrets.SecretVal{Value: value}
+	}
+	return res
+}
+
+func (b *directoryBackend) readSecret(handle string) (string, error) {
+	path := filepath.Join(b.root, filepath.FromSlash(handle))
+	if !isInDirectory(path, b.root) {
+		return "", fmt.Errorf("secret '%s' is outside of '%s'", handle, b.root)
+	}
+
+	// Kubernetes mounts secrets as symlinks to allow atomic updates, follow them as long as
+	// they stay in the directory
+	root, err := filepath.EvalSymlinks(b.root)
+	if err != nil {
+		return "", err
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errors.New("secret does not exist")
+		}
+		return "", err
+	}
+	if !isInDirectory(target, root) {
+		return "", fmt.Errorf("not following symlink '%s' outside of '%s'", target, b.root)
+	}
+
+	content, err := readFileWithLimit(target, maxSecretFileSize)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func isInDirectory(path, dir string) bool {
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+func readFileWithLimit(path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("'%s' exceeds the max allowed size of %d bytes", path, limit)
+	}
+	return content, nil
+}
+
+// structuredFileBackend reads all the secrets from a single JSON or YAML file. The handle is the
+// key of the secret, nested keys being separated by '/'.
+type structuredFileBackend struct {
+	path      string
+	unmarshal func([]byte, interface{}) error
+}
+
+func newJSONFileBackend(config map[string]interface{}) (secrets.Backend, error) {
+	return newStructuredFileBackend(config, json.Unmarshal)
+}
+
+func newYAMLFileBackend(config map[string]interface{}) (secrets.Backend, error) {
+	return newStructuredFileBackend(config, yaml.Unmarshal)
+}
+
+func newStructuredFileBackend(config map[string]interface{}, unmarshal func([]byte, interface{}) error) (secrets.Backend, error) {
+	path, err := configString(config, "path", "")
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return nil, errors.New("'path' is required")
+	}
+	return &structuredFileBackend{path: path, unmarshal: unmarshal}, nil
+}
+
+// GetSecrets reads the file once and looks up every handle in it
+func (b *structuredFileBackend) GetSecrets(_ context.Context, handles []string) map[string]secrets.SecretVal {
+	res := make(map[string]secrets.SecretVal, len(handles))
+
+	var content interface{}
+	raw, err := readFileWithLimit(b.path, maxSecretsFileSize)
+	if err == nil {
+		err = b.unmarshal(raw, &content)
+	}
+	if err != nil {
+		for _, handle := range handles {
+			res[handle] = secrets.SecretVal{ErrorMsg: fmt.Sprintf("could not read '%s': %s", b.path, err)}
+		}
+		return res
+	}
+
+	for _, handle := range handles {
+		value, err := lookupSecret(content, strings.Split(handle, "/"))
+		if err != nil {
+			res[handle] = secrets.SecretVal{ErrorMsg: err.Error()}
+			continue
+		}
+		res[handle] = secrets.SecretVal{Value: value}
+	}
+	return res
+}
+
+// lookupSecret walks the keys of a decoded JSON or YAML document and returns the scalar value found
+func lookupSecret(content interface{}, keys []string) (string, error) {
+	for i, key := range keys {
+		var value interface{}
+		var found bool
+		switch node := content.(type) {
+		case map[string]interface{}:
+			value, found = node[key]
+		case map[interface{}]interface{}:
+			value, found = node[key]
+		}
+		if !found {
+			return "", fmt.Errorf("secret '%s' does not exist", strings.Join(keys[:i+1], "/"))
+		}
+		content = value
+	}
+
+	switch value := content.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+		return "", fmt.Errorf("secret '%s' is not a scalar value", strings.Join(keys, "/"))
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// kubernetesBackend reads Kubernetes Secrets from the API server, the handle having the
+// '<namespace>/<name>/<key>' format used by the secret helper
+type kubernetesBackend struct {
+	apiServer string
+	tokenFile string
+	client    *http.Client
+}
+
+func newKubernetesBackend(config map[string]interface{}) (secrets.Backend, error) {
+	defaultAPIServer := ""
+	if host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"); host != "" && port != "" {
+		defaultAPIServer = "https://" + host + ":" + port
+	}
+
+	var errs []error
+	str := func(key, defaultValue string) string {
+		value, err := configString(config, key, defaultValue)
+		errs = append(errs, err)
+		return value
+	}
+	b := &kubernetesBackend{
+		apiServer: strings.TrimSuffix(str("api_server", defaultAPIServer), "/"),
+		tokenFile: str("service_account_token_file", defaultServiceAccountTokenPath),
+	}
+	caFile := str("tls_ca_file", defaultServiceAccountCAPath)
+	skipVerify, err := configBool(config, "tls_skip_verify", false)
+	errs = append(errs, err)
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if b.apiServer == "" {
+		return nil, errors.New("'api_server' is required when not running in a Kubernetes pod")
+	}
+	if _, err := os.Stat(caFile); err != nil {
+		// outside of a pod, rely on the system CAs
+		caFile = ""
+	}
+	b.client, err = newBackendHTTPClient(caFile, skipVerify)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetSecrets reads each Kubernetes Secret once, whatever the number of handles pointing to its keys
+func (b *kubernetesBackend) GetSecrets(ctx context.Context, handles []string) map[string]secrets.SecretVal {
+	res := make(map[string]secrets.SecretVal, len(handles))
+
+	token, err := readFileWithLimit(b.tokenFile, maxSecretFileSize)
+	if err != nil {
+		for _, handle := range handles {
+			res[handle] = secrets.SecretVal{ErrorMsg: fmt.Sprintf("could not read the service account token: %s", err)}
+		}
+		return res
+	}
+
+	secretsData := map[string]map[string]string{}
+	secretsErrors := map[string]error{}
+	for _, handle := range handles {
+		parts := strings.Split(handle, "/")
+		if len(parts) != 3 {
+			res[handle] = secrets.SecretVal{ErrorMsg: "invalid format, use '<namespace>/<name>/<key>'"}
+			continue
+		}
+		id := parts[0] + "/" + parts[1]
+		if _, fetched := secretsData[id]; !fetched && secretsErrors[id] == nil {
+			secretsData[id], secretsErrors[id] = b.readSecret(ctx, strings.TrimSpace(string(token)), parts[0], parts[1])
+		}
+		if err := secretsErrors[id]; err != nil {
+			res[handle] = secrets.SecretVal{ErrorMsg: err.Error()}
+			continue
+		}
+		encoded, ok := secretsData[id][parts[2]]
+		if !ok {
+			res[handle] = secrets.SecretVal{ErrorMsg: fmt.Sprintf("key '%s' not found in secret '%s'", parts[2], id)}
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			res[handle] = secrets.SecretVal{ErrorMsg: fmt.Sprintf("could not decode key '%s' of secret '%s': %s", parts[2], id, err)}
+			continue
+		}
+		res[handle] = secrets.SecretVal{Value: string(value)}
+	}
+	return res
+}
+
+// readSecret returns the base64 encoded data of a Kubernetes Secret
+func (b *kubernetesBackend) readSecret(ctx context.Context, token, namespace, name string) (map[string]string, error) {
+	u := fmt.Sprintf("%s/api/v1/namespaces/%s/secrets/%s", b.apiServer, url.PathEscape(namespace), url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	var secret struct {
+		Data map[string]string `json:"data"`
+	}
+	if _, err := doJSONRequest(b.client, req, &secret); err != nil {
+		return nil, fmt.Errorf("could not read secret '%s/%s': %s", namespace, name, err)
+	}
+	return secret.Data, nil
+}
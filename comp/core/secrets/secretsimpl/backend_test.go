@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/comp/core/telemetry"
+	nooptelemetry "github.com/DataDog/datadog-agent/comp/core/telemetry/noopsimpl"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestDirectoryBackend(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "db"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api_key"), []byte("123456"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db", "password"), []byte("pass"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "big"), []byte(strings.Repeat("a", maxSecretFileSize+1)), 0600))
+	outside := filepath.Join(t.TempDir(), "outside")
+	require.NoError(t, os.WriteFile(outside, []byte("nope"), 0600))
+	if runtime.GOOS != "windows" {
+		require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+	}
+
+	_, err := newDirectoryBackend(map[string]interface{}{})
+	require.Error(t, err)
+
+	b, err := newDirectoryBackend(map[string]interface{}{"path": dir})
+	require.NoError(t, err)
+	res := b.GetSecrets(context.Background(), []string{"api_key", "db/password", "missing", "../outside", "big"})
+	assert.Equal(t, secrets.SecretVal{Value: "123456"}, res["api_key"])
+	assert.Equal(t, secrets.SecretVal{Value: "pass"}, res["db/password"])
+	assert.Equal(t, "secret does not exist", res["missing"].ErrorMsg)
+	assert.Contains(t, res["../outside"].ErrorMsg, "is outside of")
+	assert.Contains(t, res["big"].ErrorMsg, "exceeds the max allowed size")
+
+	if runtime.GOOS != "windows" {
+		res = b.GetSecrets(context.Background(), []string{"link"})
+		assert.Contains(t, res["link"].ErrorMsg, "not following symlink")
+	}
+}
+
+func TestStructuredFileBackends(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "secrets.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"api_key": "123456", "db": {"password": "pass", "port": 5432, "hosts": ["a"]}}`), 0600))
+	yamlPath := filepath.Join(dir, "secrets.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("api_key: '123456'\ndb:\n  password: pass\n  port: 5432\n  hosts: [a]\n"), 0600))
+
+	for name, backend := range map[string]struct {
+		factory secrets.BackendFactory
+		path    string
+	}{
+		"json": {newJSONFileBackend, jsonPath},
+		"yaml": {newYAMLFileBackend, yamlPath},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b, err := backend.factory(map[string]interface{}{"path": backend.path})
+			require.NoError(t, err)
+			res := b.GetSecrets(context.Background(), []string{"api_key", "db/password", "db/port", "db/hosts", "db/user"})
+			assert.Equal(t, "123456", res["api_key"].Value)
+			assert.Equal(t, "pass", res["db/password"].Value)
+			assert.Equal(t, "5432", res["db/port"].Value)
+			assert.Equal(t, "secret 'db/hosts' is not a scalar value", res["db/hosts"].ErrorMsg)
+			assert.Equal(t, "secret 'db/user' does not exist", res["db/user"].ErrorMsg)
+		})
+	}
+
+	b, err := newJSONFileBackend(map[string]interface{}{"path": filepath.Join(dir, "missing.json")})
+	require.NoError(t, err)
+	res := b.GetSecrets(context.Background(), []string{"api_key"})
+	assert.Contains(t, res["api_key"].ErrorMsg, "could not read")
+}
+
+type countingBackend struct {
+	calls [][]string
+}
+
+func (b *countingBackend) GetSecrets(_ context.Context, handles []string) map[string]secrets.SecretVal {
+	b.calls = append(b.calls, handles)
+	res := map[string]secrets.SecretVal{}
+	for _, handle := range handles {
+		if handle == "broken" {
+			res[handle] = secrets.SecretVal{ErrorMsg: "broken"}
+			continue
+		}
+		res[handle] = secrets.SecretVal{Value: handle + "_value"}
+	}
+	return res
+}
+
+func TestCachingBackend(t *testing.T) {
+	backend := &countingBackend{}
+	c := newCachingBackend(backend, time.Minute)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	res := c.GetSecrets(context.Background(), []string{"a", "broken"})
+	assert.Equal(t, "a_value", res["a"].Value)
+	assert.Equal(t, "broken", res["broken"].ErrorMsg)
+
+	// cached values aren't fetched again, errors are
+	now = now.Add(30 * time.Second)
+	res = c.GetSecrets(context.Background(), []string{"a", "b", "broken"})
+	assert.Equal(t, "a_value", res["a"].Value)
+	assert.Equal(t, "b_value", res["b"].Value)
+	assert.Equal(t, [][]string{{"a", "broken"}, {"b", "broken"}}, backend.calls)
+
+	// expired values are fetched again
+	now = now.Add(45 * time.Second)
+	c.GetSecrets(context.Background(), []string{"a", "b"})
+	assert.Equal(t, []string{"a"}, backend.calls[2])
+}
+
+func TestNewBackend(t *testing.T) {
+	_, err := newBackend("unknown", nil)
+	require.EqualError(t, err, "unknown secret_backend_type 'unknown', available types are: directory, json, kubernetes, vault, yaml")
+
+	_, err = newBackend("directory", map[string]interface{}{"path": 1})
+	require.EqualError(t, err, "could not create the 'directory' secret backend: 'path' must be a string, not int")
+
+	b, err := newBackend("directory", map[string]interface{}{"path": t.TempDir(), "cache_ttl": "60"})
+	require.NoError(t, err)
+	assert.IsType(t, &cachingBackend{}, b)
+
+	RegisterBackend("test_counting", func(map[string]interface{}) (secrets.Backend, error) { return &countingBackend{}, nil })
+	defer func() {
+		backendFactoriesLock.Lock()
+		delete(backendFactories, "test_counting")
+		backendFactoriesLock.Unlock()
+	}()
+	b, err = newBackend("test_counting", nil)
+	require.NoError(t, err)
+	assert.IsType(t, &countingBackend{}, b)
+}
+
+func TestResolveWithNativeBackend(t *testing.T) {
+	tel := fxutil.Test[telemetry.Component](t, nooptelemetry.Module())
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api_key"), []byte("123456\n"), 0600))
+
+	resolver := newEnabledSecretResolver(tel)
+	resolver.Configure(secrets.ConfigParams{
+		Type:            "directory",
+		Config:          map[string]interface{}{"path": dir, "cache_ttl": 300},
+		RemoveLinebreak: true,
+	})
+	assert.Equal(t, 300*time.Second, resolver.refreshInterval)
+
+	resolved, err := resolver.Resolve([]byte("api_key: ENC[api_key]\n"), "test")
+	require.NoError(t, err)
+	assert.Equal(t, "api_key: \"123456\"\n", string(resolved))
+
+	_, err = resolver.Resolve([]byte("password: ENC[missing]\n"), "test")
+	require.EqualError(t, err, "an error occurred while resolving 'missing': secret does not exist")
+
+	var buf strings.Builder
+	resolver.GetDebugInfo(&buf)
+	assert.Contains(t, buf.String(), "Backend type: directory\nBackend status: OK\n")
+	assert.Contains(t, buf.String(), "- 'api_key':\n\tused in 'test' configuration in entry 'api_key'")
+
+	resolver.Configure(secrets.ConfigParams{Type: "directory"})
+	_, err = resolver.Resolve([]byte("password: ENC[other]\n"), "test")
+	require.EqualError(t, err, "could not create the 'directory' secret backend: 'path' is required")
+	buf.Reset()
+	resolver.GetDebugInfo(&buf)
+	assert.Contains(t, buf.String(), "Backend status: error: could not create the 'directory' secret backend: 'path' is required")
+}
@@ -0,0 +1,341 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+const (
+	defaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	defaultServiceAccountCAPath    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+	// vaultHandleKeySeparator separates the path of a Vault secret from the key to read in it,
+	// for example 'datadog/agent#api_key'
+	vaultHandleKeySeparator = "#"
+	// vaultDefaultKey is the key read when the handle doesn't have one
+	vaultDefaultKey = "value"
+)
+
+// vaultBackend reads secrets from a Vault KV secrets engine, version 1 or 2
+type vaultBackend struct {
+	address    string
+	namespace  string
+	mount      string
+	kvVersion  int
+	authMethod string
+	authMount  string
+	client     *http.Client
+
+	// token auth
+	token     string
+	tokenFile string
+	// approle auth
+	roleID       string
+	secretID     string
+	secretIDFile string
+	// kubernetes auth
+	role                    string
+	serviceAccountTokenFile string
+
+	lock        sync.Mutex
+	clientToken string
+	tokenExpiry time.Time
+	now         func() time.Time
+}
+
+func newVaultBackend(config map[string]interface{}) (secrets.Backend, error) {
+	var errs []error
+	str := func(key, defaultValue string) string {
+		value, err := configString(config, key, defaultValue)
+		errs = append(errs, err)
+		return value
+	}
+
+	b := &vaultBackend{
+		address:                 strings.TrimSuffix(str("address", os.Getenv("VAULT_ADDR")), "/"),
+		namespace:               str("namespace", os.Getenv("VAULT_NAMESPACE")),
+		mount:                   strings.Trim(str("mount", "secret"), "/"),
+		authMethod:              str("auth_method", "token"),
+		token:                   str("token", os.Getenv("VAULT_TOKEN")),
+		tokenFile:               str("token_file", ""),
+		roleID:                  str("role_id", ""),
+		secretID:                str("secret_id", ""),
+		secretIDFile:            str("secret_id_file", ""),
+		role:                    str("role", ""),
+		serviceAccountTokenFile: str("service_account_token_file", defaultServiceAccountTokenPath),
+		now:                     time.Now,
+	}
+	b.authMount = strings.Trim(str("auth_mount", b.authMethod), "/")
+	caFile := str("tls_ca_file", "")
+
+	kvVersion, err := configInt(config, "kv_version", 2)
+	errs = append(errs, err)
+	skipVerify, err := configBool(config, "tls_skip_verify", false)
+	errs = append(errs, err)
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if b.address == "" {
+		return nil, errors.New("'address' is required")
+	}
+	if kvVersion != 1 && kvVersion != 2 {
+		return nil, fmt.Errorf("unsupported 'kv_version' %d, must be 1 or 2", kvVersion)
+	}
+	b.kvVersion = kvVersion
+
+	switch b.authMethod {
+	case "token":
+		if b.token == "" && b.tokenFile == "" {
+			return nil, errors.New("'token' or 'token_file' is required with the token auth method")
+		}
+	case "approle":
+		if b.roleID == "" || (b.secretID == "" && b.secretIDFile == "") {
+			return nil, errors.New("'role_id' and 'secret_id' or 'secret_id_file' are required with the approle auth method")
+		}
+	case "kubernetes":
+		if b.role == "" {
+			return nil, errors.New("'role' is required with the kubernetes auth method")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported 'auth_method' '%s', must be one of token, approle or kubernetes", b.authMethod)
+	}
+
+	b.client, err = newBackendHTTPClient(caFile, skipVerify)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// newBackendHTTPClient returns an HTTP client trusting the given CA in addition to the system ones
+func newBackendHTTPClient(caFile string, skipVerify bool) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: skipVerify,
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the CA file: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in '%s'", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
+
+// GetSecrets reads each Vault secret once, whatever the number of handles pointing to its keys
+func (b *vaultBackend) GetSecrets(ctx context.Context, handles []string) map[string]secrets.SecretVal {
+	res := make(map[string]secrets.SecretVal, len(handles))
+
+	handlesByPath := map[string][]string{}
+	for _, handle := range handles {
+		path, _, _ := strings.Cut(handle, vaultHandleKeySeparator)
+		handlesByPath[path] = append(handlesByPath[path], handle)
+	}
+
+	for path, pathHandles := range handlesByPath {
+		data, err := b.readSecret(ctx, path)
+		for _, handle := range pathHandles {
+			if err != nil {
+				res[handle] = secrets.SecretVal{ErrorMsg: err.Error()}
+				continue
+			}
+			_, key, found := strings.Cut(handle, vaultHandleKeySeparator)
+			if !found {
+				key = vaultDefaultKey
+			}
+			value, err := lookupSecret(data, []string{key})
+			if err != nil {
+				res[handle] = secrets.SecretVal{ErrorMsg: fmt.Sprintf("key '%s' not found in Vault secret '%s'", key, path)}
+				continue
+			}
+			res[handle] = secrets.SecretVal{Value: value}
+		}
+	}
+	return res
+}
+
+// readSecret returns the key/value pairs of a secret, logging in again once if the token was revoked
+func (b *vaultBackend) readSecret(ctx context.Context, path string) (map[string]interface{}, error) {
+	path = strings.Trim(path, "/")
+	url := fmt.Sprintf("%s/v1/%s/%s", b.address, b.mount, path)
+	if b.kvVersion == 2 {
+		url = fmt.Sprintf("%s/v1/%s/data/%s", b.address, b.mount, path)
+	}
+
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	for attempt := 0; ; attempt++ {
+		token, err := b.getToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		status, err := b.do(ctx, http.MethodGet, url, token, nil, &resp)
+		if status == http.StatusForbidden && attempt == 0 && b.authMethod != "token" {
+			b.resetToken()
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read Vault secret '%s': %s", path, err)
+		}
+		break
+	}
+
+	if b.kvVersion == 1 {
+		return resp.Data, nil
+	}
+	data, ok := resp.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Vault secret '%s' has no data, is it a KV version 2 engine?", path)
+	}
+	return data, nil
+}
+
+func (b *vaultBackend) resetToken() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.clientToken = ""
+}
+
+// getToken returns the token to use, logging in with the configured auth method when needed
+func (b *vaultBackend) getToken(ctx context.Context) (string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.authMethod == "token" {
+		if b.tokenFile == "" {
+			return b.token, nil
+		}
+		// the token file can be rotated by an external agent, always read it
+		token, err := readFileWithLimit(b.tokenFile, maxSecretFileSize)
+		if err != nil {
+			return "", fmt.Errorf("could not read the Vault token: %s", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+
+	if b.clientToken != "" && (b.tokenExpiry.IsZero() || b.now().Before(b.tokenExpiry)) {
+		return b.clientToken, nil
+	}
+
+	payload := map[string]string{}
+	switch b.authMethod {
+	case "approle":
+		payload["role_id"] = b.roleID
+		payload["secret_id"] = b.secretID
+		if b.secretIDFile != "" {
+			secretID, err := readFileWithLimit(b.secretIDFile, maxSecretFileSize)
+			if err != nil {
+				return "", fmt.Errorf("could not read the Vault secret ID: %s", err)
+			}
+			payload["secret_id"] = strings.TrimSpace(string(secretID))
+		}
+	case "kubernetes":
+		jwt, err := readFileWithLimit(b.serviceAccountTokenFile, maxSecretFileSize)
+		if err != nil {
+			return "", fmt.Errorf("could not read the service account token: %s", err)
+		}
+		payload["role"] = b.role
+		payload["jwt"] = strings.TrimSpace(string(jwt))
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	url := fmt.Sprintf("%s/v1/auth/%s/login", b.address, b.authMount)
+	if _, err := b.do(ctx, http.MethodPost, url, "", payload, &resp); err != nil {
+		return "", fmt.Errorf("could not log in to Vault with the %s auth method: %s", b.authMethod, err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", fmt.Errorf("Vault login with the %s auth method returned no token", b.authMethod)
+	}
+
+	b.clientToken = resp.Auth.ClientToken
+	b.tokenExpiry = time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		// log in again a bit before the token expires
+		lease := time.Duration(resp.Auth.LeaseDuration) * time.Second
+		b.tokenExpiry = b.now().Add(lease - lease/10)
+	}
+	return b.clientToken, nil
+}
+
+// do sends a request to Vault and decodes its JSON response, returning the HTTP status code
+func (b *vaultBackend) do(ctx context.Context, method, url, token string, payload, out interface{}) (int, error) {
+	var body io.Reader
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return 0, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if b.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.namespace)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return doJSONRequest(b.client, req, out)
+}
+
+// doJSONRequest sends the request and decodes the JSON response in out, returning the HTTP status code
+func doJSONRequest(client *http.Client, req *http.Request, out interface{}) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxSecretsFileSize))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("could not parse the response: %s", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVaultServer(t *testing.T, logins *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/approle/login", "/v1/auth/k8s/login":
+			var payload map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+			if payload["secret_id"] != "s3cr3t" && payload["jwt"] != "sa-token" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			logins.Add(1)
+			w.Write([]byte(`{"auth": {"client_token": "login-token", "lease_duration": 3600}}`))
+			return
+		}
+
+		token := r.Header.Get("X-Vault-Token")
+		if token != "root-token" && token != "login-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+		assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+		switch r.URL.Path {
+		case "/v1/secret/data/datadog":
+			w.Write([]byte(`{"data": {"data": {"api_key": "123456", "value": "default"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/datadog":
+			w.Write([]byte(`{"data": {"api_key": "654321"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+}
+
+func TestVaultBackendKV(t *testing.T) {
+	var logins atomic.Int32
+	server := newTestVaultServer(t, &logins)
+	defer server.Close()
+
+	b, err := newVaultBackend(map[string]interface{}{"address": server.URL, "namespace": "team", "token": "root-token"})
+	require.NoError(t, err)
+	res := b.GetSecrets(context.Background(), []string{"datadog#api_key", "datadog", "datadog#missing", "other#api_key"})
+	assert.Equal(t, "123456", res["datadog#api_key"].Value)
+	assert.Equal(t, "default", res["datadog"].Value)
+	assert.Equal(t, "key 'missing' not found in Vault secret 'datadog'", res["datadog#missing"].ErrorMsg)
+	assert.Contains(t, res["other#api_key"].ErrorMsg, "could not read Vault secret 'other': unexpected status code 404")
+
+	b, err = newVaultBackend(map[string]interface{}{"address": server.URL, "namespace": "team", "token": "root-token", "mount": "kv", "kv_version": 1})
+	require.NoError(t, err)
+	res = b.GetSecrets(context.Background(), []string{"datadog#api_key"})
+	assert.Equal(t, "654321", res["datadog#api_key"].Value)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("wrong-token\n"), 0600))
+	b, err = newVaultBackend(map[string]interface{}{"address": server.URL, "namespace": "team", "token_file": tokenFile})
+	require.NoError(t, err)
+	res = b.GetSecrets(context.Background(), []string{"datadog#api_key"})
+	assert.Contains(t, res["datadog#api_key"].ErrorMsg, "unexpected status code 403")
+	// the token file is read again on each fetch
+	require.NoError(t, os.WriteFile(tokenFile, []byte("root-token\n"), 0600))
+	res = b.GetSecrets(context.Background(), []string{"datadog#api_key"})
+	assert.Equal(t, "123456", res["datadog#api_key"].Value)
+}
+
+func TestVaultBackendLogin(t *testing.T) {
+	var logins atomic.Int32
+	server := newTestVaultServer(t, &logins)
+	defer server.Close()
+
+	b, err := newVaultBackend(map[string]interface{}{"address": server.URL, "namespace": "team", "auth_method": "approle", "role_id": "agent", "secret_id": "s3cr3t"})
+	require.NoError(t, err)
+	vault := b.(*vaultBackend)
+	res := b.GetSecrets(context.Background(), []string{"datadog#api_key"})
+	assert.Equal(t, "123456", res["datadog#api_key"].Value)
+	b.GetSecrets(context.Background(), []string{"datadog#api_key"})
+	assert.EqualValues(t, 1, logins.Load())
+
+	// a revoked token is replaced by logging in again
+	vault.clientToken = "revoked"
+	res = b.GetSecrets(context.Background(), []string{"datadog#api_key"})
+	assert.Equal(t, "123456", res["datadog#api_key"].Value)
+	assert.EqualValues(t, 2, logins.Load())
+
+	// so is an expired one
+	vault.now = func() time.Time { return time.Now().Add(time.Hour) }
+	b.GetSecrets(context.Background(), []string{"datadog#api_key"})
+	assert.EqualValues(t, 3, logins.Load())
+
+	jwtFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(jwtFile, []byte("sa-token"), 0600))
+	b, err = newVaultBackend(map[string]interface{}{"address": server.URL, "namespace": "team", "auth_method": "kubernetes", "auth_mount": "k8s", "role": "agent", "service_account_token_file": jwtFile})
+	require.NoError(t, err)
+	res = b.GetSecrets(context.Background(), []string{"datadog#api_key"})
+	assert.Equal(t, "123456", res["datadog#api_key"].Value)
+
+	b, err = newVaultBackend(map[string]interface{}{"address": server.URL, "auth_method": "approle", "role_id": "agent", "secret_id": "wrong"})
+	require.NoError(t, err)
+	res = b.GetSecrets(context.Background(), []string{"datadog#api_key"})
+	assert.Contains(t, res["datadog#api_key"].ErrorMsg, "could not log in to Vault with the approle auth method")
+}
+
+func TestVaultBackendConfig(t *testing.T) {
+	for _, tc := range []struct {
+		config map[string]interface{}
+		err    string
+	}{
+		{map[string]interface{}{"token": "t"}, "'address' is required"},
+		{map[string]interface{}{"address": "http://vault", "token": "t", "kv_version": 3}, "unsupported 'kv_version' 3, must be 1 or 2"},
+		{map[string]interface{}{"address": "http://vault"}, "'token' or 'token_file' is required with the token auth method"},
+		{map[string]interface{}{"address": "http://vault", "auth_method": "approle", "role_id": "r"}, "'role_id' and 'secret_id' or 'secret_id_file' are required with the approle auth method"},
+		{map[string]interface{}{"address": "http://vault", "auth_method": "kubernetes"}, "'role' is required with the kubernetes auth method"},
+		{map[string]interface{}{"address": "http://vault", "auth_method": "ldap"}, "unsupported 'auth_method' 'ldap', must be one of token, approle or kubernetes"},
+		{map[string]interface{}{"address": "http://vault", "token": 1}, "'token' must be a string, not int"},
+	} {
+		t.Setenv("VAULT_ADDR", "")
+		t.Setenv("VAULT_TOKEN", "")
+		_, err := newVaultBackend(tc.config)
+		assert.EqualError(t, err, tc.err)
+	}
+}
+
+func TestKubernetesBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sa-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/namespaces/default/secrets/datadog":
+			w.Write([]byte(`{"data": {"api_key": "MTIzNDU2", "bad": "%%%"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("sa-token\n"), 0600))
+	b, err := newKubernetesBackend(map[string]interface{}{"api_server": server.URL, "service_account_token_file": tokenFile})
+	require.NoError(t, err)
+
+	res := b.GetSecrets(context.Background(), []string{"default/datadog/api_key", "default/datadog/bad", "default/datadog/other", "default/missing/key", "invalid"})
+	assert.Equal(t, "123456", res["default/datadog/api_key"].Value)
+	assert.Contains(t, res["default/datadog/bad"].ErrorMsg, "could not decode key 'bad'")
+	assert.Equal(t, "key 'other' not found in secret 'default/datadog'", res["default/datadog/other"].ErrorMsg)
+	assert.Contains(t, res["default/missing/key"].ErrorMsg, "unexpected status code 404")
+	assert.Equal(t, "invalid format, use '<namespace>/<name>/<key>'", res["invalid"].ErrorMsg)
+}
@@ -80,8 +80,13 @@ type secretResolver struct {
 	// list of handles and where they were found
 	origin handleToContext
 
-	backendCommand          string
-	backendArguments        []string
+	backendCommand   string
+	backendArguments []string
+	// backendType is the native backend used instead of backendCommand when set
+	backendType string
+	backend     secrets.Backend
+	// backendErr is the error that prevented the creation of the native backend
+	backendErr              error
 	backendTimeout          int
 	commandAllowGroupExec   bool
 	removeTrailingLinebreak bool
@@ -96,6 +101,10 @@ type secretResolver struct {
 	auditRotRecs     *rotatingNDRecords
 	// subscriptions want to be notified about changes to the secrets
 	subscriptions []secrets.SecretChangeCallback
+	// refreshSubscriptions want to be notified about all the handles changed by a refresh
+	refreshSubscriptions []secrets.SecretRefreshCallback
+	// refreshStats keeps track of the refreshes and of the rotated handles
+	refreshStats refreshStats
 
 	// can be overridden for testing purposes
 	commandHookFunc func(string) ([]byte, error)
@@ -114,6 +123,7 @@ func newEnabledSecretResolver(telemetry telemetry.Component) *secretResolver {
 	return &secretResolver{
 		cache:                   make(map[string]string),
 		origin:                  make(handleToContext),
+		refreshStats:            refreshStats{Rotations: map[string]rotationInfo{}},
 		enabled:                 true,
 		tlmSecretBackendElapsed: telemetry.NewGauge("secret_backend", "elapsed_ms", []string{"command", "exit_code"}, "Elapsed time of secret backend invocation"),
 		tlmSecretUnmarshalError: telemetry.NewCounter("secret_backend", "unmarshal_errors_count", []string{}, "Count of errors when unmarshalling the output of the secret binary"),
@@ -226,6 +236,42 @@ func (r *secretResolver) Configure(params secrets.ConfigParams) {
 	if r.auditFileMaxSize == 0 {
 		r.auditFileMaxSize = SecretAuditFileMaxSizeDefault
 	}
+
+	r.backendType = params.Type
+	r.backend, r.backendErr = nil, nil
+	if r.backendType != "" {
+		if r.backendCommand != "" {
+			log.Warnf("Both secret_backend_type and secret_backend_command are set, ignoring secret_backend_command")
+		}
+		r.backend, r.backendErr = newBackend(r.backendType, params.Config)
+		if r.backendErr != nil {
+			log.Errorf("Could not configure the secret backend: %s", r.backendErr)
+		}
+		// secrets are refreshed when their cache expires unless a refresh interval is set
+		if ttl, err := configInt(params.Config, "cache_ttl", 0); err == nil && ttl > 0 && r.refreshInterval == 0 {
+			r.refreshInterval = time.Duration(ttl) * time.Second
+		}
+	}
+}
+
+// isBackendConfigured returns whether a secret_backend_command or a native backend is configured
+func (r *secretResolver) isBackendConfigured() bool {
+	return r.backendCommand != "" || r.backendType != ""
+}
+
+// fetch returns the value of the given handles from the configured backend
+func (r *secretResolver) fetch(handles []string) (map[string]string, error) {
+	if r.fetchHookFunc != nil {
+		// hook used only for tests
+		return r.fetchHookFunc(handles)
+	}
+	if r.backendErr != nil {
+		return nil, r.backendErr
+	}
+	if r.backend != nil {
+		return r.fetchSecretFromBackend(handles)
+	}
+	return r.fetchSecret(handles)
 }
 
 func isEnc(str string) (bool, string) {
@@ -261,6 +307,15 @@ func (r *secretResolver) SubscribeToChanges(cb secrets.SecretChangeCallback) {
 	r.subscriptions = append(r.subscriptions, cb)
 }
 
+// SubscribeToRefresh adds this callback to the list that get notified of the handles changed by a refresh
+func (r *secretResolver) SubscribeToRefresh(cb secrets.SecretRefreshCallback) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.startRefreshRoutine()
+	r.refreshSubscriptions = append(r.refreshSubscriptions, cb)
+}
+
 // Resolve replaces all encoded secrets in data by executing "secret_backend_command" once if all secrets aren't
 // present in the cache.
 func (r *secretResolver) Resolve(data []byte, origin string) ([]byte, error) {
@@ -271,7 +326,7 @@ func (r *secretResolver) Resolve(data []byte, origin string) ([]byte, error) {
 		log.Infof("Agent secrets is disabled by caller")
 		return nil, nil
 	}
-	if data == nil || r.backendCommand == "" {
+	if data == nil || !r.isBackendConfigured() {
 		return data, nil
 	}
 
@@ -322,14 +377,7 @@ func (r *secretResolver) Resolve(data []byte, origin string) ([]byte, error) {
 
 	// check if any new secrets need to be fetch
 	if len(newHandles) != 0 {
-		var secretResponse map[string]string
-		var err error
-		if r.fetchHookFunc != nil {
-			// hook used only for tests
-			secretResponse, err = r.fetchHookFunc(newHandles)
-		} else {
-			secretResponse, err = r.fetchSecret(newHandles)
-		}
+		secretResponse, err := r.fetch(newHandles)
 		if err != nil {
 			return nil, err
 		}
@@ -403,6 +451,14 @@ func (r *secretResolver) processSecretResponse(secretResponse map[string]string,
 
 		// if allowlist is enabled and the config setting path is not contained in it, skip it
 		if useAllowlist && !r.matchesAllowlist(handle) {
+			// refresh subscribers are notified wherever the handle is used, they resolve their configurations again
+			if len(r.refreshSubscriptions) > 0 {
+				places := make([]handlePlace, 0, len(r.origin[handle]))
+				for _, secretCtx := range r.origin[handle] {
+					places = append(places, handlePlace{Context: secretCtx.origin, Path: strings.Join(secretCtx.path, "/")})
+				}
+				handleInfoList = append(handleInfoList, handleInfo{Name: handle, Places: places})
+			}
 			continue
 		}
 
@@ -439,11 +495,26 @@ func (r *secretResolver) processSecretResponse(secretResponse map[string]string,
 // Refresh the secrets after they have been Resolved by fetching them from the backend again
 func (r *secretResolver) Refresh() (string, error) {
 	r.lock.Lock()
-	defer r.lock.Unlock()
+	report, changedHandles, err := r.refresh()
+	subscriptions := slices.Clone(r.refreshSubscriptions)
+	r.lock.Unlock()
+
+	// refresh subscribers resolve secrets again, so they're notified without holding the lock
+	if len(changedHandles) > 0 {
+		for _, sub := range subscriptions {
+			sub(changedHandles)
+		}
+	}
+	return report, err
+}
 
-	// get handles from the cache that match the allowlist
+// refresh fetches the secrets again and returns a report along with the handles whose value changed
+//
+// This method must be called with r.lock locked.
+func (r *secretResolver) refresh() (string, []string, error) {
+	// get handles from the cache that match the allowlist, refresh subscribers need all of them
 	newHandles := maps.Keys(r.cache)
-	if allowlistPaths != nil {
+	if allowlistPaths != nil && len(r.refreshSubscriptions) == 0 {
 		filteredHandles := make([]string, 0, len(newHandles))
 		for _, handle := range newHandles {
 			if r.matchesAllowlist(handle) {
@@ -453,26 +524,32 @@ func (r *secretResolver) Refresh() (string, error) {
 		newHandles = filteredHandles
 	}
 	if len(newHandles) == 0 {
-		return "", nil
+		return "", nil, nil
 	}
 
 	log.Infof("Refreshing secrets for %d handles", len(newHandles))
 
-	var secretResponse map[string]string
-	var err error
-	if r.fetchHookFunc != nil {
-		// hook used only for tests
-		secretResponse, err = r.fetchHookFunc(newHandles)
-	} else {
-		secretResponse, err = r.fetchSecret(newHandles)
-	}
+	now := time.Now()
+	r.refreshStats.Count++
+	r.refreshStats.LastRefresh = now
+	secretResponse, err := r.fetch(newHandles)
 	if err != nil {
-		return "", err
+		r.refreshStats.LastError = err.Error()
+		return "", nil, err
 	}
+	r.refreshStats.LastError = ""
 
 	var auditRecordErr error
 	// when Refreshing secrets, only update what the allowlist allows by passing `true`
 	refreshResult := r.processSecretResponse(secretResponse, true)
+	changedHandles := make([]string, 0, len(refreshResult.Handles))
+	for _, handle := range refreshResult.Handles {
+		changedHandles = append(changedHandles, handle.Name)
+		rotation := r.refreshStats.Rotations[handle.Name]
+		rotation.Count++
+		rotation.LastRotation = now
+		r.refreshStats.Rotations[handle.Name] = rotation
+	}
 	if len(refreshResult.Handles) > 0 {
 		// add the results to the audit file, if any secrets have new values
 		if err := r.addToAuditFile(secretResponse); err != nil {
@@ -485,13 +562,13 @@ func (r *secretResolver) Refresh() (string, error) {
 	t := template.New("secret_refresh")
 	t, err = t.Parse(secretRefreshTmpl)
 	if err != nil {
-		return "", err
+		return "", changedHandles, err
 	}
 	b := new(strings.Builder)
 	if err = t.Execute(b, refreshResult); err != nil {
-		return "", err
+		return "", changedHandles, err
 	}
-	return b.String(), auditRecordErr
+	return b.String(), changedHandles, auditRecordErr
 }
 
 type auditRecord struct {
@@ -547,11 +624,29 @@ func isLikelyAPIOrAppKey(handle, secretValue string, origin handleToContext) boo
 }
 
 type secretInfo struct {
+	BackendType                  string
+	BackendError                 string
 	Executable                   string
 	ExecutablePermissions        string
 	ExecutablePermissionsDetails interface{}
 	ExecutablePermissionsError   string
 	Handles                      map[string][][]string
+	Refresh                      *refreshStats
+}
+
+// refreshStats are the statistics about secret refreshes shown in the debug info
+type refreshStats struct {
+	Interval    time.Duration
+	Count       int
+	LastRefresh time.Time
+	LastError   string
+	Rotations   map[string]rotationInfo
+}
+
+// rotationInfo tracks how many times the value of a handle changed when refreshing secrets
+type rotationInfo struct {
+	Count        int
+	LastRotation time.Time
 }
 
 type secretRefreshInfo struct {
@@ -580,7 +675,7 @@ func (r *secretResolver) GetDebugInfo(w io.Writer) {
 		fmt.Fprintf(w, "Agent secrets is disabled by caller")
 		return
 	}
-	if r.backendCommand == "" {
+	if !r.isBackendConfigured() {
 		fmt.Fprintf(w, "No secret_backend_command set: secrets feature is not enabled")
 		return
 	}
@@ -598,22 +693,32 @@ func (r *secretResolver) GetDebugInfo(w io.Writer) {
 		return
 	}
 
-	err = checkRights(r.backendCommand, r.commandAllowGroupExec)
-
-	permissions := "OK, the executable has the correct permissions"
-	if err != nil {
-		permissions = fmt.Sprintf("error: %s", err)
-	}
-
-	details, err := r.getExecutablePermissions()
 	info := secretInfo{
-		Executable:                   r.backendCommand,
-		ExecutablePermissions:        permissions,
-		ExecutablePermissionsDetails: details,
-		Handles:                      map[string][][]string{},
+		BackendType: r.backendType,
+		Handles:     map[string][][]string{},
 	}
-	if err != nil {
-		info.ExecutablePermissionsError = err.Error()
+	if r.refreshInterval > 0 || r.refreshStats.Count > 0 {
+		stats := r.refreshStats
+		stats.Interval = r.refreshInterval
+		info.Refresh = &stats
+	}
+	if r.backendType != "" {
+		if r.backendErr != nil {
+			info.BackendError = r.backendErr.Error()
+		}
+	} else {
+		permissions := "OK, the executable has the correct permissions"
+		if err := checkRights(r.backendCommand, r.commandAllowGroupExec); err != nil {
+			permissions = fmt.Sprintf("error: %s", err)
+		}
+
+		details, err := r.getExecutablePermissions()
+		info.Executable = r.backendCommand
+		info.ExecutablePermissions = permissions
+		info.ExecutablePermissionsDetails = details
+		if err != nil {
+			info.ExecutablePermissionsError = err.Error()
+		}
 	}
 
 	// we sort handles so the output is consistent and testable
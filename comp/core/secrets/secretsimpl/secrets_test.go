@@ -6,6 +6,7 @@
 package secretsimpl
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"slices"
@@ -713,6 +714,64 @@ func TestRefreshAllowlist(t *testing.T) {
 	assert.Equal(t, changes, []string{"second_value"})
 }
 
+// test that refresh subscribers are notified of changed handles wherever they're used
+func TestRefreshSubscription(t *testing.T) {
+	tel := fxutil.Test[telemetry.Component](t, nooptelemetry.Module())
+	resolver := newEnabledSecretResolver(tel)
+	resolver.backendCommand = "some_command"
+
+	resolver.fetchHookFunc = func([]string) (map[string]string, error) {
+		return map[string]string{"db_pass": "first", "api_pass": "key"}, nil
+	}
+	_, err := resolver.Resolve([]byte("instances:\n- password: ENC[db_pass]\n  api: ENC[api_pass]\n"), "postgres")
+	require.NoError(t, err)
+
+	changes := []string{}
+	resolver.SubscribeToChanges(func(handle, _ string, _ []string, oldValue, newValue any) {
+		if oldValue != newValue {
+			changes = append(changes, handle)
+		}
+	})
+	var refreshed [][]string
+	resolver.SubscribeToRefresh(func(handles []string) {
+		// the callback can resolve configurations again
+		resolved, err := resolver.Resolve([]byte("password: ENC[db_pass]"), "postgres")
+		require.NoError(t, err)
+		assert.Equal(t, "password: second\n", string(resolved))
+		refreshed = append(refreshed, handles)
+	})
+
+	// nothing changed, no notification
+	_, err = resolver.Refresh()
+	require.NoError(t, err)
+	assert.Empty(t, refreshed)
+
+	resolver.fetchHookFunc = func([]string) (map[string]string, error) {
+		return map[string]string{"db_pass": "second", "api_pass": "key"}, nil
+	}
+	output, err := resolver.Refresh()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"db_pass"}}, refreshed)
+	// the allowlist still applies to the other subscribers
+	assert.Empty(t, changes)
+	assert.Contains(t, output, "'db_pass'")
+	assert.Contains(t, output, "'instances/0/password'")
+
+	var buffer bytes.Buffer
+	resolver.GetDebugInfo(&buffer)
+	assert.Contains(t, buffer.String(), "=== Secrets refresh ===\nRefresh interval: disabled\nNumber of refreshes: 2\n")
+	assert.Contains(t, buffer.String(), "Rotated handles: 1\n- 'db_pass': rotated 1 time(s), last on ")
+
+	resolver.fetchHookFunc = func([]string) (map[string]string, error) {
+		return nil, fmt.Errorf("backend down")
+	}
+	_, err = resolver.Refresh()
+	require.Error(t, err)
+	buffer.Reset()
+	resolver.GetDebugInfo(&buffer)
+	assert.Contains(t, buffer.String(), "Last refresh error: backend down\n")
+}
+
 // test that only setting paths that match the allowlist will get notifications
 // about changed secret values from a Refresh
 func TestRefreshAllowlistAppliesToEachSettingPath(t *testing.T) {
@@ -20,5 +20,11 @@ type SecretVal struct {
 // `newValue`: the new value that the secret has resolved to
 type SecretChangeCallback func(handle, origin string, path []string, oldValue, newValue any)
 
+// SecretRefreshCallback is the callback type used by SubscribeToRefresh to send notifications
+// This callback is called once per refresh changing the value of at least one handle, without any lock held so that
+// it can resolve configurations again.
+// `handles`: the sorted handles whose value changed
+type SecretRefreshCallback func(handles []string)
+
 // PayloadVersion defines the current payload version sent to a secret backend
 const PayloadVersion = "1.0"
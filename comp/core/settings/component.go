@@ -32,6 +32,22 @@ type RuntimeSettingResponse struct {
 	Hidden      bool
 }
 
+// ProvenanceValue is a value of a setting with the source that set it
+type ProvenanceValue struct {
+	Value  interface{}  `json:"value"`
+	Source model.Source `json:"source"`
+	// Origin is the configuration file or the environment variable the value comes from, when known
+	Origin string `json:"origin,omitempty"`
+}
+
+// SettingProvenance describes where the value in effect of a setting comes from, and the values it overrides
+type SettingProvenance struct {
+	Key string `json:"key"`
+	ProvenanceValue
+	// Overridden are the values set by sources of lower priority, from the highest to the lowest priority
+	Overridden []ProvenanceValue `json:"overridden,omitempty"`
+}
+
 // Params that the settings component need
 type Params struct {
 	// Settings define the runtime settings the component would understand
@@ -58,6 +74,8 @@ type Component interface {
 	GetFullConfig(namespaces ...string) http.HandlerFunc
 	// GetFullConfigBySource returns the full config by sources (config, default, env vars ...)
 	GetFullConfigBySource() http.HandlerFunc
+	// GetFullConfigProvenance returns the provenance of every setting not set by its default value
+	GetFullConfigProvenance() http.HandlerFunc
 	// GetValue allows to retrieve the runtime setting
 	GetValue(w http.ResponseWriter, r *http.Request)
 	// SetValue allows to modify the runtime setting
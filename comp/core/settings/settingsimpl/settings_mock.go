@@ -29,11 +29,12 @@ func MockModule() fxutil.Module {
 type MockProvides struct {
 	fx.Out
 
-	Comp         settings.Component
-	FullEndpoint api.AgentEndpointProvider
-	ListEndpoint api.AgentEndpointProvider
-	GetEndpoint  api.AgentEndpointProvider
-	SetEndpoint  api.AgentEndpointProvider
+	Comp               settings.Component
+	FullEndpoint       api.AgentEndpointProvider
+	ProvenanceEndpoint api.AgentEndpointProvider
+	ListEndpoint       api.AgentEndpointProvider
+	GetEndpoint        api.AgentEndpointProvider
+	SetEndpoint        api.AgentEndpointProvider
 }
 
 type mock struct{}
@@ -41,11 +42,12 @@ type mock struct{}
 func newMock() MockProvides {
 	m := mock{}
 	return MockProvides{
-		Comp:         m,
-		FullEndpoint: api.NewAgentEndpointProvider(m.handlerFunc, "/config", "GET"),
-		ListEndpoint: api.NewAgentEndpointProvider(m.handlerFunc, "/config/list-runtime", "GET"),
-		GetEndpoint:  api.NewAgentEndpointProvider(m.handlerFunc, "/config/{setting}", "GET"),
-		SetEndpoint:  api.NewAgentEndpointProvider(m.handlerFunc, "/config/{setting}", "POST"),
+		Comp:               m,
+		FullEndpoint:       api.NewAgentEndpointProvider(m.handlerFunc, "/config", "GET"),
+		ProvenanceEndpoint: api.NewAgentEndpointProvider(m.handlerFunc, "/config/provenance", "GET"),
+		ListEndpoint:       api.NewAgentEndpointProvider(m.handlerFunc, "/config/list-runtime", "GET"),
+		GetEndpoint:        api.NewAgentEndpointProvider(m.handlerFunc, "/config/{setting}", "GET"),
+		SetEndpoint:        api.NewAgentEndpointProvider(m.handlerFunc, "/config/{setting}", "POST"),
 	}
 }
 
@@ -79,6 +81,11 @@ func (m mock) GetFullConfigBySource() http.HandlerFunc {
 	return func(http.ResponseWriter, *http.Request) {}
 }
 
+// GetFullConfigProvenance returns the provenance of every setting
+func (m mock) GetFullConfigProvenance() http.HandlerFunc {
+	return func(http.ResponseWriter, *http.Request) {}
+}
+
 // GetValue allows to retrieve the runtime setting
 func (m mock) GetValue(http.ResponseWriter, *http.Request) {}
 
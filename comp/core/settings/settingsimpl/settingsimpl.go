@@ -9,6 +9,8 @@ package settingsimpl
 import (
 	"html"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/gorilla/mux"
@@ -37,11 +39,12 @@ func Module() fxutil.Module {
 type provides struct {
 	fx.Out
 
-	Comp         settings.Component
-	FullEndpoint api.AgentEndpointProvider
-	ListEndpoint api.AgentEndpointProvider
-	GetEndpoint  api.AgentEndpointProvider
-	SetEndpoint  api.AgentEndpointProvider
+	Comp               settings.Component
+	FullEndpoint       api.AgentEndpointProvider
+	ProvenanceEndpoint api.AgentEndpointProvider
+	ListEndpoint       api.AgentEndpointProvider
+	GetEndpoint        api.AgentEndpointProvider
+	SetEndpoint        api.AgentEndpointProvider
 }
 
 type dependencies struct {
@@ -168,6 +171,56 @@ func (s *settingsRegistry) GetFullConfigBySource() http.HandlerFunc {
 	}
 }
 
+func (s *settingsRegistry) GetFullConfigProvenance() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		keys := s.config.AllKeysLowercased()
+		sort.Strings(keys)
+		provenances := []settings.SettingProvenance{}
+		for _, key := range keys {
+			values := s.config.GetProvenance(key)
+			// settings left to their default value aren't interesting
+			if len(values) == 0 || values[len(values)-1].Source == model.SourceDefault {
+				continue
+			}
+
+			provenance := settings.SettingProvenance{Key: key}
+			for i := len(values) - 1; i >= 0; i-- {
+				value := settings.ProvenanceValue{
+					Value:  scrubSettingValue(key, values[i].Value),
+					Source: values[i].Source,
+					Origin: values[i].Origin,
+				}
+				if i == len(values)-1 {
+					provenance.ProvenanceValue = value
+				} else {
+					provenance.Overridden = append(provenance.Overridden, value)
+				}
+			}
+			provenances = append(provenances, provenance)
+		}
+
+		jsonData, err := json.Marshal(provenances)
+		if err != nil {
+			s.log.Errorf("Unable to marshal config provenance: %s", err)
+			body, _ := json.Marshal(map[string]string{"error": err.Error()})
+			http.Error(w, string(body), http.StatusInternalServerError)
+			return
+		}
+
+		_, _ = w.Write(jsonData)
+	}
+}
+
+// scrubSettingValue scrubs the value of a setting with the scrubber rules matching its name
+func scrubSettingValue(key string, value interface{}) interface{} {
+	name := key[strings.LastIndex(key, ".")+1:]
+	data := interface{}(map[string]interface{}{name: deepcopy.Copy(value)})
+	scrubber.ScrubDataObj(&data)
+	return data.(map[string]interface{})[name]
+}
+
 func (s *settingsRegistry) ListConfigurable(w http.ResponseWriter, _ *http.Request) {
 	configurableSettings := make(map[string]settings.RuntimeSettingResponse)
 	for name, setting := range s.RuntimeSettings() {
@@ -248,10 +301,11 @@ func newSettings(deps dependencies) provides {
 		config:   deps.Params.Config,
 	}
 	return provides{
-		Comp:         s,
-		FullEndpoint: api.NewAgentEndpointProvider(s.GetFullConfig(deps.Params.Namespaces...), "/config", "GET"),
-		ListEndpoint: api.NewAgentEndpointProvider(s.ListConfigurable, "/config/list-runtime", "GET"),
-		GetEndpoint:  api.NewAgentEndpointProvider(s.GetValue, "/config/{setting}", "GET"),
-		SetEndpoint:  api.NewAgentEndpointProvider(s.SetValue, "/config/{setting}", "POST"),
+		Comp:               s,
+		FullEndpoint:       api.NewAgentEndpointProvider(s.GetFullConfig(deps.Params.Namespaces...), "/config", "GET"),
+		ProvenanceEndpoint: api.NewAgentEndpointProvider(s.GetFullConfigProvenance(), "/config/provenance", "GET"),
+		ListEndpoint:       api.NewAgentEndpointProvider(s.ListConfigurable, "/config/list-runtime", "GET"),
+		GetEndpoint:        api.NewAgentEndpointProvider(s.GetValue, "/config/{setting}", "GET"),
+		SetEndpoint:        api.NewAgentEndpointProvider(s.SetValue, "/config/{setting}", "POST"),
 	}
 }
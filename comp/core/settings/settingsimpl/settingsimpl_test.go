@@ -195,6 +195,43 @@ func TestRuntimeSettings(t *testing.T) {
 				assert.NotEqual(t, "", string(body))
 			},
 		},
+		{
+			"GetFullConfigProvenance",
+			func(t *testing.T, comp settings.Component) {
+				config := comp.(*settingsRegistry).config
+				config.Set("api_key", "aaaaaaaaaaaaaaaaaaaaaaaaaaaabbbb", model.SourceFile)
+				config.Set("log_level", "warn", model.SourceFile)
+				config.Set("log_level", "debug", model.SourceAgentRuntime)
+
+				responseRecorder := httptest.NewRecorder()
+				request := httptest.NewRequest("GET", "http://agent.host/config/provenance", nil)
+				comp.GetFullConfigProvenance()(responseRecorder, request)
+
+				resp := responseRecorder.Result()
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, 200, responseRecorder.Code)
+
+				var provenances []settings.SettingProvenance
+				require.NoError(t, json.Unmarshal(body, &provenances))
+				byKey := map[string]settings.SettingProvenance{}
+				for _, provenance := range provenances {
+					assert.NotEqual(t, model.SourceDefault, provenance.Source, provenance.Key)
+					byKey[provenance.Key] = provenance
+				}
+
+				assert.Equal(t, settings.SettingProvenance{
+					Key:             "log_level",
+					ProvenanceValue: settings.ProvenanceValue{Value: "debug", Source: model.SourceAgentRuntime},
+					Overridden: []settings.ProvenanceValue{
+						{Value: "warn", Source: model.SourceFile},
+						{Value: "info", Source: model.SourceDefault},
+					},
+				}, byKey["log_level"])
+				assert.Equal(t, "***************************abbbb", byKey["api_key"].Value)
+			},
+		},
 		{
 			"ListConfigurable",
 			func(t *testing.T, comp settings.Component) {
@@ -13,6 +13,7 @@ import (
 
 	k8smetadata "github.com/DataDog/datadog-agent/comp/core/tagger/k8s_metadata"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/taglist"
+	"github.com/DataDog/datadog-agent/comp/core/tagger/tagrules"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/tags"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/types"
 	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
@@ -229,6 +230,14 @@ func (c *WorkloadMetaCollector) handleContainer(ev workloadmeta.Event) []*types.
 		k8smetadata.AddMetadataAsTags(envName, envValue, c.containerEnvAsTags, c.globContainerEnvLabels, tagList)
 	}
 
+	c.tagRules.Apply(tagrules.Entity{
+		Kind: workloadmeta.KindContainer,
+		Fields: map[string]map[string]string{
+			tagrules.FieldLabels: container.Labels,
+			tagrules.FieldEnv:    container.EnvVars,
+		},
+	}, tagList)
+
 	// static tags for ECS and EKS Fargate containers
 	for tag, value := range c.staticTags {
 		tagList.AddLow(tag, value)
@@ -284,6 +293,11 @@ func (c *WorkloadMetaCollector) handleContainerImage(ev workloadmeta.Event) []*t
 
 	c.labelsToTags(image.Labels, tagList)
 
+	c.tagRules.Apply(tagrules.Entity{
+		Kind:   workloadmeta.KindContainerImageMetadata,
+		Fields: map[string]map[string]string{tagrules.FieldLabels: image.Labels},
+	}, tagList)
+
 	low, orch, high, standard := tagList.Compute()
 	return []*types.TagInfo{
 		{
@@ -363,6 +377,16 @@ func (c *WorkloadMetaCollector) handleKubePod(ev workloadmeta.Event) []*types.Ta
 		k8smetadata.AddMetadataAsTags(name, value, c.k8sResourcesAnnotationsAsTags["namespaces"], c.globK8sResourcesAnnotations["namespaces"], tagList)
 	}
 
+	c.tagRules.Apply(tagrules.Entity{
+		Kind: workloadmeta.KindKubernetesPod,
+		Fields: map[string]map[string]string{
+			tagrules.FieldLabels:               pod.Labels,
+			tagrules.FieldAnnotations:          pod.Annotations,
+			tagrules.FieldNamespaceLabels:      pod.NamespaceLabels,
+			tagrules.FieldNamespaceAnnotations: pod.NamespaceAnnotations,
+		},
+	}, tagList)
+
 	kubeServiceDisabled := false
 	for _, disabledTag := range config.Datadog().GetStringSlice("kubernetes_ad_tags_disabled") {
 		if disabledTag == "kube_service" {
@@ -526,7 +550,7 @@ func (c *WorkloadMetaCollector) handleKubeDeployment(ev workloadmeta.Event) []*t
 	labelsAsTags := c.k8sResourcesLabelsAsTags[groupResource]
 	annotationsAsTags := c.k8sResourcesAnnotationsAsTags[groupResource]
 
-	if len(labelsAsTags)+len(annotationsAsTags) == 0 {
+	if len(labelsAsTags)+len(annotationsAsTags) == 0 && !c.tagRules.AppliesTo(workloadmeta.KindKubernetesDeployment) {
 		return nil
 	}
 
@@ -543,6 +567,14 @@ func (c *WorkloadMetaCollector) handleKubeDeployment(ev workloadmeta.Event) []*t
 		k8smetadata.AddMetadataAsTags(name, value, annotationsAsTags, globAnnotations, tagList)
 	}
 
+	c.tagRules.Apply(tagrules.Entity{
+		Kind: workloadmeta.KindKubernetesDeployment,
+		Fields: map[string]map[string]string{
+			tagrules.FieldLabels:      deployment.Labels,
+			tagrules.FieldAnnotations: deployment.Annotations,
+		},
+	}, tagList)
+
 	low, orch, high, standard := tagList.Compute()
 
 	if len(low)+len(orch)+len(high)+len(standard) == 0 {
@@ -585,6 +617,14 @@ func (c *WorkloadMetaCollector) handleKubeMetadata(ev workloadmeta.Event) []*typ
 		k8smetadata.AddMetadataAsTags(name, value, annotationsAsTags, globAnnotations, tagList)
 	}
 
+	c.tagRules.Apply(tagrules.Entity{
+		Kind: workloadmeta.KindKubernetesMetadata,
+		Fields: map[string]map[string]string{
+			tagrules.FieldLabels:      kubeMetadata.Labels,
+			tagrules.FieldAnnotations: kubeMetadata.Annotations,
+		},
+	}, tagList)
+
 	low, orch, high, standard := tagList.Compute()
 
 	if len(low)+len(orch)+len(high)+len(standard) == 0 {
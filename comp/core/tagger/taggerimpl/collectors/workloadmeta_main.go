@@ -13,6 +13,7 @@ import (
 
 	k8smetadata "github.com/DataDog/datadog-agent/comp/core/tagger/k8s_metadata"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/taglist"
+	"github.com/DataDog/datadog-agent/comp/core/tagger/tagrules"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/types"
 	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -64,6 +65,7 @@ type WorkloadMetaCollector struct {
 	globContainerEnvLabels        map[string]glob.Glob
 	globK8sResourcesAnnotations   map[string]map[string]glob.Glob
 	globK8sResourcesLabels        map[string]map[string]glob.Glob
+	tagRules                      *tagrules.Engine
 
 	collectEC2ResourceTags            bool
 	collectPersistentVolumeClaimsTags bool
@@ -188,9 +190,31 @@ func NewWorkloadMetaCollector(_ context.Context, store workloadmeta.Component, p
 	metadataAsTags := configutils.GetMetadataAsTags(config.Datadog())
 	c.initK8sResourcesMetaAsTags(metadataAsTags.GetResourcesLabelsAsTags(), metadataAsTags.GetResourcesAnnotationsAsTags())
 
+	c.initTagRules(retrieveTagRulesFromConfig())
+
 	return c
 }
 
+func (c *WorkloadMetaCollector) initTagRules(rules []tagrules.Rule) {
+	c.tagRules = tagrules.NewEngine()
+	for _, rule := range rules {
+		if err := c.tagRules.AddRule(rule); err != nil {
+			log.Errorf("%s, skipping it", err)
+		}
+	}
+}
+
+// retrieveTagRulesFromConfig gets the tag extraction rules from the
+// tag_extraction_rules config key
+func retrieveTagRulesFromConfig() []tagrules.Rule {
+	rules, err := tagrules.RulesFromConfig(config.Datadog())
+	if err != nil {
+		log.Error(err)
+		return nil
+	}
+	return rules
+}
+
 // retrieveMappingFromConfig gets a stringmapstring config key and
 // lowercases all map keys to make envvar and yaml sources consistent
 func retrieveMappingFromConfig(configKey string) map[string]string {
@@ -20,6 +20,7 @@ import (
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
 	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/taglist"
+	"github.com/DataDog/datadog-agent/comp/core/tagger/tagrules"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/types"
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/util"
 	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
@@ -2384,6 +2385,107 @@ func TestParseJSONValue(t *testing.T) {
 	}
 }
 
+func TestHandleKubePodWithTagRules(t *testing.T) {
+	store := fxutil.Test[workloadmetamock.Mock](t, fx.Options(
+		fx.Provide(func() log.Component { return logmock.New(t) }),
+		config.MockModule(),
+		fx.Supply(workloadmeta.NewParams()),
+		fx.Supply(context.Background()),
+		workloadmetafxmock.MockModule(),
+	))
+
+	collector := NewWorkloadMetaCollector(context.Background(), store, nil)
+	collector.initTagRules([]tagrules.Rule{
+		{
+			Name: "team",
+			Tag:  "team",
+			From: []tagrules.KeyMatcher{
+				{Field: tagrules.FieldLabels, Key: "team"},
+				{Field: tagrules.FieldNamespaceLabels, Key: "team"},
+			},
+			Transforms: []tagrules.Transform{{Lowercase: true}},
+		},
+		{
+			Name:        "app-labels",
+			Tag:         "app_%%key%%",
+			Cardinality: "orchestrator",
+			From:        []tagrules.KeyMatcher{{Field: tagrules.FieldLabels, KeyRegex: `^app\.example\.com/(.*)$`}},
+		},
+		{
+			Name:        "version",
+			Tag:         "version",
+			Cardinality: "standard",
+			From:        []tagrules.KeyMatcher{{Field: tagrules.FieldLabels, Key: "release"}},
+			Transforms:  []tagrules.Transform{{Regex: `^v(.*)$`}},
+		},
+		{
+			Name:        "owners",
+			Tag:         "owner",
+			Cardinality: "high",
+			From:        []tagrules.KeyMatcher{{Field: tagrules.FieldAnnotations, Key: "owners"}},
+			Transforms:  []tagrules.Transform{{Split: ","}},
+		},
+		{
+			Name: "criticality in prod only",
+			Tag:  "criticality",
+			From: []tagrules.KeyMatcher{{Field: tagrules.FieldNamespaceAnnotations, Key: "criticality"}},
+			When: []tagrules.Condition{{Field: tagrules.FieldNamespaceLabels, Key: "env", Value: "prod*"}},
+		},
+		{
+			Name:  "containers only",
+			Kinds: []string{string(workloadmeta.KindContainer)},
+			Tag:   "container_team",
+			From:  []tagrules.KeyMatcher{{Field: tagrules.FieldNamespaceLabels, Key: "team"}},
+		},
+		{
+			Name: "invalid",
+			Tag:  "invalid",
+			From: []tagrules.KeyMatcher{{Field: "spec", Key: "team"}},
+		},
+	})
+
+	pod := &workloadmeta.KubernetesPod{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindKubernetesPod,
+			ID:   "pod-uid",
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name:      "redis-0",
+			Namespace: "default",
+			Labels: map[string]string{
+				"app.example.com/tier": "db",
+				"release":              "v1.2.3",
+			},
+			Annotations: map[string]string{
+				"owners": "alice, bob",
+			},
+		},
+		NamespaceLabels: map[string]string{
+			"team": "Payments",
+			"env":  "staging",
+		},
+		NamespaceAnnotations: map[string]string{
+			"criticality": "high",
+		},
+	}
+
+	actual := collector.handleKubePod(workloadmeta.Event{
+		Type:   workloadmeta.EventTypeSet,
+		Entity: pod,
+	})
+
+	assertTagInfoListEqual(t, []*types.TagInfo{
+		{
+			Source:               podSource,
+			Entity:               "kubernetes_pod_uid://pod-uid",
+			HighCardTags:         []string{"owner:alice", "owner:bob"},
+			OrchestratorCardTags: []string{"pod_name:redis-0", "app_tier:db"},
+			LowCardTags:          []string{"kube_namespace:default", "team:payments", "version:1.2.3"},
+			StandardTags:         []string{"version:1.2.3"},
+		},
+	}, actual)
+}
+
 func Test_mergeMaps(t *testing.T) {
 	tests := []struct {
 		name   string
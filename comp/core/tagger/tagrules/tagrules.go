@@ -0,0 +1,504 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package tagrules implements the declarative tag extraction rules configured
+// with `tag_extraction_rules`, which derive tags from the labels, annotations
+// and environment variables of the workloadmeta entities.
+package tagrules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gobwas/glob"
+
+	"github.com/DataDog/datadog-agent/comp/core/tagger/taglist"
+	"github.com/DataDog/datadog-agent/comp/core/tagger/types"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+// Fields of the entities that rules can read from
+const (
+	FieldLabels               = "labels"
+	FieldAnnotations          = "annotations"
+	FieldEnv                  = "env"
+	FieldNamespaceLabels      = "namespace_labels"
+	FieldNamespaceAnnotations = "namespace_annotations"
+)
+
+// standardCardinality adds the tags as standard tags (env, service, version)
+const standardCardinality = "standard"
+
+// keyTemplateVariable is replaced in tag names by the matched key, or by the
+// first capture group of key_regex when it has one
+const keyTemplateVariable = "%%key%%"
+
+var knownFields = map[string]struct{}{
+	FieldLabels:               {},
+	FieldAnnotations:          {},
+	FieldEnv:                  {},
+	FieldNamespaceLabels:      {},
+	FieldNamespaceAnnotations: {},
+}
+
+// knownKinds are the kinds of the entities the rules are applied on
+var knownKinds = map[workloadmeta.Kind]struct{}{
+	workloadmeta.KindContainer:              {},
+	workloadmeta.KindContainerImageMetadata: {},
+	workloadmeta.KindKubernetesPod:          {},
+	workloadmeta.KindKubernetesDeployment:   {},
+	workloadmeta.KindKubernetesMetadata:     {},
+}
+
+// Rule is a tag extraction rule
+type Rule struct {
+	// Name identifies the rule in logs
+	Name string `mapstructure:"name" json:"name" yaml:"name"`
+	// Kinds are the workloadmeta kinds the rule applies to, all of them
+	// when empty
+	Kinds []string `mapstructure:"kinds" json:"kinds" yaml:"kinds"`
+	// Tag is the name of the extracted tag. It can contain %%key%%.
+	Tag string `mapstructure:"tag" json:"tag" yaml:"tag"`
+	// Cardinality is low (the default), orchestrator, high or standard
+	Cardinality string `mapstructure:"cardinality" json:"cardinality" yaml:"cardinality"`
+	// From are the keys the values are read from. The first one matching
+	// any key of the entity is used, the next ones are fallbacks.
+	From []KeyMatcher `mapstructure:"from" json:"from" yaml:"from"`
+	// Transforms are applied to the values, in order
+	Transforms []Transform `mapstructure:"transforms" json:"transforms" yaml:"transforms"`
+	// When are conditions that must all be true for the rule to apply
+	When []Condition `mapstructure:"when" json:"when" yaml:"when"`
+}
+
+// KeyMatcher selects keys of a field of the entity
+type KeyMatcher struct {
+	Field string `mapstructure:"field" json:"field" yaml:"field"`
+	// Key is a key name or a glob pattern
+	Key string `mapstructure:"key" json:"key" yaml:"key"`
+	// KeyRegex is a regular expression matching key names, used instead of
+	// Key
+	KeyRegex string `mapstructure:"key_regex" json:"key_regex" yaml:"key_regex"`
+}
+
+// Transform transforms the extracted values. Exactly one of its settings must
+// be set.
+type Transform struct {
+	// Lowercase lowercases the values
+	Lowercase bool `mapstructure:"lowercase" json:"lowercase" yaml:"lowercase"`
+	// Regex replaces the values by their first capture group, or by the
+	// whole match without capture group. Values that don't match are dropped.
+	Regex string `mapstructure:"regex" json:"regex" yaml:"regex"`
+	// Split splits the values on this separator
+	Split string `mapstructure:"split" json:"split" yaml:"split"`
+}
+
+// Condition is a condition on a key of a field of the entity
+type Condition struct {
+	Field string `mapstructure:"field" json:"field" yaml:"field"`
+	Key   string `mapstructure:"key" json:"key" yaml:"key"`
+	// Value is a glob pattern the value of the key must match
+	Value string `mapstructure:"value" json:"value" yaml:"value"`
+	// Regex is a regular expression the value of the key must match
+	Regex string `mapstructure:"regex" json:"regex" yaml:"regex"`
+	// Exists checks that the key exists, or doesn't when false. Without
+	// Value nor Regex, the condition checks that the key exists by default.
+	Exists *bool `mapstructure:"exists" json:"exists" yaml:"exists"`
+}
+
+// Entity is the view of a workloadmeta entity the rules are applied on. Its
+// fields are indexed by the Field* constants.
+type Entity struct {
+	Kind   workloadmeta.Kind
+	Fields map[string]map[string]string
+}
+
+// Engine applies tag extraction rules to entities. A nil engine has no rules.
+type Engine struct {
+	rules []*compiledRule
+}
+
+type compiledRule struct {
+	kinds       map[workloadmeta.Kind]struct{}
+	tag         string
+	cardinality string
+	from        []keyMatcher
+	transforms  []func([]string) []string
+	when        []condition
+}
+
+type keyMatcher struct {
+	field string
+	key   string
+	glob  glob.Glob
+	regex *regexp.Regexp
+}
+
+type condition struct {
+	field  string
+	key    string
+	glob   glob.Glob
+	regex  *regexp.Regexp
+	exists bool
+}
+
+// RulesFromConfig reads the rules of the tag_extraction_rules setting
+func RulesFromConfig(cfg model.Reader) ([]Rule, error) {
+	var rules []Rule
+	if err := cfg.UnmarshalKey("tag_extraction_rules", &rules); err != nil {
+		return nil, fmt.Errorf("could not parse tag_extraction_rules: %w", err)
+	}
+	return rules, nil
+}
+
+// UsesField returns whether some of the rules read the given field, in their
+// keys or their conditions, so that collectors only collect it when needed
+func UsesField(rules []Rule, field string) bool {
+	for _, rule := range rules {
+		for _, from := range rule.From {
+			if from.Field == field {
+				return true
+			}
+		}
+		for _, when := range rule.When {
+			if when.Field == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewEngine returns an engine without rules
+func NewEngine() *Engine {
+	return &Engine{}
+}
+
+// AddRule validates a rule and adds it to the engine. Invalid rules are not
+// added, so that they don't prevent the other rules from applying.
+func (e *Engine) AddRule(rule Rule) error {
+	compiled, err := compileRule(rule)
+	if err != nil {
+		name := rule.Name
+		if name == "" {
+			name = rule.Tag
+		}
+		return fmt.Errorf("invalid tag extraction rule %q: %w", name, err)
+	}
+	e.rules = append(e.rules, compiled)
+	return nil
+}
+
+// AppliesTo returns whether some rules apply to entities of the given kind
+func (e *Engine) AppliesTo(kind workloadmeta.Kind) bool {
+	if e == nil {
+		return false
+	}
+	for _, rule := range e.rules {
+		if rule.appliesTo(kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply adds the tags extracted by the rules from the entity to the tag list
+func (e *Engine) Apply(entity Entity, tagList *taglist.TagList) {
+	if e == nil {
+		return
+	}
+	for _, rule := range e.rules {
+		if rule.appliesTo(entity.Kind) {
+			rule.apply(entity, tagList)
+		}
+	}
+}
+
+func compileRule(rule Rule) (*compiledRule, error) {
+	if rule.Tag == "" {
+		return nil, errors.New("tag is required")
+	}
+	if len(rule.From) == 0 {
+		return nil, errors.New("from is required")
+	}
+
+	compiled := &compiledRule{tag: rule.Tag}
+
+	switch c := strings.ToLower(rule.Cardinality); c {
+	case "":
+		compiled.cardinality = types.LowCardinalityString
+	case standardCardinality:
+		compiled.cardinality = c
+	default:
+		cardinality, err := types.StringToTagCardinality(c)
+		if err != nil {
+			return nil, err
+		}
+		compiled.cardinality = types.TagCardinalityToString(cardinality)
+	}
+
+	if len(rule.Kinds) > 0 {
+		compiled.kinds = make(map[workloadmeta.Kind]struct{}, len(rule.Kinds))
+		for _, kind := range rule.Kinds {
+			if _, ok := knownKinds[workloadmeta.Kind(kind)]; !ok {
+				return nil, fmt.Errorf("unknown kind %q", kind)
+			}
+			compiled.kinds[workloadmeta.Kind(kind)] = struct{}{}
+		}
+	}
+
+	for _, from := range rule.From {
+		matcher, err := compileKeyMatcher(from)
+		if err != nil {
+			return nil, err
+		}
+		compiled.from = append(compiled.from, matcher)
+	}
+
+	for _, transform := range rule.Transforms {
+		fn, err := compileTransform(transform)
+		if err != nil {
+			return nil, err
+		}
+		compiled.transforms = append(compiled.transforms, fn)
+	}
+
+	for _, when := range rule.When {
+		cond, err := compileCondition(when)
+		if err != nil {
+			return nil, err
+		}
+		compiled.when = append(compiled.when, cond)
+	}
+
+	return compiled, nil
+}
+
+func checkField(field string) error {
+	if _, ok := knownFields[field]; !ok {
+		return fmt.Errorf("unknown field %q", field)
+	}
+	return nil
+}
+
+func compileKeyMatcher(from KeyMatcher) (keyMatcher, error) {
+	matcher := keyMatcher{field: from.Field, key: from.Key}
+	if err := checkField(from.Field); err != nil {
+		return matcher, err
+	}
+
+	var err error
+	switch {
+	case from.Key != "" && from.KeyRegex != "":
+		return matcher, fmt.Errorf("key and key_regex of field %s are mutually exclusive", from.Field)
+	// the environment variables are only collected by name, patterns would
+	// never match
+	case from.Field == FieldEnv && (from.KeyRegex != "" || isGlob(from.Key)):
+		return matcher, fmt.Errorf("the keys of field %s must be names, not patterns", from.Field)
+	case from.KeyRegex != "":
+		matcher.regex, err = regexp.Compile(from.KeyRegex)
+	case isGlob(from.Key):
+		matcher.glob, err = glob.Compile(from.Key)
+	case from.Key == "":
+		return matcher, fmt.Errorf("key or key_regex is required for field %s", from.Field)
+	}
+	return matcher, err
+}
+
+// isGlob returns whether a key is a glob pattern rather than a name
+func isGlob(key string) bool {
+	return strings.ContainsAny(key, "*?[{")
+}
+
+func compileTransform(transform Transform) (func([]string) []string, error) {
+	set := 0
+	for _, isSet := range []bool{transform.Lowercase, transform.Regex != "", transform.Split != ""} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("each transform must set exactly one of lowercase, regex or split")
+	}
+
+	switch {
+	case transform.Lowercase:
+		return func(values []string) []string {
+			for i := range values {
+				values[i] = strings.ToLower(values[i])
+			}
+			return values
+		}, nil
+
+	case transform.Regex != "":
+		re, err := regexp.Compile(transform.Regex)
+		if err != nil {
+			return nil, err
+		}
+		return func(values []string) []string {
+			res := values[:0]
+			for _, value := range values {
+				match := re.FindStringSubmatch(value)
+				switch {
+				case match == nil:
+				case len(match) > 1:
+					res = append(res, match[1])
+				default:
+					res = append(res, match[0])
+				}
+			}
+			return res
+		}, nil
+
+	default:
+		sep := transform.Split
+		return func(values []string) []string {
+			var res []string
+			for _, value := range values {
+				for _, part := range strings.Split(value, sep) {
+					if part = strings.TrimSpace(part); part != "" {
+						res = append(res, part)
+					}
+				}
+			}
+			return res
+		}, nil
+	}
+}
+
+func compileCondition(when Condition) (condition, error) {
+	cond := condition{field: when.Field, key: when.Key, exists: true}
+	if err := checkField(when.Field); err != nil {
+		return cond, err
+	}
+	if when.Key == "" {
+		return cond, fmt.Errorf("key is required in the conditions on field %s", when.Field)
+	}
+	if when.Exists != nil {
+		cond.exists = *when.Exists
+	}
+
+	var err error
+	if when.Value != "" {
+		if cond.glob, err = glob.Compile(when.Value); err != nil {
+			return cond, err
+		}
+	}
+	if when.Regex != "" {
+		if cond.regex, err = regexp.Compile(when.Regex); err != nil {
+			return cond, err
+		}
+	}
+	return cond, nil
+}
+
+func (r *compiledRule) appliesTo(kind workloadmeta.Kind) bool {
+	if r.kinds == nil {
+		return true
+	}
+	_, ok := r.kinds[kind]
+	return ok
+}
+
+func (r *compiledRule) apply(entity Entity, tagList *taglist.TagList) {
+	for _, cond := range r.when {
+		if !cond.match(entity) {
+			return
+		}
+	}
+
+	add := r.addFunc(tagList)
+	for _, from := range r.from {
+		extracted := from.extract(entity, r.tag)
+		if len(extracted) == 0 {
+			continue
+		}
+
+		for tagName, values := range extracted {
+			for _, transform := range r.transforms {
+				values = transform(values)
+			}
+			for _, value := range values {
+				add(tagName, value)
+			}
+		}
+		return
+	}
+}
+
+func (r *compiledRule) addFunc(tagList *taglist.TagList) func(string, string) {
+	switch r.cardinality {
+	case standardCardinality:
+		return tagList.AddStandard
+	case types.HighCardinalityString:
+		return tagList.AddHigh
+	case types.OrchestratorCardinalityString:
+		return tagList.AddOrchestrator
+	default:
+		return tagList.AddLow
+	}
+}
+
+// extract returns the values of the matching keys, indexed by the tag names
+// resolved from the tag template
+func (m keyMatcher) extract(entity Entity, tag string) map[string][]string {
+	fields := entity.Fields[m.field]
+	if len(fields) == 0 {
+		return nil
+	}
+
+	if m.glob == nil && m.regex == nil {
+		value, found := fields[m.key]
+		if !found {
+			return nil
+		}
+		return map[string][]string{resolveTag(tag, m.key): {value}}
+	}
+
+	extracted := map[string][]string{}
+	for key, value := range fields {
+		keyValue := key
+		switch {
+		case m.glob != nil:
+			if !m.glob.Match(key) {
+				continue
+			}
+		default:
+			match := m.regex.FindStringSubmatch(key)
+			if match == nil {
+				continue
+			}
+			if len(match) > 1 {
+				keyValue = match[1]
+			}
+		}
+		tagName := resolveTag(tag, keyValue)
+		extracted[tagName] = append(extracted[tagName], value)
+	}
+	return extracted
+}
+
+func resolveTag(tag, key string) string {
+	return strings.ReplaceAll(tag, keyTemplateVariable, key)
+}
+
+func (c condition) match(entity Entity) bool {
+	value, found := entity.Fields[c.field][c.key]
+	if !found {
+		return !c.exists
+	}
+	if !c.exists {
+		return false
+	}
+	if c.glob != nil && !c.glob.Match(value) {
+		return false
+	}
+	if c.regex != nil && !c.regex.MatchString(value) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,243 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tagrules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/comp/core/tagger/taglist"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
+)
+
+func TestApply(t *testing.T) {
+	exists := false
+	container := Entity{
+		Kind: workloadmeta.KindContainer,
+		Fields: map[string]map[string]string{
+			FieldLabels: {
+				"com.example.team":   "Checkout",
+				"com.example.domain": "payments",
+				"com.example.owners": "alice,bob,,",
+				"image.version":      "release-2024.1",
+			},
+			FieldEnv: {
+				"STAGE": "prod-eu",
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		entity   Entity
+		wantLow  []string
+		wantOrch []string
+		wantHigh []string
+	}{
+		{
+			name:    "exact key",
+			rule:    Rule{Tag: "team", From: []KeyMatcher{{Field: FieldLabels, Key: "com.example.team"}}},
+			entity:  container,
+			wantLow: []string{"team:Checkout"},
+		},
+		{
+			name:    "glob key with key template",
+			rule:    Rule{Tag: "%%key%%", From: []KeyMatcher{{Field: FieldLabels, Key: "com.example.d*"}}},
+			entity:  container,
+			wantLow: []string{"com.example.domain:payments"},
+		},
+		{
+			name:     "regex key with capture group",
+			rule:     Rule{Tag: "example_%%key%%", Cardinality: "orch", From: []KeyMatcher{{Field: FieldLabels, KeyRegex: `^com\.example\.(team|domain)$`}}},
+			entity:   container,
+			wantOrch: []string{"example_team:Checkout", "example_domain:payments"},
+		},
+		{
+			name:    "fallback",
+			rule:    Rule{Tag: "team", From: []KeyMatcher{{Field: FieldLabels, Key: "team"}, {Field: FieldLabels, Key: "com.example.team"}}},
+			entity:  container,
+			wantLow: []string{"team:Checkout"},
+		},
+		{
+			name: "transforms",
+			rule: Rule{
+				Tag:        "stage",
+				From:       []KeyMatcher{{Field: FieldEnv, Key: "STAGE"}},
+				Transforms: []Transform{{Regex: `^(\w+)-`}, {Lowercase: true}},
+			},
+			entity:  container,
+			wantLow: []string{"stage:prod"},
+		},
+		{
+			name: "regex transform without match drops the value",
+			rule: Rule{
+				Tag:        "version",
+				From:       []KeyMatcher{{Field: FieldLabels, Key: "image.version"}},
+				Transforms: []Transform{{Regex: `^v\d+`}},
+			},
+			entity: container,
+		},
+		{
+			name: "split",
+			rule: Rule{
+				Tag:         "owner",
+				Cardinality: "high",
+				From:        []KeyMatcher{{Field: FieldLabels, Key: "com.example.owners"}},
+				Transforms:  []Transform{{Split: ","}},
+			},
+			entity:   container,
+			wantHigh: []string{"owner:alice", "owner:bob"},
+		},
+		{
+			name: "matching conditions",
+			rule: Rule{
+				Tag:  "team",
+				From: []KeyMatcher{{Field: FieldLabels, Key: "com.example.team"}},
+				When: []Condition{
+					{Field: FieldEnv, Key: "STAGE", Regex: "^prod"},
+					{Field: FieldLabels, Key: "com.example.domain"},
+					{Field: FieldLabels, Key: "team", Exists: &exists},
+				},
+			},
+			entity:  container,
+			wantLow: []string{"team:Checkout"},
+		},
+		{
+			name: "failing condition",
+			rule: Rule{
+				Tag:  "team",
+				From: []KeyMatcher{{Field: FieldLabels, Key: "com.example.team"}},
+				When: []Condition{{Field: FieldEnv, Key: "STAGE", Value: "staging*"}},
+			},
+			entity: container,
+		},
+		{
+			name: "other kind",
+			rule: Rule{
+				Kinds: []string{string(workloadmeta.KindKubernetesPod)},
+				Tag:   "team",
+				From:  []KeyMatcher{{Field: FieldLabels, Key: "com.example.team"}},
+			},
+			entity: container,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine()
+			assert.NoError(t, engine.AddRule(test.rule))
+
+			tagList := taglist.NewTagList()
+			engine.Apply(test.entity, tagList)
+			low, orch, high, _ := tagList.Compute()
+			assert.ElementsMatch(t, test.wantLow, low)
+			assert.ElementsMatch(t, test.wantOrch, orch)
+			assert.ElementsMatch(t, test.wantHigh, high)
+		})
+	}
+}
+
+func TestStandardCardinality(t *testing.T) {
+	engine := NewEngine()
+	assert.NoError(t, engine.AddRule(Rule{Tag: "service", Cardinality: "standard", From: []KeyMatcher{{Field: FieldLabels, Key: "app"}}}))
+
+	tagList := taglist.NewTagList()
+	engine.Apply(Entity{Kind: workloadmeta.KindKubernetesPod, Fields: map[string]map[string]string{FieldLabels: {"app": "web"}}}, tagList)
+	low, _, _, standard := tagList.Compute()
+	assert.Equal(t, []string{"service:web"}, low)
+	assert.Equal(t, []string{"service:web"}, standard)
+}
+
+func TestAddRuleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		err  string
+	}{
+		{
+			name: "no tag",
+			rule: Rule{Name: "no-tag", From: []KeyMatcher{{Field: FieldLabels, Key: "team"}}},
+			err:  `invalid tag extraction rule "no-tag": tag is required`,
+		},
+		{
+			name: "no from",
+			rule: Rule{Tag: "team"},
+			err:  `invalid tag extraction rule "team": from is required`,
+		},
+		{
+			name: "unknown field",
+			rule: Rule{Tag: "team", From: []KeyMatcher{{Field: "spec", Key: "team"}}},
+			err:  `unknown field "spec"`,
+		},
+		{
+			name: "key and key_regex",
+			rule: Rule{Tag: "team", From: []KeyMatcher{{Field: FieldLabels, Key: "team", KeyRegex: "team"}}},
+			err:  "mutually exclusive",
+		},
+		{
+			name: "invalid cardinality",
+			rule: Rule{Tag: "team", Cardinality: "medium", From: []KeyMatcher{{Field: FieldLabels, Key: "team"}}},
+			err:  "unsupported value medium",
+		},
+		{
+			name: "invalid regex",
+			rule: Rule{Tag: "team", From: []KeyMatcher{{Field: FieldLabels, KeyRegex: "("}}},
+			err:  "error parsing regexp",
+		},
+		{
+			name: "transform with several settings",
+			rule: Rule{Tag: "team", From: []KeyMatcher{{Field: FieldLabels, Key: "team"}}, Transforms: []Transform{{Lowercase: true, Split: ","}}},
+			err:  "exactly one of lowercase, regex or split",
+		},
+		{
+			name: "unknown kind",
+			rule: Rule{Tag: "team", Kinds: []string{"kubernetes_pods"}, From: []KeyMatcher{{Field: FieldLabels, Key: "team"}}},
+			err:  `unknown kind "kubernetes_pods"`,
+		},
+		{
+			name: "glob env key",
+			rule: Rule{Tag: "stage", From: []KeyMatcher{{Field: FieldEnv, Key: "STAGE_*"}}},
+			err:  "the keys of field env must be names, not patterns",
+		},
+		{
+			name: "regex env key",
+			rule: Rule{Tag: "stage", From: []KeyMatcher{{Field: FieldEnv, KeyRegex: "^STAGE"}}},
+			err:  "the keys of field env must be names, not patterns",
+		},
+		{
+			name: "condition without key",
+			rule: Rule{Tag: "team", From: []KeyMatcher{{Field: FieldLabels, Key: "team"}}, When: []Condition{{Field: FieldEnv}}},
+			err:  "key is required",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine()
+			assert.ErrorContains(t, engine.AddRule(test.rule), test.err)
+			assert.False(t, engine.AppliesTo(workloadmeta.KindContainer))
+		})
+	}
+}
+
+func TestNilEngine(t *testing.T) {
+	var engine *Engine
+	assert.False(t, engine.AppliesTo(workloadmeta.KindContainer))
+	engine.Apply(Entity{Kind: workloadmeta.KindContainer}, taglist.NewTagList())
+}
+
+func TestUsesField(t *testing.T) {
+	rules := []Rule{
+		{Tag: "team", From: []KeyMatcher{{Field: FieldLabels, Key: "team"}, {Field: FieldNamespaceLabels, Key: "team"}}},
+		{Tag: "tier", From: []KeyMatcher{{Field: FieldLabels, Key: "tier"}}, When: []Condition{{Field: FieldNamespaceAnnotations, Key: "tiered"}}},
+	}
+	assert.True(t, UsesField(rules, FieldNamespaceLabels))
+	assert.True(t, UsesField(rules, FieldNamespaceAnnotations))
+	assert.False(t, UsesField(rules, FieldEnv))
+	assert.False(t, UsesField(nil, FieldLabels))
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/podman"
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/process"
 	remoteprocesscollector "github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/remote/processcollector"
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/replay"
 )
 
 func getCollectorOptions() []fx.Option {
@@ -40,6 +41,7 @@ func getCollectorOptions() []fx.Option {
 		podman.GetFxOptions(),
 		remoteprocesscollector.GetFxOptions(),
 		host.GetFxOptions(),
+		replay.GetFxOptions(),
 		process.GetFxOptions(),
 	}
 }
@@ -24,6 +24,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/podman"
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/remote/processcollector"
 	remoteworkloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/remote/workloadmeta"
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/replay"
 )
 
 func getCollectorOptions() []fx.Option {
@@ -42,5 +43,6 @@ func getCollectorOptions() []fx.Option {
 		fx.Supply(remoteworkloadmeta.Params{}),
 		processcollector.GetFxOptions(),
 		host.GetFxOptions(),
+		replay.GetFxOptions(),
 	}
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/podman"
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/remote/processcollector"
 	remoteworkloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/remote/workloadmeta"
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/replay"
 )
 
 func getCollectorOptions() []fx.Option {
@@ -47,5 +48,6 @@ func getCollectorOptions() []fx.Option {
 		remoteWorkloadmetaParams(),
 		processcollector.GetFxOptions(),
 		host.GetFxOptions(),
+		replay.GetFxOptions(),
 	}
 }
@@ -17,9 +17,11 @@ import (
 	"go.uber.org/fx"
 	"k8s.io/apimachinery/pkg/util/sets"
 
+	"github.com/DataDog/datadog-agent/comp/core/tagger/tagrules"
 	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
 	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
 	configutils "github.com/DataDog/datadog-agent/pkg/config/utils"
 	"github.com/DataDog/datadog-agent/pkg/errors"
 	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
@@ -116,13 +118,27 @@ func (c *collector) Start(_ context.Context, store workloadmeta.Component) error
 
 	c.updateFreq = time.Duration(config.Datadog().GetInt("kubernetes_metadata_tag_update_freq")) * time.Second
 
-	metadataAsTags := configutils.GetMetadataAsTags(config.Datadog())
-	c.collectNamespaceLabels = len(metadataAsTags.GetNamespaceLabelsAsTags()) > 0
-	c.collectNamespaceAnnotations = len(metadataAsTags.GetNamespaceAnnotationsAsTags()) > 0
+	c.collectNamespaceLabels, c.collectNamespaceAnnotations = namespaceMetadataToCollect(config.Datadog())
 
 	return err
 }
 
+// namespaceMetadataToCollect returns whether the namespace labels and annotations are needed, either
+// to be added as tags or to be read by the tag extraction rules
+func namespaceMetadataToCollect(cfg model.Reader) (labels bool, annotations bool) {
+	metadataAsTags := configutils.GetMetadataAsTags(cfg)
+	labels = len(metadataAsTags.GetNamespaceLabelsAsTags()) > 0
+	annotations = len(metadataAsTags.GetNamespaceAnnotationsAsTags()) > 0
+
+	rules, err := tagrules.RulesFromConfig(cfg)
+	if err != nil {
+		log.Debug(err)
+	}
+	labels = labels || tagrules.UsesField(rules, tagrules.FieldNamespaceLabels)
+	annotations = annotations || tagrules.UsesField(rules, tagrules.FieldNamespaceAnnotations)
+	return labels, annotations
+}
+
 // Pull triggers an event collection from kubelet and the Datadog Cluster Agent.
 func (c *collector) Pull(ctx context.Context) error {
 	// Time constraints, get the delta in seconds to display it in the logs:
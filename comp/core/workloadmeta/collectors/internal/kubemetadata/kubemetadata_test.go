@@ -12,6 +12,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
 	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
 	pbgo "github.com/DataDog/datadog-agent/pkg/proto/pbgo/process"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
@@ -395,6 +397,45 @@ func TestKubeMetadataCollector_getNamespaceMetadata(t *testing.T) {
 	}
 }
 
+func TestNamespaceMetadataToCollect(t *testing.T) {
+	t.Run("nothing configured", func(t *testing.T) {
+		labels, annotations := namespaceMetadataToCollect(configmock.New(t))
+		assert.False(t, labels)
+		assert.False(t, annotations)
+	})
+
+	t.Run("namespace annotations as tags", func(t *testing.T) {
+		cfg := configmock.New(t)
+		cfg.SetWithoutSource("kubernetes_namespace_annotations_as_tags", map[string]string{"tier": "tier"})
+		labels, annotations := namespaceMetadataToCollect(cfg)
+		assert.False(t, labels)
+		assert.True(t, annotations)
+	})
+
+	// the example of the configuration template reads the team namespace label
+	t.Run("tag extraction rules", func(t *testing.T) {
+		cfg := configmock.New(t)
+		cfg.SetConfigType("yaml")
+		err := cfg.ReadConfig(strings.NewReader(`
+tag_extraction_rules:
+  - name: team
+    kinds: [kubernetes_pod]
+    tag: team
+    from:
+      - field: labels
+        key: team
+      - field: namespace_labels
+        key: team
+    transforms:
+      - lowercase: true
+`))
+		assert.NoError(t, err)
+		labels, annotations := namespaceMetadataToCollect(cfg)
+		assert.True(t, labels)
+		assert.False(t, annotations)
+	})
+}
+
 func TestKubeMetadataCollector_parsePods(t *testing.T) {
 	pods := []*kubelet.Pod{{
 		Metadata: kubelet.PodMetadata{
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package replay implements a workloadmeta collector replaying the events
+// recorded from another agent, to reproduce its state offline.
+package replay
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
+	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	collectorID   = "replay"
+	componentName = "workloadmeta-replay"
+)
+
+type dependencies struct {
+	fx.In
+
+	Config config.Component
+}
+
+type collector struct {
+	store   workloadmeta.Component
+	catalog workloadmeta.AgentType
+	config  config.Component
+}
+
+// GetFxOptions returns the FX framework options for the collector
+func GetFxOptions() fx.Option {
+	return fx.Provide(NewCollector)
+}
+
+// NewCollector returns a new replay collector provider and an error
+func NewCollector(deps dependencies) (workloadmeta.CollectorProvider, error) {
+	return workloadmeta.CollectorProvider{
+		Collector: &collector{
+			catalog: workloadmeta.NodeAgent | workloadmeta.ClusterAgent | workloadmeta.ProcessAgent,
+			config:  deps.Config,
+		},
+	}, nil
+}
+
+// Start reads the recording and replays it in the background
+func (c *collector) Start(ctx context.Context, store workloadmeta.Component) error {
+	path := c.config.GetString("workloadmeta.replay.file")
+	if path == "" {
+		return errors.NewDisabled(componentName, "no workloadmeta recording to replay")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open the workloadmeta recording: %w", err)
+	}
+	events, err := workloadmeta.ReadRecordedEvents(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("cannot read the workloadmeta recording %s: %w", path, err)
+	}
+
+	c.store = store
+	speed := c.config.GetFloat64("workloadmeta.replay.speed")
+	log.Infof("Replaying %d workloadmeta events from %s", len(events), path)
+
+	go func() {
+		if err := workloadmeta.ReplayEvents(ctx, store, events, speed); err != nil {
+			log.Warnf("Workloadmeta replay of %s interrupted: %v", path, err)
+			return
+		}
+		log.Infof("Workloadmeta replay of %s done", path)
+	}()
+
+	return nil
+}
+
+func (c *collector) Pull(_ context.Context) error {
+	return nil
+}
+
+func (c *collector) GetID() string {
+	return collectorID
+}
+
+func (c *collector) GetTargetCatalog() workloadmeta.AgentType {
+	return c.catalog
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
+	workloadmetafxmock "github.com/DataDog/datadog-agent/comp/core/workloadmeta/fx-mock"
+	workloadmetamock "github.com/DataDog/datadog-agent/comp/core/workloadmeta/mock"
+	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+type testDeps struct {
+	fx.In
+
+	Config config.Component
+	Wml    workloadmetamock.Mock
+}
+
+func newTestDeps(t *testing.T, overrides map[string]interface{}) testDeps {
+	return fxutil.Test[testDeps](t, fx.Options(
+		fx.Replace(config.MockParams{Overrides: overrides}),
+		core.MockBundle(),
+		fx.Supply(workloadmeta.NewParams()),
+		fx.Supply(context.Background()),
+		workloadmetafxmock.MockModule(),
+	))
+}
+
+func TestReplayCollector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	recording := `{"timestamp":"2024-01-02T03:04:05Z","type":"set","source":"runtime","kind":"container","entity":{"Kind":"container","ID":"abcdef","Name":"redis"}}
+{"timestamp":"2024-01-02T03:04:05Z","type":"set","source":"runtime","kind":"container","entity":{"Kind":"container","ID":"123456","Name":"nginx"}}
+{"timestamp":"2024-01-02T03:04:06Z","type":"unset","source":"runtime","kind":"container","entity":{"Kind":"container","ID":"123456"}}
+`
+	require.NoError(t, os.WriteFile(path, []byte(recording), 0644))
+
+	deps := newTestDeps(t, map[string]interface{}{
+		"workloadmeta.replay.file":  path,
+		"workloadmeta.replay.speed": 0,
+	})
+	c := collector{config: deps.Config}
+	require.NoError(t, c.Start(context.Background(), deps.Wml))
+
+	require.EventuallyWithT(t, func(t *assert.CollectT) {
+		container, err := deps.Wml.GetContainer("abcdef")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "redis", container.Name)
+		}
+		_, err = deps.Wml.GetContainer("123456")
+		assert.Error(t, err)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestReplayCollectorDisabled(t *testing.T) {
+	deps := newTestDeps(t, nil)
+	c := collector{config: deps.Config}
+	err := c.Start(context.Background(), deps.Wml)
+	assert.True(t, errors.IsDisabled(err))
+}
@@ -0,0 +1,222 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package workloadmeta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	recordedEventSet   = "set"
+	recordedEventUnset = "unset"
+)
+
+// recordedSources are the sources recorded by RecordEvents, each of them with
+// its own subscription so that the events keep the data of their source only.
+var recordedSources = []Source{
+	SourceRuntime,
+	SourceNodeOrchestrator,
+	SourceClusterOrchestrator,
+	SourceRemoteWorkloadmeta,
+	SourceRemoteProcessCollector,
+	SourceLanguageDetectionServer,
+	SourceHost,
+	SourceLocalProcessCollector,
+}
+
+// RecordedEvent is a collector event recorded by RecordEvents, to be replayed
+// with ReplayEvents. Recordings are made of one JSON encoded RecordedEvent
+// per line.
+type RecordedEvent struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Type      string          `json:"type"`
+	Source    Source          `json:"source"`
+	Kind      Kind            `json:"kind"`
+	Entity    json.RawMessage `json:"entity"`
+}
+
+// NewRecordedEvent returns the recorded version of a collector event
+func NewRecordedEvent(timestamp time.Time, ev CollectorEvent) (RecordedEvent, error) {
+	recorded := RecordedEvent{
+		Timestamp: timestamp,
+		Source:    ev.Source,
+		Kind:      ev.Entity.GetID().Kind,
+	}
+
+	switch ev.Type {
+	case EventTypeSet:
+		recorded.Type = recordedEventSet
+	case EventTypeUnset:
+		recorded.Type = recordedEventUnset
+	default:
+		return recorded, fmt.Errorf("cannot record event of type %d", ev.Type)
+	}
+
+	entity, err := json.Marshal(ev.Entity)
+	if err != nil {
+		return recorded, fmt.Errorf("cannot record %s %s: %w", recorded.Kind, ev.Entity.GetID().ID, err)
+	}
+	recorded.Entity = entity
+	return recorded, nil
+}
+
+// CollectorEvent returns the collector event that was recorded
+func (e RecordedEvent) CollectorEvent() (CollectorEvent, error) {
+	ev := CollectorEvent{Source: e.Source}
+
+	switch e.Type {
+	case recordedEventSet:
+		ev.Type = EventTypeSet
+	case recordedEventUnset:
+		ev.Type = EventTypeUnset
+	default:
+		return ev, fmt.Errorf("unknown event type %q", e.Type)
+	}
+
+	entity, err := newEntityOfKind(e.Kind)
+	if err != nil {
+		return ev, err
+	}
+	if err := json.Unmarshal(e.Entity, entity); err != nil {
+		return ev, fmt.Errorf("cannot decode %s entity: %w", e.Kind, err)
+	}
+	ev.Entity = entity
+	return ev, nil
+}
+
+// newEntityOfKind returns a pointer to an empty entity of the given kind
+func newEntityOfKind(kind Kind) (Entity, error) {
+	switch kind {
+	case KindContainer:
+		return &Container{}, nil
+	case KindKubernetesPod:
+		return &KubernetesPod{}, nil
+	case KindKubernetesMetadata:
+		return &KubernetesMetadata{}, nil
+	case KindKubernetesDeployment:
+		return &KubernetesDeployment{}, nil
+	case KindECSTask:
+		return &ECSTask{}, nil
+	case KindContainerImageMetadata:
+		return &ContainerImageMetadata{}, nil
+	case KindProcess:
+		return &Process{}, nil
+	case KindHost:
+		return &HostTags{}, nil
+	}
+	return nil, fmt.Errorf("unknown entity kind %q", kind)
+}
+
+// ReadRecordedEvents reads a recording written by RecordEvents. The events
+// are sorted by timestamp.
+func ReadRecordedEvents(r io.Reader) ([]RecordedEvent, error) {
+	var events []RecordedEvent
+	decoder := json.NewDecoder(r)
+	for {
+		var ev RecordedEvent
+		err := decoder.Decode(&ev)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid recorded event %d: %w", len(events)+1, err)
+		}
+		events = append(events, ev)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
+
+// RecordEvents writes the events of the store to w until the context is done.
+// The entities already in the store are recorded first, as set events.
+func RecordEvents(ctx context.Context, store Component, w io.Writer) error {
+	var (
+		m        sync.Mutex
+		encoder  = json.NewEncoder(w)
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	for _, source := range recordedSources {
+		filter := NewFilterBuilder().SetSource(source).Build()
+		ch := store.Subscribe(fmt.Sprintf("workloadmeta-recorder-%s", source), NormalPriority, filter)
+
+		wg.Add(1)
+		go func(source Source) {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					// keep draining the channel while unsubscribing, the
+					// store may be blocked sending a bundle
+					go store.Unsubscribe(ch)
+					for bundle := range ch {
+						bundle.Acknowledge()
+					}
+					return
+				case bundle, ok := <-ch:
+					if !ok {
+						return
+					}
+					now := time.Now()
+					m.Lock()
+					for _, ev := range bundle.Events {
+						recorded, err := NewRecordedEvent(now, CollectorEvent{Type: ev.Type, Source: source, Entity: ev.Entity})
+						if err == nil {
+							err = encoder.Encode(recorded)
+						}
+						if err != nil && firstErr == nil {
+							firstErr = err
+						}
+					}
+					m.Unlock()
+					bundle.Acknowledge()
+				}
+			}
+		}(source)
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+// ReplayEvents notifies the store of the recorded events, keeping the delays
+// between them divided by speed. The events are notified without delay when
+// speed is 0.
+func ReplayEvents(ctx context.Context, store Component, events []RecordedEvent, speed float64) error {
+	var previous time.Time
+	for i, recorded := range events {
+		ev, err := recorded.CollectorEvent()
+		if err != nil {
+			return fmt.Errorf("cannot replay recorded event %d: %w", i+1, err)
+		}
+
+		if speed > 0 && !previous.IsZero() {
+			if delay := time.Duration(float64(recorded.Timestamp.Sub(previous)) / speed); delay > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(delay):
+				}
+			}
+		}
+		previous = recorded.Timestamp
+
+		store.Notify([]CollectorEvent{ev})
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package workloadmeta
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langUtil "github.com/DataDog/datadog-agent/pkg/languagedetection/util"
+)
+
+func TestRecordedEventRoundTrip(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := []CollectorEvent{
+		{
+			Type:   EventTypeSet,
+			Source: SourceRuntime,
+			Entity: &Container{
+				EntityID:   EntityID{Kind: KindContainer, ID: "abcdef"},
+				EntityMeta: EntityMeta{Name: "redis", Labels: map[string]string{"app": "redis"}},
+				Image:      ContainerImage{Name: "redis", Tag: "7.2"},
+				State:      ContainerState{Running: true},
+			},
+		},
+		{
+			Type:   EventTypeSet,
+			Source: SourceLanguageDetectionServer,
+			Entity: &KubernetesDeployment{
+				EntityID: EntityID{Kind: KindKubernetesDeployment, ID: "default/web"},
+				DetectedLanguages: langUtil.ContainersLanguages{
+					*langUtil.NewContainer("web"):      {"java": {}},
+					*langUtil.NewInitContainer("init"): {"python": {}},
+				},
+			},
+		},
+		{
+			Type:   EventTypeUnset,
+			Source: SourceRuntime,
+			Entity: &Container{EntityID: EntityID{Kind: KindContainer, ID: "abcdef"}},
+		},
+	}
+
+	for _, ev := range events {
+		recorded, err := NewRecordedEvent(now, ev)
+		require.NoError(t, err)
+		assert.Equal(t, ev.Source, recorded.Source)
+		assert.Equal(t, ev.Entity.GetID().Kind, recorded.Kind)
+
+		replayed, err := recorded.CollectorEvent()
+		require.NoError(t, err)
+		assert.Equal(t, ev, replayed)
+	}
+}
+
+func TestReadRecordedEvents(t *testing.T) {
+	recording := `{"timestamp":"2024-01-02T03:04:06Z","type":"unset","source":"runtime","kind":"container","entity":{"Kind":"container","ID":"b"}}
+{"timestamp":"2024-01-02T03:04:05Z","type":"set","source":"runtime","kind":"container","entity":{"Kind":"container","ID":"a"}}
+{"timestamp":"2024-01-02T03:04:05Z","type":"set","source":"runtime","kind":"container","entity":{"Kind":"container","ID":"b"}}
+`
+	events, err := ReadRecordedEvents(strings.NewReader(recording))
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	var ids []string
+	for _, recorded := range events {
+		ev, err := recorded.CollectorEvent()
+		require.NoError(t, err)
+		ids = append(ids, recorded.Type+":"+ev.Entity.GetID().ID)
+	}
+	assert.Equal(t, []string{"set:a", "set:b", "unset:b"}, ids)
+
+	_, err = ReadRecordedEvents(strings.NewReader("{not json\n"))
+	assert.ErrorContains(t, err, "invalid recorded event 1")
+
+	_, err = RecordedEvent{Type: recordedEventSet, Kind: "unknown", Entity: []byte("{}")}.CollectorEvent()
+	assert.ErrorContains(t, err, `unknown entity kind "unknown"`)
+}
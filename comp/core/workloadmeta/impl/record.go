@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package workloadmetaimpl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	wmdef "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
+	grpccontext "github.com/DataDog/datadog-agent/pkg/util/grpc/context"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+)
+
+// maxRecordDuration bounds the duration of the recordings made through the API
+const maxRecordDuration = time.Hour
+
+// flushWriter flushes the response after each write, so that recorded events
+// are streamed to the client as they happen
+type flushWriter struct {
+	w http.ResponseWriter
+	f http.Flusher
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if fw.f != nil {
+		fw.f.Flush()
+	}
+	return n, err
+}
+
+// writeRecording streams the events of the store, one JSON encoded
+// wmdef.RecordedEvent per line, for the duration given in the query.
+func (w *workloadmeta) writeRecording(writer http.ResponseWriter, r *http.Request) {
+	duration, err := time.ParseDuration(r.URL.Query().Get("duration"))
+	if err != nil || duration <= 0 || duration > maxRecordDuration {
+		httputils.SetJSONError(writer, fmt.Errorf("the duration must be a positive duration of at most %s", maxRecordDuration), 400)
+		return
+	}
+
+	// Reset the `server_timeout` deadline for this connection as the recording holds the connection open.
+	if conn, ok := r.Context().Value(grpccontext.ConnContextKey).(net.Conn); ok {
+		_ = conn.SetDeadline(time.Time{})
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), duration)
+	defer cancel()
+
+	writer.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := writer.(http.Flusher)
+	w.log.Infof("Recording workloadmeta events for %s", duration)
+	if err := wmdef.RecordEvents(ctx, w, flushWriter{w: writer, f: flusher}); err != nil {
+		w.log.Warnf("Error while recording workloadmeta events: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package workloadmetaimpl
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	wmdef "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	m sync.Mutex
+	b bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.b.Write(p)
+}
+
+func TestRecordAndReplayEvents(t *testing.T) {
+	s := newWorkloadmetaObject(t)
+
+	runtimeContainer := &wmdef.Container{
+		EntityID:   wmdef.EntityID{Kind: wmdef.KindContainer, ID: "abcdef"},
+		EntityMeta: wmdef.EntityMeta{Name: "redis"},
+		Runtime:    wmdef.ContainerRuntimeContainerd,
+	}
+	s.handleEvents([]wmdef.CollectorEvent{{Type: wmdef.EventTypeSet, Source: wmdef.SourceRuntime, Entity: runtimeContainer}})
+
+	var buf syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- wmdef.RecordEvents(ctx, s, &buf)
+	}()
+
+	// wait for the recorder to subscribe to every source
+	require.Eventually(t, func() bool {
+		s.subscribersMut.RLock()
+		defer s.subscribersMut.RUnlock()
+		return len(s.subscribers) == 8
+	}, 5*time.Second, 10*time.Millisecond)
+
+	orchestratorContainer := &wmdef.Container{
+		EntityID:   wmdef.EntityID{Kind: wmdef.KindContainer, ID: "abcdef"},
+		EntityMeta: wmdef.EntityMeta{Namespace: "default"},
+		Owner:      &wmdef.EntityID{Kind: wmdef.KindKubernetesPod, ID: "pod-uid"},
+	}
+	pod := &wmdef.KubernetesPod{
+		EntityID:   wmdef.EntityID{Kind: wmdef.KindKubernetesPod, ID: "pod-uid"},
+		EntityMeta: wmdef.EntityMeta{Name: "redis-0", Namespace: "default"},
+	}
+	s.handleEvents([]wmdef.CollectorEvent{
+		{Type: wmdef.EventTypeSet, Source: wmdef.SourceNodeOrchestrator, Entity: orchestratorContainer},
+		{Type: wmdef.EventTypeSet, Source: wmdef.SourceNodeOrchestrator, Entity: pod},
+	})
+	s.handleEvents([]wmdef.CollectorEvent{{Type: wmdef.EventTypeUnset, Source: wmdef.SourceNodeOrchestrator, Entity: pod}})
+
+	cancel()
+	require.NoError(t, <-done)
+
+	events, err := wmdef.ReadRecordedEvents(&buf.b)
+	require.NoError(t, err)
+
+	var recorded []string
+	for _, ev := range events {
+		recorded = append(recorded, ev.Type+" "+string(ev.Source)+" "+string(ev.Kind))
+	}
+	assert.ElementsMatch(t, []string{
+		"set runtime container",
+		"set node_orchestrator container",
+		"set node_orchestrator kubernetes_pod",
+		"unset node_orchestrator kubernetes_pod",
+	}, recorded)
+
+	// replaying the recording into another store rebuilds the merged entities
+	replayStore := newWorkloadmetaObject(t)
+	replayCtx, replayCancel := context.WithCancel(context.Background())
+	defer replayCancel()
+	go func() {
+		for {
+			select {
+			case evs := <-replayStore.eventCh:
+				replayStore.handleEvents(evs)
+			case <-replayCtx.Done():
+				return
+			}
+		}
+	}()
+	require.NoError(t, wmdef.ReplayEvents(replayCtx, replayStore, events, 0))
+
+	require.Eventually(t, func() bool {
+		_, err := replayStore.GetKubernetesPod("pod-uid")
+		container, containerErr := replayStore.GetContainer("abcdef")
+		return err != nil && containerErr == nil && container.Owner != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	container, err := replayStore.GetContainer("abcdef")
+	require.NoError(t, err)
+	assert.Equal(t, "redis", container.Name)
+	assert.Equal(t, "default", container.Namespace)
+	assert.Equal(t, wmdef.ContainerRuntimeContainerd, container.Runtime)
+}
+
+func TestWriteRecordingInvalidDuration(t *testing.T) {
+	s := newWorkloadmetaObject(t)
+
+	for _, duration := range []string{"", "soon", "-1s", "2h"} {
+		w := httptest.NewRecorder()
+		s.writeRecording(w, httptest.NewRequest(http.MethodPost, "/workload-record?duration="+duration, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, duration)
+	}
+}
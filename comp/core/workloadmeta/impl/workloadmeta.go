@@ -60,9 +60,10 @@ type Dependencies struct {
 
 // Provider contains components provided by workloadmeta constructor.
 type Provider struct {
-	Comp          wmdef.Component
-	FlareProvider flaretypes.Provider
-	Endpoint      api.AgentEndpointProvider
+	Comp           wmdef.Component
+	FlareProvider  flaretypes.Provider
+	Endpoint       api.AgentEndpointProvider
+	RecordEndpoint api.AgentEndpointProvider
 }
 
 // NewWorkloadMeta creates a new workloadmeta component.
@@ -109,9 +110,10 @@ func NewWorkloadMeta(deps Dependencies) Provider {
 	}})
 
 	return Provider{
-		Comp:          wm,
-		FlareProvider: flaretypes.NewProvider(wm.sbomFlareProvider),
-		Endpoint:      api.NewAgentEndpointProvider(wm.writeResponse, "/workload-list", "GET"),
+		Comp:           wm,
+		FlareProvider:  flaretypes.NewProvider(wm.sbomFlareProvider),
+		Endpoint:       api.NewAgentEndpointProvider(wm.writeResponse, "/workload-list", "GET"),
+		RecordEndpoint: api.NewAgentEndpointProvider(wm.writeRecording, "/workload-record", "POST"),
 	}
 }
 
@@ -0,0 +1,540 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replayimpl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/core"
+)
+
+// Export formats supported by ExportCapture
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+)
+
+// packetSizeBuckets are the upper bounds of the packet size distribution reported by CaptureStats
+var packetSizeBuckets = []int{64, 256, 1024, 4096, 8192, 65536}
+
+// CaptureFilter selects packets and lines from a capture. Zero values match everything.
+type CaptureFilter struct {
+	// MetricNames are shell patterns (see path.Match) matched against metric names.
+	// Events and service checks are dropped when set.
+	MetricNames  []string
+	From         time.Time
+	To           time.Time
+	Pids         []int32
+	ContainerIDs []string
+}
+
+func (f *CaptureFilter) matchPacket(ts time.Time, pid int32, containerID string) bool {
+	if !f.From.IsZero() && ts.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && ts.After(f.To) {
+		return false
+	}
+	if len(f.Pids) > 0 && !slices.Contains(f.Pids, pid) {
+		return false
+	}
+	if len(f.ContainerIDs) > 0 && !slices.Contains(f.ContainerIDs, containerID) {
+		return false
+	}
+	return true
+}
+
+func (f *CaptureFilter) matchLine(l *captureLine) bool {
+	if len(f.MetricNames) == 0 {
+		return true
+	}
+	if l.kind != lineKindMetric {
+		return false
+	}
+	for _, pattern := range f.MetricNames {
+		if ok, _ := path.Match(pattern, l.name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CapturePacket is a packet read from a capture, with the lines selected by a CaptureFilter
+type CapturePacket struct {
+	Timestamp   time.Time
+	Pid         int32
+	ContainerID string
+	Lines       [][]byte
+	msg         *pb.UnixDogstatsdMsg
+}
+
+// ForEachPacket reads every packet of the capture from the beginning and calls fn with the ones
+// matching filter. Packets with no line left after filtering are skipped.
+func ForEachPacket(tc *TrafficCaptureReader, filter CaptureFilter, fn func(p *CapturePacket) error) error {
+	pidMap, _, err := tc.ReadState()
+	if err != nil && tc.Version >= minStateVersion {
+		return err
+	}
+
+	tc.Seek(0)
+	for {
+		msg, err := tc.ReadNext()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		ts := captureTimestamp(msg.Timestamp, tc.Version)
+		containerID := pidMap[msg.Pid]
+		if !filter.matchPacket(ts, msg.Pid, containerID) {
+			continue
+		}
+
+		payload := msg.Payload
+		if int(msg.PayloadSize) <= len(payload) {
+			payload = payload[:msg.PayloadSize]
+		}
+		var lines [][]byte
+		for _, raw := range bytes.Split(payload, []byte("\n")) {
+			if len(raw) == 0 {
+				continue
+			}
+			l := parseCaptureLine(raw)
+			if filter.matchLine(&l) {
+				lines = append(lines, raw)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		if err := fn(&CapturePacket{
+			Timestamp:   ts,
+			Pid:         msg.Pid,
+			ContainerID: containerID,
+			Lines:       lines,
+			msg:         msg,
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func captureTimestamp(ts int64, version int) time.Time {
+	if version < minNanoVersion {
+		return time.Unix(ts, 0)
+	}
+	return time.Unix(0, ts)
+}
+
+type lineKind int
+
+const (
+	lineKindMetric lineKind = iota
+	lineKindEvent
+	lineKindServiceCheck
+)
+
+func (k lineKind) String() string {
+	switch k {
+	case lineKindEvent:
+		return "event"
+	case lineKindServiceCheck:
+		return "service_check"
+	default:
+		return "metric"
+	}
+}
+
+// captureLine is a lightweight decoding of a dogstatsd message, only meant for inspection:
+// values are not parsed and no validation is done.
+type captureLine struct {
+	kind  lineKind
+	name  string
+	mtype string
+	value string
+	tags  []string
+}
+
+func parseCaptureLine(raw []byte) captureLine {
+	l := captureLine{}
+	switch {
+	case bytes.HasPrefix(raw, []byte("_e{")):
+		l.kind = lineKindEvent
+	case bytes.HasPrefix(raw, []byte("_sc|")):
+		l.kind = lineKindServiceCheck
+	}
+
+	fields := bytes.Split(raw, []byte("|"))
+	for i, field := range fields {
+		if i > 0 && len(field) > 1 && field[0] == '#' {
+			for _, tag := range bytes.Split(field[1:], []byte(",")) {
+				l.tags = append(l.tags, string(tag))
+			}
+		}
+	}
+
+	switch l.kind {
+	case lineKindServiceCheck:
+		if len(fields) > 1 {
+			l.name = string(fields[1])
+		}
+	case lineKindMetric:
+		nameAndValue := fields[0]
+		if sep := bytes.IndexByte(nameAndValue, ':'); sep >= 0 {
+			l.name = string(nameAndValue[:sep])
+			l.value = string(nameAndValue[sep+1:])
+		} else {
+			l.name = string(nameAndValue)
+		}
+		if len(fields) > 1 {
+			l.mtype = string(fields[1])
+		}
+	}
+	return l
+}
+
+// NameCount associates a name with a count
+type NameCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// SenderStats holds the traffic sent by a single process
+type SenderStats struct {
+	Pid         int32  `json:"pid"`
+	ContainerID string `json:"container_id,omitempty"`
+	Packets     int    `json:"packets"`
+	Bytes       int    `json:"bytes"`
+}
+
+// PacketSizeStats holds the distribution of packet sizes, in bytes
+type PacketSizeStats struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+	Avg int `json:"avg"`
+	// Buckets maps the upper bound of each bucket (or "+Inf") to the number of packets in it
+	Buckets map[string]int `json:"buckets"`
+}
+
+// CaptureStats summarizes the contents of a capture
+type CaptureStats struct {
+	Version       int       `json:"version"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	Packets       int       `json:"packets"`
+	Bytes         int       `json:"bytes"`
+	Metrics       int       `json:"metrics"`
+	Events        int       `json:"events"`
+	ServiceChecks int       `json:"service_checks"`
+
+	PacketSizes PacketSizeStats `json:"packet_sizes"`
+	// TopMetrics are the metric names with the most samples
+	TopMetrics []NameCount `json:"top_metrics"`
+	// TopTagKeys are the tag keys with the most distinct values
+	TopTagKeys []NameCount `json:"top_tag_keys"`
+	// TopSenders are the processes that sent the most bytes
+	TopSenders []SenderStats `json:"top_senders"`
+	// Contexts is the number of distinct metric name and tag combinations
+	Contexts int `json:"contexts"`
+}
+
+// ComputeCaptureStats reads the whole capture and returns statistics about the packets matching filter.
+// At most top entries are reported for metric names, tag keys and senders, all of them when top is 0.
+func ComputeCaptureStats(tc *TrafficCaptureReader, filter CaptureFilter, top int) (*CaptureStats, error) {
+	stats := &CaptureStats{
+		Version:     tc.Version,
+		PacketSizes: PacketSizeStats{Buckets: map[string]int{}},
+	}
+	metricNames := map[string]int{}
+	tagValues := map[string]map[string]struct{}{}
+	senders := map[int32]*SenderStats{}
+	contexts := map[string]struct{}{}
+
+	err := ForEachPacket(tc, filter, func(p *CapturePacket) error {
+		size := 0
+		for _, raw := range p.Lines {
+			size += len(raw)
+			l := parseCaptureLine(raw)
+			switch l.kind {
+			case lineKindEvent:
+				stats.Events++
+				continue
+			case lineKindServiceCheck:
+				stats.ServiceChecks++
+				continue
+			}
+			stats.Metrics++
+			metricNames[l.name]++
+			tags := append([]string(nil), l.tags...)
+			sort.Strings(tags)
+			contexts[l.name+"|"+strings.Join(tags, ",")] = struct{}{}
+			for _, tag := range l.tags {
+				key, value := tag, ""
+				if sep := strings.IndexByte(tag, ':'); sep >= 0 {
+					key, value = tag[:sep], tag[sep+1:]
+				}
+				if tagValues[key] == nil {
+					tagValues[key] = map[string]struct{}{}
+				}
+				tagValues[key][value] = struct{}{}
+			}
+		}
+		// account for the line separators
+		size += len(p.Lines) - 1
+
+		if stats.Packets == 0 || p.Timestamp.Before(stats.Start) {
+			stats.Start = p.Timestamp
+		}
+		if p.Timestamp.After(stats.End) {
+			stats.End = p.Timestamp
+		}
+		if stats.Packets == 0 || size < stats.PacketSizes.Min {
+			stats.PacketSizes.Min = size
+		}
+		if size > stats.PacketSizes.Max {
+			stats.PacketSizes.Max = size
+		}
+		stats.PacketSizes.Buckets[packetSizeBucket(size)]++
+		stats.Packets++
+		stats.Bytes += size
+
+		s, ok := senders[p.Pid]
+		if !ok {
+			s = &SenderStats{Pid: p.Pid, ContainerID: p.ContainerID}
+			senders[p.Pid] = s
+		}
+		s.Packets++
+		s.Bytes += size
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.Packets > 0 {
+		stats.PacketSizes.Avg = stats.Bytes / stats.Packets
+	}
+	stats.Contexts = len(contexts)
+	stats.TopMetrics = topNameCounts(metricNames, top)
+
+	tagKeys := make(map[string]int, len(tagValues))
+	for key, values := range tagValues {
+		tagKeys[key] = len(values)
+	}
+	stats.TopTagKeys = topNameCounts(tagKeys, top)
+
+	for _, s := range senders {
+		stats.TopSenders = append(stats.TopSenders, *s)
+	}
+	sort.Slice(stats.TopSenders, func(i, j int) bool {
+		if stats.TopSenders[i].Bytes != stats.TopSenders[j].Bytes {
+			return stats.TopSenders[i].Bytes > stats.TopSenders[j].Bytes
+		}
+		return stats.TopSenders[i].Pid < stats.TopSenders[j].Pid
+	})
+	if top > 0 && len(stats.TopSenders) > top {
+		stats.TopSenders = stats.TopSenders[:top]
+	}
+
+	return stats, nil
+}
+
+func packetSizeBucket(size int) string {
+	for _, bound := range packetSizeBuckets {
+		if size <= bound {
+			return strconv.Itoa(bound)
+		}
+	}
+	return "+Inf"
+}
+
+func topNameCounts(counts map[string]int, top int) []NameCount {
+	res := make([]NameCount, 0, len(counts))
+	for name, count := range counts {
+		res = append(res, NameCount{Name: name, Count: count})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		return res[i].Name < res[j].Name
+	})
+	if top > 0 && len(res) > top {
+		res = res[:top]
+	}
+	return res
+}
+
+// exportedLine is a single dogstatsd message, as written by ExportCapture
+type exportedLine struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Pid         int32     `json:"pid"`
+	ContainerID string    `json:"container_id,omitempty"`
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type,omitempty"`
+	Value       string    `json:"value,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Raw         string    `json:"raw"`
+}
+
+// ExportCapture writes every message matching filter to w, one record per dogstatsd message.
+// JSON output is newline-delimited, CSV output starts with a header row.
+func ExportCapture(tc *TrafficCaptureReader, filter CaptureFilter, w io.Writer, format string) error {
+	var write func(l *exportedLine) error
+	var flush func() error
+
+	switch format {
+	case ExportFormatJSON:
+		enc := json.NewEncoder(w)
+		write = func(l *exportedLine) error { return enc.Encode(l) }
+		flush = func() error { return nil }
+	case ExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"timestamp", "pid", "container_id", "kind", "name", "type", "value", "tags", "raw"}); err != nil {
+			return err
+		}
+		write = func(l *exportedLine) error {
+			return cw.Write([]string{
+				l.Timestamp.Format(time.RFC3339Nano),
+				strconv.Itoa(int(l.Pid)),
+				l.ContainerID,
+				l.Kind,
+				l.Name,
+				l.Type,
+				l.Value,
+				strings.Join(l.Tags, ","),
+				l.Raw,
+			})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		return fmt.Errorf("unsupported export format %q, must be %q or %q", format, ExportFormatJSON, ExportFormatCSV)
+	}
+
+	err := ForEachPacket(tc, filter, func(p *CapturePacket) error {
+		for _, raw := range p.Lines {
+			l := parseCaptureLine(raw)
+			if err := write(&exportedLine{
+				Timestamp:   p.Timestamp,
+				Pid:         p.Pid,
+				ContainerID: p.ContainerID,
+				Kind:        l.kind.String(),
+				Name:        l.name,
+				Type:        l.mtype,
+				Value:       l.value,
+				Tags:        l.tags,
+				Raw:         string(raw),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// WriteFilteredCapture writes a new, uncompressed, capture file to w holding the packets matching
+// filter, so that it can be replayed with dogstatsd-replay. The tagger state of the original capture
+// is kept for the processes still present. It returns the number of packets written.
+func WriteFilteredCapture(tc *TrafficCaptureReader, filter CaptureFilter, w io.Writer) (int, error) {
+	pidMap, state, err := tc.ReadState()
+	if err != nil && tc.Version >= minStateVersion {
+		return 0, err
+	}
+
+	if err := WriteHeader(w); err != nil {
+		return 0, err
+	}
+
+	written := 0
+	keptPids := map[int32]string{}
+	err = ForEachPacket(tc, filter, func(p *CapturePacket) error {
+		payload := bytes.Join(p.Lines, []byte("\n"))
+		msg := &pb.UnixDogstatsdMsg{
+			// captures are always written with the current version, which uses nanoseconds
+			Timestamp:     p.Timestamp.UnixNano(),
+			PayloadSize:   int32(len(payload)),
+			Payload:       payload,
+			Pid:           p.msg.Pid,
+			AncillarySize: p.msg.AncillarySize,
+			Ancillary:     p.msg.Ancillary,
+		}
+		buf, err := proto.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if err := writeRecord(w, buf); err != nil {
+			return err
+		}
+		if containerID, ok := pidMap[p.Pid]; ok {
+			keptPids[p.Pid] = containerID
+		}
+		written++
+		return nil
+	})
+	if err != nil {
+		return written, err
+	}
+
+	pbState := &pb.TaggerState{
+		State:  make(map[string]*pb.Entity),
+		PidMap: keptPids,
+	}
+	for _, containerID := range keptPids {
+		if entity, ok := state[containerID]; ok {
+			pbState.State[containerID] = entity
+		}
+	}
+	s, err := proto.Marshal(pbState)
+	if err != nil {
+		return written, err
+	}
+
+	// state separator, state and state size, as written by TrafficCaptureWriter.writeState
+	if _, err := w.Write([]byte{0, 0, 0, 0}); err != nil {
+		return written, err
+	}
+	if _, err := w.Write(s); err != nil {
+		return written, err
+	}
+	size := make([]byte, 4)
+	binary.LittleEndian.PutUint32(size, uint32(len(s)))
+	_, err = w.Write(size)
+	return written, err
+}
+
+// writeRecord writes a size-prefixed record, the same way TrafficCaptureWriter.Write does
+func writeRecord(w io.Writer, p []byte) error {
+	size := make([]byte, 4)
+	binary.LittleEndian.PutUint32(size, uint32(len(p)))
+	if _, err := w.Write(size); err != nil {
+		return err
+	}
+	_, err := w.Write(p)
+	return err
+}
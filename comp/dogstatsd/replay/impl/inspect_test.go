@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replayimpl
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testContainerID = "container_id://c1371eaf97a11f43ac700fd8524b4ea316d83a7259282a9e9eeac8d071406b22"
+
+func TestParseCaptureLine(t *testing.T) {
+	l := parseCaptureLine([]byte("my.metric:1:2|d|@0.5|#env:prod,team:a|c:abc"))
+	assert.Equal(t, captureLine{kind: lineKindMetric, name: "my.metric", mtype: "d", value: "1:2", tags: []string{"env:prod", "team:a"}}, l)
+
+	l = parseCaptureLine([]byte("_sc|my.check|0|#env:prod"))
+	assert.Equal(t, captureLine{kind: lineKindServiceCheck, name: "my.check", tags: []string{"env:prod"}}, l)
+
+	l = parseCaptureLine([]byte("_e{5,4}:title|text|#env:prod"))
+	assert.Equal(t, lineKindEvent, l.kind)
+	assert.Equal(t, []string{"env:prod"}, l.tags)
+}
+
+func TestComputeCaptureStats(t *testing.T) {
+	tc, err := NewTrafficCaptureReader("resources/test/datadog-capture.dog", 1, false)
+	require.NoError(t, err)
+
+	stats, err := ComputeCaptureStats(tc, CaptureFilter{}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 21, stats.Packets)
+	assert.Equal(t, 21, stats.Metrics)
+	assert.Equal(t, 1, stats.Contexts)
+	assert.Equal(t, []NameCount{{Name: "jaime.uds.test", Count: 21}}, stats.TopMetrics)
+	assert.Equal(t, []NameCount{{Name: "shell", Count: 1}}, stats.TopTagKeys)
+	assert.Len(t, stats.TopSenders, 1)
+	assert.Equal(t, len("jaime.uds.test:8|g|#shell:test"), stats.PacketSizes.Max)
+	assert.Equal(t, map[string]int{"64": 21}, stats.PacketSizes.Buckets)
+
+	// reading again starts from the beginning of the capture
+	stats, err = ComputeCaptureStats(tc, CaptureFilter{ContainerIDs: []string{testContainerID}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 7, stats.Packets)
+	assert.Len(t, stats.TopSenders, 7)
+	assert.Equal(t, testContainerID, stats.TopSenders[0].ContainerID)
+
+	stats, err = ComputeCaptureStats(tc, CaptureFilter{MetricNames: []string{"other.*"}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Packets)
+}
+
+func TestExportCapture(t *testing.T) {
+	tc, err := NewTrafficCaptureReader("resources/test/datadog-capture.dog", 1, false)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportCapture(tc, CaptureFilter{Pids: []int32{2809, 2815}}, &buf, ExportFormatCSV))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"2809", "", "metric", "jaime.uds.test", "g", "8", "shell:test"}, records[1][1:8])
+	assert.Equal(t, testContainerID, records[2][2])
+
+	buf.Reset()
+	require.NoError(t, ExportCapture(tc, CaptureFilter{Pids: []int32{2809}}, &buf, ExportFormatJSON))
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	assert.Contains(t, buf.String(), `"raw":"jaime.uds.test:8|g|#shell:test"`)
+
+	assert.Error(t, ExportCapture(tc, CaptureFilter{}, &buf, "xml"))
+}
+
+func TestWriteFilteredCapture(t *testing.T) {
+	tc, err := NewTrafficCaptureReader("resources/test/datadog-capture.dog", 1, false)
+	require.NoError(t, err)
+
+	from := time.Date(2021, 5, 17, 21, 7, 56, 0, time.UTC)
+	to := time.Date(2021, 5, 17, 21, 7, 57, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "filtered.dog")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	n, err := WriteFilteredCapture(tc, CaptureFilter{From: from, To: to}, f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, 4, n)
+
+	filtered, err := NewTrafficCaptureReader(path, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, int(datadogFileVersion), filtered.Version)
+
+	pidMap, state, err := filtered.ReadState()
+	require.NoError(t, err)
+	assert.Len(t, pidMap, 3)
+	assert.Contains(t, state, testContainerID)
+
+	stats, err := ComputeCaptureStats(filtered, CaptureFilter{}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.Packets)
+	assert.True(t, stats.Start.Equal(from))
+	assert.True(t, stats.End.Equal(to))
+}
@@ -0,0 +1,431 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package defaultforwarder
+
+import (
+	"expvar"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+var (
+	bandwidthShapingExpvars = expvar.Map{}
+
+	tlmBandwidthThrottled = telemetry.NewCounter("transactions", "bandwidth_throttled_seconds",
+		[]string{"domain"}, "Time spent waiting for the bandwidth limit before sending transactions")
+	tlmBandwidthSentBytes = telemetry.NewCounter("transactions", "bandwidth_shaped_bytes",
+		[]string{"domain", "kind"}, "Bytes of transactions sent through the bandwidth limiter")
+)
+
+// defaultBandwidthWeights are the weights used to share the bandwidth between transaction kinds
+// when `forwarder_bandwidth_weights` doesn't override them. Metrics get the largest share.
+var defaultBandwidthWeights = map[transaction.Kind]int{
+	transaction.Series:        8,
+	transaction.Sketches:      8,
+	transaction.ServiceChecks: 4,
+	transaction.Events:        2,
+	transaction.CheckRuns:     2,
+	transaction.Metadata:      1,
+	transaction.Process:       1,
+	transaction.Orchestrator:  1,
+}
+
+func init() {
+	bandwidthShapingExpvars.Init()
+	transaction.ForwarderExpvars.Set("BandwidthShaping", &bandwidthShapingExpvars)
+}
+
+// tokenBucket limits the number of bytes sent per second. It allows bursts up to its capacity.
+// It is not thread safe.
+type tokenBucket struct {
+	rate   float64 // bytes per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// delay returns how long to wait before n bytes can be sent. Payloads larger than the
+// burst only wait for a full bucket, the bucket then goes into debt.
+func (b *tokenBucket) delay(n int, now time.Time) time.Duration {
+	b.refill(now)
+	missing := math.Min(float64(n), b.burst) - b.tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.rate * float64(time.Second))
+}
+
+// take removes n bytes from the bucket
+func (b *tokenBucket) take(n int, now time.Time) {
+	b.refill(now)
+	b.tokens -= float64(n)
+}
+
+// kindQueue holds the transactions of a single kind waiting for bandwidth
+type kindQueue struct {
+	kind       transaction.Kind
+	weight     float64
+	newTx      []transaction.Transaction
+	retriedTx  []transaction.Transaction
+	lastFinish float64
+	sentBytes  int64
+	sentCount  int64
+}
+
+func (q *kindQueue) len() int {
+	return len(q.newTx) + len(q.retriedTx)
+}
+
+// head returns the next transaction of the queue, new transactions go before retried ones
+// like they do in the workers.
+func (q *kindQueue) head() transaction.Transaction {
+	if len(q.newTx) > 0 {
+		return q.newTx[0]
+	}
+	return q.retriedTx[0]
+}
+
+func (q *kindQueue) pop() {
+	if len(q.newTx) > 0 {
+		q.newTx[0] = nil
+		q.newTx = q.newTx[1:]
+		return
+	}
+	q.retriedTx[0] = nil
+	q.retriedTx = q.retriedTx[1:]
+}
+
+// bandwidthShaper sits between a domainForwarder queues and its workers. It shares the
+// bandwidth between transaction kinds with a start-time weighted fair queuing and limits
+// the total bandwidth with a token bucket. The limit is local to the domainForwarder: the
+// forwarders of other processes sending to the same domain have their own limit.
+type bandwidthShaper struct {
+	log    log.Component
+	domain string
+
+	high <-chan transaction.Transaction
+	low  <-chan transaction.Transaction
+	out  chan transaction.Transaction
+
+	stopChan chan struct{}
+	stopped  chan struct{}
+
+	maxQueued int
+
+	// m protects the fields below, which are read by the status page
+	m           sync.Mutex
+	bucket      *tokenBucket
+	queues      []*kindQueue
+	virtualTime float64
+	queued      int
+	throttled   time.Duration
+}
+
+func newBandwidthShaper(
+	config config.Component,
+	log log.Component,
+	domain string,
+	high <-chan transaction.Transaction,
+	low <-chan transaction.Transaction,
+) *bandwidthShaper {
+	rate := config.GetInt("forwarder_bandwidth_limit_bytes_per_sec")
+	burst := config.GetInt("forwarder_bandwidth_burst_bytes")
+	if burst <= 0 {
+		burst = rate
+	}
+
+	weights := make(map[transaction.Kind]int, len(defaultBandwidthWeights))
+	for kind, weight := range defaultBandwidthWeights {
+		weights[kind] = weight
+	}
+	var configuredWeights map[string]interface{}
+	if config.IsSet("forwarder_bandwidth_weights") {
+		configuredWeights = config.GetStringMap("forwarder_bandwidth_weights")
+	}
+	for name, value := range configuredWeights {
+		kind, ok := kindFromName(name)
+		if !ok {
+			log.Warnf("Unknown transaction kind %q in forwarder_bandwidth_weights, ignoring it", name)
+			continue
+		}
+		weight, err := weightFromValue(value)
+		if err != nil || weight <= 0 {
+			log.Warnf("Invalid weight %v for %q in forwarder_bandwidth_weights, it must be a positive integer", value, name)
+			continue
+		}
+		weights[kind] = weight
+	}
+
+	queues := make([]*kindQueue, 0, len(weights))
+	for _, kind := range sortedKinds(weights) {
+		queues = append(queues, &kindQueue{kind: kind, weight: float64(weights[kind])})
+	}
+
+	s := &bandwidthShaper{
+		log:       log,
+		domain:    domain,
+		high:      high,
+		low:       low,
+		out:       make(chan transaction.Transaction),
+		stopChan:  make(chan struct{}),
+		stopped:   make(chan struct{}),
+		maxQueued: config.GetInt("forwarder_high_prio_buffer_size") + config.GetInt("forwarder_low_prio_buffer_size"),
+		bucket:    newTokenBucket(rate, burst, time.Now()),
+		queues:    queues,
+	}
+	bandwidthShapingExpvars.Set(domain, expvar.Func(func() interface{} { return s.getStatus() }))
+	return s
+}
+
+// enabledBandwidthShaping returns true when a bandwidth limit is configured
+func enabledBandwidthShaping(config config.Component) bool {
+	return config.GetInt("forwarder_bandwidth_limit_bytes_per_sec") > 0
+}
+
+func (s *bandwidthShaper) queueFor(kind transaction.Kind) *kindQueue {
+	for _, q := range s.queues {
+		if q.kind == kind {
+			return q
+		}
+	}
+	// kinds without a weight share the lowest one
+	q := &kindQueue{kind: kind, weight: 1}
+	s.queues = append(s.queues, q)
+	return q
+}
+
+func (s *bandwidthShaper) enqueue(t transaction.Transaction, retried bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	q := s.queueFor(t.GetKind())
+	if q.len() == 0 {
+		// a kind becoming active starts at the current virtual time: idle kinds don't accumulate credit
+		q.lastFinish = math.Max(q.lastFinish, s.virtualTime)
+	}
+	if retried {
+		q.retriedTx = append(q.retriedTx, t)
+	} else {
+		q.newTx = append(q.newTx, t)
+	}
+	s.queued++
+}
+
+// next returns the queue holding the next transaction to send: the one with the smallest
+// virtual finish time, sizes being divided by the queue weights.
+func (s *bandwidthShaper) next() *kindQueue {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var best *kindQueue
+	bestFinish := math.Inf(1)
+	for _, q := range s.queues {
+		if q.len() == 0 {
+			continue
+		}
+		// the start tag of a backlogged queue is the finish tag of its previous transaction
+		finish := q.lastFinish + float64(q.head().GetPayloadSize())/q.weight
+		if finish < bestFinish {
+			best, bestFinish = q, finish
+		}
+	}
+	return best
+}
+
+// delay returns how long to wait before the head of q can be sent
+func (s *bandwidthShaper) delay(q *kindQueue, now time.Time) time.Duration {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.bucket.delay(q.head().GetPayloadSize(), now)
+}
+
+// sent removes the head of q once it has been handed to a worker
+func (s *bandwidthShaper) sent(q *kindQueue, now time.Time) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	size := q.head().GetPayloadSize()
+	s.virtualTime = q.lastFinish
+	q.lastFinish += float64(size) / q.weight
+	q.sentBytes += int64(size)
+	q.sentCount++
+	q.pop()
+	s.queued--
+	s.bucket.take(size, now)
+	tlmBandwidthSentBytes.Add(float64(size), s.domain, q.kind.String())
+}
+
+func (s *bandwidthShaper) addThrottled(d time.Duration) {
+	s.m.Lock()
+	s.throttled += d
+	s.m.Unlock()
+	tlmBandwidthThrottled.Add(d.Seconds(), s.domain)
+}
+
+func (s *bandwidthShaper) start() {
+	go s.run()
+}
+
+func (s *bandwidthShaper) run() {
+	defer close(s.stopped)
+
+	for {
+		high, low := s.high, s.low
+		if s.queued >= s.maxQueued {
+			// stop reading: the input queues fill up and new transactions go to the retry queue
+			high, low = nil, nil
+		}
+
+		var out chan transaction.Transaction
+		var next transaction.Transaction
+		var timer *time.Timer
+		var timerChan <-chan time.Time
+		var waitStart time.Time
+		q := s.next()
+		if q != nil {
+			now := time.Now()
+			if d := s.delay(q, now); d > 0 {
+				timer = time.NewTimer(d)
+				timerChan = timer.C
+				waitStart = now
+			} else {
+				out = s.out
+				next = q.head()
+			}
+		}
+
+		select {
+		case t, ok := <-high:
+			if ok {
+				s.enqueue(t, false)
+			} else {
+				s.high = nil
+			}
+		case t, ok := <-low:
+			if ok {
+				s.enqueue(t, true)
+			} else {
+				s.low = nil
+			}
+		case <-timerChan:
+		case out <- next:
+			s.sent(q, time.Now())
+		case <-s.stopChan:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+
+		if timer != nil {
+			timer.Stop()
+			s.addThrottled(time.Since(waitStart))
+		}
+	}
+}
+
+// stop stops the shaper and returns the transactions that were waiting for bandwidth: the new
+// ones read from the high priority queue and the retried ones read from the low priority queue
+func (s *bandwidthShaper) stop() (highPrio []transaction.Transaction, lowPrio []transaction.Transaction) {
+	close(s.stopChan)
+	<-s.stopped
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for _, q := range s.queues {
+		highPrio = append(highPrio, q.newTx...)
+		lowPrio = append(lowPrio, q.retriedTx...)
+		q.newTx, q.retriedTx = nil, nil
+	}
+	s.queued = 0
+	bandwidthShapingExpvars.Delete(s.domain)
+	return highPrio, lowPrio
+}
+
+func (s *bandwidthShaper) getStatus() map[string]interface{} {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.bucket.refill(time.Now())
+	kinds := make(map[string]interface{}, len(s.queues))
+	for _, q := range s.queues {
+		kinds[q.kind.String()] = map[string]interface{}{
+			"Weight":    int(q.weight),
+			"Queued":    q.len(),
+			"SentBytes": q.sentBytes,
+			"SentCount": q.sentCount,
+		}
+	}
+	return map[string]interface{}{
+		"LimitBytesPerSecond": int64(s.bucket.rate),
+		"BurstBytes":          int64(s.bucket.burst),
+		"AvailableBytes":      int64(math.Max(0, s.bucket.tokens)),
+		"Queued":              s.queued,
+		"ThrottledSeconds":    int64(s.throttled.Seconds()),
+		"Kinds":               kinds,
+	}
+}
+
+func kindFromName(name string) (transaction.Kind, bool) {
+	for kind := range defaultBandwidthWeights {
+		if kind.String() == name {
+			return kind, true
+		}
+	}
+	return 0, false
+}
+
+func sortedKinds(weights map[transaction.Kind]int) []transaction.Kind {
+	kinds := make([]transaction.Kind, 0, len(weights))
+	for kind := range weights {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	return kinds
+}
+
+// weightFromValue converts a weight read from the configuration, which is a string when set
+// from the environment
+func weightFromValue(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case string:
+		return strconv.Atoi(v)
+	default:
+		return 0, fmt.Errorf("unsupported type %T", value)
+	}
+}
@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package defaultforwarder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+)
+
+func newShapedTransaction(kind transaction.Kind, size int) *transaction.HTTPTransaction {
+	t := transaction.NewHTTPTransaction()
+	t.Kind = kind
+	t.Payload = transaction.NewBytesPayloadWithoutMetaData(make([]byte, size))
+	return t
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(100, 200, now)
+
+	assert.Zero(t, b.delay(150, now))
+	b.take(150, now)
+	assert.Equal(t, 500*time.Millisecond, b.delay(100, now))
+	// payloads larger than the burst wait for a full bucket
+	assert.Equal(t, 1500*time.Millisecond, b.delay(1000, now))
+
+	now = now.Add(10 * time.Second)
+	assert.Zero(t, b.delay(200, now))
+	b.take(1000, now)
+	assert.Equal(t, 8*time.Second, b.delay(100, now.Add(time.Second)))
+}
+
+func TestBandwidthShaperWeightedFairQueuing(t *testing.T) {
+	mockConfig := pkgconfigsetup.Conf()
+	mockConfig.SetWithoutSource("forwarder_bandwidth_limit_bytes_per_sec", 1000)
+	mockConfig.SetWithoutSource("forwarder_bandwidth_weights", map[string]interface{}{"series": 3, "metadata": "1", "unknown": 2})
+	// the shaper isn't started, the test drives it
+	s := newBandwidthShaper(mockConfig, logmock.New(t), "test", nil, nil)
+	defer bandwidthShapingExpvars.Delete("test")
+
+	for i := 0; i < 6; i++ {
+		s.enqueue(newShapedTransaction(transaction.Series, 90), false)
+		s.enqueue(newShapedTransaction(transaction.Metadata, 90), false)
+	}
+
+	var kinds []transaction.Kind
+	for i := 0; i < 8; i++ {
+		q := s.next()
+		require.NotNil(t, q)
+		kinds = append(kinds, q.kind)
+		s.sent(q, time.Now())
+	}
+	assert.Equal(t, []transaction.Kind{
+		transaction.Series, transaction.Series, transaction.Series, transaction.Metadata,
+		transaction.Series, transaction.Series, transaction.Series, transaction.Metadata,
+	}, kinds)
+
+	// a kind becoming active doesn't get credit for the time it was idle
+	s.enqueue(newShapedTransaction(transaction.Events, 90), false)
+	q := s.next()
+	require.NotNil(t, q)
+	assert.EqualValues(t, transaction.Events, q.kind)
+	assert.Equal(t, float64(90), q.lastFinish)
+
+	// new transactions go before retried ones of the same kind
+	retried := newShapedTransaction(transaction.Sketches, 1)
+	fresh := newShapedTransaction(transaction.Sketches, 1)
+	s.enqueue(retried, true)
+	s.enqueue(fresh, false)
+	q = s.next()
+	require.NotNil(t, q)
+	assert.Same(t, fresh, q.head())
+}
+
+func TestBandwidthShaperOrchestratorWeight(t *testing.T) {
+	mockConfig := pkgconfigsetup.Conf()
+	mockConfig.SetWithoutSource("forwarder_bandwidth_limit_bytes_per_sec", 1000)
+	mockConfig.SetWithoutSource("forwarder_bandwidth_weights", map[string]interface{}{"orchestrator": 2})
+	s := newBandwidthShaper(mockConfig, logmock.New(t), "test", nil, nil)
+	defer bandwidthShapingExpvars.Delete("test")
+
+	for i := 0; i < 4; i++ {
+		s.enqueue(newShapedTransaction(transaction.Orchestrator, 90), false)
+		s.enqueue(newShapedTransaction(transaction.Process, 90), false)
+	}
+
+	sent := map[transaction.Kind]int{}
+	for i := 0; i < 6; i++ {
+		q := s.next()
+		require.NotNil(t, q)
+		sent[q.kind]++
+		s.sent(q, time.Now())
+	}
+	// orchestrator payloads get twice the share of process payloads instead of sharing it
+	assert.Equal(t, map[transaction.Kind]int{transaction.Orchestrator: 4, transaction.Process: 2}, sent)
+}
+
+func TestBandwidthShaperLimit(t *testing.T) {
+	mockConfig := pkgconfigsetup.Conf()
+	mockConfig.SetWithoutSource("forwarder_bandwidth_limit_bytes_per_sec", 1000)
+	high := make(chan transaction.Transaction, 10)
+	low := make(chan transaction.Transaction, 10)
+	s := newBandwidthShaper(mockConfig, logmock.New(t), "test", high, low)
+	s.start()
+
+	for i := 0; i < 3; i++ {
+		high <- newShapedTransaction(transaction.Series, 500)
+	}
+	low <- newShapedTransaction(transaction.Series, 500)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		<-s.out
+	}
+	// the burst allows the first two transactions, the third one waits for 500 bytes
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+
+	status := s.getStatus()
+	assert.EqualValues(t, 1000, status["LimitBytesPerSecond"])
+	assert.EqualValues(t, 1000, status["BurstBytes"])
+	series := status["Kinds"].(map[string]interface{})["series"].(map[string]interface{})
+	assert.EqualValues(t, 3, series["SentCount"])
+	assert.EqualValues(t, 1500, series["SentBytes"])
+
+	highPrio, lowPrio := s.stop()
+	assert.Len(t, append(highPrio, lowPrio...), 1)
+}
+
+func TestDomainForwarderBandwidthShaping(t *testing.T) {
+	mockConfig := pkgconfigsetup.Conf()
+	mockConfig.SetWithoutSource("forwarder_bandwidth_limit_bytes_per_sec", 1)
+	forwarder := newDomainForwarderForTest(mockConfig, logmock.New(t), 0, false)
+	require.NoError(t, forwarder.Start())
+	require.NotNil(t, forwarder.shaper)
+
+	first := newTestTransactionWithKindDomainForwarder(transaction.Series)
+	first.On("Process", forwarder.workers[0].Client).Return(nil).Times(1)
+	forwarder.sendHTTPTransactions(first)
+	<-first.processed
+
+	// the next transactions wait for the bandwidth and end up in the retry queue when stopping
+	for i := 0; i < 2; i++ {
+		tr := newTestTransactionWithKindDomainForwarder(transaction.Series)
+		tr.On("GetCreatedAt").Return(time.Now())
+		forwarder.sendHTTPTransactions(tr)
+	}
+	require.Eventually(t, func() bool {
+		return forwarder.shaper.getStatus()["Queued"] == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	forwarder.Stop(false)
+	requireLenForwarderRetryQueue(t, forwarder, 2)
+	first.AssertExpectations(t)
+}
+
+func TestDomainForwarderBandwidthShapingStopPurgeHighPrio(t *testing.T) {
+	mockConfig := pkgconfigsetup.Conf()
+	mockConfig.SetWithoutSource("forwarder_bandwidth_limit_bytes_per_sec", 1)
+	forwarder := newDomainForwarderForTest(mockConfig, logmock.New(t), 0, false)
+	require.NoError(t, forwarder.Start())
+	client := forwarder.workers[0].Client
+
+	first := newTestTransactionWithKindDomainForwarder(transaction.Series)
+	first.On("Process", client).Return(nil).Times(1)
+	forwarder.sendHTTPTransactions(first)
+	<-first.processed
+
+	// waiting for the bandwidth: a new transaction and a retried one
+	pending := newTestTransactionWithKindDomainForwarder(transaction.Series)
+	pending.On("Process", client).Return(nil).Times(1)
+	forwarder.sendHTTPTransactions(pending)
+	retried := newTestTransactionWithKindDomainForwarder(transaction.Series)
+	retried.On("GetCreatedAt").Return(time.Now())
+	forwarder.lowPrio <- retried
+	require.Eventually(t, func() bool {
+		return forwarder.shaper.getStatus()["Queued"] == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// the high priority transaction is sent despite the limit, the retried one is kept
+	forwarder.Stop(true)
+	select {
+	case <-pending.processed:
+	default:
+		assert.Fail(t, "the pending high priority transaction wasn't sent")
+	}
+	requireLenForwarderRetryQueue(t, forwarder, 1)
+	first.AssertExpectations(t)
+	pending.AssertExpectations(t)
+	retried.AssertNotCalled(t, "Process", client)
+}
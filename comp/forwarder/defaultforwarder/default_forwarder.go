@@ -661,17 +661,21 @@ func (f *DefaultForwarder) SubmitOrchestratorChecks(payload transaction.BytesPay
 		endpoint = endpoints.LegacyOrchestratorEndpoint
 	}
 
-	return f.submitProcessLikePayload(endpoint, payload, extra, true)
+	return f.submitProcessLikePayloadOfKind(endpoint, payload, extra, true, transaction.Orchestrator)
 }
 
 // SubmitOrchestratorManifests sends orchestrator manifests
 func (f *DefaultForwarder) SubmitOrchestratorManifests(payload transaction.BytesPayloads, extra http.Header) (chan Response, error) {
 	transactionsOrchestratorManifest.Add(1)
-	return f.submitProcessLikePayload(endpoints.OrchestratorManifestEndpoint, payload, extra, true)
+	return f.submitProcessLikePayloadOfKind(endpoints.OrchestratorManifestEndpoint, payload, extra, true, transaction.Orchestrator)
 }
 
 func (f *DefaultForwarder) submitProcessLikePayload(ep transaction.Endpoint, payload transaction.BytesPayloads, extra http.Header, retryable bool) (chan Response, error) {
-	transactions := f.createHTTPTransactions(ep, payload, transaction.Process, extra)
+	return f.submitProcessLikePayloadOfKind(ep, payload, extra, retryable, transaction.Process)
+}
+
+func (f *DefaultForwarder) submitProcessLikePayloadOfKind(ep transaction.Endpoint, payload transaction.BytesPayloads, extra http.Header, retryable bool, kind transaction.Kind) (chan Response, error) {
+	transactions := f.createHTTPTransactions(ep, payload, kind, extra)
 	results := make(chan Response, len(transactions))
 	internalResults := make(chan Response, len(transactions))
 	expectedResponses := len(transactions)
@@ -45,6 +45,8 @@ type domainForwarder struct {
 	transactionPrioritySorter retry.TransactionPrioritySorter
 	blockedList               *blockedEndpoints
 	pointCountTelemetry       *retry.PointCountTelemetry
+	// shaper limits the bandwidth used by the workers, it is nil when no limit is configured
+	shaper *bandwidthShaper
 }
 
 func newDomainForwarder(
@@ -209,8 +211,17 @@ func (f *domainForwarder) Start() error {
 	// reset internal state to purge transactions from past starts
 	f.init()
 
+	// workers read from the queues directly unless the bandwidth is limited, in which case
+	// the shaper decides which transaction is sent next and when
+	var workersHighPrio, workersLowPrio <-chan transaction.Transaction = f.highPrio, f.lowPrio
+	if enabledBandwidthShaping(f.config) {
+		f.shaper = newBandwidthShaper(f.config, f.log, f.domain, f.highPrio, f.lowPrio)
+		f.shaper.start()
+		workersHighPrio, workersLowPrio = f.shaper.out, nil
+	}
+
 	for i := 0; i < f.numberOfWorkers; i++ {
-		w := NewWorker(f.config, f.log, f.highPrio, f.lowPrio, f.requeuedTransaction, f.blockedList, f.pointCountTelemetry)
+		w := NewWorker(f.config, f.log, workersHighPrio, workersLowPrio, f.requeuedTransaction, f.blockedList, f.pointCountTelemetry)
 		w.Start()
 		f.workers = append(f.workers, w)
 	}
@@ -238,9 +249,23 @@ func (f *domainForwarder) Stop(purgeHighPrio bool) {
 		f.stopConnectionReset <- true
 	}
 	f.stopRetry <- true
+
+	// transactions waiting for bandwidth are kept in the retry queue so they can be flushed to disk
+	shaping := f.shaper != nil
+	var shapedHighPrio, shapedLowPrio []transaction.Transaction
+	if shaping {
+		shapedHighPrio, shapedLowPrio = f.shaper.stop()
+		f.shaper = nil
+	}
+
 	for _, w := range f.workers {
 		w.Stop(purgeHighPrio)
 	}
+	if shaping && purgeHighPrio && len(f.workers) > 0 {
+		// the workers read from the shaper, which held the high priority transactions
+		f.purgeShapedHighPrio(f.workers[0], shapedHighPrio)
+		shapedHighPrio = nil
+	}
 	f.workers = []*Worker{}
 	close(f.highPrio)
 	close(f.lowPrio)
@@ -249,6 +274,20 @@ func (f *domainForwarder) Stop(purgeHighPrio bool) {
 	for t := range f.requeuedTransaction {
 		f.requeueTransaction(t)
 	}
+	if shaping {
+		for t := range f.highPrio {
+			shapedHighPrio = append(shapedHighPrio, t)
+		}
+		for t := range f.lowPrio {
+			shapedLowPrio = append(shapedLowPrio, t)
+		}
+		for _, t := range shapedHighPrio {
+			f.addToTransactionRetryQueue(t)
+		}
+		for _, t := range shapedLowPrio {
+			f.addToTransactionRetryQueue(t)
+		}
+	}
 	if err := f.retryQueue.FlushToDisk(); err != nil {
 		f.log.Errorf("Error when flushing the retry queue to disk: %v", err)
 	}
@@ -257,6 +296,25 @@ func (f *domainForwarder) Stop(purgeHighPrio bool) {
 	f.internalState = Stopped
 }
 
+// purgeShapedHighPrio sends the high priority transactions held by the bandwidth shaper and the
+// ones it didn't read yet with the stopped worker w, ignoring the bandwidth limit. Transactions
+// that fail are requeued by the worker.
+func (f *domainForwarder) purgeShapedHighPrio(w *Worker, shaped []transaction.Transaction) {
+	for _, t := range shaped {
+		f.log.Debugf("Flushing one new transaction before stopping the domainForwarder")
+		w.callProcess(t) //nolint:errcheck
+	}
+	for {
+		select {
+		case t := <-f.highPrio:
+			f.log.Debugf("Flushing one new transaction before stopping the domainForwarder")
+			w.callProcess(t) //nolint:errcheck
+		default:
+			return
+		}
+	}
+}
+
 func (f *domainForwarder) State() uint32 {
 	// Lock so we can't start/stop a Forwarder while getting its state
 	f.m.Lock()
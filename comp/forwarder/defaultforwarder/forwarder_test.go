@@ -346,6 +346,39 @@ func TestSubmitV1Intake(t *testing.T) {
 	}
 }
 
+func TestSubmitOrchestratorKind(t *testing.T) {
+	mockConfig := pkgconfigsetup.Conf()
+	log := logmock.New(t)
+	forwarder := NewDefaultForwarder(mockConfig, log, NewOptionsWithResolvers(mockConfig, log, resolver.NewSingleDomainResolvers(monoKeysDomains)))
+	forwarder.Start()
+	defer forwarder.Stop()
+
+	inputQueue := make(chan transaction.Transaction, 2)
+	df := forwarder.domainForwarders[testVersionDomain]
+	bk := df.highPrio
+	df.highPrio = inputQueue
+	defer func() { df.highPrio = bk }()
+
+	p := []byte("test")
+	for _, submit := range []func(transaction.BytesPayloads, http.Header) (chan Response, error){
+		forwarder.SubmitOrchestratorManifests,
+		forwarder.SubmitProcessChecks,
+	} {
+		_, err := submit(transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&p}), make(http.Header))
+		require.NoError(t, err)
+	}
+
+	// orchestrator payloads have their own kind so that their bandwidth share can be configured
+	for _, kind := range []transaction.Kind{transaction.Orchestrator, transaction.Process} {
+		select {
+		case tr := <-df.highPrio:
+			assert.Equal(t, kind, tr.GetKind())
+		case <-time.After(1 * time.Second):
+			require.Fail(t, "highPrio queue should contain a transaction")
+		}
+	}
+}
+
 // TestForwarderEndtoEnd is a simple test to see if a payload is well broadcast
 // between every components of the forwarder. Corner cases and error are tested
 // per component.
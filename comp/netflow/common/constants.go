@@ -26,4 +26,13 @@ const (
 
 	// DefaultPrometheusListenerAddress is the default goflow prometheus listener address
 	DefaultPrometheusListenerAddress = "localhost:9090"
+
+	// DefaultTopTalkersSize is the default number of top talkers kept per dimension
+	DefaultTopTalkersSize = 10
+
+	// DefaultTopTalkersApplicationField is the default additional field used as application of the flows
+	DefaultTopTalkersApplicationField = "application"
 )
+
+// TopTalkersDimensions are the dimensions by which the top talkers are ranked
+var TopTalkersDimensions = []string{"source", "destination", "port", "interface", "application"}
@@ -9,8 +9,10 @@ package common
 import (
 	"bytes"
 	"encoding/binary"
-	flowmessage "github.com/netsampler/goflow2/pb"
+	"fmt"
 	"hash/fnv"
+
+	flowmessage "github.com/netsampler/goflow2/pb"
 )
 
 // Flow contains flow info used for aggregation
@@ -74,6 +76,9 @@ type Flow struct {
 
 	// Configured fields
 	AdditionalFields AdditionalFields
+
+	// Configured fields used as aggregation keys, sorted
+	AggregationKeys []string // FLOW KEY
 }
 
 // AdditionalFields holds additional fields collected
@@ -105,6 +110,15 @@ var (
 	Integer FieldType = "integer"
 	// Hex type is used to configure a hex additional field
 	Hex FieldType = "hex"
+	// IP type is used to configure an IPv4/IPv6 address additional field
+	IP FieldType = "ip"
+	// MAC type is used to configure a mac address additional field
+	MAC FieldType = "mac"
+	// ApplicationID type is used to configure an application ID additional field (RFC 6759),
+	// like the ones exported by Cisco AVC
+	ApplicationID FieldType = "application_id"
+	// AllFieldTypes contains all the valid additional field types
+	AllFieldTypes = []FieldType{String, Integer, Hex, IP, MAC, ApplicationID}
 	// DefaultFieldTypes contains types for default payload fields
 	DefaultFieldTypes = map[string]FieldType{
 		"direction":         Integer,
@@ -143,6 +157,10 @@ func (f *Flow) AggregationHash() uint64 {
 	binary.Write(h, binary.LittleEndian, f.IPProtocol)     //nolint:errcheck
 	binary.Write(h, binary.LittleEndian, f.Tos)            //nolint:errcheck
 	binary.Write(h, binary.LittleEndian, f.InputInterface) //nolint:errcheck
+	for _, key := range f.AggregationKeys {
+		// null separators avoid collisions between the concatenated keys and values
+		h.Write([]byte(key + "\x00" + f.additionalFieldKeyValue(key) + "\x00")) //nolint:errcheck
+	}
 	return h.Sum64()
 }
 
@@ -157,8 +175,31 @@ func IsEqualFlowContext(a Flow, b Flow) bool {
 		a.DstPort == b.DstPort &&
 		a.IPProtocol == b.IPProtocol &&
 		a.Tos == b.Tos &&
-		a.InputInterface == b.InputInterface {
+		a.InputInterface == b.InputInterface &&
+		isEqualAggregationKeys(a, b) {
 		return true
 	}
 	return false
 }
+
+func isEqualAggregationKeys(a Flow, b Flow) bool {
+	if len(a.AggregationKeys) != len(b.AggregationKeys) {
+		return false
+	}
+	for i, key := range a.AggregationKeys {
+		if key != b.AggregationKeys[i] || a.additionalFieldKeyValue(key) != b.additionalFieldKeyValue(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// additionalFieldKeyValue returns the value of an additional field used as aggregation key,
+// a missing field is an empty value
+func (f *Flow) additionalFieldKeyValue(key string) string {
+	value, ok := f.AdditionalFields[key]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -158,3 +158,42 @@ func TestFlow_IsEqualFlowContext(t *testing.T) {
 	flow.Bytes = 999
 	assert.True(t, IsEqualFlowContext(origFlow, flow))
 }
+
+func TestFlow_AggregationKeys(t *testing.T) {
+	origFlow := Flow{
+		Namespace:        "default",
+		ExporterAddr:     []byte{127, 0, 0, 1},
+		SrcAddr:          []byte{1, 2, 3, 4},
+		DstAddr:          []byte{2, 3, 4, 5},
+		IPProtocol:       6,
+		SrcPort:          2000,
+		DstPort:          80,
+		AdditionalFields: AdditionalFields{"application": "ssl", "user": "alice"},
+	}
+	withoutKeysHash := origFlow.AggregationHash()
+
+	origFlow.AggregationKeys = []string{"application"}
+	origHash := origFlow.AggregationHash()
+	assert.NotEqual(t, withoutKeysHash, origHash)
+
+	// fields which are not aggregation keys don't change the hash
+	flow := origFlow
+	flow.AdditionalFields = AdditionalFields{"application": "ssl", "user": "bob"}
+	assert.Equal(t, origHash, flow.AggregationHash())
+	assert.True(t, IsEqualFlowContext(origFlow, flow))
+
+	flow = origFlow
+	flow.AdditionalFields = AdditionalFields{"application": "http", "user": "alice"}
+	assert.NotEqual(t, origHash, flow.AggregationHash())
+	assert.False(t, IsEqualFlowContext(origFlow, flow))
+
+	flow = origFlow
+	flow.AdditionalFields = nil
+	assert.NotEqual(t, origHash, flow.AggregationHash())
+	assert.False(t, IsEqualFlowContext(origFlow, flow))
+
+	flow = origFlow
+	flow.AggregationKeys = []string{"application", "user"}
+	assert.NotEqual(t, origHash, flow.AggregationHash())
+	assert.False(t, IsEqualFlowContext(origFlow, flow))
+}
@@ -8,6 +8,7 @@ package config
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
@@ -35,6 +36,20 @@ type NetflowConfig struct {
 	PrometheusListenerEnabled bool   `mapstructure:"prometheus_listener_enabled"`
 
 	ReverseDNSEnrichmentEnabled bool `mapstructure:"reverse_dns_enrichment_enabled"`
+
+	TopTalkers TopTalkersConfig `mapstructure:"top_talkers"`
+}
+
+// TopTalkersConfig contains configuration for the top talkers computed locally from the flows
+type TopTalkersConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Size is the number of top talkers kept per dimension
+	Size       int      `mapstructure:"size"`
+	Dimensions []string `mapstructure:"dimensions"`
+	// ApplicationField is the additional field used by the application dimension
+	ApplicationField string `mapstructure:"application_field"`
+	// Interval is the duration in seconds of the time windows over which the top talkers are ranked
+	Interval int `mapstructure:"interval"`
 }
 
 // ListenerConfig contains configuration for a single flow listener
@@ -47,14 +62,27 @@ type ListenerConfig struct {
 	Mapping   []Mapping       `mapstructure:"mapping"`
 }
 
-// Mapping contains configuration for a Netflow/IPFIX field mapping
+// Mapping contains configuration for a Netflow/IPFIX field or sFlow record mapping
 type Mapping struct {
+	// Enterprise is the private enterprise number (PEN) of IPFIX enterprise-specific elements and sFlow records,
+	// 0 for standard elements
+	Enterprise  uint32            `mapstructure:"enterprise"`
 	Field       uint16            `mapstructure:"field"`
 	Destination string            `mapstructure:"destination"`
 	Endian      common.EndianType `mapstructure:"endianness"`
 	Type        common.FieldType  `mapstructure:"type"`
+	// AggregationKey makes flows with different values of the field aggregated separately
+	AggregationKey bool `mapstructure:"aggregation_key"`
 }
 
+const (
+	// the first bit of IPFIX enterprise-specific field IDs is the enterprise bit
+	maxIPFIXEnterpriseField = 1<<15 - 1
+	// sFlow record data formats are made of a 20 bits enterprise number and a 12 bits format
+	maxSFlowEnterprise = 1<<20 - 1
+	maxSFlowFormat     = 1<<12 - 1
+)
+
 // ReadConfig builds and returns configuration from Agent configuration.
 func ReadConfig(conf config.Component, logger log.Component) (*NetflowConfig, error) {
 	var mainConfig NetflowConfig
@@ -109,6 +137,26 @@ func (mainConfig *NetflowConfig) SetDefaults(namespace string, logger log.Compon
 				logger.Warnf("ignoring invalid mapping type %s for netflow field %s, type %s must be used for %s", mapping.Type, mapping.Destination, fieldType, mapping.Destination)
 				mapping.Type = fieldType
 			}
+			if mapping.Type != "" && !slices.Contains(common.AllFieldTypes, mapping.Type) {
+				logger.Warnf("ignoring invalid mapping type %s for netflow field %s (valid types: %v), type %s is used", mapping.Type, mapping.Destination, common.AllFieldTypes, common.Hex)
+				mapping.Type = common.Hex
+			}
+			if ok && mapping.AggregationKey {
+				logger.Warnf("ignoring aggregation_key for netflow field %s, default fields can't be used as aggregation keys", mapping.Destination)
+				mapping.AggregationKey = false
+			}
+			if mapping.Enterprise != 0 && listenerConfig.FlowType == common.TypeNetFlow9 {
+				return fmt.Errorf("the mapping of field `%s` can't have an enterprise number, NetFlow v9 fields are not enterprise-specific", mapping.Destination)
+			}
+			if mapping.Enterprise != 0 && mapping.Field > maxIPFIXEnterpriseField && listenerConfig.FlowType == common.TypeIPFIX {
+				return fmt.Errorf("the ID of IPFIX enterprise-specific field `%s` must be lower than %d", mapping.Destination, maxIPFIXEnterpriseField+1)
+			}
+			if mapping.Enterprise > maxSFlowEnterprise && listenerConfig.FlowType == common.TypeSFlow5 {
+				return fmt.Errorf("the enterprise number of sFlow record `%s` must be lower than %d", mapping.Destination, maxSFlowEnterprise+1)
+			}
+			if mapping.Field > maxSFlowFormat && listenerConfig.FlowType == common.TypeSFlow5 {
+				return fmt.Errorf("the format of sFlow record `%s` must be lower than %d", mapping.Destination, maxSFlowFormat+1)
+			}
 		}
 	}
 
@@ -137,9 +185,55 @@ func (mainConfig *NetflowConfig) SetDefaults(namespace string, logger log.Compon
 		mainConfig.PrometheusListenerAddress = common.DefaultPrometheusListenerAddress
 	}
 
+	if mainConfig.TopTalkers.Enabled {
+		if err := mainConfig.TopTalkers.setDefaults(mainConfig.AggregatorFlushInterval); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+func (c *TopTalkersConfig) setDefaults(flushInterval int) error {
+	if c.Size < 0 {
+		return fmt.Errorf("the top talkers size must be positive, got %d", c.Size)
+	}
+	if c.Interval < 0 {
+		return fmt.Errorf("the top talkers interval must be positive, got %d", c.Interval)
+	}
+	if c.Size == 0 {
+		c.Size = common.DefaultTopTalkersSize
+	}
+	if len(c.Dimensions) == 0 {
+		c.Dimensions = common.TopTalkersDimensions
+	}
+	for _, dimension := range c.Dimensions {
+		if !slices.Contains(common.TopTalkersDimensions, dimension) {
+			return fmt.Errorf("the provided top talkers dimension `%s` is not valid (valid dimensions: %v)", dimension, common.TopTalkersDimensions)
+		}
+	}
+	if c.ApplicationField == "" {
+		c.ApplicationField = common.DefaultTopTalkersApplicationField
+	}
+	if c.Interval == 0 {
+		// flows are flushed once per flush interval, shorter windows would miss part of them
+		c.Interval = flushInterval
+	}
+	return nil
+}
+
+// AggregationKeys returns the sorted destinations of the mapped fields used as aggregation keys.
+func AggregationKeys(mappings []Mapping) []string {
+	var keys []string
+	for _, mapping := range mappings {
+		if mapping.AggregationKey && !slices.Contains(keys, mapping.Destination) {
+			keys = append(keys, mapping.Destination)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
 // Addr returns the host:port address to listen on.
 func (c *ListenerConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", c.BindHost, c.Port)
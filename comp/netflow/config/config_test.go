@@ -213,6 +213,203 @@ network_devices:
 				ReverseDNSEnrichmentEnabled: false,
 			},
 		},
+		{
+			name: "enterprise field mapping",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    listeners:
+      - flow_type: ipfix
+        mapping:
+          - destination: http.host
+            enterprise: 9
+            field: 12235
+            type: string
+            aggregation_key: true
+          - destination: application_id
+            field: 95
+            type: application_id
+            aggregation_key: true
+          - destination: source.port
+            field: 7
+            type: integer
+            aggregation_key: true
+          - destination: vendor_field
+            field: 1234
+            type: unknown
+`,
+			expectedConfig: NetflowConfig{
+				Enabled:                                true,
+				StopTimeout:                            5,
+				AggregatorBufferSize:                   10000,
+				AggregatorFlushInterval:                300,
+				AggregatorFlowContextTTL:               300,
+				AggregatorPortRollupThreshold:          10,
+				AggregatorRollupTrackerRefreshInterval: 300,
+				PrometheusListenerAddress:              "localhost:9090",
+				Listeners: []ListenerConfig{
+					{
+						FlowType:  common.TypeIPFIX,
+						BindHost:  "0.0.0.0",
+						Port:      uint16(4739),
+						Workers:   1,
+						Namespace: "default",
+						Mapping: []Mapping{
+							{
+								Enterprise:     9,
+								Field:          12235,
+								Destination:    "http.host",
+								Type:           common.String,
+								AggregationKey: true,
+							},
+							{
+								Field:          95,
+								Destination:    "application_id",
+								Type:           common.ApplicationID,
+								AggregationKey: true,
+							},
+							{
+								Field:       7,
+								Destination: "source.port",
+								Type:        common.Integer, // default fields can't be aggregation keys
+							},
+							{
+								Field:       1234,
+								Destination: "vendor_field",
+								Type:        common.Hex, // invalid types are replaced by hex
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "enterprise field mapping with netflow9",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    listeners:
+      - flow_type: netflow9
+        mapping:
+          - destination: http.host
+            enterprise: 9
+            field: 12235
+`,
+			expectedError: "the mapping of field `http.host` can't have an enterprise number, NetFlow v9 fields are not enterprise-specific",
+		},
+		{
+			name: "invalid IPFIX enterprise field",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    listeners:
+      - flow_type: ipfix
+        mapping:
+          - destination: http.host
+            enterprise: 9
+            field: 45003
+`,
+			expectedError: "the ID of IPFIX enterprise-specific field `http.host` must be lower than 32768",
+		},
+		{
+			name: "invalid sFlow record",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    listeners:
+      - flow_type: sflow5
+        mapping:
+          - destination: user
+            field: 5000
+`,
+			expectedError: "the format of sFlow record `user` must be lower than 4096",
+		},
+		{
+			name: "invalid sFlow enterprise",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    listeners:
+      - flow_type: sflow5
+        mapping:
+          - destination: user
+            enterprise: 2000000
+            field: 1
+`,
+			expectedError: "the enterprise number of sFlow record `user` must be lower than 1048576",
+		},
+		{
+			name: "top talkers defaults",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    aggregator_flush_interval: 50
+    top_talkers:
+      enabled: true
+    listeners:
+      - flow_type: netflow9
+`,
+			expectedConfig: NetflowConfig{
+				Enabled:                                true,
+				StopTimeout:                            5,
+				AggregatorBufferSize:                   10000,
+				AggregatorFlushInterval:                50,
+				AggregatorFlowContextTTL:               50,
+				AggregatorPortRollupThreshold:          10,
+				AggregatorRollupTrackerRefreshInterval: 300,
+				PrometheusListenerAddress:              "localhost:9090",
+				Listeners: []ListenerConfig{
+					{
+						FlowType:  common.TypeNetFlow9,
+						BindHost:  "0.0.0.0",
+						Port:      uint16(2055),
+						Workers:   1,
+						Namespace: "default",
+					},
+				},
+				TopTalkers: TopTalkersConfig{
+					Enabled:          true,
+					Size:             10,
+					Dimensions:       common.TopTalkersDimensions,
+					ApplicationField: "application",
+					Interval:         50,
+				},
+			},
+		},
+		{
+			name: "negative top talkers size",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    top_talkers:
+      enabled: true
+      size: -1
+    listeners:
+      - flow_type: netflow9
+`,
+			expectedError: "the top talkers size must be positive, got -1",
+		},
+		{
+			name: "negative top talkers interval",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    top_talkers:
+      enabled: true
+      interval: -60
+    listeners:
+      - flow_type: netflow9
+`,
+			expectedError: "the top talkers interval must be positive, got -60",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -232,6 +429,17 @@ network_devices:
 	}
 }
 
+func TestAggregationKeys(t *testing.T) {
+	mappings := []Mapping{
+		{Destination: "user", AggregationKey: true},
+		{Destination: "vlan"},
+		{Destination: "application", AggregationKey: true},
+		{Destination: "user", AggregationKey: true},
+	}
+	assert.Equal(t, []string{"application", "user"}, AggregationKeys(mappings))
+	assert.Nil(t, AggregationKeys(nil))
+}
+
 func TestListenerConfig_Addr(t *testing.T) {
 	listenerConfig := ListenerConfig{
 		FlowType: common.TypeNetFlow9,
@@ -19,10 +19,12 @@ import (
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
 	"github.com/DataDog/datadog-agent/comp/forwarder/eventplatform"
 	"github.com/DataDog/datadog-agent/comp/netflow/format"
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector"
 	rdnsquerier "github.com/DataDog/datadog-agent/comp/rdnsquerier/def"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
 
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/metadata"
 
@@ -49,7 +51,8 @@ type FlowAggregator struct {
 	flushedFlowCount             *atomic.Uint64
 	hostname                     string
 	goflowPrometheusGatherer     prometheus.Gatherer
-	TimeNowFunction              func() time.Time // Allows to mock time in tests
+	TimeNowFunction              func() time.Time      // Allows to mock time in tests
+	NpCollector                  npcollector.Component // Schedules network paths to the flows destinations, nil if unavailable
 
 	lastSequencePerExporter   map[sequenceDeltaKey]uint32
 	lastSequencePerExporterMu sync.Mutex
@@ -82,9 +85,13 @@ func NewFlowAggregator(sender sender.Sender, epForwarder eventplatform.Forwarder
 	flushInterval := time.Duration(config.AggregatorFlushInterval) * time.Second
 	flowContextTTL := time.Duration(config.AggregatorFlowContextTTL) * time.Second
 	rollupTrackerRefreshInterval := time.Duration(config.AggregatorRollupTrackerRefreshInterval) * time.Second
+	flowAcc := newFlowAccumulator(flushInterval, flowContextTTL, config.AggregatorPortRollupThreshold, config.AggregatorPortRollupDisabled, logger, rdnsQuerier)
+	if config.TopTalkers.Enabled {
+		flowAcc.topTalkers = newTopTalkersAccumulator(config.TopTalkers)
+	}
 	return &FlowAggregator{
 		flowIn:                       make(chan *common.Flow, config.AggregatorBufferSize),
-		flowAcc:                      newFlowAccumulator(flushInterval, flowContextTTL, config.AggregatorPortRollupThreshold, config.AggregatorPortRollupDisabled, logger, rdnsQuerier),
+		flowAcc:                      flowAcc,
 		FlushFlowsToSendInterval:     flushFlowsToSendInterval,
 		rollupTrackerRefreshInterval: rollupTrackerRefreshInterval,
 		sender:                       sender,
@@ -271,8 +278,16 @@ func (agg *FlowAggregator) flush() int {
 	// TODO: Add flush stats to agent telemetry e.g. aggregator newFlushCountStats()
 	if len(flowsToFlush) > 0 {
 		agg.sendFlows(flowsToFlush, flushTime)
+		if agg.NpCollector != nil && agg.NpCollector.NetflowMonitoringEnabled() {
+			agg.NpCollector.ScheduleFlows(buildNetworkPathFlows(flowsToFlush))
+		}
 	}
 	agg.sendExporterMetadata(flowsToFlush, flushTime)
+	if agg.flowAcc.topTalkers != nil {
+		if topTalkers := agg.flowAcc.topTalkers.takeCompleted(); topTalkers != nil {
+			agg.sendTopTalkersMetrics(topTalkers)
+		}
+	}
 
 	flushCount := len(flowsToFlush)
 
@@ -296,6 +311,57 @@ func (agg *FlowAggregator) flush() int {
 	return len(flowsToFlush)
 }
 
+// buildNetworkPathFlows converts the TCP and UDP flows to the destinations Network Path can test
+func buildNetworkPathFlows(flows []*common.Flow) []npcollector.Flow {
+	npFlows := make([]npcollector.Flow, 0, len(flows))
+	for _, flow := range flows {
+		var protocol payload.Protocol
+		switch flow.IPProtocol {
+		case 6:
+			protocol = payload.ProtocolTCP
+		case 17:
+			protocol = payload.ProtocolUDP
+		default:
+			continue
+		}
+		// DstPort is negative when ephemeral ports are rolled up
+		if flow.DstPort <= 0 || flow.DstPort > 65535 {
+			continue
+		}
+		dstIP := net.IP(flow.DstAddr).To4()
+		if dstIP == nil {
+			continue
+		}
+		npFlows = append(npFlows, npcollector.Flow{
+			DestinationIP:   dstIP.String(),
+			DestinationPort: uint16(flow.DstPort),
+			Protocol:        protocol,
+			Bytes:           flow.Bytes,
+			Packets:         flow.Packets,
+		})
+	}
+	return npFlows
+}
+
+// sendTopTalkersMetrics submits the bytes and packets of the top talkers of a time window
+func (agg *FlowAggregator) sendTopTalkersMetrics(topTalkers *TopTalkers) {
+	for dimension, talkers := range topTalkers.Dimensions {
+		for _, talker := range talkers {
+			tags := []string{"device_namespace:" + talker.Namespace, "dimension:" + dimension, "talker:" + talker.Key}
+			agg.sender.Gauge("netflow.top_talkers.bytes", float64(talker.Bytes), "", tags)
+			agg.sender.Gauge("netflow.top_talkers.packets", float64(talker.Packets), "", tags)
+		}
+	}
+}
+
+// TopTalkers returns the top talkers of the last complete time window and of the window in progress
+func (agg *FlowAggregator) TopTalkers() TopTalkersResponse {
+	if agg.flowAcc.topTalkers == nil {
+		return TopTalkersResponse{}
+	}
+	return agg.flowAcc.topTalkers.get(timeNow())
+}
+
 // getSequenceDelta return the delta of current sequence number compared to previously saved sequence number
 // Since we track per exporterIP, the returned delta is only accurate when for the specific exporterIP there is
 // only one NetFlow9/IPFIX observation domain, NetFlow5 engineType/engineId, sFlow agent/subagent.
@@ -12,12 +12,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	model "github.com/DataDog/agent-payload/v5/process"
 	"github.com/cihub/seelog"
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/mock/gomock"
@@ -33,6 +35,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/forwarder/eventplatform/eventplatformimpl"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 	ddlog "github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -43,6 +46,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/netflow/config"
 	"github.com/DataDog/datadog-agent/comp/netflow/goflowlib"
 	"github.com/DataDog/datadog-agent/comp/netflow/testutil"
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector"
 	rdnsquerier "github.com/DataDog/datadog-agent/comp/rdnsquerier/def"
 	rdnsquerierfxmock "github.com/DataDog/datadog-agent/comp/rdnsquerier/fx-mock"
 )
@@ -1184,3 +1188,136 @@ func TestFlowAggregator_getSequenceDelta(t *testing.T) {
 		})
 	}
 }
+
+func TestFlowAggregator_topTalkers(t *testing.T) {
+	logger := logmock.New(t)
+	rdnsQuerier := fxutil.Test[rdnsquerier.Component](t, rdnsquerierfxmock.MockModule())
+	sender := mocksender.NewMockSender("")
+	sender.On("Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	sender.On("Count", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	sender.On("MonotonicCount", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	conf := config.NetflowConfig{
+		StopTimeout:                            10,
+		AggregatorBufferSize:                   20,
+		AggregatorFlushInterval:                1,
+		AggregatorPortRollupThreshold:          10,
+		AggregatorPortRollupDisabled:           true,
+		AggregatorRollupTrackerRefreshInterval: 3600,
+		TopTalkers: config.TopTalkersConfig{
+			Enabled:    true,
+			Size:       10,
+			Dimensions: []string{"source", "port"},
+			Interval:   1,
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	epForwarder := eventplatformimpl.NewMockEventPlatformForwarder(ctrl)
+	epForwarder.EXPECT().SendEventPlatformEventBlocking(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger, rdnsQuerier)
+	aggregator.goflowPrometheusGatherer = prometheus.GathererFunc(func() ([]*promClient.MetricFamily, error) {
+		return nil, nil
+	})
+	assert.Equal(t, TopTalkersResponse{Enabled: true}, aggregator.TopTalkers())
+
+	start := MockTimeNow()
+	setMockTimeNow(start)
+	defer func() { timeNow = time.Now }()
+	aggregator.flowAcc.add(newTopTalkersFlow(1, 443, 100, ""))
+	aggregator.flush()
+	sender.AssertNotCalled(t, "Gauge", "netflow.top_talkers.bytes", mock.Anything, mock.Anything, mock.Anything)
+
+	setMockTimeNow(start.Add(time.Second))
+	aggregator.flush()
+	sender.AssertMetric(t, "Gauge", "netflow.top_talkers.bytes", 1000, "", []string{"device_namespace:default", "dimension:source", "talker:10.0.0.1"})
+	sender.AssertMetric(t, "Gauge", "netflow.top_talkers.packets", 10, "", []string{"device_namespace:default", "dimension:port", "talker:443/tcp"})
+
+	response := aggregator.TopTalkers()
+	require.NotNil(t, response.Last)
+	assert.Equal(t, []Talker{{Namespace: "default", Key: "10.0.0.1", Bytes: 1000, Packets: 10, Flows: 1}}, response.Last.Dimensions["source"])
+	require.NotNil(t, response.Current)
+	assert.Empty(t, response.Current.Dimensions["source"])
+
+	conf.TopTalkers.Enabled = false
+	aggregator = NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger, rdnsQuerier)
+	assert.Equal(t, TopTalkersResponse{}, aggregator.TopTalkers())
+}
+
+func TestFlowAggregator_buildNetworkPathFlows(t *testing.T) {
+	flows := []*common.Flow{
+		{DstAddr: []byte{10, 10, 10, 10}, DstPort: 443, IPProtocol: 6, Bytes: 100, Packets: 2},
+		{DstAddr: []byte{10, 10, 10, 11}, DstPort: 53, IPProtocol: 17, Bytes: 10, Packets: 1},
+		// skipped: ICMP, rolled up ephemeral port, IPv6
+		{DstAddr: []byte{10, 10, 10, 12}, DstPort: 0, IPProtocol: 1},
+		{DstAddr: []byte{10, 10, 10, 13}, DstPort: -1, IPProtocol: 6},
+		{DstAddr: net.ParseIP("2001:db8::1"), DstPort: 443, IPProtocol: 6},
+	}
+
+	assert.Equal(t, []npcollector.Flow{
+		{DestinationIP: "10.10.10.10", DestinationPort: 443, Protocol: payload.ProtocolTCP, Bytes: 100, Packets: 2},
+		{DestinationIP: "10.10.10.11", DestinationPort: 53, Protocol: payload.ProtocolUDP, Bytes: 10, Packets: 1},
+	}, buildNetworkPathFlows(flows))
+}
+
+type fakeNpCollector struct {
+	netflowMonitoringEnabled bool
+	scheduledFlows           [][]npcollector.Flow
+}
+
+func (c *fakeNpCollector) ScheduleConns(_ []*model.Connection) {}
+
+func (c *fakeNpCollector) ScheduleFlows(flows []npcollector.Flow) {
+	c.scheduledFlows = append(c.scheduledFlows, flows)
+}
+
+func (c *fakeNpCollector) NetflowMonitoringEnabled() bool {
+	return c.netflowMonitoringEnabled
+}
+
+func TestFlowAggregator_flushSchedulesNetworkPaths(t *testing.T) {
+	logger := logmock.New(t)
+	rdnsQuerier := fxutil.Test[rdnsquerier.Component](t, rdnsquerierfxmock.MockModule())
+	sender := mocksender.NewMockSender("")
+	sender.On("Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	sender.On("Count", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	sender.On("MonotonicCount", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	conf := config.NetflowConfig{
+		StopTimeout:                            10,
+		AggregatorBufferSize:                   20,
+		AggregatorFlushInterval:                1,
+		AggregatorPortRollupThreshold:          10,
+		AggregatorPortRollupDisabled:           true,
+		AggregatorRollupTrackerRefreshInterval: 3600,
+	}
+
+	ctrl := gomock.NewController(t)
+	epForwarder := eventplatformimpl.NewMockEventPlatformForwarder(ctrl)
+	epForwarder.EXPECT().SendEventPlatformEventBlocking(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	start := MockTimeNow()
+	setMockTimeNow(start)
+	defer func() { timeNow = time.Now }()
+
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			npCollector := &fakeNpCollector{netflowMonitoringEnabled: enabled}
+			aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger, rdnsQuerier)
+			aggregator.goflowPrometheusGatherer = prometheus.GathererFunc(func() ([]*promClient.MetricFamily, error) {
+				return nil, nil
+			})
+			aggregator.NpCollector = npCollector
+
+			aggregator.flowAcc.add(newTopTalkersFlow(1, 443, 100, ""))
+			aggregator.flush()
+
+			if !enabled {
+				assert.Empty(t, npCollector.scheduledFlows)
+				return
+			}
+			assert.Equal(t, [][]npcollector.Flow{{
+				{DestinationIP: "10.0.1.1", DestinationPort: 443, Protocol: payload.ProtocolTCP, Bytes: 100, Packets: 1},
+			}}, npCollector.scheduledFlows)
+		})
+	}
+}
@@ -41,6 +41,9 @@ type flowAccumulator struct {
 
 	hashCollisionFlowCount *atomic.Uint64
 
+	// topTalkers is nil when the top talkers are disabled
+	topTalkers *topTalkersAccumulator
+
 	logger      log.Component
 	rdnsQuerier rdnsquerier.Component
 }
@@ -101,6 +104,9 @@ func (f *flowAccumulator) flush() []*common.Flow {
 		flowCtx.nextFlush = flowCtx.nextFlush.Add(f.flowFlushInterval)
 		f.flows[key] = flowCtx
 	}
+	if f.topTalkers != nil {
+		f.topTalkers.add(flowsToFlush, timeNow())
+	}
 	return flowsToFlush
 }
 
@@ -115,6 +115,49 @@ func Test_flowAccumulator_add(t *testing.T) {
 	assert.Equal(t, []byte{10, 10, 10, 30}, wrappedFlowB.flow.DstAddr)
 }
 
+func Test_flowAccumulator_addWithAggregationKeys(t *testing.T) {
+	logger := logmock.New(t)
+	rdnsQuerier := fxutil.Test[rdnsquerier.Component](t, rdnsquerierfxmock.MockModule())
+
+	newFlow := func(application string, user string) *common.Flow {
+		return &common.Flow{
+			FlowType:     common.TypeIPFIX,
+			ExporterAddr: []byte{127, 0, 0, 1},
+			Bytes:        10,
+			Packets:      2,
+			SrcAddr:      []byte{10, 10, 10, 10},
+			DstAddr:      []byte{10, 10, 10, 20},
+			IPProtocol:   uint32(6),
+			SrcPort:      2000,
+			DstPort:      443,
+			AdditionalFields: map[string]any{
+				"application": application,
+				"user":        user,
+			},
+			AggregationKeys: []string{"application"},
+		}
+	}
+	flowSSL1 := newFlow("ssl", "alice")
+	flowSSL2 := newFlow("ssl", "bob")
+	flowQUIC := newFlow("quic", "alice")
+
+	acc := newFlowAccumulator(common.DefaultAggregatorFlushInterval, common.DefaultAggregatorFlushInterval, common.DefaultAggregatorPortRollupThreshold, false, logger, rdnsQuerier)
+	acc.add(flowSSL1)
+	acc.add(flowSSL2)
+	acc.add(flowQUIC)
+
+	// flows with different values of an aggregation key are aggregated separately
+	assert.Equal(t, 2, len(acc.flows))
+
+	wrappedFlowSSL := acc.flows[flowSSL1.AggregationHash()]
+	assert.Equal(t, uint64(20), wrappedFlowSSL.flow.Bytes)
+	assert.Equal(t, map[string]any{"application": "ssl", "user": "alice"}, wrappedFlowSSL.flow.AdditionalFields)
+
+	wrappedFlowQUIC := acc.flows[flowQUIC.AggregationHash()]
+	assert.Equal(t, uint64(10), wrappedFlowQUIC.flow.Bytes)
+	assert.Equal(t, map[string]any{"application": "quic", "user": "alice"}, wrappedFlowQUIC.flow.AdditionalFields)
+}
+
 func Test_flowAccumulator_portRollUp(t *testing.T) {
 	logger := logmock.New(t)
 	rdnsQuerier := fxutil.Test[rdnsquerier.Component](t, rdnsquerierfxmock.MockModule())
@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package flowaggregator
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+	"github.com/DataDog/datadog-agent/comp/netflow/format"
+)
+
+// maxTalkersPerDimension bounds the memory used by a time window, the talkers seen after the limit is reached
+// are not ranked until the next window
+const maxTalkersPerDimension = 100000
+
+// Talker is the traffic of a source, destination, port, interface or application during a time window.
+// Bytes and packets are estimated from the sampling rate of the flows.
+type Talker struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Bytes     uint64 `json:"bytes"`
+	Packets   uint64 `json:"packets"`
+	Flows     uint64 `json:"flows"`
+}
+
+// TopTalkers are the talkers with the most bytes during a time window, per dimension
+type TopTalkers struct {
+	Start      int64               `json:"start"`
+	End        int64               `json:"end"`
+	Dimensions map[string][]Talker `json:"dimensions"`
+}
+
+// TopTalkersResponse is returned by the top talkers API, Last is the last complete time window and Current the
+// window in progress
+type TopTalkersResponse struct {
+	Enabled bool        `json:"enabled"`
+	Last    *TopTalkers `json:"last,omitempty"`
+	Current *TopTalkers `json:"current,omitempty"`
+}
+
+type talkerKey struct {
+	namespace string
+	key       string
+}
+
+// topTalkersAccumulator ranks the flushed flows over consecutive time windows
+type topTalkersAccumulator struct {
+	conf     config.TopTalkersConfig
+	interval time.Duration
+
+	mutex       sync.Mutex
+	windowStart time.Time
+	talkers     map[string]map[talkerKey]*Talker
+	last        *TopTalkers
+	// completed is the last window, until its metrics are submitted
+	completed *TopTalkers
+}
+
+func newTopTalkersAccumulator(conf config.TopTalkersConfig) *topTalkersAccumulator {
+	return &topTalkersAccumulator{
+		conf:     conf,
+		interval: time.Duration(conf.Interval) * time.Second,
+		talkers:  newTalkersMaps(conf.Dimensions),
+	}
+}
+
+func newTalkersMaps(dimensions []string) map[string]map[talkerKey]*Talker {
+	talkers := make(map[string]map[talkerKey]*Talker, len(dimensions))
+	for _, dimension := range dimensions {
+		talkers[dimension] = make(map[talkerKey]*Talker)
+	}
+	return talkers
+}
+
+// add adds the flushed flows to the current window, after completing it if it's over
+func (t *topTalkersAccumulator) add(flows []*common.Flow, now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.windowStart.IsZero() {
+		t.windowStart = now
+	}
+	if !now.Before(t.windowStart.Add(t.interval)) {
+		t.last = t.rank(now)
+		t.completed = t.last
+		t.windowStart = now
+		t.talkers = newTalkersMaps(t.conf.Dimensions)
+	}
+
+	for _, flow := range flows {
+		samplingRate := flow.SamplingRate
+		if samplingRate == 0 {
+			samplingRate = 1
+		}
+		for dimension, talkers := range t.talkers {
+			key, ok := t.talkerKey(dimension, flow)
+			if !ok {
+				continue
+			}
+			talker, ok := talkers[key]
+			if !ok {
+				if len(talkers) >= maxTalkersPerDimension {
+					continue
+				}
+				talker = &Talker{Namespace: key.namespace, Key: key.key}
+				talkers[key] = talker
+			}
+			talker.Bytes += flow.Bytes * samplingRate
+			talker.Packets += flow.Packets * samplingRate
+			talker.Flows++
+		}
+	}
+}
+
+// talkerKey returns the talker of a flow for a dimension, false if the flow doesn't have one
+func (t *topTalkersAccumulator) talkerKey(dimension string, flow *common.Flow) (talkerKey, bool) {
+	var key string
+	switch dimension {
+	case "source":
+		key = format.IPAddr(flow.SrcAddr)
+	case "destination":
+		key = format.IPAddr(flow.DstAddr)
+	case "port":
+		protocol := strings.ToLower(format.IPProtocol(flow.IPProtocol))
+		if protocol == "" {
+			protocol = strconv.Itoa(int(flow.IPProtocol))
+		}
+		key = format.Port(flow.DstPort) + "/" + protocol
+	case "interface":
+		key = net.JoinHostPort(format.IPAddr(flow.ExporterAddr), strconv.Itoa(int(flow.InputInterface)))
+	case "application":
+		value, ok := flow.AdditionalFields[t.conf.ApplicationField]
+		if !ok {
+			return talkerKey{}, false
+		}
+		key = fmt.Sprint(value)
+	}
+	if key == "" {
+		return talkerKey{}, false
+	}
+	return talkerKey{namespace: flow.Namespace, key: key}, true
+}
+
+// rank returns the top talkers of the current window, ending at the given time
+func (t *topTalkersAccumulator) rank(end time.Time) *TopTalkers {
+	topTalkers := &TopTalkers{
+		Start:      t.windowStart.Unix(),
+		End:        end.Unix(),
+		Dimensions: make(map[string][]Talker, len(t.talkers)),
+	}
+	for dimension, talkers := range t.talkers {
+		ranked := make([]Talker, 0, len(talkers))
+		for _, talker := range talkers {
+			ranked = append(ranked, *talker)
+		}
+		sort.Slice(ranked, func(i, j int) bool {
+			if ranked[i].Bytes != ranked[j].Bytes {
+				return ranked[i].Bytes > ranked[j].Bytes
+			}
+			if ranked[i].Namespace != ranked[j].Namespace {
+				return ranked[i].Namespace < ranked[j].Namespace
+			}
+			return ranked[i].Key < ranked[j].Key
+		})
+		if len(ranked) > t.conf.Size {
+			ranked = ranked[:t.conf.Size]
+		}
+		topTalkers.Dimensions[dimension] = ranked
+	}
+	return topTalkers
+}
+
+// takeCompleted returns the window completed since the last call, nil if there is none
+func (t *topTalkersAccumulator) takeCompleted() *TopTalkers {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	completed := t.completed
+	t.completed = nil
+	return completed
+}
+
+// get returns the last complete window and the window in progress
+func (t *topTalkersAccumulator) get(now time.Time) TopTalkersResponse {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	response := TopTalkersResponse{Enabled: true, Last: t.last}
+	if !t.windowStart.IsZero() {
+		response.Current = t.rank(now)
+	}
+	return response
+}
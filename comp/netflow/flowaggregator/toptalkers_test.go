@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package flowaggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+)
+
+func newTopTalkersFlow(src byte, dstPort int32, bytes uint64, application string) *common.Flow {
+	flow := &common.Flow{
+		Namespace:      "default",
+		ExporterAddr:   []byte{127, 0, 0, 1},
+		SrcAddr:        []byte{10, 0, 0, src},
+		DstAddr:        []byte{10, 0, 1, 1},
+		IPProtocol:     6,
+		SrcPort:        -1,
+		DstPort:        dstPort,
+		InputInterface: 5,
+		Bytes:          bytes,
+		Packets:        1,
+		SamplingRate:   10,
+	}
+	if application != "" {
+		flow.AdditionalFields = common.AdditionalFields{"app": application}
+	}
+	return flow
+}
+
+func Test_topTalkersAccumulator(t *testing.T) {
+	start := time.Unix(1000, 0)
+	acc := newTopTalkersAccumulator(config.TopTalkersConfig{
+		Enabled:          true,
+		Size:             2,
+		Dimensions:       common.TopTalkersDimensions,
+		ApplicationField: "app",
+		Interval:         60,
+	})
+
+	acc.add([]*common.Flow{
+		newTopTalkersFlow(1, 443, 100, "ssl"),
+		newTopTalkersFlow(2, 443, 300, "ssl"),
+		newTopTalkersFlow(3, 53, 200, "dns"),
+		newTopTalkersFlow(1, 22, 50, ""),
+	}, start)
+	assert.Nil(t, acc.takeCompleted())
+
+	// the window in progress is ranked on demand
+	response := acc.get(start.Add(10 * time.Second))
+	assert.True(t, response.Enabled)
+	assert.Nil(t, response.Last)
+	require.NotNil(t, response.Current)
+	assert.Equal(t, int64(1000), response.Current.Start)
+	assert.Equal(t, int64(1010), response.Current.End)
+	assert.Equal(t, []Talker{
+		{Namespace: "default", Key: "10.0.0.2", Bytes: 3000, Packets: 10, Flows: 1},
+		{Namespace: "default", Key: "10.0.0.3", Bytes: 2000, Packets: 10, Flows: 1},
+	}, response.Current.Dimensions["source"])
+	assert.Equal(t, []Talker{
+		{Namespace: "default", Key: "10.0.1.1", Bytes: 6500, Packets: 40, Flows: 4},
+	}, response.Current.Dimensions["destination"])
+	assert.Equal(t, []Talker{
+		{Namespace: "default", Key: "443/tcp", Bytes: 4000, Packets: 20, Flows: 2},
+		{Namespace: "default", Key: "53/tcp", Bytes: 2000, Packets: 10, Flows: 1},
+	}, response.Current.Dimensions["port"])
+	assert.Equal(t, []Talker{
+		{Namespace: "default", Key: "127.0.0.1:5", Bytes: 6500, Packets: 40, Flows: 4},
+	}, response.Current.Dimensions["interface"])
+	// flows without application are not ranked
+	assert.Equal(t, []Talker{
+		{Namespace: "default", Key: "ssl", Bytes: 4000, Packets: 20, Flows: 2},
+		{Namespace: "default", Key: "dns", Bytes: 2000, Packets: 10, Flows: 1},
+	}, response.Current.Dimensions["application"])
+
+	// the window is completed by the first flush after its end
+	acc.add([]*common.Flow{newTopTalkersFlow(4, 80, 10, "")}, start.Add(60*time.Second))
+	completed := acc.takeCompleted()
+	require.NotNil(t, completed)
+	assert.Equal(t, int64(1000), completed.Start)
+	assert.Equal(t, int64(1060), completed.End)
+	assert.Equal(t, response.Current.Dimensions["source"], completed.Dimensions["source"])
+	assert.Nil(t, acc.takeCompleted())
+
+	response = acc.get(start.Add(70 * time.Second))
+	assert.Equal(t, completed, response.Last)
+	assert.Equal(t, []Talker{
+		{Namespace: "default", Key: "10.0.0.4", Bytes: 100, Packets: 10, Flows: 1},
+	}, response.Current.Dimensions["source"])
+	assert.Empty(t, response.Current.Dimensions["application"])
+}
+
+func Test_topTalkersAccumulator_dimensions(t *testing.T) {
+	acc := newTopTalkersAccumulator(config.TopTalkersConfig{
+		Enabled:    true,
+		Size:       10,
+		Dimensions: []string{"port"},
+		Interval:   60,
+	})
+	flow := newTopTalkersFlow(1, -1, 100, "")
+	flow.IPProtocol = 253
+	flow.SamplingRate = 0
+	acc.add([]*common.Flow{flow}, time.Unix(1000, 0))
+
+	response := acc.get(time.Unix(1010, 0))
+	assert.Equal(t, map[string][]Talker{
+		"port": {{Namespace: "default", Key: "*/253", Bytes: 100, Packets: 1, Flows: 1}},
+	}, response.Current.Dimensions)
+}
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package format
+
+import (
+	"encoding/hex"
+	"strconv"
+)
+
+// ApplicationID formats an application ID (RFC 6759) as "<classification engine ID>:<selector ID>", like
+// "13:453" for the NBAR2 application 453 exported by Cisco AVC. Selector IDs longer than 8 bytes are formatted
+// as hex.
+func ApplicationID(id []byte) string {
+	if len(id) == 0 {
+		return ""
+	}
+	engineID := strconv.Itoa(int(id[0]))
+	selector := id[1:]
+	if len(selector) == 0 {
+		return engineID
+	}
+	if len(selector) > 8 {
+		return engineID + ":" + hex.EncodeToString(selector)
+	}
+	var selectorID uint64
+	for _, b := range selector {
+		selectorID = selectorID<<8 | uint64(b)
+	}
+	return engineID + ":" + strconv.FormatUint(selectorID, 10)
+}
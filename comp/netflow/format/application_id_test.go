@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplicationID(t *testing.T) {
+	assert.Equal(t, "13:453", ApplicationID([]byte{13, 0, 1, 0xc5}))
+	assert.Equal(t, "3:80", ApplicationID([]byte{3, 0, 80}))
+	assert.Equal(t, "1", ApplicationID([]byte{1}))
+	assert.Equal(t, "20:0102030405060708090a", ApplicationID([]byte{20, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}))
+	assert.Equal(t, "", ApplicationID(nil))
+}
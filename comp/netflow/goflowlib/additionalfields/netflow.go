@@ -4,18 +4,39 @@
 // Copyright 2023-present Datadog, Inc.
 
 // Package additionalfields provides a producer collecting
-// additional fields from Netflow/IPFIX packets.
+// additional fields from Netflow/IPFIX and sFlow packets.
 package additionalfields
 
 import (
 	"bytes"
 	"errors"
+	"net"
+
 	"github.com/DataDog/datadog-agent/comp/netflow/common"
 	"github.com/DataDog/datadog-agent/comp/netflow/config"
+	"github.com/DataDog/datadog-agent/comp/netflow/format"
 	"github.com/netsampler/goflow2/decoders/netflow"
 	"github.com/netsampler/goflow2/producer"
 )
 
+// ipfixEnterpriseBit is set in the ID of IPFIX enterprise-specific elements
+const ipfixEnterpriseBit = 0x8000
+
+// FieldKey identifies a Netflow/IPFIX field or a sFlow record, enterprise is 0 for standard fields and records
+type FieldKey struct {
+	Enterprise uint32
+	Field      uint16
+}
+
+// MapFieldsConfig indexes the mappings by field key
+func MapFieldsConfig(mappingConfs []config.Mapping) map[FieldKey]config.Mapping {
+	mappedFieldsConfig := make(map[FieldKey]config.Mapping)
+	for _, conf := range mappingConfs {
+		mappedFieldsConfig[FieldKey{Enterprise: conf.Enterprise, Field: conf.Field}] = conf
+	}
+	return mappedFieldsConfig
+}
+
 func decodeUNumberWithEndianness(b []byte, out *uint64, endianness common.EndianType) error {
 	if endianness == common.LittleEndian {
 		return producer.DecodeUNumberLE(b, out)
@@ -24,22 +45,35 @@ func decodeUNumberWithEndianness(b []byte, out *uint64, endianness common.Endian
 }
 
 func mapAdditionalField(additionalFields common.AdditionalFields, v []byte, cfg config.Mapping) {
-	// TODO : Add more types (IP address, timestamp, ...)
-	if cfg.Type == common.Integer {
+	switch cfg.Type {
+	case common.Integer:
 		var dstVar uint64
 		err := decodeUNumberWithEndianness(v, &dstVar, cfg.Endian)
 		if err != nil {
 			return
 		}
 		additionalFields[cfg.Destination] = dstVar
-	} else if cfg.Type == common.String {
+	case common.String:
 		additionalFields[cfg.Destination] = string(bytes.Trim(v, "\x00")) // Removing trailing null chars
-	} else {
+	case common.IP:
+		if len(v) != net.IPv4len && len(v) != net.IPv6len {
+			return
+		}
+		additionalFields[cfg.Destination] = format.IPAddr(v)
+	case common.MAC:
+		var mac uint64
+		if len(v) != 6 || producer.DecodeUNumber(v, &mac) != nil {
+			return
+		}
+		additionalFields[cfg.Destination] = format.MacAddress(mac)
+	case common.ApplicationID:
+		additionalFields[cfg.Destination] = format.ApplicationID(v)
+	default:
 		additionalFields[cfg.Destination] = v
 	}
 }
 
-func convertNetFlowDataSet(record []netflow.DataField, fieldsConfig map[uint16]config.Mapping) common.AdditionalFields {
+func convertNetFlowDataSet(record []netflow.DataField, fieldsConfig map[FieldKey]config.Mapping) common.AdditionalFields {
 	additionalFields := make(common.AdditionalFields)
 
 	for i := range record {
@@ -50,7 +84,7 @@ func convertNetFlowDataSet(record []netflow.DataField, fieldsConfig map[uint16]c
 			continue
 		}
 
-		mappingConfig, ok := fieldsConfig[df.Type]
+		mappingConfig, ok := fieldsConfig[netFlowFieldKey(df)]
 		if !ok {
 			continue
 		}
@@ -61,7 +95,16 @@ func convertNetFlowDataSet(record []netflow.DataField, fieldsConfig map[uint16]c
 	return additionalFields
 }
 
-func searchNetFlowDataSetsRecords(dataRecords []netflow.DataRecord, fieldsConfig map[uint16]config.Mapping) []common.AdditionalFields {
+// netFlowFieldKey returns the key of a field, IPFIX enterprise-specific elements are identified by their PEN and their
+// element ID without the enterprise bit
+func netFlowFieldKey(df netflow.DataField) FieldKey {
+	if df.PenProvided {
+		return FieldKey{Enterprise: df.Pen, Field: df.Type &^ ipfixEnterpriseBit}
+	}
+	return FieldKey{Field: df.Type}
+}
+
+func searchNetFlowDataSetsRecords(dataRecords []netflow.DataRecord, fieldsConfig map[FieldKey]config.Mapping) []common.AdditionalFields {
 	var setsAdditionalFields []common.AdditionalFields
 	for _, record := range dataRecords {
 		additionalFields := convertNetFlowDataSet(record.Values, fieldsConfig)
@@ -72,7 +115,7 @@ func searchNetFlowDataSetsRecords(dataRecords []netflow.DataRecord, fieldsConfig
 	return setsAdditionalFields
 }
 
-func searchNetFlowDataSets(dataFlowSet []netflow.DataFlowSet, fieldsConfig map[uint16]config.Mapping) []common.AdditionalFields {
+func searchNetFlowDataSets(dataFlowSet []netflow.DataFlowSet, fieldsConfig map[FieldKey]config.Mapping) []common.AdditionalFields {
 	var flowsAdditonalFields []common.AdditionalFields
 	for _, dataFlowSetItem := range dataFlowSet {
 		setsAdditionalFields := searchNetFlowDataSetsRecords(dataFlowSetItem.Records, fieldsConfig)
@@ -84,7 +127,7 @@ func searchNetFlowDataSets(dataFlowSet []netflow.DataFlowSet, fieldsConfig map[u
 }
 
 // ProcessMessageNetFlowAdditionalFields collects additional fields from netflow packet using the given config
-func ProcessMessageNetFlowAdditionalFields(msgDec interface{}, fieldsConfig map[uint16]config.Mapping) ([]common.AdditionalFields, error) {
+func ProcessMessageNetFlowAdditionalFields(msgDec interface{}, fieldsConfig map[FieldKey]config.Mapping) ([]common.AdditionalFields, error) {
 	if len(fieldsConfig) == 0 {
 		return nil, nil
 	}
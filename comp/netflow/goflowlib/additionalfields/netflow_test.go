@@ -42,7 +42,7 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 	tests := []struct {
 		name                    string
 		fields                  []netflow.DataField
-		config                  map[uint16]config.Mapping
+		config                  map[FieldKey]config.Mapping
 		expectedCollectedFields []common.AdditionalFields
 	}{
 		{
@@ -51,8 +51,8 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				Type:  123,
 				Value: []byte{45},
 			}},
-			config: map[uint16]config.Mapping{
-				123: {
+			config: map[FieldKey]config.Mapping{
+				{Field: 123}: {
 					Field:       123,
 					Destination: "test_field",
 					Type:        common.Integer,
@@ -68,8 +68,8 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				Type:  123,
 				Value: []byte("test"),
 			}},
-			config: map[uint16]config.Mapping{
-				123: {
+			config: map[FieldKey]config.Mapping{
+				{Field: 123}: {
 					Field:       123,
 					Destination: "test_field",
 					Type:        common.String,
@@ -85,8 +85,8 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				Type:  123,
 				Value: []byte{45, 12},
 			}},
-			config: map[uint16]config.Mapping{
-				123: {
+			config: map[FieldKey]config.Mapping{
+				{Field: 123}: {
 					Field:       123,
 					Destination: "test_field",
 				},
@@ -104,13 +104,13 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				Type:  124,
 				Value: []byte("hello"),
 			}},
-			config: map[uint16]config.Mapping{
-				123: {
+			config: map[FieldKey]config.Mapping{
+				{Field: 123}: {
 					Field:       123,
 					Destination: "test_field",
 					Type:        common.Integer,
 				},
-				124: {
+				{Field: 124}: {
 					Field:       124,
 					Destination: "second_field",
 					Type:        common.String,
@@ -130,13 +130,13 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				Type:  124,
 				Value: []byte("hello"),
 			}},
-			config: map[uint16]config.Mapping{
-				123: {
+			config: map[FieldKey]config.Mapping{
+				{Field: 123}: {
 					Field:       123,
 					Destination: "test_field",
 					Type:        common.Integer,
 				},
-				126: {
+				{Field: 126}: {
 					Field:       126,
 					Destination: "missing_field",
 					Type:        common.Integer,
@@ -146,13 +146,125 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				"test_field": uint64(45),
 			}},
 		},
+		{
+			name: "Enterprise field",
+			fields: []netflow.DataField{{
+				PenProvided: true,
+				Pen:         9,
+				Type:        0x8000 | 12235,
+				Value:       []byte("example.com\x00\x00"),
+			}, {
+				Type:  123,
+				Value: []byte{45},
+			}},
+			config: map[FieldKey]config.Mapping{
+				{Enterprise: 9, Field: 12235}: {
+					Enterprise:  9,
+					Field:       12235,
+					Destination: "http.host",
+					Type:        common.String,
+				},
+			},
+			expectedCollectedFields: []common.AdditionalFields{{
+				"http.host": "example.com",
+			}},
+		},
+		{
+			name: "Enterprise field doesn't match standard field with the same ID",
+			fields: []netflow.DataField{{
+				PenProvided: true,
+				Pen:         9,
+				Type:        0x8000 | 123,
+				Value:       []byte{45},
+			}, {
+				Type:  123,
+				Value: []byte{46},
+			}},
+			config: map[FieldKey]config.Mapping{
+				{Field: 123}: {
+					Field:       123,
+					Destination: "test_field",
+					Type:        common.Integer,
+				},
+			},
+			expectedCollectedFields: []common.AdditionalFields{{
+				"test_field": uint64(46),
+			}},
+		},
+		{
+			name: "Custom field ip, mac and application ID",
+			fields: []netflow.DataField{{
+				Type:  225,
+				Value: []byte{10, 0, 0, 1},
+			}, {
+				Type:  281,
+				Value: []byte{0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+			}, {
+				Type:  81,
+				Value: []byte{0x82, 0xa5, 0x6e, 0xa5, 0xaa, 0x99},
+			}, {
+				Type:  95,
+				Value: []byte{13, 0, 1, 0xc5},
+			}},
+			config: map[FieldKey]config.Mapping{
+				{Field: 225}: {
+					Field:       225,
+					Destination: "post_nat.source.ip",
+					Type:        common.IP,
+				},
+				{Field: 281}: {
+					Field:       281,
+					Destination: "post_nat.destination.ip",
+					Type:        common.IP,
+				},
+				{Field: 81}: {
+					Field:       81,
+					Destination: "post_source.mac",
+					Type:        common.MAC,
+				},
+				{Field: 95}: {
+					Field:       95,
+					Destination: "application_id",
+					Type:        common.ApplicationID,
+				},
+			},
+			expectedCollectedFields: []common.AdditionalFields{{
+				"post_nat.source.ip":      "10.0.0.1",
+				"post_nat.destination.ip": "2001:db8::1",
+				"post_source.mac":         "82:a5:6e:a5:aa:99",
+				"application_id":          "13:453",
+			}},
+		},
+		{
+			name: "Custom field invalid ip and mac",
+			fields: []netflow.DataField{{
+				Type:  225,
+				Value: []byte{10, 0, 0},
+			}, {
+				Type:  81,
+				Value: []byte{0x82, 0xa5},
+			}},
+			config: map[FieldKey]config.Mapping{
+				{Field: 225}: {
+					Field:       225,
+					Destination: "post_nat.source.ip",
+					Type:        common.IP,
+				},
+				{Field: 81}: {
+					Field:       81,
+					Destination: "post_source.mac",
+					Type:        common.MAC,
+				},
+			},
+			expectedCollectedFields: []common.AdditionalFields{{}},
+		},
 		{
 			name: "Custom field empty configuration",
 			fields: []netflow.DataField{{
 				Type:  123,
 				Value: []byte{45, 12},
 			}},
-			config:                  map[uint16]config.Mapping{},
+			config:                  map[FieldKey]config.Mapping{},
 			expectedCollectedFields: nil,
 		},
 	}
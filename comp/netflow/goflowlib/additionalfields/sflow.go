@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package additionalfields
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+	"github.com/netsampler/goflow2/decoders/sflow"
+)
+
+const (
+	sflowExtendedURL  = 1005
+	sflowExtendedUser = 1004
+)
+
+// sflowRecordDecoders decode the standard extended records made of several fields, the fields are
+// collected as <destination>.<name>
+var sflowRecordDecoders = map[FieldKey]func(data []byte) (map[string]string, error){
+	{Field: sflowExtendedURL}:  decodeSFlowExtendedURL,
+	{Field: sflowExtendedUser}: decodeSFlowExtendedUser,
+}
+
+// decodeSFlowExtendedURL decodes an extended_url record: direction, url and host
+func decodeSFlowExtendedURL(data []byte) (map[string]string, error) {
+	buf := bytes.NewBuffer(data)
+	if _, err := readXDRUint32(buf); err != nil {
+		return nil, err
+	}
+	url, err := readXDRString(buf)
+	if err != nil {
+		return nil, err
+	}
+	host, err := readXDRString(buf)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"url": url, "host": host}, nil
+}
+
+// decodeSFlowExtendedUser decodes an extended_user record: the charset and name of the source and destination users
+func decodeSFlowExtendedUser(data []byte) (map[string]string, error) {
+	buf := bytes.NewBuffer(data)
+	users := make(map[string]string)
+	for _, name := range []string{"source", "destination"} {
+		if _, err := readXDRUint32(buf); err != nil {
+			return nil, err
+		}
+		user, err := readXDRString(buf)
+		if err != nil {
+			return nil, err
+		}
+		users[name] = user
+	}
+	return users, nil
+}
+
+func readXDRUint32(buf *bytes.Buffer) (uint32, error) {
+	if buf.Len() < 4 {
+		return 0, errors.New("not enough data")
+	}
+	return binary.BigEndian.Uint32(buf.Next(4)), nil
+}
+
+// readXDRString reads a length-prefixed string, padded to 4 bytes
+func readXDRString(buf *bytes.Buffer) (string, error) {
+	length, err := readXDRUint32(buf)
+	if err != nil {
+		return "", err
+	}
+	padded := (int(length) + 3) &^ 3
+	if length > uint32(buf.Len()) || padded > buf.Len() {
+		return "", errors.New("string longer than the record")
+	}
+	return string(buf.Next(padded)[:length]), nil
+}
+
+func convertSFlowRecords(records []sflow.FlowRecord, fieldsConfig map[FieldKey]config.Mapping) common.AdditionalFields {
+	additionalFields := make(common.AdditionalFields)
+
+	for _, record := range records {
+		// records decoded by goflow are used to build the flow, only the unknown records are kept raw
+		raw, ok := record.Data.(*sflow.FlowRecordRaw)
+		if !ok {
+			continue
+		}
+
+		// the data format is made of a 20 bits enterprise number and a 12 bits format
+		key := FieldKey{
+			Enterprise: record.Header.DataFormat >> 12,
+			Field:      uint16(record.Header.DataFormat & 0xfff),
+		}
+		mappingConfig, ok := fieldsConfig[key]
+		if !ok {
+			continue
+		}
+
+		if decode, ok := sflowRecordDecoders[key]; ok {
+			fields, err := decode(raw.Data)
+			if err != nil {
+				continue
+			}
+			for name, value := range fields {
+				additionalFields[mappingConfig.Destination+"."+name] = value
+			}
+			continue
+		}
+		mapAdditionalField(additionalFields, raw.Data, mappingConfig)
+	}
+
+	return additionalFields
+}
+
+// ProcessMessageSFlowAdditionalFields collects additional fields from the records of sFlow flow samples using the
+// given config, the additional fields are returned in the same order as the flow samples
+func ProcessMessageSFlowAdditionalFields(msgDec interface{}, fieldsConfig map[FieldKey]config.Mapping) ([]common.AdditionalFields, error) {
+	if len(fieldsConfig) == 0 {
+		return nil, nil
+	}
+
+	packet, ok := msgDec.(sflow.Packet)
+	if !ok {
+		return nil, errors.New("Bad sFlow version")
+	}
+
+	var flowsAdditionalFields []common.AdditionalFields
+	for _, sample := range packet.Samples {
+		switch sampleConv := sample.(type) {
+		case sflow.FlowSample:
+			flowsAdditionalFields = append(flowsAdditionalFields, convertSFlowRecords(sampleConv.Records, fieldsConfig))
+		case sflow.ExpandedFlowSample:
+			flowsAdditionalFields = append(flowsAdditionalFields, convertSFlowRecords(sampleConv.Records, fieldsConfig))
+		}
+	}
+	return flowsAdditionalFields, nil
+}
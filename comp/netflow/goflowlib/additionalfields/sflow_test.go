@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package additionalfields
+
+import (
+	"testing"
+
+	"github.com/netsampler/goflow2/decoders/sflow"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+)
+
+func xdrString(s string) []byte {
+	b := []byte{0, 0, 0, byte(len(s))}
+	b = append(b, s...)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func Test_ProcessMessageSFlowAdditionalFields(t *testing.T) {
+	extendedUser := append(append([]byte{0, 0, 0, 106}, xdrString("alice")...), append([]byte{0, 0, 0, 106}, xdrString("bob")...)...)
+	extendedURL := append(append([]byte{0, 0, 0, 1}, xdrString("/index.html")...), xdrString("example.com")...)
+	packet := sflow.Packet{
+		Samples: []interface{}{
+			sflow.FlowSample{Records: []sflow.FlowRecord{
+				{Header: sflow.RecordHeader{DataFormat: 1004}, Data: &sflow.FlowRecordRaw{Data: extendedUser}},
+				{Header: sflow.RecordHeader{DataFormat: 1005}, Data: &sflow.FlowRecordRaw{Data: extendedURL}},
+				{Header: sflow.RecordHeader{DataFormat: 1}, Data: sflow.SampledHeader{}},
+			}},
+			sflow.CounterSample{},
+			sflow.ExpandedFlowSample{Records: []sflow.FlowRecord{
+				// enterprise 4413, format 5
+				{Header: sflow.RecordHeader{DataFormat: 4413<<12 | 5}, Data: &sflow.FlowRecordRaw{Data: []byte{0, 0, 1, 0}}},
+				{Header: sflow.RecordHeader{DataFormat: 1004}, Data: &sflow.FlowRecordRaw{Data: []byte{0, 0, 0, 106, 0, 0, 0, 10}}},
+			}},
+		},
+	}
+	fieldsConfig := MapFieldsConfig([]config.Mapping{
+		{Field: 1004, Destination: "user"},
+		{Field: 1005, Destination: "http"},
+		{Enterprise: 4413, Field: 5, Destination: "vendor_field", Type: common.Integer},
+	})
+
+	additionalFields, err := ProcessMessageSFlowAdditionalFields(packet, fieldsConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, []common.AdditionalFields{
+		{
+			"user.source":      "alice",
+			"user.destination": "bob",
+			"http.url":         "/index.html",
+			"http.host":        "example.com",
+		},
+		{
+			// the truncated extended_user record is ignored
+			"vendor_field": uint64(256),
+		},
+	}, additionalFields)
+
+	additionalFields, err = ProcessMessageSFlowAdditionalFields(packet, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, additionalFields)
+
+	_, err = ProcessMessageSFlowAdditionalFields("invalid", fieldsConfig)
+	assert.EqualError(t, err, "Bad sFlow version")
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/netflow/config"
 	"github.com/DataDog/datadog-agent/comp/netflow/goflowlib/netflowstate"
+	"github.com/DataDog/datadog-agent/comp/netflow/goflowlib/sflowstate"
 
 	"github.com/netsampler/goflow2/decoders/netflow/templates"
 	"go.uber.org/atomic"
@@ -57,7 +58,7 @@ func StartFlowRoutine(
 	listenerFlowCount *atomic.Int64) (*FlowStateWrapper, error) {
 	var flowState FlowRunnableState
 
-	formatDriver := NewAggregatorFormatDriver(flowInChan, namespace, listenerFlowCount)
+	formatDriver := NewAggregatorFormatDriver(flowInChan, namespace, config.AggregationKeys(fieldMappings), listenerFlowCount)
 	logrusLogger := GetLogrusLevel(logger)
 	ctx := context.Background()
 
@@ -75,7 +76,7 @@ func StartFlowRoutine(
 		state.TemplateSystem = templateSystem
 		flowState = state
 	case common.TypeSFlow5:
-		state := utils.NewStateSFlow()
+		state := sflowstate.NewStateSFlow(fieldMappings)
 		state.Format = formatDriver
 		state.Logger = logrusLogger
 		flowState = state
@@ -17,14 +17,16 @@ import (
 // AggregatorFormatDriver is used as goflow formatter to forward flow data to aggregator/EP Forwarder
 type AggregatorFormatDriver struct {
 	namespace         string
+	aggregationKeys   []string
 	flowAggIn         chan *common.Flow
 	listenerFlowCount *atomic.Int64
 }
 
 // NewAggregatorFormatDriver returns a new AggregatorFormatDriver
-func NewAggregatorFormatDriver(flowAgg chan *common.Flow, namespace string, listenerFlowCount *atomic.Int64) *AggregatorFormatDriver {
+func NewAggregatorFormatDriver(flowAgg chan *common.Flow, namespace string, aggregationKeys []string, listenerFlowCount *atomic.Int64) *AggregatorFormatDriver {
 	return &AggregatorFormatDriver{
 		namespace:         namespace,
+		aggregationKeys:   aggregationKeys,
 		flowAggIn:         flowAgg,
 		listenerFlowCount: listenerFlowCount,
 	}
@@ -48,7 +50,9 @@ func (d *AggregatorFormatDriver) Format(data interface{}) ([]byte, []byte, error
 		d.flowAggIn <- ConvertFlow(flow, d.namespace)
 	case *common.FlowMessageWithAdditionalFields:
 		d.listenerFlowCount.Add(1)
-		d.flowAggIn <- ConvertFlowWithAdditionalFields(flow, d.namespace)
+		convertedFlow := ConvertFlowWithAdditionalFields(flow, d.namespace)
+		convertedFlow.AggregationKeys = d.aggregationKeys
+		d.flowAggIn <- convertedFlow
 	default:
 		return nil, nil, fmt.Errorf("message is not flowpb.FlowMessage or common.FlowMessageWithAdditionalFields")
 	}
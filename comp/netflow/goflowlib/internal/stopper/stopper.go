@@ -3,7 +3,8 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2023-present Datadog, Inc.
 
-package netflowstate
+// Package stopper implements the stop mechanism shared by the NetFlow and sFlow flow routines
+package stopper
 
 import (
 	"errors"
@@ -12,20 +13,22 @@ import (
 // ErrAlreadyStarted error happens when you try to start twice a flow routine
 var ErrAlreadyStarted = errors.New("the routine is already started")
 
-// stopper mechanism, common for all the flow routines
-type stopper struct {
+// Stopper mechanism, common for all the flow routines
+type Stopper struct {
 	stopCh chan struct{}
 }
 
-func (s *stopper) start() error {
+// Start returns the channel closed on Shutdown, or an error if the routine is already started
+func (s *Stopper) Start() (<-chan struct{}, error) {
 	if s.stopCh != nil {
-		return ErrAlreadyStarted
+		return nil, ErrAlreadyStarted
 	}
 	s.stopCh = make(chan struct{})
-	return nil
+	return s.stopCh, nil
 }
 
-func (s *stopper) Shutdown() {
+// Shutdown stops the routine
+func (s *Stopper) Shutdown() {
 	if s.stopCh != nil {
 		select {
 		case <-s.stopCh:
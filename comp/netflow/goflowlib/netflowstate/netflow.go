@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/netflow/common"
 	"github.com/DataDog/datadog-agent/comp/netflow/config"
 	"github.com/DataDog/datadog-agent/comp/netflow/goflowlib/additionalfields"
+	"github.com/DataDog/datadog-agent/comp/netflow/goflowlib/internal/stopper"
 	"github.com/netsampler/goflow2/utils"
 	"sync"
 	"time"
@@ -28,7 +29,7 @@ import (
 
 // StateNetFlow holds a NetflowV9/IPFIX producer
 type StateNetFlow struct {
-	stopper
+	stopper stopper.Stopper
 
 	Format    format.FormatInterface
 	Transport transport.TransportInterface
@@ -44,7 +45,7 @@ type StateNetFlow struct {
 
 	ctx context.Context
 
-	mappedFieldsConfig map[uint16]config.Mapping
+	mappedFieldsConfig map[additionalfields.FieldKey]config.Mapping
 }
 
 // NewStateNetFlow initializes a new Netflow/IPFIX producer, with the goflow default producer and the additional fields producer
@@ -53,7 +54,7 @@ func NewStateNetFlow(mappingConfs []config.Mapping) *StateNetFlow {
 		ctx:                context.Background(),
 		samplinglock:       &sync.RWMutex{},
 		sampling:           make(map[string]producer.SamplingRateSystem),
-		mappedFieldsConfig: mapFieldsConfig(mappingConfs),
+		mappedFieldsConfig: additionalfields.MapFieldsConfig(mappingConfs),
 	}
 }
 
@@ -158,21 +159,19 @@ func (s *StateNetFlow) initConfig() {
 	s.configMapped = producer.NewProducerConfigMapped(s.Config)
 }
 
-func mapFieldsConfig(mappingConfs []config.Mapping) map[uint16]config.Mapping {
-	mappedFieldsConfig := make(map[uint16]config.Mapping)
-	for _, conf := range mappingConfs {
-		mappedFieldsConfig[conf.Field] = conf
-	}
-	return mappedFieldsConfig
-}
-
 // FlowRoutine starts a goflow flow routine
 func (s *StateNetFlow) FlowRoutine(workers int, addr string, port int, reuseport bool) error {
-	if err := s.start(); err != nil {
+	stopCh, err := s.stopper.Start()
+	if err != nil {
 		return err
 	}
 	s.initConfig()
-	return utils.UDPStoppableRoutine(s.stopCh, "NetFlow", s.DecodeFlow, workers, addr, port, reuseport, s.Logger)
+	return utils.UDPStoppableRoutine(stopCh, "NetFlow", s.DecodeFlow, workers, addr, port, reuseport, s.Logger)
+}
+
+// Shutdown stops the flow routine
+func (s *StateNetFlow) Shutdown() {
+	s.stopper.Shutdown()
 }
 
 func (s *StateNetFlow) sendTelemetryMetrics(msg any, exporterIP string) {
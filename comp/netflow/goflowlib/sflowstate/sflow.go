@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package sflowstate provides a sFlow state manager
+// on top of goflow default producer, to allow additional fields collection.
+package sflowstate
+
+import (
+	"bytes"
+	"net"
+	"time"
+
+	"github.com/netsampler/goflow2/decoders/sflow"
+	"github.com/netsampler/goflow2/format"
+	"github.com/netsampler/goflow2/producer"
+	"github.com/netsampler/goflow2/utils"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+	"github.com/DataDog/datadog-agent/comp/netflow/goflowlib/additionalfields"
+	"github.com/DataDog/datadog-agent/comp/netflow/goflowlib/internal/stopper"
+)
+
+// StateSFlow holds a sFlow producer
+type StateSFlow struct {
+	stopper stopper.Stopper
+
+	Format format.FormatInterface
+	Logger utils.Logger
+
+	Config       *producer.ProducerConfig
+	configMapped *producer.ProducerConfigMapped
+
+	mappedFieldsConfig map[additionalfields.FieldKey]config.Mapping
+}
+
+// NewStateSFlow initializes a new sFlow producer, with the goflow default producer and the additional fields producer
+func NewStateSFlow(mappingConfs []config.Mapping) *StateSFlow {
+	return &StateSFlow{
+		mappedFieldsConfig: additionalfields.MapFieldsConfig(mappingConfs),
+	}
+}
+
+// DecodeFlow decodes a flow into common.FlowMessageWithAdditionalFields
+func (s *StateSFlow) DecodeFlow(msg interface{}) error {
+	pkt := msg.(utils.BaseMessage)
+	buf := bytes.NewBuffer(pkt.Payload)
+	key := pkt.Src.String()
+
+	ts := uint64(time.Now().UTC().Unix())
+	if pkt.SetTime {
+		ts = uint64(pkt.RecvTime.UTC().Unix())
+	}
+
+	timeTrackStart := time.Now()
+	msgDec, err := sflow.DecodeMessage(buf)
+	if err != nil {
+		errorLabel := "error_decoding"
+		switch err.(type) {
+		case *sflow.ErrorVersion:
+			errorLabel = "error_version"
+		case *sflow.ErrorIPVersion:
+			errorLabel = "error_ip_version"
+		case *sflow.ErrorDataFormat:
+			errorLabel = "error_data_format"
+		}
+		utils.SFlowErrors.With(
+			prometheus.Labels{
+				"router": key,
+				"error":  errorLabel,
+			}).
+			Inc()
+		return err
+	}
+
+	s.sendTelemetryMetrics(msgDec, key)
+
+	flowMessageSet, err := producer.ProcessMessageSFlowConfig(msgDec, s.configMapped)
+	if err != nil {
+		s.Logger.Errorf("failed to process sflow packet %s", err)
+	}
+
+	additionalFields, err := additionalfields.ProcessMessageSFlowAdditionalFields(msgDec, s.mappedFieldsConfig)
+	if err != nil {
+		s.Logger.Errorf("failed to process additional fields %s", err)
+	}
+
+	utils.DecoderTime.With(
+		prometheus.Labels{
+			"name": "sFlow",
+		}).
+		Observe(float64((time.Since(timeTrackStart)).Nanoseconds()) / 1000)
+
+	for i, fmsg := range flowMessageSet {
+		fmsg.TimeReceived = ts
+		fmsg.TimeFlowStart = ts
+		fmsg.TimeFlowEnd = ts
+
+		message := common.FlowMessageWithAdditionalFields{
+			FlowMessage: fmsg,
+		}
+
+		if i < len(additionalFields) {
+			message.AdditionalFields = additionalFields[i]
+		}
+
+		_, _, err := s.Format.Format(&message)
+
+		if err != nil && s.Logger != nil {
+			s.Logger.Error(err)
+		}
+	}
+
+	return nil
+}
+
+func (s *StateSFlow) sendTelemetryMetrics(msgDec interface{}, key string) {
+	packet, ok := msgDec.(sflow.Packet)
+	if !ok {
+		return
+	}
+	agentStr := net.IP(packet.AgentIP).String()
+	utils.SFlowStats.With(
+		prometheus.Labels{
+			"router":  key,
+			"agent":   agentStr,
+			"version": "5",
+		}).
+		Inc()
+
+	for _, samples := range packet.Samples {
+		typeStr := "unknown"
+		countRec := 0
+		switch samplesConv := samples.(type) {
+		case sflow.FlowSample:
+			typeStr = "FlowSample"
+			countRec = len(samplesConv.Records)
+		case sflow.CounterSample:
+			typeStr = "CounterSample"
+			if samplesConv.Header.Format == 4 {
+				typeStr = "Expanded" + typeStr
+			}
+			countRec = len(samplesConv.Records)
+		case sflow.ExpandedFlowSample:
+			typeStr = "ExpandedFlowSample"
+			countRec = len(samplesConv.Records)
+		}
+		labels := prometheus.Labels{
+			"router":  key,
+			"agent":   agentStr,
+			"version": "5",
+			"type":    typeStr,
+		}
+		utils.SFlowSampleStatsSum.With(labels).Inc()
+		utils.SFlowSampleRecordsStatsSum.With(labels).Add(float64(countRec))
+	}
+}
+
+func (s *StateSFlow) initConfig() {
+	s.configMapped = producer.NewProducerConfigMapped(s.Config)
+}
+
+// FlowRoutine starts a goflow flow routine
+func (s *StateSFlow) FlowRoutine(workers int, addr string, port int, reuseport bool) error {
+	stopCh, err := s.stopper.Start()
+	if err != nil {
+		return err
+	}
+	s.initConfig()
+	return utils.UDPStoppableRoutine(stopCh, "sFlow", s.DecodeFlow, workers, addr, port, reuseport, s.Logger)
+}
+
+// Shutdown stops the flow routine
+func (s *StateSFlow) Shutdown() {
+	s.stopper.Shutdown()
+}
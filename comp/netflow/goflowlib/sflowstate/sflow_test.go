@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+//go:build test
+
+package sflowstate
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/netsampler/goflow2/utils"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+	"github.com/DataDog/datadog-agent/comp/netflow/testutil"
+)
+
+type mockedFormatDriver struct {
+	messages []*common.FlowMessageWithAdditionalFields
+}
+
+func (m *mockedFormatDriver) Format(data interface{}) ([]byte, []byte, error) {
+	m.messages = append(m.messages, data.(*common.FlowMessageWithAdditionalFields))
+	return nil, nil, nil
+}
+
+func appendUint32(b []byte, values ...uint32) []byte {
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	return b
+}
+
+func appendRecord(b []byte, dataFormat uint32, data []byte) []byte {
+	b = appendUint32(b, dataFormat, uint32(len(data)))
+	return append(b, data...)
+}
+
+// buildSFlowPacket builds a sFlow datagram with a flow sample made of an extended_switch record and an extended_user record
+func buildSFlowPacket() []byte {
+	extendedSwitch := appendUint32(nil, 10, 0, 20, 0)
+
+	user := appendUint32(nil, 106, 5)
+	user = append(user, "alice\x00\x00\x00"...)
+	user = appendUint32(user, 106, 0)
+
+	var records []byte
+	records = appendRecord(records, 1001, extendedSwitch)
+	records = appendRecord(records, 1004, user)
+
+	sample := appendUint32(nil, 1, 1, 100, 1000, 0, 5, 6, 2)
+	sample = append(sample, records...)
+
+	datagram := appendUint32(nil, 5, 1)
+	datagram = append(datagram, 127, 0, 0, 1)
+	datagram = appendUint32(datagram, 0, 1, 1000, 1)
+	return appendRecord(datagram, 1, sample)
+}
+
+func TestSFlowState_AdditionalFields(t *testing.T) {
+	formatDriver := &mockedFormatDriver{}
+	state := NewStateSFlow([]config.Mapping{{Field: 1004, Destination: "user"}})
+	state.Format = formatDriver
+	state.Logger = logrus.StandardLogger()
+	state.initConfig()
+
+	err := state.DecodeFlow(utils.BaseMessage{
+		Src:     net.ParseIP("127.0.0.1"),
+		Port:    3000,
+		Payload: buildSFlowPacket(),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, formatDriver.messages, 1)
+	message := formatDriver.messages[0]
+	assert.Equal(t, uint32(10), message.SrcVlan)
+	assert.Equal(t, uint32(20), message.DstVlan)
+	assert.Equal(t, uint32(5), message.InIf)
+	assert.Equal(t, uint64(100), message.SamplingRate)
+	assert.Equal(t, common.AdditionalFields{
+		"user.source":      "alice",
+		"user.destination": "",
+	}, message.AdditionalFields)
+}
+
+func TestSFlowState_TelemetryMetrics(t *testing.T) {
+	state := NewStateSFlow(nil)
+	state.Format = &mockedFormatDriver{}
+	state.Logger = logrus.StandardLogger()
+	state.initConfig()
+
+	flowData, err := testutil.GetSFlow5Packet()
+	require.NoError(t, err, "error getting sflow packet data")
+
+	err = state.DecodeFlow(utils.BaseMessage{
+		Src:      net.ParseIP("127.0.0.2"),
+		Port:     3000,
+		Payload:  flowData,
+		RecvTime: time.Now(),
+	})
+	require.NoError(t, err, "error handling flow packet")
+
+	assert.Equal(t, float64(1), promtestutil.ToFloat64(utils.SFlowStats.WithLabelValues("127.0.0.2", "10.0.0.16", "5")))
+	assert.Equal(t, float64(7), promtestutil.ToFloat64(utils.SFlowSampleStatsSum.WithLabelValues("127.0.0.2", "10.0.0.16", "5", "FlowSample")))
+
+	err = state.DecodeFlow(utils.BaseMessage{
+		Src:     net.ParseIP("127.0.0.2"),
+		Port:    3000,
+		Payload: []byte{0, 0, 0, 4},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, float64(1), promtestutil.ToFloat64(utils.SFlowErrors.WithLabelValues("127.0.0.2", "error_version")))
+}
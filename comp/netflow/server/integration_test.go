@@ -201,7 +201,7 @@ func BenchmarkNetflowAdditionalFields(b *testing.B) {
 		}
 	}()
 
-	formatDriver := goflowlib.NewAggregatorFormatDriver(flowChan, "bench", listenerFlowCount)
+	formatDriver := goflowlib.NewAggregatorFormatDriver(flowChan, "bench", nil, listenerFlowCount)
 	logrusLogger := logrus.StandardLogger()
 	ctx := context.Background()
 
@@ -7,6 +7,7 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"time"
@@ -15,14 +16,17 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/DataDog/datadog-agent/comp/aggregator/demultiplexer"
+	api "github.com/DataDog/datadog-agent/comp/api/api/def"
 	"github.com/DataDog/datadog-agent/comp/core/hostname"
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
 	"github.com/DataDog/datadog-agent/comp/core/status"
 	"github.com/DataDog/datadog-agent/comp/ndmtmp/forwarder"
 	nfconfig "github.com/DataDog/datadog-agent/comp/netflow/config"
 	"github.com/DataDog/datadog-agent/comp/netflow/flowaggregator"
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector"
 	rdnsquerier "github.com/DataDog/datadog-agent/comp/rdnsquerier/def"
 	rdnsquerierimplnone "github.com/DataDog/datadog-agent/comp/rdnsquerier/impl-none"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 )
 
 type dependencies struct {
@@ -33,13 +37,15 @@ type dependencies struct {
 	Forwarder     forwarder.Component
 	Hostname      hostname.Component
 	RDNSQuerier   rdnsquerier.Component
+	NpCollector   npcollector.Component `optional:"true"`
 }
 
 type provides struct {
 	fx.Out
 
-	Comp           Component
-	StatusProvider status.InformationProvider
+	Comp               Component
+	StatusProvider     status.InformationProvider
+	TopTalkersEndpoint api.AgentEndpointProvider
 }
 
 // newServer configures a netflow server.
@@ -64,6 +70,7 @@ func newServer(lc fx.Lifecycle, deps dependencies) (provides, error) {
 	}
 
 	flowAgg := flowaggregator.NewFlowAggregator(sender, deps.Forwarder, conf, deps.Hostname.GetSafe(context.Background()), deps.Logger, rdnsQuerier)
+	flowAgg.NpCollector = deps.NpCollector
 
 	server := &Server{
 		config:  conf,
@@ -92,8 +99,9 @@ func newServer(lc fx.Lifecycle, deps dependencies) (provides, error) {
 		})
 	}
 	return provides{
-		Comp:           server,
-		StatusProvider: status.NewInformationProvider(statusProvider),
+		Comp:               server,
+		StatusProvider:     status.NewInformationProvider(statusProvider),
+		TopTalkersEndpoint: api.NewAgentEndpointProvider(server.writeTopTalkers, "/netflow/top-talkers", "GET"),
 	}, nil
 }
 
@@ -164,3 +172,17 @@ func (s *Server) Stop() {
 	}
 	s.running = false
 }
+
+// writeTopTalkers writes the top talkers computed from the flows as JSON
+func (s *Server) writeTopTalkers(w http.ResponseWriter, _ *http.Request) {
+	var response flowaggregator.TopTalkersResponse
+	if s.config.Enabled {
+		response = s.FlowAgg.TopTalkers()
+	}
+	jsonTopTalkers, err := json.Marshal(response)
+	if err != nil {
+		httputils.SetJSONError(w, err, 500)
+		return
+	}
+	w.Write(jsonTopTalkers)
+}
@@ -9,6 +9,7 @@ package server
 
 import (
 	"context"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -109,3 +110,42 @@ func TestStartServerAndStopServer(t *testing.T) {
 	app.RequireStop()
 	assert.False(t, server.running)
 }
+
+func TestWriteTopTalkers(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		conf     *nfconfig.NetflowConfig
+		expected string
+	}{
+		{
+			name:     "netflow disabled",
+			conf:     &nfconfig.NetflowConfig{TopTalkers: nfconfig.TopTalkersConfig{Enabled: true}},
+			expected: `{"enabled":false}`,
+		},
+		{
+			name:     "top talkers disabled",
+			conf:     &nfconfig.NetflowConfig{Enabled: true},
+			expected: `{"enabled":false}`,
+		},
+		{
+			name:     "top talkers enabled",
+			conf:     &nfconfig.NetflowConfig{Enabled: true, TopTalkers: nfconfig.TopTalkersConfig{Enabled: true, Size: 10, Interval: 300}},
+			expected: `{"enabled":true}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var component Component
+			fxtest.New(t, fx.Options(
+				testOptions,
+				fx.Supply(fx.Annotate(t, fx.As(new(testing.TB)))),
+				fx.Replace(tt.conf),
+				fx.Populate(&component),
+			))
+
+			w := httptest.NewRecorder()
+			component.(*Server).writeTopTalkers(w, httptest.NewRequest("GET", "/netflow/top-talkers", nil))
+			assert.Equal(t, 200, w.Code)
+			assert.JSONEq(t, tt.expected, w.Body.String())
+		})
+	}
+}
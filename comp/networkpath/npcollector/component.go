@@ -6,11 +6,29 @@
 // Package npcollector used to manage network paths
 package npcollector
 
-import model "github.com/DataDog/agent-payload/v5/process"
+import (
+	model "github.com/DataDog/agent-payload/v5/process"
+
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
+)
 
 // team: Networks network-device-monitoring
 
 // Component is the component type.
 type Component interface {
 	ScheduleConns(conns []*model.Connection)
+	// ScheduleFlows schedules the destinations of the flows received by NetFlow,
+	// it does nothing unless network_path.netflow_monitoring.enabled is set
+	ScheduleFlows(flows []Flow)
+	// NetflowMonitoringEnabled returns true when ScheduleFlows schedules the flows it receives
+	NetflowMonitoringEnabled() bool
+}
+
+// Flow is the traffic to a destination seen in a NetFlow flow
+type Flow struct {
+	DestinationIP   string
+	DestinationPort uint16
+	Protocol        payload.Protocol
+	Bytes           uint64
+	Packets         uint64
 }
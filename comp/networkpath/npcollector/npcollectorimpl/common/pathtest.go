@@ -18,6 +18,8 @@ type Pathtest struct {
 	Port              uint16
 	Protocol          payload.Protocol
 	SourceContainerID string
+	// Selection is why the Pathtest was scheduled, it's not part of the hash
+	Selection *payload.NetworkPathSelection
 }
 
 // GetHash returns the hash of the Pathtest
@@ -6,13 +6,18 @@
 package npcollectorimpl
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/selection"
 )
 
 type collectorConfigs struct {
 	connectionsMonitoringEnabled bool
+	netflowMonitoringEnabled     bool
+	selectionEnabled             bool
+	selection                    selection.Config
 	workers                      int
 	pathtestInputChanSize        int
 	pathtestProcessingChanSize   int
@@ -26,6 +31,8 @@ type collectorConfigs struct {
 func newConfig(agentConfig config.Component) *collectorConfigs {
 	return &collectorConfigs{
 		connectionsMonitoringEnabled: agentConfig.GetBool("network_path.connections_monitoring.enabled"),
+		netflowMonitoringEnabled:     agentConfig.GetBool("network_path.netflow_monitoring.enabled"),
+		selectionEnabled:             agentConfig.GetBool("network_path.collector.selection.enabled"),
 		workers:                      agentConfig.GetInt("network_path.collector.workers"),
 		pathtestInputChanSize:        agentConfig.GetInt("network_path.collector.input_chan_size"),
 		pathtestProcessingChanSize:   agentConfig.GetInt("network_path.collector.processing_chan_size"),
@@ -34,11 +41,27 @@ func newConfig(agentConfig config.Component) *collectorConfigs {
 		pathtestInterval:             agentConfig.GetDuration("network_path.collector.pathtest_interval"),
 		flushInterval:                agentConfig.GetDuration("network_path.collector.flush_interval"),
 		networkDevicesNamespace:      agentConfig.GetString("network_devices.namespace"),
+		selection: selection.Config{
+			Policy:             selection.Policy(agentConfig.GetString("network_path.collector.selection.policy")),
+			Window:             agentConfig.GetDuration("network_path.collector.selection.window"),
+			MaxPerWindow:       agentConfig.GetInt("network_path.collector.selection.max_tests_per_window"),
+			SubnetPrefixLength: agentConfig.GetInt("network_path.collector.selection.subnet_prefix_length"),
+		},
 	}
 }
 
 // networkPathCollectorEnabled checks if Network Path Collector should be enabled
 // Network Path Collector is expected to be enabled if a feature depend on it.
 func (c *collectorConfigs) networkPathCollectorEnabled() bool {
-	return c.connectionsMonitoringEnabled
+	return c.connectionsMonitoringEnabled || c.netflowMonitoringEnabled
+}
+
+// validate checks the configuration of the features that can be misconfigured
+func (c *collectorConfigs) validate() error {
+	if c.selectionEnabled {
+		if err := c.selection.Validate(); err != nil {
+			return fmt.Errorf("invalid network_path.collector.selection: %w", err)
+		}
+	}
+	return nil
 }
@@ -20,8 +20,10 @@ import (
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
 	telemetryComp "github.com/DataDog/datadog-agent/comp/core/telemetry"
 	"github.com/DataDog/datadog-agent/comp/forwarder/eventplatform"
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector"
 	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/common"
 	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/pathteststore"
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/selection"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/networkpath/metricsender"
 	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
@@ -48,6 +50,9 @@ type npCollectorImpl struct {
 	pathtestInputChan      chan *common.Pathtest
 	pathtestProcessingChan chan *pathteststore.PathtestContext
 
+	// Selection of the destinations to test, nil if all destinations are tested
+	selector *selection.Selector
+
 	// Scheduling related
 	running       bool
 	workers       int
@@ -84,6 +89,16 @@ func newNpCollectorImpl(epForwarder eventplatform.Forwarder, collectorConfigs *c
 		collectorConfigs.pathtestInterval,
 		collectorConfigs.flushInterval)
 
+	var selector *selection.Selector
+	if collectorConfigs.selectionEnabled {
+		logger.Infof("NpCollector destination selection enabled (policy=%s window=%s max_tests_per_window=%d subnet_prefix_length=%d)",
+			collectorConfigs.selection.Policy,
+			collectorConfigs.selection.Window,
+			collectorConfigs.selection.MaxPerWindow,
+			collectorConfigs.selection.SubnetPrefixLength)
+		selector = selection.NewSelector(collectorConfigs.selection)
+	}
+
 	return &npCollectorImpl{
 		epForwarder:      epForwarder,
 		collectorConfigs: collectorConfigs,
@@ -92,6 +107,7 @@ func newNpCollectorImpl(epForwarder eventplatform.Forwarder, collectorConfigs *c
 		pathtestStore:          pathteststore.NewPathtestStore(collectorConfigs.pathtestTTL, collectorConfigs.pathtestInterval, collectorConfigs.pathtestContextsLimit, logger),
 		pathtestInputChan:      make(chan *common.Pathtest, collectorConfigs.pathtestInputChanSize),
 		pathtestProcessingChan: make(chan *pathteststore.PathtestContext, collectorConfigs.pathtestProcessingChanSize),
+		selector:               selector,
 		flushInterval:          collectorConfigs.flushInterval,
 		workers:                collectorConfigs.workers,
 
@@ -124,8 +140,23 @@ func (s *npCollectorImpl) ScheduleConns(conns []*model.Connection) {
 			s.logger.Tracef("Skipped connection: addr=%s, port=%d, protocol=%s", remoteAddr, remotePort, protocol)
 			continue
 		}
-		sourceContainer := conn.Laddr.GetContainerId()
-		err := s.scheduleOne(remoteAddr.GetIp(), remotePort, protocol, sourceContainer)
+		ptest := common.Pathtest{
+			Hostname:          remoteAddr.GetIp(),
+			Port:              remotePort,
+			Protocol:          protocol,
+			SourceContainerID: conn.Laddr.GetContainerId(),
+		}
+		if s.selector != nil {
+			s.selector.Observe(selection.Observation{
+				Pathtest: ptest,
+				Source:   selection.SourceConnections,
+				Bytes:    conn.LastBytesSent + conn.LastBytesReceived,
+				Packets:  conn.LastPacketsSent + conn.LastPacketsReceived,
+				Errors:   connErrors(conn),
+			})
+			continue
+		}
+		err := s.scheduleOne(&ptest)
 		if err != nil {
 			s.logger.Errorf("Error scheduling pathtests: %s", err)
 		}
@@ -135,20 +166,48 @@ func (s *npCollectorImpl) ScheduleConns(conns []*model.Connection) {
 	s.statsdClient.Gauge("datadog.network_path.collector.schedule_duration", scheduleDuration.Seconds(), nil, 1) //nolint:errcheck
 }
 
+func (s *npCollectorImpl) NetflowMonitoringEnabled() bool {
+	return s.collectorConfigs.netflowMonitoringEnabled
+}
+
+func (s *npCollectorImpl) ScheduleFlows(flows []npcollector.Flow) {
+	if !s.collectorConfigs.netflowMonitoringEnabled {
+		return
+	}
+	for _, flow := range flows {
+		if !shouldScheduleNetworkPathForFlow(flow) {
+			s.logger.Tracef("Skipped flow: addr=%s, port=%d, protocol=%s", flow.DestinationIP, flow.DestinationPort, flow.Protocol)
+			continue
+		}
+		ptest := common.Pathtest{
+			Hostname: flow.DestinationIP,
+			Port:     flow.DestinationPort,
+			Protocol: flow.Protocol,
+		}
+		if s.selector != nil {
+			s.selector.Observe(selection.Observation{
+				Pathtest: ptest,
+				Source:   selection.SourceNetFlow,
+				Bytes:    flow.Bytes,
+				Packets:  flow.Packets,
+			})
+			continue
+		}
+		err := s.scheduleOne(&ptest)
+		if err != nil {
+			s.logger.Errorf("Error scheduling pathtests: %s", err)
+		}
+	}
+}
+
 // scheduleOne schedules pathtests.
 // It shouldn't block, if the input channel is full, an error is returned.
-func (s *npCollectorImpl) scheduleOne(hostname string, port uint16, protocol payload.Protocol, sourceContainerID string) error {
+func (s *npCollectorImpl) scheduleOne(ptest *common.Pathtest) error {
 	if s.pathtestInputChan == nil {
 		return errors.New("no input channel, please check that network path is enabled")
 	}
-	s.logger.Debugf("Schedule traceroute for: hostname=%s port=%d", hostname, port)
+	s.logger.Debugf("Schedule traceroute for: hostname=%s port=%d", ptest.Hostname, ptest.Port)
 
-	ptest := &common.Pathtest{
-		Hostname:          hostname,
-		Port:              port,
-		Protocol:          protocol,
-		SourceContainerID: sourceContainerID,
-	}
 	select {
 	case s.pathtestInputChan <- ptest:
 		return nil
@@ -156,6 +215,7 @@ func (s *npCollectorImpl) scheduleOne(hostname string, port uint16, protocol pay
 		return fmt.Errorf("collector input channel is full (channel capacity is %d)", cap(s.pathtestInputChan))
 	}
 }
+
 func (s *npCollectorImpl) start() error {
 	if s.running {
 		return errors.New("server already started")
@@ -222,6 +282,7 @@ func (s *npCollectorImpl) runTracerouteForPath(ptest *pathteststore.PathtestCont
 	}
 	path.Source.ContainerID = ptest.Pathtest.SourceContainerID
 	path.Namespace = s.networkDevicesNamespace
+	path.Selection = ptest.Pathtest.Selection
 
 	s.sendTelemetry(path, startTime, ptest)
 
@@ -286,6 +347,16 @@ func (s *npCollectorImpl) flushWrapper(flushTime time.Time, lastFlushTime time.T
 func (s *npCollectorImpl) flush() {
 	s.statsdClient.Gauge("datadog.network_path.collector.workers", float64(s.workers), []string{}, 1) //nolint:errcheck
 
+	if s.selector != nil {
+		selected := s.selector.Select()
+		if len(selected) > 0 {
+			s.logger.Debugf("Selected %d pathtests", len(selected))
+		}
+		for _, ptest := range selected {
+			s.pathtestStore.Add(ptest)
+		}
+	}
+
 	flowsContexts := s.pathtestStore.GetContextsCount()
 	s.statsdClient.Gauge("datadog.network_path.collector.pathtest_store_size", float64(flowsContexts), []string{}, 1) //nolint:errcheck
 
@@ -11,6 +11,7 @@ import (
 	model "github.com/DataDog/agent-payload/v5/process"
 	"go.uber.org/fx"
 
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
 
@@ -27,6 +28,14 @@ func (s *npCollectorMock) ScheduleConns(_ []*model.Connection) {
 	panic("implement me")
 }
 
+func (s *npCollectorMock) ScheduleFlows(_ []npcollector.Flow) {
+	panic("implement me")
+}
+
+func (s *npCollectorMock) NetflowMonitoringEnabled() bool {
+	panic("implement me")
+}
+
 func newMock() provides {
 	// Mock initialization
 	return provides{
@@ -21,12 +21,15 @@ import (
 	"github.com/cihub/seelog"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/comp/core/telemetry"
 	"github.com/DataDog/datadog-agent/comp/forwarder/eventplatform"
 	"github.com/DataDog/datadog-agent/comp/forwarder/eventplatform/eventplatformimpl"
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector"
 	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/common"
 	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/pathteststore"
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/selection"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/networkpath/metricsender"
 	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
@@ -317,6 +320,43 @@ func Test_newNpCollectorImpl_overrideConfigs(t *testing.T) {
 	assert.Equal(t, "ns1", npCollector.networkDevicesNamespace)
 }
 
+func Test_newNpCollectorImpl_selectionConfigs(t *testing.T) {
+	agentConfigs := map[string]any{
+		"network_path.netflow_monitoring.enabled":               true,
+		"network_path.collector.selection.enabled":              true,
+		"network_path.collector.selection.policy":               "error_rate",
+		"network_path.collector.selection.window":               "10m",
+		"network_path.collector.selection.max_tests_per_window": 20,
+		"network_path.collector.selection.subnet_prefix_length": 16,
+	}
+
+	_, npCollector := newTestNpCollector(t, agentConfigs)
+
+	assert.Equal(t, true, npCollector.collectorConfigs.networkPathCollectorEnabled())
+	require.NotNil(t, npCollector.selector)
+	assert.Equal(t, selection.Config{
+		Policy:             selection.PolicyErrorRate,
+		Window:             10 * time.Minute,
+		MaxPerWindow:       20,
+		SubnetPrefixLength: 16,
+	}, npCollector.collectorConfigs.selection)
+}
+
+func Test_newNpCollectorImpl_invalidSelectionConfigs(t *testing.T) {
+	agentConfigs := map[string]any{
+		"network_path.connections_monitoring.enabled": true,
+		"network_path.collector.selection.enabled":    true,
+		"network_path.collector.selection.policy":     "random",
+	}
+
+	_, npCollector := newTestNpCollector(t, agentConfigs)
+
+	// falls back to the noop collector
+	assert.Equal(t, false, npCollector.collectorConfigs.networkPathCollectorEnabled())
+	assert.Nil(t, npCollector.pathtestInputChan)
+	assert.Nil(t, npCollector.selector)
+}
+
 func Test_npCollectorImpl_ScheduleConns(t *testing.T) {
 	type logCount struct {
 		log   string
@@ -523,6 +563,141 @@ func Test_npCollectorImpl_ScheduleConns(t *testing.T) {
 	}
 }
 
+func Test_npCollectorImpl_ScheduleConns_selection(t *testing.T) {
+	agentConfigs := map[string]any{
+		"network_path.connections_monitoring.enabled":           true,
+		"network_path.collector.selection.enabled":              true,
+		"network_path.collector.selection.window":               "1ms",
+		"network_path.collector.selection.max_tests_per_window": 1,
+	}
+	_, npCollector := newTestNpCollector(t, agentConfigs)
+	stats := &teststatsd.Client{}
+	npCollector.statsdClient = stats
+
+	conns := []*model.Connection{
+		{
+			Laddr:             &model.Addr{Ip: "10.0.0.1", Port: int32(30000), ContainerId: "testId1"},
+			Raddr:             &model.Addr{Ip: "10.0.1.1", Port: int32(443)},
+			Direction:         model.ConnectionDirection_outgoing,
+			Type:              model.ConnectionType_tcp,
+			LastBytesSent:     100,
+			LastBytesReceived: 200,
+		},
+		{
+			Laddr:             &model.Addr{Ip: "10.0.0.1", Port: int32(30001), ContainerId: "testId2"},
+			Raddr:             &model.Addr{Ip: "10.0.1.2", Port: int32(443)},
+			Direction:         model.ConnectionDirection_outgoing,
+			Type:              model.ConnectionType_tcp,
+			LastBytesSent:     1000,
+			LastBytesReceived: 2000,
+		},
+		{
+			Laddr:         &model.Addr{Ip: "10.0.0.1", Port: int32(30002)},
+			Raddr:         &model.Addr{Ip: "10.0.2.1", Port: int32(53)},
+			Direction:     model.ConnectionDirection_outgoing,
+			Type:          model.ConnectionType_udp,
+			LastBytesSent: 10,
+		},
+	}
+
+	// WHEN
+	npCollector.ScheduleConns(conns)
+
+	// THEN the destinations are observed instead of being scheduled
+	assert.Equal(t, 0, len(npCollector.pathtestInputChan))
+	selectionStats := npCollector.selector.GetStats()
+	assert.Equal(t, 3, selectionStats.Observations)
+	assert.Equal(t, 2, selectionStats.Candidates)
+
+	// the top destination is tested once the window is over
+	time.Sleep(5 * time.Millisecond)
+	npCollector.flush()
+
+	require.Equal(t, 1, len(npCollector.pathtestProcessingChan))
+	ptestCtx := <-npCollector.pathtestProcessingChan
+	assert.Equal(t, &common.Pathtest{
+		Hostname:          "10.0.1.2",
+		Port:              443,
+		Protocol:          payload.ProtocolTCP,
+		SourceContainerID: "testId2",
+		Selection: &payload.NetworkPathSelection{
+			Reason:  "traffic_volume",
+			Rank:    1,
+			Score:   3300,
+			Hits:    2,
+			Sources: []string{"connections"},
+		},
+	}, ptestCtx.Pathtest)
+	assert.Equal(t, uint64(1), npCollector.selector.GetStats().TotalSelected)
+	assert.Equal(t, 1, npCollector.selector.GetStats().LastOverCap)
+}
+
+func Test_npCollectorImpl_ScheduleFlows(t *testing.T) {
+	flows := []npcollector.Flow{
+		{DestinationIP: "10.0.0.4", DestinationPort: 443, Protocol: payload.ProtocolTCP, Bytes: 100, Packets: 1},
+		{DestinationIP: "10.0.0.5", DestinationPort: 53, Protocol: payload.ProtocolUDP, Bytes: 10, Packets: 1},
+		// skipped
+		{DestinationIP: "10.0.0.6", DestinationPort: 0, Protocol: payload.ProtocolTCP},
+		{DestinationIP: "127.0.0.1", DestinationPort: 80, Protocol: payload.ProtocolTCP},
+		{DestinationIP: "::1", DestinationPort: 80, Protocol: payload.ProtocolTCP},
+		{DestinationIP: "10.0.0.7", DestinationPort: 80, Protocol: ""},
+	}
+	tests := []struct {
+		name              string
+		agentConfigs      map[string]any
+		expectedPathtests []*common.Pathtest
+	}{
+		{
+			name: "netflow monitoring disabled",
+			agentConfigs: map[string]any{
+				"network_path.connections_monitoring.enabled": true,
+			},
+			expectedPathtests: []*common.Pathtest{},
+		},
+		{
+			name: "netflow monitoring enabled",
+			agentConfigs: map[string]any{
+				"network_path.netflow_monitoring.enabled": true,
+			},
+			expectedPathtests: []*common.Pathtest{
+				{Hostname: "10.0.0.4", Port: uint16(443), Protocol: payload.ProtocolTCP},
+				{Hostname: "10.0.0.5", Port: uint16(53), Protocol: payload.ProtocolUDP},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, npCollector := newTestNpCollector(t, tt.agentConfigs)
+
+			npCollector.ScheduleFlows(flows)
+
+			actualPathtests := []*common.Pathtest{}
+			for len(npCollector.pathtestInputChan) > 0 {
+				actualPathtests = append(actualPathtests, <-npCollector.pathtestInputChan)
+			}
+			assert.Equal(t, tt.expectedPathtests, actualPathtests)
+		})
+	}
+}
+
+func Test_npCollectorImpl_ScheduleFlows_selection(t *testing.T) {
+	agentConfigs := map[string]any{
+		"network_path.netflow_monitoring.enabled":  true,
+		"network_path.collector.selection.enabled": true,
+	}
+	_, npCollector := newTestNpCollector(t, agentConfigs)
+
+	npCollector.ScheduleFlows([]npcollector.Flow{
+		{DestinationIP: "10.0.0.4", DestinationPort: 443, Protocol: payload.ProtocolTCP, Bytes: 100, Packets: 1},
+		{DestinationIP: "10.0.0.5", DestinationPort: 443, Protocol: payload.ProtocolTCP, Bytes: 10, Packets: 1},
+	})
+
+	assert.Equal(t, 0, len(npCollector.pathtestInputChan))
+	selectionStats := npCollector.selector.GetStats()
+	assert.Equal(t, 2, selectionStats.Observations)
+	assert.Equal(t, 1, selectionStats.Candidates)
+}
+
 func Test_npCollectorImpl_stopWorker(t *testing.T) {
 	agentConfigs := map[string]any{
 		"network_path.connections_monitoring.enabled": true,
@@ -12,6 +12,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/comp/core/status"
 	"github.com/DataDog/datadog-agent/comp/core/telemetry"
 	"github.com/DataDog/datadog-agent/comp/forwarder/eventplatform"
 	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector"
@@ -30,7 +31,8 @@ type dependencies struct {
 type provides struct {
 	fx.Out
 
-	Comp npcollector.Component
+	Comp           npcollector.Component
+	StatusProvider status.InformationProvider
 }
 
 // Module defines the fx options for this component.
@@ -43,6 +45,8 @@ func Module() fxutil.Module {
 func newNpCollector(deps dependencies) provides {
 	var collector *npCollectorImpl
 
+	var statusProvider status.Provider
+
 	configs := newConfig(deps.AgentConfig)
 	if configs.networkPathCollectorEnabled() {
 		deps.Logger.Debugf("Network Path Collector enabled")
@@ -50,8 +54,14 @@ func newNpCollector(deps dependencies) provides {
 		if !ok {
 			deps.Logger.Errorf("Error getting EpForwarder")
 			collector = newNoopNpCollectorImpl()
+		} else if err := configs.validate(); err != nil {
+			deps.Logger.Errorf("Invalid Network Path Collector configuration: %s", err)
+			collector = newNoopNpCollectorImpl()
 		} else {
 			collector = newNpCollectorImpl(epForwarder, configs, deps.Logger, deps.Telemetry)
+			statusProvider = Provider{
+				collector: collector,
+			}
 			deps.Lc.Append(fx.Hook{
 				// No need for OnStart hook since NpCollector.Init() will be called by clients when needed.
 				OnStart: func(context.Context) error {
@@ -69,6 +79,7 @@ func newNpCollector(deps dependencies) provides {
 	}
 
 	return provides{
-		Comp: collector,
+		Comp:           collector,
+		StatusProvider: status.NewInformationProvider(statusProvider),
 	}
 }
@@ -113,7 +113,9 @@ func (f *Store) Flush() []*PathtestContext {
 			ptConfigCtx.lastFlushInterval = now.Sub(ptConfigCtx.lastFlushTime)
 		}
 		ptConfigCtx.lastFlushTime = now
-		pathtestsToFlush = append(pathtestsToFlush, ptConfigCtx)
+		// flush a copy since the Pathtest can be replaced by `Store.Add` while the workers run it
+		flushedCtx := *ptConfigCtx
+		pathtestsToFlush = append(pathtestsToFlush, &flushedCtx)
 		ptConfigCtx.nextRun = ptConfigCtx.nextRun.Add(f.interval)
 	}
 	return pathtestsToFlush
@@ -142,6 +144,10 @@ func (f *Store) Add(pathtestToAdd *common.Pathtest) {
 		return
 	}
 	pathtestCtx.runUntil = timeNow().Add(f.ttl)
+	if pathtestToAdd.Selection != nil {
+		// keep the latest reason the Pathtest was selected for
+		pathtestCtx.Pathtest = pathtestToAdd
+	}
 }
 
 // GetContextsCount returns pathtest contexts count
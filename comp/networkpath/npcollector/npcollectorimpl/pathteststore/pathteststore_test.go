@@ -17,6 +17,7 @@ import (
 
 	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
 	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/common"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
 	utillog "github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -218,3 +219,21 @@ func Test_pathtestStore_flush(t *testing.T) {
 	store.Flush()
 	assert.Equal(t, 0, len(store.contexts))
 }
+
+func Test_pathtestStore_add_updates_selection(t *testing.T) {
+	logger := logmock.New(t)
+	store := NewPathtestStore(10*time.Minute, 1*time.Minute, 10, logger)
+
+	store.Add(&common.Pathtest{Hostname: "host1", Port: 53, Selection: &payload.NetworkPathSelection{Reason: "traffic_volume", Rank: 2}})
+	flushed := store.Flush()
+
+	// the selection is updated without changing the flushed contexts
+	store.Add(&common.Pathtest{Hostname: "host1", Port: 53, Selection: &payload.NetworkPathSelection{Reason: "traffic_volume", Rank: 1}})
+	// adding a Pathtest without selection keeps the previous one
+	store.Add(&common.Pathtest{Hostname: "host1", Port: 53})
+
+	assert.Equal(t, 1, len(store.contexts))
+	assert.Equal(t, 1, store.contexts[(&common.Pathtest{Hostname: "host1", Port: 53}).GetHash()].Pathtest.Selection.Rank)
+	assert.Len(t, flushed, 1)
+	assert.Equal(t, 2, flushed[0].Pathtest.Selection.Rank)
+}
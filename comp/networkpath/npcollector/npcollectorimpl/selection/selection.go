@@ -0,0 +1,327 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package selection chooses the paths to test from the traffic seen by the agent
+package selection
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/common"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
+)
+
+var timeNow = time.Now
+
+// Policy defines how destinations are ranked
+type Policy string
+
+const (
+	// PolicyTrafficVolume ranks destinations by bytes sent and received
+	PolicyTrafficVolume Policy = "traffic_volume"
+	// PolicyErrorRate ranks destinations by errors per packet, e.g. TCP retransmits and failures
+	PolicyErrorRate Policy = "error_rate"
+)
+
+// Source is where a destination was seen
+type Source string
+
+const (
+	// SourceConnections is the connections seen by the process agent
+	SourceConnections Source = "connections"
+	// SourceNetFlow is the flows received by NetFlow
+	SourceNetFlow Source = "netflow"
+)
+
+// maxCandidates bounds the destinations tracked during a window
+const maxCandidates = 100000
+
+// Config is the configuration of the Selector
+type Config struct {
+	Policy Policy
+	// Window is how often destinations are selected, from the traffic seen during the window
+	Window time.Duration
+	// MaxPerWindow is the maximum number of destinations selected per window
+	MaxPerWindow int
+	// SubnetPrefixLength is the prefix length of the IPv4 subnets deduplicating destinations
+	SubnetPrefixLength int
+}
+
+// Validate checks the configuration
+func (c Config) Validate() error {
+	if c.Policy != PolicyTrafficVolume && c.Policy != PolicyErrorRate {
+		return fmt.Errorf("invalid policy %q, must be %q or %q", c.Policy, PolicyTrafficVolume, PolicyErrorRate)
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("window must be > 0")
+	}
+	if c.MaxPerWindow <= 0 {
+		return fmt.Errorf("max tests per window must be > 0")
+	}
+	if c.SubnetPrefixLength < 0 || c.SubnetPrefixLength > 32 {
+		return fmt.Errorf("subnet prefix length must be between 0 and 32, got %d", c.SubnetPrefixLength)
+	}
+	return nil
+}
+
+// Observation is the traffic seen to a destination
+type Observation struct {
+	Pathtest common.Pathtest
+	Source   Source
+	Bytes    uint64
+	Packets  uint64
+	Errors   uint64
+}
+
+// SelectedPath is a destination selected in the last window
+type SelectedPath struct {
+	Hostname string
+	Port     uint16
+	Protocol payload.Protocol
+	Subnet   string
+	Rank     int
+	Score    float64
+	Hits     int
+	Sources  []string
+}
+
+// Stats are the stats of the Selector, used in the agent status
+type Stats struct {
+	Policy             Policy
+	Window             time.Duration
+	MaxPerWindow       int
+	SubnetPrefixLength int
+	// Observations and Candidates are for the current window
+	Observations int
+	Candidates   int
+	// DroppedObservations are the observations of new destinations dropped because too many destinations were seen
+	DroppedObservations uint64
+	LastSelection       time.Time
+	// LastCandidates are the destinations that could be selected in the last window, LastOverCap are the ones that
+	// weren't selected because of MaxPerWindow
+	LastCandidates int
+	LastOverCap    int
+	TotalSelected  uint64
+	Selected       []SelectedPath
+}
+
+type groupKey struct {
+	protocol payload.Protocol
+	subnet   string
+	port     uint16
+}
+
+// candidate aggregates the traffic of the destinations of a subnet and port
+type candidate struct {
+	key groupKey
+	// pathtest is the destination of the subnet with the highest score, it's the one tested
+	pathtest      common.Pathtest
+	pathtestScore float64
+	bytes         uint64
+	packets       uint64
+	errors        uint64
+	hits          int
+	sources       map[Source]struct{}
+}
+
+// Selector ranks the destinations seen during a window and selects the top ones
+type Selector struct {
+	config Config
+
+	mutex               sync.Mutex
+	windowStart         time.Time
+	candidates          map[groupKey]*candidate
+	observations        int
+	droppedObservations uint64
+	lastSelection       time.Time
+	lastCandidates      int
+	lastOverCap         int
+	totalSelected       uint64
+	selected            []SelectedPath
+}
+
+// NewSelector creates a Selector
+func NewSelector(config Config) *Selector {
+	return &Selector{
+		config:      config,
+		windowStart: timeNow(),
+		candidates:  make(map[groupKey]*candidate),
+	}
+}
+
+// Observe adds the traffic seen to a destination to the current window
+func (s *Selector) Observe(obs Observation) {
+	key, ok := s.groupKey(obs.Pathtest)
+	if !ok {
+		return
+	}
+	score := s.score(obs.Bytes, obs.Packets, obs.Errors)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.observations++
+	c, ok := s.candidates[key]
+	if !ok {
+		if len(s.candidates) >= maxCandidates {
+			s.droppedObservations++
+			return
+		}
+		c = &candidate{
+			key:           key,
+			pathtest:      obs.Pathtest,
+			pathtestScore: score,
+			sources:       make(map[Source]struct{}),
+		}
+		s.candidates[key] = c
+	}
+	if score > c.pathtestScore {
+		c.pathtest = obs.Pathtest
+		c.pathtestScore = score
+	}
+	c.bytes += obs.Bytes
+	c.packets += obs.Packets
+	c.errors += obs.Errors
+	c.hits++
+	c.sources[obs.Source] = struct{}{}
+}
+
+// Select returns the top destinations of the window once it's over, and starts a new window
+func (s *Selector) Select() []*common.Pathtest {
+	now := timeNow()
+
+	s.mutex.Lock()
+	if now.Sub(s.windowStart) < s.config.Window {
+		s.mutex.Unlock()
+		return nil
+	}
+	candidates := s.candidates
+	s.candidates = make(map[groupKey]*candidate)
+	s.observations = 0
+	s.windowStart = now
+	s.mutex.Unlock()
+
+	type scored struct {
+		*candidate
+		score float64
+	}
+	ranked := make([]scored, 0, len(candidates))
+	for _, c := range candidates {
+		score := s.score(c.bytes, c.packets, c.errors)
+		if score <= 0 {
+			continue
+		}
+		ranked = append(ranked, scored{candidate: c, score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		if ranked[i].bytes != ranked[j].bytes {
+			return ranked[i].bytes > ranked[j].bytes
+		}
+		// sort the ties by destination to select the same destinations between runs
+		return ranked[i].pathtest.Hostname < ranked[j].pathtest.Hostname ||
+			(ranked[i].pathtest.Hostname == ranked[j].pathtest.Hostname && ranked[i].key.port < ranked[j].key.port)
+	})
+
+	overCap := 0
+	if len(ranked) > s.config.MaxPerWindow {
+		overCap = len(ranked) - s.config.MaxPerWindow
+		ranked = ranked[:s.config.MaxPerWindow]
+	}
+
+	pathtests := make([]*common.Pathtest, 0, len(ranked))
+	selected := make([]SelectedPath, 0, len(ranked))
+	for i, c := range ranked {
+		sources := make([]string, 0, len(c.sources))
+		for source := range c.sources {
+			sources = append(sources, string(source))
+		}
+		sort.Strings(sources)
+
+		pathtest := c.pathtest
+		pathtest.Selection = &payload.NetworkPathSelection{
+			Reason:  string(s.config.Policy),
+			Rank:    i + 1,
+			Score:   c.score,
+			Hits:    c.hits,
+			Sources: sources,
+		}
+		pathtests = append(pathtests, &pathtest)
+		selected = append(selected, SelectedPath{
+			Hostname: pathtest.Hostname,
+			Port:     pathtest.Port,
+			Protocol: pathtest.Protocol,
+			Subnet:   c.key.subnet,
+			Rank:     i + 1,
+			Score:    c.score,
+			Hits:     c.hits,
+			Sources:  sources,
+		})
+	}
+
+	s.mutex.Lock()
+	s.lastSelection = now
+	s.lastCandidates = len(candidates)
+	s.lastOverCap = overCap
+	s.totalSelected += uint64(len(pathtests))
+	s.selected = selected
+	s.mutex.Unlock()
+
+	return pathtests
+}
+
+// GetStats returns the stats of the Selector
+func (s *Selector) GetStats() Stats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return Stats{
+		Policy:              s.config.Policy,
+		Window:              s.config.Window,
+		MaxPerWindow:        s.config.MaxPerWindow,
+		SubnetPrefixLength:  s.config.SubnetPrefixLength,
+		Observations:        s.observations,
+		Candidates:          len(s.candidates),
+		DroppedObservations: s.droppedObservations,
+		LastSelection:       s.lastSelection,
+		LastCandidates:      s.lastCandidates,
+		LastOverCap:         s.lastOverCap,
+		TotalSelected:       s.totalSelected,
+		Selected:            append([]SelectedPath(nil), s.selected...),
+	}
+}
+
+// score returns the score of some traffic according to the policy, destinations with a score of 0 are never selected
+func (s *Selector) score(bytes uint64, packets uint64, errors uint64) float64 {
+	if s.config.Policy == PolicyErrorRate {
+		if errors == 0 {
+			return 0
+		}
+		if packets == 0 {
+			packets = 1
+		}
+		return float64(errors) / float64(packets)
+	}
+	return float64(bytes)
+}
+
+// groupKey returns the key deduplicating the destinations, ok is false if the destination isn't an IPv4 address
+func (s *Selector) groupKey(pt common.Pathtest) (groupKey, bool) {
+	ip := net.ParseIP(pt.Hostname).To4()
+	if ip == nil {
+		return groupKey{}, false
+	}
+	subnet := net.IPNet{
+		IP:   ip.Mask(net.CIDRMask(s.config.SubnetPrefixLength, 32)),
+		Mask: net.CIDRMask(s.config.SubnetPrefixLength, 32),
+	}
+	return groupKey{protocol: pt.Protocol, subnet: subnet.String(), port: pt.Port}, true
+}
@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package selection
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/common"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
+)
+
+// MockTimeNow mocks time.Now
+var MockTimeNow = func() time.Time {
+	layout := "2006-01-02 15:04:05"
+	str := "2000-01-01 00:00:00"
+	t, _ := time.Parse(layout, str)
+	return t
+}
+
+func setMockTimeNow(newTime time.Time) {
+	timeNow = func() time.Time {
+		return newTime
+	}
+}
+
+func observation(hostname string, port uint16, source Source, bytes uint64, packets uint64, errors uint64) Observation {
+	return Observation{
+		Pathtest: common.Pathtest{Hostname: hostname, Port: port, Protocol: payload.ProtocolTCP},
+		Source:   source,
+		Bytes:    bytes,
+		Packets:  packets,
+		Errors:   errors,
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := Config{Policy: PolicyTrafficVolume, Window: time.Minute, MaxPerWindow: 10, SubnetPrefixLength: 24}
+	assert.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name          string
+		update        func(*Config)
+		expectedError string
+	}{
+		{"policy", func(c *Config) { c.Policy = "random" }, `invalid policy "random", must be "traffic_volume" or "error_rate"`},
+		{"window", func(c *Config) { c.Window = 0 }, "window must be > 0"},
+		{"max per window", func(c *Config) { c.MaxPerWindow = 0 }, "max tests per window must be > 0"},
+		{"prefix length", func(c *Config) { c.SubnetPrefixLength = 33 }, "subnet prefix length must be between 0 and 32, got 33"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.update(&config)
+			assert.EqualError(t, config.Validate(), tt.expectedError)
+		})
+	}
+}
+
+func TestSelector_TrafficVolume(t *testing.T) {
+	setMockTimeNow(MockTimeNow())
+	s := NewSelector(Config{Policy: PolicyTrafficVolume, Window: 5 * time.Minute, MaxPerWindow: 2, SubnetPrefixLength: 24})
+
+	// same subnet and port, deduplicated, 10.0.1.2 has the most traffic
+	s.Observe(observation("10.0.1.1", 443, SourceConnections, 100, 1, 0))
+	s.Observe(observation("10.0.1.2", 443, SourceNetFlow, 300, 1, 0))
+	// other port
+	s.Observe(observation("10.0.1.1", 80, SourceConnections, 350, 1, 0))
+	// other subnet
+	s.Observe(observation("10.0.2.1", 443, SourceConnections, 50, 1, 0))
+	// not an IPv4 address
+	s.Observe(observation("::1", 443, SourceConnections, 5000, 1, 0))
+
+	stats := s.GetStats()
+	assert.Equal(t, 4, stats.Observations)
+	assert.Equal(t, 3, stats.Candidates)
+
+	// the window isn't over
+	setMockTimeNow(MockTimeNow().Add(time.Minute))
+	assert.Nil(t, s.Select())
+
+	setMockTimeNow(MockTimeNow().Add(5 * time.Minute))
+	pathtests := s.Select()
+	require.Len(t, pathtests, 2)
+	assert.Equal(t, &common.Pathtest{
+		Hostname: "10.0.1.2",
+		Port:     443,
+		Protocol: payload.ProtocolTCP,
+		Selection: &payload.NetworkPathSelection{
+			Reason:  "traffic_volume",
+			Rank:    1,
+			Score:   400,
+			Hits:    2,
+			Sources: []string{"connections", "netflow"},
+		},
+	}, pathtests[0])
+	assert.Equal(t, "10.0.1.1", pathtests[1].Hostname)
+	assert.Equal(t, uint16(80), pathtests[1].Port)
+	assert.Equal(t, 2, pathtests[1].Selection.Rank)
+
+	stats = s.GetStats()
+	assert.Equal(t, 0, stats.Observations)
+	assert.Equal(t, 0, stats.Candidates)
+	assert.Equal(t, 3, stats.LastCandidates)
+	assert.Equal(t, 1, stats.LastOverCap)
+	assert.Equal(t, uint64(2), stats.TotalSelected)
+	assert.Equal(t, MockTimeNow().Add(5*time.Minute), stats.LastSelection)
+	assert.Equal(t, []SelectedPath{
+		{Hostname: "10.0.1.2", Port: 443, Protocol: payload.ProtocolTCP, Subnet: "10.0.1.0/24", Rank: 1, Score: 400, Hits: 2, Sources: []string{"connections", "netflow"}},
+		{Hostname: "10.0.1.1", Port: 80, Protocol: payload.ProtocolTCP, Subnet: "10.0.1.0/24", Rank: 2, Score: 350, Hits: 1, Sources: []string{"connections"}},
+	}, stats.Selected)
+
+	// nothing seen during the next window
+	setMockTimeNow(MockTimeNow().Add(10 * time.Minute))
+	assert.Empty(t, s.Select())
+	assert.Empty(t, s.GetStats().Selected)
+	assert.Equal(t, uint64(2), s.GetStats().TotalSelected)
+}
+
+func TestSelector_ErrorRate(t *testing.T) {
+	setMockTimeNow(MockTimeNow())
+	s := NewSelector(Config{Policy: PolicyErrorRate, Window: time.Minute, MaxPerWindow: 10, SubnetPrefixLength: 32})
+
+	s.Observe(observation("10.0.1.1", 443, SourceConnections, 1000, 100, 1))
+	s.Observe(observation("10.0.1.2", 443, SourceConnections, 10, 10, 5))
+	// no errors, never selected by the error rate
+	s.Observe(observation("10.0.1.3", 443, SourceNetFlow, 100000, 1000, 0))
+
+	setMockTimeNow(MockTimeNow().Add(time.Minute))
+	pathtests := s.Select()
+	require.Len(t, pathtests, 2)
+	assert.Equal(t, "10.0.1.2", pathtests[0].Hostname)
+	assert.Equal(t, 0.5, pathtests[0].Selection.Score)
+	assert.Equal(t, "error_rate", pathtests[0].Selection.Reason)
+	assert.Equal(t, "10.0.1.1", pathtests[1].Hostname)
+	assert.Equal(t, 0.01, pathtests[1].Selection.Score)
+	assert.Equal(t, 0, s.GetStats().LastOverCap)
+}
+
+func TestSelector_MaxCandidates(t *testing.T) {
+	setMockTimeNow(MockTimeNow())
+	s := NewSelector(Config{Policy: PolicyTrafficVolume, Window: time.Minute, MaxPerWindow: 10, SubnetPrefixLength: 32})
+	for i := 0; i < maxCandidates; i++ {
+		s.candidates[groupKey{subnet: strconv.Itoa(i)}] = &candidate{}
+	}
+
+	s.Observe(observation("10.0.1.1", 443, SourceConnections, 1000, 100, 1))
+	assert.Equal(t, uint64(1), s.GetStats().DroppedObservations)
+	assert.Equal(t, maxCandidates, s.GetStats().Candidates)
+}
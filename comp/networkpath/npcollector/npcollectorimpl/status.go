@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package npcollectorimpl
+
+import (
+	"embed"
+	"io"
+
+	"github.com/DataDog/datadog-agent/comp/core/status"
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/selection"
+)
+
+//go:embed status_templates
+var templatesFS embed.FS
+
+// npCollectorStatus represents the status of the collector, Selection is only set
+// when the destinations to test are selected
+type npCollectorStatus struct {
+	ConnectionsMonitoringEnabled bool
+	NetflowMonitoringEnabled     bool
+	ReceivedPathtests            uint64
+	ProcessedTraceroutes         uint64
+	PathtestContexts             int
+	Selection                    *selection.Stats
+}
+
+// Provider provides the functionality to populate the status output
+type Provider struct {
+	collector *npCollectorImpl
+}
+
+// Name returns the name
+func (Provider) Name() string {
+	return "Network Path Collector"
+}
+
+// Section return the section
+func (Provider) Section() string {
+	return "Network Path"
+}
+
+// JSON populates the status map
+func (p Provider) JSON(_ bool, stats map[string]interface{}) error {
+	p.getStatus(stats)
+
+	return nil
+}
+
+// Text renders the text output
+func (p Provider) Text(_ bool, buffer io.Writer) error {
+	return status.RenderText(templatesFS, "npcollector.tmpl", buffer, p.populateStatus())
+}
+
+// HTML renders the html output
+func (p Provider) HTML(_ bool, buffer io.Writer) error {
+	return status.RenderHTML(templatesFS, "npcollectorHTML.tmpl", buffer, p.populateStatus())
+}
+
+func (p Provider) getStatus(stats map[string]interface{}) {
+	collectorStatus := npCollectorStatus{
+		ConnectionsMonitoringEnabled: p.collector.collectorConfigs.connectionsMonitoringEnabled,
+		NetflowMonitoringEnabled:     p.collector.collectorConfigs.netflowMonitoringEnabled,
+		ReceivedPathtests:            p.collector.receivedPathtestCount.Load(),
+		ProcessedTraceroutes:         p.collector.processedTracerouteCount.Load(),
+		PathtestContexts:             p.collector.pathtestStore.GetContextsCount(),
+	}
+	if p.collector.selector != nil {
+		selectionStats := p.collector.selector.GetStats()
+		collectorStatus.Selection = &selectionStats
+	}
+
+	stats["networkPathCollectorStats"] = collectorStatus
+}
+
+func (p Provider) populateStatus() map[string]interface{} {
+	stats := make(map[string]interface{})
+
+	p.getStatus(stats)
+
+	return stats
+}
@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+//go:build test
+
+package npcollectorimpl
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/common"
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector/npcollectorimpl/selection"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
+)
+
+func TestStatusProvider(t *testing.T) {
+	agentConfigs := map[string]any{
+		"network_path.connections_monitoring.enabled":           true,
+		"network_path.netflow_monitoring.enabled":               true,
+		"network_path.collector.selection.enabled":              true,
+		"network_path.collector.selection.window":               "1ms",
+		"network_path.collector.selection.max_tests_per_window": 10,
+	}
+	_, npCollector := newTestNpCollector(t, agentConfigs)
+	npCollector.receivedPathtestCount.Add(3)
+	npCollector.processedTracerouteCount.Add(2)
+
+	npCollector.selector.Observe(selection.Observation{
+		Pathtest: common.Pathtest{Hostname: "10.0.1.1", Port: 443, Protocol: payload.ProtocolTCP},
+		Source:   selection.SourceConnections,
+		Bytes:    100,
+	})
+	npCollector.selector.Observe(selection.Observation{
+		Pathtest: common.Pathtest{Hostname: "10.0.1.2", Port: 443, Protocol: payload.ProtocolTCP},
+		Source:   selection.SourceNetFlow,
+		Bytes:    200,
+	})
+	time.Sleep(5 * time.Millisecond)
+	require.Len(t, npCollector.selector.Select(), 1)
+
+	statusProvider := Provider{
+		collector: npCollector,
+	}
+
+	tests := []struct {
+		name       string
+		assertFunc func(t *testing.T)
+	}{
+		{"JSON", func(t *testing.T) {
+			stats := make(map[string]interface{})
+			statusProvider.JSON(false, stats)
+
+			collectorStatus := stats["networkPathCollectorStats"].(npCollectorStatus)
+			assert.Equal(t, uint64(3), collectorStatus.ReceivedPathtests)
+			require.NotNil(t, collectorStatus.Selection)
+			assert.Equal(t, []selection.SelectedPath{
+				{Hostname: "10.0.1.2", Port: 443, Protocol: payload.ProtocolTCP, Subnet: "10.0.1.0/24", Rank: 1, Score: 300, Hits: 2, Sources: []string{"connections", "netflow"}},
+			}, collectorStatus.Selection.Selected)
+		}},
+		{"Text", func(t *testing.T) {
+			b := new(bytes.Buffer)
+			err := statusProvider.Text(false, b)
+
+			assert.NoError(t, err)
+
+			output := b.String()
+			assert.Contains(t, output, `
+  Connections Monitoring Enabled: true
+  NetFlow Monitoring Enabled: true
+  Pathtests Received: 3
+  Traceroutes Processed: 2
+  Pathtest Contexts: 0
+
+  === Destination Selection ===
+  Policy: traffic_volume
+  Window: 1ms
+  Max Tests Per Window: 10
+  Subnet Prefix Length: 24
+  Current Window Observations: 0
+  Current Window Candidates: 0
+  Dropped Observations: 0
+  Total Selected: 1
+`)
+			assert.Contains(t, output, `
+  Last Selection Candidates: 1
+  Last Selection Over Cap: 0
+
+  === Selected Destinations ===
+  ---------
+  Rank: 1
+  Destination: 10.0.1.2:443 (TCP)
+  Subnet: 10.0.1.0/24
+  Score: 300
+  Hits: 2
+  Sources: connections, netflow
+  ---------`)
+		}},
+		{"HTML", func(t *testing.T) {
+			b := new(bytes.Buffer)
+			err := statusProvider.HTML(false, b)
+
+			assert.NoError(t, err)
+
+			output := b.String()
+			assert.Contains(t, output, `<span class="stat_title">Network Path Collector</span>`)
+			assert.Contains(t, output, `<br>Destination: 10.0.1.2:443 (TCP)`)
+			assert.Contains(t, output, `<br>Hits: 2`)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.assertFunc(t)
+		})
+	}
+}
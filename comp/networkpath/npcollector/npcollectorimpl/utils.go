@@ -9,6 +9,7 @@ import (
 	"net"
 
 	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/DataDog/datadog-agent/comp/networkpath/npcollector"
 	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
 )
 
@@ -23,6 +24,26 @@ func shouldScheduleNetworkPathForConn(conn *model.Connection) bool {
 	return conn.Family == model.ConnectionFamily_v4
 }
 
+func shouldScheduleNetworkPathForFlow(flow npcollector.Flow) bool {
+	if flow.DestinationPort == 0 {
+		return false
+	}
+	if flow.Protocol != payload.ProtocolTCP && flow.Protocol != payload.ProtocolUDP {
+		return false
+	}
+	remoteIP := net.ParseIP(flow.DestinationIP)
+	return remoteIP.To4() != nil && !remoteIP.IsLoopback()
+}
+
+// connErrors returns the errors seen on a connection, used to rank destinations by error rate
+func connErrors(conn *model.Connection) uint64 {
+	errors := uint64(conn.LastRetransmits)
+	for _, count := range conn.TcpFailuresByErrCode {
+		errors += uint64(count)
+	}
+	return errors
+}
+
 func convertProtocol(connType model.ConnectionType) payload.Protocol {
 	if connType == model.ConnectionType_tcp {
 		return payload.ProtocolTCP
@@ -16,17 +16,29 @@ import (
 
 // ZlibStrategy is the strategy for when serializer_compressor_kind is zlib
 type ZlibStrategy struct {
+	level int
 }
 
-// NewZlibStrategy returns a new ZlibStrategy
+// NewZlibStrategy returns a new ZlibStrategy using the default compression level
 func NewZlibStrategy() *ZlibStrategy {
-	return &ZlibStrategy{}
+	return NewZlibStrategyWithLevel(zlib.DefaultCompression)
+}
+
+// NewZlibStrategyWithLevel returns a new ZlibStrategy using the given compression level.
+// Invalid levels fall back to the default compression level.
+func NewZlibStrategyWithLevel(level int) *ZlibStrategy {
+	if level < zlib.HuffmanOnly || level > zlib.BestCompression {
+		level = zlib.DefaultCompression
+	}
+	return &ZlibStrategy{
+		level: level,
+	}
 }
 
 // Compress will compress the data with zlib
 func (s *ZlibStrategy) Compress(src []byte) ([]byte, error) {
 	var b bytes.Buffer
-	w := zlib.NewWriter(&b)
+	w := s.newWriter(&b)
 	_, err := w.Write(src)
 	if err != nil {
 		return nil, err
@@ -69,5 +81,11 @@ func (s *ZlibStrategy) ContentEncoding() string {
 
 // NewStreamCompressor returns a new zlib writer
 func (s *ZlibStrategy) NewStreamCompressor(output *bytes.Buffer) compression.StreamCompressor {
-	return zlib.NewWriter(output)
+	return s.newWriter(output)
+}
+
+func (s *ZlibStrategy) newWriter(output io.Writer) *zlib.Writer {
+	// the level is validated by the constructor, NewWriterLevel can't fail
+	w, _ := zlib.NewWriterLevel(output, s.level)
+	return w
 }
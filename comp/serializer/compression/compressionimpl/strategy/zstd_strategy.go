@@ -13,6 +13,15 @@ import (
 	"github.com/DataDog/zstd"
 )
 
+const (
+	// ZstdMinLevel is the lowest supported zstd compression level
+	ZstdMinLevel = zstd.BestSpeed
+	// ZstdMaxLevel is the highest supported zstd compression level
+	ZstdMaxLevel = 22
+	// ZstdDefaultLevel is the default zstd compression level
+	ZstdDefaultLevel = zstd.DefaultCompression
+)
+
 // ZstdStrategy is the strategy for when serializer_compressor_kind is zstd
 type ZstdStrategy struct {
 	level int
@@ -30,9 +30,14 @@ func Module() fxutil.Module {
 func NewCompressor(cfg config.Component) compression.Component {
 	switch cfg.GetString("serializer_compressor_kind") {
 	case ZlibKind:
-		return strategy.NewZlibStrategy()
+		return strategy.NewZlibStrategyWithLevel(zlibLevel(cfg))
 	case ZstdKind:
 		level := cfg.GetInt("serializer_zstd_compressor_level")
+		if level < strategy.ZstdMinLevel || level > strategy.ZstdMaxLevel {
+			log.Warnf("invalid serializer_zstd_compressor_level %d, it must be between %d and %d. using %d",
+				level, strategy.ZstdMinLevel, strategy.ZstdMaxLevel, strategy.ZstdDefaultLevel)
+			level = strategy.ZstdDefaultLevel
+		}
 		return strategy.NewZstdStrategy(level)
 	case NoneKind:
 		log.Warn("no serializer_compressor_kind set. use zlib or zstd")
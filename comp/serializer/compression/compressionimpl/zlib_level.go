@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build zlib
+
+package compressionimpl
+
+import (
+	"compress/zlib"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// zlibLevel returns the configured zlib compression level, or the default one when it is invalid
+func zlibLevel(cfg config.Component) int {
+	level := cfg.GetInt("serializer_zlib_compressor_level")
+	if level < zlib.HuffmanOnly || level > zlib.BestCompression {
+		log.Warnf("invalid serializer_zlib_compressor_level %d, it must be between %d and %d. using the default level",
+			level, zlib.HuffmanOnly, zlib.BestCompression)
+		return zlib.DefaultCompression
+	}
+	return level
+}
@@ -30,10 +30,10 @@ func Module() fxutil.Module {
 func NewCompressor(cfg config.Component) compression.Component {
 	switch cfg.GetString("serializer_compressor_kind") {
 	case ZlibKind:
-		return strategy.NewZlibStrategy()
+		return strategy.NewZlibStrategyWithLevel(zlibLevel(cfg))
 	case ZstdKind:
 		log.Warn("zstd build tag not included. using zlib")
-		return strategy.NewZlibStrategy()
+		return strategy.NewZlibStrategyWithLevel(zlibLevel(cfg))
 	case NoneKind:
 		log.Warn("no serializer_compressor_kind set. use zlib or zstd")
 		return strategy.NewNoopStrategy()
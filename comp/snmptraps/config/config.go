@@ -6,8 +6,10 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
 	"hash/fnv"
+	"strings"
 
 	"github.com/gosnmp/gosnmp"
 
@@ -33,19 +35,26 @@ type UserV3 struct {
 	AuthProtocol   string `mapstructure:"authProtocol" yaml:"authProtocol"`
 	PrivKey        string `mapstructure:"privKey" yaml:"privKey"`
 	PrivProtocol   string `mapstructure:"privProtocol" yaml:"privProtocol"`
+	// EngineIDs restricts the traps of the user to the devices with these engine IDs, as hex strings
+	EngineIDs []string `mapstructure:"engine_ids" yaml:"engine_ids"`
 }
 
 // TrapsConfig contains configuration for SNMP trap listeners.
 // YAML field tags provided for test marshalling purposes.
 type TrapsConfig struct {
-	Enabled               bool     `mapstructure:"enabled" yaml:"enabled"`
-	Port                  uint16   `mapstructure:"port" yaml:"port"`
-	Users                 []UserV3 `mapstructure:"users" yaml:"users"`
-	CommunityStrings      []string `mapstructure:"community_strings" yaml:"community_strings"`
-	BindHost              string   `mapstructure:"bind_host" yaml:"bind_host"`
-	StopTimeout           int      `mapstructure:"stop_timeout" yaml:"stop_timeout"`
-	Namespace             string   `mapstructure:"namespace" yaml:"namespace"`
-	authoritativeEngineID string   `mapstructure:"-" yaml:"-"`
+	Enabled               bool            `mapstructure:"enabled" yaml:"enabled"`
+	Port                  uint16          `mapstructure:"port" yaml:"port"`
+	Users                 []UserV3        `mapstructure:"users" yaml:"users"`
+	CommunityStrings      []string        `mapstructure:"community_strings" yaml:"community_strings"`
+	BindHost              string          `mapstructure:"bind_host" yaml:"bind_host"`
+	StopTimeout           int             `mapstructure:"stop_timeout" yaml:"stop_timeout"`
+	Namespace             string          `mapstructure:"namespace" yaml:"namespace"`
+	Rules                 []TrapRule      `mapstructure:"rules" yaml:"rules"`
+	EngineID              string          `mapstructure:"engine_id" yaml:"engine_id"`
+	Relay                 []RelayReceiver `mapstructure:"relay" yaml:"relay"`
+	authoritativeEngineID string          `mapstructure:"-" yaml:"-"`
+	// userEngineIDs are the engine IDs allowed for the users restricted to some devices
+	userEngineIDs map[string]map[string]bool `mapstructure:"-" yaml:"-"`
 }
 
 // ReadConfig builds the traps configuration from the Agent configuration.
@@ -80,13 +89,31 @@ func (c *TrapsConfig) SetDefaults(host string, namespace string) error {
 		// Unlikely to happen since the agent cannot start without a hostname
 		host = "unknown-datadog-agent"
 	}
-	h := fnv.New128()
-	h.Write([]byte(host))
-	// First byte is always 0x80
-	// Next four bytes are the Private Enterprise Number (set to an invalid value here)
-	// The next 16 bytes are the hash of the agent hostname
-	engineID := h.Sum([]byte{0x80, 0xff, 0xff, 0xff, 0xff})
-	c.authoritativeEngineID = string(engineID)
+	if c.EngineID != "" {
+		// Reusing the engine ID of another receiver lets the devices sending it informs send them to the Agent
+		engineID, err := ParseEngineID(c.EngineID)
+		if err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		c.authoritativeEngineID = engineID
+	} else {
+		h := fnv.New128()
+		h.Write([]byte(host))
+		// First byte is always 0x80
+		// Next four bytes are the Private Enterprise Number (set to an invalid value here)
+		// The next 16 bytes are the hash of the agent hostname
+		engineID := h.Sum([]byte{0x80, 0xff, 0xff, 0xff, 0xff})
+		c.authoritativeEngineID = string(engineID)
+	}
+
+	if err := c.setUserEngineIDs(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	for i := range c.Relay {
+		if err := c.Relay[i].setDefaults(); err != nil {
+			return fmt.Errorf("invalid config: relay receiver %d: %w", i, err)
+		}
+	}
 
 	if c.Namespace == "" {
 		c.Namespace = namespace
@@ -100,6 +127,63 @@ func (c *TrapsConfig) SetDefaults(host string, namespace string) error {
 	return nil
 }
 
+// setUserEngineIDs indexes the engine IDs of the users. A user is only restricted to some devices if none of its
+// entries accepts all the devices.
+func (c *TrapsConfig) setUserEngineIDs() error {
+	c.userEngineIDs = make(map[string]map[string]bool)
+	unrestricted := make(map[string]bool)
+	for _, user := range c.Users {
+		username := user.Username
+		if username == "" {
+			username = user.UsernameLegacy
+		}
+		if len(user.EngineIDs) == 0 {
+			unrestricted[username] = true
+			continue
+		}
+		if c.userEngineIDs[username] == nil {
+			c.userEngineIDs[username] = make(map[string]bool)
+		}
+		for _, id := range user.EngineIDs {
+			engineID, err := ParseEngineID(id)
+			if err != nil {
+				return fmt.Errorf("user %s: %w", username, err)
+			}
+			c.userEngineIDs[username][engineID] = true
+		}
+	}
+	for username := range unrestricted {
+		delete(c.userEngineIDs, username)
+	}
+	return nil
+}
+
+// ParseEngineID parses an SNMP engine ID written as a hex string, with an optional 0x prefix.
+func ParseEngineID(s string) (string, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "0x"), "0X")
+	engineID, err := hex.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("engine ID %q is not a hex string: %w", s, err)
+	}
+	// RFC3411 section 5: an engine ID is 5 to 32 bytes long
+	if len(engineID) < 5 || len(engineID) > 32 {
+		return "", fmt.Errorf("engine ID %q must be 5 to 32 bytes long", s)
+	}
+	return string(engineID), nil
+}
+
+// AuthoritativeEngineID returns the engine ID of the Agent. The informs are sent to this engine ID.
+func (c *TrapsConfig) AuthoritativeEngineID() string {
+	return c.authoritativeEngineID
+}
+
+// IsAllowedEngineID returns whether a v3 user may send packets with this engine ID. The users restricted to
+// some devices can still send informs, which use the engine ID of the Agent.
+func (c *TrapsConfig) IsAllowedEngineID(username string, engineID string) bool {
+	engineIDs, restricted := c.userEngineIDs[username]
+	return !restricted || engineIDs[engineID] || engineID == c.authoritativeEngineID
+}
+
 // Addr returns the host:port address to listen on.
 func (c *TrapsConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", c.BindHost, c.Port)
@@ -122,6 +206,12 @@ func (c *TrapsConfig) BuildSNMPParams(logger log.Component) (*gosnmp.GoSNMP, err
 
 	// Set up user security params table from config
 	usmTable := gosnmp.NewSnmpV3SecurityParametersTable(snmpLogger)
+	// The devices sending informs discover the engine ID of the Agent with a request without user,
+	// it's answered with a report by gosnmp as long as the request can be unmarshalled.
+	err := usmTable.Add("", &gosnmp.UsmSecurityParameters{AuthenticationProtocol: gosnmp.NoAuth, PrivacyProtocol: gosnmp.NoPriv})
+	if err != nil {
+		return nil, err
+	}
 	for _, user := range c.Users {
 		// Backward compatibility
 		if user.Username == "" {
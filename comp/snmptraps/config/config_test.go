@@ -9,6 +9,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	"github.com/DataDog/datadog-agent/comp/core/hostname"
@@ -21,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/fx"
+	"gopkg.in/yaml.v3"
 )
 
 const mockedHostname = "VeryLongHostnameThatDoesNotFitIntoTheByteArray"
@@ -290,3 +292,142 @@ func TestNamespaceSetBothGloballyAndLocally(t *testing.T) {
 
 	assert.Equal(t, "bar", config.Namespace)
 }
+
+func TestTrapRules(t *testing.T) {
+	var rawTrapConfig map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+enabled: true
+rules:
+  - name: link_down
+    trap: IF-MIB::linkDown
+    match:
+      - variable: ifAdminStatus
+        values: [up, 3]
+    tags:
+      interface: ifName
+    metrics:
+      - name: snmp_traps.interface.link_down
+    event:
+      title: Interface {ifName} down
+      severity: critical
+    alarm:
+      name: interface_link
+      key: [ifIndex]
+`), &rawTrapConfig))
+	ddConfig := fxutil.Test[config.Component](t,
+		config.MockModule(),
+		fx.Replace(config.MockParams{Overrides: map[string]interface{}{"network_devices.snmp_traps": rawTrapConfig}}),
+	)
+	trapsConfig, err := ReadConfig("", ddConfig)
+	require.NoError(t, err)
+	assert.Equal(t, []TrapRule{{
+		Name:    "link_down",
+		Trap:    "IF-MIB::linkDown",
+		Match:   []RuleMatch{{Variable: "ifAdminStatus", Values: []string{"up", "3"}}},
+		Tags:    map[string]string{"interface": "ifName"},
+		Metrics: []RuleMetric{{Name: "snmp_traps.interface.link_down"}},
+		Event:   &RuleEvent{Title: "Interface {ifName} down", Severity: "critical"},
+		Alarm:   &RuleAlarm{Name: "interface_link", Key: []string{"ifIndex"}},
+	}}, trapsConfig.Rules)
+}
+
+func TestEngineIDs(t *testing.T) {
+	config := fxutil.Test[*TrapsConfig](t,
+		testOptions(t),
+		withConfig(t, &TrapsConfig{
+			EngineID: "0x80001f8880e9630000d61ff449",
+			Users: []UserV3{
+				{Username: "device", AuthKey: "password", AuthProtocol: "sha", EngineIDs: []string{"8000000001020304", "0x8000000005060708"}},
+				{Username: "shared", AuthKey: "password", AuthProtocol: "sha", EngineIDs: []string{"8000000001020304"}},
+				{Username: "shared", AuthKey: "password2", AuthProtocol: "sha"},
+			},
+		}, ""),
+	)
+	agentEngineID := "\x80\x00\x1f\x88\x80\xe9\x63\x00\x00\xd6\x1f\xf4\x49"
+	assert.Equal(t, agentEngineID, config.AuthoritativeEngineID())
+
+	assert.True(t, config.IsAllowedEngineID("device", "\x80\x00\x00\x00\x01\x02\x03\x04"))
+	assert.True(t, config.IsAllowedEngineID("device", "\x80\x00\x00\x00\x05\x06\x07\x08"))
+	assert.True(t, config.IsAllowedEngineID("device", agentEngineID))
+	assert.False(t, config.IsAllowedEngineID("device", "\x80\x00\x00\x00\x09\x09\x09\x09"))
+	// one of the entries of the user accepts all the devices
+	assert.True(t, config.IsAllowedEngineID("shared", "\x80\x00\x00\x00\x09\x09\x09\x09"))
+}
+
+func TestInvalidEngineID(t *testing.T) {
+	for _, trapsConfig := range []*TrapsConfig{
+		{EngineID: "not hex"},
+		{EngineID: "0x8000"},
+		{Users: []UserV3{{Username: "user", EngineIDs: []string{"0x80"}}}},
+	} {
+		ddConfig := fxutil.Test[config.Component](t, withConfig(t, trapsConfig, ""))
+		_, err := ReadConfig("", ddConfig)
+		assert.ErrorContains(t, err, "engine ID")
+	}
+}
+
+func TestRelay(t *testing.T) {
+	var rawTrapConfig map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+enabled: true
+relay:
+  - host: nms.example.com
+    community_string: public
+  - host: 10.0.0.1
+    port: 1162
+    version: 3
+    user: relay
+    authKey: password
+    authProtocol: sha
+    privKey: password
+    privProtocol: aes
+    inform: true
+`), &rawTrapConfig))
+	ddConfig := fxutil.Test[config.Component](t,
+		config.MockModule(),
+		fx.Replace(config.MockParams{Overrides: map[string]interface{}{"network_devices.snmp_traps": rawTrapConfig}}),
+	)
+	trapsConfig, err := ReadConfig("", ddConfig)
+	require.NoError(t, err)
+	require.Len(t, trapsConfig.Relay, 2)
+
+	v2 := trapsConfig.Relay[0]
+	assert.Equal(t, "nms.example.com:162", v2.Addr())
+	assert.Equal(t, "2c", v2.Version)
+	params, err := v2.BuildSNMPParams(trapsConfig.AuthoritativeEngineID(), gosnmp.Default.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, gosnmp.Version2c, params.Version)
+	assert.Equal(t, "public", params.Community)
+	assert.Equal(t, 5*time.Second, params.Timeout)
+	assert.Equal(t, 2, params.Retries)
+
+	v3 := trapsConfig.Relay[1]
+	assert.Equal(t, "10.0.0.1:1162", v3.Addr())
+	params, err = v3.BuildSNMPParams(trapsConfig.AuthoritativeEngineID(), gosnmp.Default.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, gosnmp.Version3, params.Version)
+	assert.Equal(t, gosnmp.AuthPriv, params.MsgFlags)
+	usm := params.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	assert.Equal(t, "relay", usm.UserName)
+	assert.Equal(t, gosnmp.SHA, usm.AuthenticationProtocol)
+	assert.Equal(t, gosnmp.AES, usm.PrivacyProtocol)
+	// the engine ID of the receiver is discovered when sending informs
+	assert.Empty(t, usm.AuthoritativeEngineID)
+}
+
+func TestInvalidRelay(t *testing.T) {
+	for _, test := range []struct {
+		receiver RelayReceiver
+		err      string
+	}{
+		{RelayReceiver{CommunityString: "public"}, "host is required"},
+		{RelayReceiver{Host: "nms"}, "community_string is required"},
+		{RelayReceiver{Host: "nms", Version: "1", CommunityString: "public", Inform: true}, "informs can't be sent"},
+		{RelayReceiver{Host: "nms", Version: "3"}, "user is required"},
+		{RelayReceiver{Host: "nms", Version: "2"}, "invalid version"},
+	} {
+		trapsConfig := &TrapsConfig{Relay: []RelayReceiver{test.receiver}}
+		err := trapsConfig.SetDefaults("host", "default")
+		assert.ErrorContains(t, err, test.err)
+	}
+}
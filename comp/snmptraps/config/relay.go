@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/DataDog/datadog-agent/pkg/snmp/gosnmplib"
+)
+
+const (
+	defaultRelayPort    = uint16(162)
+	defaultRelayTimeout = 5
+	defaultRelayRetries = 2
+)
+
+// RelayReceiver is a downstream receiver, like another NMS, to which the received traps are sent again.
+type RelayReceiver struct {
+	Host string `mapstructure:"host" yaml:"host"`
+	Port uint16 `mapstructure:"port" yaml:"port"`
+	// Version is the SNMP version of the traps sent to the receiver: 1, 2c or 3
+	Version         string `mapstructure:"version" yaml:"version"`
+	CommunityString string `mapstructure:"community_string" yaml:"community_string"`
+	User            string `mapstructure:"user" yaml:"user"`
+	AuthKey         string `mapstructure:"authKey" yaml:"authKey"`
+	AuthProtocol    string `mapstructure:"authProtocol" yaml:"authProtocol"`
+	PrivKey         string `mapstructure:"privKey" yaml:"privKey"`
+	PrivProtocol    string `mapstructure:"privProtocol" yaml:"privProtocol"`
+	// Inform sends informs, acknowledged by the receiver, instead of traps
+	Inform bool `mapstructure:"inform" yaml:"inform"`
+	// Timeout is the number of seconds to wait for the acknowledgement of an inform
+	Timeout int `mapstructure:"timeout" yaml:"timeout"`
+	Retries int `mapstructure:"retries" yaml:"retries"`
+}
+
+func (r *RelayReceiver) setDefaults() error {
+	if r.Host == "" {
+		return errors.New("host is required")
+	}
+	if r.Port == 0 {
+		r.Port = defaultRelayPort
+	}
+	if r.Version == "" {
+		r.Version = "2c"
+	}
+	switch r.Version {
+	case "1", "2c":
+		if r.CommunityString == "" {
+			return errors.New("community_string is required")
+		}
+		if r.Inform && r.Version == "1" {
+			return errors.New("informs can't be sent with SNMP v1")
+		}
+	case "3":
+		if r.User == "" {
+			return errors.New("user is required")
+		}
+	default:
+		return fmt.Errorf("invalid version %q, expected 1, 2c or 3", r.Version)
+	}
+	if r.Timeout == 0 {
+		r.Timeout = defaultRelayTimeout
+	}
+	if r.Retries == 0 {
+		r.Retries = defaultRelayRetries
+	}
+	return nil
+}
+
+// Addr returns the host:port address of the receiver.
+func (r *RelayReceiver) Addr() string {
+	return net.JoinHostPort(r.Host, strconv.Itoa(int(r.Port)))
+}
+
+// BuildSNMPParams returns the GoSNMP params sending traps to the receiver. The v3 traps are sent with the engine ID
+// of the Agent, the informs with the engine ID discovered from the receiver.
+func (r *RelayReceiver) BuildSNMPParams(engineID string, logger gosnmp.Logger) (*gosnmp.GoSNMP, error) {
+	params := &gosnmp.GoSNMP{
+		Target:    r.Host,
+		Port:      r.Port,
+		Transport: "udp",
+		Community: r.CommunityString,
+		Timeout:   time.Duration(r.Timeout) * time.Second,
+		Retries:   r.Retries,
+		Logger:    logger,
+	}
+	switch r.Version {
+	case "1":
+		params.Version = gosnmp.Version1
+		return params, nil
+	case "2c":
+		params.Version = gosnmp.Version2c
+		return params, nil
+	}
+
+	authProtocol, err := gosnmplib.GetAuthProtocol(r.AuthProtocol)
+	if err != nil {
+		return nil, err
+	}
+	privProtocol, err := gosnmplib.GetPrivProtocol(r.PrivProtocol)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case r.AuthKey == "":
+		authProtocol = gosnmp.NoAuth
+	case authProtocol == gosnmp.NoAuth:
+		authProtocol = gosnmp.MD5
+	}
+	switch {
+	case r.PrivKey == "" || authProtocol == gosnmp.NoAuth:
+		privProtocol = gosnmp.NoPriv
+	case privProtocol == gosnmp.NoPriv:
+		privProtocol = gosnmp.DES
+	}
+	msgFlags := gosnmp.NoAuthNoPriv
+	if authProtocol != gosnmp.NoAuth {
+		msgFlags = gosnmp.AuthNoPriv
+		if privProtocol != gosnmp.NoPriv {
+			msgFlags = gosnmp.AuthPriv
+		}
+	}
+	securityParameters := &gosnmp.UsmSecurityParameters{
+		UserName:                 r.User,
+		AuthenticationProtocol:   authProtocol,
+		AuthenticationPassphrase: r.AuthKey,
+		PrivacyProtocol:          privProtocol,
+		PrivacyPassphrase:        r.PrivKey,
+	}
+	if !r.Inform {
+		securityParameters.AuthoritativeEngineID = engineID
+	}
+	params.Version = gosnmp.Version3
+	params.SecurityModel = gosnmp.UserSecurityModel
+	params.MsgFlags = msgFlags
+	params.SecurityParameters = securityParameters
+	return params, nil
+}
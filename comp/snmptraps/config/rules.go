@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package config
+
+// TrapRule turns the traps it matches into events and metrics.
+// Variables are referenced by their name in the trap database, or by their OID.
+type TrapRule struct {
+	Name string `mapstructure:"name" yaml:"name"`
+	// Trap is the OID, the name or the MIB::name of the trap
+	Trap  string      `mapstructure:"trap" yaml:"trap"`
+	Match []RuleMatch `mapstructure:"match" yaml:"match,omitempty"`
+	// Tags maps tag names to the variables whose value they take
+	Tags    map[string]string `mapstructure:"tags" yaml:"tags,omitempty"`
+	Metrics []RuleMetric      `mapstructure:"metrics" yaml:"metrics,omitempty"`
+	Event   *RuleEvent        `mapstructure:"event" yaml:"event,omitempty"`
+	Alarm   *RuleAlarm        `mapstructure:"alarm" yaml:"alarm,omitempty"`
+}
+
+// RuleMatch restricts a rule to the traps whose variable has one of the values
+type RuleMatch struct {
+	Variable string   `mapstructure:"variable" yaml:"variable"`
+	Values   []string `mapstructure:"values" yaml:"values"`
+}
+
+// RuleMetric is a metric submitted for each trap matched by a rule
+type RuleMetric struct {
+	Name string `mapstructure:"name" yaml:"name"`
+	// Type is count (the default) or gauge
+	Type string `mapstructure:"type" yaml:"type,omitempty"`
+	// Value is the variable holding the value of the metric, counts default to 1
+	Value string `mapstructure:"value" yaml:"value,omitempty"`
+}
+
+// RuleEvent is the event sent for the traps matched by a rule.
+// Title and Text can reference variables with {variable}.
+type RuleEvent struct {
+	Title string `mapstructure:"title" yaml:"title"`
+	Text  string `mapstructure:"text" yaml:"text,omitempty"`
+	// Severity is critical, error, warning, info or ok
+	Severity string `mapstructure:"severity" yaml:"severity,omitempty"`
+}
+
+// RuleAlarm correlates the traps raising an alarm with the traps clearing it.
+// The rules sharing an alarm name and the values of the key variables are the same alarm.
+type RuleAlarm struct {
+	Name  string   `mapstructure:"name" yaml:"name"`
+	Clear bool     `mapstructure:"clear" yaml:"clear,omitempty"`
+	Key   []string `mapstructure:"key" yaml:"key,omitempty"`
+}
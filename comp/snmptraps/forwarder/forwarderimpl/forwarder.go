@@ -19,7 +19,10 @@ import (
 	"github.com/DataDog/datadog-agent/comp/snmptraps/formatter"
 	"github.com/DataDog/datadog-agent/comp/snmptraps/forwarder"
 	"github.com/DataDog/datadog-agent/comp/snmptraps/listener"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/oidresolver"
 	"github.com/DataDog/datadog-agent/comp/snmptraps/packet"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/relay"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/rules"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
@@ -38,6 +41,8 @@ func Module() fxutil.Module {
 type trapForwarder struct {
 	trapsIn   packet.PacketsChannel
 	formatter formatter.Component
+	rules     *rules.Engine
+	relay     *relay.Relay
 	sender    sender.Sender
 	stopChan  chan struct{}
 	logger    log.Component
@@ -45,11 +50,12 @@ type trapForwarder struct {
 
 type dependencies struct {
 	fx.In
-	Config    config.Component
-	Formatter formatter.Component
-	Demux     demultiplexer.Component
-	Listener  listener.Component
-	Logger    log.Component
+	Config      config.Component
+	Formatter   formatter.Component
+	OIDResolver oidresolver.Component
+	Demux       demultiplexer.Component
+	Listener    listener.Component
+	Logger      log.Component
 }
 
 // newTrapForwarder creates a simple TrapForwarder instance
@@ -58,14 +64,25 @@ func newTrapForwarder(lc fx.Lifecycle, dep dependencies) (forwarder.Component, e
 	if err != nil {
 		return nil, err
 	}
+	conf := dep.Config.Get()
+	rulesEngine, err := rules.NewEngine(conf.Rules, dep.OIDResolver, sender, dep.Logger)
+	if err != nil {
+		// the valid rules are still applied
+		dep.Logger.Errorf("failed to load the trap rules: %s", err)
+	}
+	trapRelay, err := relay.New(conf, sender, dep.Logger)
+	if err != nil {
+		return nil, err
+	}
 	tf := &trapForwarder{
 		trapsIn:   dep.Listener.Packets(),
 		formatter: dep.Formatter,
+		rules:     rulesEngine,
+		relay:     trapRelay,
 		sender:    sender,
 		stopChan:  make(chan struct{}, 1),
 		logger:    dep.Logger,
 	}
-	conf := dep.Config.Get()
 	if conf.Enabled {
 		lc.Append(fx.Hook{
 			OnStart: func(_ context.Context) error {
@@ -85,6 +102,7 @@ func newTrapForwarder(lc fx.Lifecycle, dep dependencies) (forwarder.Component, e
 // Start the TrapForwarder instance. Need to Stop it manually.
 func (tf *trapForwarder) Start() {
 	tf.logger.Info("Starting TrapForwarder")
+	tf.relay.Start()
 	go tf.run()
 }
 
@@ -103,17 +121,21 @@ func (tf *trapForwarder) run() {
 	for {
 		select {
 		case <-tf.stopChan:
+			tf.relay.Stop()
 			tf.logger.Info("Stopped TrapForwarder")
 			return
 		case packet := <-tf.trapsIn:
 			tf.sendTrap(packet)
 		case <-flushTicker.C:
+			tf.rules.ReportTelemetry()
 			tf.sender.Commit() // Commit metrics
 		}
 	}
 }
 
 func (tf *trapForwarder) sendTrap(packet *packet.SnmpPacket) {
+	// the downstream receivers get the traps even if they can't be formatted
+	tf.relay.Relay(packet)
 	data, err := tf.formatter.FormatPacket(packet)
 	if err != nil {
 		tf.logger.Errorf("failed to format packet: %s", err)
@@ -122,4 +144,5 @@ func (tf *trapForwarder) sendTrap(packet *packet.SnmpPacket) {
 	tf.logger.Tracef("send trap payload: %s", string(data))
 	tf.sender.Count("datadog.snmp_traps.forwarded", 1, "", packet.GetTags())
 	tf.sender.EventPlatformEvent(data, eventplatform.EventTypeSnmpTraps)
+	tf.rules.Process(packet)
 }
@@ -15,12 +15,14 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/DataDog/datadog-agent/comp/forwarder/eventplatform"
+	trapsconf "github.com/DataDog/datadog-agent/comp/snmptraps/config"
 	"github.com/DataDog/datadog-agent/comp/snmptraps/config/configimpl"
 	"github.com/DataDog/datadog-agent/comp/snmptraps/formatter"
 	"github.com/DataDog/datadog-agent/comp/snmptraps/formatter/formatterimpl"
 	"github.com/DataDog/datadog-agent/comp/snmptraps/forwarder"
 	"github.com/DataDog/datadog-agent/comp/snmptraps/listener"
 	"github.com/DataDog/datadog-agent/comp/snmptraps/listener/listenerimpl"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/oidresolver/oidresolverimpl"
 	"github.com/DataDog/datadog-agent/comp/snmptraps/packet"
 	"github.com/DataDog/datadog-agent/comp/snmptraps/senderhelper"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
@@ -37,13 +39,15 @@ type services struct {
 	Forwarder forwarder.Component
 }
 
-func setUp(t *testing.T) *services {
+func setUp(t *testing.T, opts ...fx.Option) *services {
 	t.Helper()
 	s := fxutil.Test[services](t,
+		fx.Options(opts...),
 		configimpl.MockModule(),
 		senderhelper.Opts,
 		formatterimpl.MockModule(),
 		listenerimpl.MockModule(),
+		oidresolverimpl.MockModule(),
 		Module(),
 	)
 	return &s
@@ -98,3 +102,54 @@ func TestForwarderTelemetry(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 	s.Sender.AssertMetric(t, "Count", "datadog.snmp_traps.forwarded", 1, "", []string{"snmp_device:1.1.1.1", "device_namespace:totoro", "snmp_version:2"})
 }
+
+func TestForwarderTrapRules(t *testing.T) {
+	s := setUp(t, fx.Replace(&trapsconf.TrapsConfig{
+		Enabled: true,
+		Rules: []trapsconf.TrapRule{{
+			Name:    "link_down",
+			Trap:    "IF-MIB::ifDown",
+			Tags:    map[string]string{"interface_index": "ifIndex"},
+			Metrics: []trapsconf.RuleMetric{{Name: "snmp_traps.interface.link_down"}},
+		}},
+	}))
+	packet := makeSnmpPacket(packet.LinkDownv1GenericTrap)
+	packet.Content.Version = gosnmp.Version1
+	rawEvent, err := s.Formatter.FormatPacket(packet)
+	require.NoError(t, err)
+	s.Listener.Send(packet)
+	time.Sleep(100 * time.Millisecond)
+	// the trap is still forwarded
+	s.Sender.AssertEventPlatformEvent(t, rawEvent, eventplatform.EventTypeSnmpTraps)
+	s.Sender.AssertMetric(t, "Count", "snmp_traps.interface.link_down", 1, "", []string{"snmp_device:1.1.1.1", "device_namespace:totoro", "snmp_version:1", "interface_index:2"})
+}
+
+func TestForwarderRelay(t *testing.T) {
+	receiver, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer receiver.Close()
+	s := setUp(t, fx.Replace(&trapsconf.TrapsConfig{
+		Enabled: true,
+		Relay: []trapsconf.RelayReceiver{{
+			Host:            "127.0.0.1",
+			Port:            uint16(receiver.LocalAddr().(*net.UDPAddr).Port),
+			CommunityString: "private",
+		}},
+	}))
+	packet := makeSnmpPacket(packet.NetSNMPExampleHeartbeatNotification)
+	rawEvent, err := s.Formatter.FormatPacket(packet)
+	require.NoError(t, err)
+	s.Listener.Send(packet)
+
+	require.NoError(t, receiver.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, 4096)
+	n, _, err := receiver.ReadFrom(buf)
+	require.NoError(t, err)
+	relayed, err := gosnmp.Default.SnmpDecodePacket(buf[:n])
+	require.NoError(t, err)
+	require.Equal(t, "private", relayed.Community)
+	require.Equal(t, gosnmp.SNMPv2Trap, relayed.PDUType)
+	// the trap is still forwarded
+	time.Sleep(100 * time.Millisecond)
+	s.Sender.AssertEventPlatformEvent(t, rawEvent, eventplatform.EventTypeSnmpTraps)
+}
@@ -143,12 +143,23 @@ func (t *trapListener) stop() error {
 }
 
 func (t *trapListener) receiveTrap(p *gosnmp.SnmpPacket, u *net.UDPAddr) {
+	if p.PDUType == gosnmp.InformRequest {
+		// gosnmp turns informs into their response once they're handled, the forwarded packet must be a copy
+		inform := *p
+		p = &inform
+	}
 	packet := &packet.SnmpPacket{Content: p, Addr: u, Timestamp: time.Now().UnixMilli(), Namespace: t.config.Namespace}
 	tags := packet.GetTags()
 
 	t.sender.Count("datadog.snmp_traps.received", 1, "", tags)
 
-	if err := validatePacket(p, t.config); err != nil {
+	if p.Version == gosnmp.Version3 {
+		if err := validateV3Packet(p, t.config); err != nil {
+			t.logger.Debugf("Invalid v3 packet from %s on listener %s, dropping traps: %s", u.String(), t.config.Addr(), err)
+			t.sender.Count("datadog.snmp_traps.invalid_packet", 1, "", append(tags, "reason:"+err.Error()))
+			return
+		}
+	} else if err := validatePacket(p, t.config); err != nil {
 		t.logger.Debugf("Invalid credentials from %s on listener %s, dropping traps", u.String(), t.config.Addr())
 		t.status.AddTrapsPacketsUnknownCommunityString(1)
 		t.sender.Count("datadog.snmp_traps.invalid_packet", 1, "", append(tags, "reason:unknown_community_string"))
@@ -174,3 +185,16 @@ func validatePacket(p *gosnmp.SnmpPacket, c *config.TrapsConfig) error {
 
 	return errors.New("unknown community string")
 }
+
+// validateV3Packet checks the user and the engine ID of v3 packets, the errors are the reason tags of the telemetry
+func validateV3Packet(p *gosnmp.SnmpPacket, c *config.TrapsConfig) error {
+	usm, ok := p.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	// the packets without user are only accepted to answer the engine ID discovery of informs
+	if !ok || usm.UserName == "" {
+		return errors.New("unknown_user")
+	}
+	if !c.IsAllowedEngineID(usm.UserName, usm.AuthoritativeEngineID) {
+		return errors.New("unknown_engine_id")
+	}
+	return nil
+}
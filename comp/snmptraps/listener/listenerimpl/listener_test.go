@@ -7,6 +7,8 @@ package listenerimpl
 
 import (
 	"errors"
+	"fmt"
+	"net"
 	"testing"
 	"time"
 
@@ -233,6 +235,113 @@ func TestServerV3BadCredentials(t *testing.T) {
 	assertNoPacketReceived(t, s.Listener)
 }
 
+func TestServerV3Inform(t *testing.T) {
+	serverPort, err := ndmtestutils.GetFreePort()
+	require.NoError(t, err)
+	userV3 := config.UserV3{Username: "user", AuthKey: "password", AuthProtocol: "sha", PrivKey: "password", PrivProtocol: "aes"}
+	config := &config.TrapsConfig{Port: serverPort, Users: []config.UserV3{userV3}}
+	s := listenerTestSetup(t, config)
+
+	response, err := sendTestV3Inform(t, config, gosnmp.AuthPriv, &gosnmp.UsmSecurityParameters{
+		UserName:                 "user",
+		AuthenticationPassphrase: "password",
+		AuthenticationProtocol:   gosnmp.SHA,
+		PrivacyPassphrase:        "password",
+		PrivacyProtocol:          gosnmp.AES,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, gosnmp.GetResponse, response.PDUType)
+	assert.Equal(t, config.AuthoritativeEngineID(), response.SecurityParameters.(*gosnmp.UsmSecurityParameters).AuthoritativeEngineID)
+
+	packet, err := receivePacket(s, defaultTimeout)
+	require.NoError(t, err)
+	assert.Equal(t, gosnmp.InformRequest, packet.Content.PDUType)
+	assertVariables(t, packet)
+}
+
+func TestServerV3InformWithEngineID(t *testing.T) {
+	serverPort, err := ndmtestutils.GetFreePort()
+	require.NoError(t, err)
+	userV3 := config.UserV3{Username: "user", AuthKey: "password", AuthProtocol: "sha"}
+	// the engine ID of the NMS the devices were sending informs to
+	config := &config.TrapsConfig{Port: serverPort, Users: []config.UserV3{userV3}, EngineID: "0x80001f8880e9630000d61ff449"}
+	s := listenerTestSetup(t, config)
+
+	response, err := sendTestV3Inform(t, config, gosnmp.AuthNoPriv, &gosnmp.UsmSecurityParameters{
+		UserName:                 "user",
+		AuthoritativeEngineID:    "\x80\x00\x1f\x88\x80\xe9\x63\x00\x00\xd6\x1f\xf4\x49",
+		AuthenticationPassphrase: "password",
+		AuthenticationProtocol:   gosnmp.SHA,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, gosnmp.GetResponse, response.PDUType)
+	packet, err := receivePacket(s, defaultTimeout)
+	require.NoError(t, err)
+	assertVariables(t, packet)
+}
+
+func TestServerV3EngineIDs(t *testing.T) {
+	serverPort, err := ndmtestutils.GetFreePort()
+	require.NoError(t, err)
+	userV3 := config.UserV3{Username: "user", AuthKey: "password", AuthProtocol: "sha", EngineIDs: []string{"666f6f62617262617a"}}
+	config := &config.TrapsConfig{Port: serverPort, Users: []config.UserV3{userV3}, Namespace: "totoro"}
+	s := listenerTestSetup(t, config)
+
+	secParams := func(engineID string) *gosnmp.UsmSecurityParameters {
+		return &gosnmp.UsmSecurityParameters{
+			UserName:                 "user",
+			AuthoritativeEngineID:    engineID,
+			AuthenticationPassphrase: "password",
+			AuthenticationProtocol:   gosnmp.SHA,
+		}
+	}
+
+	sendTestV3Trap(t, config, gosnmp.AuthNoPriv, secParams("foobarbaz"))
+	packet, err := receivePacket(s, defaultTimeout)
+	require.NoError(t, err)
+	assertVariables(t, packet)
+
+	sendTestV3Trap(t, config, gosnmp.AuthNoPriv, secParams("otherdevice"))
+	assertNoPacketReceived(t, s.Listener)
+	s.Sender.AssertMetric(t, "Count", "datadog.snmp_traps.invalid_packet", 1, "", []string{"snmp_device:127.0.0.1", "device_namespace:totoro", "snmp_version:3", "reason:unknown_engine_id"})
+
+	// informs use the engine ID of the Agent
+	_, err = sendTestV3Inform(t, config, gosnmp.AuthNoPriv, secParams(""))
+	require.NoError(t, err)
+	packet, err = receivePacket(s, defaultTimeout)
+	require.NoError(t, err)
+	assertVariables(t, packet)
+}
+
+func TestServerV3WithoutUser(t *testing.T) {
+	serverPort, err := ndmtestutils.GetFreePort()
+	require.NoError(t, err)
+	userV3 := config.UserV3{Username: "user", AuthKey: "password", AuthProtocol: "sha"}
+	config := &config.TrapsConfig{Port: serverPort, Users: []config.UserV3{userV3}}
+	s := listenerTestSetup(t, config)
+
+	// packets without user are unmarshalled to answer the engine ID discovery of informs, but they're dropped
+	trap := &gosnmp.SnmpPacket{
+		Version:            gosnmp.Version3,
+		MsgFlags:           gosnmp.NoAuthNoPriv,
+		SecurityModel:      gosnmp.UserSecurityModel,
+		SecurityParameters: &gosnmp.UsmSecurityParameters{AuthoritativeEngineID: "foobarbaz"},
+		PDUType:            gosnmp.SNMPv2Trap,
+		MsgID:              1,
+		RequestID:          1,
+		MsgMaxSize:         65507,
+		Variables:          packetModule.NetSNMPExampleHeartbeatNotification.Variables,
+	}
+	msg, err := trap.MarshalMsg()
+	require.NoError(t, err)
+	conn, err := net.Dial("udp", fmt.Sprintf("127.0.0.1:%d", serverPort))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write(msg)
+	require.NoError(t, err)
+	assertNoPacketReceived(t, s.Listener)
+}
+
 func TestListenerTrapsReceivedTelemetry(t *testing.T) {
 	serverPort, err := ndmtestutils.GetFreePort()
 	require.NoError(t, err)
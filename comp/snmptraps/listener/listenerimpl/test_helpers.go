@@ -91,6 +91,30 @@ func sendTestV3Trap(t *testing.T, trapConfig *config.TrapsConfig, msgFlags gosnm
 	return params
 }
 
+// sendTestV3Inform sends an inform without knowing the engine ID of the listener, which is discovered first like
+// devices do
+func sendTestV3Inform(t *testing.T, trapConfig *config.TrapsConfig, msgFlags gosnmp.SnmpV3MsgFlags, securityParams *gosnmp.UsmSecurityParameters) (*gosnmp.SnmpPacket, error) {
+	params := &gosnmp.GoSNMP{
+		Target:             "127.0.0.1",
+		Port:               trapConfig.Port,
+		Transport:          "udp",
+		Version:            gosnmp.Version3,
+		SecurityModel:      gosnmp.UserSecurityModel,
+		MsgFlags:           msgFlags,
+		SecurityParameters: securityParams,
+		Timeout:            1 * time.Second,
+		Retries:            1,
+	}
+
+	err := params.Connect()
+	require.NoError(t, err)
+	defer params.Conn.Close()
+
+	trap := packet.NetSNMPExampleHeartbeatNotification
+	trap.IsInform = true
+	return params.SendTrap(trap)
+}
+
 func assertIsValidV2Packet(t *testing.T, packet *packet.SnmpPacket, trapConfig *config.TrapsConfig) {
 	require.Equal(t, gosnmp.Version2c, packet.Content.Version)
 	communityValid := false
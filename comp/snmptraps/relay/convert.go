@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package relay
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/DataDog/datadog-agent/comp/snmptraps/oidresolver"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/packet"
+)
+
+const (
+	sysUpTimeInstanceOID  = "1.3.6.1.2.1.1.3.0"
+	snmpTrapOID           = "1.3.6.1.6.3.1.1.4.1.0"
+	snmpTrapEnterpriseOID = "1.3.6.1.6.3.1.1.4.3.0"
+	snmpTrapAddressOID    = "1.3.6.1.6.3.18.1.3.0"
+	genericTrapOID        = "1.3.6.1.6.3.1.1.5"
+)
+
+// toSnmpTrap converts a received trap to a trap of the given version, following the rules of RFC 3584 when the
+// versions differ. The address of the device sending the trap is kept in the agent-addr field of v1 traps and in the
+// snmpTrapAddress variable of v2 traps, since the receiver only sees the address of the Agent.
+func toSnmpTrap(p *packet.SnmpPacket, version gosnmp.SnmpVersion) (gosnmp.SnmpTrap, error) {
+	content := p.Content
+	switch {
+	case content.Version == gosnmp.Version1 && version == gosnmp.Version1:
+		return gosnmp.SnmpTrap{
+			Variables:    content.Variables,
+			Enterprise:   content.Enterprise,
+			AgentAddress: agentAddress(content.AgentAddress, p.Addr),
+			GenericTrap:  content.GenericTrap,
+			SpecificTrap: content.SpecificTrap,
+			Timestamp:    content.Timestamp,
+		}, nil
+	case content.Version == gosnmp.Version1:
+		return v1ToV2(p), nil
+	case version == gosnmp.Version1:
+		return v2ToV1(p)
+	}
+
+	variables := append([]gosnmp.SnmpPDU{}, content.Variables...)
+	if findVariable(variables, snmpTrapAddressOID) < 0 {
+		if ip := p.Addr.IP.To4(); ip != nil {
+			variables = append(variables, gosnmp.SnmpPDU{Name: snmpTrapAddressOID, Type: gosnmp.IPAddress, Value: ip.String()})
+		}
+	}
+	return gosnmp.SnmpTrap{Variables: variables}, nil
+}
+
+// v1ToV2 converts a v1 trap to a v2 trap, RFC 3584 section 3.1
+func v1ToV2(p *packet.SnmpPacket) gosnmp.SnmpTrap {
+	content := p.Content
+	enterprise := oidresolver.NormalizeOID(content.Enterprise)
+	trapOID := fmt.Sprintf("%s.0.%d", enterprise, content.SpecificTrap)
+	if content.GenericTrap != 6 {
+		trapOID = fmt.Sprintf("%s.%d", genericTrapOID, content.GenericTrap+1)
+	}
+	variables := []gosnmp.SnmpPDU{
+		{Name: sysUpTimeInstanceOID, Type: gosnmp.TimeTicks, Value: uint32(content.Timestamp)},
+		{Name: snmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: trapOID},
+	}
+	variables = append(variables, content.Variables...)
+	variables = append(variables,
+		gosnmp.SnmpPDU{Name: snmpTrapAddressOID, Type: gosnmp.IPAddress, Value: agentAddress(content.AgentAddress, p.Addr)},
+		gosnmp.SnmpPDU{Name: snmpTrapEnterpriseOID, Type: gosnmp.ObjectIdentifier, Value: enterprise},
+	)
+	return gosnmp.SnmpTrap{Variables: variables}
+}
+
+// v2ToV1 converts a v2 trap to a v1 trap, RFC 3584 section 3.2. The Counter64 variables can't be sent with v1, they
+// are dropped.
+func v2ToV1(p *packet.SnmpPacket) (gosnmp.SnmpTrap, error) {
+	var trapOID, enterprise, address string
+	var timestamp uint
+	var variables []gosnmp.SnmpPDU
+	for _, pdu := range p.Content.Variables {
+		switch oidresolver.NormalizeOID(pdu.Name) {
+		case sysUpTimeInstanceOID:
+			if ticks, ok := pdu.Value.(uint32); ok {
+				timestamp = uint(ticks)
+			}
+			continue
+		case snmpTrapOID:
+			trapOID = oidValue(pdu)
+			continue
+		case snmpTrapEnterpriseOID:
+			enterprise = oidValue(pdu)
+		case snmpTrapAddressOID:
+			address, _ = pdu.Value.(string)
+		}
+		if pdu.Type == gosnmp.Counter64 {
+			continue
+		}
+		variables = append(variables, pdu)
+	}
+	if trapOID == "" {
+		return gosnmp.SnmpTrap{}, fmt.Errorf("no snmpTrapOID variable in trap from %s", p.Addr.IP)
+	}
+
+	trap := gosnmp.SnmpTrap{
+		Variables:    variables,
+		AgentAddress: agentAddress(address, p.Addr),
+		Timestamp:    timestamp,
+	}
+	subIDs := strings.Split(trapOID, ".")
+	last, err := strconv.Atoi(subIDs[len(subIDs)-1])
+	if err != nil || len(subIDs) < 2 {
+		return gosnmp.SnmpTrap{}, fmt.Errorf("invalid snmpTrapOID %s", trapOID)
+	}
+	parent := strings.Join(subIDs[:len(subIDs)-1], ".")
+	switch {
+	case parent == genericTrapOID && last >= 1 && last <= 6:
+		trap.GenericTrap = last - 1
+		trap.Enterprise = enterprise
+		if trap.Enterprise == "" {
+			trap.Enterprise = genericTrapOID
+		}
+	case subIDs[len(subIDs)-2] == "0":
+		trap.GenericTrap = 6
+		trap.SpecificTrap = last
+		trap.Enterprise = strings.Join(subIDs[:len(subIDs)-2], ".")
+	default:
+		trap.GenericTrap = 6
+		trap.SpecificTrap = last
+		trap.Enterprise = parent
+	}
+	return trap, nil
+}
+
+// agentAddress returns the address of the device sending a trap, v1 traps only support IPv4 addresses
+func agentAddress(address string, addr *net.UDPAddr) string {
+	if ip := net.ParseIP(address); ip != nil && !ip.IsUnspecified() {
+		return address
+	}
+	if ip := addr.IP.To4(); ip != nil {
+		return ip.String()
+	}
+	return "0.0.0.0"
+}
+
+// oidValue returns the normalized OID held by a variable
+func oidValue(pdu gosnmp.SnmpPDU) string {
+	switch value := pdu.Value.(type) {
+	case string:
+		return oidresolver.NormalizeOID(value)
+	case []byte:
+		return oidresolver.NormalizeOID(string(value))
+	}
+	return ""
+}
+
+// findVariable returns the index of a variable, or -1 if it's not found
+func findVariable(variables []gosnmp.SnmpPDU, oid string) int {
+	for i, pdu := range variables {
+		if oidresolver.NormalizeOID(pdu.Name) == oid {
+			return i
+		}
+	}
+	return -1
+}
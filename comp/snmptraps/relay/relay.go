@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package relay sends the received traps again to downstream receivers, so that the Agent can sit in front of
+// another NMS.
+package relay
+
+import (
+	"sync"
+
+	"github.com/gosnmp/gosnmp"
+
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/config"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/packet"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/snmplog"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+)
+
+const (
+	// queueSize bounds the traps waiting to be sent to a receiver, the traps are dropped when it's full
+	queueSize = 1000
+
+	telemetrySent    = "datadog.snmp_traps.relay.sent"
+	telemetryErrors  = "datadog.snmp_traps.relay.errors"
+	telemetryDropped = "datadog.snmp_traps.relay.dropped"
+)
+
+// Relay sends the traps to the downstream receivers. Each receiver has its own queue, a slow receiver waiting for
+// the acknowledgement of informs doesn't delay the others.
+type Relay struct {
+	receivers []*receiver
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+type receiver struct {
+	conf   config.RelayReceiver
+	params *gosnmp.GoSNMP
+	queue  chan *packet.SnmpPacket
+	tags   []string
+	sender sender.Sender
+	logger log.Component
+}
+
+// New returns a relay to the receivers of the configuration.
+func New(conf *config.TrapsConfig, sender sender.Sender, logger log.Component) (*Relay, error) {
+	var snmpLogger gosnmp.Logger
+	if logger != nil {
+		snmpLogger = gosnmp.NewLogger(snmplog.New(logger))
+	}
+	r := &Relay{stop: make(chan struct{})}
+	for _, c := range conf.Relay {
+		params, err := c.BuildSNMPParams(conf.AuthoritativeEngineID(), snmpLogger)
+		if err != nil {
+			return nil, err
+		}
+		r.receivers = append(r.receivers, &receiver{
+			conf:   c,
+			params: params,
+			queue:  make(chan *packet.SnmpPacket, queueSize),
+			tags:   []string{"receiver:" + c.Addr()},
+			sender: sender,
+			logger: logger,
+		})
+	}
+	return r, nil
+}
+
+// Empty returns true if there are no receivers
+func (r *Relay) Empty() bool {
+	return len(r.receivers) == 0
+}
+
+// Start starts sending the traps to the receivers
+func (r *Relay) Start() {
+	for _, rcv := range r.receivers {
+		r.wg.Add(1)
+		go func(rcv *receiver) {
+			defer r.wg.Done()
+			rcv.run(r.stop)
+		}(rcv)
+	}
+}
+
+// Stop stops the relay, the queued traps are dropped
+func (r *Relay) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+// Relay queues a trap to be sent to the receivers
+func (r *Relay) Relay(p *packet.SnmpPacket) {
+	for _, rcv := range r.receivers {
+		select {
+		case rcv.queue <- p:
+		default:
+			rcv.logger.Debugf("relay queue of %s is full, dropping trap from %s", rcv.conf.Addr(), p.Addr.IP)
+			rcv.sender.Count(telemetryDropped, 1, "", rcv.tags)
+		}
+	}
+}
+
+func (rcv *receiver) run(stop chan struct{}) {
+	defer func() {
+		if rcv.params.Conn != nil {
+			rcv.params.Conn.Close()
+		}
+	}()
+	for {
+		var p *packet.SnmpPacket
+		select {
+		case <-stop:
+			return
+		case p = <-rcv.queue:
+		}
+		if err := rcv.send(p); err != nil {
+			rcv.logger.Warnf("failed to relay trap from %s to %s: %s", p.Addr.IP, rcv.conf.Addr(), err)
+			rcv.sender.Count(telemetryErrors, 1, "", rcv.tags)
+			continue
+		}
+		rcv.sender.Count(telemetrySent, 1, "", rcv.tags)
+	}
+}
+
+func (rcv *receiver) send(p *packet.SnmpPacket) error {
+	if rcv.params.Conn == nil {
+		// connecting fails when the host can't be resolved, it's retried with the next trap
+		if err := rcv.params.Connect(); err != nil {
+			return err
+		}
+	}
+	trap, err := toSnmpTrap(p, rcv.params.Version)
+	if err != nil {
+		return err
+	}
+	trap.IsInform = rcv.conf.Inform
+	_, err = rcv.params.SendTrap(trap)
+	return err
+}
@@ -0,0 +1,269 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+//go:build test
+
+package relay
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/config"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/packet"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	ndmtestutils "github.com/DataDog/datadog-agent/pkg/networkdevice/testutils"
+)
+
+var deviceAddr = &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 161}
+
+func v2Packet(trapOID string, variables ...gosnmp.SnmpPDU) *packet.SnmpPacket {
+	pdus := []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(1000)},
+		{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: "." + trapOID},
+	}
+	return &packet.SnmpPacket{
+		Content: &gosnmp.SnmpPacket{
+			Version:   gosnmp.Version2c,
+			Community: "public",
+			Variables: append(pdus, variables...),
+		},
+		Addr:      deviceAddr,
+		Namespace: "default",
+	}
+}
+
+func v1Packet(trap gosnmp.SnmpTrap) *packet.SnmpPacket {
+	content := &gosnmp.SnmpPacket{Version: gosnmp.Version1, Community: "public", SnmpTrap: trap}
+	content.Variables = trap.Variables
+	return &packet.SnmpPacket{Content: content, Addr: deviceAddr, Namespace: "default"}
+}
+
+func TestToSnmpTrap(t *testing.T) {
+	ifIndex := gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.2.2.1.1", Type: gosnmp.Integer, Value: 2}
+	hcInOctets := gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.31.1.1.1.6.2", Type: gosnmp.Counter64, Value: uint64(42)}
+
+	tests := []struct {
+		name     string
+		packet   *packet.SnmpPacket
+		version  gosnmp.SnmpVersion
+		expected gosnmp.SnmpTrap
+	}{
+		{
+			name:     "v1 to v1 is unchanged",
+			packet:   v1Packet(packet.AlarmActiveStatev1SpecificTrap),
+			version:  gosnmp.Version1,
+			expected: packet.AlarmActiveStatev1SpecificTrap,
+		},
+		{
+			name:    "v1 generic trap to v2",
+			packet:  v1Packet(gosnmp.SnmpTrap{Enterprise: ".1.3.6.1.4.1.9", AgentAddress: "0.0.0.0", GenericTrap: 2, Timestamp: 1000, Variables: []gosnmp.SnmpPDU{ifIndex}}),
+			version: gosnmp.Version2c,
+			expected: gosnmp.SnmpTrap{Variables: []gosnmp.SnmpPDU{
+				{Name: "1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(1000)},
+				{Name: "1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.6.3.1.1.5.3"},
+				ifIndex,
+				{Name: "1.3.6.1.6.3.18.1.3.0", Type: gosnmp.IPAddress, Value: "10.0.0.1"},
+				{Name: "1.3.6.1.6.3.1.1.4.3.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.4.1.9"},
+			}},
+		},
+		{
+			name:    "v1 specific trap to v2",
+			packet:  v1Packet(gosnmp.SnmpTrap{Enterprise: ".1.3.6.1.2.1.118", AgentAddress: "192.168.1.1", GenericTrap: 6, SpecificTrap: 2, Timestamp: 1000}),
+			version: gosnmp.Version3,
+			expected: gosnmp.SnmpTrap{Variables: []gosnmp.SnmpPDU{
+				{Name: "1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(1000)},
+				{Name: "1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.2.1.118.0.2"},
+				{Name: "1.3.6.1.6.3.18.1.3.0", Type: gosnmp.IPAddress, Value: "192.168.1.1"},
+				{Name: "1.3.6.1.6.3.1.1.4.3.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.2.1.118"},
+			}},
+		},
+		{
+			name:    "v2 to v2 keeps the address of the device",
+			packet:  v2Packet("1.3.6.1.6.3.1.1.5.3", ifIndex),
+			version: gosnmp.Version2c,
+			expected: gosnmp.SnmpTrap{Variables: append(v2Packet("1.3.6.1.6.3.1.1.5.3", ifIndex).Content.Variables,
+				gosnmp.SnmpPDU{Name: "1.3.6.1.6.3.18.1.3.0", Type: gosnmp.IPAddress, Value: "10.0.0.1"},
+			)},
+		},
+		{
+			name:    "v2 generic trap to v1",
+			packet:  v2Packet("1.3.6.1.6.3.1.1.5.3", ifIndex, hcInOctets),
+			version: gosnmp.Version1,
+			expected: gosnmp.SnmpTrap{
+				Enterprise:   "1.3.6.1.6.3.1.1.5",
+				AgentAddress: "10.0.0.1",
+				GenericTrap:  2,
+				Timestamp:    1000,
+				Variables:    []gosnmp.SnmpPDU{ifIndex},
+			},
+		},
+		{
+			name:    "v2 specific trap to v1",
+			packet:  v2Packet("1.3.6.1.4.1.8072.2.3.0.1", ifIndex),
+			version: gosnmp.Version1,
+			expected: gosnmp.SnmpTrap{
+				Enterprise:   "1.3.6.1.4.1.8072.2.3",
+				AgentAddress: "10.0.0.1",
+				GenericTrap:  6,
+				SpecificTrap: 1,
+				Timestamp:    1000,
+				Variables:    []gosnmp.SnmpPDU{ifIndex},
+			},
+		},
+		{
+			name:    "v2 specific trap without 0 to v1",
+			packet:  v2Packet("1.3.6.1.4.1.99999.7", ifIndex),
+			version: gosnmp.Version1,
+			expected: gosnmp.SnmpTrap{
+				Enterprise:   "1.3.6.1.4.1.99999",
+				AgentAddress: "10.0.0.1",
+				GenericTrap:  6,
+				SpecificTrap: 7,
+				Timestamp:    1000,
+				Variables:    []gosnmp.SnmpPDU{ifIndex},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trap, err := toSnmpTrap(test.packet, test.version)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, trap)
+		})
+	}
+}
+
+// startReceiver starts a downstream receiver, built like the listener of the Agent, and returns its port
+func startReceiver(t *testing.T, trapsConfig *config.TrapsConfig) (uint16, <-chan *gosnmp.SnmpPacket) {
+	t.Helper()
+	port, err := ndmtestutils.GetFreePort()
+	require.NoError(t, err)
+	trapsConfig.Port = port
+	require.NoError(t, trapsConfig.SetDefaults("downstream", "default"))
+
+	listener := gosnmp.NewTrapListener()
+	listener.Params, err = trapsConfig.BuildSNMPParams(nil)
+	require.NoError(t, err)
+	traps := make(chan *gosnmp.SnmpPacket, 10)
+	listener.OnNewTrap = func(p *gosnmp.SnmpPacket, _ *net.UDPAddr) {
+		// gosnmp turns informs into their response once they're handled
+		trap := *p
+		traps <- &trap
+	}
+	go func() {
+		_ = listener.Listen(fmt.Sprintf("127.0.0.1:%d", port))
+	}()
+	select {
+	case <-listener.Listening():
+	case <-time.After(time.Second):
+		require.FailNow(t, "the receiver isn't listening")
+	}
+	t.Cleanup(listener.Close)
+	return port, traps
+}
+
+func startTestRelay(t *testing.T, receiver config.RelayReceiver) (*Relay, *mocksender.MockSender) {
+	t.Helper()
+	trapsConfig := &config.TrapsConfig{Relay: []config.RelayReceiver{receiver}}
+	require.NoError(t, trapsConfig.SetDefaults("agent", "default"))
+	sender := mocksender.NewMockSender("relay")
+	sender.SetupAcceptAll()
+	r, err := New(trapsConfig, sender, logmock.New(t))
+	require.NoError(t, err)
+	r.Start()
+	return r, sender
+}
+
+func receiveTrap(t *testing.T, traps <-chan *gosnmp.SnmpPacket) *gosnmp.SnmpPacket {
+	t.Helper()
+	select {
+	case trap := <-traps:
+		return trap
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timeout waiting for the relayed trap")
+		return nil
+	}
+}
+
+func TestRelayV1ToV2(t *testing.T) {
+	port, traps := startReceiver(t, &config.TrapsConfig{CommunityStrings: []string{"private"}})
+	r, sender := startTestRelay(t, config.RelayReceiver{Host: "127.0.0.1", Port: port, CommunityString: "private"})
+
+	r.Relay(v1Packet(packet.LinkDownv1GenericTrap))
+	trap := receiveTrap(t, traps)
+	r.Stop()
+
+	assert.Equal(t, gosnmp.Version2c, trap.Version)
+	assert.Equal(t, gosnmp.SNMPv2Trap, trap.PDUType)
+	assert.Equal(t, "private", trap.Community)
+	require.Len(t, trap.Variables, 8)
+	assert.Equal(t, ".1.3.6.1.6.3.1.1.4.1.0", trap.Variables[1].Name)
+	assert.Equal(t, ".1.3.6.1.6.3.1.1.5.3", trap.Variables[1].Value)
+	assert.Equal(t, ".1.3.6.1.6.3.18.1.3.0", trap.Variables[6].Name)
+	assert.Equal(t, "127.0.0.1", trap.Variables[6].Value)
+	sender.AssertMetric(t, "Count", telemetrySent, 1, "", []string{fmt.Sprintf("receiver:127.0.0.1:%d", port)})
+}
+
+func TestRelayV3Inform(t *testing.T) {
+	user := config.UserV3{Username: "relay", AuthKey: "password", AuthProtocol: "sha", PrivKey: "password", PrivProtocol: "aes"}
+	port, traps := startReceiver(t, &config.TrapsConfig{Users: []config.UserV3{user}})
+	r, sender := startTestRelay(t, config.RelayReceiver{
+		Host:         "127.0.0.1",
+		Port:         port,
+		Version:      "3",
+		User:         "relay",
+		AuthKey:      "password",
+		AuthProtocol: "sha",
+		PrivKey:      "password",
+		PrivProtocol: "aes",
+		Inform:       true,
+		Timeout:      1,
+	})
+
+	r.Relay(v2Packet("1.3.6.1.4.1.8072.2.3.0.1", gosnmp.SnmpPDU{Name: ".1.3.6.1.4.1.8072.2.3.2.1", Type: gosnmp.Integer, Value: 1024}))
+	trap := receiveTrap(t, traps)
+	// the inform is acknowledged before the relay stops
+	r.Stop()
+
+	assert.Equal(t, gosnmp.Version3, trap.Version)
+	assert.Equal(t, gosnmp.InformRequest, trap.PDUType)
+	assert.Equal(t, "relay", trap.SecurityParameters.(*gosnmp.UsmSecurityParameters).UserName)
+	assert.Equal(t, ".1.3.6.1.6.3.1.1.4.1.0", trap.Variables[1].Name)
+	assert.Equal(t, ".1.3.6.1.4.1.8072.2.3.0.1", trap.Variables[1].Value)
+	sender.AssertMetric(t, "Count", telemetrySent, 1, "", []string{fmt.Sprintf("receiver:127.0.0.1:%d", port)})
+}
+
+func TestRelayUnreachableReceiver(t *testing.T) {
+	trapsConfig := &config.TrapsConfig{Relay: []config.RelayReceiver{
+		{Host: "127.0.0.1", Port: 1, CommunityString: "public", Inform: true, Timeout: 1, Retries: 1},
+	}}
+	require.NoError(t, trapsConfig.SetDefaults("agent", "default"))
+	sender := mocksender.NewMockSender("relay")
+	failed := make(chan struct{})
+	sender.On("Count", telemetryErrors, 1.0, "", []string{"receiver:127.0.0.1:1"}).Run(func(_ mock.Arguments) {
+		close(failed)
+	}).Once()
+	r, err := New(trapsConfig, sender, logmock.New(t))
+	require.NoError(t, err)
+	r.Start()
+	defer r.Stop()
+
+	// the inform is never acknowledged
+	r.Relay(v2Packet("1.3.6.1.6.3.1.1.5.3"))
+	select {
+	case <-failed:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the relay didn't fail")
+	}
+}
@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package rules
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+)
+
+// maxActiveAlarms bounds the alarms kept in memory, the least recently raised are dropped first
+const maxActiveAlarms = 10000
+
+// alarm is an alarm raised by a trap and not cleared yet
+type alarm struct {
+	title    string
+	raisedAt time.Time
+	lastSeen time.Time
+	// count is the number of traps that raised the alarm
+	count int
+}
+
+// alarmKey identifies an alarm by its name, the device and the values of its key variables
+func alarmKey(r *rule, t *trap) string {
+	parts := []string{r.Alarm.Name, t.namespace, t.device}
+	for _, ref := range r.Alarm.Key {
+		v, _ := t.lookup(ref)
+		parts = append(parts, v.value)
+	}
+	return strings.Join(parts, "|")
+}
+
+// aggregationKey is the aggregation key of the events of an alarm, so that they're grouped
+func aggregationKey(key string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return fmt.Sprintf("snmp_traps:%x", h.Sum64())
+}
+
+// processAlarm sends an event when an alarm is raised, and a recovery event when it's cleared. The traps raising an
+// alarm already raised and the traps clearing an unknown alarm don't send events.
+func (e *Engine) processAlarm(r *rule, t *trap, tags []string) {
+	key := alarmKey(r, t)
+	now := e.now()
+	active, raised := e.alarms[key]
+
+	if !r.Alarm.Clear {
+		if raised {
+			active.count++
+			active.lastSeen = now
+			return
+		}
+		ev := e.event(r, t, append(tags, "alarm:"+r.Alarm.Name, "alarm_state:raised"))
+		ev.AggregationKey = aggregationKey(key)
+		e.evictAlarms()
+		e.alarms[key] = &alarm{
+			title:    ev.Title,
+			raisedAt: now,
+			lastSeen: now,
+			count:    1,
+		}
+		e.sender.Event(ev)
+		return
+	}
+
+	if !raised {
+		e.logger.Debugf("trap rule %s: no active alarm %s to clear", r.Name, key)
+		return
+	}
+	delete(e.alarms, key)
+	ev := e.event(r, t, append(tags, "alarm:"+r.Alarm.Name, "alarm_state:cleared"))
+	if r.Event == nil || r.Event.Title == "" {
+		ev.Title = "[Recovered] " + active.title
+	}
+	summary := fmt.Sprintf("Cleared after %s, raised by %d trap(s).", now.Sub(active.raisedAt).Round(time.Second), active.count)
+	if ev.Text != "" {
+		ev.Text += "\n\n"
+	}
+	ev.Text += summary
+	ev.AggregationKey = aggregationKey(key)
+	e.sender.Event(ev)
+}
+
+// evictAlarms drops the least recently raised alarm when the limit of active alarms is reached
+func (e *Engine) evictAlarms() {
+	if len(e.alarms) < maxActiveAlarms {
+		return
+	}
+	var oldestKey string
+	var oldest time.Time
+	for key, a := range e.alarms {
+		if oldestKey == "" || a.lastSeen.Before(oldest) {
+			oldestKey, oldest = key, a.lastSeen
+		}
+	}
+	e.logger.Debugf("too many active alarms, dropping alarm %s", oldestKey)
+	delete(e.alarms, oldestKey)
+}
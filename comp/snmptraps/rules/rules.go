@@ -0,0 +1,277 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package rules turns the SNMP traps matching the trap rules of the configuration into events and metrics, and
+// correlates the traps raising and clearing alarms.
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/config"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/oidresolver"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/packet"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/metrics/event"
+)
+
+const (
+	eventSourceType = "snmp"
+
+	telemetryRulesMatched = "datadog.snmp_traps.rules.matched"
+	telemetryActiveAlarms = "datadog.snmp_traps.rules.active_alarms"
+)
+
+var templateReference = regexp.MustCompile(`\{([^{}\s]+)\}`)
+
+// rule is a validated trap rule
+type rule struct {
+	config.TrapRule
+	alertType event.AlertType
+}
+
+// Engine applies the trap rules to the traps. It isn't safe for concurrent use.
+type Engine struct {
+	rules    []*rule
+	resolver oidresolver.Component
+	sender   sender.Sender
+	logger   log.Component
+	alarms   map[string]*alarm
+	now      func() time.Time
+}
+
+// NewEngine returns an engine applying the valid rules. The invalid rules are reported in the returned error, the
+// engine is still usable.
+func NewEngine(configs []config.TrapRule, resolver oidresolver.Component, sender sender.Sender, logger log.Component) (*Engine, error) {
+	e := &Engine{
+		resolver: resolver,
+		sender:   sender,
+		logger:   logger,
+		alarms:   make(map[string]*alarm),
+		now:      time.Now,
+	}
+	var errs []error
+	for i, c := range configs {
+		r, err := newRule(c)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid trap rule %d (%s): %w", i, c.Name, err))
+			continue
+		}
+		e.rules = append(e.rules, r)
+	}
+	return e, errors.Join(errs...)
+}
+
+func newRule(c config.TrapRule) (*rule, error) {
+	if c.Trap == "" {
+		return nil, errors.New("trap is required")
+	}
+	if oid := oidresolver.NormalizeOID(c.Trap); oidresolver.IsValidOID(oid) {
+		c.Trap = oid
+	}
+	if c.Name == "" {
+		c.Name = c.Trap
+	}
+	if len(c.Metrics) == 0 && c.Event == nil && c.Alarm == nil {
+		return nil, errors.New("a rule needs metrics, an event or an alarm")
+	}
+	for _, match := range c.Match {
+		if match.Variable == "" || len(match.Values) == 0 {
+			return nil, errors.New("match needs a variable and values")
+		}
+	}
+	for i, metric := range c.Metrics {
+		if metric.Name == "" {
+			return nil, fmt.Errorf("metric %d has no name", i)
+		}
+		switch metric.Type {
+		case "", "count":
+		case "gauge":
+			if metric.Value == "" {
+				return nil, fmt.Errorf("gauge %s needs a value", metric.Name)
+			}
+		default:
+			return nil, fmt.Errorf("metric %s has invalid type %q, expected count or gauge", metric.Name, metric.Type)
+		}
+	}
+
+	r := &rule{TrapRule: c, alertType: event.AlertTypeInfo}
+	if c.Alarm != nil {
+		if c.Alarm.Name == "" {
+			return nil, errors.New("alarm has no name")
+		}
+		r.alertType = event.AlertTypeError
+		if c.Alarm.Clear {
+			r.alertType = event.AlertTypeSuccess
+		}
+	}
+	if c.Event != nil && c.Event.Severity != "" {
+		alertType, err := alertTypeFromSeverity(c.Event.Severity)
+		if err != nil {
+			return nil, err
+		}
+		r.alertType = alertType
+	}
+	return r, nil
+}
+
+func alertTypeFromSeverity(severity string) (event.AlertType, error) {
+	switch strings.ToLower(severity) {
+	case "critical", "error":
+		return event.AlertTypeError, nil
+	case "warning":
+		return event.AlertTypeWarning, nil
+	case "info":
+		return event.AlertTypeInfo, nil
+	case "ok", "success":
+		return event.AlertTypeSuccess, nil
+	default:
+		return "", fmt.Errorf("invalid severity %q, expected critical, error, warning, info or ok", severity)
+	}
+}
+
+// Empty returns true if there are no valid rules
+func (e *Engine) Empty() bool {
+	return len(e.rules) == 0
+}
+
+// Process applies the rules to a trap
+func (e *Engine) Process(p *packet.SnmpPacket) {
+	if e.Empty() {
+		return
+	}
+	t, err := newTrap(p, e.resolver)
+	if err != nil {
+		e.logger.Debugf("unable to apply the trap rules: %s", err)
+		return
+	}
+	for _, r := range e.rules {
+		if !r.matches(t) {
+			continue
+		}
+		e.sender.Count(telemetryRulesMatched, 1, "", append(p.GetTags(), "rule:"+r.Name))
+		tags := r.tags(t)
+		e.submitMetrics(r, t, tags)
+		switch {
+		case r.Alarm != nil:
+			e.processAlarm(r, t, tags)
+		case r.Event != nil:
+			e.sender.Event(e.event(r, t, tags))
+		}
+	}
+}
+
+// ReportTelemetry submits the number of active alarms
+func (e *Engine) ReportTelemetry() {
+	if e.Empty() {
+		return
+	}
+	e.sender.Gauge(telemetryActiveAlarms, float64(len(e.alarms)), "", nil)
+}
+
+func (e *Engine) submitMetrics(r *rule, t *trap, tags []string) {
+	for _, metric := range r.Metrics {
+		value := 1.0
+		if metric.Value != "" {
+			v, ok := t.lookup(metric.Value)
+			if !ok {
+				e.logger.Debugf("trap rule %s: no variable %s in trap %s", r.Name, metric.Value, t.displayName())
+				continue
+			}
+			if value, ok = toFloat(v); !ok {
+				e.logger.Debugf("trap rule %s: value %q of variable %s isn't a number", r.Name, v.value, metric.Value)
+				continue
+			}
+		}
+		if metric.Type == "gauge" {
+			e.sender.Gauge(metric.Name, value, "", tags)
+		} else {
+			e.sender.Count(metric.Name, value, "", tags)
+		}
+	}
+}
+
+func (e *Engine) event(r *rule, t *trap, tags []string) event.Event {
+	title := "{trap} on {device}"
+	var text string
+	if r.Event != nil {
+		if r.Event.Title != "" {
+			title = r.Event.Title
+		}
+		text = r.Event.Text
+	}
+	return event.Event{
+		Title:          render(title, t),
+		Text:           render(text, t),
+		Ts:             e.now().Unix(),
+		Priority:       event.PriorityNormal,
+		Tags:           tags,
+		AlertType:      r.alertType,
+		SourceTypeName: eventSourceType,
+	}
+}
+
+// matches returns true if the trap is the trap of the rule and its variables have the expected values
+func (r *rule) matches(t *trap) bool {
+	switch {
+	case oidresolver.IsValidOID(r.Trap):
+		if t.oid != r.Trap {
+			return false
+		}
+	case strings.Contains(r.Trap, "::"):
+		if t.mib+"::"+t.name != r.Trap {
+			return false
+		}
+	default:
+		if t.name != r.Trap {
+			return false
+		}
+	}
+	for _, match := range r.Match {
+		v, ok := t.lookup(match.Variable)
+		if !ok || !matchesValue(v, match.Values) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesValue returns true if the variable has one of the values, enumerations can be matched by name or by number
+func matchesValue(v variable, values []string) bool {
+	raw := fmt.Sprint(v.raw)
+	for _, value := range values {
+		if value == v.value || value == raw {
+			return true
+		}
+	}
+	return false
+}
+
+// tags returns the tags of the trap, with the tags of the rule taking their values from the variables
+func (r *rule) tags(t *trap) []string {
+	tags := append([]string{}, t.tags...)
+	for _, name := range sortedKeys(r.Tags) {
+		if v, ok := t.lookup(r.Tags[name]); ok && v.value != "" {
+			tags = append(tags, name+":"+v.value)
+		}
+	}
+	return tags
+}
+
+// render replaces the {variable} references of a template by the values of the variables, unknown references are
+// kept as is
+func render(template string, t *trap) string {
+	return templateReference.ReplaceAllStringFunc(template, func(reference string) string {
+		if v, ok := t.lookup(reference[1 : len(reference)-1]); ok {
+			return v.value
+		}
+		return reference
+	})
+}
@@ -0,0 +1,272 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+//go:build test
+
+package rules
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/config"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/oidresolver"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/oidresolver/oidresolverimpl"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/packet"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics/event"
+)
+
+var testTrapDB = oidresolver.TrapDBFileContent{
+	Traps: oidresolver.TrapSpec{
+		"1.3.6.1.6.3.1.1.5.3": {Name: "linkDown", MIBName: "IF-MIB"},
+		"1.3.6.1.6.3.1.1.5.4": {Name: "linkUp", MIBName: "IF-MIB"},
+		"1.3.6.1.2.1.15.0.2":  {Name: "bgpBackwardTransition", MIBName: "BGP4-MIB"},
+	},
+	Variables: oidresolver.VariableSpec{
+		"1.3.6.1.2.1.2.2.1.1":      {Name: "ifIndex"},
+		"1.3.6.1.2.1.2.2.1.7":      {Name: "ifAdminStatus", Enumeration: map[int]string{1: "up", 2: "down", 3: "testing"}},
+		"1.3.6.1.2.1.31.1.1.1.1":   {Name: "ifName"},
+		"1.3.6.1.2.1.15.3.1.2":     {Name: "bgpPeerState", Enumeration: map[int]string{1: "idle", 2: "connect", 3: "active", 4: "opensent", 5: "openconfirm", 6: "established"}},
+		"1.3.6.1.2.1.15.3.1.7":     {Name: "bgpPeerRemoteAddr"},
+		"1.3.6.1.4.1.99999.1.1.2":  {Name: "acmeFailedParts", Bits: map[int]string{0: "fan", 1: "power"}},
+		"1.3.6.1.4.1.99999.1.1.10": {Name: "acmeTemperature"},
+	},
+}
+
+var interfaceRules = []config.TrapRule{
+	{
+		Name:    "link_down",
+		Trap:    "IF-MIB::linkDown",
+		Match:   []config.RuleMatch{{Variable: "ifAdminStatus", Values: []string{"up"}}},
+		Tags:    map[string]string{"interface": "ifName", "interface_index": "ifIndex"},
+		Metrics: []config.RuleMetric{{Name: "snmp_traps.interface.link_down"}},
+		Event:   &config.RuleEvent{Title: "Interface {ifName} down on {device}", Text: "{trap} from {mib}", Severity: "critical"},
+		Alarm:   &config.RuleAlarm{Name: "interface_link", Key: []string{"ifIndex"}},
+	},
+	{
+		Name:  "link_up",
+		Trap:  "linkUp",
+		Tags:  map[string]string{"interface": "ifName", "interface_index": "ifIndex"},
+		Alarm: &config.RuleAlarm{Name: "interface_link", Clear: true, Key: []string{"ifIndex"}},
+	},
+}
+
+// testResolver resolves the variables with an index, like the resolver of the agent
+type testResolver struct {
+	oidresolver.Component
+}
+
+func (r testResolver) GetVariableMetadata(trapOID string, varOID string) (oidresolver.VariableMetadata, error) {
+	for {
+		metadata, err := r.Component.GetVariableMetadata(trapOID, varOID)
+		i := strings.LastIndex(varOID, ".")
+		if err == nil || i < 0 {
+			return metadata, err
+		}
+		varOID = varOID[:i]
+	}
+}
+
+func newTestResolver() oidresolver.Component {
+	return testResolver{oidresolverimpl.NewMockResolver(&testTrapDB)}
+}
+
+func newTestEngine(t *testing.T, rules []config.TrapRule) (*Engine, *mocksender.MockSender, *time.Time) {
+	t.Helper()
+	sender := mocksender.NewMockSender("rules")
+	sender.SetupAcceptAll()
+	e, err := NewEngine(rules, newTestResolver(), sender, logmock.New(t))
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	return e, sender, &now
+}
+
+func v2Packet(trapOID string, variables ...gosnmp.SnmpPDU) *packet.SnmpPacket {
+	pdus := []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(1000)},
+		{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: trapOID},
+	}
+	return &packet.SnmpPacket{
+		Content: &gosnmp.SnmpPacket{
+			Version:   gosnmp.Version2c,
+			Variables: append(pdus, variables...),
+		},
+		Addr:      &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 161},
+		Namespace: "default",
+	}
+}
+
+func linkPacket(trapOID string, ifIndex int, adminStatus int) *packet.SnmpPacket {
+	return v2Packet(trapOID,
+		gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.2.2.1.1." + itoa(ifIndex), Type: gosnmp.Integer, Value: ifIndex},
+		gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.2.2.1.7." + itoa(ifIndex), Type: gosnmp.Integer, Value: adminStatus},
+		gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.31.1.1.1.1." + itoa(ifIndex), Type: gosnmp.OctetString, Value: []byte("eth" + itoa(ifIndex))},
+	)
+}
+
+func itoa(i int) string {
+	return string(rune('0' + i))
+}
+
+func sentEvents(sender *mocksender.MockSender) []event.Event {
+	var events []event.Event
+	for _, call := range sender.Calls {
+		if call.Method == "Event" {
+			events = append(events, call.Arguments.Get(0).(event.Event))
+		}
+	}
+	return events
+}
+
+func TestAlarmCorrelation(t *testing.T) {
+	e, sender, now := newTestEngine(t, interfaceRules)
+	deviceTags := []string{"snmp_version:2", "device_namespace:default", "snmp_device:10.0.0.1"}
+	interfaceTags := append(deviceTags, "interface:eth3", "interface_index:3")
+
+	e.Process(linkPacket("1.3.6.1.6.3.1.1.5.3", 3, 1))
+	*now = now.Add(time.Minute)
+	// the alarm is already raised
+	e.Process(linkPacket("1.3.6.1.6.3.1.1.5.3", 3, 1))
+	// administratively down interfaces don't match the rule
+	e.Process(linkPacket("1.3.6.1.6.3.1.1.5.3", 4, 2))
+	*now = now.Add(time.Minute)
+	e.Process(linkPacket("1.3.6.1.6.3.1.1.5.4", 3, 1))
+	// the alarm is already cleared
+	e.Process(linkPacket("1.3.6.1.6.3.1.1.5.4", 3, 1))
+
+	sender.AssertMetric(t, "Count", "snmp_traps.interface.link_down", 1, "", interfaceTags)
+	sender.AssertNumberOfCalls(t, "Count", 2+4) // link_down twice, rules.matched four times
+	sender.AssertMetric(t, "Count", telemetryRulesMatched, 1, "", append(deviceTags, "rule:link_up"))
+
+	key := aggregationKey("interface_link|default|10.0.0.1|3")
+	assert.Equal(t, []event.Event{
+		{
+			Title:          "Interface eth3 down on 10.0.0.1",
+			Text:           "linkDown from IF-MIB",
+			Ts:             1704067200,
+			Priority:       event.PriorityNormal,
+			Tags:           append(interfaceTags, "alarm:interface_link", "alarm_state:raised"),
+			AlertType:      event.AlertTypeError,
+			AggregationKey: key,
+			SourceTypeName: "snmp",
+		},
+		{
+			Title:          "[Recovered] Interface eth3 down on 10.0.0.1",
+			Text:           "Cleared after 2m0s, raised by 2 trap(s).",
+			Ts:             1704067320,
+			Priority:       event.PriorityNormal,
+			Tags:           append(interfaceTags, "alarm:interface_link", "alarm_state:cleared"),
+			AlertType:      event.AlertTypeSuccess,
+			AggregationKey: key,
+			SourceTypeName: "snmp",
+		},
+	}, sentEvents(sender))
+
+	e.ReportTelemetry()
+	sender.AssertMetric(t, "Gauge", telemetryActiveAlarms, 0, "", nil)
+}
+
+func TestMetricsAndEvents(t *testing.T) {
+	e, sender, _ := newTestEngine(t, []config.TrapRule{
+		{
+			Trap:  "1.3.6.1.2.1.15.0.2",
+			Match: []config.RuleMatch{{Variable: "bgpPeerState", Values: []string{"1", "active"}}},
+			Tags:  map[string]string{"peer": "1.3.6.1.2.1.15.3.1.7", "state": "bgpPeerState"},
+			Metrics: []config.RuleMetric{
+				{Name: "snmp_traps.bgp.backward_transition", Type: "count"},
+				{Name: "snmp_traps.bgp.peer_state", Type: "gauge", Value: "bgpPeerState"},
+			},
+			Event: &config.RuleEvent{Title: "BGP peer {bgpPeerRemoteAddr} went {bgpPeerState} ({unknown})", Severity: "warning"},
+		},
+		{
+			Name:    "temperature",
+			Trap:    ".1.3.6.1.4.1.99999.0.1",
+			Metrics: []config.RuleMetric{{Name: "snmp_traps.acme.temperature", Type: "gauge", Value: "acmeTemperature"}},
+			Event:   &config.RuleEvent{Text: "failed parts: {acmeFailedParts}"},
+		},
+	})
+	bgpPacket := func(state int) *packet.SnmpPacket {
+		return v2Packet(".1.3.6.1.2.1.15.0.2",
+			gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.15.3.1.7.192.168.1.2", Type: gosnmp.IPAddress, Value: "192.168.1.2"},
+			gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.15.3.1.2.192.168.1.2", Type: gosnmp.Integer, Value: state},
+		)
+	}
+	e.Process(bgpPacket(3))
+	e.Process(bgpPacket(2))
+	// the trap isn't in the trap database, it's matched by OID
+	e.Process(v2Packet(".1.3.6.1.4.1.99999.0.1",
+		gosnmp.SnmpPDU{Name: ".1.3.6.1.4.1.99999.1.1.10.0", Type: gosnmp.Gauge32, Value: uint(42)},
+		gosnmp.SnmpPDU{Name: ".1.3.6.1.4.1.99999.1.1.2.0", Type: gosnmp.OctetString, Value: []byte{0xc0}},
+	))
+
+	deviceTags := []string{"snmp_version:2", "device_namespace:default", "snmp_device:10.0.0.1"}
+	bgpTags := append(deviceTags, "peer:192.168.1.2", "state:active")
+	sender.AssertMetric(t, "Count", "snmp_traps.bgp.backward_transition", 1, "", bgpTags)
+	// gauges take the number of enumerations
+	sender.AssertMetric(t, "Gauge", "snmp_traps.bgp.peer_state", 3, "", bgpTags)
+	sender.AssertNotCalled(t, "Gauge", "snmp_traps.bgp.peer_state", 2.0, "", mocksender.MatchTagsContains(deviceTags))
+	sender.AssertMetric(t, "Gauge", "snmp_traps.acme.temperature", 42, "", deviceTags)
+
+	events := sentEvents(sender)
+	require.Len(t, events, 2)
+	assert.Equal(t, "BGP peer 192.168.1.2 went active ({unknown})", events[0].Title)
+	assert.Equal(t, event.AlertTypeWarning, events[0].AlertType)
+	assert.Empty(t, events[0].AggregationKey)
+	assert.Equal(t, "1.3.6.1.4.1.99999.0.1 on 10.0.0.1", events[1].Title)
+	assert.Equal(t, "failed parts: fan,power", events[1].Text)
+	assert.Equal(t, event.AlertTypeInfo, events[1].AlertType)
+}
+
+func TestV1Trap(t *testing.T) {
+	e, sender, _ := newTestEngine(t, []config.TrapRule{{
+		Trap:    "IF-MIB::linkDown",
+		Tags:    map[string]string{"interface_index": "ifIndex"},
+		Metrics: []config.RuleMetric{{Name: "snmp_traps.interface.link_down"}},
+	}})
+	e.Process(packet.CreateTestV1GenericPacket())
+	sender.AssertMetric(t, "Count", "snmp_traps.interface.link_down", 1, "", []string{"snmp_version:1", "interface_index:2"})
+}
+
+func TestInvalidRules(t *testing.T) {
+	sender := mocksender.NewMockSender("rules")
+	sender.SetupAcceptAll()
+	e, err := NewEngine([]config.TrapRule{
+		{Name: "no_trap", Metrics: []config.RuleMetric{{Name: "a"}}},
+		{Name: "no_output", Trap: "linkDown"},
+		{Name: "bad_metric", Trap: "linkDown", Metrics: []config.RuleMetric{{Name: "a", Type: "rate"}}},
+		{Name: "bad_gauge", Trap: "linkDown", Metrics: []config.RuleMetric{{Name: "a", Type: "gauge"}}},
+		{Name: "bad_severity", Trap: "linkDown", Event: &config.RuleEvent{Severity: "fatal"}},
+		{Name: "bad_alarm", Trap: "linkDown", Alarm: &config.RuleAlarm{}},
+		{Name: "bad_match", Trap: "linkDown", Alarm: &config.RuleAlarm{Name: "a"}, Match: []config.RuleMatch{{Variable: "ifIndex"}}},
+		{Trap: "linkDown", Metrics: []config.RuleMetric{{Name: "a"}}},
+	}, newTestResolver(), sender, logmock.New(t))
+
+	assert.EqualError(t, err, `invalid trap rule 0 (no_trap): trap is required
+invalid trap rule 1 (no_output): a rule needs metrics, an event or an alarm
+invalid trap rule 2 (bad_metric): metric a has invalid type "rate", expected count or gauge
+invalid trap rule 3 (bad_gauge): gauge a needs a value
+invalid trap rule 4 (bad_severity): invalid severity "fatal", expected critical, error, warning, info or ok
+invalid trap rule 5 (bad_alarm): alarm has no name
+invalid trap rule 6 (bad_match): match needs a variable and values`)
+	require.Len(t, e.rules, 1)
+	assert.Equal(t, "linkDown", e.rules[0].Name)
+	assert.False(t, e.Empty())
+
+	empty, err := NewEngine(nil, newTestResolver(), sender, logmock.New(t))
+	require.NoError(t, err)
+	assert.True(t, empty.Empty())
+	empty.Process(linkPacket("1.3.6.1.6.3.1.1.5.3", 3, 1))
+	empty.ReportTelemetry()
+	sender.AssertNotCalled(t, "Gauge", telemetryActiveAlarms, 0.0, "", []string(nil))
+}
@@ -0,0 +1,223 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/DataDog/datadog-agent/comp/snmptraps/oidresolver"
+	"github.com/DataDog/datadog-agent/comp/snmptraps/packet"
+)
+
+const (
+	sysUpTimeInstanceOID = "1.3.6.1.2.1.1.3.0"
+	snmpTrapOID          = "1.3.6.1.6.3.1.1.4.1.0"
+	genericTrapOID       = "1.3.6.1.6.3.1.1.5"
+)
+
+// trap is a trap resolved with the trap database, as seen by the rules
+type trap struct {
+	oid       string
+	name      string
+	mib       string
+	device    string
+	namespace string
+	tags      []string
+	variables []variable
+}
+
+// variable is a variable of a trap
+type variable struct {
+	// oid is the OID of the variable, including its index
+	oid  string
+	name string
+	raw  interface{}
+	// value is the formatted value of the variable, enumerations and bits are resolved to their names
+	value string
+}
+
+func newTrap(p *packet.SnmpPacket, resolver oidresolver.Component) (*trap, error) {
+	t := &trap{
+		device:    p.Addr.IP.String(),
+		namespace: p.Namespace,
+		tags:      p.GetTags(),
+	}
+	content := p.Content
+	pdus := content.Variables
+	if content.Version == gosnmp.Version1 {
+		enterprise := oidresolver.NormalizeOID(content.Enterprise)
+		if content.GenericTrap == 6 {
+			t.oid = fmt.Sprintf("%s.0.%d", enterprise, content.SpecificTrap)
+		} else {
+			t.oid = fmt.Sprintf("%s.%d", genericTrapOID, content.GenericTrap+1)
+		}
+	} else {
+		pdus = nil
+		for _, pdu := range content.Variables {
+			switch oidresolver.NormalizeOID(pdu.Name) {
+			case sysUpTimeInstanceOID:
+			case snmpTrapOID:
+				switch value := pdu.Value.(type) {
+				case string:
+					t.oid = oidresolver.NormalizeOID(value)
+				case []byte:
+					t.oid = oidresolver.NormalizeOID(string(value))
+				}
+			default:
+				pdus = append(pdus, pdu)
+			}
+		}
+		if t.oid == "" {
+			return nil, fmt.Errorf("no snmpTrapOID variable in trap from %s", t.device)
+		}
+	}
+
+	if metadata, err := resolver.GetTrapMetadata(t.oid); err == nil {
+		t.name = metadata.Name
+		t.mib = metadata.MIBName
+	}
+	for _, pdu := range pdus {
+		v := variable{
+			oid: oidresolver.NormalizeOID(pdu.Name),
+			raw: pdu.Value,
+		}
+		v.value = formatValue(pdu)
+		if metadata, err := resolver.GetVariableMetadata(t.oid, v.oid); err == nil {
+			v.name = metadata.Name
+			v.value = enrichValue(v, metadata)
+		}
+		t.variables = append(t.variables, v)
+	}
+	return t, nil
+}
+
+// displayName is the name of the trap, or its OID if it isn't in the trap database
+func (t *trap) displayName() string {
+	if t.name != "" {
+		return t.name
+	}
+	return t.oid
+}
+
+// lookup returns the variable referenced by name or by OID. The device, namespace, trap, trap_oid and mib references
+// return the attributes of the trap.
+func (t *trap) lookup(ref string) (variable, bool) {
+	switch ref {
+	case "device":
+		return variable{value: t.device, raw: t.device}, true
+	case "namespace":
+		return variable{value: t.namespace, raw: t.namespace}, true
+	case "trap":
+		return variable{value: t.displayName(), raw: t.displayName()}, true
+	case "trap_oid":
+		return variable{value: t.oid, raw: t.oid}, true
+	case "mib":
+		return variable{value: t.mib, raw: t.mib}, t.mib != ""
+	}
+	for _, v := range t.variables {
+		if v.name == ref {
+			return v, true
+		}
+	}
+	if oid := oidresolver.NormalizeOID(ref); oidresolver.IsValidOID(oid) {
+		// the OID of the variable may have an index
+		for _, v := range t.variables {
+			if v.oid == oid || strings.HasPrefix(v.oid, oid+".") {
+				return v, true
+			}
+		}
+	}
+	return variable{}, false
+}
+
+func formatValue(pdu gosnmp.SnmpPDU) string {
+	switch value := pdu.Value.(type) {
+	case []byte:
+		return string(value)
+	case string:
+		if pdu.Type == gosnmp.ObjectIdentifier {
+			return oidresolver.NormalizeOID(value)
+		}
+		return value
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// enrichValue resolves the names of the values of enumerations and bits
+func enrichValue(v variable, metadata oidresolver.VariableMetadata) string {
+	if len(metadata.Enumeration) > 0 {
+		if i, ok := v.raw.(int); ok {
+			if name, ok := metadata.Enumeration[i]; ok {
+				return name
+			}
+		}
+		return v.value
+	}
+	if len(metadata.Bits) > 0 {
+		bytes, ok := v.raw.([]byte)
+		if !ok {
+			return v.value
+		}
+		var names []string
+		for i, b := range bytes {
+			for j := 0; j < 8; j++ {
+				if b&(1<<(7-j)) == 0 {
+					continue
+				}
+				position := i*8 + j
+				if name, ok := metadata.Bits[position]; ok {
+					names = append(names, name)
+				} else {
+					names = append(names, strconv.Itoa(position))
+				}
+			}
+		}
+		return strings.Join(names, ",")
+	}
+	return v.value
+}
+
+// toFloat returns the numeric value of a variable
+func toFloat(v variable) (float64, bool) {
+	switch value := v.raw.(type) {
+	case int:
+		return float64(value), true
+	case int32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case uint:
+		return float64(value), true
+	case uint32:
+		return float64(value), true
+	case uint64:
+		return float64(value), true
+	case float32:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	f, err := strconv.ParseFloat(v.value, 64)
+	return f, err == nil
+}
+
+// sortedKeys returns the keys of a map in alphabetical order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -27,6 +27,8 @@ type Context struct {
 	metricTags *tags.Entry
 	noIndex    bool
 	source     metrics.MetricSource
+	// histogramConfig overrides the global histogram configuration for this context
+	histogramConfig *metrics.HistogramConfig
 }
 
 type resolverEntry struct {
@@ -75,6 +77,7 @@ type contextResolver struct {
 	keyGenerator     *ckey.KeyGenerator
 	taggerBuffer     *tagset.HashingTagsAccumulator
 	metricBuffer     *tagset.HashingTagsAccumulator
+	histogramConfigs *metrics.HistogramConfigResolver
 }
 
 // generateContextKey generates the contextKey associated with the context of the metricSample
@@ -82,7 +85,7 @@ func (cr *contextResolver) generateContextKey(metricSampleContext metrics.Metric
 	return cr.keyGenerator.GenerateWithTags2(metricSampleContext.GetName(), metricSampleContext.GetHost(), cr.taggerBuffer, cr.metricBuffer)
 }
 
-func newContextResolver(cache *tags.Store, id string) *contextResolver {
+func newContextResolver(cache *tags.Store, id string, histogramConfigs *metrics.HistogramConfigResolver) *contextResolver {
 	return &contextResolver{
 		id:               id,
 		contextsByKey:    make(map[ckey.ContextKey]resolverEntry),
@@ -94,6 +97,7 @@ func newContextResolver(cache *tags.Store, id string) *contextResolver {
 		keyGenerator:     ckey.NewKeyGenerator(),
 		taggerBuffer:     tagset.NewHashingTagsAccumulator(),
 		metricBuffer:     tagset.NewHashingTagsAccumulator(),
+		histogramConfigs: histogramConfigs,
 	}
 }
 
@@ -116,6 +120,10 @@ func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSample
 			noIndex:    metricSampleContext.IsNoIndex(),
 			source:     metricSampleContext.GetSource(),
 		}
+		if mtype == metrics.HistogramType || mtype == metrics.HistorateType {
+			// Resolved once per context so that histogram samples don't pay for matching the overrides
+			context.histogramConfig = cr.histogramConfigs.Resolve(context.Name)
+		}
 		cr.contextsByKey[contextKey] = resolverEntry{
 			lastSeen: timestamp,
 			context:  context,
@@ -141,6 +149,19 @@ func (cr *contextResolver) get(key ckey.ContextKey) (*Context, bool) {
 	return ctx.context, found
 }
 
+// HistogramConfig returns the histogram configuration override of the given context, if any.
+// It implements metrics.HistogramConfigGetter and is only called when a new histogram is created.
+func (cr *contextResolver) HistogramConfig(key ckey.ContextKey) *metrics.HistogramConfig {
+	if cr.histogramConfigs.Empty() {
+		return nil
+	}
+	context, ok := cr.get(key)
+	if !ok {
+		return nil
+	}
+	return context.histogramConfig
+}
+
 func (cr *contextResolver) length() int {
 	return len(cr.contextsByKey)
 }
@@ -219,9 +240,9 @@ type timestampContextResolver struct {
 	counterExpireTime int64
 }
 
-func newTimestampContextResolver(cache *tags.Store, id string, contextExpireTime, counterExpireTime int64) *timestampContextResolver {
+func newTimestampContextResolver(cache *tags.Store, id string, contextExpireTime, counterExpireTime int64, histogramConfigs *metrics.HistogramConfigResolver) *timestampContextResolver {
 	return &timestampContextResolver{
-		resolver: newContextResolver(cache, id),
+		resolver: newContextResolver(cache, id, histogramConfigs),
 
 		contextExpireTime: contextExpireTime,
 		counterExpireTime: counterExpireTime,
@@ -279,9 +300,9 @@ type countBasedContextResolver struct {
 	expireCountInterval int64
 }
 
-func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store, id string) *countBasedContextResolver {
+func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store, id string, histogramConfigs *metrics.HistogramConfigResolver) *countBasedContextResolver {
 	return &countBasedContextResolver{
-		resolver:            newContextResolver(cache, id),
+		resolver:            newContextResolver(cache, id, histogramConfigs),
 		expireCount:         0,
 		expireCountInterval: int64(expireCountInterval),
 	}
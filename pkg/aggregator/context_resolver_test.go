@@ -74,7 +74,7 @@ func testTrackContext(t *testing.T, store *tags.Store) {
 		SampleRate: 1,
 	}
 
-	contextResolver := newContextResolver(store, "test")
+	contextResolver := newContextResolver(store, "test", nil)
 
 	// Track the 2 contexts
 	contextKey1 := contextResolver.trackContext(&mSample1, 0)
@@ -97,8 +97,8 @@ func testTrackContext(t *testing.T, store *tags.Store) {
 
 	// If the struct changes it's ok to change these, but be careful if you notice that
 	// the size increases a lot.
-	assert.Equal(t, uint64(0x90), contextResolver.bytesByMtype[metrics.GaugeType])
-	assert.Equal(t, uint64(0x48), contextResolver.bytesByMtype[metrics.CountType])
+	assert.Equal(t, uint64(0xa0), contextResolver.bytesByMtype[metrics.GaugeType])
+	assert.Equal(t, uint64(0x50), contextResolver.bytesByMtype[metrics.CountType])
 	assert.Equal(t, uint64(0), contextResolver.bytesByMtype[metrics.RateType])
 	assert.Equal(t, uint64(0x2b), contextResolver.dataBytesByMtype[metrics.GaugeType])
 	assert.Equal(t, uint64(0x26), contextResolver.dataBytesByMtype[metrics.CountType])
@@ -138,7 +138,7 @@ func testExpireContexts(t *testing.T, store *tags.Store) {
 		Tags:       []string{"foo"},
 		SampleRate: 1,
 	}
-	contextResolver := newTimestampContextResolver(store, "test", 2, 4)
+	contextResolver := newTimestampContextResolver(store, "test", 2, 4, nil)
 
 	// Track the 2 contexts
 	contextKey1 := contextResolver.trackContext(&mSample1, 4) // expires after 6
@@ -190,7 +190,7 @@ func testCountBasedExpireContexts(t *testing.T, store *tags.Store) {
 	mSample1 := metrics.MetricSample{Name: "my.metric.name1"}
 	mSample2 := metrics.MetricSample{Name: "my.metric.name2"}
 	mSample3 := metrics.MetricSample{Name: "my.metric.name3"}
-	contextResolver := newCountBasedContextResolver(2, store, "test")
+	contextResolver := newCountBasedContextResolver(2, store, "test", nil)
 
 	contextKey1 := contextResolver.trackContext(&mSample1)
 	contextKey2 := contextResolver.trackContext(&mSample2)
@@ -215,7 +215,7 @@ func TestCountBasedExpireContexts(t *testing.T) {
 }
 
 func testTagDeduplication(t *testing.T, store *tags.Store) {
-	resolver := newContextResolver(store, "test")
+	resolver := newContextResolver(store, "test", nil)
 
 	ckey := resolver.trackContext(&metrics.MetricSample{
 		Name: "foo",
@@ -253,7 +253,7 @@ func (s *mockSample) GetTags(tb, mb tagset.TagsAccumulator, _ metrics.EnrichTags
 }
 
 func TestOriginTelemetry(t *testing.T) {
-	r := newContextResolver(tags.NewStore(true, "test"), "test")
+	r := newContextResolver(tags.NewStore(true, "test"), "test", nil)
 	r.trackContext(&mockSample{"foo", []string{"foo"}, []string{"ook"}}, 0)
 	r.trackContext(&mockSample{"foo", []string{"foo"}, []string{"eek"}}, 0)
 	r.trackContext(&mockSample{"foo", []string{"bar"}, []string{"ook"}}, 0)
@@ -111,6 +111,8 @@ type dataOutputs struct {
 	forwarders       forwarders
 	sharedSerializer serializer.MetricSerializer
 	noAggSerializer  serializer.MetricSerializer
+	// openMetrics is nil unless the local OpenMetrics endpoint is enabled
+	openMetrics *openMetricsEndpoint
 }
 
 // InitAndStartAgentDemultiplexer creates a new Demultiplexer and runs what's necessary
@@ -206,6 +208,7 @@ func initAgentDemultiplexer(
 
 			sharedSerializer: sharedSerializer,
 			noAggSerializer:  noAggSerializer,
+			openMetrics:      newOpenMetricsEndpoint(config.Datadog()),
 		},
 
 		senders: newSenders(agg),
@@ -270,6 +273,10 @@ func (d *AgentDemultiplexer) run() {
 		d.log.Debug("Forwarders started")
 	}
 
+	if d.openMetrics != nil {
+		d.openMetrics.start(d.log)
+	}
+
 	for _, w := range d.statsd.workers {
 		go w.run()
 	}
@@ -361,6 +368,9 @@ func (d *AgentDemultiplexer) Stop(flush bool) {
 
 	// misc
 
+	if d.dataOutputs.openMetrics != nil {
+		d.dataOutputs.openMetrics.stop()
+	}
 	d.dataOutputs.sharedSerializer = nil
 	d.senders = nil
 }
@@ -442,9 +452,15 @@ func (d *AgentDemultiplexer) flushToSerializer(start time.Time, waitForSerialize
 				<-t.trigger.blockChan
 			}
 		}, func(serieSource metrics.SerieSource) {
+			if d.openMetrics != nil {
+				serieSource = d.openMetrics.exporter.RecordSeries(serieSource)
+			}
 			sendIterableSeries(d.sharedSerializer, start, serieSource)
 		},
 		func(sketches metrics.SketchesSource) {
+			if d.openMetrics != nil {
+				sketches = d.openMetrics.exporter.RecordSketches(sketches)
+			}
 			// Don't send empty sketches payloads
 			if sketches.WaitForValue() {
 				err := d.sharedSerializer.SendSketch(sketches)
@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/serializer/openmetrics"
+)
+
+// openMetricsEndpoint exposes the metrics of the last flush on a local HTTP endpoint.
+type openMetricsEndpoint struct {
+	exporter *openmetrics.Exporter
+	server   *http.Server
+}
+
+// newOpenMetricsEndpoint returns nil when the endpoint is disabled.
+func newOpenMetricsEndpoint(cfg model.Reader) *openMetricsEndpoint {
+	if !cfg.GetBool("openmetrics_endpoint.enabled") {
+		return nil
+	}
+
+	exporter := openmetrics.NewExporter(cfg.GetStringSlice("openmetrics_endpoint.allowlist"))
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+
+	return &openMetricsEndpoint{
+		exporter: exporter,
+		server: &http.Server{
+			Addr:              net.JoinHostPort(cfg.GetString("openmetrics_endpoint.host"), strconv.Itoa(cfg.GetInt("openmetrics_endpoint.port"))),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+func (e *openMetricsEndpoint) start(log log.Component) {
+	listener, err := net.Listen("tcp", e.server.Addr)
+	if err != nil {
+		log.Errorf("Unable to start the OpenMetrics endpoint: %v", err)
+		return
+	}
+	log.Infof("OpenMetrics endpoint listening on http://%s/metrics", listener.Addr())
+
+	go func() {
+		if err := e.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("OpenMetrics endpoint stopped: %v", err)
+		}
+	}()
+}
+
+func (e *openMetricsEndpoint) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = e.server.Shutdown(ctx)
+}
@@ -17,6 +17,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
 	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
@@ -503,6 +504,39 @@ func TestBucketSamplingWithSketchAndSeries(t *testing.T) {
 	testWithTagsStore(t, testBucketSamplingWithSketchAndSeries)
 }
 
+func testHistogramOverrides(t *testing.T, store *tags.Store) {
+	defer config.Datadog().SetWithoutSource("histogram_overrides", nil)
+	config.Datadog().SetWithoutSource("histogram_overrides", []map[string]interface{}{
+		{"match": "my.latency.*", "aggregates": []string{"count"}, "percentiles": []string{"0.99"}},
+	})
+	sampler := testTimeSampler(store)
+
+	for _, name := range []string{"my.latency.p", "my.other"} {
+		for i := 1; i <= 10; i++ {
+			sampler.sample(&metrics.MetricSample{
+				Name:       name,
+				Value:      float64(i),
+				Mtype:      metrics.HistogramType,
+				SampleRate: 1,
+			}, 12345.0)
+		}
+	}
+	series, _ := flushSerie(sampler, 12360.0)
+
+	var names []string
+	for _, serie := range series {
+		names = append(names, serie.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"my.latency.p.count", "my.latency.p.99percentile",
+		"my.other.max", "my.other.median", "my.other.avg", "my.other.count", "my.other.95percentile",
+	}, names)
+}
+
+func TestHistogramOverrides(t *testing.T) {
+	testWithTagsStore(t, testHistogramOverrides)
+}
+
 func testFlushMissingContext(t *testing.T, store *tags.Store) {
 	sampler := testTimeSampler(store)
 	sampler.sample(&metrics.MetricSample{
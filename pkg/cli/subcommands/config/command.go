@@ -7,13 +7,17 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 
 	"go.uber.org/fx"
 
 	"github.com/DataDog/datadog-agent/comp/core"
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	settingscomp "github.com/DataDog/datadog-agent/comp/core/settings"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config/settings"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
@@ -28,6 +32,9 @@ type cliParams struct {
 	// source enables detailed information about each source and its value
 	source bool
 
+	// provenance prints where the value of each setting comes from, and the values it overrides
+	provenance bool
+
 	// args are the positional command line args
 	args []string
 }
@@ -72,6 +79,7 @@ func MakeCommand(globalParamsGetter func() GlobalParams) *cobra.Command {
 		Long:  ``,
 		RunE:  oneShotRunE(showRuntimeConfiguration),
 	}
+	cmd.Flags().BoolVar(&cliParams.provenance, "provenance", false, "print, for each setting not left to its default, the source file or environment variable of its value and the values it overrides")
 
 	listRuntimeCmd := &cobra.Command{
 		Use:   "list-runtime",
@@ -112,6 +120,15 @@ func showRuntimeConfiguration(_ log.Component, config config.Component, cliParam
 		return err
 	}
 
+	if cliParams.provenance {
+		provenances, err := c.FullConfigProvenance()
+		if err != nil {
+			return err
+		}
+		printProvenance(os.Stdout, provenances)
+		return nil
+	}
+
 	runtimeConfig, err := c.FullConfig()
 	if err != nil {
 		return err
@@ -217,3 +234,29 @@ func getConfigValue(_ log.Component, config config.Component, cliParams *cliPara
 
 	return nil
 }
+
+// printProvenance prints the value in effect of each setting with where it comes from, followed by the values it
+// overrides from the highest to the lowest priority
+func printProvenance(w io.Writer, provenances []settingscomp.SettingProvenance) {
+	for _, provenance := range provenances {
+		fmt.Fprintf(w, "%s: %s (%s)\n", provenance.Key, formatProvenanceValue(provenance.Value), describeProvenance(provenance.ProvenanceValue))
+		for _, overridden := range provenance.Overridden {
+			fmt.Fprintf(w, "    overrides %s (%s)\n", formatProvenanceValue(overridden.Value), describeProvenance(overridden))
+		}
+	}
+}
+
+func formatProvenanceValue(value interface{}) string {
+	formatted, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(formatted)
+}
+
+func describeProvenance(value settingscomp.ProvenanceValue) string {
+	if value.Origin == "" {
+		return string(value.Source)
+	}
+	return fmt.Sprintf("%s: %s", value.Source, value.Origin)
+}
@@ -6,6 +6,7 @@
 package config
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -13,6 +14,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/core"
 	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/comp/core/settings"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
 
@@ -33,6 +36,46 @@ func TestConfigCommand(t *testing.T) {
 		})
 }
 
+func TestConfigProvenanceCommand(t *testing.T) {
+	commands := []*cobra.Command{
+		MakeCommand(func() GlobalParams {
+			return GlobalParams{}
+		}),
+	}
+
+	fxutil.TestOneShotSubcommand(t,
+		commands,
+		[]string{"config", "--provenance"},
+		showRuntimeConfiguration,
+		func(cliParams *cliParams, _ core.BundleParams, _ secrets.Params) {
+			require.True(t, cliParams.provenance)
+		})
+}
+
+func TestPrintProvenance(t *testing.T) {
+	var out bytes.Buffer
+	printProvenance(&out, []settings.SettingProvenance{
+		{
+			Key:             "log_level",
+			ProvenanceValue: settings.ProvenanceValue{Value: "debug", Source: model.SourceEnvVar, Origin: "DD_LOG_LEVEL"},
+			Overridden: []settings.ProvenanceValue{
+				{Value: "warn", Source: model.SourceFile, Origin: "/etc/datadog-agent/datadog.yaml.d/10-team.yaml"},
+				{Value: "info", Source: model.SourceDefault},
+			},
+		},
+		{
+			Key:             "tags",
+			ProvenanceValue: settings.ProvenanceValue{Value: []interface{}{"env:prod"}, Source: model.SourceAgentRuntime},
+		},
+	})
+
+	require.Equal(t, `log_level: "debug" (environment-variable: DD_LOG_LEVEL)
+    overrides "warn" (file: /etc/datadog-agent/datadog.yaml.d/10-team.yaml)
+    overrides "info" (default)
+tags: ["env:prod"] (agent-runtime)
+`, out.String())
+}
+
 func TestConfigListRuntimeCommand(t *testing.T) {
 	commands := []*cobra.Command{
 		MakeCommand(func() GlobalParams {
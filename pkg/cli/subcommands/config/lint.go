@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/pkg/config/lint"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// lintParams are the command-line arguments for the lint subcommand
+type lintParams struct {
+	GlobalParams
+
+	// jsonOutput prints the report as JSON
+	jsonOutput bool
+
+	// strict makes warnings fail the command too
+	strict bool
+
+	// confdPath overrides the confd_path of the configuration
+	confdPath string
+}
+
+// MakeLintCommand returns a `lint` command validating the configuration files of the agent, without starting it or
+// requiring it to run. It is meant to be added to the `config` command of the agent.
+func MakeLintCommand(globalParamsGetter func() GlobalParams) *cobra.Command {
+	params := &lintParams{}
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Validate the configuration files",
+		Long: `Validate the main configuration file and the integration configuration files.
+
+The main configuration file is checked for unknown keys, values of the wrong type, deprecated keys and keys overridden
+by environment variables. Integration configurations are checked for the init_config/instances structure and their
+logs configuration. The command fails when errors are found, or warnings with --strict.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			params.GlobalParams = globalParamsGetter()
+			// the configuration component isn't used: loading the configuration fails on the errors lint reports
+			return fxutil.OneShot(lintConfig, fx.Supply(params))
+		},
+	}
+	cmd.Flags().BoolVarP(&params.jsonOutput, "json", "j", false, "Print the findings as JSON.")
+	cmd.Flags().BoolVar(&params.strict, "strict", false, "Fail on warnings too.")
+	cmd.Flags().StringVar(&params.confdPath, "confd-path", "", "Integration configurations folder to lint instead of the confd_path of the configuration.")
+	return cmd
+}
+
+func lintConfig(params *lintParams) error {
+	opts := lint.Options{
+		ConfigFile: lintConfigFile(params.GlobalParams, config.DefaultConfPath),
+		Environ:    os.Environ(),
+	}
+	if params.confdPath != "" {
+		opts.ConfdPaths = []string{params.confdPath}
+	}
+
+	report := lint.Lint(opts)
+	if err := printLintReport(os.Stdout, report, params.jsonOutput); err != nil {
+		return err
+	}
+	if report.Errors > 0 || (params.strict && report.Warnings > 0) {
+		return fmt.Errorf("the configuration has %d error(s) and %d warning(s)", report.Errors, report.Warnings)
+	}
+	return nil
+}
+
+// lintConfigFile returns the path of the configuration file the agent would read, like the config component finds it
+func lintConfigFile(globalParams GlobalParams, defaultConfPath string) string {
+	confFilePath := globalParams.ConfFilePath
+	if strings.HasSuffix(confFilePath, ".yaml") || strings.HasSuffix(confFilePath, ".yml") {
+		return confFilePath
+	}
+
+	configName := globalParams.ConfigName
+	if configName == "" {
+		configName = "datadog"
+	}
+	dirs := []string{}
+	for _, dir := range []string{confFilePath, defaultConfPath} {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, dir := range dirs {
+		for _, ext := range []string{".yaml", ".yml"} {
+			path := filepath.Join(dir, configName+ext)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+	}
+	if len(dirs) == 0 {
+		return configName + ".yaml"
+	}
+	// the missing file is reported by the linter
+	return filepath.Join(dirs[0], configName+".yaml")
+}
+
+func printLintReport(w io.Writer, report lint.Report, jsonOutput bool) error {
+	if jsonOutput {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	for _, finding := range report.Findings {
+		location := finding.File
+		if location == "" {
+			location = "environment"
+		} else if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, finding.Line)
+		}
+		fmt.Fprintf(w, "%s: %s: %s (%s)\n", location, finding.Severity, finding.Message, finding.Rule)
+	}
+	if len(report.Findings) == 0 {
+		fmt.Fprintln(w, "No issues found")
+		return nil
+	}
+	fmt.Fprintf(w, "\n%d error(s), %d warning(s)\n", report.Errors, report.Warnings)
+	return nil
+}
@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config/lint"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestConfigLintCommand(t *testing.T) {
+	cmd := MakeCommand(func() GlobalParams { return GlobalParams{} })
+	cmd.AddCommand(MakeLintCommand(func() GlobalParams {
+		return GlobalParams{ConfFilePath: "/etc/datadog-agent/datadog.yaml"}
+	}))
+
+	fxutil.TestOneShotSubcommand(t,
+		[]*cobra.Command{cmd},
+		[]string{"config", "lint", "--json", "--strict", "--confd-path", "/tmp/conf.d"},
+		lintConfig,
+		func(params *lintParams) {
+			require.True(t, params.jsonOutput)
+			require.True(t, params.strict)
+			require.Equal(t, "/tmp/conf.d", params.confdPath)
+			require.Equal(t, "/etc/datadog-agent/datadog.yaml", params.ConfFilePath)
+		})
+}
+
+func TestLintConfigFile(t *testing.T) {
+	confDir := t.TempDir()
+	defaultDir := t.TempDir()
+
+	assert.Equal(t, "/custom/agent.yml", lintConfigFile(GlobalParams{ConfFilePath: "/custom/agent.yml"}, defaultDir))
+	assert.Equal(t, filepath.Join(confDir, "datadog.yaml"), lintConfigFile(GlobalParams{ConfFilePath: confDir}, defaultDir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(defaultDir, "datadog.yml"), nil, 0644))
+	assert.Equal(t, filepath.Join(defaultDir, "datadog.yml"), lintConfigFile(GlobalParams{ConfFilePath: confDir}, defaultDir))
+	assert.Equal(t, filepath.Join(defaultDir, "datadog.yml"), lintConfigFile(GlobalParams{}, defaultDir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(confDir, "datadog-cluster.yaml"), nil, 0644))
+	assert.Equal(t, filepath.Join(confDir, "datadog-cluster.yaml"), lintConfigFile(GlobalParams{ConfFilePath: confDir, ConfigName: "datadog-cluster"}, defaultDir))
+}
+
+func TestPrintLintReport(t *testing.T) {
+	report := lint.Report{
+		Findings: []lint.Finding{
+			{Key: "DD_UNKNOWN", Rule: lint.RuleUnknownEnvVar, Severity: lint.SeverityWarning, Message: "unknown environment variable DD_UNKNOWN"},
+			{File: "/etc/datadog-agent/datadog.yaml", Line: 3, Key: "log_levl", Rule: lint.RuleUnknownKey, Severity: lint.SeverityWarning, Message: "unknown configuration key log_levl"},
+			{File: "/etc/datadog-agent/conf.d/redis.yaml", Rule: lint.RuleInvalidYAML, Severity: lint.SeverityError, Message: "yaml: invalid"},
+		},
+		Errors:   1,
+		Warnings: 2,
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, printLintReport(&out, report, false))
+	assert.Equal(t, `environment: warning: unknown environment variable DD_UNKNOWN (unknown-env-var)
+/etc/datadog-agent/datadog.yaml:3: warning: unknown configuration key log_levl (unknown-key)
+/etc/datadog-agent/conf.d/redis.yaml: error: yaml: invalid (invalid-yaml)
+
+1 error(s), 2 warning(s)
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, printLintReport(&out, report, true))
+	var decoded lint.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, report, decoded)
+
+	out.Reset()
+	require.NoError(t, printLintReport(&out, lint.Report{Findings: []lint.Finding{}}, false))
+	assert.Equal(t, "No issues found\n", out.String())
+}
@@ -8,7 +8,12 @@ package workloadlist
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
 
 	"go.uber.org/fx"
 
@@ -29,7 +34,9 @@ import (
 type cliParams struct {
 	GlobalParams
 
-	verboseList bool
+	verboseList    bool
+	recordFilePath string
+	recordDuration time.Duration
 }
 
 // GlobalParams contains the values of agent-global Cobra flags.
@@ -51,7 +58,11 @@ func MakeCommand(globalParamsGetter func() GlobalParams) *cobra.Command {
 	workloadListCommand := &cobra.Command{
 		Use:   "workload-list",
 		Short: "Print the workload content of a running agent",
-		Long:  ``,
+		Long: `Print the workload content of a running agent.
+
+With --record, the events of the workload store are recorded to a file instead, for the
+given duration. The recording can be replayed in another agent with the
+workloadmeta.replay.file setting.`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			globalParams := globalParamsGetter()
 
@@ -73,6 +84,8 @@ func MakeCommand(globalParamsGetter func() GlobalParams) *cobra.Command {
 	}
 
 	workloadListCommand.Flags().BoolVarP(&cliParams.verboseList, "verbose", "v", false, "print out a full dump of the workload store")
+	workloadListCommand.Flags().StringVar(&cliParams.recordFilePath, "record", "", "record the events of the workload store to this file")
+	workloadListCommand.Flags().DurationVar(&cliParams.recordDuration, "duration", time.Minute, "duration of the recording")
 
 	return workloadListCommand
 }
@@ -86,6 +99,10 @@ func workloadList(_ log.Component, config config.Component, cliParams *cliParams
 		return err
 	}
 
+	if cliParams.recordFilePath != "" {
+		return workloadRecord(c, cliParams)
+	}
+
 	url, err := workloadURL(cliParams.verboseList)
 	if err != nil {
 		return err
@@ -130,3 +147,42 @@ func workloadURL(verbose bool) (string, error) {
 
 	return prefix, nil
 }
+
+func workloadRecord(c *http.Client, cliParams *cliParams) error {
+	if flavor.GetFlavor() == flavor.ClusterAgent {
+		return errors.New("recording the workload store is only supported by the node agent")
+	}
+
+	ipcAddress, err := pkgconfig.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("https://%v:%v/agent/workload-record?duration=%s", ipcAddress, pkgconfig.Datadog().GetInt("cmd_port"), cliParams.recordDuration)
+
+	f, err := os.Create(cliParams.recordFilePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+util.GetAuthToken())
+
+	fmt.Fprintf(color.Output, "Recording the workload store events to %s for %s\n", cliParams.recordFilePath, cliParams.recordDuration)
+	r, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query the agent (running?): %w", err)
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(r.Body)
+		return fmt.Errorf("the agent ran into an error while recording the workload store: %s", body)
+	}
+
+	// the events are streamed as they happen
+	_, err = io.Copy(f, r.Body)
+	return err
+}
@@ -7,6 +7,7 @@ package workloadlist
 
 import (
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
@@ -32,3 +33,20 @@ func TestCommand(t *testing.T) {
 			require.Equal(t, false, secretParams.Enabled)
 		})
 }
+
+func TestRecordCommand(t *testing.T) {
+	commands := []*cobra.Command{
+		MakeCommand(func() GlobalParams {
+			return GlobalParams{}
+		}),
+	}
+
+	fxutil.TestOneShotSubcommand(t,
+		commands,
+		[]string{"workload-list", "--record", "recording.jsonl", "--duration", "30s"},
+		workloadList,
+		func(cliParams *cliParams, _ core.BundleParams, _ secrets.Params) {
+			require.Equal(t, "recording.jsonl", cliParams.recordFilePath)
+			require.Equal(t, 30*time.Second, cliParams.recordDuration)
+		})
+}
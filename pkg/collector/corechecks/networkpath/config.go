@@ -31,6 +31,10 @@ type InstanceConfig struct {
 
 	Protocol string `yaml:"protocol"`
 
+	TCPMethod    string `yaml:"tcp_method"`
+	NumPaths     uint16 `yaml:"num_paths"`
+	ProbesPerHop uint8  `yaml:"probes_per_hop"`
+
 	SourceService      string `yaml:"source_service"`
 	DestinationService string `yaml:"destination_service"`
 
@@ -52,6 +56,9 @@ type CheckConfig struct {
 	DestinationService    string
 	MaxTTL                uint8
 	Protocol              payload.Protocol
+	TCPMethod             payload.TCPMethod
+	NumPaths              uint16
+	ProbesPerHop          uint8
 	TimeoutMs             uint
 	MinCollectionInterval time.Duration
 	Tags                  []string
@@ -82,6 +89,18 @@ func NewCheckConfig(rawInstance integration.Data, rawInitConfig integration.Data
 	c.MaxTTL = instance.MaxTTL
 	c.TimeoutMs = instance.TimeoutMs
 	c.Protocol = payload.Protocol(strings.ToUpper(instance.Protocol))
+	c.TCPMethod = payload.TCPMethod(strings.ToLower(instance.TCPMethod))
+	c.NumPaths = instance.NumPaths
+	c.ProbesPerHop = instance.ProbesPerHop
+
+	if c.TCPMethod != "" {
+		if c.TCPMethod != payload.TCPMethodSYN && c.TCPMethod != payload.TCPMethodSACK {
+			return nil, fmt.Errorf("invalid tcp_method %q, must be %q or %q", instance.TCPMethod, payload.TCPMethodSYN, payload.TCPMethodSACK)
+		}
+		if c.Protocol != payload.ProtocolTCP {
+			return nil, fmt.Errorf("tcp_method is only supported with the TCP protocol")
+		}
+	}
 
 	c.MinCollectionInterval = firstNonZero(
 		time.Duration(instance.MinCollectionInterval)*time.Second,
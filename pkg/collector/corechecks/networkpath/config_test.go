@@ -153,6 +153,66 @@ protocol: TCP
 				Protocol:              payload.ProtocolTCP,
 			},
 		},
+		{
+			name: "multipath config",
+			rawInstance: []byte(`
+hostname: 1.2.3.4
+protocol: tcp
+tcp_method: SACK
+num_paths: 8
+probes_per_hop: 3
+`),
+			rawInitConfig: []byte(``),
+			expectedConfig: &CheckConfig{
+				DestHostname:          "1.2.3.4",
+				MinCollectionInterval: time.Duration(60) * time.Second,
+				Namespace:             "my-namespace",
+				Protocol:              payload.ProtocolTCP,
+				TCPMethod:             payload.TCPMethodSACK,
+				NumPaths:              8,
+				ProbesPerHop:          3,
+			},
+		},
+		{
+			name: "icmp protocol",
+			rawInstance: []byte(`
+hostname: 1.2.3.4
+protocol: icmp
+`),
+			rawInitConfig: []byte(``),
+			expectedConfig: &CheckConfig{
+				DestHostname:          "1.2.3.4",
+				MinCollectionInterval: time.Duration(60) * time.Second,
+				Namespace:             "my-namespace",
+				Protocol:              payload.ProtocolICMP,
+			},
+		},
+		{
+			name: "invalid tcp_method",
+			rawInstance: []byte(`
+hostname: 1.2.3.4
+protocol: tcp
+tcp_method: fin
+`),
+			expectedError: `invalid tcp_method "fin", must be "syn" or "sack"`,
+		},
+		{
+			name: "tcp_method without tcp",
+			rawInstance: []byte(`
+hostname: 1.2.3.4
+protocol: udp
+tcp_method: sack
+`),
+			expectedError: "tcp_method is only supported with the TCP protocol",
+		},
+		{
+			name: "invalid probes_per_hop",
+			rawInstance: []byte(`
+hostname: 1.2.3.4
+probes_per_hop: 300
+`),
+			expectedError: "invalid instance config",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -38,6 +38,7 @@ type Check struct {
 	core.CheckBase
 	config        *CheckConfig
 	lastCheckTime time.Time
+	lastPath      *payload.NetworkPath
 	telemetryComp telemetryComp.Component
 }
 
@@ -56,6 +57,9 @@ func (c *Check) Run() error {
 		MaxTTL:       c.config.MaxTTL,
 		TimeoutMs:    c.config.TimeoutMs,
 		Protocol:     c.config.Protocol,
+		TCPMethod:    c.config.TCPMethod,
+		NumPaths:     c.config.NumPaths,
+		ProbesPerHop: c.config.ProbesPerHop,
 	}
 
 	tr, err := traceroute.New(cfg, c.telemetryComp)
@@ -74,6 +78,14 @@ func (c *Check) Run() error {
 	path.Destination.Service = c.config.DestinationService
 	path.Tags = commonTags
 
+	if c.lastPath != nil {
+		diff := payload.Diff(*c.lastPath, path)
+		path.Diff = &diff
+	}
+	lastPath := path
+	lastPath.Diff = nil
+	c.lastPath = &lastPath
+
 	// send to EP
 	err = c.SendNetPathMDToEP(senderInstance, path)
 	if err != nil {
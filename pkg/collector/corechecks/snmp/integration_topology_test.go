@@ -1423,7 +1423,7 @@ profiles:
 	sender.AssertEventPlatformEvent(t, compactEvent.Bytes(), "network-devices-metadata")
 }
 
-// we have different data for LLDP and CDP to test that we're only using LLDP to build the links
+// the CDP neighbor also reported by LLDP (RemoteDev2) is deduplicated, the other CDP neighbor is added to the LLDP links
 func TestTopologyPayload_LLDP_CDP(t *testing.T) {
 	timeNow = common.MockTimeNow
 	aggregator.NewBufferedAggregator(nil, nil, "", 1*time.Hour)
@@ -1776,7 +1776,7 @@ profiles:
 			{
 				Name:  "1.3.6.1.4.1.9.9.23.1.2.1.1.20.2.3",
 				Type:  gosnmp.OctetString,
-				Value: []byte{10, 10, 0, 132},
+				Value: []byte{10, 250, 0, 7},
 			},
 			{
 				Name:  "1.3.6.1.4.1.9.9.23.1.2.1.1.5.2.3",
@@ -1786,7 +1786,7 @@ profiles:
 			{
 				Name:  "1.3.6.1.4.1.9.9.23.1.2.1.1.6.2.3",
 				Type:  gosnmp.OctetString,
-				Value: []byte("K06-ITV.tine.no"),
+				Value: []byte("RemoteDev2-Name.example.com"),
 			},
 			{
 				Name:  "1.3.6.1.4.1.9.9.23.1.2.1.1.7.2.3",
@@ -2107,6 +2107,29 @@ profiles:
                     "description": "RemoteDev2-Port1-Description"
                 }
             }
+        },
+        {
+            "id": "profile-metadata:1.2.3.4:1.5",
+            "source_type": "cdp",
+            "local": {
+                "device": {
+                    "dd_id": "profile-metadata:1.2.3.4"
+                },
+                "interface": {
+                    "dd_id": "profile-metadata:1.2.3.4:1",
+                    "id": ""
+                }
+            },
+            "remote": {
+                "device": {
+                    "id": "K10-ITV.tine.no",
+                    "ip_address": "10.10.0.134"
+                },
+                "interface": {
+                    "id": "GE0/1",
+                    "id_type": "interface_name"
+                }
+            }
         }
   ],
   "diagnoses": [
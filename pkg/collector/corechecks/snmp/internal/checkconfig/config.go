@@ -30,6 +30,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/pinger"
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
 	netutils "github.com/DataDog/datadog-agent/pkg/networkdevice/utils"
+	"github.com/DataDog/datadog-agent/pkg/snmp/snmpdiscovery"
 	"github.com/DataDog/datadog-agent/pkg/snmp/snmpintegration"
 	"github.com/DataDog/datadog-agent/pkg/snmp/utils"
 
@@ -148,6 +149,12 @@ type InstanceConfig struct {
 	Workers                  int      `yaml:"workers"`
 	Namespace                string   `yaml:"namespace"`
 
+	// DiscoveryMode is how the candidate devices of `network_address` are found, see snmpdiscovery.Mode
+	DiscoveryMode         string                          `yaml:"discovery_mode"`
+	DiscoverySeeds        []string                        `yaml:"discovery_seeds"`
+	DiscoveryCredentials  []snmpdiscovery.Credentials     `yaml:"discovery_credentials"`
+	DiscoveryFingerprints []snmpdiscovery.FingerprintRule `yaml:"discovery_fingerprints"`
+
 	// When DetectMetricsEnabled is enabled, instead of using profile detection using sysObjectID
 	// the integration will fetch OIDs from the devices and deduct which metrics  can be monitored (from all OOTB profile metrics definition)
 	DetectMetricsEnabled         *Boolean `yaml:"experimental_detect_metrics_enabled"`
@@ -212,6 +219,9 @@ type CheckConfig struct {
 	DiscoveryAllowedFailures int
 	InterfaceConfigs         []snmpintegration.InterfaceConfig
 
+	// Discovery is how the candidate devices of the network are found and probed
+	Discovery snmpdiscovery.Config
+
 	PingEnabled bool
 	PingConfig  pinger.Config
 }
@@ -448,6 +458,16 @@ func NewCheckConfig(rawInstance integration.Data, rawInitConfig integration.Data
 		c.IgnoredIPAddresses[ipAddress] = true
 	}
 
+	c.Discovery, err = snmpdiscovery.Settings{
+		Mode:         instance.DiscoveryMode,
+		Seeds:        instance.DiscoverySeeds,
+		Credentials:  instance.DiscoveryCredentials,
+		Fingerprints: instance.DiscoveryFingerprints,
+	}.Parse()
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Port == 0 {
 		c.Port = defaultPort
 	}
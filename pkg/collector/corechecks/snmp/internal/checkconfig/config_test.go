@@ -6,6 +6,7 @@
 package checkconfig
 
 import (
+	"net"
 	"regexp"
 	"testing"
 	"time"
@@ -13,12 +14,14 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/profile"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
 
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/pinger"
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
+	"github.com/DataDog/datadog-agent/pkg/snmp/snmpdiscovery"
 	"github.com/DataDog/datadog-agent/pkg/snmp/snmpintegration"
 )
 
@@ -283,6 +286,40 @@ workers: 30
 		"127.0.0.8": true,
 		"127.0.0.9": true,
 	}, config.IgnoredIPAddresses)
+	assert.Equal(t, snmpdiscovery.ModeSweep, config.Discovery.Mode)
+}
+
+func TestDiscoveryModeConfigurations(t *testing.T) {
+	// language=yaml
+	rawInstanceConfig := []byte(`
+network_address: 10.0.0.0/16
+community_string: public
+discovery_mode: hybrid
+discovery_seeds:
+  - 10.0.0.1
+  - 10.0.0.2
+discovery_credentials:
+  - community_string: private
+    snmp_version: 1
+  - user: admin
+    authProtocol: sha
+    authKey: secret
+discovery_fingerprints:
+  - sysobjectid: 1.3.6.1.4.1.9.*
+    sysdescr: NX-OS
+    profile: cisco-nexus
+`)
+	config, err := NewCheckConfig(rawInstanceConfig, []byte(``))
+	require.NoError(t, err)
+	assert.Equal(t, snmpdiscovery.ModeHybrid, config.Discovery.Mode)
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}, config.Discovery.Seeds)
+	assert.Equal(t, []snmpdiscovery.Credentials{
+		{Community: "private", Version: "1"},
+		{User: "admin", AuthProtocol: "sha", AuthKey: "secret"},
+	}, config.Discovery.Credentials)
+	require.Len(t, config.Discovery.Fingerprints, 1)
+	assert.Equal(t, "cisco-nexus", config.Discovery.Fingerprints[0].Profile)
+	assert.True(t, config.Discovery.Fingerprints[0].Matches(snmpdiscovery.Fingerprint{SysObjectID: "1.3.6.1.4.1.9.12.3.1.3.1812", SysDescr: "Cisco NX-OS"}))
 }
 
 func TestProfileNormalizeMetrics(t *testing.T) {
@@ -690,6 +727,46 @@ network_address: 10.0.0.0/xx
 				"couldn't parse SNMP network: invalid CIDR address: 10.0.0.0/xx",
 			},
 		},
+		{
+			name: "invalid discovery mode",
+			// language=yaml
+			rawInstanceConfig: []byte(`
+network_address: 10.0.0.0/24
+discovery_mode: arp
+`),
+			// language=yaml
+			rawInitConfig: []byte(``),
+			expectedErrors: []string{
+				`invalid discovery mode "arp", must be "sweep", "neighbors" or "hybrid"`,
+			},
+		},
+		{
+			name: "neighbors discovery without seeds",
+			// language=yaml
+			rawInstanceConfig: []byte(`
+network_address: 10.0.0.0/24
+discovery_mode: neighbors
+`),
+			// language=yaml
+			rawInitConfig: []byte(``),
+			expectedErrors: []string{
+				"`discovery_seeds` must be set when `discovery_mode` is `neighbors`",
+			},
+		},
+		{
+			name: "invalid discovery fingerprint",
+			// language=yaml
+			rawInstanceConfig: []byte(`
+network_address: 10.0.0.0/24
+discovery_fingerprints:
+  - sysobjectid: 1.3.6.1.4.1.9.*
+`),
+			// language=yaml
+			rawInitConfig: []byte(``),
+			expectedErrors: []string{
+				"discovery fingerprint 0: profile must be set",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -243,6 +243,17 @@ func (d *DeviceCheck) Run(collectionTime time.Time) error {
 	return checkErr
 }
 
+// ReportBackoff reports the device as unreachable without polling it, while its polls are backed off after
+// consecutive failures
+func (d *DeviceCheck) ReportBackoff(nextPoll time.Time) {
+	tags := append(d.config.GetStaticTags(), d.config.GetNetworkTags()...)
+	tags = append(tags, d.savedDynamicTags...)
+	message := fmt.Sprintf("device unreachable, next poll at %s", nextPoll.Format(time.RFC3339))
+	d.sender.ServiceCheck(serviceCheckName, servicecheck.ServiceCheckCritical, tags, message)
+	d.sender.Gauge(deviceReachableMetric, utils.BoolToFloat64(false), tags)
+	d.sender.Gauge(deviceUnreachableMetric, utils.BoolToFloat64(true), tags)
+}
+
 func (d *DeviceCheck) setDeviceHostExternalTags() {
 	deviceHostname, err := d.GetDeviceHostname()
 	if deviceHostname == "" || err != nil {
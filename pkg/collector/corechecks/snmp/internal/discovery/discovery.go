@@ -7,13 +7,13 @@
 package discovery
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
 	"time"
 
-	"github.com/DataDog/datadog-agent/pkg/persistentcache"
+	"github.com/DataDog/datadog-agent/pkg/snmp/snmpdiscovery"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/checkconfig"
@@ -22,7 +22,8 @@ import (
 )
 
 const cacheKeyPrefix = "snmp"
-const sysObjectIDOid = "1.3.6.1.2.1.1.2.0"
+
+var timeNow = time.Now
 
 // Discovery handles snmp discovery states
 type Discovery struct {
@@ -43,6 +44,8 @@ type Device struct {
 	deviceDigest checkconfig.DeviceDigest
 	deviceIP     string
 	deviceCheck  *devicecheck.DeviceCheck
+	// info is the device as persisted in the inventory
+	info snmpdiscovery.Device
 }
 type snmpSubnet struct {
 	config     *checkconfig.CheckConfig
@@ -58,11 +61,22 @@ type snmpSubnet struct {
 	// discoveredDevices contains device failures count with device deviceDigest as map key
 	// see also CheckConfig.DeviceDigest()
 	deviceFailures map[checkconfig.DeviceDigest]int
+
+	// credentialsFailures counts, per device, the consecutive probes that only succeeded with other credentials
+	credentialsFailures map[checkconfig.DeviceDigest]int
 }
 
 type checkDeviceJob struct {
 	subnet    *snmpSubnet
 	currentIP net.IP
+	source    snmpdiscovery.Source
+	// candidates is set when discovering neighbors, the neighbors of the device are added to it
+	candidates *snmpdiscovery.CandidateQueue
+}
+
+// sessionConfigError is returned when the session of a device can't be configured
+type sessionConfigError struct {
+	error
 }
 
 // Start discovery
@@ -103,6 +117,9 @@ func (d *Discovery) runWorker(w int, jobs <-chan checkDeviceJob) {
 			if err != nil {
 				log.Errorf("%s", err.Error())
 			}
+			if job.candidates != nil {
+				job.candidates.Done()
+			}
 		}
 	}
 }
@@ -127,8 +144,9 @@ func (d *Discovery) discoverDevices() {
 
 		// Since subnet devices fields (`devices` and `deviceFailures`) are changed at the same time
 		// as Discovery.discoveredDevices, we rely on Discovery.discDevMu mutex to protect against concurrent changes.
-		devices:        map[checkconfig.DeviceDigest]string{},
-		deviceFailures: map[checkconfig.DeviceDigest]int{},
+		devices:             map[checkconfig.DeviceDigest]string{},
+		deviceFailures:      map[checkconfig.DeviceDigest]int{},
+		credentialsFailures: map[checkconfig.DeviceDigest]int{},
 	}
 
 	d.loadCache(&subnet)
@@ -142,29 +160,37 @@ func (d *Discovery) discoverDevices() {
 	defer discoveryTicker.Stop()
 	for {
 		log.Debugf("subnet %s: Run discovery", d.config.Network)
-		startingIP := make(net.IP, len(subnet.startingIP))
-		copy(startingIP, subnet.startingIP)
-		for currentIP := startingIP; subnet.network.Contains(currentIP); incrementIP(currentIP) {
-
-			if ignored := subnet.config.IsIPIgnored(currentIP); ignored {
-				continue
-			}
-
-			jobIP := make(net.IP, len(currentIP))
-			copy(jobIP, currentIP)
+		run := snmpdiscovery.Run{
+			Network:   subnet.network,
+			IsIgnored: d.config.IsIPIgnored,
+			Config:    d.config.Discovery,
+			Known:     d.knownDevices(&subnet),
+		}
+		scheduled := snmpdiscovery.ScheduleCandidates(run, d.stop, func(candidate snmpdiscovery.Candidate, candidates *snmpdiscovery.CandidateQueue) bool {
 			job := checkDeviceJob{
-				subnet:    &subnet,
-				currentIP: jobIP,
+				subnet:     &subnet,
+				currentIP:  candidate.IP,
+				source:     candidate.Source,
+				candidates: candidates,
 			}
-			jobs <- job
-
 			select {
+			case jobs <- job:
+				return true
 			case <-d.stop:
-				log.Debugf("subnet %s: Stop scheduling devices", d.config.Network)
-				return
-			default:
+				return false
 			}
+		})
+		if !scheduled {
+			log.Debugf("subnet %s: Stop scheduling devices", d.config.Network)
+			return
+		}
+
+		// persist when the devices were last seen
+		d.discDevMu.Lock()
+		if len(subnet.devices) > 0 {
+			d.writeCache(&subnet)
 		}
+		d.discDevMu.Unlock()
 
 		select {
 		case <-d.stop:
@@ -175,62 +201,209 @@ func (d *Discovery) discoverDevices() {
 	}
 }
 
+// knownDevices returns the IPs of the devices discovered in the subnet
+func (d *Discovery) knownDevices(subnet *snmpSubnet) []net.IP {
+	d.discDevMu.RLock()
+	defer d.discDevMu.RUnlock()
+	ips := make([]net.IP, 0, len(subnet.devices))
+	for _, deviceIP := range subnet.devices {
+		if ip := net.ParseIP(deviceIP); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
 func (d *Discovery) checkDevice(job checkDeviceJob) error {
 	deviceIP := job.currentIP.String()
-	config := *job.subnet.config // shallow copy
-	config.IPAddress = deviceIP
-	sess, err := d.sessionFactory(&config)
+	configs := credentialConfigs(job.subnet.config, deviceIP)
+	deviceDigest := job.subnet.config.DeviceDigest(deviceIP)
+
+	// the credentials that worked last time are tried first, so that the device keeps them while they work
+	d.discDevMu.RLock()
+	previous := d.discoveredDevices[deviceDigest].info
+	d.discDevMu.RUnlock()
+	order := snmpdiscovery.ProbeOrder(len(configs), previous.Credentials)
+
+	// all the credentials sets are tried concurrently, the first one in order that works is used
+	i, fingerprint, err := snmpdiscovery.Probe(len(order), func(i int) (snmpdiscovery.Fingerprint, error) {
+		sess, err := d.sessionFactory(configs[order[i]])
+		if err != nil {
+			return snmpdiscovery.Fingerprint{}, sessionConfigError{fmt.Errorf("error configure session for ip %s: %v", deviceIP, err)}
+		}
+		if err := sess.Connect(); err != nil {
+			return snmpdiscovery.Fingerprint{}, fmt.Errorf("SNMP connect to %s error: %v", deviceIP, err)
+		}
+		defer sess.Close()
+		fingerprint, err := snmpdiscovery.FetchFingerprint(sess, snmpdiscovery.UsesSysDescr(job.subnet.config.Discovery.Fingerprints))
+		if err != nil {
+			return snmpdiscovery.Fingerprint{}, fmt.Errorf("SNMP get to %s error: %v", deviceIP, err)
+		}
+		return fingerprint, nil
+	})
 	if err != nil {
-		return fmt.Errorf("error configure session for ip %s: %v", deviceIP, err)
+		if errors.As(err, &sessionConfigError{}) {
+			return err
+		}
+		log.Debugf("subnet %s: %v", d.config.Network, err)
+		d.deleteDevice(deviceDigest, job.subnet)
+		return nil
+	}
+	log.Debugf("subnet %s: SNMP get to %s success: %v", d.config.Network, deviceIP, fingerprint.SysObjectID)
+	credentials := d.probedCredentials(deviceDigest, job.subnet, order[i])
+
+	if job.candidates != nil {
+		d.addNeighbors(job, configs[order[i]])
+	}
+
+	profile, _ := snmpdiscovery.MatchFingerprint(job.subnet.config.Discovery.Fingerprints, fingerprint)
+	now := timeNow()
+	d.createDevice(deviceDigest, job.subnet, snmpdiscovery.Device{
+		IP:          deviceIP,
+		SysObjectID: fingerprint.SysObjectID,
+		SysDescr:    fingerprint.SysDescr,
+		SysName:     fingerprint.SysName,
+		Profile:     profile,
+		Credentials: credentials,
+		Source:      job.source,
+		FirstSeen:   now,
+		LastSeen:    now,
+	}, true)
+	return nil
+}
+
+// probedCredentials returns the credentials to use for a device that answered a probe with the given credentials.
+// The previous credentials of the device are kept until they failed for the allowed failures count in a row while
+// other credentials answered, so that a transient failure doesn't replace the device check.
+func (d *Discovery) probedCredentials(deviceDigest checkconfig.DeviceDigest, subnet *snmpSubnet, credentials int) int {
+	d.discDevMu.Lock()
+	defer d.discDevMu.Unlock()
+	device, present := d.discoveredDevices[deviceDigest]
+	if !present || credentials == device.info.Credentials {
+		delete(subnet.credentialsFailures, deviceDigest)
+		return credentials
+	}
+	subnet.credentialsFailures[deviceDigest]++
+	allowedFailures := snmpdiscovery.CredentialsAllowedFailures(d.config.DiscoveryAllowedFailures)
+	if subnet.credentialsFailures[deviceDigest] < allowedFailures {
+		log.Debugf("subnet %s: device %s didn't answer with credentials %d, keeping them until they fail %d times", d.config.Network, device.deviceIP, device.info.Credentials, allowedFailures)
+		return device.info.Credentials
+	}
+	delete(subnet.credentialsFailures, deviceDigest)
+	return credentials
+}
+
+// addNeighbors adds the neighbors found in the tables of the device to the candidates
+func (d *Discovery) addNeighbors(job checkDeviceJob, config *checkconfig.CheckConfig) {
+	deviceIP := job.currentIP.String()
+	sess, err := d.sessionFactory(config)
+	if err != nil {
+		log.Debugf("subnet %s: error configure session for ip %s: %v", d.config.Network, deviceIP, err)
+		return
 	}
-	deviceDigest := job.subnet.config.DeviceDigest(deviceIP)
 	if err := sess.Connect(); err != nil {
 		log.Debugf("subnet %s: SNMP connect to %s error: %v", d.config.Network, deviceIP, err)
-		d.deleteDevice(deviceDigest, job.subnet)
-	} else {
-		defer sess.Close()
+		return
+	}
+	defer sess.Close()
 
-		oids := []string{sysObjectIDOid}
-		// Since `params<GoSNMP>.ContextEngineID` is empty
-		// `params.Get` might lead to multiple SNMP GET calls when using SNMP v3
-		// a first call might be needed to retrieve the engineID and then the call to get the oid values.
-		value, err := sess.Get(oids)
-		if err != nil {
-			log.Debugf("subnet %s: SNMP get to %s error: %v", d.config.Network, deviceIP, err)
-			d.deleteDevice(deviceDigest, job.subnet)
-		} else if len(value.Variables) < 1 || value.Variables[0].Value == nil {
-			log.Debugf("subnet %s: SNMP get to %s no data", d.config.Network, deviceIP)
-			d.deleteDevice(deviceDigest, job.subnet)
+	added := 0
+	neighbors := snmpdiscovery.FetchNeighbors(sess, snmpdiscovery.DefaultMaxNeighborRows)
+	for _, neighbor := range neighbors {
+		if job.candidates.Add(neighbor.IP, neighbor.Source) {
+			added++
+		}
+	}
+	log.Debugf("subnet %s: found %d neighbors of %s, %d new candidates", d.config.Network, len(neighbors), deviceIP, added)
+}
+
+// credentialConfigs returns the configs of the device for the credentials of the subnet followed by each
+// of the discovery credentials sets
+func credentialConfigs(config *checkconfig.CheckConfig, deviceIP string) []*checkconfig.CheckConfig {
+	base := *config // shallow copy
+	base.IPAddress = deviceIP
+	configs := []*checkconfig.CheckConfig{&base}
+	for _, credentials := range config.Discovery.Credentials {
+		credentialsConfig := base
+		applyCredentials(&credentialsConfig, credentials)
+		configs = append(configs, &credentialsConfig)
+	}
+	return configs
+}
+
+func applyCredentials(config *checkconfig.CheckConfig, credentials snmpdiscovery.Credentials) {
+	config.SnmpVersion = credentials.Version
+	config.CommunityString = credentials.Community
+	config.User = credentials.User
+	config.AuthKey = credentials.AuthKey
+	config.AuthProtocol = credentials.AuthProtocol
+	config.PrivKey = credentials.PrivKey
+	config.PrivProtocol = credentials.PrivProtocol
+	config.ContextName = credentials.ContextName
+}
+
+// deviceConfig returns the config of the device check, using the credentials that worked for the device
+// and the profile selected from its fingerprint
+func (d *Discovery) deviceConfig(subnet *snmpSubnet, info snmpdiscovery.Device) *checkconfig.CheckConfig {
+	config := subnet.config
+	if info.Credentials > 0 && info.Credentials <= len(subnet.config.Discovery.Credentials) {
+		config = config.Copy()
+		applyCredentials(config, subnet.config.Discovery.Credentials[info.Credentials-1])
+	}
+	// a profile or metrics configured on the instance take precedence over the fingerprint
+	if info.Profile != "" && config.AutodetectProfile && !config.DetectMetricsEnabled {
+		if config == subnet.config {
+			config = config.Copy()
+		}
+		if err := config.SetProfile(info.Profile); err != nil {
+			log.Warnf("subnet %s: device %s: couldn't use profile `%s` selected from the fingerprint: %s", d.config.Network, info.IP, info.Profile, err)
 		} else {
-			log.Debugf("subnet %s: SNMP get to %s success: %v", d.config.Network, deviceIP, value.Variables[0].Value)
-			d.createDevice(deviceDigest, job.subnet, deviceIP, true)
+			config.AutodetectProfile = false
 		}
 	}
-	return nil
+	return config
 }
 
-func (d *Discovery) createDevice(deviceDigest checkconfig.DeviceDigest, subnet *snmpSubnet, deviceIP string, writeCache bool) {
-	deviceCk, err := devicecheck.NewDeviceCheck(subnet.config, deviceIP, d.sessionFactory)
+func (d *Discovery) createDevice(deviceDigest checkconfig.DeviceDigest, subnet *snmpSubnet, info snmpdiscovery.Device, writeCache bool) {
+	d.discDevMu.Lock()
+	if device, present := d.discoveredDevices[deviceDigest]; present {
+		// keep when the device was first seen and how it was found
+		if !device.info.FirstSeen.IsZero() {
+			info.FirstSeen = device.info.FirstSeen
+		}
+		if device.info.Source != "" {
+			info.Source = device.info.Source
+		}
+		if info.Profile == device.info.Profile && info.Credentials == device.info.Credentials {
+			device.info = info
+			d.discoveredDevices[deviceDigest] = device
+			d.discDevMu.Unlock()
+			return
+		}
+		// the device check is replaced to use the new profile or credentials
+		log.Infof("subnet %s: device %s changed, using profile `%s` and credentials %d", d.config.Network, info.IP, info.Profile, info.Credentials)
+	}
+	d.discDevMu.Unlock()
+
+	deviceCk, err := devicecheck.NewDeviceCheck(d.deviceConfig(subnet, info), info.IP, d.sessionFactory)
 	if err != nil {
 		// should not happen since the deviceCheck is expected to be valid at this point
 		// and are only changing the device ip
-		log.Warnf("subnet %s: failed to create new device check `%s`: %s", d.config.Network, deviceIP, err)
+		log.Warnf("subnet %s: failed to create new device check `%s`: %s", d.config.Network, info.IP, err)
 		return
 	}
 
 	d.discDevMu.Lock()
 	defer d.discDevMu.Unlock()
 
-	if _, present := d.discoveredDevices[deviceDigest]; present {
-		return
-	}
 	device := Device{
 		deviceDigest: deviceDigest,
-		deviceIP:     deviceIP,
+		deviceIP:     info.IP,
 		deviceCheck:  deviceCk,
+		info:         info,
 	}
 	d.discoveredDevices[deviceDigest] = device
-	subnet.devices[deviceDigest] = deviceIP
+	subnet.devices[deviceDigest] = info.IP
 	subnet.deviceFailures[deviceDigest] = 0
 
 	if writeCache {
@@ -257,24 +430,14 @@ func (d *Discovery) deleteDevice(deviceDigest checkconfig.DeviceDigest, subnet *
 			delete(d.discoveredDevices, deviceDigest)
 			delete(subnet.devices, deviceDigest)
 			delete(subnet.deviceFailures, deviceDigest)
+			delete(subnet.credentialsFailures, deviceDigest)
 			d.writeCache(subnet)
 		}
 	}
 }
 
-func (d *Discovery) readCache(subnet *snmpSubnet) ([]net.IP, error) {
-	cacheValue, err := persistentcache.Read(subnet.cacheKey)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't read cache for %s: %s", subnet.cacheKey, err)
-	}
-	if cacheValue == "" {
-		return []net.IP{}, nil
-	}
-	var devices []net.IP
-	if err = json.Unmarshal([]byte(cacheValue), &devices); err != nil {
-		return nil, fmt.Errorf("couldn't unmarshal cache for %s: %s", subnet.cacheKey, err)
-	}
-	return devices, nil
+func (d *Discovery) readCache(subnet *snmpSubnet) ([]snmpdiscovery.Device, error) {
+	return snmpdiscovery.ReadInventory(subnet.cacheKey)
 }
 
 func (d *Discovery) loadCache(subnet *snmpSubnet) {
@@ -283,27 +446,23 @@ func (d *Discovery) loadCache(subnet *snmpSubnet) {
 		log.Errorf("subnet %s: error reading cache: %s", d.config.Network, err)
 		return
 	}
-	for _, deviceIP := range devices {
-		deviceDigest := subnet.config.DeviceDigest(deviceIP.String())
-		d.createDevice(deviceDigest, subnet, deviceIP.String(), false)
+	for _, device := range devices {
+		deviceDigest := subnet.config.DeviceDigest(device.IP)
+		d.createDevice(deviceDigest, subnet, device, false)
 	}
 }
 
 func (d *Discovery) writeCache(subnet *snmpSubnet) {
 	// We don't lock the subnet for now, because the discovery ought to be already locked
-	devices := make([]string, 0, len(subnet.devices))
-	for _, v := range subnet.devices {
-		devices = append(devices, v)
-	}
-
-	cacheValue, err := json.Marshal(devices)
-	if err != nil {
-		log.Errorf("subnet %s: Couldn't marshal cache: %s", d.config.Network, err)
-		return
+	devices := make([]snmpdiscovery.Device, 0, len(subnet.devices))
+	for deviceDigest, deviceIP := range subnet.devices {
+		info := d.discoveredDevices[deviceDigest].info
+		info.IP = deviceIP
+		devices = append(devices, info)
 	}
 
-	if err = persistentcache.Write(subnet.cacheKey, string(cacheValue)); err != nil {
-		log.Errorf("subnet %s: Couldn't write cache: %s", d.config.Network, err)
+	if err := snmpdiscovery.WriteInventory(subnet.cacheKey, devices); err != nil {
+		log.Errorf("subnet %s: %s", d.config.Network, err)
 	}
 }
 
@@ -9,15 +9,20 @@ import (
 	"fmt"
 	"net"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/gosnmp/gosnmp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/checkconfig"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/profile"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/session"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
+	"github.com/DataDog/datadog-agent/pkg/snmp/snmpdiscovery"
 )
 
 func waitForDiscoveredDevices(discovery *Discovery, expectedDeviceCount int, timeout time.Duration) error {
@@ -332,9 +337,9 @@ func TestDiscovery_createDevice(t *testing.T) {
 	device1Digest := subnet.config.DeviceDigest("192.168.0.1")
 	device2Digest := subnet.config.DeviceDigest("192.168.0.2")
 	device3Digest := subnet.config.DeviceDigest("192.168.0.3")
-	discovery.createDevice(device1Digest, subnet, "192.168.0.1", true)
-	discovery.createDevice(device2Digest, subnet, "192.168.0.2", true)
-	discovery.createDevice(device3Digest, subnet, "192.168.0.3", false)
+	discovery.createDevice(device1Digest, subnet, snmpdiscovery.Device{IP: "192.168.0.1"}, true)
+	discovery.createDevice(device2Digest, subnet, snmpdiscovery.Device{IP: "192.168.0.2"}, true)
+	discovery.createDevice(device3Digest, subnet, snmpdiscovery.Device{IP: "192.168.0.3"}, false)
 
 	assert.Equal(t, 3, len(discovery.discoveredDevices))
 
@@ -365,3 +370,208 @@ func TestDiscovery_createDevice(t *testing.T) {
 	discovery.deleteDevice(device1Digest, subnet) // really deletes the device
 	assert.Equal(t, 2, len(discovery.discoveredDevices))
 }
+
+func TestDiscovery_neighbors(t *testing.T) {
+	config.Datadog().SetWithoutSource("run_path", t.TempDir())
+
+	// 10.0.0.1 is the seed, its ARP table leads to 10.0.0.2 and 10.0.0.3 which isn't an SNMP device,
+	// the LLDP table of 10.0.0.2 leads to 10.0.0.4
+	devices := map[string][]gosnmp.SnmpPDU{
+		"10.0.0.1": {
+			{Name: "1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.4.1.9.1.1745"},
+			{Name: "1.3.6.1.2.1.4.22.1.3.2.10.0.0.2", Type: gosnmp.IPAddress, Value: "10.0.0.2"},
+			{Name: "1.3.6.1.2.1.4.22.1.3.2.10.0.0.3", Type: gosnmp.IPAddress, Value: "10.0.0.3"},
+			// out of the subnet
+			{Name: "1.3.6.1.2.1.4.22.1.3.3.10.1.0.1", Type: gosnmp.IPAddress, Value: "10.1.0.1"},
+		},
+		"10.0.0.2": {
+			{Name: "1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.4.1.3375.2.1.3.4.1"},
+			{Name: "1.0.8802.1.1.2.1.4.2.1.3.0.7.1.1.4.10.0.0.4", Type: gosnmp.Integer, Value: 2},
+		},
+		"10.0.0.4": {
+			{Name: "1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.4.1.9.1.1745"},
+		},
+	}
+	var mu sync.Mutex
+	probed := map[string]bool{}
+	deviceConfigs := map[string]*checkconfig.CheckConfig{}
+	sessionFactory := func(config *checkconfig.CheckConfig) (session.Session, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		probed[config.IPAddress] = true
+		deviceConfigs[config.IPAddress] = config
+		sess := session.CreateFakeSession()
+		sess.SetMany(devices[config.IPAddress]...)
+		return sess, nil
+	}
+
+	discoveryConfig, err := snmpdiscovery.Settings{
+		Mode:         "neighbors",
+		Seeds:        []string{"10.0.0.1"},
+		Fingerprints: []snmpdiscovery.FingerprintRule{{SysObjectID: "1.3.6.1.4.1.9.*", Profile: "cisco"}},
+	}.Parse()
+	require.NoError(t, err)
+	checkConfig := &checkconfig.CheckConfig{
+		Network:           "10.0.0.0/24",
+		CommunityString:   "public",
+		DiscoveryInterval: 3600,
+		DiscoveryWorkers:  2,
+		Discovery:         discoveryConfig,
+		AutodetectProfile: true,
+		Profiles: profile.ProfileConfigMap{
+			"cisco": profile.ProfileConfig{Definition: profiledefinition.ProfileDefinition{Device: profiledefinition.DeviceMeta{Vendor: "cisco"}}},
+		},
+	}
+	discovery := NewDiscovery(checkConfig, sessionFactory)
+	discovery.Start()
+	assert.NoError(t, waitForDiscoveredDevices(discovery, 3, 2*time.Second))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(probed) == 4
+	}, 2*time.Second, 10*time.Millisecond)
+	discovery.Stop()
+
+	var actualDiscoveredIps []string
+	for _, deviceCk := range discovery.GetDiscoveredDeviceConfigs() {
+		actualDiscoveredIps = append(actualDiscoveredIps, deviceCk.GetIPAddress())
+	}
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.2", "10.0.0.4"}, actualDiscoveredIps)
+
+	mu.Lock()
+	defer mu.Unlock()
+	// the subnet isn't swept
+	assert.Equal(t, map[string]bool{"10.0.0.1": true, "10.0.0.2": true, "10.0.0.3": true, "10.0.0.4": true}, probed)
+
+	// the profile is selected from the fingerprint
+	assert.Equal(t, "cisco", deviceConfigs["10.0.0.1"].Profile)
+	assert.False(t, deviceConfigs["10.0.0.1"].AutodetectProfile)
+	assert.Equal(t, "", deviceConfigs["10.0.0.2"].Profile)
+	assert.True(t, deviceConfigs["10.0.0.2"].AutodetectProfile)
+
+	discovery.discDevMu.RLock()
+	defer discovery.discDevMu.RUnlock()
+	info := discovery.discoveredDevices[checkConfig.DeviceDigest("10.0.0.4")].info
+	assert.Equal(t, snmpdiscovery.SourceLLDP, info.Source)
+	assert.Equal(t, "cisco", info.Profile)
+	assert.Equal(t, "1.3.6.1.4.1.9.1.1745", info.SysObjectID)
+	assert.False(t, info.LastSeen.IsZero())
+}
+
+func TestDiscovery_credentials(t *testing.T) {
+	config.Datadog().SetWithoutSource("run_path", t.TempDir())
+
+	var mu sync.Mutex
+	deviceConfigs := map[string]*checkconfig.CheckConfig{}
+	sessionFactory := func(config *checkconfig.CheckConfig) (session.Session, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		deviceConfigs[config.IPAddress] = config
+		sess := session.CreateFakeSession()
+		// the device only answers to the second credentials set
+		if config.CommunityString == "private" && config.SnmpVersion == "1" {
+			sess.Set("1.3.6.1.2.1.1.2.0", gosnmp.ObjectIdentifier, "1.3.6.1.4.1.3375.2.1.3.4.1")
+		}
+		return sess, nil
+	}
+
+	checkConfig := &checkconfig.CheckConfig{
+		Network:           "10.0.0.0/32",
+		CommunityString:   "public",
+		DiscoveryInterval: 3600,
+		DiscoveryWorkers:  1,
+		Discovery: snmpdiscovery.Config{
+			Credentials: []snmpdiscovery.Credentials{
+				{Community: "private", Version: "2"},
+				{Community: "private", Version: "1"},
+			},
+		},
+	}
+	subnet := &snmpSubnet{
+		config:         checkConfig,
+		cacheKey:       "snmp:TestDiscoveryCredentials",
+		devices:        map[checkconfig.DeviceDigest]string{},
+		deviceFailures: map[checkconfig.DeviceDigest]int{},
+	}
+	discovery := NewDiscovery(checkConfig, sessionFactory)
+	assert.NoError(t, discovery.checkDevice(checkDeviceJob{subnet: subnet, currentIP: net.ParseIP("10.0.0.0"), source: snmpdiscovery.SourceSweep}))
+
+	device, ok := discovery.discoveredDevices[checkConfig.DeviceDigest("10.0.0.0")]
+	require.True(t, ok)
+	assert.Equal(t, 2, device.info.Credentials)
+	assert.Equal(t, "private", deviceConfigs["10.0.0.0"].CommunityString)
+	assert.Equal(t, "1", deviceConfigs["10.0.0.0"].SnmpVersion)
+
+	// the inventory is persisted with the credentials set that worked
+	devices, err := discovery.readCache(subnet)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "10.0.0.0", devices[0].IP)
+	assert.Equal(t, 2, devices[0].Credentials)
+	assert.Equal(t, snmpdiscovery.SourceSweep, devices[0].Source)
+
+	// loading the inventory uses the same credentials
+	discovery2 := NewDiscovery(checkConfig, sessionFactory)
+	subnet.devices = map[checkconfig.DeviceDigest]string{}
+	discovery2.loadCache(subnet)
+	assert.Equal(t, 2, discovery2.discoveredDevices[checkConfig.DeviceDigest("10.0.0.0")].info.Credentials)
+	assert.Equal(t, "private", deviceConfigs["10.0.0.0"].CommunityString)
+}
+
+func TestDiscovery_credentialsFailures(t *testing.T) {
+	config.Datadog().SetWithoutSource("run_path", t.TempDir())
+
+	var mu sync.Mutex
+	answering := "private"
+	sessionFactory := func(config *checkconfig.CheckConfig) (session.Session, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		sess := session.CreateFakeSession()
+		if config.CommunityString == answering {
+			sess.Set("1.3.6.1.2.1.1.2.0", gosnmp.ObjectIdentifier, "1.3.6.1.4.1.3375.2.1.3.4.1")
+		}
+		return sess, nil
+	}
+
+	checkConfig := &checkconfig.CheckConfig{
+		Network:                  "10.0.0.0/32",
+		CommunityString:          "public",
+		DiscoveryInterval:        3600,
+		DiscoveryWorkers:         1,
+		DiscoveryAllowedFailures: 2,
+		Discovery: snmpdiscovery.Config{
+			Credentials: []snmpdiscovery.Credentials{{Community: "private", Version: "2"}},
+		},
+	}
+	subnet := &snmpSubnet{
+		config:              checkConfig,
+		cacheKey:            "snmp:TestDiscoveryCredentialsFailures",
+		devices:             map[checkconfig.DeviceDigest]string{},
+		deviceFailures:      map[checkconfig.DeviceDigest]int{},
+		credentialsFailures: map[checkconfig.DeviceDigest]int{},
+	}
+	discovery := NewDiscovery(checkConfig, sessionFactory)
+	deviceDigest := checkConfig.DeviceDigest("10.0.0.0")
+	checkDevice := func() Device {
+		assert.NoError(t, discovery.checkDevice(checkDeviceJob{subnet: subnet, currentIP: net.ParseIP("10.0.0.0"), source: snmpdiscovery.SourceSweep}))
+		return discovery.discoveredDevices[deviceDigest]
+	}
+
+	device := checkDevice()
+	assert.Equal(t, 1, device.info.Credentials)
+	deviceCheck := device.deviceCheck
+
+	// the credentials of the device stop working once while the subnet ones answer, the device check is kept
+	mu.Lock()
+	answering = "public"
+	mu.Unlock()
+	device = checkDevice()
+	assert.Equal(t, 1, device.info.Credentials)
+	assert.Same(t, deviceCheck, device.deviceCheck)
+
+	// they failed for the allowed failures count, the device check is replaced
+	device = checkDevice()
+	assert.Equal(t, 0, device.info.Credentials)
+	assert.NotSame(t, deviceCheck, device.deviceCheck)
+	assert.Empty(t, subnet.credentialsFailures)
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profile
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/configvalidation"
+)
+
+// ReadProfileFile reads the profile of a definition file and expands the profiles it extends. The extended profiles
+// are looked up in the folder of the file first, then in the user and default profiles of the confd folder.
+//
+// Unlike the profiles loaded by the check, which are skipped when they are invalid, the validation errors of the
+// profile are returned.
+func ReadProfileFile(definitionFile string) (profiledefinition.ProfileDefinition, error) {
+	definitionFile = resolveProfileDefinitionPath(definitionFile)
+	definition, err := readProfileDefinition(definitionFile)
+	if err != nil {
+		return profiledefinition.ProfileDefinition{}, err
+	}
+	name := strings.TrimSuffix(filepath.Base(definitionFile), filepath.Ext(definitionFile))
+
+	// the profiles next to the definition file take precedence over the confd profiles, they are usually the
+	// abstract profiles of a profile being written
+	siblingProfiles, err := readProfileDefinitionsDir(filepath.Dir(definitionFile), true)
+	if err != nil {
+		return profiledefinition.ProfileDefinition{}, err
+	}
+	delete(siblingProfiles, name)
+	userProfiles := mergeProfiles(getYamlUserProfiles(), siblingProfiles)
+
+	err = recursivelyExpandBaseProfiles(name, definition, definition.Extends, []string{}, userProfiles, getYamlDefaultProfiles())
+	if err != nil {
+		return profiledefinition.ProfileDefinition{}, fmt.Errorf("failed to expand profile %q: %w", name, err)
+	}
+	profiledefinition.NormalizeMetrics(definition.Metrics)
+	errors := configvalidation.ValidateEnrichMetadata(definition.Metadata)
+	errors = append(errors, configvalidation.ValidateEnrichMetrics(definition.Metrics)...)
+	errors = append(errors, configvalidation.ValidateEnrichMetricTags(definition.MetricTags)...)
+	if len(errors) > 0 {
+		return profiledefinition.ProfileDefinition{}, fmt.Errorf("validation errors in profile %q: %s", name, strings.Join(errors, "\n"))
+	}
+	return *definition, nil
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestReadProfileFile(t *testing.T) {
+	config.Datadog().SetWithoutSource("confd_path", t.TempDir())
+
+	definitionFile, _ := filepath.Abs(filepath.Join("..", "test", "conf.d", "snmp.d", "profiles", "f5-big-ip.yaml"))
+	definition, err := ReadProfileFile(definitionFile)
+	require.NoError(t, err)
+
+	// the abstract profiles next to the profile are expanded
+	var metricNames []string
+	for _, metric := range definition.Metrics {
+		if metric.IsScalar() {
+			metricNames = append(metricNames, metric.Symbol.Name)
+		}
+		for _, symbol := range metric.Symbols {
+			metricNames = append(metricNames, symbol.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{"sysStatMemoryTotal", "oldSyntax", "ifInErrors", "ifInDiscards", "someMetric"}, metricNames)
+	assert.Contains(t, definition.StaticTags, "static_tag:from_base_profile")
+	assert.Contains(t, definition.Metadata["device"].Fields, "sys_object_id")
+	assert.Equal(t, "f5", definition.Metadata["device"].Fields["vendor"].Value)
+}
+
+func TestReadProfileFileErrors(t *testing.T) {
+	config.Datadog().SetWithoutSource("confd_path", t.TempDir())
+
+	dir := t.TempDir()
+	missingExtend := filepath.Join(dir, "missing-extend.yaml")
+	require.NoError(t, os.WriteFile(missingExtend, []byte("extends:\n  - _unknown.yaml\n"), 0600))
+	_, err := ReadProfileFile(missingExtend)
+	assert.ErrorContains(t, err, "extend does not exist: `_unknown`")
+
+	invalidMetric := filepath.Join(dir, "invalid-metric.yaml")
+	require.NoError(t, os.WriteFile(invalidMetric, []byte("metrics:\n  - symbol:\n      name: noOID\n"), 0600))
+	_, err = ReadProfileFile(invalidMetric)
+	assert.ErrorContains(t, err, "validation errors in profile \"invalid-metric\"")
+
+	_, err = ReadProfileFile(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorContains(t, err, "unable to read file")
+}
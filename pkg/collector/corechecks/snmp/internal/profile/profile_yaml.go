@@ -52,7 +52,10 @@ func loadYamlProfiles() (ProfileConfigMap, error) {
 }
 
 func getProfileDefinitions(profilesFolder string, isUserProfile bool) (ProfileConfigMap, error) {
-	profilesRoot := getProfileConfdRoot(profilesFolder)
+	return readProfileDefinitionsDir(getProfileConfdRoot(profilesFolder), isUserProfile)
+}
+
+func readProfileDefinitionsDir(profilesRoot string, isUserProfile bool) (ProfileConfigMap, error) {
 	files, err := os.ReadDir(profilesRoot)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read profile dir %q: %w", profilesRoot, err)
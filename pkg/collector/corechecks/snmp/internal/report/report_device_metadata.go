@@ -19,6 +19,7 @@ import (
 
 	devicemetadata "github.com/DataDog/datadog-agent/pkg/networkdevice/metadata"
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/topology"
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/utils"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/checkconfig"
@@ -63,6 +64,19 @@ func (ms *MetricSender) ReportNetworkDeviceMetadata(config *checkconfig.CheckCon
 	ipAddresses := buildNetworkIPAddressesMetadata(config.DeviceID, metadataStore)
 	topologyLinks := buildNetworkTopologyMetadata(config.DeviceID, metadataStore, interfaces)
 
+	if ms.topologyStore != nil && store != nil {
+		snapshot := topology.DeviceSnapshot{
+			Namespace:        config.Namespace,
+			Device:           devices[0],
+			Interfaces:       interfaces,
+			Links:            topologyLinks,
+			CollectTimestamp: collectTime.Unix(),
+		}
+		if err := ms.topologyStore.Save(snapshot); err != nil {
+			log.Warnf("Error saving the topology of device %s: %s", config.DeviceID, err)
+		}
+	}
+
 	metadataPayloads := devicemetadata.BatchPayloads(config.Namespace, config.ResolvedSubnetName, collectTime, devicemetadata.PayloadMetadataBatchSize, devices, interfaces, ipAddresses, topologyLinks, nil, diagnoses)
 
 	for _, payload := range metadataPayloads {
@@ -335,12 +349,43 @@ func buildNetworkTopologyMetadata(deviceID string, store *metadata.Store, interf
 	}
 
 	links := buildNetworkTopologyMetadataWithLLDP(deviceID, store, interfaces)
-	if len(links) == 0 {
-		links = buildNetworkTopologyMetadataWithCDP(deviceID, store, interfaces)
+	for _, cdpLink := range buildNetworkTopologyMetadataWithCDP(deviceID, store, interfaces) {
+		if isNeighborReported(links, cdpLink) {
+			log.Tracef("Skipping CDP link %s: the neighbor is already reported by LLDP", cdpLink.ID)
+			continue
+		}
+		links = append(links, cdpLink)
 	}
 	return links
 }
 
+// isNeighborReported returns true if one of links already connects the local interface of link to the same remote device.
+// Devices running both LLDP and CDP advertise themselves with both protocols, the CDP links are only kept for
+// neighbors that don't run LLDP.
+func isNeighborReported(links []devicemetadata.TopologyLinkMetadata, link devicemetadata.TopologyLinkMetadata) bool {
+	for _, other := range links {
+		if other.Local.Interface.DDID == "" || other.Local.Interface.DDID != link.Local.Interface.DDID {
+			continue
+		}
+		if sameRemoteDevice(other.Remote.Device, link.Remote.Device) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameRemoteDevice(a *devicemetadata.TopologyLinkDevice, b *devicemetadata.TopologyLinkDevice) bool {
+	if a.IPAddress != "" && a.IPAddress == b.IPAddress {
+		return true
+	}
+	aName, bName := topology.ShortName(a.Name), topology.ShortName(b.Name)
+	if aName != "" && aName == bName {
+		return true
+	}
+	// the CDP device id is often the hostname of the device, the LLDP system name may only be the short hostname
+	return (aName != "" && aName == topology.ShortName(b.ID)) || (bName != "" && bName == topology.ShortName(a.ID))
+}
+
 func buildNetworkTopologyMetadataWithLLDP(deviceID string, store *metadata.Store, interfaces []devicemetadata.InterfaceMetadata) []devicemetadata.TopologyLinkMetadata {
 	interfaceIndexByIDType := buildInterfaceIndexByIDType(interfaces)
 
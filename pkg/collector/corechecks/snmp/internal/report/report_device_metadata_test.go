@@ -21,6 +21,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/metadata"
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/topology"
 	"github.com/DataDog/datadog-agent/pkg/snmp/snmpintegration"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/checkconfig"
@@ -990,3 +991,69 @@ func Test_getProfileVersion(t *testing.T) {
 		})
 	}
 }
+
+func Test_metricSender_reportNetworkDeviceMetadata_topologyStore(t *testing.T) {
+	var emptyMetadataStore = &valuestore.ResultValueStore{
+		ColumnValues: valuestore.ColumnResultValuesType{},
+	}
+
+	sender := mocksender.NewMockSender("testID") // required to initiate aggregator
+	sender.On("EventPlatformEvent", mock.Anything, mock.Anything).Return()
+	store := topology.NewStore(t.TempDir())
+	ms := &MetricSender{
+		sender: sender,
+	}
+	ms.SetTopologyStore(store)
+
+	config := &checkconfig.CheckConfig{
+		IPAddress: "1.2.3.4",
+		DeviceID:  "my-ns:1.2.3.4",
+		Namespace: "my-ns",
+	}
+	collectTime := time.Unix(1415792726, 0)
+
+	// unreachable devices keep their last snapshot
+	ms.ReportNetworkDeviceMetadata(config, nil, nil, collectTime, metadata.DeviceStatusUnreachable, 0, nil)
+	snapshots, err := store.Load(collectTime, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	ms.ReportNetworkDeviceMetadata(config, emptyMetadataStore, nil, collectTime, metadata.DeviceStatusReachable, 0, nil)
+	snapshots, err = store.Load(collectTime, 0)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	assert.Equal(t, "my-ns", snapshots[0].Namespace)
+	assert.Equal(t, "my-ns:1.2.3.4", snapshots[0].Device.ID)
+	assert.Equal(t, int64(1415792726), snapshots[0].CollectTimestamp)
+}
+
+func Test_isNeighborReported(t *testing.T) {
+	newLink := func(localInterfaceID string, remoteDevice metadata.TopologyLinkDevice) metadata.TopologyLinkMetadata {
+		return metadata.TopologyLinkMetadata{
+			Local:  &metadata.TopologyLinkSide{Interface: &metadata.TopologyLinkInterface{DDID: localInterfaceID}},
+			Remote: &metadata.TopologyLinkSide{Device: &remoteDevice},
+		}
+	}
+	lldpLinks := []metadata.TopologyLinkMetadata{
+		newLink("dev:1", metadata.TopologyLinkDevice{ID: "00:00:00:00:00:01", IDType: "mac_address", Name: "switch-a", IPAddress: "10.0.0.1"}),
+		newLink("dev:2", metadata.TopologyLinkDevice{ID: "00:00:00:00:00:02", IDType: "mac_address", Name: "Switch-B.example.com"}),
+		newLink("", metadata.TopologyLinkDevice{Name: "switch-c"}),
+	}
+
+	for _, tt := range []struct {
+		name     string
+		link     metadata.TopologyLinkMetadata
+		expected bool
+	}{
+		{"same ip address", newLink("dev:1", metadata.TopologyLinkDevice{ID: "other", IPAddress: "10.0.0.1"}), true},
+		{"device id is the hostname", newLink("dev:1", metadata.TopologyLinkDevice{ID: "switch-a.example.com", IPAddress: "10.0.0.9"}), true},
+		{"same short name", newLink("dev:2", metadata.TopologyLinkDevice{ID: "SEP0001", Name: "switch-b"}), true},
+		{"other interface", newLink("dev:3", metadata.TopologyLinkDevice{ID: "switch-a", IPAddress: "10.0.0.1"}), false},
+		{"other device", newLink("dev:1", metadata.TopologyLinkDevice{ID: "phone", IPAddress: "10.0.0.50"}), false},
+		{"unresolved local interface", newLink("", metadata.TopologyLinkDevice{ID: "switch-c"}), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isNeighborReported(lldpLinks, tt.link))
+		})
+	}
+}
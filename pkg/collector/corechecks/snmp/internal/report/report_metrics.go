@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/topology"
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/utils"
 	"github.com/DataDog/datadog-agent/pkg/snmp/snmpintegration"
 
@@ -27,6 +28,7 @@ type MetricSender struct {
 	submittedMetrics        int
 	interfaceConfigs        []snmpintegration.InterfaceConfig
 	interfaceBandwidthState InterfaceBandwidthState
+	topologyStore           *topology.Store
 }
 
 // MetricSample is a collected metric sample with its metadata, ready to be submitted through the metric sender
@@ -48,6 +50,11 @@ func NewMetricSender(sender sender.Sender, hostname string, interfaceConfigs []s
 	}
 }
 
+// SetTopologyStore sets the store the topology of the devices is saved to, to be exported locally
+func (ms *MetricSender) SetTopologyStore(store *topology.Store) {
+	ms.topologyStore = store
+}
+
 // ReportMetrics reports metrics using Sender
 func (ms *MetricSender) ReportMetrics(metrics []profiledefinition.MetricsConfig, values *valuestore.ResultValueStore, tags []string, deviceID string) {
 	scalarSamples := make(map[string]MetricSample)
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package scheduler
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Budget limits the number of devices polled at the same time and the number of SNMP requests sent per second
+type Budget struct {
+	// slots has a slot per device that can be polled at the same time, it's nil if there is no limit
+	slots chan struct{}
+	// limiter is nil if there is no limit
+	limiter *rate.Limiter
+}
+
+var (
+	globalBudget     *Budget
+	globalBudgetOnce sync.Once
+)
+
+// NewBudget returns a budget, limits of 0 disable the limits
+func NewBudget(maxConcurrency int, maxPacketsPerSecond float64) *Budget {
+	b := &Budget{}
+	if maxConcurrency > 0 {
+		b.slots = make(chan struct{}, maxConcurrency)
+	}
+	if maxPacketsPerSecond > 0 {
+		// bursts are limited to a second of packets
+		b.limiter = rate.NewLimiter(rate.Limit(maxPacketsPerSecond), max(1, int(maxPacketsPerSecond)))
+	}
+	return b
+}
+
+// GlobalBudget returns the budget shared by all the SNMP checks, it's created with the limits of the first config
+func GlobalBudget(config Config) *Budget {
+	globalBudgetOnce.Do(func() {
+		globalBudget = NewBudget(config.MaxConcurrency, config.MaxPacketsPerSecond)
+	})
+	return globalBudget
+}
+
+// Acquire waits until a device can be polled, Release must be called once the poll is done
+func (b *Budget) Acquire(ctx context.Context) error {
+	if b.slots == nil {
+		return ctx.Err()
+	}
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release releases the slot of a poll
+func (b *Budget) Release() {
+	if b.slots != nil {
+		<-b.slots
+	}
+}
+
+// WaitPacket waits until a SNMP request can be sent
+func (b *Budget) WaitPacket(ctx context.Context) error {
+	if b.limiter == nil {
+		return nil
+	}
+	return b.limiter.Wait(ctx)
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package scheduler schedules the polls of the SNMP devices: it spreads the polls of the discovered devices across
+// the check interval, adapts the interval and the timeout of each device to its response times, backs off the
+// unreachable devices and enforces the concurrency and packets per second budgets shared by all the SNMP checks.
+package scheduler
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+const (
+	defaultMinTimeout        = 500 * time.Millisecond
+	defaultMaxBackoff        = 15 * time.Minute
+	defaultMaxIntervalFactor = 4
+
+	// timeoutMultiplier is applied to the average response time of a device to get its timeout
+	timeoutMultiplier = 4
+	// spreadRatio is the part of the check interval the polls of the discovered devices are spread over, the rest of
+	// the interval is left for the last polls to complete
+	spreadRatio = 0.5
+	// responseTimeWeight is the weight of the last response time in the average response time of a device
+	responseTimeWeight = 0.2
+)
+
+// Config is the configuration of the scheduler, shared by all the SNMP checks
+type Config struct {
+	Enabled bool
+	// MaxConcurrency is the maximum number of devices polled at the same time by all the checks, 0 for no limit
+	MaxConcurrency int
+	// MaxPacketsPerSecond is the maximum number of SNMP requests per second sent by all the checks, 0 for no limit
+	MaxPacketsPerSecond float64
+	// Spread spreads the polls of the discovered devices across the check interval
+	Spread bool
+	// MinTimeout is the lowest timeout of a device, the highest one is the timeout of the check config
+	MinTimeout time.Duration
+	// MaxBackoff is the longest time an unreachable device isn't polled
+	MaxBackoff time.Duration
+	// MaxIntervalFactor is the maximum multiple of the check interval a slow device is polled at
+	MaxIntervalFactor int
+}
+
+// NewConfig returns the scheduler configuration of the agent configuration
+func NewConfig(cfg model.Reader) Config {
+	config := Config{
+		Enabled:             cfg.GetBool("network_devices.polling.enabled"),
+		MaxConcurrency:      cfg.GetInt("network_devices.polling.max_concurrency"),
+		MaxPacketsPerSecond: cfg.GetFloat64("network_devices.polling.max_packets_per_second"),
+		Spread:              cfg.GetBool("network_devices.polling.spread"),
+		MinTimeout:          time.Duration(cfg.GetFloat64("network_devices.polling.min_timeout") * float64(time.Second)),
+		MaxBackoff:          time.Duration(cfg.GetInt("network_devices.polling.max_backoff")) * time.Second,
+		MaxIntervalFactor:   cfg.GetInt("network_devices.polling.max_interval_factor"),
+	}
+	if config.MinTimeout <= 0 {
+		config.MinTimeout = defaultMinTimeout
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultMaxBackoff
+	}
+	if config.MaxIntervalFactor < 1 {
+		config.MaxIntervalFactor = defaultMaxIntervalFactor
+	}
+	return config
+}
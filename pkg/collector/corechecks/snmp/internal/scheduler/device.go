@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// DeviceStats are the polling statistics of a device
+type DeviceStats struct {
+	// ResponseTime is the average response time of the SNMP requests of the device
+	ResponseTime time.Duration
+	// PollDuration is the duration of the last poll
+	PollDuration time.Duration
+	// BudgetWait is the time the last poll waited for the concurrency and packets per second budgets
+	BudgetWait time.Duration
+	// Interval is the time between two polls of the device
+	Interval time.Duration
+	// Timeout is the timeout of the SNMP requests of the device
+	Timeout             time.Duration
+	ConsecutiveFailures int
+	NextPoll            time.Time
+}
+
+// deviceState is the polling state of a device
+type deviceState struct {
+	mu sync.Mutex
+
+	responseTime        time.Duration
+	pollDuration        time.Duration
+	budgetWait          time.Duration
+	consecutiveFailures int
+	// intervalFactor is the multiple of the check interval the device is polled at
+	intervalFactor int
+	timeout        time.Duration
+	nextPoll       time.Time
+
+	// responses is the number of responses received during the current poll
+	responses int
+}
+
+func newDeviceState(timeout time.Duration) *deviceState {
+	return &deviceState{intervalFactor: 1, timeout: timeout}
+}
+
+// observeRequest records the response time of a SNMP request of the current poll
+func (s *deviceState) observeRequest(responseTime time.Duration, budgetWait time.Duration, success bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budgetWait += budgetWait
+	if !success {
+		return
+	}
+	s.responses++
+	if s.responseTime == 0 {
+		s.responseTime = responseTime
+	} else {
+		s.responseTime = time.Duration(responseTimeWeight*float64(responseTime) + (1-responseTimeWeight)*float64(s.responseTime))
+	}
+}
+
+func (s *deviceState) startPoll(budgetWait time.Duration) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses = 0
+	s.budgetWait = budgetWait
+	return s.timeout
+}
+
+// finishPoll adapts the interval and the timeout of the device to the poll. A device is reachable if it answered at
+// least one request.
+func (s *deviceState) finishPoll(config Config, interval time.Duration, maxTimeout time.Duration, start time.Time, duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pollDuration = duration
+
+	if s.responses == 0 {
+		s.consecutiveFailures++
+		// the timeout of unreachable devices isn't reduced, they might just be slow
+		s.timeout = maxTimeout
+		// the first failure is retried at the next interval, then the delay doubles at each failure
+		backoff := interval * time.Duration(s.intervalFactor)
+		for i := 1; i < s.consecutiveFailures && backoff < config.MaxBackoff; i++ {
+			backoff *= 2
+		}
+		s.nextPoll = start.Add(min(backoff, max(config.MaxBackoff, interval)))
+		return
+	}
+
+	s.consecutiveFailures = 0
+	s.timeout = min(max(s.responseTime*timeoutMultiplier, config.MinTimeout), maxTimeout)
+	// devices whose polls take more than half of the interval are polled less often, so that they aren't polled again
+	// before their previous poll completes. The interval shrinks back a step at a time once the device is faster.
+	factor := 1
+	if interval > 0 {
+		factor = int((2*duration + interval - 1) / interval)
+	}
+	factor = min(max(factor, 1), config.MaxIntervalFactor)
+	if factor < s.intervalFactor {
+		factor = s.intervalFactor - 1
+	}
+	s.intervalFactor = factor
+	s.nextPoll = start.Add(interval * time.Duration(s.intervalFactor))
+}
+
+// due returns true if the device must be polled at now. Check runs aren't exactly one interval apart, a device is due
+// if its next poll is less than half an interval away.
+func (s *deviceState) due(now time.Time, interval time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !now.Add(interval / 2).Before(s.nextPoll)
+}
+
+func (s *deviceState) stats(interval time.Duration) DeviceStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return DeviceStats{
+		ResponseTime:        s.responseTime,
+		PollDuration:        s.pollDuration,
+		BudgetWait:          s.budgetWait,
+		Interval:            interval * time.Duration(s.intervalFactor),
+		Timeout:             s.timeout,
+		ConsecutiveFailures: s.consecutiveFailures,
+		NextPoll:            s.nextPoll,
+	}
+}
@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testConfig = Config{
+	Enabled:           true,
+	Spread:            true,
+	MinTimeout:        500 * time.Millisecond,
+	MaxBackoff:        5 * time.Minute,
+	MaxIntervalFactor: 4,
+}
+
+func TestDeviceStateAdaptiveTimeout(t *testing.T) {
+	state := newDeviceState(5 * time.Second)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	state.startPoll(0)
+	state.observeRequest(200*time.Millisecond, 0, true)
+	state.observeRequest(300*time.Millisecond, time.Second, true)
+	state.finishPoll(testConfig, time.Minute, 5*time.Second, start, time.Second)
+
+	stats := state.stats(time.Minute)
+	// 200ms then 0.8*200ms + 0.2*300ms
+	assert.Equal(t, 220*time.Millisecond, stats.ResponseTime)
+	assert.Equal(t, 880*time.Millisecond, stats.Timeout)
+	assert.Equal(t, time.Second, stats.BudgetWait)
+	assert.Equal(t, time.Minute, stats.Interval)
+	assert.Equal(t, start.Add(time.Minute), stats.NextPoll)
+
+	// fast devices get the min timeout, slow ones the timeout of the config
+	state.responseTime = 10 * time.Millisecond
+	state.startPoll(0)
+	state.observeRequest(10*time.Millisecond, 0, true)
+	state.finishPoll(testConfig, time.Minute, 5*time.Second, start, time.Second)
+	assert.Equal(t, 500*time.Millisecond, state.stats(time.Minute).Timeout)
+
+	state.responseTime = 3 * time.Second
+	state.startPoll(0)
+	state.observeRequest(3*time.Second, 0, true)
+	state.finishPoll(testConfig, time.Minute, 5*time.Second, start, time.Second)
+	assert.Equal(t, 5*time.Second, state.stats(time.Minute).Timeout)
+}
+
+func TestDeviceStateBackoff(t *testing.T) {
+	state := newDeviceState(5 * time.Second)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// the timeout is reset to the timeout of the config when the device doesn't answer
+	state.timeout = time.Second
+	var nextPolls []time.Duration
+	for i := 0; i < 6; i++ {
+		state.startPoll(0)
+		state.observeRequest(5*time.Second, 0, false)
+		state.finishPoll(testConfig, time.Minute, 5*time.Second, start, 20*time.Second)
+		nextPolls = append(nextPolls, state.stats(time.Minute).NextPoll.Sub(start))
+	}
+	assert.Equal(t, []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute, 5 * time.Minute}, nextPolls)
+	assert.Equal(t, 6, state.stats(time.Minute).ConsecutiveFailures)
+	assert.Equal(t, 5*time.Second, state.stats(time.Minute).Timeout)
+
+	assert.False(t, state.due(start.Add(4*time.Minute), time.Minute))
+	assert.True(t, state.due(start.Add(4*time.Minute+30*time.Second), time.Minute))
+
+	// a response resets the backoff
+	state.startPoll(0)
+	state.observeRequest(100*time.Millisecond, 0, true)
+	state.finishPoll(testConfig, time.Minute, 5*time.Second, start, time.Second)
+	stats := state.stats(time.Minute)
+	assert.Equal(t, 0, stats.ConsecutiveFailures)
+	assert.Equal(t, start.Add(time.Minute), stats.NextPoll)
+}
+
+func TestDeviceStateAdaptiveInterval(t *testing.T) {
+	state := newDeviceState(5 * time.Second)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	poll := func(duration time.Duration) time.Duration {
+		state.startPoll(0)
+		state.observeRequest(time.Second, 0, true)
+		state.finishPoll(testConfig, time.Minute, 5*time.Second, start, duration)
+		return state.stats(time.Minute).Interval
+	}
+
+	assert.Equal(t, time.Minute, poll(20*time.Second))
+	// polls taking more than half of the interval are spaced out
+	assert.Equal(t, 2*time.Minute, poll(40*time.Second))
+	assert.Equal(t, 4*time.Minute, poll(5*time.Minute))
+	// the interval shrinks back a step at a time
+	assert.Equal(t, 3*time.Minute, poll(10*time.Second))
+	assert.Equal(t, 2*time.Minute, poll(10*time.Second))
+	assert.Equal(t, time.Minute, poll(10*time.Second))
+	assert.Equal(t, time.Minute, poll(10*time.Second))
+}
@@ -0,0 +1,194 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package scheduler
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/checkconfig"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/session"
+)
+
+// Scheduler schedules the polls of the devices of a check
+type Scheduler struct {
+	config Config
+	budget *Budget
+	// interval is the interval of the check
+	interval time.Duration
+	// maxTimeout is the timeout of the check config
+	maxTimeout time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu sync.Mutex
+	// devices holds the state of the devices polled by the check, the devices only probed by discovery aren't tracked
+	devices map[string]*deviceState
+
+	// now and sleep are replaced in tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration)
+}
+
+// New returns the scheduler of a check
+func New(config Config, budget *Budget, interval time.Duration, maxTimeout time.Duration) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		config:     config,
+		budget:     budget,
+		interval:   interval,
+		maxTimeout: maxTimeout,
+		ctx:        ctx,
+		cancel:     cancel,
+		devices:    make(map[string]*deviceState),
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+}
+
+// Stop cancels the pending polls
+func (s *Scheduler) Stop() {
+	s.cancel()
+}
+
+// SessionFactory wraps a session factory, so that the requests of the sessions are limited by the packets per second
+// budget and their response times are recorded
+func (s *Scheduler) SessionFactory(factory session.Factory) session.Factory {
+	return func(config *checkconfig.CheckConfig) (session.Session, error) {
+		sess, err := factory(config)
+		if err != nil {
+			return nil, err
+		}
+		return &scheduledSession{Session: sess, scheduler: s, deviceID: config.DeviceID}, nil
+	}
+}
+
+// device returns the state of a polled device, creating it on the first poll
+func (s *Scheduler) device(deviceID string) *deviceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.devices[deviceID]
+	if !ok {
+		state = newDeviceState(s.maxTimeout)
+		s.devices[deviceID] = state
+	}
+	return state
+}
+
+// trackedDevice returns the state of a polled device, or nil if the device isn't polled by the check
+func (s *Scheduler) trackedDevice(deviceID string) *deviceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.devices[deviceID]
+}
+
+// Retain deletes the state of the devices that aren't in deviceIDs, so that the devices dropped by discovery are
+// forgotten
+func (s *Scheduler) Retain(deviceIDs []string) {
+	retained := make(map[string]struct{}, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		retained[deviceID] = struct{}{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for deviceID := range s.devices {
+		if _, ok := retained[deviceID]; !ok {
+			delete(s.devices, deviceID)
+		}
+	}
+}
+
+// Due returns true if a device must be polled now. Unreachable devices are backed off and slow devices are polled at
+// a multiple of the check interval.
+func (s *Scheduler) Due(deviceID string) bool {
+	return s.device(deviceID).due(s.now(), s.interval)
+}
+
+// Stats returns the polling statistics of a device
+func (s *Scheduler) Stats(deviceID string) DeviceStats {
+	return s.device(deviceID).stats(s.interval)
+}
+
+// Poll polls a device once the concurrency budget allows it, then adapts the interval and the timeout of the device
+// to the poll
+func (s *Scheduler) Poll(deviceID string, poll func() error) (DeviceStats, error) {
+	state := s.device(deviceID)
+	waitStart := s.now()
+	if err := s.budget.Acquire(s.ctx); err != nil {
+		return state.stats(s.interval), err
+	}
+	defer s.budget.Release()
+
+	start := s.now()
+	state.startPoll(start.Sub(waitStart))
+	err := poll()
+	state.finishPoll(s.config, s.interval, s.maxTimeout, start, s.now().Sub(start))
+	return state.stats(s.interval), err
+}
+
+// Run calls poll for each device, with at most workers calls at the same time. If spreading is enabled, the calls are
+// spread across the first half of the check interval, each device keeping the same offset from a run to the other.
+func (s *Scheduler) Run(deviceIDs []string, workers int, poll func(deviceID string)) {
+	type job struct {
+		deviceID string
+		offset   time.Duration
+	}
+	jobs := make([]job, 0, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		jobs = append(jobs, job{deviceID: deviceID, offset: s.offset(deviceID)})
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].offset < jobs[j].offset
+	})
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(workers, 1))
+	start := s.now()
+	for _, j := range jobs {
+		if wait := j.offset - s.now().Sub(start); wait > 0 {
+			s.sleep(s.ctx, wait)
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-s.ctx.Done():
+		}
+		if s.ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(deviceID string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			poll(deviceID)
+		}(j.deviceID)
+	}
+	wg.Wait()
+}
+
+// offset returns the delay of the poll of a device from the start of a run. It's derived from the device ID so that
+// a device is polled at regular intervals.
+func (s *Scheduler) offset(deviceID string) time.Duration {
+	window := time.Duration(float64(s.interval) * spreadRatio)
+	if !s.config.Spread || window <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(deviceID))
+	return time.Duration(h.Sum64() % uint64(window))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,265 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/checkconfig"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/session"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+func TestNewConfig(t *testing.T) {
+	cfg := model.NewConfig("datadog", "DD", nil)
+	cfg.SetWithoutSource("network_devices.polling.enabled", true)
+	cfg.SetWithoutSource("network_devices.polling.max_concurrency", 100)
+	cfg.SetWithoutSource("network_devices.polling.max_packets_per_second", 2000.5)
+	cfg.SetWithoutSource("network_devices.polling.min_timeout", 0.25)
+
+	assert.Equal(t, Config{
+		Enabled:             true,
+		MaxConcurrency:      100,
+		MaxPacketsPerSecond: 2000.5,
+		MinTimeout:          250 * time.Millisecond,
+		MaxBackoff:          defaultMaxBackoff,
+		MaxIntervalFactor:   defaultMaxIntervalFactor,
+	}, NewConfig(cfg))
+}
+
+func TestBudgetConcurrency(t *testing.T) {
+	budget := NewBudget(2, 0)
+	ctx := context.Background()
+	require.NoError(t, budget.Acquire(ctx))
+	require.NoError(t, budget.Acquire(ctx))
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, budget.Acquire(timeoutCtx), context.DeadlineExceeded)
+
+	budget.Release()
+	assert.NoError(t, budget.Acquire(ctx))
+
+	// no limit
+	unlimited := NewBudget(0, 0)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, unlimited.Acquire(ctx))
+		require.NoError(t, unlimited.WaitPacket(ctx))
+	}
+}
+
+func TestBudgetPacketsPerSecond(t *testing.T) {
+	budget := NewBudget(0, 100)
+	start := time.Now()
+	// the first second of packets is a burst, the next ones are spaced by 10ms
+	for i := 0; i < 110; i++ {
+		require.NoError(t, budget.WaitPacket(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+}
+
+func TestSchedulerSession(t *testing.T) {
+	s := New(testConfig, NewBudget(0, 0), time.Minute, 5*time.Second)
+	fakeSession := session.CreateFakeSession()
+	fakeSession.SetStr("1.3.6.1.2.1.1.5.0", "device")
+	sessionFactory := s.SessionFactory(func(*checkconfig.CheckConfig) (session.Session, error) {
+		return fakeSession, nil
+	})
+
+	sess, err := sessionFactory(&checkconfig.CheckConfig{DeviceID: "default:10.0.0.1"})
+	require.NoError(t, err)
+
+	stats, err := s.Poll("default:10.0.0.1", func() error {
+		require.NoError(t, sess.Connect())
+		_, err := sess.Get([]string{"1.3.6.1.2.1.1.5.0"})
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.ConsecutiveFailures)
+	assert.Equal(t, 500*time.Millisecond, stats.Timeout)
+	assert.False(t, s.Due("default:10.0.0.1"))
+
+	failingFactory := s.SessionFactory(func(*checkconfig.CheckConfig) (session.Session, error) {
+		return nil, errors.New("invalid config")
+	})
+	_, err = failingFactory(&checkconfig.CheckConfig{})
+	assert.EqualError(t, err, "invalid config")
+}
+
+// timeoutSession records the timeouts set by the scheduler
+type timeoutSession struct {
+	*session.FakeSession
+	timeouts []time.Duration
+}
+
+func (s *timeoutSession) SetTimeout(timeout time.Duration) {
+	s.timeouts = append(s.timeouts, timeout)
+}
+
+func (s *timeoutSession) GetNext([]string) (*gosnmp.SnmpPacket, error) {
+	return nil, errors.New("request timeout")
+}
+
+func TestSchedulerUnreachableDevice(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := New(testConfig, NewBudget(0, 0), time.Minute, 5*time.Second)
+	s.now = func() time.Time { return now }
+
+	inner := &timeoutSession{FakeSession: session.CreateFakeSession()}
+	sess, err := s.SessionFactory(func(*checkconfig.CheckConfig) (session.Session, error) {
+		return inner, nil
+	})(&checkconfig.CheckConfig{DeviceID: "default:10.0.0.1"})
+	require.NoError(t, err)
+
+	var polls int
+	for i := 0; i < 8; i++ {
+		if s.Due("default:10.0.0.1") {
+			polls++
+			_, err := s.Poll("default:10.0.0.1", func() error {
+				require.NoError(t, sess.Connect())
+				_, err := sess.GetNext([]string{"1.3.6.1.2.1.1.2.0"})
+				return err
+			})
+			assert.EqualError(t, err, "request timeout")
+		}
+		now = now.Add(time.Minute)
+	}
+	// polled at 0, 1, 3 and 7 minutes
+	assert.Equal(t, 4, polls)
+	assert.Equal(t, []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second, 5 * time.Second}, inner.timeouts)
+	assert.Equal(t, 4, s.Stats("default:10.0.0.1").ConsecutiveFailures)
+}
+
+func TestSchedulerTrackedDevices(t *testing.T) {
+	s := New(testConfig, NewBudget(0, 0), time.Minute, 5*time.Second)
+	inner := &timeoutSession{FakeSession: session.CreateFakeSession()}
+	factory := s.SessionFactory(func(*checkconfig.CheckConfig) (session.Session, error) {
+		return inner, nil
+	})
+
+	// the sessions of discovery probes don't track the devices
+	probe, err := factory(&checkconfig.CheckConfig{DeviceID: "default:10.0.0.2"})
+	require.NoError(t, err)
+	require.NoError(t, probe.Connect())
+	_, err = probe.GetNext([]string{"1.3.6.1.2.1.1.2.0"})
+	assert.EqualError(t, err, "request timeout")
+	assert.Empty(t, inner.timeouts)
+	assert.Empty(t, s.devices)
+
+	// the devices are tracked once polled
+	for _, deviceID := range []string{"default:10.0.0.1", "default:10.0.0.2"} {
+		_, err := s.Poll(deviceID, func() error { return nil })
+		require.NoError(t, err)
+	}
+	require.NoError(t, probe.Connect())
+	assert.Equal(t, []time.Duration{5 * time.Second}, inner.timeouts)
+	assert.Len(t, s.devices, 2)
+
+	// the devices dropped by discovery are forgotten
+	s.Retain([]string{"default:10.0.0.1"})
+	assert.Len(t, s.devices, 1)
+	assert.Contains(t, s.devices, "default:10.0.0.1")
+}
+
+func TestSchedulerRunSpread(t *testing.T) {
+	s := New(testConfig, NewBudget(0, 0), time.Minute, 5*time.Second)
+	var mu sync.Mutex
+	var slept time.Duration
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}
+	s.sleep = func(_ context.Context, d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		slept += d
+		clock = clock.Add(d)
+	}
+
+	var deviceIDs []string
+	for i := 0; i < 50; i++ {
+		deviceIDs = append(deviceIDs, fmt.Sprintf("default:10.0.0.%d", i))
+	}
+	var polled []string
+	s.Run(deviceIDs, 1, func(deviceID string) {
+		mu.Lock()
+		defer mu.Unlock()
+		polled = append(polled, deviceID)
+	})
+
+	assert.ElementsMatch(t, deviceIDs, polled)
+	// the devices are polled by order of offset, over the first half of the interval
+	for i := 1; i < len(polled); i++ {
+		assert.LessOrEqual(t, s.offset(polled[i-1]), s.offset(polled[i]))
+	}
+	assert.Greater(t, slept, 20*time.Second)
+	assert.Less(t, slept, 30*time.Second)
+	// offsets are stable
+	assert.Equal(t, s.offset("default:10.0.0.1"), s.offset("default:10.0.0.1"))
+
+	// without spreading, devices are polled right away
+	noSpread := testConfig
+	noSpread.Spread = false
+	assert.Equal(t, time.Duration(0), New(noSpread, NewBudget(0, 0), time.Minute, 5*time.Second).offset("default:10.0.0.1"))
+}
+
+func TestSchedulerRunConcurrency(t *testing.T) {
+	noSpread := testConfig
+	noSpread.Spread = false
+	s := New(noSpread, NewBudget(3, 0), time.Minute, 5*time.Second)
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	var deviceIDs []string
+	for i := 0; i < 20; i++ {
+		deviceIDs = append(deviceIDs, fmt.Sprintf("default:10.0.0.%d", i))
+	}
+	s.Run(deviceIDs, 10, func(deviceID string) {
+		_, err := s.Poll(deviceID, func() error {
+			mu.Lock()
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+	// the global budget is lower than the workers of the check
+	assert.Equal(t, 3, maxRunning)
+}
+
+func TestSchedulerStop(t *testing.T) {
+	s := New(testConfig, NewBudget(1, 0), time.Minute, 5*time.Second)
+	require.NoError(t, s.budget.Acquire(context.Background()))
+	s.Stop()
+
+	_, err := s.Poll("default:10.0.0.1", func() error {
+		t.Fatal("the device must not be polled")
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	polled := 0
+	s.Run([]string{"default:10.0.0.1", "default:10.0.0.2"}, 1, func(string) {
+		polled++
+	})
+	assert.Equal(t, 0, polled)
+}
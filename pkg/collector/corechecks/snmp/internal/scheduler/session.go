@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package scheduler
+
+import (
+	"context"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/session"
+)
+
+// scheduledSession is a session whose requests are limited by the packets per second budget, it records the response
+// times of the device if it's polled by the check
+type scheduledSession struct {
+	session.Session
+	scheduler *Scheduler
+	deviceID  string
+}
+
+// Connect applies the timeout of the device before connecting
+func (s *scheduledSession) Connect() error {
+	if timeoutSetter, ok := s.Session.(session.TimeoutSetter); ok {
+		if state := s.scheduler.trackedDevice(s.deviceID); state != nil {
+			state.mu.Lock()
+			timeout := state.timeout
+			state.mu.Unlock()
+			timeoutSetter.SetTimeout(timeout)
+		}
+	}
+	return s.Session.Connect()
+}
+
+// Get will send a SNMPGET command
+func (s *scheduledSession) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	return s.request(func() (*gosnmp.SnmpPacket, error) {
+		return s.Session.Get(oids)
+	})
+}
+
+// GetBulk will send a SNMP BULKGET command
+func (s *scheduledSession) GetBulk(oids []string, bulkMaxRepetitions uint32) (*gosnmp.SnmpPacket, error) {
+	return s.request(func() (*gosnmp.SnmpPacket, error) {
+		return s.Session.GetBulk(oids, bulkMaxRepetitions)
+	})
+}
+
+// GetNext will send a SNMP GETNEXT command
+func (s *scheduledSession) GetNext(oids []string) (*gosnmp.SnmpPacket, error) {
+	return s.request(func() (*gosnmp.SnmpPacket, error) {
+		return s.Session.GetNext(oids)
+	})
+}
+
+func (s *scheduledSession) request(send func() (*gosnmp.SnmpPacket, error)) (*gosnmp.SnmpPacket, error) {
+	waitStart := s.scheduler.now()
+	// the requests of polls already started are sent even if the scheduler is stopped
+	if err := s.scheduler.budget.WaitPacket(context.Background()); err != nil {
+		return nil, err
+	}
+	start := s.scheduler.now()
+	packet, err := send()
+	if state := s.scheduler.trackedDevice(s.deviceID); state != nil {
+		state.observeRequest(s.scheduler.now().Sub(start), start.Sub(waitStart), err == nil)
+	}
+	return packet, err
+}
+
+var _ session.Session = (*scheduledSession)(nil)
@@ -3,8 +3,6 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2016-present Datadog, Inc.
 
-//go:build test
-
 //nolint:revive // TODO(NDM) Fix revive linter
 package session
 
@@ -63,7 +61,8 @@ func cmpOIDs(a, b []int) int {
 	return 0
 }
 
-// FakeSession implements Session wrapping around a fixed set of PDUs.
+// FakeSession implements Session wrapping around a fixed set of PDUs. It is used by tests and to replay the
+// recorded walks of a device offline.
 // Caveats:
 //   - Fetching an object that isn't there will always return NoSuchObject,
 //     never NoSuchInstance. I don't think we can do NoSuchInstance without
@@ -36,6 +36,11 @@ type Session interface {
 	GetVersion() gosnmp.SnmpVersion
 }
 
+// TimeoutSetter is implemented by the sessions whose timeout can be changed
+type TimeoutSetter interface {
+	SetTimeout(timeout time.Duration)
+}
+
 // GosnmpSession is used to connect to a snmp device
 type GosnmpSession struct {
 	gosnmpInst gosnmp.GoSNMP
@@ -66,6 +71,11 @@ func (s *GosnmpSession) GetNext(oids []string) (result *gosnmp.SnmpPacket, err e
 	return s.gosnmpInst.GetNext(oids)
 }
 
+// SetTimeout sets the timeout of the next requests
+func (s *GosnmpSession) SetTimeout(timeout time.Duration) {
+	s.gosnmpInst.Timeout = timeout
+}
+
 // GetVersion returns the snmp version used
 func (s *GosnmpSession) GetVersion() gosnmp.SnmpVersion {
 	return s.gosnmpInst.Version
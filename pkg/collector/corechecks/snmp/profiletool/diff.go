@@ -0,0 +1,149 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profiletool
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SampleChange is a sample whose value or type differs between two reports
+type SampleChange struct {
+	Old Sample `json:"old"`
+	New Sample `json:"new"`
+}
+
+// MetadataChange is a device metadata field whose value differs between two reports
+type MetadataChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// ReportDiff is the difference between the output of two versions of a profile collecting the same walk
+type ReportDiff struct {
+	Added    []Sample         `json:"added"`
+	Removed  []Sample         `json:"removed"`
+	Changed  []SampleChange   `json:"changed"`
+	Metadata []MetadataChange `json:"metadata"`
+}
+
+// Empty returns true if both reports have the same output
+func (d ReportDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && len(d.Metadata) == 0
+}
+
+// Diff compares the samples and the device metadata of two reports. The reports must have been generated with the
+// same profile name, since the name of the profile is one of the tags of the samples.
+func Diff(oldReport *Report, newReport *Report) ReportDiff {
+	diff := ReportDiff{
+		Added:    []Sample{},
+		Removed:  []Sample{},
+		Changed:  []SampleChange{},
+		Metadata: []MetadataChange{},
+	}
+
+	oldSamples := samplesByKey(oldReport.Samples)
+	newSamples := samplesByKey(newReport.Samples)
+	for _, key := range sortedKeys(oldSamples) {
+		newSample, ok := newSamples[key]
+		if !ok {
+			diff.Removed = append(diff.Removed, oldSamples[key])
+		} else if oldSample := oldSamples[key]; oldSample.Value != newSample.Value || oldSample.Type != newSample.Type {
+			diff.Changed = append(diff.Changed, SampleChange{Old: oldSample, New: newSample})
+		}
+	}
+	for _, key := range sortedKeys(newSamples) {
+		if _, ok := oldSamples[key]; !ok {
+			diff.Added = append(diff.Added, newSamples[key])
+		}
+	}
+
+	oldMetadata := flattenMetadata(oldReport)
+	newMetadata := flattenMetadata(newReport)
+	fields := make(map[string]string, len(oldMetadata)+len(newMetadata))
+	for field := range oldMetadata {
+		fields[field] = ""
+	}
+	for field := range newMetadata {
+		fields[field] = ""
+	}
+	for _, field := range sortedKeys(fields) {
+		if oldMetadata[field] != newMetadata[field] {
+			diff.Metadata = append(diff.Metadata, MetadataChange{Field: field, Old: oldMetadata[field], New: newMetadata[field]})
+		}
+	}
+	return diff
+}
+
+func samplesByKey(samples []Sample) map[string]Sample {
+	byKey := make(map[string]Sample, len(samples))
+	for _, sample := range samples {
+		byKey[sample.key()] = sample
+	}
+	return byKey
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// flattenMetadata returns the fields of the device metadata of a report, like `device.vendor` or
+// `interface[1].name`
+func flattenMetadata(r *Report) map[string]string {
+	fields := make(map[string]string)
+	for _, device := range r.DeviceMetadata.Devices {
+		flattenJSON(fields, "device", device)
+	}
+	for _, itf := range r.DeviceMetadata.Interfaces {
+		flattenJSON(fields, fmt.Sprintf("interface[%d]", itf.Index), itf)
+	}
+	for _, ipAddress := range r.DeviceMetadata.IPAddresses {
+		flattenJSON(fields, fmt.Sprintf("ip_address[%s]", ipAddress.IPAddress), ipAddress)
+	}
+	for _, link := range r.DeviceMetadata.Links {
+		flattenJSON(fields, fmt.Sprintf("link[%s]", link.ID), link)
+	}
+	return fields
+}
+
+func flattenJSON(fields map[string]string, prefix string, value interface{}) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(content, &object); err != nil {
+		return
+	}
+	flattenValue(fields, prefix, object)
+}
+
+func flattenValue(fields map[string]string, prefix string, value interface{}) {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typedValue {
+			flattenValue(fields, prefix+"."+key, child)
+		}
+	case []interface{}:
+		var values []string
+		for _, child := range typedValue {
+			values = append(values, fmt.Sprint(child))
+		}
+		sort.Strings(values)
+		fields[prefix] = strings.Join(values, ",")
+	case nil:
+	default:
+		fields[prefix] = fmt.Sprint(typedValue)
+	}
+}
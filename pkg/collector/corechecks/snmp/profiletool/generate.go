@@ -0,0 +1,277 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profiletool
+
+import (
+	"io"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/common"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/profile"
+)
+
+// metricSyntaxes are the base syntaxes of the MIB objects reported as metrics
+var metricSyntaxes = map[string]bool{
+	"INTEGER":   true,
+	"Counter32": true,
+	"Counter64": true,
+	"Gauge32":   true,
+}
+
+// binaryStringTypes are the string types that aren't readable, they aren't used as tags
+var binaryStringTypes = map[string]bool{
+	"OCTET STRING": true,
+	"Opaque":       true,
+	"BITS":         true,
+	"PhysAddress":  true,
+	"MacAddress":   true,
+	"DateAndTime":  true,
+	"InetAddress":  true,
+}
+
+// LoadProfiles returns the profiles the SNMP check would load: the profiles of the profile bundle, or the default and
+// user profiles of the confd folder
+func LoadProfiles() (map[string]profiledefinition.ProfileDefinition, error) {
+	profiles, err := profile.GetProfiles(nil)
+	if err != nil {
+		return nil, err
+	}
+	definitions := make(map[string]profiledefinition.ProfileDefinition, len(profiles))
+	for name, profileConfig := range profiles {
+		definitions[name] = profileConfig.Definition
+	}
+	return definitions, nil
+}
+
+// Generate drafts a profile for the device of a walk. It reuses the metrics, tags and metadata of the known profiles
+// whose OIDs are in the walk, then adds the tables and scalars of the MIB found in the walk that aren't covered yet.
+// The profile is a starting point: the metric types, tags and metadata of the device still need to be reviewed.
+func Generate(walk Walk, knownProfiles map[string]profiledefinition.ProfileDefinition, mib *MIB) profiledefinition.ProfileDefinition {
+	g := &generator{
+		trie:       walk.OIDTrie(),
+		definition: profiledefinition.NewProfileDefinition(),
+		seenOIDs:   make(map[string]bool),
+		seenTags:   make(map[string]bool),
+	}
+	if sysObjectID := walk.SysObjectID(); sysObjectID != "" {
+		g.definition.SysObjectIDs = profiledefinition.StringArray{sysObjectID}
+	}
+	for _, name := range sortedKeys(knownProfiles) {
+		g.addProfile(knownProfiles[name])
+	}
+	if mib != nil {
+		g.addMIB(mib)
+	}
+	return *g.definition
+}
+
+// WriteProfile writes a profile as YAML
+func WriteProfile(w io.Writer, definition profiledefinition.ProfileDefinition) error {
+	content, err := yaml.Marshal(definition)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+type generator struct {
+	trie       *common.OIDTrie
+	definition *profiledefinition.ProfileDefinition
+	// seenOIDs are the OIDs of the metrics already in the profile
+	seenOIDs map[string]bool
+	// seenTags are the global tags already in the profile
+	seenTags map[string]bool
+}
+
+func (g *generator) addProfile(knownProfile profiledefinition.ProfileDefinition) {
+	for _, metric := range knownProfile.Metrics {
+		if metric.IsScalar() {
+			if !g.seenOIDs[metric.Symbol.OID] && g.trie.LeafExist(metric.Symbol.OID) {
+				g.seenOIDs[metric.Symbol.OID] = true
+				g.definition.Metrics = append(g.definition.Metrics, metric)
+			}
+			continue
+		}
+		var symbols []profiledefinition.SymbolConfig
+		for _, symbol := range metric.Symbols {
+			if !g.seenOIDs[symbol.OID] && g.trie.NonLeafNodeExist(symbol.OID) {
+				g.seenOIDs[symbol.OID] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+		if len(symbols) == 0 {
+			continue
+		}
+		var metricTags profiledefinition.MetricTagConfigList
+		for _, metricTag := range metric.MetricTags {
+			if metricTag.Symbol.OID == "" || g.trie.NonLeafNodeExist(metricTag.Symbol.OID) {
+				metricTags = append(metricTags, metricTag)
+			}
+		}
+		metric.Symbols = symbols
+		metric.MetricTags = metricTags
+		g.definition.Metrics = append(g.definition.Metrics, metric)
+	}
+
+	for _, metricTag := range knownProfile.MetricTags {
+		tag := metricTagName(metricTag)
+		if !g.seenTags[tag] && g.trie.LeafExist(metricTag.Symbol.OID) {
+			g.seenTags[tag] = true
+			g.definition.MetricTags = append(g.definition.MetricTags, metricTag)
+		}
+	}
+
+	for resource, resourceConfig := range knownProfile.Metadata {
+		g.addMetadata(resource, resourceConfig)
+	}
+}
+
+// addMetadata adds the metadata fields of a known profile whose OIDs are in the walk. Constant values are specific
+// to the devices of the known profile, they aren't added.
+func (g *generator) addMetadata(resource string, resourceConfig profiledefinition.MetadataResourceConfig) {
+	exists := g.trie.NonLeafNodeExist
+	if profiledefinition.IsMetadataResourceWithScalarOids(resource) {
+		exists = g.trie.LeafExist
+	}
+	target, ok := g.definition.Metadata[resource]
+	if !ok {
+		target = profiledefinition.MetadataResourceConfig{Fields: make(map[string]profiledefinition.MetadataField)}
+	}
+	for field, fieldConfig := range resourceConfig.Fields {
+		if _, ok := target.Fields[field]; ok || fieldConfig.Value != "" {
+			continue
+		}
+		var symbols []profiledefinition.SymbolConfig
+		for _, symbol := range fieldConfig.Symbols {
+			if exists(symbol.OID) {
+				symbols = append(symbols, symbol)
+			}
+		}
+		if exists(fieldConfig.Symbol.OID) {
+			target.Fields[field] = profiledefinition.MetadataField{Symbol: fieldConfig.Symbol}
+		} else if len(symbols) > 0 {
+			target.Fields[field] = profiledefinition.MetadataField{Symbols: symbols}
+		}
+	}
+	if len(target.IDTags) == 0 {
+		for _, idTag := range resourceConfig.IDTags {
+			if exists(idTag.Symbol.OID) {
+				target.IDTags = append(target.IDTags, idTag)
+			}
+		}
+	}
+	if len(target.Fields) > 0 || len(target.IDTags) > 0 {
+		g.definition.Metadata[resource] = target
+	}
+}
+
+func (g *generator) addMIB(mib *MIB) {
+	for _, object := range mib.Objects {
+		switch object.Kind {
+		case MIBObjectTable:
+			g.addMIBTable(mib, object)
+		case MIBObjectScalar:
+			oid := object.OID + ".0"
+			if isMIBMetric(object) && !g.seenOIDs[oid] && g.trie.LeafExist(oid) {
+				g.seenOIDs[oid] = true
+				g.definition.Metrics = append(g.definition.Metrics, profiledefinition.MetricsConfig{
+					MIB:    object.Module,
+					Symbol: profiledefinition.SymbolConfig{OID: oid, Name: object.Name},
+				})
+			}
+		}
+	}
+}
+
+// addMIBTable adds the numeric columns of a table found in the walk as metrics, tagged by the readable and
+// enumerated columns of the table, or by the indexes of the table if it has none
+func (g *generator) addMIBTable(mib *MIB, table *MIBObject) {
+	row, ok := mib.Row(table)
+	if !ok {
+		return
+	}
+	indexes := make(map[string]bool, len(row.Index))
+	for _, index := range row.Index {
+		indexes[index] = true
+	}
+
+	metric := profiledefinition.MetricsConfig{
+		MIB:   table.Module,
+		Table: profiledefinition.SymbolConfig{OID: table.OID, Name: table.Name},
+	}
+	for _, column := range mib.Children(row.OID) {
+		if column.Kind != MIBObjectColumn || !g.trie.NonLeafNodeExist(column.OID) {
+			continue
+		}
+		switch {
+		case isMIBMetric(column) && !indexes[column.Name]:
+			if !g.seenOIDs[column.OID] {
+				g.seenOIDs[column.OID] = true
+				metric.Symbols = append(metric.Symbols, profiledefinition.SymbolConfig{OID: column.OID, Name: column.Name})
+			}
+		case len(column.Enum) > 0:
+			mapping := make(profiledefinition.ListMap[string], len(column.Enum))
+			for value, name := range column.Enum {
+				mapping[value] = name
+			}
+			metric.MetricTags = append(metric.MetricTags, profiledefinition.MetricTagConfig{
+				Tag:     tagName(column.Name),
+				Symbol:  profiledefinition.SymbolConfigCompat{OID: column.OID, Name: column.Name},
+				Mapping: mapping,
+			})
+		case column.Syntax == "OCTET STRING" && !binaryStringTypes[column.Type]:
+			metric.MetricTags = append(metric.MetricTags, profiledefinition.MetricTagConfig{
+				Tag:    tagName(column.Name),
+				Symbol: profiledefinition.SymbolConfigCompat{OID: column.OID, Name: column.Name},
+			})
+		}
+	}
+	if len(metric.Symbols) == 0 {
+		return
+	}
+	if len(metric.MetricTags) == 0 {
+		for i, index := range row.Index {
+			metric.MetricTags = append(metric.MetricTags, profiledefinition.MetricTagConfig{Tag: tagName(index), Index: uint(i + 1)})
+		}
+	}
+	g.definition.Metrics = append(g.definition.Metrics, metric)
+}
+
+// isMIBMetric returns true for the readable numeric objects, except the enumerations which are states rather than
+// measures
+func isMIBMetric(object *MIBObject) bool {
+	if !metricSyntaxes[object.Syntax] || len(object.Enum) > 0 {
+		return false
+	}
+	return object.Access != "not-accessible" && object.Access != "accessible-for-notify"
+}
+
+// tagName converts the name of a MIB object to a tag, like `acmeDiskName` to `acme_disk_name`
+func tagName(name string) string {
+	var builder strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// acronyms stay in a single word: ifHCInOctets becomes if_hc_in_octets
+			if i > 0 && runes[i-1] != '-' && (!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		if r == '-' {
+			r = '_'
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profiletool
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
+)
+
+func TestGenerate(t *testing.T) {
+	confdPath, _ := filepath.Abs(filepath.Join("..", "internal", "test", "conf.d"))
+	config.Datadog().SetWithoutSource("confd_path", confdPath)
+	knownProfiles, err := LoadProfiles()
+	require.NoError(t, err)
+	walk, err := ReadWalkFile(filepath.Join("testdata", "f5-big-ip.snmprec"))
+	require.NoError(t, err)
+	mib, err := CompileMIBs(filepath.Join("testdata", "mibs"))
+	require.NoError(t, err)
+
+	definition := Generate(walk, knownProfiles, mib)
+
+	assert.Equal(t, profiledefinition.StringArray{"1.3.6.1.4.1.3375.2.1.3.4.43"}, definition.SysObjectIDs)
+
+	var symbols []string
+	for _, metric := range definition.Metrics {
+		if metric.IsScalar() {
+			symbols = append(symbols, metric.Symbol.Name)
+		}
+		for _, symbol := range metric.Symbols {
+			symbols = append(symbols, symbol.Name)
+		}
+	}
+	// the metrics absent from the walk, like oldSyntax, aren't added, and metrics aren't duplicated
+	assert.Equal(t, []string{"sysStatMemoryTotal", "ifInErrors", "ifInDiscards", "acmeUptimeSeconds", "acmeDiskReadOps", "acmeDiskTemp"}, symbols)
+
+	acmeDisk := definition.Metrics[len(definition.Metrics)-1]
+	assert.Equal(t, "ACME-STORAGE-MIB", acmeDisk.MIB)
+	assert.Equal(t, profiledefinition.SymbolConfig{OID: "1.3.6.1.4.1.99999.1.1.3", Name: "acmeDiskTable"}, acmeDisk.Table)
+	assert.Equal(t, profiledefinition.MetricTagConfigList{
+		{
+			Tag:    "acme_disk_name",
+			Symbol: profiledefinition.SymbolConfigCompat{OID: "1.3.6.1.4.1.99999.1.1.3.1.2", Name: "acmeDiskName"},
+		},
+		{
+			Tag:     "acme_disk_health",
+			Symbol:  profiledefinition.SymbolConfigCompat{OID: "1.3.6.1.4.1.99999.1.1.3.1.3", Name: "acmeDiskHealth"},
+			Mapping: profiledefinition.ListMap[string]{"1": "ok", "2": "degraded", "3": "failed"},
+		},
+	}, acmeDisk.MetricTags)
+
+	var globalTags []string
+	for _, metricTag := range definition.MetricTags {
+		globalTags = append(globalTags, metricTagName(metricTag))
+	}
+	assert.ElementsMatch(t, []string{"snmp_host", "snmp_host2", "prefix,some_tag,suffix"}, globalTags)
+
+	// constant values are specific to the known profiles
+	assert.NotContains(t, definition.Metadata["device"].Fields, "vendor")
+	assert.Contains(t, definition.Metadata["device"].Fields, "serial_number")
+	assert.Contains(t, definition.Metadata["interface"].Fields, "oper_status")
+
+	// the generated profile is valid and resolves against the walk
+	dir := t.TempDir()
+	var out bytes.Buffer
+	require.NoError(t, WriteProfile(&out, definition))
+	profileFile := filepath.Join(dir, "acme.yaml")
+	require.NoError(t, os.WriteFile(profileFile, out.Bytes(), 0600))
+	config.Datadog().SetWithoutSource("confd_path", t.TempDir())
+	generated, err := ReadProfile(profileFile)
+	require.NoError(t, err)
+	r, err := Validate("acme", generated, walk)
+	require.NoError(t, err)
+	assert.True(t, r.SysObjectIDMatch)
+	for _, resolution := range r.Metrics {
+		assert.True(t, resolution.Resolved(), resolution.Name)
+	}
+}
+
+func TestTagName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"acmeDiskName":     "acme_disk_name",
+		"ifHCInOctets":     "if_hc_in_octets",
+		"sysUpTime":        "sys_up_time",
+		"cpmCPUTotal5min":  "cpm_cpu_total5min",
+		"entPhysical-Name": "ent_physical_name",
+	} {
+		assert.Equal(t, expected, tagName(name), name)
+	}
+}
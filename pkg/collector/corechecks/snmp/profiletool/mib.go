@@ -0,0 +1,811 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profiletool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MIBObjectKind is the kind of a MIB object
+type MIBObjectKind string
+
+const (
+	// MIBObjectNode is a node of the OID tree without value, like a module identity or an object group
+	MIBObjectNode MIBObjectKind = "node"
+	// MIBObjectScalar is an object with a single instance
+	MIBObjectScalar MIBObjectKind = "scalar"
+	// MIBObjectTable is a table, its only child is its row
+	MIBObjectTable MIBObjectKind = "table"
+	// MIBObjectRow is the row of a table, its children are the columns of the table
+	MIBObjectRow MIBObjectKind = "row"
+	// MIBObjectColumn is a column of a table
+	MIBObjectColumn MIBObjectKind = "column"
+	// MIBObjectNotification is a notification (trap)
+	MIBObjectNotification MIBObjectKind = "notification"
+)
+
+// MIBObject is an object defined by a MIB module
+type MIBObject struct {
+	Module string        `json:"module"`
+	Name   string        `json:"name"`
+	OID    string        `json:"oid"`
+	Kind   MIBObjectKind `json:"kind"`
+	// Type is the declared syntax of the object, like DisplayString
+	Type string `json:"type,omitempty"`
+	// Syntax is the base syntax of the object, like OCTET STRING for a DisplayString
+	Syntax   string            `json:"syntax,omitempty"`
+	Access   string            `json:"access,omitempty"`
+	Units    string            `json:"units,omitempty"`
+	Index    []string          `json:"index,omitempty"`
+	Augments string            `json:"augments,omitempty"`
+	Enum     map[string]string `json:"enum,omitempty"`
+	// Objects are the variables of a notification
+	Objects     []string `json:"objects,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// MIB is a set of compiled MIB modules
+type MIB struct {
+	// Objects are sorted by OID
+	Objects []*MIBObject
+	byOID   map[string]*MIBObject
+	byName  map[string]*MIBObject
+}
+
+// wellKnownOIDs are the roots of the OID tree and the nodes defined by the SMI modules, which are usually not among
+// the MIB files of a device
+var wellKnownOIDs = map[string]string{
+	"ccitt":           "0",
+	"zeroDotZero":     "0.0",
+	"iso":             "1",
+	"joint-iso-ccitt": "2",
+	"org":             "1.3",
+	"dod":             "1.3.6",
+	"internet":        "1.3.6.1",
+	"directory":       "1.3.6.1.1",
+	"mgmt":            "1.3.6.1.2",
+	"mib-2":           "1.3.6.1.2.1",
+	"system":          "1.3.6.1.2.1.1",
+	"interfaces":      "1.3.6.1.2.1.2",
+	"ip":              "1.3.6.1.2.1.4",
+	"transmission":    "1.3.6.1.2.1.10",
+	"snmp":            "1.3.6.1.2.1.11",
+	"experimental":    "1.3.6.1.3",
+	"private":         "1.3.6.1.4",
+	"enterprises":     "1.3.6.1.4.1",
+	"security":        "1.3.6.1.5",
+	"snmpV2":          "1.3.6.1.6",
+	"snmpDomains":     "1.3.6.1.6.1",
+	"snmpProxys":      "1.3.6.1.6.2",
+	"snmpModules":     "1.3.6.1.6.3",
+}
+
+// mibMacros are the SMI macros assigning an OID to the objects they define
+var mibMacros = map[string]bool{
+	"OBJECT-TYPE":        true,
+	"MODULE-IDENTITY":    true,
+	"OBJECT-IDENTITY":    true,
+	"NOTIFICATION-TYPE":  true,
+	"TRAP-TYPE":          true,
+	"OBJECT-GROUP":       true,
+	"NOTIFICATION-GROUP": true,
+	"MODULE-COMPLIANCE":  true,
+	"AGENT-CAPABILITIES": true,
+}
+
+// wellKnownTypes are the base types of SMI and the textual conventions of the SNMPv2-TC module
+var wellKnownTypes = map[string]string{
+	"INTEGER":              "INTEGER",
+	"Integer32":            "INTEGER",
+	"Unsigned32":           "Gauge32",
+	"Gauge32":              "Gauge32",
+	"Gauge":                "Gauge32",
+	"Counter32":            "Counter32",
+	"Counter":              "Counter32",
+	"Counter64":            "Counter64",
+	"TimeTicks":            "TimeTicks",
+	"IpAddress":            "IpAddress",
+	"NetworkAddress":       "IpAddress",
+	"OCTET STRING":         "OCTET STRING",
+	"Opaque":               "OCTET STRING",
+	"BITS":                 "OCTET STRING",
+	"OBJECT IDENTIFIER":    "OBJECT IDENTIFIER",
+	"DisplayString":        "OCTET STRING",
+	"SnmpAdminString":      "OCTET STRING",
+	"PhysAddress":          "OCTET STRING",
+	"MacAddress":           "OCTET STRING",
+	"DateAndTime":          "OCTET STRING",
+	"OwnerString":          "OCTET STRING",
+	"InetAddress":          "OCTET STRING",
+	"AutonomousType":       "OBJECT IDENTIFIER",
+	"VariablePointer":      "OBJECT IDENTIFIER",
+	"RowPointer":           "OBJECT IDENTIFIER",
+	"TruthValue":           "INTEGER",
+	"RowStatus":            "INTEGER",
+	"StorageType":          "INTEGER",
+	"TestAndIncr":          "INTEGER",
+	"TimeInterval":         "INTEGER",
+	"InterfaceIndex":       "INTEGER",
+	"InterfaceIndexOrZero": "INTEGER",
+	"InetAddressType":      "INTEGER",
+	"InetPortNumber":       "Gauge32",
+	"TimeStamp":            "TimeTicks",
+}
+
+// CompileMIBs compiles the MIB modules of files and folders. The names of the objects are resolved across all the
+// modules, regardless of their imports. The files that can't be parsed and the objects whose OID can't be resolved
+// are reported in the returned error, the objects of the other modules are still compiled.
+func CompileMIBs(paths ...string) (*MIB, error) {
+	var modules []*mibModule
+	var errs []error
+	for _, path := range paths {
+		files, err := mibFiles(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			fileModules, err := parseMIBModules(string(content))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", file, err))
+				continue
+			}
+			modules = append(modules, fileModules...)
+		}
+	}
+	mib, err := compileModules(modules)
+	errs = append(errs, err)
+	return mib, errors.Join(errs...)
+}
+
+// mibFiles returns the files of a folder, or the path itself if it's a file
+func mibFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		files = append(files, filepath.Join(path, entry.Name()))
+	}
+	return files, nil
+}
+
+// Lookup returns the object of an OID
+func (m *MIB) Lookup(oid string) (*MIBObject, bool) {
+	object, ok := m.byOID[strings.TrimPrefix(oid, ".")]
+	return object, ok
+}
+
+// LookupName returns the object of a name
+func (m *MIB) LookupName(name string) (*MIBObject, bool) {
+	object, ok := m.byName[name]
+	return object, ok
+}
+
+// Children returns the objects directly below an OID, sorted by OID
+func (m *MIB) Children(oid string) []*MIBObject {
+	var children []*MIBObject
+	prefix := strings.TrimPrefix(oid, ".") + "."
+	for _, object := range m.Objects {
+		if strings.HasPrefix(object.OID, prefix) && !strings.Contains(object.OID[len(prefix):], ".") {
+			children = append(children, object)
+		}
+	}
+	return children
+}
+
+// Columns returns the columns of a table
+func (m *MIB) Columns(table *MIBObject) []*MIBObject {
+	row, ok := m.Row(table)
+	if !ok {
+		return nil
+	}
+	return m.Children(row.OID)
+}
+
+// Row returns the row of a table
+func (m *MIB) Row(table *MIBObject) (*MIBObject, bool) {
+	for _, row := range m.Children(table.OID) {
+		if row.Kind == MIBObjectRow {
+			return row, true
+		}
+	}
+	return nil, false
+}
+
+type mibModule struct {
+	name        string
+	definitions []*mibDefinition
+	// types are the textual conventions and type assignments of the module
+	types map[string]mibSyntax
+}
+
+type mibDefinition struct {
+	object     MIBObject
+	macro      string
+	oidValue   []oidComponent
+	sequenceOf string
+	enterprise string
+	// trapNumber is the number of SMIv1 traps, which are assigned a number instead of an OID
+	trapNumber int
+}
+
+type oidComponent struct {
+	name      string
+	number    int
+	hasNumber bool
+}
+
+type mibSyntax struct {
+	name       string
+	enum       map[string]string
+	sequenceOf string
+}
+
+type mibParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseMIBModules parses the modules of a MIB file
+func parseMIBModules(content string) ([]*mibModule, error) {
+	p := &mibParser{tokens: tokenizeMIB(content)}
+	var modules []*mibModule
+	for !p.done() {
+		// <module> DEFINITIONS ::= BEGIN
+		if p.peekAt(1) != "DEFINITIONS" {
+			p.pos++
+			continue
+		}
+		module := &mibModule{name: p.next(), types: make(map[string]mibSyntax)}
+		p.skipUntil("BEGIN")
+		p.pos++
+		p.parseModuleBody(module)
+		modules = append(modules, module)
+	}
+	if len(modules) == 0 {
+		return nil, errors.New("no MIB module found")
+	}
+	return modules, nil
+}
+
+func (p *mibParser) parseModuleBody(module *mibModule) {
+	for !p.done() {
+		token := p.next()
+		switch token {
+		case "END":
+			return
+		case "IMPORTS", "EXPORTS":
+			p.skipUntil(";")
+			p.pos++
+			continue
+		}
+		name := token
+		switch p.peek() {
+		case "MACRO":
+			// the definitions of the SMI macros, in SNMPv2-SMI for instance
+			p.skipUntil("END")
+			p.pos++
+		case "::=":
+			p.pos++
+			p.parseTypeAssignment(module, name)
+		case "OBJECT":
+			if p.peekAt(1) != "IDENTIFIER" || p.peekAt(2) != "::=" {
+				p.pos++
+				continue
+			}
+			p.pos += 3
+			module.definitions = append(module.definitions, &mibDefinition{
+				object:   MIBObject{Module: module.name, Name: name},
+				macro:    "OBJECT IDENTIFIER",
+				oidValue: p.parseOIDValue(),
+			})
+		default:
+			if !mibMacros[p.peek()] || !isLower(name) {
+				continue
+			}
+			if definition := p.parseMacroValue(module.name, name); definition != nil {
+				module.definitions = append(module.definitions, definition)
+			}
+		}
+	}
+}
+
+// parseTypeAssignment parses `Name ::= <type>` assignments, they are the textual conventions and the SEQUENCE types
+// of the table rows
+func (p *mibParser) parseTypeAssignment(module *mibModule, name string) {
+	switch p.peek() {
+	case "TEXTUAL-CONVENTION":
+		for !p.done() && p.peek() != "SYNTAX" {
+			p.skipToken()
+		}
+		p.pos++
+		module.types[name] = p.parseSyntax()
+	case "SEQUENCE", "CHOICE":
+		if p.peekAt(1) == "{" {
+			p.pos++
+			p.skipToken()
+			return
+		}
+		module.types[name] = p.parseSyntax()
+	default:
+		module.types[name] = p.parseSyntax()
+	}
+}
+
+// parseMacroValue parses the invocation of a macro, like OBJECT-TYPE, up to its value
+func (p *mibParser) parseMacroValue(moduleName string, name string) *mibDefinition {
+	definition := &mibDefinition{
+		object: MIBObject{Module: moduleName, Name: name},
+		macro:  p.next(),
+	}
+	for !p.done() && p.peek() != "::=" {
+		switch p.peek() {
+		case "SYNTAX":
+			p.pos++
+			syntax := p.parseSyntax()
+			if definition.object.Type == "" {
+				definition.object.Type = syntax.name
+				definition.object.Enum = syntax.enum
+				definition.sequenceOf = syntax.sequenceOf
+			}
+		case "MAX-ACCESS", "ACCESS":
+			p.pos++
+			definition.object.Access = p.next()
+		case "UNITS":
+			p.pos++
+			definition.object.Units = unquoteMIBString(p.next())
+		case "DESCRIPTION":
+			p.pos++
+			if definition.object.Description == "" {
+				definition.object.Description = unquoteMIBString(p.next())
+			}
+		case "INDEX":
+			p.pos++
+			definition.object.Index = p.parseNameList()
+		case "AUGMENTS":
+			p.pos++
+			if augments := p.parseNameList(); len(augments) > 0 {
+				definition.object.Augments = augments[0]
+			}
+		case "OBJECTS", "VARIABLES":
+			p.pos++
+			definition.object.Objects = p.parseNameList()
+		case "ENTERPRISE":
+			p.pos++
+			definition.enterprise = p.next()
+		case "END":
+			// not a macro invocation, don't consume the end of the module
+			return nil
+		default:
+			p.skipToken()
+		}
+	}
+	p.pos++
+	if p.peek() == "{" {
+		definition.oidValue = p.parseOIDValue()
+		return definition
+	}
+	number, err := strconv.Atoi(p.peek())
+	if err != nil || definition.enterprise == "" {
+		return nil
+	}
+	p.pos++
+	definition.trapNumber = number
+	return definition
+}
+
+// parseSyntax parses a syntax like `INTEGER { up(1), down(2) }`, `OCTET STRING (SIZE (0..255))` or
+// `SEQUENCE OF IfEntry`
+func (p *mibParser) parseSyntax() mibSyntax {
+	var syntax mibSyntax
+	for p.peek() == "[" || p.peek() == "IMPLICIT" {
+		// tags of the application types of SNMPv2-SMI, like `[APPLICATION 1] IMPLICIT INTEGER`
+		p.skipToken()
+	}
+	switch token := p.next(); token {
+	case "OCTET", "OBJECT":
+		syntax.name = token + " " + p.next()
+	case "SEQUENCE":
+		if p.peek() == "OF" {
+			p.pos++
+			syntax.name = "SEQUENCE OF"
+			syntax.sequenceOf = p.next()
+		} else {
+			syntax.name = token
+		}
+	default:
+		syntax.name = token
+	}
+	if p.peek() == "{" {
+		syntax.enum = p.parseNamedNumbers()
+	}
+	if p.peek() == "(" {
+		// size and range constraints
+		p.skipToken()
+	}
+	return syntax
+}
+
+// parseNamedNumbers parses the values of enumerations and bits, like `{ up(1), down(2) }`
+func (p *mibParser) parseNamedNumbers() map[string]string {
+	values := make(map[string]string)
+	p.pos++
+	for !p.done() && p.peek() != "}" {
+		name := p.next()
+		if p.peek() == "(" && p.peekAt(2) == ")" {
+			values[p.peekAt(1)] = name
+			p.pos += 3
+		}
+	}
+	p.pos++
+	return values
+}
+
+// parseNameList parses the lists of INDEX, AUGMENTS and OBJECTS clauses, like `{ ifIndex }`
+func (p *mibParser) parseNameList() []string {
+	if p.peek() != "{" {
+		return nil
+	}
+	p.pos++
+	var names []string
+	for !p.done() && p.peek() != "}" {
+		token := p.next()
+		if token != "," && token != "IMPLIED" {
+			names = append(names, token)
+		}
+	}
+	p.pos++
+	return names
+}
+
+// parseOIDValue parses an OID value like `{ ifEntry 1 }` or `{ iso org(3) dod(6) 1 }`
+func (p *mibParser) parseOIDValue() []oidComponent {
+	if p.peek() != "{" {
+		return nil
+	}
+	p.pos++
+	var components []oidComponent
+	for !p.done() && p.peek() != "}" {
+		token := p.next()
+		if number, err := strconv.Atoi(token); err == nil {
+			components = append(components, oidComponent{number: number, hasNumber: true})
+			continue
+		}
+		component := oidComponent{name: token}
+		if p.peek() == "(" && p.peekAt(2) == ")" {
+			if number, err := strconv.Atoi(p.peekAt(1)); err == nil {
+				component.number = number
+				component.hasNumber = true
+			}
+			p.pos += 3
+		}
+		components = append(components, component)
+	}
+	p.pos++
+	return components
+}
+
+func (p *mibParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *mibParser) peek() string {
+	return p.peekAt(0)
+}
+
+func (p *mibParser) peekAt(offset int) string {
+	if p.pos+offset >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *mibParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+// skipToken skips a token, or a whole block if the token opens one
+func (p *mibParser) skipToken() {
+	closing := map[string]string{"{": "}", "(": ")", "[": "]"}[p.peek()]
+	opening := p.next()
+	if closing == "" {
+		return
+	}
+	for depth := 1; !p.done() && depth > 0; {
+		switch p.next() {
+		case opening:
+			depth++
+		case closing:
+			depth--
+		}
+	}
+}
+
+func (p *mibParser) skipUntil(token string) {
+	for !p.done() && p.peek() != token {
+		p.pos++
+	}
+}
+
+// tokenizeMIB splits a MIB file into tokens, skipping the comments. Strings are kept quoted.
+func tokenizeMIB(content string) []string {
+	var tokens []string
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case strings.HasPrefix(content[i:], "--"):
+			// comments end at the end of the line or at the next `--`
+			end := i + 2
+			for end < len(content) && content[end] != '\n' && !strings.HasPrefix(content[end:], "--") {
+				end++
+			}
+			if strings.HasPrefix(content[end:], "--") {
+				end += 2
+			}
+			i = end
+		case c == '"':
+			end := strings.IndexByte(content[i+1:], '"')
+			if end < 0 {
+				end = len(content) - i - 1
+			}
+			tokens = append(tokens, content[i:i+end+2])
+			i += end + 2
+		case c == '\'':
+			// binary and hex strings, like '00'H
+			end := strings.IndexByte(content[i+1:], '\'')
+			if end < 0 {
+				end = len(content) - i - 1
+			}
+			end = i + end + 2
+			if end < len(content) && (content[end] == 'H' || content[end] == 'h' || content[end] == 'B' || content[end] == 'b') {
+				end++
+			}
+			tokens = append(tokens, content[i:min(end, len(content))])
+			i = end
+		case strings.HasPrefix(content[i:], "::="):
+			tokens = append(tokens, "::=")
+			i += 3
+		case strings.HasPrefix(content[i:], ".."):
+			tokens = append(tokens, "..")
+			i += 2
+		case isMIBIdentifierChar(c):
+			end := i + 1
+			for end < len(content) && isMIBIdentifierChar(content[end]) && !strings.HasPrefix(content[end:], "--") {
+				end++
+			}
+			tokens = append(tokens, content[i:end])
+			i = end
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens
+}
+
+func isMIBIdentifierChar(c byte) bool {
+	return c == '-' || c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isLower(name string) bool {
+	return name != "" && name[0] >= 'a' && name[0] <= 'z'
+}
+
+func unquoteMIBString(token string) string {
+	token = strings.TrimSuffix(strings.TrimPrefix(token, `"`), `"`)
+	// descriptions are indented on several lines
+	return strings.Join(strings.Fields(token), " ")
+}
+
+// compileModules resolves the OIDs, syntaxes and kinds of the objects of the modules
+func compileModules(modules []*mibModule) (*MIB, error) {
+	resolver := &oidResolver{
+		modules:  make(map[string]*mibModule),
+		byModule: make(map[string]map[string]*mibDefinition),
+		byName:   make(map[string]*mibDefinition),
+		oids:     make(map[*mibDefinition]string),
+		visiting: make(map[*mibDefinition]bool),
+	}
+	for _, module := range modules {
+		resolver.modules[module.name] = module
+		definitions := make(map[string]*mibDefinition, len(module.definitions))
+		for _, definition := range module.definitions {
+			definitions[definition.object.Name] = definition
+			if _, ok := resolver.byName[definition.object.Name]; !ok {
+				resolver.byName[definition.object.Name] = definition
+			}
+		}
+		resolver.byModule[module.name] = definitions
+	}
+
+	mib := &MIB{
+		byOID:  make(map[string]*MIBObject),
+		byName: make(map[string]*MIBObject),
+	}
+	var errs []error
+	for _, module := range modules {
+		for _, definition := range module.definitions {
+			oid, err := resolver.resolve(module.name, definition)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s::%s: %w", module.name, definition.object.Name, err))
+				continue
+			}
+			if _, ok := mib.byOID[oid]; ok {
+				// the same module can be found in several files
+				continue
+			}
+			object := definition.object
+			object.OID = oid
+			object.Syntax = resolver.baseSyntax(module.name, object.Type, &object)
+			switch {
+			case definition.macro == "NOTIFICATION-TYPE" || definition.macro == "TRAP-TYPE":
+				object.Kind = MIBObjectNotification
+			case definition.macro != "OBJECT-TYPE":
+				object.Kind = MIBObjectNode
+			case definition.sequenceOf != "":
+				object.Kind = MIBObjectTable
+			case len(object.Index) > 0 || object.Augments != "":
+				object.Kind = MIBObjectRow
+			default:
+				object.Kind = MIBObjectScalar
+			}
+			mib.Objects = append(mib.Objects, &object)
+			mib.byOID[oid] = &object
+			if _, ok := mib.byName[object.Name]; !ok {
+				mib.byName[object.Name] = &object
+			}
+		}
+	}
+
+	// the objects below a row are its columns
+	for _, object := range mib.Objects {
+		if object.Kind != MIBObjectScalar {
+			continue
+		}
+		parent, ok := mib.byOID[object.OID[:strings.LastIndex(object.OID, ".")]]
+		if ok && parent.Kind == MIBObjectRow {
+			object.Kind = MIBObjectColumn
+		}
+	}
+	// rows augmenting another row are indexed like the row they augment
+	for _, object := range mib.Objects {
+		if object.Kind == MIBObjectRow && len(object.Index) == 0 {
+			if augmented, ok := mib.byName[object.Augments]; ok {
+				object.Index = augmented.Index
+			}
+		}
+	}
+	numbers := make(map[*MIBObject][]int, len(mib.Objects))
+	for _, object := range mib.Objects {
+		numbers[object] = oidNumbers(object.OID)
+	}
+	sort.Slice(mib.Objects, func(i, j int) bool {
+		return compareOIDs(numbers[mib.Objects[i]], numbers[mib.Objects[j]]) < 0
+	})
+	return mib, errors.Join(errs...)
+}
+
+type oidResolver struct {
+	modules  map[string]*mibModule
+	byModule map[string]map[string]*mibDefinition
+	byName   map[string]*mibDefinition
+	oids     map[*mibDefinition]string
+	visiting map[*mibDefinition]bool
+}
+
+func (r *oidResolver) resolve(moduleName string, definition *mibDefinition) (string, error) {
+	if oid, ok := r.oids[definition]; ok {
+		return oid, nil
+	}
+	if r.visiting[definition] {
+		return "", errors.New("cyclic OID definition")
+	}
+	r.visiting[definition] = true
+	defer delete(r.visiting, definition)
+
+	var oid string
+	if definition.oidValue == nil {
+		// SMIv1 traps are numbered below their enterprise: <enterprise>.0.<number>
+		enterprise, err := r.resolveName(moduleName, definition.enterprise)
+		if err != nil {
+			return "", err
+		}
+		oid = enterprise + ".0." + strconv.Itoa(definition.trapNumber)
+	} else {
+		var numbers []string
+		for i, component := range definition.oidValue {
+			switch {
+			case i == 0 && component.name != "" && !(component.hasNumber && isWellKnownRoot(component.name)):
+				parent, err := r.resolveName(moduleName, component.name)
+				if err != nil {
+					return "", err
+				}
+				numbers = append(numbers, parent)
+			case component.hasNumber:
+				numbers = append(numbers, strconv.Itoa(component.number))
+			default:
+				return "", fmt.Errorf("invalid OID component %q", component.name)
+			}
+		}
+		oid = strings.Join(numbers, ".")
+	}
+	r.oids[definition] = oid
+	return oid, nil
+}
+
+// isWellKnownRoot returns true for the names of the roots of the OID tree, which are written with their number in
+// OID values like `{ iso(1) org(3) 6 }`
+func isWellKnownRoot(name string) bool {
+	return name == "iso" || name == "ccitt" || name == "joint-iso-ccitt"
+}
+
+// resolveName resolves the OID of a name, the names of the module take precedence over the names of other modules
+func (r *oidResolver) resolveName(moduleName string, name string) (string, error) {
+	if definition, ok := r.byModule[moduleName][name]; ok {
+		return r.resolve(moduleName, definition)
+	}
+	if definition, ok := r.byName[name]; ok {
+		return r.resolve(definition.object.Module, definition)
+	}
+	if oid, ok := wellKnownOIDs[name]; ok {
+		return oid, nil
+	}
+	return "", fmt.Errorf("unknown parent %q", name)
+}
+
+// baseSyntax returns the base syntax of a declared syntax, following the textual conventions. The enumeration of
+// the textual convention is set on the object if it doesn't define one.
+func (r *oidResolver) baseSyntax(moduleName string, typeName string, object *MIBObject) string {
+	for i := 0; i < 10 && typeName != ""; i++ {
+		if base, ok := wellKnownTypes[typeName]; ok {
+			return base
+		}
+		syntax, ok := r.modules[moduleName].types[typeName]
+		if !ok {
+			for name, module := range r.modules {
+				if syntax, ok = module.types[typeName]; ok {
+					moduleName = name
+					break
+				}
+			}
+		}
+		if !ok {
+			return ""
+		}
+		if len(object.Enum) == 0 && len(syntax.enum) > 0 {
+			object.Enum = syntax.enum
+		}
+		typeName = syntax.name
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profiletool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileMIBs(t *testing.T) {
+	mib, err := CompileMIBs(filepath.Join("testdata", "mibs"))
+	require.NoError(t, err)
+
+	var objects []string
+	for _, object := range mib.Objects {
+		objects = append(objects, object.OID+" "+object.Name+" "+string(object.Kind))
+	}
+	assert.Equal(t, []string{
+		"1.3.6.1.4.1.99999 acme node",
+		"1.3.6.1.4.1.99999.0.1 acmeControllerReboot notification",
+		"1.3.6.1.4.1.99999.1 acmeStorageMIB node",
+		"1.3.6.1.4.1.99999.1.1 acmeStorageObjects node",
+		"1.3.6.1.4.1.99999.1.1.1 acmeUptimeSeconds scalar",
+		"1.3.6.1.4.1.99999.1.1.2 acmeSerialNumber scalar",
+		"1.3.6.1.4.1.99999.1.1.3 acmeDiskTable table",
+		"1.3.6.1.4.1.99999.1.1.3.1 acmeDiskEntry row",
+		"1.3.6.1.4.1.99999.1.1.3.1.1 acmeDiskIndex column",
+		"1.3.6.1.4.1.99999.1.1.3.1.2 acmeDiskName column",
+		"1.3.6.1.4.1.99999.1.1.3.1.3 acmeDiskHealth column",
+		"1.3.6.1.4.1.99999.1.1.3.1.4 acmeDiskReadOps column",
+		"1.3.6.1.4.1.99999.1.1.3.1.5 acmeDiskTemp column",
+		"1.3.6.1.4.1.99999.1.2 acmeStorageNotifications node",
+		"1.3.6.1.4.1.99999.1.2.1 acmeDiskFailed notification",
+	}, objects)
+
+	uptime, ok := mib.LookupName("acmeUptimeSeconds")
+	require.True(t, ok)
+	assert.Equal(t, &MIBObject{
+		Module:      "ACME-STORAGE-MIB",
+		Name:        "acmeUptimeSeconds",
+		OID:         "1.3.6.1.4.1.99999.1.1.1",
+		Kind:        MIBObjectScalar,
+		Type:        "Counter32",
+		Syntax:      "Counter32",
+		Access:      "read-only",
+		Units:       "seconds",
+		Description: "Seconds since the controller started.",
+	}, uptime)
+
+	serialNumber, ok := mib.Lookup(".1.3.6.1.4.1.99999.1.1.2")
+	require.True(t, ok)
+	assert.Equal(t, "DisplayString", serialNumber.Type)
+	assert.Equal(t, "OCTET STRING", serialNumber.Syntax)
+	assert.Equal(t, "The serial number of the array.", serialNumber.Description)
+
+	// the enumeration of the textual convention is inherited
+	health, ok := mib.LookupName("acmeDiskHealth")
+	require.True(t, ok)
+	assert.Equal(t, "AcmeHealth", health.Type)
+	assert.Equal(t, "INTEGER", health.Syntax)
+	assert.Equal(t, map[string]string{"1": "ok", "2": "degraded", "3": "failed"}, health.Enum)
+
+	table, ok := mib.LookupName("acmeDiskTable")
+	require.True(t, ok)
+	row, ok := mib.Row(table)
+	require.True(t, ok)
+	assert.Equal(t, []string{"acmeDiskIndex"}, row.Index)
+	assert.Len(t, mib.Columns(table), 5)
+}
+
+func TestCompileMIBsErrors(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("not a MIB"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "BROKEN-MIB.txt"), []byte(`BROKEN-MIB DEFINITIONS ::= BEGIN
+brokenScalar OBJECT-TYPE
+    SYNTAX      Integer32
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Its parent is unknown."
+    ::= { unknownParent 1 }
+validScalar OBJECT-TYPE
+    SYNTAX      Integer32
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "A scalar."
+    ::= { enterprises 99998 1 }
+END
+`), 0600))
+
+	mib, err := CompileMIBs(dir)
+	assert.ErrorContains(t, err, "README: no MIB module found")
+	assert.ErrorContains(t, err, `BROKEN-MIB::brokenScalar: unknown parent "unknownParent"`)
+	// the valid objects are still compiled
+	object, ok := mib.LookupName("validScalar")
+	require.True(t, ok)
+	assert.Equal(t, "1.3.6.1.4.1.99998.1", object.OID)
+}
+
+func TestTokenizeMIB(t *testing.T) {
+	assert.Equal(t, []string{"a", "::=", "{", "b", "1", "}", `"a -- string"`, "c", "'0A'H", "(", "0", "..", "10", ")", "d"},
+		tokenizeMIB("a ::= { b 1 } -- comment\n\"a -- string\" c -- inline -- '0A'H (0..10)\nd"))
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profiletool
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteReport writes a report as text: the resolution of the metrics, tags and metadata fields of the profile, then
+// the submitted samples if verbose is set
+func WriteReport(w io.Writer, r *Report, verbose bool) error {
+	fmt.Fprintf(w, "Profile: %s\n", r.Profile)
+	switch {
+	case r.SysObjectID == "":
+		fmt.Fprintln(w, "sysObjectID: not found in the walk")
+	case r.SysObjectIDMatch:
+		fmt.Fprintf(w, "sysObjectID: %s (matches the profile)\n", r.SysObjectID)
+	default:
+		fmt.Fprintf(w, "sysObjectID: %s (does not match the profile)\n", r.SysObjectID)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	writeResolutions(tw, "Metrics", r.Metrics, true)
+	writeResolutions(tw, "Metric tags", r.MetricTags, false)
+	writeResolutions(tw, "Metadata", r.Metadata, false)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	unresolved := 0
+	for _, resolutions := range [][]Resolution{r.Metrics, r.MetricTags, r.Metadata} {
+		for _, resolution := range resolutions {
+			if !resolution.Resolved() {
+				unresolved++
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d samples, %d unresolved symbols\n", len(r.Samples), unresolved)
+
+	if verbose && len(r.Samples) > 0 {
+		fmt.Fprintln(w, "\nSamples")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, sample := range r.Samples {
+			fmt.Fprintf(tw, "  %s\t%s\t%v\t%s\n", sample.Name, sample.Type, sample.Value, strings.Join(sample.Tags, ","))
+		}
+		return tw.Flush()
+	}
+	return nil
+}
+
+func writeResolutions(tw *tabwriter.Writer, title string, resolutions []Resolution, withSamples bool) {
+	if len(resolutions) == 0 {
+		return
+	}
+	fmt.Fprintf(tw, "\n%s\n", title)
+	for _, resolution := range resolutions {
+		status := "ok"
+		if !resolution.Resolved() {
+			status = "MISSING"
+		}
+		details := fmt.Sprintf("%d values", resolution.Values)
+		if withSamples {
+			details += fmt.Sprintf(", %d samples", resolution.Samples)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", status, resolution.Name, resolution.Symbol, resolution.OID, details)
+	}
+}
+
+// WriteDiff writes the difference between two reports as text
+func WriteDiff(w io.Writer, d ReportDiff) error {
+	if d.Empty() {
+		_, err := fmt.Fprintln(w, "No difference")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, sample := range d.Removed {
+		fmt.Fprintf(tw, "- %s\t%s\t%v\t%s\n", sample.Name, sample.Type, sample.Value, strings.Join(sample.Tags, ","))
+	}
+	for _, sample := range d.Added {
+		fmt.Fprintf(tw, "+ %s\t%s\t%v\t%s\n", sample.Name, sample.Type, sample.Value, strings.Join(sample.Tags, ","))
+	}
+	for _, change := range d.Changed {
+		fmt.Fprintf(tw, "~ %s\t%s -> %s\t%v -> %v\t%s\n", change.New.Name, change.Old.Type, change.New.Type, change.Old.Value,
+			change.New.Value, strings.Join(change.New.Tags, ","))
+	}
+	for _, change := range d.Metadata {
+		fmt.Fprintf(tw, "~ %s\t%q -> %q\n", change.Field, change.Old, change.New)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d added, %d removed, %d changed samples, %d changed metadata fields\n",
+		len(d.Added), len(d.Removed), len(d.Changed), len(d.Metadata))
+	return err
+}
+
+// WriteMIB writes the objects of compiled MIBs as text
+func WriteMIB(w io.Writer, mib *MIB) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, object := range mib.Objects {
+		syntax := object.Type
+		if object.Syntax != "" && object.Syntax != object.Type {
+			syntax += " (" + object.Syntax + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s::%s\t%s\t%s\n", object.OID, object.Module, object.Name, object.Kind, syntax)
+	}
+	return tw.Flush()
+}
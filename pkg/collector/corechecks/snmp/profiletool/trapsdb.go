@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profiletool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/snmptraps/oidresolver"
+)
+
+// GenerateTrapsDB returns the trap database of the notifications of a MIB, in the format of the files of the
+// snmp.d/traps_db folder. The variables of the database are the objects of the notifications. The objects that
+// can't be found in the MIB are returned, the trap database is still generated without them.
+func GenerateTrapsDB(mib *MIB) (oidresolver.TrapDBFileContent, []string) {
+	content := oidresolver.TrapDBFileContent{
+		Traps:     make(oidresolver.TrapSpec),
+		Variables: make(oidresolver.VariableSpec),
+	}
+	var missing []string
+	for _, object := range mib.Objects {
+		if object.Kind != MIBObjectNotification {
+			continue
+		}
+		content.Traps[object.OID] = oidresolver.TrapMetadata{
+			Name:        object.Name,
+			MIBName:     object.Module,
+			Description: object.Description,
+		}
+		for _, name := range object.Objects {
+			variable, ok := mib.LookupName(name)
+			if !ok {
+				missing = append(missing, object.Module+"::"+object.Name+": "+name)
+				continue
+			}
+			content.Variables[variable.OID] = variableMetadata(variable)
+		}
+	}
+	return content, missing
+}
+
+func variableMetadata(object *MIBObject) oidresolver.VariableMetadata {
+	metadata := oidresolver.VariableMetadata{
+		Name:        object.Name,
+		Description: object.Description,
+	}
+	if len(object.Enum) == 0 {
+		return metadata
+	}
+	values := make(map[int]string, len(object.Enum))
+	for number, name := range object.Enum {
+		if i, err := strconv.Atoi(number); err == nil {
+			values[i] = name
+		}
+	}
+	if object.Type == "BITS" {
+		metadata.Bits = values
+	} else {
+		metadata.Enumeration = values
+	}
+	return metadata
+}
+
+// WriteTrapsDB writes a trap database as YAML, or as JSON if asJSON is true
+func WriteTrapsDB(w io.Writer, content oidresolver.TrapDBFileContent, asJSON bool) error {
+	var data []byte
+	var err error
+	if asJSON {
+		data, err = json.MarshalIndent(content, "", "  ")
+	} else {
+		data, err = yaml.Marshal(content)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal the trap database: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profiletool
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/snmptraps/oidresolver"
+)
+
+func TestGenerateTrapsDB(t *testing.T) {
+	mib, err := CompileMIBs(filepath.Join("testdata", "mibs"))
+	require.NoError(t, err)
+
+	content, missing := GenerateTrapsDB(mib)
+	assert.Empty(t, missing)
+	assert.Equal(t, oidresolver.TrapSpec{
+		"1.3.6.1.4.1.99999.0.1": {
+			Name:        "acmeControllerReboot",
+			MIBName:     "ACME-TRAP-MIB",
+			Description: "The controller rebooted.",
+		},
+		"1.3.6.1.4.1.99999.1.2.1": {
+			Name:        "acmeDiskFailed",
+			MIBName:     "ACME-STORAGE-MIB",
+			Description: "A disk failed.",
+		},
+	}, content.Traps)
+	assert.Equal(t, oidresolver.VariableSpec{
+		"1.3.6.1.4.1.99999.1.1.2": {
+			Name:        "acmeSerialNumber",
+			Description: "The serial number of the array.",
+		},
+		"1.3.6.1.4.1.99999.1.1.3.1.2": {
+			Name:        "acmeDiskName",
+			Description: "The name of the disk.",
+		},
+		"1.3.6.1.4.1.99999.1.1.3.1.3": {
+			Name:        "acmeDiskHealth",
+			Description: "The health of the disk.",
+			Enumeration: map[int]string{1: "ok", 2: "degraded", 3: "failed"},
+		},
+	}, content.Variables)
+
+	// the output can be read like the files of the traps_db folder
+	var buf bytes.Buffer
+	require.NoError(t, WriteTrapsDB(&buf, content, false))
+	var loaded oidresolver.TrapDBFileContent
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &loaded))
+	assert.Equal(t, content.Traps, loaded.Traps)
+	assert.Equal(t, "failed", loaded.Variables["1.3.6.1.4.1.99999.1.1.3.1.3"].Enumeration[3])
+}
+
+func TestGenerateTrapsDBMissingObjects(t *testing.T) {
+	modules, err := parseMIBModules(`BITS-MIB DEFINITIONS ::= BEGIN
+bitsTrap NOTIFICATION-TYPE
+    OBJECTS     { bitsFlags, unknownObject }
+    STATUS      current
+    DESCRIPTION "A trap."
+    ::= { enterprises 99997 0 1 }
+bitsFlags OBJECT-TYPE
+    SYNTAX      BITS { fan(0), power(1) }
+    MAX-ACCESS  accessible-for-notify
+    STATUS      current
+    DESCRIPTION "The failed components."
+    ::= { enterprises 99997 1 }
+END
+`)
+	require.NoError(t, err)
+	mib, err := compileModules(modules)
+	require.NoError(t, err)
+
+	content, missing := GenerateTrapsDB(mib)
+	assert.Equal(t, []string{"BITS-MIB::bitsTrap: unknownObject"}, missing)
+	assert.Equal(t, map[int]string{0: "fan", 1: "power"}, content.Variables["1.3.6.1.4.1.99997.1"].Bits)
+	assert.Empty(t, content.Variables["1.3.6.1.4.1.99997.1"].Enumeration)
+}
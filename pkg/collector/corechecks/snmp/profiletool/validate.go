@@ -0,0 +1,261 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profiletool
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	devicemetadata "github.com/DataDog/datadog-agent/pkg/networkdevice/metadata"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/checkconfig"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/fetch"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/profile"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/report"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/valuestore"
+)
+
+// replayIPAddress is the IP address of the device whose walk is replayed, it only appears in the tags
+const replayIPAddress = "127.0.0.1"
+
+// Resolution tells whether a metric, tag or metadata field of a profile resolves against a walk
+type Resolution struct {
+	// Name is the metric name, the tag or the metadata field
+	Name string `json:"name"`
+	// Symbol is the name of the symbol the value is read from
+	Symbol string `json:"symbol,omitempty"`
+	OID    string `json:"oid,omitempty"`
+	// Values is the number of values of the OID found in the walk, 1 for scalars
+	Values int `json:"values"`
+	// Samples is the number of metric samples submitted for a metric
+	Samples int `json:"samples,omitempty"`
+}
+
+// Resolved returns true if the walk contains a value for the OID
+func (r Resolution) Resolved() bool {
+	return r.Values > 0
+}
+
+// Report is the result of collecting a device with a profile, replaying a walk of the device
+type Report struct {
+	Profile string `json:"profile"`
+	// SysObjectID is the sysObjectID of the walk, SysObjectIDMatch tells whether it matches the profile
+	SysObjectID      string                                `json:"sys_object_id,omitempty"`
+	SysObjectIDMatch bool                                  `json:"sys_object_id_match"`
+	Metrics          []Resolution                          `json:"metrics"`
+	MetricTags       []Resolution                          `json:"metric_tags"`
+	Metadata         []Resolution                          `json:"metadata"`
+	Samples          []Sample                              `json:"samples"`
+	DeviceMetadata   devicemetadata.NetworkDevicesMetadata `json:"device_metadata"`
+}
+
+// ReadProfile reads a profile file and expands the profiles it extends. Validation errors of the profile are
+// returned.
+func ReadProfile(definitionFile string) (profiledefinition.ProfileDefinition, error) {
+	absPath, err := filepath.Abs(definitionFile)
+	if err != nil {
+		return profiledefinition.ProfileDefinition{}, err
+	}
+	return profile.ReadProfileFile(absPath)
+}
+
+// ProfileName returns the name of the profile of a definition file
+func ProfileName(definitionFile string) string {
+	return strings.TrimSuffix(filepath.Base(definitionFile), filepath.Ext(definitionFile))
+}
+
+// Validate collects the device of a walk with a profile, like the SNMP check does, and reports which metrics, tags
+// and metadata fields of the profile resolve
+func Validate(name string, definition profiledefinition.ProfileDefinition, walk Walk) (*Report, error) {
+	config, err := checkconfig.NewCheckConfig([]byte("ip_address: "+replayIPAddress), []byte(""))
+	if err != nil {
+		return nil, err
+	}
+	config.Profiles = profile.ProfileConfigMap{name: profile.ProfileConfig{Definition: definition}}
+	config.AutodetectProfile = false
+	if err := config.SetProfile(name); err != nil {
+		return nil, err
+	}
+
+	values, err := fetch.Fetch(walk.Session(), config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch values: %w", err)
+	}
+
+	sender := &recordingSender{}
+	metricSender := report.NewMetricSender(sender, "", config.InterfaceConfigs, report.MakeInterfaceBandwidthState())
+	tags := append(config.GetStaticTags(), config.ProfileTags...)
+	tags = append(tags, metricSender.GetCheckInstanceMetricTags(config.MetricTags, values)...)
+	metricSender.ReportMetrics(config.Metrics, values, tags, config.DeviceID)
+	if config.CollectDeviceMetadata {
+		metadataTags := append(append([]string{}, tags...), config.InstanceTags...)
+		// the device isn't pinged, its ping status is left unset like when ping is disabled
+		var pingStatus devicemetadata.DeviceStatus
+		metricSender.ReportNetworkDeviceMetadata(config, values, metadataTags, time.Now(), devicemetadata.DeviceStatusReachable, pingStatus, nil)
+	}
+
+	r := &Report{
+		Profile:        name,
+		SysObjectID:    walk.SysObjectID(),
+		Samples:        sender.samples,
+		DeviceMetadata: sender.metadata,
+	}
+	r.SysObjectIDMatch = r.SysObjectID != "" && matchesSysObjectID(definition.SysObjectIDs, r.SysObjectID)
+	sort.SliceStable(r.Samples, func(i, j int) bool {
+		return r.Samples[i].key() < r.Samples[j].key()
+	})
+
+	samplesByName := make(map[string]int)
+	for _, sample := range r.Samples {
+		samplesByName[sample.Name]++
+	}
+	for _, metric := range definition.Metrics {
+		r.Metrics = append(r.Metrics, metricResolutions(metric, values, samplesByName)...)
+		for _, metricTag := range metric.MetricTags {
+			r.MetricTags = append(r.MetricTags, columnTagResolution(metric, metricTag, values))
+		}
+	}
+	for _, metricTag := range definition.MetricTags {
+		resolution := symbolResolution(metricTagName(metricTag), profiledefinition.SymbolConfig(metricTag.Symbol), true, values)
+		r.MetricTags = append(r.MetricTags, resolution)
+	}
+	r.Metadata = metadataResolutions(definition.Metadata, values)
+	return r, nil
+}
+
+func matchesSysObjectID(patterns []string, sysObjectID string) bool {
+	for _, pattern := range patterns {
+		if matched, err := filepath.Match(pattern, sysObjectID); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func metricResolutions(metric profiledefinition.MetricsConfig, values *valuestore.ResultValueStore, samplesByName map[string]int) []Resolution {
+	var resolutions []Resolution
+	if metric.IsScalar() {
+		resolution := symbolResolution(metricName(metric.Symbol.Name, metric.Options), metric.Symbol, true, values)
+		resolution.Samples = samplesByName[resolution.Name]
+		return append(resolutions, resolution)
+	}
+	for _, symbol := range metric.Symbols {
+		resolution := symbolResolution(metricName(symbol.Name, metric.Options), symbol, false, values)
+		if symbol.ConstantValueOne {
+			// constant metrics have a sample per row of the table, the rows are those of the tags
+			resolution.Values = 0
+			for _, metricTag := range metric.MetricTags {
+				resolution.Values = max(resolution.Values, columnTagResolution(metric, metricTag, values).Values)
+			}
+		}
+		resolution.Samples = samplesByName[resolution.Name]
+		resolutions = append(resolutions, resolution)
+	}
+	return resolutions
+}
+
+func metricName(symbolName string, options profiledefinition.MetricsConfigOption) string {
+	name := "snmp." + symbolName
+	if options.MetricSuffix != "" {
+		name += "." + options.MetricSuffix
+	}
+	return name
+}
+
+func metricTagName(metricTag profiledefinition.MetricTagConfig) string {
+	if metricTag.Tag != "" {
+		return metricTag.Tag
+	}
+	var tags []string
+	for tag := range metricTag.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return strings.Join(tags, ",")
+}
+
+// columnTagResolution returns the resolution of the tag of a table metric. Index based tags resolve for every row.
+func columnTagResolution(metric profiledefinition.MetricsConfig, metricTag profiledefinition.MetricTagConfig, values *valuestore.ResultValueStore) Resolution {
+	if metricTag.Symbol.OID != "" {
+		return symbolResolution(metricTagName(metricTag), profiledefinition.SymbolConfig(metricTag.Symbol), false, values)
+	}
+	resolution := Resolution{Name: metricTagName(metricTag)}
+	if metricTag.Index > 0 {
+		resolution.Symbol = fmt.Sprintf("index %d", metricTag.Index)
+	}
+	for _, symbol := range metric.Symbols {
+		if !symbol.ConstantValueOne {
+			resolution.Values = max(resolution.Values, symbolResolution("", symbol, false, values).Values)
+		}
+	}
+	return resolution
+}
+
+func symbolResolution(name string, symbol profiledefinition.SymbolConfig, scalar bool, values *valuestore.ResultValueStore) Resolution {
+	resolution := Resolution{Name: name, Symbol: symbol.Name, OID: symbol.OID}
+	if symbol.OID == "" {
+		return resolution
+	}
+	if scalar {
+		if _, err := values.GetScalarValue(symbol.OID); err == nil {
+			resolution.Values = 1
+		}
+		return resolution
+	}
+	columnValues, err := values.GetColumnValues(symbol.OID)
+	if err == nil {
+		resolution.Values = len(columnValues)
+	}
+	return resolution
+}
+
+func metadataResolutions(metadataConfig profiledefinition.MetadataConfig, values *valuestore.ResultValueStore) []Resolution {
+	var resources []string
+	for resource := range metadataConfig {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	var resolutions []Resolution
+	for _, resource := range resources {
+		scalar := profiledefinition.IsMetadataResourceWithScalarOids(resource)
+		var fields []string
+		for field := range metadataConfig[resource].Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			fieldConfig := metadataConfig[resource].Fields[field]
+			name := resource + "." + field
+			if fieldConfig.Value != "" {
+				resolutions = append(resolutions, Resolution{Name: name, Symbol: fmt.Sprintf("value %q", fieldConfig.Value), Values: 1})
+				continue
+			}
+			// the first symbol with a value is used
+			symbols := fieldConfig.Symbols
+			if fieldConfig.Symbol.OID != "" {
+				symbols = append([]profiledefinition.SymbolConfig{fieldConfig.Symbol}, symbols...)
+			}
+			resolution := Resolution{Name: name}
+			for _, symbol := range symbols {
+				resolution = symbolResolution(name, symbol, scalar, values)
+				if resolution.Resolved() {
+					break
+				}
+			}
+			resolutions = append(resolutions, resolution)
+		}
+		for _, idTag := range metadataConfig[resource].IDTags {
+			name := resource + ".id_tags." + metricTagName(idTag)
+			resolutions = append(resolutions, symbolResolution(name, profiledefinition.SymbolConfig(idTag.Symbol), scalar, values))
+		}
+	}
+	return resolutions
+}
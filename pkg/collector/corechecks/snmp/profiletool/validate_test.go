@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profiletool
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+var f5ProfileFile = filepath.Join("..", "internal", "test", "conf.d", "snmp.d", "profiles", "f5-big-ip.yaml")
+
+func validateF5(t *testing.T) *Report {
+	config.Datadog().SetWithoutSource("confd_path", t.TempDir())
+	definition, err := ReadProfile(f5ProfileFile)
+	require.NoError(t, err)
+	walk, err := ReadWalkFile(filepath.Join("testdata", "f5-big-ip.snmprec"))
+	require.NoError(t, err)
+	r, err := Validate(ProfileName(f5ProfileFile), definition, walk)
+	require.NoError(t, err)
+	return r
+}
+
+func resolutionsByName(resolutions []Resolution) map[string]Resolution {
+	byName := make(map[string]Resolution, len(resolutions))
+	for _, resolution := range resolutions {
+		byName[resolution.Name] = resolution
+	}
+	return byName
+}
+
+func TestValidate(t *testing.T) {
+	r := validateF5(t)
+
+	assert.Equal(t, "f5-big-ip", r.Profile)
+	assert.Equal(t, "1.3.6.1.4.1.3375.2.1.3.4.43", r.SysObjectID)
+	assert.True(t, r.SysObjectIDMatch)
+
+	metrics := resolutionsByName(r.Metrics)
+	assert.Equal(t, Resolution{Name: "snmp.sysStatMemoryTotal", Symbol: "sysStatMemoryTotal", OID: "1.3.6.1.4.1.3375.2.1.1.2.1.44.0", Values: 1, Samples: 1},
+		metrics["snmp.sysStatMemoryTotal"])
+	assert.Equal(t, Resolution{Name: "snmp.ifInErrors", Symbol: "ifInErrors", OID: "1.3.6.1.2.1.2.2.1.14", Values: 2, Samples: 2},
+		metrics["snmp.ifInErrors"])
+	assert.False(t, metrics["snmp.oldSyntax"].Resolved())
+	assert.False(t, metrics["snmp.someMetric"].Resolved())
+
+	metricTags := resolutionsByName(r.MetricTags)
+	assert.Equal(t, 2, metricTags["interface"].Values)
+	assert.Equal(t, 2, metricTags["interface_alias"].Values)
+	assert.Equal(t, 1, metricTags["snmp_host"].Values)
+
+	metadata := resolutionsByName(r.Metadata)
+	assert.Equal(t, 1, metadata["device.serial_number"].Values)
+	assert.Equal(t, 1, metadata["device.vendor"].Values)
+	assert.Equal(t, 2, metadata["interface.oper_status"].Values)
+	assert.Equal(t, 2, metadata["interface.id_tags.custom-tag"].Values)
+
+	var memory []Sample
+	for _, sample := range r.Samples {
+		if sample.Name == "snmp.sysStatMemoryTotal" {
+			memory = append(memory, sample)
+		}
+	}
+	require.Len(t, memory, 1)
+	assert.Equal(t, "gauge", memory[0].Type)
+	assert.Equal(t, float64(2048), memory[0].Value)
+	assert.Contains(t, memory[0].Tags, "snmp_profile:f5-big-ip")
+	assert.Contains(t, memory[0].Tags, "snmp_host:f5-lb")
+
+	require.Len(t, r.DeviceMetadata.Devices, 1)
+	assert.Equal(t, "26ff4a4d-190e-12ac-d4257ed36ba6", r.DeviceMetadata.Devices[0].SerialNumber)
+	assert.Equal(t, "f5", r.DeviceMetadata.Devices[0].Vendor)
+	assert.Len(t, r.DeviceMetadata.Interfaces, 2)
+
+	var out bytes.Buffer
+	require.NoError(t, WriteReport(&out, r, false))
+	assert.Contains(t, out.String(), "sysObjectID: 1.3.6.1.4.1.3375.2.1.3.4.43 (matches the profile)")
+	assert.Regexp(t, `MISSING\s+snmp.oldSyntax\s+oldSyntax\s+1.3.6.1.4.1.3375.2.1.1.2.1.44.999`, out.String())
+	assert.Regexp(t, `ok\s+snmp.ifInErrors\s+ifInErrors\s+1.3.6.1.2.1.2.2.1.14\s+2 values, 2 samples`, out.String())
+}
+
+func TestDiff(t *testing.T) {
+	oldReport := validateF5(t)
+
+	// the new version doesn't scale the memory and reads the serial number from another OID
+	dir := t.TempDir()
+	profiles := filepath.Dir(f5ProfileFile)
+	for _, name := range []string{"_base.yaml", "_generic-if.yaml", "_abstract.yaml"} {
+		content, err := os.ReadFile(filepath.Join(profiles, name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), content, 0600))
+	}
+	content, err := os.ReadFile(f5ProfileFile)
+	require.NoError(t, err)
+	content = bytes.Replace(content, []byte("scale_factor: 2"), []byte(""), 1)
+	content = bytes.Replace(content, []byte("1.3.6.1.4.1.3375.2.1.3.3.3.0"), []byte("1.3.6.1.4.1.3375.2.1.3.3.4.0"), 1)
+	newProfileFile := filepath.Join(dir, "f5-big-ip.yaml")
+	require.NoError(t, os.WriteFile(newProfileFile, content, 0600))
+	definition, err := ReadProfile(newProfileFile)
+	require.NoError(t, err)
+	walk, err := ReadWalkFile(filepath.Join("testdata", "f5-big-ip.snmprec"))
+	require.NoError(t, err)
+	newReport, err := Validate(oldReport.Profile, definition, walk)
+	require.NoError(t, err)
+
+	diff := Diff(oldReport, newReport)
+	assert.False(t, diff.Empty())
+
+	var changed []string
+	for _, change := range diff.Changed {
+		changed = append(changed, change.New.Name)
+	}
+	assert.Equal(t, []string{"snmp.sysStatMemoryTotal"}, changed)
+	assert.Equal(t, float64(2048), diff.Changed[0].Old.Value)
+	assert.Equal(t, float64(1024), diff.Changed[0].New.Value)
+
+	assert.Empty(t, diff.Added)
+	assert.Empty(t, diff.Removed)
+
+	metadataChanges := make(map[string]MetadataChange)
+	for _, change := range diff.Metadata {
+		metadataChanges[change.Field] = change
+	}
+	assert.Equal(t, MetadataChange{Field: "device.serial_number", Old: "26ff4a4d-190e-12ac-d4257ed36ba6"}, metadataChanges["device.serial_number"])
+
+	assert.True(t, Diff(oldReport, oldReport).Empty())
+
+	var out bytes.Buffer
+	require.NoError(t, WriteDiff(&out, diff))
+	assert.Regexp(t, `~ snmp.sysStatMemoryTotal\s+gauge -> gauge\s+2048 -> 1024`, out.String())
+	assert.Contains(t, out.String(), "0 added, 0 removed, 1 changed samples, 1 changed metadata fields")
+
+	out.Reset()
+	require.NoError(t, WriteDiff(&out, Diff(oldReport, oldReport)))
+	assert.Equal(t, "No difference\n", out.String())
+}
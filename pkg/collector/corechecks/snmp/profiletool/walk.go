@@ -0,0 +1,386 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package profiletool implements the offline SNMP profile authoring tools of the `agent snmp profile` command: it
+// replays recorded walks of a device to validate and compare profiles, compiles MIB files and drafts new profiles
+// from the tables of a walk.
+package profiletool
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/common"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/session"
+)
+
+const sysObjectIDOid = "1.3.6.1.2.1.1.2.0"
+
+// Walk is a recorded walk of a device, the PDUs are sorted by OID
+type Walk []gosnmp.SnmpPDU
+
+var (
+	// snmprec lines are `<oid>|<tag>|<value>`, the tag can have a `x` suffix for hex encoded values and a
+	// `:<module>` suffix for snmpsim variation modules
+	snmprecLinePattern = regexp.MustCompile(`^\.?(\d+(?:\.\d+)*)\|(\d+)([a-z]*)(:[^|]*)?\|(.*)$`)
+	// walk lines are `<oid> = <type>: <value>`, as printed by `snmpwalk -On` and `agent snmp walk`
+	walkLinePattern = regexp.MustCompile(`^\.?(\d+(?:\.\d+)*)\s*=\s*(.*)$`)
+	// numbers between parentheses, like `up(1)` for enumerations or `(1234) 0:00:12.34` for time ticks
+	parenthesizedNumberPattern = regexp.MustCompile(`\((-?\d+)\)`)
+)
+
+// ReadWalkFile reads a walk from a snmprec file or from the output of snmpwalk/`agent snmp walk`
+func ReadWalkFile(path string) (Walk, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	walk, err := ParseWalk(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid walk file %s: %w", path, err)
+	}
+	return walk, nil
+}
+
+// ParseWalk parses a walk in the snmprec format or in the format printed by `snmpwalk -On` and `agent snmp walk`.
+// The format is detected from the first line.
+func ParseWalk(r io.Reader) (Walk, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var walk Walk
+		var err error
+		if snmprecLinePattern.MatchString(line) {
+			walk, err = parseSnmprec(lines)
+		} else {
+			walk, err = parseWalkOutput(lines)
+		}
+		if err != nil {
+			return nil, err
+		}
+		walk.sort()
+		return walk, nil
+	}
+	return nil, fmt.Errorf("the walk is empty")
+}
+
+func parseSnmprec(lines []string) (Walk, error) {
+	var walk Walk
+	for i, line := range lines {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		matches := snmprecLinePattern.FindStringSubmatch(line)
+		if matches == nil {
+			return nil, fmt.Errorf("line %d: invalid snmprec record %q", i+1, line)
+		}
+		oid, tag, flags, module, value := matches[1], matches[2], matches[3], matches[4], matches[5]
+		if module != "" {
+			// the values of variation modules are generated by snmpsim, they can't be replayed
+			continue
+		}
+		if strings.Contains(flags, "x") {
+			decoded, err := hex.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid hex value %q: %w", i+1, value, err)
+			}
+			value = string(decoded)
+		}
+		pdu, ok, err := snmprecPDU(oid, tag, value, strings.Contains(flags, "x"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if ok {
+			walk = append(walk, pdu)
+		}
+	}
+	return walk, nil
+}
+
+// snmprecPDU returns the PDU of a snmprec record, tags are the BER types of the values. ok is false for the records
+// without value (null, noSuchObject...).
+func snmprecPDU(oid string, tag string, value string, hexEncoded bool) (gosnmp.SnmpPDU, bool, error) {
+	pdu := gosnmp.SnmpPDU{Name: oid}
+	var err error
+	switch tag {
+	case "2":
+		pdu.Type = gosnmp.Integer
+		pdu.Value, err = strconv.Atoi(value)
+	case "4":
+		pdu.Type = gosnmp.OctetString
+		pdu.Value = []byte(value)
+	case "6":
+		pdu.Type = gosnmp.ObjectIdentifier
+		pdu.Value = strings.TrimPrefix(value, ".")
+	case "64":
+		pdu.Type = gosnmp.IPAddress
+		if hexEncoded && len(value) == 4 {
+			value = fmt.Sprintf("%d.%d.%d.%d", value[0], value[1], value[2], value[3])
+		}
+		pdu.Value = value
+	case "65":
+		pdu.Type = gosnmp.Counter32
+		pdu.Value, err = parseUint32(value)
+	case "66":
+		pdu.Type = gosnmp.Gauge32
+		pdu.Value, err = parseUint32(value)
+	case "67":
+		pdu.Type = gosnmp.TimeTicks
+		pdu.Value, err = parseTimeTicks(value)
+	case "70":
+		pdu.Type = gosnmp.Counter64
+		pdu.Value, err = strconv.ParseUint(value, 10, 64)
+	default:
+		// null, opaque, noSuchObject, noSuchInstance and endOfMibView values aren't replayed
+		return pdu, false, nil
+	}
+	if err != nil {
+		return pdu, false, fmt.Errorf("invalid value %q for tag %s: %w", value, tag, err)
+	}
+	return pdu, true, nil
+}
+
+func parseWalkOutput(lines []string) (Walk, error) {
+	var walk Walk
+	var oid, value string
+	flush := func() error {
+		if oid == "" {
+			return nil
+		}
+		pdu, ok, err := walkOutputPDU(oid, value)
+		if err != nil {
+			return fmt.Errorf("oid %s: %w", oid, err)
+		}
+		if ok {
+			walk = append(walk, pdu)
+		}
+		oid, value = "", ""
+		return nil
+	}
+	for i, line := range lines {
+		if matches := walkLinePattern.FindStringSubmatch(line); matches != nil {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			oid, value = matches[1], matches[2]
+			continue
+		}
+		if line == "End of MIB" {
+			continue
+		}
+		if oid == "" {
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid walk line %q", i+1, line)
+		}
+		// strings and hex strings can span several lines
+		value += "\n" + line
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return walk, nil
+}
+
+// walkOutputPDU returns the PDU of a walk line value like `Counter32: 12`. ok is false for the values that aren't
+// replayed, like `No Such Object available on this agent at this OID`.
+func walkOutputPDU(oid string, value string) (gosnmp.SnmpPDU, bool, error) {
+	pdu := gosnmp.SnmpPDU{Name: oid}
+	typ, val, found := strings.Cut(value, ": ")
+	if !found || strings.HasPrefix(value, `"`) {
+		typ, val = "", value
+		if strings.HasSuffix(value, ":") {
+			// empty value, like `STRING:`
+			typ, val = strings.TrimSuffix(value, ":"), ""
+		}
+	}
+	val = strings.TrimRight(val, "\n")
+
+	var err error
+	switch strings.ToUpper(typ) {
+	case "STRING":
+		pdu.Type = gosnmp.OctetString
+		pdu.Value = []byte(unquote(val))
+	case "HEX-STRING":
+		pdu.Type = gosnmp.OctetString
+		pdu.Value, err = hex.DecodeString(strings.Join(strings.Fields(val), ""))
+	case "OID":
+		pdu.Type = gosnmp.ObjectIdentifier
+		pdu.Value = strings.TrimPrefix(val, ".")
+	case "INTEGER":
+		pdu.Type = gosnmp.Integer
+		pdu.Value, err = strconv.Atoi(numericValue(val))
+	case "COUNTER32":
+		pdu.Type = gosnmp.Counter32
+		pdu.Value, err = parseUint32(numericValue(val))
+	case "GAUGE32", "UNSIGNED32":
+		pdu.Type = gosnmp.Gauge32
+		pdu.Value, err = parseUint32(numericValue(val))
+	case "COUNTER64":
+		pdu.Type = gosnmp.Counter64
+		pdu.Value, err = strconv.ParseUint(numericValue(val), 10, 64)
+	case "TIMETICKS":
+		pdu.Type = gosnmp.TimeTicks
+		pdu.Value, err = parseTimeTicks(numericValue(val))
+	case "IPADDRESS", "NETWORK ADDRESS":
+		pdu.Type = gosnmp.IPAddress
+		pdu.Value = val
+	case "":
+		switch {
+		case strings.HasPrefix(val, `"`):
+			// net-snmp prints empty strings without type
+			pdu.Type = gosnmp.OctetString
+			pdu.Value = []byte(unquote(val))
+		case isDigits(val):
+			// `agent snmp walk` prints time ticks without type
+			pdu.Type = gosnmp.TimeTicks
+			pdu.Value, err = parseTimeTicks(val)
+		default:
+			return pdu, false, nil
+		}
+	default:
+		return pdu, false, nil
+	}
+	if err != nil {
+		return pdu, false, fmt.Errorf("invalid %s value %q: %w", typ, val, err)
+	}
+	return pdu, true, nil
+}
+
+// numericValue returns the number of values printed with their meaning, like `up(1)` or `(1234) 0:00:12.34`
+func numericValue(value string) string {
+	if matches := parenthesizedNumberPattern.FindStringSubmatch(value); matches != nil {
+		return matches[1]
+	}
+	return strings.TrimSpace(value)
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	}
+	return value
+}
+
+// parseUint32 parses counters and gauges, which gosnmp decodes as uint
+func parseUint32(value string) (uint, error) {
+	number, err := strconv.ParseUint(value, 10, 32)
+	return uint(number), err
+}
+
+func parseTimeTicks(value string) (uint32, error) {
+	number, err := strconv.ParseUint(value, 10, 32)
+	return uint32(number), err
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (w Walk) sort() {
+	numbers := make(map[string][]int, len(w))
+	for _, pdu := range w {
+		numbers[pdu.Name] = oidNumbers(pdu.Name)
+	}
+	sort.SliceStable(w, func(i, j int) bool {
+		return compareOIDs(numbers[w[i].Name], numbers[w[j].Name]) < 0
+	})
+}
+
+// Get returns the PDU of an OID
+func (w Walk) Get(oid string) (gosnmp.SnmpPDU, bool) {
+	oid = strings.TrimPrefix(oid, ".")
+	for _, pdu := range w {
+		if pdu.Name == oid {
+			return pdu, true
+		}
+	}
+	return gosnmp.SnmpPDU{}, false
+}
+
+// SysObjectID returns the sysObjectID of the device, or an empty string if the walk doesn't contain it
+func (w Walk) SysObjectID() string {
+	pdu, ok := w.Get(sysObjectIDOid)
+	if !ok {
+		return ""
+	}
+	value, _ := pdu.Value.(string)
+	return value
+}
+
+// OIDTrie returns the trie of the OIDs of the walk
+func (w Walk) OIDTrie() *common.OIDTrie {
+	oids := make([]string, 0, len(w))
+	for _, pdu := range w {
+		oids = append(oids, pdu.Name)
+	}
+	return common.BuildOidTrie(oids)
+}
+
+// Session returns a session replaying the walk
+func (w Walk) Session() session.Session {
+	sess := session.CreateFakeSession()
+	sess.SetMany(w...)
+	return sess
+}
+
+func oidNumbers(oid string) []int {
+	parts := strings.Split(strings.TrimPrefix(oid, "."), ".")
+	numbers := make([]int, 0, len(parts))
+	for _, part := range parts {
+		number, _ := strconv.Atoi(part)
+		numbers = append(numbers, number)
+	}
+	return numbers
+}
+
+// compareOIDs returns -1 if a < b, 1 if a > b, and 0 otherwise, in the lexicographic order of OIDs
+func compareOIDs(a, b []int) int {
+	for i := range a {
+		if i >= len(b) {
+			return 1
+		}
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	if len(b) > len(a) {
+		return -1
+	}
+	return 0
+}
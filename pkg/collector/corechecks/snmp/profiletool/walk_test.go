@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package profiletool
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWalkSnmprec(t *testing.T) {
+	walk, err := ParseWalk(strings.NewReader(`# comment
+1.3.6.1.2.1.1.5.0|4x|6465766963652d31
+1.3.6.1.2.1.1.2.0|6|1.3.6.1.4.1.9.1.1
+1.3.6.1.2.1.1.3.0|67|1234
+1.3.6.1.2.1.2.2.1.10.1|65|100
+1.3.6.1.2.1.2.2.1.10.10|65|101
+1.3.6.1.2.1.2.2.1.10.2|65|102
+1.3.6.1.2.1.2.2.1.5.1|66|1000
+1.3.6.1.2.1.4.20.1.1.10.0.0.1|64|10.0.0.1
+1.3.6.1.2.1.4.20.1.1.10.0.0.2|64x|0a000002
+1.3.6.1.2.1.31.1.1.1.6.1|70|123456789012
+1.3.6.1.2.1.2.2.1.7.1|2|1
+1.3.6.1.2.1.2.2.1.7.2|2:numeric|min=1,max=2
+1.3.6.1.2.1.2.2.1.8.1|5|
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, Walk{
+		{Name: "1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.4.1.9.1.1"},
+		{Name: "1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(1234)},
+		{Name: "1.3.6.1.2.1.1.5.0", Type: gosnmp.OctetString, Value: []byte("device-1")},
+		{Name: "1.3.6.1.2.1.2.2.1.5.1", Type: gosnmp.Gauge32, Value: uint(1000)},
+		{Name: "1.3.6.1.2.1.2.2.1.7.1", Type: gosnmp.Integer, Value: 1},
+		{Name: "1.3.6.1.2.1.2.2.1.10.1", Type: gosnmp.Counter32, Value: uint(100)},
+		{Name: "1.3.6.1.2.1.2.2.1.10.2", Type: gosnmp.Counter32, Value: uint(102)},
+		{Name: "1.3.6.1.2.1.2.2.1.10.10", Type: gosnmp.Counter32, Value: uint(101)},
+		{Name: "1.3.6.1.2.1.4.20.1.1.10.0.0.1", Type: gosnmp.IPAddress, Value: "10.0.0.1"},
+		{Name: "1.3.6.1.2.1.4.20.1.1.10.0.0.2", Type: gosnmp.IPAddress, Value: "10.0.0.2"},
+		{Name: "1.3.6.1.2.1.31.1.1.1.6.1", Type: gosnmp.Counter64, Value: uint64(123456789012)},
+	}, walk)
+	assert.Equal(t, "1.3.6.1.4.1.9.1.1", walk.SysObjectID())
+}
+
+func TestParseWalkOutput(t *testing.T) {
+	walk, err := ParseWalk(strings.NewReader(`.1.3.6.1.2.1.1.1.0 = STRING: "Linux device
+second line"
+.1.3.6.1.2.1.1.2.0 = OID: .1.3.6.1.4.1.8072.3.2.10
+.1.3.6.1.2.1.1.3.0 = Timeticks: (8266) 0:01:22.66
+.1.3.6.1.2.1.1.4.0 = ""
+.1.3.6.1.2.1.2.2.1.6.1 = Hex-STRING: 00 50 56 83 A4 01
+.1.3.6.1.2.1.2.2.1.8.1 = INTEGER: up(1)
+.1.3.6.1.2.1.2.2.1.10.1 = Counter32: 100
+.1.3.6.1.2.1.2.2.1.5.1 = Gauge32: 1000
+.1.3.6.1.2.1.31.1.1.1.6.1 = Counter64: 123456789012
+.1.3.6.1.2.1.4.20.1.1.10.0.0.1 = IpAddress: 10.0.0.1
+.1.3.6.1.2.1.1.8.0 = 42
+.1.3.6.1.2.1.1.9.0 = No Such Object available on this agent at this OID
+End of MIB
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, Walk{
+		{Name: "1.3.6.1.2.1.1.1.0", Type: gosnmp.OctetString, Value: []byte("Linux device\nsecond line")},
+		{Name: "1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.4.1.8072.3.2.10"},
+		{Name: "1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(8266)},
+		{Name: "1.3.6.1.2.1.1.4.0", Type: gosnmp.OctetString, Value: []byte("")},
+		{Name: "1.3.6.1.2.1.1.8.0", Type: gosnmp.TimeTicks, Value: uint32(42)},
+		{Name: "1.3.6.1.2.1.2.2.1.5.1", Type: gosnmp.Gauge32, Value: uint(1000)},
+		{Name: "1.3.6.1.2.1.2.2.1.6.1", Type: gosnmp.OctetString, Value: []byte{0x00, 0x50, 0x56, 0x83, 0xa4, 0x01}},
+		{Name: "1.3.6.1.2.1.2.2.1.8.1", Type: gosnmp.Integer, Value: 1},
+		{Name: "1.3.6.1.2.1.2.2.1.10.1", Type: gosnmp.Counter32, Value: uint(100)},
+		{Name: "1.3.6.1.2.1.4.20.1.1.10.0.0.1", Type: gosnmp.IPAddress, Value: "10.0.0.1"},
+		{Name: "1.3.6.1.2.1.31.1.1.1.6.1", Type: gosnmp.Counter64, Value: uint64(123456789012)},
+	}, walk)
+}
+
+func TestParseWalkErrors(t *testing.T) {
+	_, err := ParseWalk(strings.NewReader("\n# only comments\n"))
+	assert.EqualError(t, err, "the walk is empty")
+
+	_, err = ParseWalk(strings.NewReader("1.3.6.1.2.1.1.3.0|67|1234\nnot a record\n"))
+	assert.EqualError(t, err, `line 2: invalid snmprec record "not a record"`)
+
+	_, err = ParseWalk(strings.NewReader("1.3.6.1.2.1.1.3.0|67|abc\n"))
+	assert.ErrorContains(t, err, `line 1: invalid value "abc" for tag 67`)
+
+	_, err = ParseWalk(strings.NewReader(".1.3.6.1.2.1.2.2.1.10.1 = Counter32: abc\n"))
+	assert.ErrorContains(t, err, `oid 1.3.6.1.2.1.2.2.1.10.1: invalid Counter32 value "abc"`)
+
+	_, err = ReadWalkFile(filepath.Join("testdata", "missing.snmprec"))
+	assert.Error(t, err)
+}
+
+func TestWalkSession(t *testing.T) {
+	walk, err := ReadWalkFile(filepath.Join("testdata", "f5-big-ip.snmprec"))
+	require.NoError(t, err)
+
+	sess := walk.Session()
+	result, err := sess.GetNext([]string{"1.3.6.1.2.1.2.2.1.13"})
+	require.NoError(t, err)
+	require.Len(t, result.Variables, 1)
+	assert.Equal(t, "1.3.6.1.2.1.2.2.1.13.1", strings.TrimPrefix(result.Variables[0].Name, "."))
+
+	trie := walk.OIDTrie()
+	assert.True(t, trie.LeafExist("1.3.6.1.4.1.3375.2.1.1.2.1.44.0"))
+	assert.True(t, trie.NonLeafNodeExist("1.3.6.1.4.1.99999.1.1.3"))
+	// the values of the variation modules aren't replayed
+	assert.False(t, trie.LeafExist("1.3.6.1.4.1.99999.1.1.3.1.5.3"))
+}
@@ -7,6 +7,8 @@
 package snmp
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -17,6 +19,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/topology"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/utils"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/optional"
 
@@ -25,6 +30,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/devicecheck"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/discovery"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/report"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/scheduler"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/snmp/internal/session"
 	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
 )
@@ -32,6 +38,8 @@ import (
 const (
 	// CheckName is the name of the check
 	CheckName = common.SnmpIntegrationName
+
+	snmpLoaderTag = "loader:core"
 )
 
 var timeNow = time.Now
@@ -44,6 +52,9 @@ type Check struct {
 	discovery                  *discovery.Discovery
 	sessionFactory             session.Factory
 	workerRunDeviceCheckErrors *atomic.Uint64
+	topologyStore              *topology.Store
+	// scheduler is nil unless network_devices.polling.enabled is true
+	scheduler *scheduler.Scheduler
 }
 
 // Run executes the check
@@ -57,15 +68,7 @@ func (c *Check) Run() error {
 	if c.config.IsDiscovery() {
 		discoveredDevices := c.discovery.GetDiscoveredDeviceConfigs()
 
-		jobs := make(chan *devicecheck.DeviceCheck, len(discoveredDevices))
-
-		var wg sync.WaitGroup
-
-		for w := 1; w <= c.config.Workers; w++ {
-			wg.Add(1)
-			go c.runCheckDeviceWorker(w, &wg, jobs)
-		}
-
+		devices := make([]*devicecheck.DeviceCheck, 0, len(discoveredDevices))
 		for i := range discoveredDevices {
 			deviceCk := discoveredDevices[i]
 			hostname, err := deviceCk.GetDeviceHostname()
@@ -74,11 +77,35 @@ func (c *Check) Run() error {
 				continue
 			}
 			// `interface_configs` option not supported by SNMP corecheck autodiscovery
-			deviceCk.SetSender(report.NewMetricSender(sender, hostname, nil, deviceCk.GetInterfaceBandwidthState()))
-			jobs <- deviceCk
+			metricSender := report.NewMetricSender(sender, hostname, nil, deviceCk.GetInterfaceBandwidthState())
+			metricSender.SetTopologyStore(c.topologyStore)
+			deviceCk.SetSender(metricSender)
+			devices = append(devices, deviceCk)
+		}
+
+		if c.scheduler != nil {
+			deviceIDs := make([]string, 0, len(discoveredDevices))
+			for _, deviceCk := range discoveredDevices {
+				deviceIDs = append(deviceIDs, deviceCk.GetDeviceID())
+			}
+			c.scheduler.Retain(deviceIDs)
+			c.runScheduledDevices(sender, devices)
+		} else {
+			jobs := make(chan *devicecheck.DeviceCheck, len(devices))
+
+			var wg sync.WaitGroup
+
+			for w := 1; w <= c.config.Workers; w++ {
+				wg.Add(1)
+				go c.runCheckDeviceWorker(w, &wg, jobs)
+			}
+
+			for _, deviceCk := range devices {
+				jobs <- deviceCk
+			}
+			close(jobs)
+			wg.Wait() // wait for all workers to finish
 		}
-		close(jobs)
-		wg.Wait() // wait for all workers to finish
 
 		tags := append(c.config.GetStaticTags(), "network:"+c.config.Network)
 		tags = append(tags, c.config.GetNetworkTags()...)
@@ -88,8 +115,18 @@ func (c *Check) Run() error {
 		if err != nil {
 			return err
 		}
-		c.singleDeviceCk.SetSender(report.NewMetricSender(sender, hostname, c.config.InterfaceConfigs, c.singleDeviceCk.GetInterfaceBandwidthState()))
-		checkErr = c.runCheckDevice(c.singleDeviceCk)
+		metricSender := report.NewMetricSender(sender, hostname, c.config.InterfaceConfigs, c.singleDeviceCk.GetInterfaceBandwidthState())
+		metricSender.SetTopologyStore(c.topologyStore)
+		c.singleDeviceCk.SetSender(metricSender)
+		if c.scheduler != nil {
+			if c.scheduler.Due(c.singleDeviceCk.GetDeviceID()) {
+				checkErr = c.runScheduledDevice(sender, c.singleDeviceCk)
+			} else {
+				c.skipScheduledDevice(c.singleDeviceCk)
+			}
+		} else {
+			checkErr = c.runCheckDevice(c.singleDeviceCk)
+		}
 	}
 
 	// Commit
@@ -98,6 +135,59 @@ func (c *Check) Run() error {
 	return checkErr
 }
 
+// runScheduledDevices polls the devices that are due, the polls are spread across the interval and limited by the
+// budgets of the scheduler
+func (c *Check) runScheduledDevices(sender sender.Sender, devices []*devicecheck.DeviceCheck) {
+	devicesByID := make(map[string]*devicecheck.DeviceCheck, len(devices))
+	var dueDeviceIDs []string
+	for _, deviceCk := range devices {
+		deviceID := deviceCk.GetDeviceID()
+		if !c.scheduler.Due(deviceID) {
+			c.skipScheduledDevice(deviceCk)
+			continue
+		}
+		devicesByID[deviceID] = deviceCk
+		dueDeviceIDs = append(dueDeviceIDs, deviceID)
+	}
+
+	c.scheduler.Run(dueDeviceIDs, c.config.Workers, func(deviceID string) {
+		deviceCk := devicesByID[deviceID]
+		if err := c.runScheduledDevice(sender, deviceCk); err != nil {
+			c.workerRunDeviceCheckErrors.Inc()
+			log.Errorf("error collecting for device %s (total errors: %d): %s", deviceCk.GetIPAddress(), c.workerRunDeviceCheckErrors.Load(), err)
+		}
+	})
+}
+
+func (c *Check) runScheduledDevice(sender sender.Sender, deviceCk *devicecheck.DeviceCheck) error {
+	stats, err := c.scheduler.Poll(deviceCk.GetDeviceID(), func() error {
+		return c.runCheckDevice(deviceCk)
+	})
+	if errors.Is(err, context.Canceled) {
+		// the check was unscheduled before the device was polled
+		return nil
+	}
+
+	tags := append(utils.CopyStrings(deviceCk.GetIDTags()), snmpLoaderTag)
+	sender.Gauge("datadog.snmp.poll_latency", stats.ResponseTime.Seconds(), "", tags)
+	sender.Gauge("datadog.snmp.poll_duration", stats.PollDuration.Seconds(), "", tags)
+	sender.Gauge("datadog.snmp.poll_budget_wait", stats.BudgetWait.Seconds(), "", tags)
+	sender.Gauge("datadog.snmp.poll_interval", stats.Interval.Seconds(), "", tags)
+	sender.Gauge("datadog.snmp.poll_timeout", stats.Timeout.Seconds(), "", tags)
+	sender.Gauge("datadog.snmp.poll_consecutive_failures", float64(stats.ConsecutiveFailures), "", tags)
+	return err
+}
+
+// skipScheduledDevice is called for the devices that aren't due. Devices backed off after failures are still
+// reported as unreachable, slow devices just skip the run.
+func (c *Check) skipScheduledDevice(deviceCk *devicecheck.DeviceCheck) {
+	stats := c.scheduler.Stats(deviceCk.GetDeviceID())
+	log.Debugf("device %s: skipping poll, next poll at %s", deviceCk.GetIPAddress(), stats.NextPoll)
+	if stats.ConsecutiveFailures > 0 {
+		deviceCk.ReportBackoff(stats.NextPoll)
+	}
+}
+
 func (c *Check) runCheckDeviceWorker(workerID int, wg *sync.WaitGroup, jobs <-chan *devicecheck.DeviceCheck) {
 	defer wg.Done()
 	for job := range jobs {
@@ -154,6 +244,15 @@ func (c *Check) Configure(senderManager sender.SenderManager, integrationConfigD
 		return fmt.Errorf("common configure failed: %s", err)
 	}
 
+	if c.config.CollectTopology && config.Datadog().GetBool("network_devices.topology.export.enabled") {
+		c.topologyStore = topology.NewStore(topology.DefaultDir(config.Datadog().GetString("run_path")))
+	}
+
+	if schedulerConfig := scheduler.NewConfig(config.Datadog()); schedulerConfig.Enabled {
+		c.scheduler = scheduler.New(schedulerConfig, scheduler.GlobalBudget(schedulerConfig), c.config.MinCollectionInterval, time.Duration(c.config.Timeout)*time.Second)
+		c.sessionFactory = c.scheduler.SessionFactory(c.sessionFactory)
+	}
+
 	if c.config.IsDiscovery() {
 		c.discovery = discovery.NewDiscovery(c.config, c.sessionFactory)
 		c.discovery.Start()
@@ -168,6 +267,9 @@ func (c *Check) Configure(senderManager sender.SenderManager, integrationConfigD
 
 // Cancel is called when check is unscheduled
 func (c *Check) Cancel() {
+	if c.scheduler != nil {
+		c.scheduler.Stop()
+	}
 	if c.discovery != nil {
 		c.discovery.Stop()
 		c.discovery = nil
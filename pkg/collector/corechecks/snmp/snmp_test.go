@@ -2599,3 +2599,53 @@ func waitForDiscoveredDevices(discovery *discovery.Discovery, expectedDeviceCoun
 		}
 	}
 }
+
+func TestScheduledPollingBackoff(t *testing.T) {
+	testDir := t.TempDir()
+	coreconfig.Datadog().SetWithoutSource("run_path", testDir)
+	coreconfig.Datadog().SetWithoutSource("network_devices.polling.enabled", true)
+	defer coreconfig.Datadog().SetWithoutSource("network_devices.polling.enabled", false)
+	profile.SetConfdPathAndCleanProfiles()
+	sess := session.CreateMockSession()
+	sessionFactory := func(*checkconfig.CheckConfig) (session.Session, error) {
+		return sess, nil
+	}
+	sess.ConnectErr = fmt.Errorf("can't connect")
+	chk := Check{sessionFactory: sessionFactory}
+
+	// language=yaml
+	rawInstanceConfig := []byte(`
+collect_device_metadata: false
+ip_address: 1.2.3.4
+community_string: public
+`)
+	senderManager := mocksender.CreateDefaultDemultiplexer()
+	err := chk.Configure(senderManager, integration.FakeConfigHash, rawInstanceConfig, []byte(``), "test")
+	assert.Nil(t, err)
+	defer chk.Cancel()
+	assert.NotNil(t, chk.scheduler)
+
+	sender := mocksender.NewMockSenderWithSenderManager(chk.ID(), senderManager)
+	sender.On("Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	sender.On("MonotonicCount", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	sender.On("ServiceCheck", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	sender.On("Commit").Return()
+
+	err = chk.Run()
+	assert.EqualError(t, err, "snmp connection error: can't connect")
+
+	snmpTags := []string{"snmp_device:1.2.3.4", "device_ip:1.2.3.4", "device_id:default:1.2.3.4"}
+	pollTags := []string{"device_namespace:default", "snmp_device:1.2.3.4", "loader:core"}
+	sender.AssertServiceCheck(t, "snmp.can_check", servicecheck.ServiceCheckCritical, "", snmpTags, "snmp connection error: can't connect")
+	sender.AssertMetric(t, "Gauge", "datadog.snmp.poll_consecutive_failures", 1, "", pollTags)
+	sender.AssertMetric(t, "Gauge", "datadog.snmp.poll_interval", 15, "", pollTags)
+
+	// the device is backed off, it's reported as unreachable without being polled
+	sender.ResetCalls()
+	err = chk.Run()
+	assert.NoError(t, err)
+	nextPoll := chk.scheduler.Stats("default:1.2.3.4").NextPoll
+	sender.AssertServiceCheck(t, "snmp.can_check", servicecheck.ServiceCheckCritical, "", snmpTags, "device unreachable, next poll at "+nextPoll.Format(time.RFC3339))
+	sender.AssertMetric(t, "Gauge", "snmp.device.unreachable", 1, "", snmpTags)
+	sender.AssertNotCalled(t, "Gauge", "datadog.snmp.poll_consecutive_failures", mock.Anything, mock.Anything, mock.Anything)
+}
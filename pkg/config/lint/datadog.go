@@ -0,0 +1,399 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+
+	logsconfig "github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+)
+
+// maxSuggestionDistance is the maximum edit distance between an unknown key and a known key to suggest the latter
+const maxSuggestionDistance = 2
+
+// schema describes the keys registered by pkg/config/setup
+type schema struct {
+	config pkgconfigmodel.Config
+	// keys are the known keys, without the wildcard ones
+	keys map[string]struct{}
+	// sections are the keys having known children
+	sections map[string]struct{}
+	// wildcards are the keys whose children can be anything, like "process_config.additional_endpoints"
+	wildcards map[string]struct{}
+}
+
+func newSchema() *schema {
+	config := pkgconfigmodel.NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	pkgconfigsetup.InitConfig(config)
+
+	s := &schema{
+		config:    config,
+		keys:      map[string]struct{}{},
+		sections:  map[string]struct{}{},
+		wildcards: map[string]struct{}{},
+	}
+	for key := range config.GetKnownKeysLowercased() {
+		if wildcard, ok := strings.CutSuffix(key, ".*"); ok {
+			s.wildcards[wildcard] = struct{}{}
+			key = wildcard
+		} else {
+			s.keys[key] = struct{}{}
+		}
+		for i := strings.LastIndex(key, "."); i != -1; i = strings.LastIndex(key, ".") {
+			key = key[:i]
+			s.sections[key] = struct{}{}
+		}
+	}
+	return s
+}
+
+func (s *schema) isKnown(key string) bool {
+	_, ok := s.keys[key]
+	return ok
+}
+
+// hasChildren returns whether the key has known children
+func (s *schema) hasChildren(key string) bool {
+	_, ok := s.sections[key]
+	return ok
+}
+
+// isSection returns whether the key is set with a mapping: it has known children or is a wildcard key
+func (s *schema) isSection(key string) bool {
+	_, ok := s.sections[key]
+	_, wildcard := s.wildcards[key]
+	return ok || wildcard
+}
+
+// matchesWildcard returns whether the key is the child of a wildcard key
+func (s *schema) matchesWildcard(key string) bool {
+	if _, ok := s.wildcards[key]; ok {
+		return true
+	}
+	for i := strings.LastIndex(key, "."); i != -1; i = strings.LastIndex(key, ".") {
+		key = key[:i]
+		if _, ok := s.wildcards[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultValue returns the default value of a key, or nil if it has none
+func (s *schema) defaultValue(key string) interface{} {
+	if !s.isKnown(key) {
+		return nil
+	}
+	for _, value := range s.config.GetAllSources(key) {
+		if value.Source == pkgconfigmodel.SourceDefault {
+			return value.Value
+		}
+	}
+	return nil
+}
+
+// suggest returns the known key closest to the given unknown key, or an empty string if none is close enough
+func (s *schema) suggest(key string) string {
+	suggestion, best := "", maxSuggestionDistance+1
+	for known := range s.keys {
+		d := levenshtein(key, known, maxSuggestionDistance+1)
+		if d < best || (d == best && d <= maxSuggestionDistance && known < suggestion) {
+			suggestion, best = known, d
+		}
+	}
+	return suggestion
+}
+
+// fileKey is a key set in a configuration file
+type fileKey struct {
+	key  string
+	file string
+	line int
+}
+
+// LintMainConfig lints the main configuration file of the agent. environ is the environment checked for unknown DD_
+// variables.
+func LintMainConfig(path string, environ []string) []Finding {
+	findings, _ := lintMainConfig(path, environ)
+	return findings
+}
+
+// lintMainConfig lints the main configuration file and returns the configuration read from it, or nil if it can't be
+// read
+func lintMainConfig(path string, environ []string) ([]Finding, pkgconfigmodel.Config) {
+	invalid := func(line int, message string) ([]Finding, pkgconfigmodel.Config) {
+		return []Finding{{File: path, Line: line, Rule: RuleInvalidYAML, Severity: SeverityError, Message: message}}, nil
+	}
+
+	// the drop-in files are merged into the main configuration file, in lexical order
+	paths := []string{path}
+	dropInDir := path + pkgconfigmodel.DropInDirSuffix
+	if entries, err := os.ReadDir(dropInDir); err == nil {
+		for _, entry := range entries {
+			if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				paths = append(paths, filepath.Join(dropInDir, entry.Name()))
+			}
+		}
+	}
+
+	s := newSchema()
+	l := &mainConfigLinter{schema: s}
+	for _, path := range paths {
+		document, finding := parseMainConfig(path)
+		if finding != nil {
+			return []Finding{*finding}, nil
+		}
+		if document != nil {
+			l.file = path
+			l.lintMapping(document, "")
+		}
+	}
+
+	config := s.config
+	config.SetConfigFile(path)
+	// the configuration is loaded with its drop-in files, like the agent does
+	config.EnableDropInFiles()
+	if err := config.ReadInConfig(); err != nil {
+		return invalid(0, fmt.Sprintf("the agent can't read the configuration: %s", err))
+	}
+	l.lintEnvConflicts()
+
+	for _, envVar := range pkgconfigsetup.FindUnknownEnvVars(config, environ, pkgconfigsetup.SystemProbe().GetEnvVars()) {
+		l.findings = append(l.findings, Finding{
+			Key:      envVar,
+			Rule:     RuleUnknownEnvVar,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("unknown environment variable %s", envVar),
+		})
+	}
+
+	if _, err := logsconfig.GlobalProcessingRules(config); err != nil {
+		k := l.fileKey("logs_config.processing_rules")
+		l.findings = append(l.findings, Finding{File: k.file, Line: k.line, Key: k.key, Rule: RuleInvalidLogsConfig, Severity: SeverityError, Message: err.Error()})
+	}
+	return l.findings, config
+}
+
+// parseMainConfig parses a file of the main configuration. It returns the root mapping of the file, nil if the file is
+// empty, or a finding if the file can't be read.
+func parseMainConfig(path string) (*yaml.Node, *Finding) {
+	invalid := func(line int, message string) (*yaml.Node, *Finding) {
+		return nil, &Finding{File: path, Line: line, Rule: RuleInvalidYAML, Severity: SeverityError, Message: message}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return invalid(0, err.Error())
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return invalid(yamlErrorLine(err), err.Error())
+	}
+	// decoding the document catches errors the node parsing accepts, like duplicate keys
+	var decoded interface{}
+	if err := root.Decode(&decoded); err != nil {
+		return invalid(yamlErrorLine(err), err.Error())
+	}
+
+	if len(root.Content) == 0 || isNull(root.Content[0]) {
+		return nil, nil
+	}
+	document := root.Content[0]
+	if document.Kind != yaml.MappingNode {
+		return invalid(document.Line, "the configuration must be a mapping")
+	}
+	return document, nil
+}
+
+type mainConfigLinter struct {
+	schema   *schema
+	file     string
+	fileKeys []fileKey
+	findings []Finding
+}
+
+func (l *mainConfigLinter) add(line int, key string, rule string, severity Severity, message string) {
+	l.findings = append(l.findings, Finding{File: l.file, Line: line, Key: key, Rule: rule, Severity: severity, Message: message})
+}
+
+// fileKey returns the last file setting the key, which is the one whose value is in effect
+func (l *mainConfigLinter) fileKey(key string) fileKey {
+	res := fileKey{key: key, file: l.file}
+	for _, k := range l.fileKeys {
+		if k.key == key {
+			res = k
+		}
+	}
+	return res
+}
+
+func (l *mainConfigLinter) lintMapping(node *yaml.Node, prefix string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := prefix + strings.ToLower(keyNode.Value)
+		l.fileKeys = append(l.fileKeys, fileKey{key: key, file: l.file, line: keyNode.Line})
+
+		if replacement, ok := pkgconfigsetup.DeprecatedKeys[key]; ok {
+			l.add(keyNode.Line, key, RuleDeprecatedKey, SeverityWarning, fmt.Sprintf("%s is deprecated, use %s instead", key, replacement))
+		}
+
+		switch {
+		case valueNode.Kind == yaml.MappingNode && l.schema.isSection(key):
+			l.lintMapping(valueNode, key+".")
+		case l.schema.hasChildren(key):
+			if !isNull(valueNode) {
+				l.add(valueNode.Line, key, RuleTypeMismatch, SeverityError, fmt.Sprintf("%s must be a mapping", key))
+			}
+		case l.schema.isKnown(key):
+			l.lintType(key, valueNode)
+		case l.schema.matchesWildcard(key):
+		default:
+			message := "unknown configuration key " + key
+			if suggestion := l.schema.suggest(key); suggestion != "" {
+				message += fmt.Sprintf(", did you mean %s?", suggestion)
+			}
+			l.add(keyNode.Line, key, RuleUnknownKey, SeverityWarning, message)
+		}
+	}
+}
+
+// lintType checks that the value of a known key can be converted to the type of its default value
+func (l *mainConfigLinter) lintType(key string, node *yaml.Node) {
+	defaultValue := l.schema.defaultValue(key)
+	if defaultValue == nil || isNull(node) {
+		return
+	}
+
+	var expected string
+	valid := true
+	switch defaultValue.(type) {
+	case bool:
+		expected = "a boolean"
+		valid = node.Kind == yaml.ScalarNode && isBool(node.Value)
+	case time.Duration:
+		expected = "a duration"
+		_, err := cast.ToDurationE(scalarValue(node))
+		valid = node.Kind == yaml.ScalarNode && err == nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		expected = "an integer"
+		_, err := cast.ToInt64E(scalarValue(node))
+		valid = node.Kind == yaml.ScalarNode && err == nil
+	case float32, float64:
+		expected = "a number"
+		_, err := cast.ToFloat64E(scalarValue(node))
+		valid = node.Kind == yaml.ScalarNode && err == nil
+	case string:
+		expected = "a string"
+		valid = node.Kind == yaml.ScalarNode
+	default:
+		switch reflect.TypeOf(defaultValue).Kind() {
+		case reflect.Slice:
+			// lists can also be set with a space separated string, like their environment variable
+			expected = "a list"
+			valid = node.Kind == yaml.SequenceNode || node.Kind == yaml.ScalarNode
+		case reflect.Map:
+			// maps can also be set with a JSON string, like their environment variable
+			expected = "a mapping"
+			valid = node.Kind == yaml.MappingNode || node.Kind == yaml.ScalarNode
+		}
+	}
+	if !valid {
+		l.add(node.Line, key, RuleTypeMismatch, SeverityError, fmt.Sprintf("%s must be %s", key, expected))
+	}
+}
+
+// lintEnvConflicts reports the keys of the configuration file overridden by environment variables
+func (l *mainConfigLinter) lintEnvConflicts() {
+	for _, k := range l.fileKeys {
+		if !l.schema.isKnown(k.key) {
+			continue
+		}
+		var fileValue, envValue interface{}
+		for _, value := range l.schema.config.GetAllSources(k.key) {
+			switch value.Source {
+			case pkgconfigmodel.SourceFile:
+				fileValue = value.Value
+			case pkgconfigmodel.SourceEnvVar:
+				envValue = value.Value
+			}
+		}
+		// values aren't printed as they can be secrets
+		if fileValue != nil && envValue != nil && fmt.Sprint(fileValue) != fmt.Sprint(envValue) {
+			l.findings = append(l.findings, Finding{
+				File:     k.file,
+				Line:     k.line,
+				Key:      k.key,
+				Rule:     RuleEnvConflict,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s is overridden by an environment variable with a different value", k.key),
+			})
+		}
+	}
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// scalarValue returns the decoded value of a scalar node
+func scalarValue(node *yaml.Node) interface{} {
+	var value interface{}
+	if node.Kind != yaml.ScalarNode || node.Decode(&value) != nil {
+		return nil
+	}
+	return value
+}
+
+// isBool returns whether the value is a boolean. The configuration is read with YAML 1.1, which also accepts values
+// like "yes" and "off".
+func isBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "y", "n", "on", "off":
+		return true
+	}
+	return false
+}
+
+// levenshtein returns the edit distance between a and b. The computation stops early and returns limit when the
+// distance is known to be at least limit.
+func levenshtein(a, b string, limit int) int {
+	if d := len(a) - len(b); d >= limit || -d >= limit {
+		return limit
+	}
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		rowMin := current[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			rowMin = min(rowMin, current[j])
+		}
+		if rowMin >= limit {
+			return limit
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
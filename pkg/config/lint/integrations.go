@@ -0,0 +1,221 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+
+	logsconfig "github.com/DataDog/datadog-agent/comp/logs/agent/config"
+)
+
+// integrationTopLevelKeys are the keys read by the autodiscovery file provider
+var integrationTopLevelKeys = map[string]struct{}{
+	"ad_identifiers":            {},
+	"advanced_ad_identifiers":   {},
+	"ad_conditions":             {},
+	"cluster_check":             {},
+	"init_config":               {},
+	"jmx_metrics":               {},
+	"logs":                      {},
+	"instances":                 {},
+	"docker_images":             {},
+	"ignore_autodiscovery_tags": {},
+}
+
+// LintConfd lints the integration configuration files of a conf.d folder: the files at its root and the ones in its
+// "<integration>.d" folders, like the autodiscovery file provider reads them.
+func LintConfd(confdPath string) []Finding {
+	entries, err := os.ReadDir(confdPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return []Finding{{File: confdPath, Rule: RuleInvalidIntegrationConfig, Severity: SeverityError, Message: err.Error()}}
+	}
+
+	var findings []Finding
+	for _, entry := range entries {
+		path := filepath.Join(confdPath, entry.Name())
+		if !entry.IsDir() {
+			if isIntegrationConfigFile(entry.Name()) {
+				findings = append(findings, LintIntegrationConfig(path)...)
+			}
+			continue
+		}
+		if filepath.Ext(entry.Name()) != ".d" {
+			continue
+		}
+		subEntries, err := os.ReadDir(path)
+		if err != nil {
+			findings = append(findings, Finding{File: path, Rule: RuleInvalidIntegrationConfig, Severity: SeverityError, Message: err.Error()})
+			continue
+		}
+		for _, subEntry := range subEntries {
+			if !subEntry.IsDir() && isIntegrationConfigFile(subEntry.Name()) {
+				findings = append(findings, LintIntegrationConfig(filepath.Join(path, subEntry.Name()))...)
+			}
+		}
+	}
+	return findings
+}
+
+// isIntegrationConfigFile returns whether the file is read by the autodiscovery file provider
+func isIntegrationConfigFile(name string) bool {
+	name = strings.TrimSuffix(name, ".default")
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// LintIntegrationConfig lints an integration configuration file
+func LintIntegrationConfig(path string) []Finding {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return []Finding{{File: path, Rule: RuleInvalidIntegrationConfig, Severity: SeverityError, Message: err.Error()}}
+	}
+	// empty files are skipped by the agent
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil
+	}
+
+	l := &integrationLinter{file: path}
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		l.add(yamlErrorLine(err), "", RuleInvalidYAML, SeverityError, err.Error())
+		return l.findings
+	}
+	var decoded interface{}
+	if err := root.Decode(&decoded); err != nil {
+		// the agent only warns about duplicate keys in integration configurations
+		l.add(yamlErrorLine(err), "", RuleInvalidYAML, SeverityWarning, err.Error())
+	}
+	if len(root.Content) == 0 || isNull(root.Content[0]) {
+		return l.findings
+	}
+
+	document := root.Content[0]
+	if document.Kind != yaml.MappingNode {
+		l.add(document.Line, "", RuleInvalidIntegrationConfig, SeverityError, "the configuration must be a mapping")
+		return l.findings
+	}
+	l.lintDocument(document, content)
+	return l.findings
+}
+
+type integrationLinter struct {
+	file     string
+	findings []Finding
+}
+
+func (l *integrationLinter) add(line int, key string, rule string, severity Severity, message string) {
+	l.findings = append(l.findings, Finding{File: l.file, Line: line, Key: key, Rule: rule, Severity: severity, Message: message})
+}
+
+func (l *integrationLinter) lintDocument(document *yaml.Node, content []byte) {
+	nodes := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(document.Content); i += 2 {
+		keyNode, valueNode := document.Content[i], document.Content[i+1]
+		if _, ok := integrationTopLevelKeys[keyNode.Value]; !ok {
+			l.add(keyNode.Line, keyNode.Value, RuleUnknownKey, SeverityWarning, fmt.Sprintf("unknown top-level key %s, it is ignored by the agent", keyNode.Value))
+			continue
+		}
+		nodes[keyNode.Value] = valueNode
+	}
+
+	if initConfig, ok := nodes["init_config"]; ok && !isNull(initConfig) && initConfig.Kind != yaml.MappingNode {
+		l.add(initConfig.Line, "init_config", RuleInvalidIntegrationConfig, SeverityError, "init_config must be a mapping")
+	}
+
+	instances, hasInstances := nodes["instances"]
+	if hasInstances && !isNull(instances) {
+		if instances.Kind != yaml.SequenceNode {
+			l.add(instances.Line, "instances", RuleInvalidIntegrationConfig, SeverityError, "instances must be a list")
+		} else {
+			for i, instance := range instances.Content {
+				l.lintInstance(i, instance)
+			}
+		}
+	}
+
+	hasLogs := nodes["logs"] != nil && !isNull(nodes["logs"])
+	hasJMXMetrics := nodes["jmx_metrics"] != nil && !isNull(nodes["jmx_metrics"])
+	if !hasLogs && !hasJMXMetrics && (!hasInstances || len(instances.Content) == 0) {
+		l.add(document.Line, "instances", RuleInvalidIntegrationConfig, SeverityError, "the configuration contains no instances, logs or jmx_metrics")
+	}
+
+	_, hasADIdentifiers := nodes["ad_identifiers"]
+	if dockerImages, ok := nodes["docker_images"]; ok && !hasADIdentifiers {
+		l.add(dockerImages.Line, "docker_images", RuleInvalidIntegrationConfig, SeverityError, "docker_images is deprecated, use ad_identifiers instead")
+	}
+
+	if hasLogs {
+		l.lintLogs(nodes["logs"], content, hasADIdentifiers)
+	}
+}
+
+func (l *integrationLinter) lintInstance(i int, instance *yaml.Node) {
+	key := fmt.Sprintf("instances[%d]", i)
+	if instance.Kind != yaml.MappingNode {
+		l.add(instance.Line, key, RuleInvalidIntegrationConfig, SeverityError, key+" must be a mapping")
+		return
+	}
+	for j := 0; j+1 < len(instance.Content); j += 2 {
+		keyNode, valueNode := instance.Content[j], instance.Content[j+1]
+		if isNull(valueNode) {
+			continue
+		}
+		switch keyNode.Value {
+		case "tags":
+			if valueNode.Kind != yaml.SequenceNode {
+				l.add(valueNode.Line, key+".tags", RuleTypeMismatch, SeverityError, key+".tags must be a list")
+			}
+		case "min_collection_interval":
+			if _, err := cast.ToFloat64E(scalarValue(valueNode)); valueNode.Kind != yaml.ScalarNode || err != nil {
+				l.add(valueNode.Line, key+".min_collection_interval", RuleTypeMismatch, SeverityError, key+".min_collection_interval must be a number")
+			}
+		}
+	}
+}
+
+// lintLogs validates the logs configurations of the file like the logs agent does
+func (l *integrationLinter) lintLogs(logs *yaml.Node, content []byte, isTemplate bool) {
+	if logs.Kind != yaml.SequenceNode {
+		l.add(logs.Line, "logs", RuleInvalidLogsConfig, SeverityError, "logs must be a list")
+		return
+	}
+
+	configs, err := logsconfig.ParseYAML(content)
+	if err != nil {
+		l.add(logs.Line, "logs", RuleInvalidLogsConfig, SeverityError, err.Error())
+		return
+	}
+	for i, config := range configs {
+		line := logs.Line
+		if i < len(logs.Content) {
+			line = logs.Content[i].Line
+		}
+		key := fmt.Sprintf("logs[%d]", i)
+
+		// autodiscovery templates don't have to set the type, it comes from the service they are applied to
+		if config.Type == "" && isTemplate {
+			if err := logsconfig.ValidateProcessingRules(config.ProcessingRules); err != nil {
+				l.add(line, key, RuleInvalidLogsConfig, SeverityError, err.Error())
+			} else if err := logsconfig.CompileProcessingRules(config.ProcessingRules); err != nil {
+				l.add(line, key, RuleInvalidLogsConfig, SeverityError, err.Error())
+			}
+			continue
+		}
+		if err := config.Validate(); err != nil {
+			l.add(line, key, RuleInvalidLogsConfig, SeverityError, err.Error())
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package lint validates the agent configuration files, without starting the agent.
+//
+// The main configuration file and its drop-in files are checked against the keys registered in pkg/config/setup:
+// unknown keys, values whose type doesn't match the default value of their key, deprecated keys and keys also set by an
+// environment variable are reported. Integration configuration files are checked for the `init_config`/`instances` structure the checks expect
+// and their logs configurations are validated with comp/logs/agent/config.
+package lint
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Severity is the severity of a finding
+type Severity string
+
+const (
+	// SeverityError is used for findings that prevent the configuration from working as intended
+	SeverityError Severity = "error"
+	// SeverityWarning is used for findings that are likely mistakes but don't prevent the agent from working
+	SeverityWarning Severity = "warning"
+)
+
+// Rules reported by the linter
+const (
+	RuleInvalidYAML              = "invalid-yaml"
+	RuleUnknownKey               = "unknown-key"
+	RuleTypeMismatch             = "type-mismatch"
+	RuleDeprecatedKey            = "deprecated-key"
+	RuleEnvConflict              = "env-conflict"
+	RuleUnknownEnvVar            = "unknown-env-var"
+	RuleInvalidIntegrationConfig = "invalid-integration-config"
+	RuleInvalidLogsConfig        = "invalid-logs-config"
+)
+
+// Finding is a problem found in the configuration
+type Finding struct {
+	// File is the file the finding is about. It is empty for findings about environment variables.
+	File string `json:"file,omitempty"`
+	// Line is the line of the finding in File, when known
+	Line int `json:"line,omitempty"`
+	// Key is the configuration key or the environment variable the finding is about
+	Key      string   `json:"key,omitempty"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Report is the result of linting the configuration
+type Report struct {
+	Findings []Finding `json:"findings"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+}
+
+// Options lists what to lint
+type Options struct {
+	// ConfigFile is the path of the main configuration file. It isn't linted when empty.
+	ConfigFile string
+	// ConfdPaths are the folders containing the integration configuration files. When nil, the confd_path of the main
+	// configuration is used.
+	ConfdPaths []string
+	// Environ is the environment checked for unknown DD_ variables, usually os.Environ(). Conflicts between the
+	// configuration file and the environment are always detected with the environment of the current process.
+	Environ []string
+}
+
+// Lint lints the configuration described by opts
+func Lint(opts Options) Report {
+	var findings []Finding
+	confdPaths := opts.ConfdPaths
+	if opts.ConfigFile != "" {
+		mainFindings, config := lintMainConfig(opts.ConfigFile, opts.Environ)
+		findings = append(findings, mainFindings...)
+		if confdPaths == nil && config != nil {
+			confdPaths = []string{config.GetString("confd_path")}
+		}
+	}
+	for _, confdPath := range confdPaths {
+		findings = append(findings, LintConfd(confdPath)...)
+	}
+	return newReport(findings)
+}
+
+func newReport(findings []Finding) Report {
+	report := Report{Findings: []Finding{}}
+	for _, finding := range findings {
+		report.Findings = append(report.Findings, finding)
+		if finding.Severity == SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return report
+}
+
+var yamlErrorLineRx = regexp.MustCompile(`line (\d+)`)
+
+// yamlErrorLine extracts the line number from a YAML parsing error, or 0 if unknown
+func yamlErrorLine(err error) int {
+	match := yamlErrorLineRx.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(match[1])
+	return line
+}
@@ -0,0 +1,278 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path string, content string) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// findingsByRule returns the key and line of the findings of each rule
+func findingsByRule(findings []Finding) map[string][]Finding {
+	res := map[string][]Finding{}
+	for _, finding := range findings {
+		res[finding.Rule] = append(res[finding.Rule], Finding{Key: finding.Key, Line: finding.Line, Severity: finding.Severity})
+	}
+	return res
+}
+
+func TestLintMainConfigValid(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "datadog.yaml"), `
+api_key: abcdef
+log_level: debug
+dogstatsd_port: "8126"
+logs_enabled: yes
+tags:
+  - env:prod
+histogram_percentiles: "0.95 0.99"
+forwarder_timeout: 30
+logs_config:
+  container_collect_all: true
+  processing_rules:
+    - type: exclude_at_match
+      name: exclude_healthchecks
+      pattern: healthcheck
+process_config:
+  additional_endpoints:
+    "https://process.datadoghq.eu": [key1]
+`)
+	assert.Empty(t, LintMainConfig(path, nil))
+}
+
+func TestLintMainConfig(t *testing.T) {
+	t.Setenv("DD_LOG_LEVEL", "warn")
+	t.Setenv("DD_DOGSTATSD_PORT", "8125")
+
+	path := writeFile(t, filepath.Join(t.TempDir(), "datadog.yaml"), `
+log_level: info
+log_levl: debug
+dogstatsd_port: 8125
+check_runners: four
+tags: {env: prod}
+logs_config:
+  unknown_option: true
+  processing_rules:
+    - type: exclude_at_match
+      name: missing_pattern
+apm_config: true
+log_enabled: true
+`)
+	findings := LintMainConfig(path, []string{"DD_LOG_LEVEL=warn", "DD_NOT_A_REAL_OPTION=1", "PATH=/bin"})
+
+	assert.Equal(t, map[string][]Finding{
+		RuleUnknownKey: {
+			{Key: "log_levl", Line: 3, Severity: SeverityWarning},
+			{Key: "logs_config.unknown_option", Line: 8, Severity: SeverityWarning},
+		},
+		RuleTypeMismatch: {
+			{Key: "check_runners", Line: 5, Severity: SeverityError},
+			{Key: "tags", Line: 6, Severity: SeverityError},
+			{Key: "apm_config", Line: 12, Severity: SeverityError},
+		},
+		RuleDeprecatedKey: {
+			{Key: "log_enabled", Line: 13, Severity: SeverityWarning},
+		},
+		RuleEnvConflict: {
+			{Key: "log_level", Line: 2, Severity: SeverityWarning},
+		},
+		RuleUnknownEnvVar: {
+			{Key: "DD_NOT_A_REAL_OPTION", Severity: SeverityWarning},
+		},
+		RuleInvalidLogsConfig: {
+			{Key: "logs_config.processing_rules", Line: 9, Severity: SeverityError},
+		},
+	}, findingsByRule(findings))
+
+	for _, finding := range findings {
+		if finding.Key == "log_levl" {
+			assert.Contains(t, finding.Message, "did you mean log_level?")
+		}
+		if finding.Rule != RuleUnknownEnvVar {
+			assert.Equal(t, path, finding.File)
+		}
+	}
+}
+
+func TestLintMainConfigDropIns(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, filepath.Join(dir, "datadog.yaml"), "api_key: abc\n")
+	dropIn := writeFile(t, filepath.Join(dir, "datadog.yaml.d", "10-team.yaml"), "\nlogs_enabled: [true]\nlog_levl: debug\n")
+	writeFile(t, filepath.Join(dir, "datadog.yaml.d", "notes.txt"), "not: [valid")
+
+	findings := LintMainConfig(path, nil)
+	require.Len(t, findings, 2)
+	for _, finding := range findings {
+		assert.Equal(t, dropIn, finding.File)
+	}
+	assert.Equal(t, map[string][]Finding{
+		RuleTypeMismatch: {{Key: "logs_enabled", Line: 2, Severity: SeverityError}},
+		RuleUnknownKey:   {{Key: "log_levl", Line: 3, Severity: SeverityWarning}},
+	}, findingsByRule(findings))
+
+	// the settings of the drop-in files are validated too
+	writeFile(t, filepath.Join(dir, "datadog.yaml.d", "10-team.yaml"), "logs_config:\n  processing_rules:\n    - type: exclude_at_match\n      name: missing_pattern\n")
+	findings = LintMainConfig(path, nil)
+	require.Len(t, findings, 1)
+	assert.Equal(t, RuleInvalidLogsConfig, findings[0].Rule)
+	assert.Equal(t, dropIn, findings[0].File)
+
+	writeFile(t, filepath.Join(dir, "datadog.yaml.d", "20-broken.yaml"), "log_level: [debug\n")
+	findings = LintMainConfig(path, nil)
+	require.Len(t, findings, 1)
+	assert.Equal(t, RuleInvalidYAML, findings[0].Rule)
+	assert.Equal(t, filepath.Join(dir, "datadog.yaml.d", "20-broken.yaml"), findings[0].File)
+}
+
+func TestLintMainConfigInvalidYAML(t *testing.T) {
+	dir := t.TempDir()
+
+	findings := LintMainConfig(writeFile(t, filepath.Join(dir, "datadog.yaml"), "api_key: abc\n  log_level: [debug\n"), nil)
+	require.Len(t, findings, 1)
+	assert.Equal(t, RuleInvalidYAML, findings[0].Rule)
+	assert.Equal(t, SeverityError, findings[0].Severity)
+	assert.Equal(t, 2, findings[0].Line)
+
+	findings = LintMainConfig(writeFile(t, filepath.Join(dir, "duplicate.yaml"), "api_key: abc\napi_key: def\n"), nil)
+	require.Len(t, findings, 1)
+	assert.Equal(t, RuleInvalidYAML, findings[0].Rule)
+
+	findings = LintMainConfig(writeFile(t, filepath.Join(dir, "list.yaml"), "- api_key\n"), nil)
+	require.Len(t, findings, 1)
+	assert.Equal(t, RuleInvalidYAML, findings[0].Rule)
+
+	findings = LintMainConfig(filepath.Join(dir, "missing.yaml"), nil)
+	require.Len(t, findings, 1)
+	assert.Equal(t, RuleInvalidYAML, findings[0].Rule)
+}
+
+func TestLintConfd(t *testing.T) {
+	confd := t.TempDir()
+	writeFile(t, filepath.Join(confd, "valid.yaml"), `
+init_config:
+instances:
+  - host: localhost
+    tags: [env:prod]
+    min_collection_interval: 30
+`)
+	writeFile(t, filepath.Join(confd, "logs_only.d", "conf.yaml"), `
+logs:
+  - type: file
+    path: /var/log/app.log
+`)
+	writeFile(t, filepath.Join(confd, "template.d", "auto_conf.yaml.default"), `
+ad_identifiers: [redis]
+init_config: {}
+instances:
+  - host: "%%host%%"
+logs:
+  - source: redis
+`)
+	writeFile(t, filepath.Join(confd, "empty.yaml"), "\n")
+	writeFile(t, filepath.Join(confd, "ignored.d", "conf.yaml.example"), "not: [valid")
+	writeFile(t, filepath.Join(confd, "notes.txt"), "not: [valid")
+
+	invalid := writeFile(t, filepath.Join(confd, "invalid.d", "conf.yaml"), `
+init_config: [a]
+instances:
+  - host: localhost
+    tags: env:prod
+    min_collection_interval: often
+  - localhost
+instance:
+  - host: localhost
+docker_images: [redis]
+logs:
+  - type: file
+  - type: tcp
+    port: 10514
+`)
+	noInstances := writeFile(t, filepath.Join(confd, "no_instances.yaml"), "init_config:\ninstances: []\n")
+	broken := writeFile(t, filepath.Join(confd, "broken.yml"), "init_config:\ninstances: [\n")
+
+	findings := LintConfd(confd)
+	byFile := map[string][]Finding{}
+	for _, finding := range findings {
+		byFile[finding.File] = append(byFile[finding.File], finding)
+	}
+	assert.Len(t, byFile, 3, findings)
+
+	assert.Equal(t, map[string][]Finding{
+		RuleInvalidIntegrationConfig: {
+			{Key: "init_config", Line: 2, Severity: SeverityError},
+			{Key: "instances[1]", Line: 7, Severity: SeverityError},
+			{Key: "docker_images", Line: 10, Severity: SeverityError},
+		},
+		RuleTypeMismatch: {
+			{Key: "instances[0].tags", Line: 5, Severity: SeverityError},
+			{Key: "instances[0].min_collection_interval", Line: 6, Severity: SeverityError},
+		},
+		RuleUnknownKey: {
+			{Key: "instance", Line: 8, Severity: SeverityWarning},
+		},
+		RuleInvalidLogsConfig: {
+			{Key: "logs[0]", Line: 12, Severity: SeverityError},
+		},
+	}, findingsByRule(byFile[invalid]))
+
+	assert.Equal(t, map[string][]Finding{
+		RuleInvalidIntegrationConfig: {{Key: "instances", Line: 1, Severity: SeverityError}},
+	}, findingsByRule(byFile[noInstances]))
+
+	require.Len(t, byFile[broken], 1)
+	assert.Equal(t, RuleInvalidYAML, byFile[broken][0].Rule)
+	assert.Equal(t, 2, byFile[broken][0].Line)
+
+	assert.Empty(t, LintConfd(filepath.Join(confd, "missing")))
+}
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	configFile := writeFile(t, filepath.Join(dir, "datadog.yaml"), "api_key: abc\nunknown_key: true\n")
+	writeFile(t, filepath.Join(dir, "conf.d", "check.yaml"), "init_config:\n")
+
+	report := Lint(Options{ConfigFile: configFile, ConfdPaths: []string{filepath.Join(dir, "conf.d")}})
+	assert.Equal(t, 1, report.Errors)
+	assert.Equal(t, 1, report.Warnings)
+	require.Len(t, report.Findings, 2)
+	assert.Equal(t, filepath.Join(dir, "conf.d", "check.yaml"), report.Findings[0].File)
+	assert.Equal(t, configFile, report.Findings[1].File)
+
+	// without conf.d folders, the confd_path of the main configuration is used
+	writeFile(t, configFile, "api_key: abc\nconfd_path: "+filepath.Join(dir, "conf.d")+"\n")
+	report = Lint(Options{ConfigFile: configFile})
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, filepath.Join(dir, "conf.d", "check.yaml"), report.Findings[0].File)
+
+	report = Lint(Options{ConfigFile: configFile, ConfdPaths: []string{}})
+	assert.Equal(t, Report{Findings: []Finding{}}, report)
+
+	// the confd_path can be set by a drop-in file
+	writeFile(t, configFile, "api_key: abc\n")
+	writeFile(t, filepath.Join(dir, "datadog.yaml.d", "10-confd.yaml"), "confd_path: "+filepath.Join(dir, "conf.d")+"\n")
+	report = Lint(Options{ConfigFile: configFile})
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, filepath.Join(dir, "conf.d", "check.yaml"), report.Findings[0].File)
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("log_level", "log_level", 3))
+	assert.Equal(t, 1, levenshtein("log_levl", "log_level", 3))
+	assert.Equal(t, 2, levenshtein("lgo_level", "log_level", 3))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting", 10))
+	assert.Equal(t, 3, levenshtein("api_key", "log_level", 3))
+	assert.Equal(t, 3, levenshtein("a", "abcdef", 3))
+}
@@ -47,6 +47,9 @@ type Reader interface {
 
 	GetSource(key string) Source
 	GetAllSources(key string) []ValueWithSource
+	// GetProvenance returns every value set for a key with the file or environment variable it comes from, from the
+	// lowest to the highest priority
+	GetProvenance(key string) []ValueWithProvenance
 
 	ConfigFileUsed() string
 	ExtraConfigFilesUsed() []string
@@ -140,6 +143,8 @@ type Compound interface {
 
 	AddConfigPath(in string)
 	AddExtraConfigPaths(in []string) error
+	// EnableDropInFiles makes ReadInConfig merge the drop-in files of the main configuration file
+	EnableDropInFiles()
 	SetConfigName(in string)
 	SetConfigFile(in string)
 	SetConfigType(in string)
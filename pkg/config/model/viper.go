@@ -78,6 +78,16 @@ type ValueWithSource struct {
 	Value  interface{}
 }
 
+// ValueWithProvenance is a value set for a key by a source, along with the file or environment variable it comes from
+// when known
+type ValueWithProvenance struct {
+	Source Source
+	// Origin is the configuration file or the environment variable that set the value. It is empty when unknown, for
+	// example for values set at runtime.
+	Origin string
+	Value  interface{}
+}
+
 // String casts Source into a string
 func (s Source) String() string {
 	// Safeguard: if we don't know the Source, we assume SourceUnknown
@@ -112,8 +122,30 @@ type safeConfig struct {
 
 	// extraConfigFilePaths represents additional configuration file paths that will be merged into the main configuration when ReadInConfig() is called.
 	extraConfigFilePaths []string
+
+	// dropInFilesEnabled tells ReadInConfig to merge the drop-in files of the main configuration file
+	dropInFilesEnabled bool
+
+	// envVarsByKey lists the environment variables bound to each key, in the order they are looked up
+	envVarsByKey map[string][]string
+
+	// fileLayers are the configuration files read by ReadInConfig, in the order they were merged
+	fileLayers []fileLayer
+
+	// fleetPoliciesPath is the file the fleet policies were merged from
+	fleetPoliciesPath string
+}
+
+// fileLayer holds the settings set by a single configuration file
+type fileLayer struct {
+	path     string
+	settings *viper.Viper
 }
 
+// DropInDirSuffix is appended to the path of the main configuration file to get the folder of the drop-in files merged
+// into it, for example "datadog.yaml.d"
+const DropInDirSuffix = ".d"
+
 // OnUpdate adds a callback to the list receivers to be called each time a value is changed in the configuration
 // by a call to the 'Set' method.
 // Callbacks are only called if the value is effectively changed.
@@ -291,6 +323,48 @@ func (c *safeConfig) GetAllSources(key string) []ValueWithSource {
 	return vals
 }
 
+// GetProvenance returns every value set for a key, from the lowest to the highest priority source: the last one is the
+// value in effect. Each configuration file setting the key is listed, in the order the files were merged.
+func (c *safeConfig) GetProvenance(key string) []ValueWithProvenance {
+	c.RLock()
+	defer c.RUnlock()
+	key = strings.ToLower(key)
+
+	var res []ValueWithProvenance
+	for _, source := range sources {
+		value := c.configSources[source].Get(key)
+		if value == nil {
+			continue
+		}
+
+		origin := ""
+		switch source {
+		case SourceFile:
+			fromLayers := false
+			for _, layer := range c.fileLayers {
+				if layerValue := layer.settings.Get(key); layerValue != nil {
+					res = append(res, ValueWithProvenance{Source: source, Origin: layer.path, Value: deepcopy.Copy(layerValue)})
+					fromLayers = true
+				}
+			}
+			if fromLayers {
+				continue
+			}
+		case SourceEnvVar:
+			for _, envVar := range c.envVarsByKey[key] {
+				if v, ok := os.LookupEnv(envVar); ok && v != "" {
+					origin = envVar
+					break
+				}
+			}
+		case SourceFleetPolicies:
+			origin = c.fleetPoliciesPath
+		}
+		res = append(res, ValueWithProvenance{Source: source, Origin: origin, Value: deepcopy.Copy(value)})
+	}
+	return res
+}
+
 // GetString wraps Viper for concurrent access
 func (c *safeConfig) GetString(key string) string {
 	c.RLock()
@@ -514,12 +588,14 @@ func (c *safeConfig) BindEnv(input ...string) {
 		envKeys = input[1:]
 	}
 
+	configKey := strings.ToLower(input[0])
 	for _, key := range envKeys {
 		// apply EnvKeyReplacer to each key
 		if c.envKeyReplacer != nil {
 			key = c.envKeyReplacer.Replace(key)
 		}
 		c.configEnvVars[key] = struct{}{}
+		c.envVarsByKey[configKey] = append(c.envVarsByKey[configKey], key)
 	}
 
 	_ = c.configSources[SourceEnvVar].BindEnv(input...)
@@ -566,14 +642,31 @@ func (c *safeConfig) ReadInConfig() error {
 	if err != nil {
 		return err
 	}
+	mainConfigPath := c.Viper.ConfigFileUsed()
+	mainLayer := viper.New()
+	_ = mainLayer.MergeConfigMap(c.configSources[SourceFile].AllSettings())
+	c.fileLayers = []fileLayer{{path: mainConfigPath, settings: mainLayer}}
 
 	type extraConf struct {
 		path    string
 		content []byte
 	}
 
-	// Read extra config files
+	// Read the drop-in files, in lexical order, then the extra config files
+	var dropInPaths []string
+	if c.dropInFilesEnabled {
+		if dropInPaths, err = dropInFiles(mainConfigPath + DropInDirSuffix); err != nil {
+			return err
+		}
+	}
 	extraConfContents := []extraConf{}
+	for _, path := range dropInPaths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read drop-in config file '%s': %w", path, err)
+		}
+		extraConfContents = append(extraConfContents, extraConf{path: path, content: b})
+	}
 	for _, path := range c.extraConfigFilePaths {
 		b, err := os.ReadFile(path)
 		if err != nil {
@@ -584,15 +677,42 @@ func (c *safeConfig) ReadInConfig() error {
 
 	// Merge with base config and 'file' config
 	for _, confFile := range extraConfContents {
-		err = errors.Join(c.Viper.MergeConfig(bytes.NewReader(confFile.content)), c.configSources[SourceFile].MergeConfig(bytes.NewReader(confFile.content)))
+		layer := viper.New()
+		layer.SetConfigType("yaml")
+		err = errors.Join(
+			c.Viper.MergeConfig(bytes.NewReader(confFile.content)),
+			c.configSources[SourceFile].MergeConfig(bytes.NewReader(confFile.content)),
+			layer.ReadConfig(bytes.NewReader(confFile.content)),
+		)
 		if err != nil {
 			return fmt.Errorf("error merging %s config file: %w", confFile.path, err)
 		}
+		c.fileLayers = append(c.fileLayers, fileLayer{path: confFile.path, settings: layer})
 		log.Infof("extra configuration file %s was loaded successfully", confFile.path)
 	}
 	return nil
 }
 
+// dropInFiles returns the YAML files of the drop-in folder, in lexical order. A missing folder has no files.
+func dropInFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not read drop-in config folder '%s': %w", dir, err)
+	}
+	// os.ReadDir returns the entries sorted by filename
+	var paths []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return paths, nil
+}
+
 // ReadConfig wraps Viper for concurrent access
 func (c *safeConfig) ReadConfig(in io.Reader) error {
 	c.Lock()
@@ -605,6 +725,7 @@ func (c *safeConfig) ReadConfig(in io.Reader) error {
 	if err != nil {
 		return err
 	}
+	c.fileLayers = nil
 	return c.configSources[SourceFile].ReadConfig(bytes.NewReader(b))
 }
 
@@ -643,6 +764,7 @@ func (c *safeConfig) MergeFleetPolicy(configPath string) error {
 	for _, key := range c.configSources[SourceFleetPolicies].AllKeys() {
 		c.mergeViperInstances(key)
 	}
+	c.fleetPoliciesPath = configPath
 	log.Infof("Fleet policies configuration %s successfully merged", path.Base(configPath))
 	return nil
 }
@@ -707,6 +829,14 @@ func (c *safeConfig) AddConfigPath(in string) {
 	c.Viper.AddConfigPath(in)
 }
 
+// EnableDropInFiles makes ReadInConfig merge the YAML files of the drop-in folder of the main configuration file (for
+// example "datadog.yaml.d"), in lexical order, before the extra configuration files.
+func (c *safeConfig) EnableDropInFiles() {
+	c.Lock()
+	defer c.Unlock()
+	c.dropInFilesEnabled = true
+}
+
 // AddExtraConfigPaths allows adding additional configuration files
 // which will be merged into the main configuration during the ReadInConfig call.
 // Configuration files are merged sequentially. If a key already exists and the foreign value type matches the existing one, the foreign value overrides it.
@@ -808,6 +938,7 @@ func NewConfig(name string, envPrefix string, envKeyReplacer *strings.Replacer)
 		configSources: map[Source]*viper.Viper{},
 		configEnvVars: map[string]struct{}{},
 		unknownKeys:   map[string]struct{}{},
+		envVarsByKey:  map[string][]string{},
 	}
 
 	// load one Viper instance per source of setting change
@@ -838,6 +969,10 @@ func (c *safeConfig) CopyConfig(cfg Config) {
 		c.configEnvVars = cfg.configEnvVars
 		c.unknownKeys = cfg.unknownKeys
 		c.notificationReceivers = cfg.notificationReceivers
+		c.envVarsByKey = cfg.envVarsByKey
+		c.fileLayers = cfg.fileLayers
+		c.fleetPoliciesPath = cfg.fleetPoliciesPath
+		c.dropInFilesEnabled = cfg.dropInFilesEnabled
 		return
 	}
 	panic("Replacement config must be an instance of safeConfig")
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
@@ -418,3 +419,85 @@ func TestMergeFleetPolicy(t *testing.T) {
 	assert.Equal(t, "baz", config.Get("foo"))
 	assert.Equal(t, SourceFleetPolicies, config.GetSource("foo"))
 }
+
+func TestDropInConfig(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "datadog.yaml")
+	dropInDir := mainPath + DropInDirSuffix
+	assert.NoError(t, os.Mkdir(dropInDir, 0755))
+	assert.NoError(t, os.Mkdir(filepath.Join(dropInDir, "ignored.yaml"), 0755))
+
+	for name, content := range map[string]string{
+		mainPath: "api_key: abcdef\nsite: datadoghq.com\nlogs_config:\n  use_http: true\n",
+		filepath.Join(dropInDir, "20-team-b.yaml"): "site: datadoghq.eu\n",
+		filepath.Join(dropInDir, "10-team-a.yml"):  "site: us3.datadoghq.com\nlogs_config:\n  use_compression: true\n",
+		filepath.Join(dropInDir, "README.md"):      "site: ignored\n",
+		filepath.Join(dir, "extra.yaml"):           "logs_config:\n  use_compression: false\n",
+	} {
+		assert.NoError(t, os.WriteFile(name, []byte(content), 0644))
+	}
+
+	config := NewConfig("test", "DD", strings.NewReplacer(".", "_"))
+	config.SetConfigFile(mainPath)
+	assert.NoError(t, config.AddExtraConfigPaths([]string{filepath.Join(dir, "extra.yaml")}))
+
+	// drop-in files are ignored unless enabled
+	assert.NoError(t, config.ReadInConfig())
+	assert.Equal(t, "datadoghq.com", config.Get("site"))
+	assert.Equal(t, false, config.Get("logs_config.use_compression"))
+
+	config.EnableDropInFiles()
+	assert.NoError(t, config.ReadInConfig())
+
+	assert.Equal(t, "abcdef", config.Get("api_key"))
+	assert.Equal(t, "datadoghq.eu", config.Get("site"))
+	assert.Equal(t, true, config.Get("logs_config.use_http"))
+	// extra config files are merged after the drop-in files
+	assert.Equal(t, false, config.Get("logs_config.use_compression"))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dropInDir, "30-invalid.yaml"), []byte("site: [invalid"), 0644))
+	assert.ErrorContains(t, config.ReadInConfig(), "30-invalid.yaml")
+}
+
+func TestGetProvenance(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "datadog.yaml")
+	dropInPath := filepath.Join(mainPath+DropInDirSuffix, "10-team-a.yaml")
+	assert.NoError(t, os.Mkdir(mainPath+DropInDirSuffix, 0755))
+	assert.NoError(t, os.WriteFile(mainPath, []byte("site: datadoghq.com\nlog_level: info\n"), 0644))
+	assert.NoError(t, os.WriteFile(dropInPath, []byte("site: datadoghq.eu\n"), 0644))
+
+	config := NewConfig("test", "DD", strings.NewReplacer(".", "_"))
+	config.BindEnvAndSetDefault("site", "datadoghq.com")
+	config.BindEnvAndSetDefault("log_level", "info", "DD_LOG_LEVEL", "LOG_LEVEL")
+	config.BindEnvAndSetDefault("hostname", "")
+	config.SetConfigFile(mainPath)
+	config.EnableDropInFiles()
+	assert.NoError(t, config.ReadInConfig())
+
+	t.Setenv("LOG_LEVEL", "debug")
+	config.Set("site", "datadoghq.fr", SourceCLI)
+
+	assert.Equal(t, []ValueWithProvenance{
+		{Source: SourceDefault, Value: "datadoghq.com"},
+		{Source: SourceFile, Origin: mainPath, Value: "datadoghq.com"},
+		{Source: SourceFile, Origin: dropInPath, Value: "datadoghq.eu"},
+		{Source: SourceCLI, Value: "datadoghq.fr"},
+	}, config.GetProvenance("site"))
+
+	assert.Equal(t, []ValueWithProvenance{
+		{Source: SourceDefault, Value: "info"},
+		{Source: SourceFile, Origin: mainPath, Value: "info"},
+		{Source: SourceEnvVar, Origin: "LOG_LEVEL", Value: "debug"},
+	}, config.GetProvenance("LOG_LEVEL"))
+
+	assert.Equal(t, []ValueWithProvenance{{Source: SourceDefault, Value: ""}}, config.GetProvenance("hostname"))
+	assert.Empty(t, config.GetProvenance("unknown"))
+
+	// values set without reading a file have no origin
+	config.Set("hostname", "myhost", SourceFile)
+	assert.Equal(t, []ValueWithProvenance{
+		{Source: SourceDefault, Value: ""},
+		{Source: SourceFile, Value: "myhost"},
+	}, config.GetProvenance("hostname"))
+}
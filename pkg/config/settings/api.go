@@ -18,6 +18,7 @@ type Client interface {
 	List() (map[string]settings.RuntimeSettingResponse, error)
 	FullConfig() (string, error)
 	FullConfigBySource() (string, error)
+	FullConfigProvenance() ([]settings.SettingProvenance, error)
 }
 
 // ClientBuilder represents a function returning a runtime settings API client
@@ -64,6 +64,19 @@ func (rc *runtimeSettingsHTTPClient) FullConfigBySource() (string, error) {
 	return string(r), nil
 }
 
+func (rc *runtimeSettingsHTTPClient) FullConfigProvenance() ([]settingsComponent.SettingProvenance, error) {
+	r, err := rc.doGet(fmt.Sprintf("%s/provenance", rc.baseURL), true)
+	if err != nil {
+		return nil, err
+	}
+	var provenances []settingsComponent.SettingProvenance
+	err = json.Unmarshal([]byte(r), &provenances)
+	if err != nil {
+		return nil, err
+	}
+	return provenances, nil
+}
+
 func (rc *runtimeSettingsHTTPClient) List() (map[string]settingsComponent.RuntimeSettingResponse, error) {
 	r, err := rc.doGet(fmt.Sprintf("%s/list-runtime", rc.baseURL), false)
 	if err != nil {
@@ -80,6 +80,9 @@ const (
 	// DefaultZstdCompressionLevel should mirror the default compression level defined in https://github.com/DataDog/zstd/blob/1.x/zstd.go#L23
 	DefaultZstdCompressionLevel = 5
 
+	// DefaultZlibCompressionLevel mirrors zlib.DefaultCompression, which maps to level 6
+	DefaultZlibCompressionLevel = -1
+
 	// DefaultLogsSenderBackoffFactor is the default logs sender backoff randomness factor
 	DefaultLogsSenderBackoffFactor = 2.0
 
@@ -165,6 +168,7 @@ type ConfigurationProviders struct {
 	PollInterval            string `mapstructure:"poll_interval"`
 	TemplateURL             string `mapstructure:"template_url"`
 	TemplateDir             string `mapstructure:"template_dir"`
+	Branch                  string `mapstructure:"branch"`
 	Username                string `mapstructure:"username"`
 	Password                string `mapstructure:"password"`
 	CAFile                  string `mapstructure:"ca_file"`
@@ -332,6 +336,15 @@ func InitConfig(config pkgconfigmodel.Config) {
 	config.BindEnvAndSetDefault("secret_backend_command_allow_group_exec_perm", false)
 	config.BindEnvAndSetDefault("secret_backend_skip_checks", false)
 	config.BindEnvAndSetDefault("secret_backend_remove_trailing_line_break", false)
+	config.BindEnvAndSetDefault("secret_backend_type", "")
+	config.BindEnv("secret_backend_config")
+	config.SetEnvKeyTransformer("secret_backend_config", func(in string) interface{} {
+		var backendConfig map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &backendConfig); err != nil {
+			log.Errorf(`"secret_backend_config" can not be parsed: %v`, err)
+		}
+		return backendConfig
+	})
 	config.BindEnvAndSetDefault("secret_refresh_interval", 0)
 	config.SetDefault("secret_audit_file_max_size", 0)
 
@@ -375,6 +388,15 @@ func InitConfig(config pkgconfigmodel.Config) {
 	// 	- `pods`
 	// 	- `nodes`
 	config.BindEnvAndSetDefault("kubernetes_resources_labels_as_tags", map[string]map[string]string{})
+	// tag_extraction_rules is a list of declarative tag extraction rules, see comp/core/tagger/tagrules
+	config.BindEnv("tag_extraction_rules")
+	config.SetEnvKeyTransformer("tag_extraction_rules", func(in string) interface{} {
+		var rules []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"tag_extraction_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
 	config.BindEnvAndSetDefault("kubernetes_persistent_volume_claims_as_tags", true)
 	config.BindEnvAndSetDefault("container_cgroup_prefix", "")
 
@@ -386,6 +408,14 @@ func InitConfig(config pkgconfigmodel.Config) {
 	// Network Devices Monitoring
 	bindEnvAndSetLogsConfigKeys(config, "network_devices.metadata.")
 	config.BindEnvAndSetDefault("network_devices.namespace", "default")
+	config.BindEnvAndSetDefault("network_devices.topology.export.enabled", false)
+	config.BindEnvAndSetDefault("network_devices.polling.enabled", false)
+	config.BindEnvAndSetDefault("network_devices.polling.max_concurrency", 0)
+	config.BindEnvAndSetDefault("network_devices.polling.max_packets_per_second", 0)
+	config.BindEnvAndSetDefault("network_devices.polling.spread", true)
+	config.BindEnvAndSetDefault("network_devices.polling.min_timeout", 0.5)
+	config.BindEnvAndSetDefault("network_devices.polling.max_backoff", 900)
+	config.BindEnvAndSetDefault("network_devices.polling.max_interval_factor", 4)
 
 	config.SetKnown("snmp_listener.discovery_interval")
 	config.SetKnown("snmp_listener.allowed_failures")
@@ -434,6 +464,9 @@ func InitConfig(config pkgconfigmodel.Config) {
 	config.BindEnvAndSetDefault("network_devices.snmp_traps.bind_host", "0.0.0.0")
 	config.BindEnvAndSetDefault("network_devices.snmp_traps.stop_timeout", 5) // in seconds
 	config.SetKnown("network_devices.snmp_traps.users")
+	config.SetKnown("network_devices.snmp_traps.rules")
+	config.SetKnown("network_devices.snmp_traps.engine_id")
+	config.SetKnown("network_devices.snmp_traps.relay")
 
 	// NetFlow
 	config.SetKnown("network_devices.netflow.listeners")
@@ -446,6 +479,11 @@ func InitConfig(config pkgconfigmodel.Config) {
 	config.BindEnvAndSetDefault("network_devices.netflow.enabled", "false")
 	bindEnvAndSetLogsConfigKeys(config, "network_devices.netflow.forwarder.")
 	config.BindEnvAndSetDefault("network_devices.netflow.reverse_dns_enrichment_enabled", false)
+	config.SetKnown("network_devices.netflow.top_talkers.enabled")
+	config.SetKnown("network_devices.netflow.top_talkers.size")
+	config.SetKnown("network_devices.netflow.top_talkers.dimensions")
+	config.SetKnown("network_devices.netflow.top_talkers.application_field")
+	config.SetKnown("network_devices.netflow.top_talkers.interval")
 
 	// Network Path
 	config.BindEnvAndSetDefault("network_path.connections_monitoring.enabled", false)
@@ -456,6 +494,12 @@ func InitConfig(config pkgconfigmodel.Config) {
 	config.BindEnvAndSetDefault("network_path.collector.pathtest_ttl", "15m")
 	config.BindEnvAndSetDefault("network_path.collector.pathtest_interval", "5m")
 	config.BindEnvAndSetDefault("network_path.collector.flush_interval", "10s")
+	config.BindEnvAndSetDefault("network_path.netflow_monitoring.enabled", false)
+	config.BindEnvAndSetDefault("network_path.collector.selection.enabled", false)
+	config.BindEnvAndSetDefault("network_path.collector.selection.policy", "traffic_volume")
+	config.BindEnvAndSetDefault("network_path.collector.selection.window", "5m")
+	config.BindEnvAndSetDefault("network_path.collector.selection.max_tests_per_window", 100)
+	config.BindEnvAndSetDefault("network_path.collector.selection.subnet_prefix_length", 24)
 	bindEnvAndSetLogsConfigKeys(config, "network_path.forwarder.")
 
 	// Kube ApiServer
@@ -877,6 +921,10 @@ func InitConfig(config pkgconfigmodel.Config) {
 	// debug config to enable a remote client to receive data from the workloadmeta agent without a timeout
 	config.BindEnvAndSetDefault("workloadmeta.remote.recv_without_timeout", false)
 
+	// debug config to replay the workloadmeta events recorded with `agent workload-list --record`
+	config.BindEnvAndSetDefault("workloadmeta.replay.file", "")
+	config.BindEnvAndSetDefault("workloadmeta.replay.speed", 1.0)
+
 	config.BindEnvAndSetDefault("security_agent.internal_profiling.enabled", false, "DD_SECURITY_AGENT_INTERNAL_PROFILING_ENABLED")
 	config.BindEnvAndSetDefault("security_agent.internal_profiling.site", DefaultSite, "DD_SECURITY_AGENT_INTERNAL_PROFILING_SITE", "DD_SITE")
 	config.BindEnvAndSetDefault("security_agent.internal_profiling.profile_dd_url", "", "DD_SECURITY_AGENT_INTERNAL_PROFILING_DD_URL", "DD_APM_INTERNAL_PROFILING_DD_URL")
@@ -1240,6 +1288,7 @@ func serializer(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("serializer_max_series_uncompressed_payload_size", 5242880)
 	config.BindEnvAndSetDefault("serializer_compressor_kind", DefaultCompressorKind)
 	config.BindEnvAndSetDefault("serializer_zstd_compressor_level", DefaultZstdCompressionLevel)
+	config.BindEnvAndSetDefault("serializer_zlib_compressor_level", DefaultZlibCompressionLevel)
 
 	config.BindEnvAndSetDefault("use_v2_api.series", true)
 	// Serializer: allow user to blacklist any kind of payload to be sent
@@ -1257,6 +1306,12 @@ func aggregator(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("basic_telemetry_add_container_tags", false) // configure adding the agent container tags to the basic agent telemetry metrics (e.g. `datadog.agent.running`)
 	config.BindEnvAndSetDefault("aggregator_flush_metrics_and_serialize_in_parallel_chan_size", 200)
 	config.BindEnvAndSetDefault("aggregator_flush_metrics_and_serialize_in_parallel_buffer_size", 4000)
+
+	// Local OpenMetrics endpoint exposing the last flushed series and sketches
+	config.BindEnvAndSetDefault("openmetrics_endpoint.enabled", false)
+	config.BindEnvAndSetDefault("openmetrics_endpoint.host", "localhost")
+	config.BindEnvAndSetDefault("openmetrics_endpoint.port", 5013)
+	config.BindEnvAndSetDefault("openmetrics_endpoint.allowlist", []string{})
 }
 
 func serverless(config pkgconfigmodel.Setup) {
@@ -1300,6 +1355,18 @@ func forwarder(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("forwarder_high_prio_buffer_size", 100)
 	config.BindEnvAndSetDefault("forwarder_low_prio_buffer_size", 100)
 	config.BindEnvAndSetDefault("forwarder_requeue_buffer_size", 100)
+
+	// Forwarder bandwidth limit, per domain
+	config.BindEnvAndSetDefault("forwarder_bandwidth_limit_bytes_per_sec", 0) // 0 means disabled
+	config.BindEnvAndSetDefault("forwarder_bandwidth_burst_bytes", 0)         // 0 means one second worth of bandwidth
+	config.BindEnv("forwarder_bandwidth_weights")
+	config.SetEnvKeyTransformer("forwarder_bandwidth_weights", func(in string) interface{} {
+		var weights map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &weights); err != nil {
+			log.Errorf(`"forwarder_bandwidth_weights" can not be parsed: %v`, err)
+		}
+		return weights
+	})
 }
 
 func dogstatsd(config pkgconfigmodel.Setup) {
@@ -1397,6 +1464,14 @@ func dogstatsd(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("histogram_copy_to_distribution_prefix", "")
 	config.BindEnvAndSetDefault("histogram_aggregates", []string{"max", "median", "avg", "count"})
 	config.BindEnvAndSetDefault("histogram_percentiles", []string{"0.95"})
+	config.BindEnv("histogram_overrides")
+	config.SetEnvKeyTransformer("histogram_overrides", func(in string) interface{} {
+		var overrides []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &overrides); err != nil {
+			log.Errorf(`"histogram_overrides" can not be parsed: %v`, err)
+		}
+		return overrides
+	})
 }
 
 func logsagent(config pkgconfigmodel.Setup) {
@@ -1785,7 +1860,9 @@ func findUnexpectedUnicode(config pkgconfigmodel.Config) []string {
 	return messages
 }
 
-func findUnknownEnvVars(config pkgconfigmodel.Config, environ []string, additionalKnownEnvVars []string) []string {
+// FindUnknownEnvVars returns the DD_ environment variables of environ that are neither bound to a configuration key nor
+// read directly by the agent
+func FindUnknownEnvVars(config pkgconfigmodel.Config, environ []string, additionalKnownEnvVars []string) []string {
 	var unknownVars []string
 
 	knownVars := map[string]struct{}{
@@ -1896,6 +1973,8 @@ func LoadDatadogCustom(config pkgconfigmodel.Config, origin string, secretResolv
 	}()
 
 	warnings := &pkgconfigmodel.Warnings{}
+	// Only the main datadog config merges the files of its drop-in folder, for example datadog.yaml.d
+	config.EnableDropInFiles()
 	err := LoadCustom(config, additionalKnownEnvVars)
 	if err != nil {
 		if errors.Is(err, os.ErrPermission) {
@@ -1973,7 +2052,7 @@ func LoadCustom(config pkgconfigmodel.Config, additionalKnownEnvVars []string) e
 		log.Warnf("Unknown key in config file: %v", key)
 	}
 
-	for _, v := range findUnknownEnvVars(config, os.Environ(), additionalKnownEnvVars) {
+	for _, v := range FindUnknownEnvVars(config, os.Environ(), additionalKnownEnvVars) {
 		log.Warnf("Unknown environment variable: %v", v)
 	}
 
@@ -2129,9 +2208,11 @@ func ResolveSecrets(config pkgconfigmodel.Config, secretResolver secrets.Compone
 		RemoveLinebreak:  config.GetBool("secret_backend_remove_trailing_line_break"),
 		RunPath:          config.GetString("run_path"),
 		AuditFileMaxSize: config.GetInt("secret_audit_file_max_size"),
+		Type:             config.GetString("secret_backend_type"),
+		Config:           secretBackendConfig(config),
 	})
 
-	if config.GetString("secret_backend_command") != "" {
+	if config.GetString("secret_backend_command") != "" || config.GetString("secret_backend_type") != "" {
 		// Viper doesn't expose the final location of the file it
 		// loads. Since we are searching for 'datadog.yaml' in multiple
 		// locations we let viper determine the one to use before
@@ -2157,6 +2238,14 @@ func ResolveSecrets(config pkgconfigmodel.Config, secretResolver secrets.Compone
 	return nil
 }
 
+// secretBackendConfig returns the settings of the native secret backend
+func secretBackendConfig(config pkgconfigmodel.Config) map[string]interface{} {
+	if !config.IsSet("secret_backend_config") {
+		return nil
+	}
+	return config.GetStringMap("secret_backend_config")
+}
+
 // confgAssignAtPath assigns a value to the given setting of the config
 // This works around viper issues that prevent us from assigning to fields that have a dot in the
 // name (example: 'additional_endpoints.http://url.com') and also allows us to assign to individual
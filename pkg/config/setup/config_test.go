@@ -149,7 +149,7 @@ func TestUnknownVarsWarning(t *testing.T) {
 			if unknown {
 				exp = append(exp, v)
 			}
-			assert.Equal(t, exp, findUnknownEnvVars(Conf(), env, additional))
+			assert.Equal(t, exp, FindUnknownEnvVars(Conf(), env, additional))
 		}
 	}
 	t.Run("DD_API_KEY", test("DD_API_KEY", false, nil))
@@ -747,6 +747,13 @@ func TestGetValidHostAliasesWithConfig(t *testing.T) {
 	assert.EqualValues(t, getValidHostAliasesWithConfig(config), []string{"foo"})
 }
 
+func TestOpenMetricsEndpointDefaultPort(t *testing.T) {
+	config := Conf()
+
+	// The endpoint must not collide with the trace-agent debug server running on the same host
+	assert.NotEqual(t, config.GetInt("apm_config.debug.port"), config.GetInt("openmetrics_endpoint.port"))
+}
+
 func TestNetworkDevicesNamespace(t *testing.T) {
 	datadogYaml := `
 network_devices:
@@ -1535,3 +1542,23 @@ app_key: '***********************************acccc'
 yet_another_key: "********"`
 	assert.YAMLEq(t, expected, scrubbed)
 }
+
+func TestLoadDropInFiles(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "datadog.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("site: datadoghq.com\n"), 0o600))
+	require.NoError(t, os.Mkdir(configPath+pkgconfigmodel.DropInDirSuffix, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configPath+pkgconfigmodel.DropInDirSuffix, "10-site.yaml"), []byte("site: datadoghq.eu\n"), 0o600))
+
+	// other configs don't read the drop-in files
+	config := Conf()
+	config.SetConfigFile(configPath)
+	require.NoError(t, LoadCustom(config, nil))
+	assert.Equal(t, "datadoghq.com", config.GetString("site"))
+
+	config = Conf()
+	config.SetConfigFile(configPath)
+	_, err := LoadWithoutSecret(config, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "datadoghq.eu", config.GetString("site"))
+}
@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package setup
+
+// DeprecatedKeys maps the deprecated configuration keys that are still supported to the keys replacing them. Keys are
+// listed here when they are kept for backward compatibility only, so that tools like `agent config lint` can suggest
+// the replacement.
+var DeprecatedKeys = map[string]string{
+	"apm_config.max_traces_per_second":                 "apm_config.target_traces_per_second",
+	"compliance_config.xccdf.enabled":                  "compliance_config.host_benchmarks.enabled",
+	"flare_stripped_keys":                              "scrubber.additional_keys",
+	"forwarder_retry_queue_max_size":                   "forwarder_retry_queue_payloads_max_size",
+	"ipc_address":                                      "cmd_host",
+	"log_enabled":                                      "logs_enabled",
+	"logs_config.use_http":                             "logs_config.force_use_http",
+	"logs_config.use_tcp":                              "logs_config.force_use_tcp",
+	"otlp_config.debug.loglevel":                       "otlp_config.debug.verbosity",
+	"process_config.enabled":                           "process_config.process_collection.enabled",
+	"process_config.orchestrator_additional_endpoints": "orchestrator_explorer.orchestrator_additional_endpoints",
+	"process_config.orchestrator_dd_url":               "orchestrator_explorer.orchestrator_dd_url",
+	"tracemalloc_blacklist":                            "tracemalloc_exclude",
+	"tracemalloc_whitelist":                            "tracemalloc_include",
+}
@@ -0,0 +1,21 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package setup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecatedKeysReplacementsAreKnown(t *testing.T) {
+	config := Conf()
+	known := config.GetKnownKeysLowercased()
+	for deprecated, replacement := range DeprecatedKeys {
+		_, ok := known[replacement]
+		assert.True(t, ok, "replacement %s of %s is not a known key", replacement, deprecated)
+	}
+}
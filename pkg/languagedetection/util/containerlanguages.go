@@ -41,6 +41,26 @@ func NewInitContainer(containerName string) *Container {
 	}
 }
 
+// initContainerTextPrefix prefixes the names of init containers in their text
+// representation. It can't be ambiguous as container names can't contain dots.
+const initContainerTextPrefix = "init."
+
+// MarshalText implements encoding.TextMarshaler, so that containers can be the
+// keys of JSON objects.
+func (c Container) MarshalText() ([]byte, error) {
+	if c.Init {
+		return []byte(initContainerTextPrefix + c.Name), nil
+	}
+	return []byte(c.Name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (c *Container) UnmarshalText(text []byte) error {
+	name, init := strings.CutPrefix(string(text), initContainerTextPrefix)
+	c.Name, c.Init = name, init
+	return nil
+}
+
 ////////////////////////////////
 //                            //
 //    ContainersLanguages     //
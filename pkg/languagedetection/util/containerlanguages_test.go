@@ -6,8 +6,10 @@
 package util
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"reflect"
 	"testing"
 	"time"
@@ -212,3 +214,18 @@ func TestRemoveExpiredLanguages(t *testing.T) {
 	expectedTimedContainersLanguages := TimedContainersLanguages{*NewContainer("cont-name"): expectedLangset}
 	assert.Truef(t, reflect.DeepEqual(containersLanguages, expectedTimedContainersLanguages), "Expected %v, found %v", expectedTimedContainersLanguages, containersLanguages)
 }
+
+func TestContainerTextMarshaling(t *testing.T) {
+	languages := ContainersLanguages{
+		*NewContainer("app"):         LanguageSet{"java": {}},
+		*NewInitContainer("migrate"): LanguageSet{"python": {}},
+	}
+
+	data, err := json.Marshal(languages)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"app": {"java": {}}, "init.migrate": {"python": {}}}`, string(data))
+
+	var decoded ContainersLanguages
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, languages, decoded)
+}
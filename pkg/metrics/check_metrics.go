@@ -67,11 +67,11 @@ func NewCheckMetrics(expireMetrics bool, statefulTimeout time.Duration) CheckMet
 // If contextKey is scheduled for removal (see Expire), it will be unscheduled.
 //
 // See also ContextMetrics.AddSample().
-func (cm *CheckMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSample, timestamp float64, interval int64, config pkgconfigmodel.Config) error {
+func (cm *CheckMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSample, timestamp float64, interval int64, histogramConfigs HistogramConfigGetter, config pkgconfigmodel.Config) error {
 	if cm.deadlines != nil {
 		delete(cm.deadlines, contextKey)
 	}
-	return cm.metrics.AddSample(contextKey, sample, timestamp, interval, checkMetricsAddSampleTelemetry, config)
+	return cm.metrics.AddSample(contextKey, sample, timestamp, interval, histogramConfigs, checkMetricsAddSampleTelemetry, config)
 }
 
 // Expire enables metric data for given context keys to be removed.
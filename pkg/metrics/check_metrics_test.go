@@ -18,16 +18,16 @@ func TestCheckMetrics(t *testing.T) {
 	t0 := 16_0000_0000.0
 
 	cfg := setupConfig()
-	cm.AddSample(1, &MetricSample{Mtype: GaugeType}, t0, 1, cfg)
+	cm.AddSample(1, &MetricSample{Mtype: GaugeType}, t0, 1, nil, cfg)
 	assert.Contains(t, cm.metrics, ckey.ContextKey(1))
 
-	cm.AddSample(2, &MetricSample{Mtype: MonotonicCountType}, t0, 1, cfg)
+	cm.AddSample(2, &MetricSample{Mtype: MonotonicCountType}, t0, 1, nil, cfg)
 	assert.Contains(t, cm.metrics, ckey.ContextKey(2))
 
-	cm.AddSample(3, &MetricSample{Mtype: MonotonicCountType}, t0, 1, cfg)
+	cm.AddSample(3, &MetricSample{Mtype: MonotonicCountType}, t0, 1, nil, cfg)
 	assert.Contains(t, cm.metrics, ckey.ContextKey(3))
 
-	cm.AddSample(4, &MetricSample{Mtype: GaugeType}, t0, 1, cfg)
+	cm.AddSample(4, &MetricSample{Mtype: GaugeType}, t0, 1, nil, cfg)
 	assert.Contains(t, cm.metrics, ckey.ContextKey(4))
 
 	cm.Expire([]ckey.ContextKey{1, 2}, t0+100)
@@ -57,16 +57,16 @@ func TestCheckMetricsNoExpiry(t *testing.T) {
 	t0 := 16_0000_0000.0
 
 	cfg := setupConfig()
-	cm.AddSample(1, &MetricSample{Mtype: GaugeType}, t0, 1, cfg)
+	cm.AddSample(1, &MetricSample{Mtype: GaugeType}, t0, 1, nil, cfg)
 	assert.Contains(t, cm.metrics, ckey.ContextKey(1))
 
-	cm.AddSample(2, &MetricSample{Mtype: MonotonicCountType}, t0, 1, cfg)
+	cm.AddSample(2, &MetricSample{Mtype: MonotonicCountType}, t0, 1, nil, cfg)
 	assert.Contains(t, cm.metrics, ckey.ContextKey(2))
 
-	cm.AddSample(3, &MetricSample{Mtype: MonotonicCountType}, t0, 1, cfg)
+	cm.AddSample(3, &MetricSample{Mtype: MonotonicCountType}, t0, 1, nil, cfg)
 	assert.Contains(t, cm.metrics, ckey.ContextKey(3))
 
-	cm.AddSample(4, &MetricSample{Mtype: GaugeType}, t0, 1, cfg)
+	cm.AddSample(4, &MetricSample{Mtype: GaugeType}, t0, 1, nil, cfg)
 	assert.Contains(t, cm.metrics, ckey.ContextKey(4))
 
 	cm.Expire([]ckey.ContextKey{1, 2}, t0+100)
@@ -42,8 +42,16 @@ func (a *AddSampleTelemetry) Inc(isStateful bool) {
 	}
 }
 
+// HistogramConfigGetter returns the histogram configuration overriding the global one for a context, if any
+type HistogramConfigGetter interface {
+	HistogramConfig(contextKey ckey.ContextKey) *HistogramConfig
+}
+
 // AddSample add a sample to the current ContextMetrics and initialize a new metrics if needed.
-func (m ContextMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSample, timestamp float64, interval int64, t *AddSampleTelemetry, config pkgconfigmodel.Config) error {
+//
+// histogramConfigs, when not nil, is queried for the configuration of a new histogram or
+// historate. It is not used for other metric types nor for existing metrics.
+func (m ContextMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSample, timestamp float64, interval int64, histogramConfigs HistogramConfigGetter, t *AddSampleTelemetry, config pkgconfigmodel.Config) error {
 	if math.IsInf(sample.Value, 0) || math.IsNaN(sample.Value) {
 		return fmt.Errorf("sample with value '%v'", sample.Value)
 	}
@@ -58,9 +66,17 @@ func (m ContextMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSamp
 		case MonotonicCountType:
 			m[contextKey] = &MonotonicCount{}
 		case HistogramType:
-			m[contextKey] = NewHistogram(interval, config) // default histogram configuration (no call to `configure`) for now
+			h := NewHistogram(interval, config)
+			if histogramConfigs != nil {
+				h.applyConfig(histogramConfigs.HistogramConfig(contextKey))
+			}
+			m[contextKey] = h
 		case HistorateType:
-			m[contextKey] = NewHistorate(interval, config) // internal histogram has the configuration for now
+			h := NewHistorate(interval, config)
+			if histogramConfigs != nil {
+				h.histogram.applyConfig(histogramConfigs.HistogramConfig(contextKey))
+			}
+			m[contextKey] = h
 		case SetType:
 			m[contextKey] = NewSet()
 		case CounterType:
@@ -74,7 +74,7 @@ func addMetricSample(contextMetrics ContextMetrics, contextKey int, value float6
 		Mtype: GaugeType,
 	}
 	c := setupConfig()
-	contextMetrics.AddSample(ckey.ContextKey(contextKey), &mSample, 1, 10, nil, c)
+	contextMetrics.AddSample(ckey.ContextKey(contextKey), &mSample, 1, 10, nil, nil, c)
 }
 
 func flushAndClear(require *require.Assertions, flusher *ContextMetricsFlusher) [][]*Serie {
@@ -7,6 +7,7 @@ package metrics
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 
@@ -57,23 +58,37 @@ type histogramPercentilesConfig struct {
 func (h *histogramPercentilesConfig) percentiles() []int {
 	res := []int{}
 	for _, p := range h.Percentiles {
-		i, err := strconv.ParseFloat(p, 64)
+		percentile, err := parsePercentile(p)
 		if err != nil {
-			log.Errorf("Could not parse '%s' from 'histogram_percentiles' (skipping): %s", p, err)
+			log.Errorf("Invalid value '%s' in 'histogram_percentiles' (skipping): %s", p, err)
 			continue
 		}
-		if i < 0 || i > 1 {
-			log.Errorf("histogram_percentiles must be between 0 and 1: skipping %f", i)
-			continue
-		}
-		// in some cases the '*100' will lower the number resulting in
-		// an int lower by 1 from what is expected (ex: 0.29 would
-		// become 28). As a workaround we add 0.5 before casting.
-		res = append(res, int(i*100+0.5))
+		res = append(res, percentile)
 	}
 	return res
 }
 
+// parsePercentile parses a percentile given as a float between 0 and 1 and returns it
+// as a whole percent. Values that are not whole percents, like 0.999, are rejected
+// rather than rounded since they would be reported under another percentile.
+func parsePercentile(p string) (int, error) {
+	i, err := strconv.ParseFloat(p, 64)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 || i > 1 {
+		return 0, fmt.Errorf("must be between 0 and 1")
+	}
+	// in some cases the '*100' will lower the number resulting in
+	// an int lower by 1 from what is expected (ex: 0.29 would
+	// become 28). As a workaround we round instead of casting.
+	percent := math.Round(i * 100)
+	if math.Abs(i*100-percent) > 1e-9 {
+		return 0, fmt.Errorf("must be a whole percent, like 0.99")
+	}
+	return int(percent), nil
+}
+
 // NewHistogram returns a newly initialized histogram
 func NewHistogram(interval int64, config pkgconfigmodel.Config) *Histogram {
 	// we initialize default value on the first histogram creation
@@ -98,6 +113,16 @@ func NewHistogram(interval int64, config pkgconfigmodel.Config) *Histogram {
 	}
 }
 
+// applyConfig overrides the global configuration with c, when not nil. c.Percentiles
+// must already be sorted since it is shared between histograms.
+func (h *Histogram) applyConfig(c *HistogramConfig) {
+	if c == nil {
+		return
+	}
+	h.aggregates = c.Aggregates
+	h.percentiles = c.Percentiles
+}
+
 func (h *Histogram) configure(aggregates []string, percentiles []int) {
 	h.aggregates = aggregates
 	sort.Ints(percentiles)
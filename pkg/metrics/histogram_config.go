@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	histogramMatchTypeWildcard = "wildcard"
+	histogramMatchTypePrefix   = "prefix"
+	histogramMatchTypeRegex    = "regex"
+)
+
+var allowedHistogramWildcardPattern = regexp.MustCompile(`^[a-zA-Z0-9\-_*.]+$`)
+
+// HistogramConfig holds the aggregates and percentiles computed by a histogram
+type HistogramConfig struct {
+	Aggregates  []string
+	Percentiles []int // each in the 1-100 range, sorted
+}
+
+// HistogramOverride is the user-facing definition of a per-metric histogram configuration,
+// as found in the `histogram_overrides` setting.
+type HistogramOverride struct {
+	Match       string   `mapstructure:"match" json:"match" yaml:"match"`
+	MatchType   string   `mapstructure:"match_type" json:"match_type" yaml:"match_type"`
+	Aggregates  []string `mapstructure:"aggregates" json:"aggregates" yaml:"aggregates"`
+	Percentiles []string `mapstructure:"percentiles" json:"percentiles" yaml:"percentiles"`
+}
+
+type histogramOverrideRule struct {
+	prefix string         // used by the `prefix` match type
+	regex  *regexp.Regexp // used by the `wildcard` and `regex` match types
+	config *HistogramConfig
+}
+
+func (r *histogramOverrideRule) matches(name string) bool {
+	if r.regex == nil {
+		return strings.HasPrefix(name, r.prefix)
+	}
+	return r.regex.MatchString(name)
+}
+
+// HistogramConfigResolver resolves the histogram configuration to use for a given metric name.
+//
+// Rules are evaluated in order and the first one matching wins. Resolving is not free, callers
+// are expected to do it once per context and keep the result around.
+type HistogramConfigResolver struct {
+	rules []histogramOverrideRule
+}
+
+// NewHistogramConfigResolver builds a resolver from the `histogram_overrides` setting.
+// Invalid rules are logged and skipped.
+func NewHistogramConfigResolver(config pkgconfigmodel.Config) *HistogramConfigResolver {
+	if !config.IsSet("histogram_overrides") {
+		return &HistogramConfigResolver{}
+	}
+
+	var overrides []HistogramOverride
+	if err := config.UnmarshalKey("histogram_overrides", &overrides); err != nil {
+		log.Errorf("Could not parse histogram_overrides: %v", err)
+		return &HistogramConfigResolver{}
+	}
+
+	defaults := HistogramConfig{Aggregates: config.GetStringSlice("histogram_aggregates")}
+	c := histogramPercentilesConfig{}
+	if err := config.Unmarshal(&c); err != nil {
+		log.Errorf("Could not Unmarshal histogram configuration: %s", err)
+	} else {
+		defaults.Percentiles = c.percentiles()
+		sort.Ints(defaults.Percentiles)
+	}
+
+	return newHistogramConfigResolver(overrides, defaults)
+}
+
+func newHistogramConfigResolver(overrides []HistogramOverride, defaults HistogramConfig) *HistogramConfigResolver {
+	r := &HistogramConfigResolver{}
+	for i, o := range overrides {
+		rule, err := buildHistogramOverrideRule(o, defaults)
+		if err != nil {
+			log.Errorf("histogram_overrides: skipping rule %d: %v", i, err)
+			continue
+		}
+		r.rules = append(r.rules, rule)
+	}
+	return r
+}
+
+// buildHistogramOverrideRule compiles an override. Fields left unset in the override
+// fall back to the global `histogram_aggregates` and `histogram_percentiles` values.
+func buildHistogramOverrideRule(o HistogramOverride, defaults HistogramConfig) (histogramOverrideRule, error) {
+	rule := histogramOverrideRule{}
+	if o.Match == "" {
+		return rule, fmt.Errorf("match is required")
+	}
+
+	switch o.MatchType {
+	case histogramMatchTypePrefix:
+		rule.prefix = o.Match
+	case "", histogramMatchTypeWildcard:
+		if !allowedHistogramWildcardPattern.MatchString(o.Match) {
+			return rule, fmt.Errorf("invalid wildcard match pattern `%s`", o.Match)
+		}
+		re := strings.ReplaceAll(regexp.QuoteMeta(o.Match), `\*`, `[^.]*`)
+		rule.regex = regexp.MustCompile("^" + re + "$")
+	case histogramMatchTypeRegex:
+		re, err := regexp.Compile(o.Match)
+		if err != nil {
+			return rule, fmt.Errorf("invalid regex `%s`: %v", o.Match, err)
+		}
+		rule.regex = re
+	default:
+		return rule, fmt.Errorf("invalid match type `%s`, must be `wildcard`, `prefix` or `regex`", o.MatchType)
+	}
+
+	aggregates := defaults.Aggregates
+	if o.Aggregates != nil {
+		aggregates = o.Aggregates
+	}
+	percentiles := defaults.Percentiles
+	if o.Percentiles != nil {
+		percentiles = make([]int, 0, len(o.Percentiles))
+		for _, p := range o.Percentiles {
+			percentile, err := parsePercentile(p)
+			if err != nil {
+				return rule, fmt.Errorf("invalid percentile `%s`: %v", p, err)
+			}
+			percentiles = append(percentiles, percentile)
+		}
+		sort.Ints(percentiles)
+	}
+
+	rule.config = &HistogramConfig{
+		Aggregates:  aggregates,
+		Percentiles: percentiles,
+	}
+	return rule, nil
+}
+
+// Resolve returns the histogram configuration overriding the global one for the
+// given metric name, or nil when no override applies.
+func (r *HistogramConfigResolver) Resolve(name string) *HistogramConfig {
+	if r == nil {
+		return nil
+	}
+	for i := range r.rules {
+		if r.rules[i].matches(name) {
+			return r.rules[i].config
+		}
+	}
+	return nil
+}
+
+// Empty returns true if no override is configured.
+func (r *HistogramConfigResolver) Empty() bool {
+	return r == nil || len(r.rules) == 0
+}
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+func TestHistogramConfigResolver(t *testing.T) {
+	defaults := HistogramConfig{Aggregates: []string{"max", "avg"}, Percentiles: []int{95}}
+	r := newHistogramConfigResolver([]HistogramOverride{
+		{Match: "http.*.latency", Percentiles: []string{"0.99", "0.5"}},
+		{Match: "queue.", MatchType: "prefix", Aggregates: []string{"count"}, Percentiles: []string{}},
+		{Match: `^db\.(read|write)$`, MatchType: "regex", Aggregates: []string{"sum"}},
+		{Match: "invalid", MatchType: "unknown"},
+		{Match: ""},
+		{Match: "bad(", MatchType: "regex"},
+		{Match: "http.*.latency", Percentiles: []string{"0.999"}},
+	}, defaults)
+
+	require.False(t, r.Empty())
+	assert.Len(t, r.rules, 3)
+
+	assert.Equal(t, &HistogramConfig{Aggregates: []string{"max", "avg"}, Percentiles: []int{50, 99}}, r.Resolve("http.server.latency"))
+	assert.Nil(t, r.Resolve("http.server.client.latency"))
+	assert.Equal(t, &HistogramConfig{Aggregates: []string{"count"}, Percentiles: []int{}}, r.Resolve("queue.depth.max"))
+	assert.Equal(t, &HistogramConfig{Aggregates: []string{"sum"}, Percentiles: []int{95}}, r.Resolve("db.read"))
+	assert.Nil(t, r.Resolve("db.reads"))
+	assert.Nil(t, r.Resolve("invalid"))
+}
+
+func TestHistogramConfigResolverFromConfig(t *testing.T) {
+	cfg := pkgconfigmodel.NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	cfg.SetWithoutSource("histogram_aggregates", []string{"max"})
+	cfg.SetWithoutSource("histogram_percentiles", []string{"0.95"})
+	assert.True(t, NewHistogramConfigResolver(cfg).Empty())
+
+	cfg.SetWithoutSource("histogram_overrides", []map[string]interface{}{
+		{"match": "latency.*", "percentiles": []string{"0.99"}},
+	})
+	r := NewHistogramConfigResolver(cfg)
+	assert.Equal(t, &HistogramConfig{Aggregates: []string{"max"}, Percentiles: []int{99}}, r.Resolve("latency.p"))
+}
+
+func TestHistogramApplyConfig(t *testing.T) {
+	cfg := setupConfig()
+	h := NewHistogram(10, cfg)
+	h.applyConfig(nil)
+	assert.Equal(t, []string{"max", "median", "avg", "count"}, h.aggregates)
+
+	h.applyConfig(&HistogramConfig{Aggregates: []string{"count"}, Percentiles: []int{99}})
+	h.addSample(&MetricSample{Value: 1}, 50)
+	h.addSample(&MetricSample{Value: 2}, 55)
+
+	series, err := h.flush(60)
+	require.NoError(t, err)
+	require.Len(t, series, 2)
+	assert.Equal(t, ".count", series[0].NameSuffix)
+	assert.Equal(t, ".99percentile", series[1].NameSuffix)
+	assert.Equal(t, 2.0, series[1].Points[0].Value)
+}
@@ -26,6 +26,11 @@ func TestHistogramConfError(t *testing.T) {
 	assert.Equal(t, []int{95, 22}, h.percentiles())
 }
 
+func TestHistogramConfNotWholePercent(t *testing.T) {
+	h := histogramPercentilesConfig{Percentiles: []string{"0.999", "0.99", "0.295", "0.29"}}
+	assert.Equal(t, []int{99, 29}, h.percentiles())
+}
+
 func TestConfigureDefault(t *testing.T) {
 	cfg := setupConfig()
 	hist := NewHistogram(10, cfg)
@@ -0,0 +1,129 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package topology
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteJSON writes the graph as indented JSON
+func WriteJSON(w io.Writer, graph Graph) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(graph)
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+var graphMLKeys = []graphMLKey{
+	{ID: "namespace", For: "node", AttrName: "namespace", AttrType: "string"},
+	{ID: "name", For: "node", AttrName: "name", AttrType: "string"},
+	{ID: "description", For: "node", AttrName: "description", AttrType: "string"},
+	{ID: "ip_address", For: "node", AttrName: "ip_address", AttrType: "string"},
+	{ID: "monitored", For: "node", AttrName: "monitored", AttrType: "boolean"},
+	{ID: "source_interface", For: "edge", AttrName: "source_interface", AttrType: "string"},
+	{ID: "target_interface", For: "edge", AttrName: "target_interface", AttrType: "string"},
+	{ID: "source_types", For: "edge", AttrName: "source_types", AttrType: "string"},
+	{ID: "bidirectional", For: "edge", AttrName: "bidirectional", AttrType: "boolean"},
+}
+
+// WriteGraphML writes the graph as GraphML. Devices are the nodes of the graph and links its edges, the interfaces
+// of a link are attributes of its edge.
+func WriteGraphML(w io.Writer, graph Graph) error {
+	interfaceNames := make(map[string]string)
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphMLKeys,
+		Graph: graphMLGraph{ID: "topology", EdgeDefault: "undirected"},
+	}
+	for _, device := range graph.Devices {
+		node := graphMLNode{ID: device.ID}
+		node.Data = appendGraphMLData(node.Data, "namespace", device.Namespace)
+		node.Data = appendGraphMLData(node.Data, "name", device.Name)
+		node.Data = appendGraphMLData(node.Data, "description", device.Description)
+		node.Data = appendGraphMLData(node.Data, "ip_address", device.IPAddress)
+		node.Data = appendGraphMLData(node.Data, "monitored", strconv.FormatBool(device.Monitored))
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+
+		for _, itf := range device.Interfaces {
+			if itf.Name != "" {
+				interfaceNames[itf.ID] = itf.Name
+			}
+		}
+	}
+	interfaceName := func(interfaceID string) string {
+		if name, ok := interfaceNames[interfaceID]; ok {
+			return name
+		}
+		return interfaceID
+	}
+	for _, link := range graph.Links {
+		edge := graphMLEdge{ID: link.ID, Source: link.Source.Device, Target: link.Target.Device}
+		edge.Data = appendGraphMLData(edge.Data, "source_interface", interfaceName(link.Source.Interface))
+		edge.Data = appendGraphMLData(edge.Data, "target_interface", interfaceName(link.Target.Interface))
+		edge.Data = appendGraphMLData(edge.Data, "source_types", strings.Join(link.SourceTypes, ","))
+		edge.Data = appendGraphMLData(edge.Data, "bidirectional", strconv.FormatBool(link.Bidirectional))
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func appendGraphMLData(data []graphMLData, key string, value string) []graphMLData {
+	if value == "" {
+		return data
+	}
+	return append(data, graphMLData{Key: key, Value: value})
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package topology
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSON(t *testing.T) {
+	graph := Build(testSnapshots())
+
+	var out bytes.Buffer
+	require.NoError(t, WriteJSON(&out, graph))
+	var decoded Graph
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, graph, decoded)
+}
+
+func TestWriteGraphML(t *testing.T) {
+	graph := Graph{
+		Devices: []Device{
+			{ID: "default:10.0.0.1", Namespace: "default", Name: "switch-a", IPAddress: "10.0.0.1", Monitored: true, Interfaces: []Interface{{ID: "default:10.0.0.1:1", Index: 1, Name: "Gi0/1"}}},
+			{ID: "default:10.0.0.50", Namespace: "default", Name: "phone & co"},
+		},
+		Links: []Link{
+			{ID: "default:10.0.0.1:1.1", Namespace: "default", Source: Endpoint{Device: "default:10.0.0.1", Interface: "default:10.0.0.1:1"}, Target: Endpoint{Device: "default:10.0.0.50", Interface: "default:10.0.0.50:Port 1"}, SourceTypes: []string{"cdp", "lldp"}},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteGraphML(&out, graph))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="namespace" for="node" attr.name="namespace" attr.type="string"></key>
+  <key id="name" for="node" attr.name="name" attr.type="string"></key>
+  <key id="description" for="node" attr.name="description" attr.type="string"></key>
+  <key id="ip_address" for="node" attr.name="ip_address" attr.type="string"></key>
+  <key id="monitored" for="node" attr.name="monitored" attr.type="boolean"></key>
+  <key id="source_interface" for="edge" attr.name="source_interface" attr.type="string"></key>
+  <key id="target_interface" for="edge" attr.name="target_interface" attr.type="string"></key>
+  <key id="source_types" for="edge" attr.name="source_types" attr.type="string"></key>
+  <key id="bidirectional" for="edge" attr.name="bidirectional" attr.type="boolean"></key>
+  <graph id="topology" edgedefault="undirected">
+    <node id="default:10.0.0.1">
+      <data key="namespace">default</data>
+      <data key="name">switch-a</data>
+      <data key="ip_address">10.0.0.1</data>
+      <data key="monitored">true</data>
+    </node>
+    <node id="default:10.0.0.50">
+      <data key="namespace">default</data>
+      <data key="name">phone &amp; co</data>
+      <data key="monitored">false</data>
+    </node>
+    <edge id="default:10.0.0.1:1.1" source="default:10.0.0.1" target="default:10.0.0.50">
+      <data key="source_interface">Gi0/1</data>
+      <data key="target_interface">default:10.0.0.50:Port 1</data>
+      <data key="source_types">cdp,lldp</data>
+      <data key="bidirectional">false</data>
+    </edge>
+  </graph>
+</graphml>
+`, out.String())
+
+	// the output is valid XML
+	var decoded graphMLDocument
+	require.NoError(t, xml.Unmarshal(out.Bytes(), &decoded))
+	assert.Len(t, decoded.Graph.Nodes, 2)
+	assert.Len(t, decoded.Graph.Edges, 1)
+}
@@ -0,0 +1,456 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package topology
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	devicemetadata "github.com/DataDog/datadog-agent/pkg/networkdevice/metadata"
+)
+
+// Graph is a device/interface topology graph
+type Graph struct {
+	Devices []Device `json:"devices"`
+	Links   []Link   `json:"links"`
+}
+
+// Device is a node of the graph
+type Device struct {
+	ID          string `json:"id"`
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	IPAddress   string `json:"ip_address,omitempty"`
+	// Monitored is false for the neighbors only known from the links reported by the monitored devices
+	Monitored  bool        `json:"monitored"`
+	Interfaces []Interface `json:"interfaces,omitempty"`
+}
+
+// Interface is an interface of a device of the graph
+type Interface struct {
+	ID          string `json:"id"`
+	Index       int32  `json:"index,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Alias       string `json:"alias,omitempty"`
+	Description string `json:"description,omitempty"`
+	MacAddress  string `json:"mac_address,omitempty"`
+}
+
+// Endpoint is an end of a link
+type Endpoint struct {
+	Device    string `json:"device"`
+	Interface string `json:"interface,omitempty"`
+}
+
+// Link is an edge of the graph, connecting the interfaces of two devices
+type Link struct {
+	ID        string   `json:"id"`
+	Namespace string   `json:"namespace"`
+	Source    Endpoint `json:"source"`
+	Target    Endpoint `json:"target"`
+	// SourceTypes are the protocols the link was discovered with (lldp, cdp)
+	SourceTypes []string `json:"source_types"`
+	// Bidirectional is true when both devices report the link
+	Bidirectional bool `json:"bidirectional"`
+}
+
+// Build merges the snapshots of the devices into a graph. Devices are only matched with the devices of their
+// namespace: neighbors are resolved to the monitored devices by IP address, name or chassis MAC address, and
+// neighbors that aren't monitored are added as unmonitored devices. A link reported by both of its ends is only
+// added once, and marked bidirectional.
+func Build(snapshots []DeviceSnapshot) Graph {
+	byNamespace := make(map[string][]DeviceSnapshot)
+	var namespaces []string
+	for _, snapshot := range snapshots {
+		if _, ok := byNamespace[snapshot.Namespace]; !ok {
+			namespaces = append(namespaces, snapshot.Namespace)
+		}
+		byNamespace[snapshot.Namespace] = append(byNamespace[snapshot.Namespace], snapshot)
+	}
+	sort.Strings(namespaces)
+
+	graph := Graph{Devices: []Device{}, Links: []Link{}}
+	for _, namespace := range namespaces {
+		builder := newNamespaceBuilder(namespace)
+		builder.build(byNamespace[namespace])
+		devices, links := builder.result()
+		graph.Devices = append(graph.Devices, devices...)
+		graph.Links = append(graph.Links, links...)
+	}
+	return graph
+}
+
+// namespaceBuilder builds the graph of the devices of a namespace
+type namespaceBuilder struct {
+	namespace string
+	devices   map[string]*Device
+	// aliases maps the identifiers neighbors are known by (`ip:`, `name:`, `mac:` prefixed) to device ids
+	aliases map[string]string
+	// interfacesByIDType maps the interface identifiers of the monitored devices to interface ids, by device and id type
+	interfacesByIDType map[string]map[string]map[string][]string
+	// interfaces are the interfaces of the devices, by interface id
+	interfaces map[string]Interface
+	// knownInterfaces maps the interfaces reported by the monitored devices themselves to their device
+	knownInterfaces map[string]string
+	links           []*Link
+	linksByKey      map[string]*Link
+}
+
+func newNamespaceBuilder(namespace string) *namespaceBuilder {
+	return &namespaceBuilder{
+		namespace:          namespace,
+		devices:            make(map[string]*Device),
+		aliases:            make(map[string]string),
+		interfacesByIDType: make(map[string]map[string]map[string][]string),
+		interfaces:         make(map[string]Interface),
+		knownInterfaces:    make(map[string]string),
+		linksByKey:         make(map[string]*Link),
+	}
+}
+
+func (b *namespaceBuilder) build(snapshots []DeviceSnapshot) {
+	// links reported by both of their ends keep the id of the device sorted first
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Device.ID < snapshots[j].Device.ID
+	})
+	// all monitored devices are added first so that neighbors are resolved to them whatever the order of the snapshots
+	for _, snapshot := range snapshots {
+		b.addMonitoredDevice(snapshot)
+	}
+	for _, snapshot := range snapshots {
+		for _, link := range snapshot.Links {
+			b.addLink(snapshot.Device.ID, link)
+		}
+	}
+	b.matchReverseLinks()
+}
+
+func (b *namespaceBuilder) addMonitoredDevice(snapshot DeviceSnapshot) {
+	deviceID := snapshot.Device.ID
+	b.devices[deviceID] = &Device{
+		ID:          deviceID,
+		Namespace:   b.namespace,
+		Name:        snapshot.Device.Name,
+		Description: snapshot.Device.Description,
+		IPAddress:   snapshot.Device.IPAddress,
+		Monitored:   true,
+	}
+	b.addAlias("ip:"+snapshot.Device.IPAddress, deviceID)
+	b.addAlias("name:"+ShortName(snapshot.Device.Name), deviceID)
+
+	byIDType := map[string]map[string][]string{
+		devicemetadata.IDTypeMacAddress:     {},
+		devicemetadata.IDTypeInterfaceName:  {},
+		devicemetadata.IDTypeInterfaceAlias: {},
+		"interface_index":                   {},
+	}
+	for _, itf := range snapshot.Interfaces {
+		interfaceID := deviceID + ":" + strconv.Itoa(int(itf.Index))
+		b.interfaces[interfaceID] = Interface{
+			ID:          interfaceID,
+			Index:       itf.Index,
+			Name:        itf.Name,
+			Alias:       itf.Alias,
+			Description: itf.Description,
+			MacAddress:  itf.MacAddress,
+		}
+		b.knownInterfaces[interfaceID] = deviceID
+
+		mac := strings.ToLower(itf.MacAddress)
+		b.addAlias("mac:"+mac, deviceID)
+		for idType, value := range map[string]string{
+			devicemetadata.IDTypeMacAddress:     mac,
+			devicemetadata.IDTypeInterfaceName:  itf.Name,
+			devicemetadata.IDTypeInterfaceAlias: itf.Alias,
+			"interface_index":                   strconv.Itoa(int(itf.Index)),
+		} {
+			if value != "" {
+				byIDType[idType][value] = append(byIDType[idType][value], interfaceID)
+			}
+		}
+	}
+	b.interfacesByIDType[deviceID] = byIDType
+}
+
+// addAlias maps an identifier to a device, unless it's empty or already mapped
+func (b *namespaceBuilder) addAlias(alias string, deviceID string) {
+	if strings.HasSuffix(alias, ":") {
+		return
+	}
+	if _, ok := b.aliases[alias]; !ok {
+		b.aliases[alias] = deviceID
+	}
+}
+
+func (b *namespaceBuilder) addLink(deviceID string, link devicemetadata.TopologyLinkMetadata) {
+	if link.Local == nil || link.Remote == nil || link.Remote.Device == nil {
+		return
+	}
+
+	source := Endpoint{Device: deviceID}
+	if link.Local.Interface != nil {
+		source.Interface = link.Local.Interface.DDID
+		if source.Interface == "" && link.Local.Interface.ID != "" {
+			source.Interface = deviceID + ":" + link.Local.Interface.ID
+			b.addInterface(source.Interface, link.Local.Interface.ID, "")
+		}
+	}
+
+	remoteDeviceID := b.resolveRemoteDevice(link.Remote.Device)
+	target := Endpoint{Device: remoteDeviceID}
+	if link.Remote.Interface != nil {
+		target.Interface = b.resolveRemoteInterface(remoteDeviceID, link.Remote.Interface)
+	}
+
+	key := linkKey(source, target)
+	if existing, ok := b.linksByKey[key]; ok {
+		existing.SourceTypes = appendSourceType(existing.SourceTypes, link.SourceType)
+		if existing.Source.Device != deviceID {
+			existing.Bidirectional = true
+		}
+		return
+	}
+	newLink := &Link{
+		ID:          link.ID,
+		Namespace:   b.namespace,
+		Source:      source,
+		Target:      target,
+		SourceTypes: appendSourceType(nil, link.SourceType),
+	}
+	b.links = append(b.links, newLink)
+	b.linksByKey[key] = newLink
+}
+
+// resolveRemoteDevice returns the id of the device a neighbor is, adding it as an unmonitored device when it isn't
+// a known device
+func (b *namespaceBuilder) resolveRemoteDevice(remote *devicemetadata.TopologyLinkDevice) string {
+	aliases := []string{"ip:" + remote.IPAddress, "name:" + ShortName(remote.Name)}
+	switch remote.IDType {
+	case devicemetadata.IDTypeMacAddress:
+		aliases = append(aliases, "mac:"+strings.ToLower(remote.ID))
+	case "network_address":
+		aliases = append(aliases, "ip:"+remote.ID)
+	default:
+		// CDP device ids and LLDP locally assigned chassis ids are usually the hostname of the device
+		aliases = append(aliases, "name:"+ShortName(remote.ID))
+	}
+	for _, alias := range aliases {
+		if deviceID, ok := b.aliases[alias]; ok {
+			b.completeUnmonitoredDevice(b.devices[deviceID], remote)
+			return deviceID
+		}
+	}
+
+	// unmonitored devices are identified like monitored ones would be when possible: `<namespace>:<ip address>`
+	identifier := remote.IPAddress
+	if identifier == "" {
+		identifier = remote.ID
+	}
+	if identifier == "" {
+		identifier = remote.Name
+	}
+	deviceID := b.namespace + ":" + identifier
+	device, ok := b.devices[deviceID]
+	if !ok {
+		device = &Device{ID: deviceID, Namespace: b.namespace}
+		b.devices[deviceID] = device
+	}
+	b.completeUnmonitoredDevice(device, remote)
+	for _, alias := range aliases {
+		b.addAlias(alias, deviceID)
+	}
+	return deviceID
+}
+
+// completeUnmonitoredDevice fills the missing fields of an unmonitored device with the data reported by a neighbor
+func (b *namespaceBuilder) completeUnmonitoredDevice(device *Device, remote *devicemetadata.TopologyLinkDevice) {
+	if device.Monitored {
+		return
+	}
+	if device.Name == "" {
+		device.Name = remote.Name
+	}
+	if device.Description == "" {
+		device.Description = remote.Description
+	}
+	if device.IPAddress == "" {
+		device.IPAddress = remote.IPAddress
+	}
+}
+
+// resolveRemoteInterface returns the id of the interface of a neighbor. Interfaces of the monitored devices are
+// resolved to their interface id when a single interface matches.
+func (b *namespaceBuilder) resolveRemoteInterface(deviceID string, remote *devicemetadata.TopologyLinkInterface) string {
+	if remote.ID == "" {
+		return ""
+	}
+	if byIDType, ok := b.interfacesByIDType[deviceID]; ok {
+		var typesToTry []string
+		switch remote.IDType {
+		case devicemetadata.IDTypeMacAddress, devicemetadata.IDTypeInterfaceName, devicemetadata.IDTypeInterfaceAlias:
+			typesToTry = []string{remote.IDType}
+		default:
+			typesToTry = []string{devicemetadata.IDTypeInterfaceName, devicemetadata.IDTypeInterfaceAlias, devicemetadata.IDTypeMacAddress, "interface_index"}
+		}
+		matches := make(map[string]struct{})
+		for _, idType := range typesToTry {
+			value := remote.ID
+			if idType == devicemetadata.IDTypeMacAddress {
+				value = strings.ToLower(value)
+			}
+			for _, interfaceID := range byIDType[idType][value] {
+				matches[interfaceID] = struct{}{}
+			}
+		}
+		if len(matches) == 1 {
+			for interfaceID := range matches {
+				return interfaceID
+			}
+		}
+	}
+
+	interfaceID := deviceID + ":" + remote.ID
+	b.addInterface(interfaceID, remote.ID, remote.Description)
+	return interfaceID
+}
+
+// addInterface adds an interface that isn't reported by its device
+func (b *namespaceBuilder) addInterface(interfaceID string, name string, description string) {
+	if _, ok := b.interfaces[interfaceID]; !ok {
+		b.interfaces[interfaceID] = Interface{ID: interfaceID, Name: name, Description: description}
+	}
+}
+
+// matchReverseLinks merges the links reported by both of their ends that couldn't be matched exactly, because one of
+// the devices doesn't advertise an interface identifier the other device can resolve.
+func (b *namespaceBuilder) matchReverseLinks() {
+	merged := make(map[*Link]bool)
+	for _, link := range b.links {
+		if merged[link] || link.Bidirectional {
+			continue
+		}
+		for _, reverse := range b.links {
+			if reverse == link || merged[reverse] || reverse.Bidirectional ||
+				reverse.Source.Device != link.Target.Device || reverse.Target.Device != link.Source.Device {
+				continue
+			}
+			if reverse.Target == link.Source && b.knownInterfaces[link.Target.Interface] == "" {
+				// the reverse link resolved the interface of this device, but this one couldn't resolve the interface of the neighbor
+				link.Target = reverse.Source
+			} else if reverse.Source != link.Target || b.knownInterfaces[reverse.Target.Interface] != "" {
+				continue
+			}
+			link.Bidirectional = true
+			for _, sourceType := range reverse.SourceTypes {
+				link.SourceTypes = appendSourceType(link.SourceTypes, sourceType)
+			}
+			merged[reverse] = true
+			break
+		}
+	}
+
+	links := b.links[:0]
+	for _, link := range b.links {
+		if !merged[link] {
+			links = append(links, link)
+		}
+	}
+	b.links = links
+}
+
+func (b *namespaceBuilder) result() ([]Device, []Link) {
+	// the interfaces of a device are the ones it reports and the ones links use
+	interfacesByDevice := make(map[string]map[string]bool)
+	addDeviceInterface := func(deviceID string, interfaceID string) {
+		if interfaceID == "" {
+			return
+		}
+		if interfacesByDevice[deviceID] == nil {
+			interfacesByDevice[deviceID] = make(map[string]bool)
+		}
+		interfacesByDevice[deviceID][interfaceID] = true
+	}
+	for interfaceID, deviceID := range b.knownInterfaces {
+		addDeviceInterface(deviceID, interfaceID)
+	}
+
+	links := make([]Link, 0, len(b.links))
+	usedDevices := make(map[string]bool)
+	for _, link := range b.links {
+		addDeviceInterface(link.Source.Device, link.Source.Interface)
+		addDeviceInterface(link.Target.Device, link.Target.Interface)
+		usedDevices[link.Target.Device] = true
+		sort.Strings(link.SourceTypes)
+		links = append(links, *link)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].ID < links[j].ID
+	})
+
+	devices := make([]Device, 0, len(b.devices))
+	for deviceID, device := range b.devices {
+		// unmonitored devices whose links were all merged in links reported by a monitored device are dropped
+		if !device.Monitored && !usedDevices[deviceID] {
+			continue
+		}
+		for interfaceID := range interfacesByDevice[deviceID] {
+			itf, ok := b.interfaces[interfaceID]
+			if !ok {
+				// e.g. CDP links use the ifIndex of the local interface even when the interfaces metadata isn't collected
+				itf = Interface{ID: interfaceID}
+			}
+			device.Interfaces = append(device.Interfaces, itf)
+		}
+		sort.Slice(device.Interfaces, func(i, j int) bool {
+			x, y := device.Interfaces[i], device.Interfaces[j]
+			if x.Index != y.Index {
+				return x.Index < y.Index
+			}
+			return x.ID < y.ID
+		})
+		devices = append(devices, *device)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].ID < devices[j].ID
+	})
+	return devices, links
+}
+
+// linkKey identifies a link whatever the direction it's reported in
+func linkKey(a Endpoint, b Endpoint) string {
+	aKey, bKey := a.Device+"|"+a.Interface, b.Device+"|"+b.Interface
+	if aKey > bKey {
+		aKey, bKey = bKey, aKey
+	}
+	return aKey + "||" + bKey
+}
+
+func appendSourceType(sourceTypes []string, sourceType string) []string {
+	if sourceType == "" {
+		return sourceTypes
+	}
+	for _, existing := range sourceTypes {
+		if existing == sourceType {
+			return sourceTypes
+		}
+	}
+	return append(sourceTypes, sourceType)
+}
+
+// ShortName returns the lowercase hostname of a device name without its domain
+func ShortName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if net.ParseIP(name) != nil {
+		return name
+	}
+	if idx := strings.IndexByte(name, '.'); idx > 0 {
+		name = name[:idx]
+	}
+	return name
+}
@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	devicemetadata "github.com/DataDog/datadog-agent/pkg/networkdevice/metadata"
+)
+
+func newLink(id string, sourceType string, localDeviceID string, localInterfaceID string, remoteDevice devicemetadata.TopologyLinkDevice, remoteInterface devicemetadata.TopologyLinkInterface) devicemetadata.TopologyLinkMetadata {
+	return devicemetadata.TopologyLinkMetadata{
+		ID:         id,
+		SourceType: sourceType,
+		Local: &devicemetadata.TopologyLinkSide{
+			Device:    &devicemetadata.TopologyLinkDevice{DDID: localDeviceID},
+			Interface: &devicemetadata.TopologyLinkInterface{DDID: localInterfaceID},
+		},
+		Remote: &devicemetadata.TopologyLinkSide{
+			Device:    &remoteDevice,
+			Interface: &remoteInterface,
+		},
+	}
+}
+
+func testSnapshots() []DeviceSnapshot {
+	switchA := DeviceSnapshot{
+		Namespace: "default",
+		Device:    devicemetadata.DeviceMetadata{ID: "default:10.0.0.1", Name: "switch-a", IPAddress: "10.0.0.1"},
+		Interfaces: []devicemetadata.InterfaceMetadata{
+			{Index: 1, Name: "Gi0/1", MacAddress: "00:00:00:00:0A:01"},
+			{Index: 2, Name: "Gi0/2", MacAddress: "00:00:00:00:0a:02"},
+			{Index: 3, Name: "Gi0/3", MacAddress: "00:00:00:00:0a:03"},
+		},
+		Links: []devicemetadata.TopologyLinkMetadata{
+			newLink("default:10.0.0.1:1.1", "lldp", "default:10.0.0.1", "default:10.0.0.1:1",
+				devicemetadata.TopologyLinkDevice{ID: "00:00:00:00:0b:00", IDType: devicemetadata.IDTypeMacAddress, Name: "switch-b"},
+				devicemetadata.TopologyLinkInterface{ID: "Eth1", IDType: devicemetadata.IDTypeInterfaceName}),
+			newLink("default:10.0.0.1:2.1", "cdp", "default:10.0.0.1", "default:10.0.0.1:2",
+				devicemetadata.TopologyLinkDevice{ID: "phone-1", Name: "phone-1", IPAddress: "10.0.0.50", Description: "IP Phone"},
+				devicemetadata.TopologyLinkInterface{ID: "Port 1", IDType: devicemetadata.IDTypeInterfaceName}),
+			newLink("default:10.0.0.1:3.2", "lldp", "default:10.0.0.1", "default:10.0.0.1:3",
+				devicemetadata.TopologyLinkDevice{ID: "00:00:00:00:0b:00", IDType: devicemetadata.IDTypeMacAddress, IPAddress: "10.0.0.2"},
+				devicemetadata.TopologyLinkInterface{ID: "00:00:00:00:0b:02", IDType: devicemetadata.IDTypeMacAddress}),
+		},
+	}
+	switchB := DeviceSnapshot{
+		Namespace: "default",
+		Device:    devicemetadata.DeviceMetadata{ID: "default:10.0.0.2", Name: "switch-b.example.com", IPAddress: "10.0.0.2"},
+		Interfaces: []devicemetadata.InterfaceMetadata{
+			{Index: 1, Name: "Eth1", MacAddress: "00:00:00:00:0b:01"},
+			{Index: 2, Name: "Eth2", MacAddress: "00:00:00:00:0b:02"},
+		},
+		Links: []devicemetadata.TopologyLinkMetadata{
+			// matches the first link of switch-a exactly
+			newLink("default:10.0.0.2:1.1", "lldp", "default:10.0.0.2", "default:10.0.0.2:1",
+				devicemetadata.TopologyLinkDevice{ID: "00:00:00:00:0a:01", IDType: devicemetadata.IDTypeMacAddress},
+				devicemetadata.TopologyLinkInterface{ID: "00:00:00:00:0a:01", IDType: devicemetadata.IDTypeMacAddress}),
+			// the interface of switch-a can't be resolved, but the link is the reverse of the third link of switch-a
+			newLink("default:10.0.0.2:2.1", "cdp", "default:10.0.0.2", "default:10.0.0.2:2",
+				devicemetadata.TopologyLinkDevice{ID: "SWITCH-A.example.com"},
+				devicemetadata.TopologyLinkInterface{ID: "GigabitEthernet0/3", IDType: devicemetadata.IDTypeInterfaceName}),
+		},
+	}
+	// same names in another namespace aren't matched with the devices of the default namespace
+	otherNamespace := DeviceSnapshot{
+		Namespace: "dc2",
+		Device:    devicemetadata.DeviceMetadata{ID: "dc2:10.0.0.1", Name: "router", IPAddress: "10.0.0.1"},
+		Links: []devicemetadata.TopologyLinkMetadata{
+			newLink("dc2:10.0.0.1:5.1", "lldp", "dc2:10.0.0.1", "dc2:10.0.0.1:5",
+				devicemetadata.TopologyLinkDevice{ID: "00:00:00:00:0a:01", IDType: devicemetadata.IDTypeMacAddress, Name: "switch-a"},
+				devicemetadata.TopologyLinkInterface{ID: "Gi0/1", IDType: devicemetadata.IDTypeInterfaceName}),
+		},
+	}
+	return []DeviceSnapshot{switchB, otherNamespace, switchA}
+}
+
+func TestBuild(t *testing.T) {
+	graph := Build(testSnapshots())
+
+	assert.Equal(t, []Device{
+		{
+			ID:        "dc2:00:00:00:00:0a:01",
+			Namespace: "dc2",
+			Name:      "switch-a",
+			Interfaces: []Interface{
+				{ID: "dc2:00:00:00:00:0a:01:Gi0/1", Name: "Gi0/1"},
+			},
+		},
+		{
+			ID:         "dc2:10.0.0.1",
+			Namespace:  "dc2",
+			Name:       "router",
+			IPAddress:  "10.0.0.1",
+			Monitored:  true,
+			Interfaces: []Interface{{ID: "dc2:10.0.0.1:5"}},
+		},
+		{
+			ID:        "default:10.0.0.1",
+			Namespace: "default",
+			Name:      "switch-a",
+			IPAddress: "10.0.0.1",
+			Monitored: true,
+			Interfaces: []Interface{
+				{ID: "default:10.0.0.1:1", Index: 1, Name: "Gi0/1", MacAddress: "00:00:00:00:0A:01"},
+				{ID: "default:10.0.0.1:2", Index: 2, Name: "Gi0/2", MacAddress: "00:00:00:00:0a:02"},
+				{ID: "default:10.0.0.1:3", Index: 3, Name: "Gi0/3", MacAddress: "00:00:00:00:0a:03"},
+			},
+		},
+		{
+			ID:        "default:10.0.0.2",
+			Namespace: "default",
+			Name:      "switch-b.example.com",
+			IPAddress: "10.0.0.2",
+			Monitored: true,
+			Interfaces: []Interface{
+				{ID: "default:10.0.0.2:1", Index: 1, Name: "Eth1", MacAddress: "00:00:00:00:0b:01"},
+				{ID: "default:10.0.0.2:2", Index: 2, Name: "Eth2", MacAddress: "00:00:00:00:0b:02"},
+			},
+		},
+		{
+			ID:          "default:10.0.0.50",
+			Namespace:   "default",
+			Name:        "phone-1",
+			Description: "IP Phone",
+			IPAddress:   "10.0.0.50",
+			Interfaces: []Interface{
+				{ID: "default:10.0.0.50:Port 1", Name: "Port 1"},
+			},
+		},
+	}, graph.Devices)
+
+	assert.Equal(t, []Link{
+		{
+			ID:          "dc2:10.0.0.1:5.1",
+			Namespace:   "dc2",
+			Source:      Endpoint{Device: "dc2:10.0.0.1", Interface: "dc2:10.0.0.1:5"},
+			Target:      Endpoint{Device: "dc2:00:00:00:00:0a:01", Interface: "dc2:00:00:00:00:0a:01:Gi0/1"},
+			SourceTypes: []string{"lldp"},
+		},
+		{
+			ID:            "default:10.0.0.1:1.1",
+			Namespace:     "default",
+			Source:        Endpoint{Device: "default:10.0.0.1", Interface: "default:10.0.0.1:1"},
+			Target:        Endpoint{Device: "default:10.0.0.2", Interface: "default:10.0.0.2:1"},
+			SourceTypes:   []string{"lldp"},
+			Bidirectional: true,
+		},
+		{
+			ID:          "default:10.0.0.1:2.1",
+			Namespace:   "default",
+			Source:      Endpoint{Device: "default:10.0.0.1", Interface: "default:10.0.0.1:2"},
+			Target:      Endpoint{Device: "default:10.0.0.50", Interface: "default:10.0.0.50:Port 1"},
+			SourceTypes: []string{"cdp"},
+		},
+		{
+			ID:            "default:10.0.0.1:3.2",
+			Namespace:     "default",
+			Source:        Endpoint{Device: "default:10.0.0.1", Interface: "default:10.0.0.1:3"},
+			Target:        Endpoint{Device: "default:10.0.0.2", Interface: "default:10.0.0.2:2"},
+			SourceTypes:   []string{"cdp", "lldp"},
+			Bidirectional: true,
+		},
+	}, graph.Links)
+}
+
+func TestBuildEmpty(t *testing.T) {
+	assert.Equal(t, Graph{Devices: []Device{}, Links: []Link{}}, Build(nil))
+}
+
+func TestShortName(t *testing.T) {
+	assert.Equal(t, "switch-a", ShortName(" Switch-A.example.com "))
+	assert.Equal(t, "10.0.0.1", ShortName("10.0.0.1"))
+	assert.Equal(t, "", ShortName(""))
+}
@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package topology builds a network topology graph from the LLDP/CDP links reported by network devices.
+//
+// Each monitored device only knows its own neighbors. The SNMP check saves a DeviceSnapshot of every device to a
+// Store after collecting its metadata, and Build merges the snapshots of the devices of a namespace into a single
+// device/interface graph, matching the links both ends report.
+package topology
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	devicemetadata "github.com/DataDog/datadog-agent/pkg/networkdevice/metadata"
+)
+
+const snapshotExtension = ".json"
+
+// DeviceSnapshot is the topology data collected for a device
+type DeviceSnapshot struct {
+	Namespace        string                                `json:"namespace"`
+	Device           devicemetadata.DeviceMetadata         `json:"device"`
+	Interfaces       []devicemetadata.InterfaceMetadata    `json:"interfaces,omitempty"`
+	Links            []devicemetadata.TopologyLinkMetadata `json:"links,omitempty"`
+	CollectTimestamp int64                                 `json:"collect_timestamp"`
+}
+
+// Store saves the device snapshots to a folder, one file per device
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns the folder the SNMP check saves the device snapshots to
+func DefaultDir(runPath string) string {
+	return filepath.Join(runPath, "snmp", "topology")
+}
+
+// NewStore returns a Store saving the device snapshots to dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the folder of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save saves the snapshot of a device, replacing the previous one
+func (s *Store) Save(snapshot DeviceSnapshot) error {
+	if snapshot.Device.ID == "" {
+		return errors.New("the device id of the snapshot is empty")
+	}
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+
+	// the file is renamed once written so that a snapshot being saved is never read partially
+	path := filepath.Join(s.dir, snapshotFileName(snapshot.Device.ID))
+	tmpFile, err := os.CreateTemp(s.dir, ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// Load returns the snapshots of the store, sorted by namespace and device id. Snapshots collected more than maxAge
+// before now are skipped, unless maxAge is 0. A missing folder is an empty store.
+func (s *Store) Load(now time.Time, maxAge time.Duration) ([]DeviceSnapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var snapshots []DeviceSnapshot
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), snapshotExtension) || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var snapshot DeviceSnapshot
+		if err := json.Unmarshal(content, &snapshot); err != nil {
+			errs = append(errs, fmt.Errorf("invalid snapshot %s: %w", entry.Name(), err))
+			continue
+		}
+		if maxAge > 0 && now.Sub(time.Unix(snapshot.CollectTimestamp, 0)) > maxAge {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Namespace != snapshots[j].Namespace {
+			return snapshots[i].Namespace < snapshots[j].Namespace
+		}
+		return snapshots[i].Device.ID < snapshots[j].Device.ID
+	})
+	return snapshots, errors.Join(errs...)
+}
+
+// snapshotFileName returns the file name of the snapshot of a device. Device ids contain characters that aren't
+// valid in file names (`:` or `/` for IPv6 addresses), so they are hashed.
+func snapshotFileName(deviceID string) string {
+	hash := sha256.Sum256([]byte(deviceID))
+	return hex.EncodeToString(hash[:16]) + snapshotExtension
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package topology
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	devicemetadata "github.com/DataDog/datadog-agent/pkg/networkdevice/metadata"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := NewStore(filepath.Join(t.TempDir(), "snmp", "topology"))
+
+	snapshots, err := store.Load(now, 0)
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	recent := DeviceSnapshot{
+		Namespace:        "default",
+		Device:           devicemetadata.DeviceMetadata{ID: "default:fe80::1", IPAddress: "fe80::1"},
+		CollectTimestamp: now.Add(-time.Minute).Unix(),
+	}
+	old := DeviceSnapshot{
+		Namespace:        "default",
+		Device:           devicemetadata.DeviceMetadata{ID: "default:10.0.0.1", IPAddress: "10.0.0.1"},
+		CollectTimestamp: now.Add(-2 * time.Hour).Unix(),
+	}
+	require.NoError(t, store.Save(recent))
+	require.NoError(t, store.Save(old))
+	assert.Error(t, store.Save(DeviceSnapshot{}))
+
+	snapshots, err = store.Load(now, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []DeviceSnapshot{old, recent}, snapshots)
+
+	snapshots, err = store.Load(now, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []DeviceSnapshot{recent}, snapshots)
+
+	// saving a device again replaces its snapshot
+	old.CollectTimestamp = now.Unix()
+	require.NoError(t, store.Save(old))
+	snapshots, err = store.Load(now, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []DeviceSnapshot{old, recent}, snapshots)
+
+	// invalid snapshots are reported, the valid ones are still loaded
+	require.NoError(t, os.WriteFile(filepath.Join(store.Dir(), "invalid.json"), []byte("{"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(store.Dir(), "notes.txt"), []byte("{"), 0600))
+	snapshots, err = store.Load(now, time.Hour)
+	assert.ErrorContains(t, err, "invalid.json")
+	assert.Len(t, snapshots, 2)
+}
+
+func TestDefaultDir(t *testing.T) {
+	assert.Equal(t, filepath.Join("/opt/datadog-agent/run", "snmp", "topology"), DefaultDir("/opt/datadog-agent/run"))
+}
@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package payload
+
+import (
+	"sort"
+	"strings"
+)
+
+// unknownHop stands for the hops that didn't answer in the path descriptions of a diff
+const unknownHop = "*"
+
+// NetworkPathDiff encapsulates the changes of a path
+// since the previous run of the same test
+type NetworkPathDiff struct {
+	PreviousPathID    string `json:"previous_path_id"`
+	PreviousTimestamp int64  `json:"previous_timestamp"`
+	Changed           bool   `json:"changed"`
+	// AddedPaths and RemovedPaths are the IPs of the hops
+	// of the paths that appeared and disappeared, the hops
+	// that didn't answer are "*"
+	AddedPaths   []string             `json:"added_paths,omitempty"`
+	RemovedPaths []string             `json:"removed_paths,omitempty"`
+	Hops         []NetworkPathHopDiff `json:"hops,omitempty"`
+}
+
+// NetworkPathHopDiff encapsulates the IPs that
+// appeared and disappeared at a TTL
+type NetworkPathHopDiff struct {
+	TTL     int      `json:"ttl"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Diff returns the changes of the current path since
+// the previous one. The hops that didn't answer match
+// any hop, so that losses are not reported as changes.
+func Diff(previous NetworkPath, current NetworkPath) NetworkPathDiff {
+	diff := NetworkPathDiff{
+		PreviousPathID:    previous.PathID,
+		PreviousTimestamp: previous.Timestamp,
+	}
+
+	previousRoutes := routes(previous)
+	currentRoutes := routes(current)
+	added := missingRoutes(currentRoutes, previousRoutes)
+	removed := missingRoutes(previousRoutes, currentRoutes)
+
+	// the hop changes come from the paths that changed, so that the losses of a path that is still taken are
+	// not reported
+	hopDiffs := make(map[int]*NetworkPathHopDiff)
+	hopDiff := func(ttl int) *NetworkPathHopDiff {
+		if hopDiffs[ttl] == nil {
+			hopDiffs[ttl] = &NetworkPathHopDiff{TTL: ttl}
+		}
+		return hopDiffs[ttl]
+	}
+	previousIPs := ipsByTTL(previousRoutes)
+	currentIPs := ipsByTTL(currentRoutes)
+	for _, route := range added {
+		diff.AddedPaths = append(diff.AddedPaths, strings.Join(route, " > "))
+		for ttl, ip := range knownHops(route) {
+			if _, ok := previousIPs[ttl][ip]; !ok {
+				hopDiff(ttl).Added = appendUnique(hopDiff(ttl).Added, ip)
+			}
+		}
+	}
+	for _, route := range removed {
+		diff.RemovedPaths = append(diff.RemovedPaths, strings.Join(route, " > "))
+		for ttl, ip := range knownHops(route) {
+			if _, ok := currentIPs[ttl][ip]; !ok {
+				hopDiff(ttl).Removed = appendUnique(hopDiff(ttl).Removed, ip)
+			}
+		}
+	}
+	for _, d := range hopDiffs {
+		sort.Strings(d.Added)
+		sort.Strings(d.Removed)
+		diff.Hops = append(diff.Hops, *d)
+	}
+	sort.Slice(diff.Hops, func(i, j int) bool {
+		return diff.Hops[i].TTL < diff.Hops[j].TTL
+	})
+
+	diff.Changed = len(diff.AddedPaths) > 0 || len(diff.RemovedPaths) > 0 || len(diff.Hops) > 0
+	return diff
+}
+
+// routes returns the IPs of the hops of each path, the hops that didn't answer are unknownHop
+func routes(path NetworkPath) [][]string {
+	hopsList := [][]NetworkPathHop{path.Hops}
+	if len(path.Paths) > 0 {
+		hopsList = hopsList[:0]
+		for _, p := range path.Paths {
+			hopsList = append(hopsList, p.Hops)
+		}
+	}
+	routes := make([][]string, 0, len(hopsList))
+	for _, hops := range hopsList {
+		route := make([]string, 0, len(hops))
+		for _, hop := range hops {
+			if hop.Reachable {
+				route = append(route, hop.IPAddress)
+			} else {
+				route = append(route, unknownHop)
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// missingRoutes returns the routes of a that don't match any route of b
+func missingRoutes(a [][]string, b [][]string) [][]string {
+	var missing [][]string
+	for _, route := range a {
+		found := false
+		for _, other := range b {
+			if sameRoute(route, other) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, route)
+		}
+	}
+	return missing
+}
+
+// sameRoute returns whether two routes have the same length and the same IPs at the TTLs where both hops answered
+func sameRoute(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != unknownHop && b[i] != unknownHop && a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// knownHops returns the IPs of the hops of a route that answered, by TTL
+func knownHops(route []string) map[int]string {
+	hops := make(map[int]string, len(route))
+	for i, ip := range route {
+		if ip != unknownHop {
+			hops[i+1] = ip
+		}
+	}
+	return hops
+}
+
+// ipsByTTL returns the IPs of the hops of the routes that answered, by TTL
+func ipsByTTL(routes [][]string) map[int]map[string]struct{} {
+	ips := make(map[int]map[string]struct{})
+	for _, route := range routes {
+		for ttl, ip := range knownHops(route) {
+			if ips[ttl] == nil {
+				ips[ttl] = make(map[string]struct{})
+			}
+			ips[ttl][ip] = struct{}{}
+		}
+	}
+	return ips
+}
+
+func appendUnique(ips []string, ip string) []string {
+	for _, existing := range ips {
+		if existing == ip {
+			return ips
+		}
+	}
+	return append(ips, ip)
+}
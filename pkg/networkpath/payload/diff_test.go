@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package payload
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func hops(ips ...string) []NetworkPathHop {
+	hops := make([]NetworkPathHop, 0, len(ips))
+	for i, ip := range ips {
+		hop := NetworkPathHop{TTL: i + 1, IPAddress: ip, Reachable: ip != "*"}
+		if ip == "*" {
+			hop.IPAddress = fmt.Sprintf("unknown_hop_%d", i+1)
+		}
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+func TestDiff(t *testing.T) {
+	previous := NetworkPath{
+		PathID:    "previous",
+		Timestamp: 1000,
+		Hops:      hops("10.0.0.1", "10.1.0.1", "10.9.0.1"),
+		Paths: []NetworkPathECMP{
+			{Hops: hops("10.0.0.1", "10.1.0.1", "10.9.0.1")},
+			{Hops: hops("10.0.0.1", "10.2.0.1", "10.9.0.1")},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		current  NetworkPath
+		expected NetworkPathDiff
+	}{
+		{
+			name: "unchanged",
+			current: NetworkPath{Paths: []NetworkPathECMP{
+				{Hops: hops("10.0.0.1", "10.2.0.1", "10.9.0.1")},
+				{Hops: hops("10.0.0.1", "10.1.0.1", "10.9.0.1")},
+			}},
+			expected: NetworkPathDiff{PreviousPathID: "previous", PreviousTimestamp: 1000},
+		},
+		{
+			name: "losses are not changes",
+			current: NetworkPath{Paths: []NetworkPathECMP{
+				{Hops: hops("10.0.0.1", "*", "10.9.0.1")},
+				{Hops: hops("*", "10.2.0.1", "10.9.0.1")},
+			}},
+			expected: NetworkPathDiff{PreviousPathID: "previous", PreviousTimestamp: 1000},
+		},
+		{
+			name: "path removed",
+			current: NetworkPath{
+				Hops: hops("10.0.0.1", "10.1.0.1", "10.9.0.1"),
+			},
+			expected: NetworkPathDiff{
+				PreviousPathID:    "previous",
+				PreviousTimestamp: 1000,
+				Changed:           true,
+				RemovedPaths:      []string{"10.0.0.1 > 10.2.0.1 > 10.9.0.1"},
+				Hops:              []NetworkPathHopDiff{{TTL: 2, Removed: []string{"10.2.0.1"}}},
+			},
+		},
+		{
+			name: "hop replaced and path longer",
+			current: NetworkPath{Paths: []NetworkPathECMP{
+				{Hops: hops("10.0.0.1", "10.1.0.1", "10.9.0.1")},
+				{Hops: hops("10.0.0.1", "10.3.0.1", "10.3.0.2", "10.9.0.1")},
+			}},
+			expected: NetworkPathDiff{
+				PreviousPathID:    "previous",
+				PreviousTimestamp: 1000,
+				Changed:           true,
+				AddedPaths:        []string{"10.0.0.1 > 10.3.0.1 > 10.3.0.2 > 10.9.0.1"},
+				RemovedPaths:      []string{"10.0.0.1 > 10.2.0.1 > 10.9.0.1"},
+				Hops: []NetworkPathHopDiff{
+					{TTL: 2, Added: []string{"10.3.0.1"}, Removed: []string{"10.2.0.1"}},
+					{TTL: 3, Added: []string{"10.3.0.2"}},
+					{TTL: 4, Added: []string{"10.9.0.1"}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Diff(previous, tt.current))
+		})
+	}
+}
@@ -18,6 +18,19 @@ const (
 	ProtocolTCP Protocol = "TCP"
 	// ProtocolUDP is the UDP protocol.
 	ProtocolUDP Protocol = "UDP"
+	// ProtocolICMP is the ICMP protocol.
+	ProtocolICMP Protocol = "ICMP"
+)
+
+// TCPMethod defines how TCP traceroutes probe the hops
+type TCPMethod string
+
+const (
+	// TCPMethodSYN sends SYN segments
+	TCPMethodSYN TCPMethod = "syn"
+	// TCPMethodSACK sends data segments on a connection established with the destination, the destination
+	// acknowledges them with SACK blocks
+	TCPMethodSACK TCPMethod = "sack"
 )
 
 // NetworkPathHop encapsulates the data for a single
@@ -28,6 +41,52 @@ type NetworkPathHop struct {
 	Hostname  string  `json:"hostname,omitempty"`
 	RTT       float64 `json:"rtt,omitempty"`
 	Reachable bool    `json:"reachable"`
+	// Stats are set when several probes are sent to each hop
+	Stats *NetworkPathHopStats `json:"stats,omitempty"`
+}
+
+// NetworkPathHopStats encapsulates the latency
+// distribution and loss measured by the probes
+// sent to a hop, latencies are in milliseconds
+type NetworkPathHopStats struct {
+	ProbesSent     int     `json:"probes_sent"`
+	ProbesReceived int     `json:"probes_received"`
+	Loss           float64 `json:"loss"` // ratio of probes without response
+	RTTMin         float64 `json:"rtt_min,omitempty"`
+	RTTMax         float64 `json:"rtt_max,omitempty"`
+	RTTAvg         float64 `json:"rtt_avg,omitempty"`
+	RTTStddev      float64 `json:"rtt_stddev,omitempty"`
+	RTTMedian      float64 `json:"rtt_median,omitempty"`
+}
+
+// NetworkPathFlow encapsulates the flow identifiers
+// of the probes of a path
+type NetworkPathFlow struct {
+	SourcePort      uint16 `json:"source_port,omitempty"`
+	DestinationPort uint16 `json:"destination_port,omitempty"`
+	ICMPIdentifier  uint16 `json:"icmp_identifier,omitempty"`
+}
+
+// NetworkPathECMP encapsulates one of the equal-cost
+// paths to the destination, along with the flows
+// whose probes took it
+type NetworkPathECMP struct {
+	Flows []NetworkPathFlow `json:"flows"`
+	Hops  []NetworkPathHop  `json:"hops"`
+}
+
+// NetworkPathSelection encapsulates why the network
+// path collector chose to test a path
+type NetworkPathSelection struct {
+	// Reason is the policy that ranked the destination, or
+	// the source of the destination when all of them are tested
+	Reason string `json:"reason"`
+	Rank   int    `json:"rank,omitempty"`
+	// Score is the traffic volume in bytes or the error
+	// rate of the destination, depending on the policy
+	Score   float64  `json:"score,omitempty"`
+	Hits    int      `json:"hits,omitempty"`
+	Sources []string `json:"sources,omitempty"`
 }
 
 // NetworkPathSource encapsulates information
@@ -56,8 +115,16 @@ type NetworkPath struct {
 	Namespace   string                 `json:"namespace"` // namespace used to resolve NDM resources
 	PathID      string                 `json:"path_id"`
 	Protocol    Protocol               `json:"protocol"`
+	TCPMethod   TCPMethod              `json:"tcp_method,omitempty"`
 	Source      NetworkPathSource      `json:"source"`
 	Destination NetworkPathDestination `json:"destination"`
 	Hops        []NetworkPathHop       `json:"hops"`
-	Tags        []string               `json:"tags,omitempty"`
+	// Paths are the distinct paths taken by the flows when several flows are probed, Hops is then the path of
+	// the first flow
+	Paths []NetworkPathECMP `json:"paths,omitempty"`
+	// Diff is the difference with the previous run of the same test
+	Diff *NetworkPathDiff `json:"diff,omitempty"`
+	// Selection is why the path was chosen by the network path collector
+	Selection *NetworkPathSelection `json:"selection,omitempty"`
+	Tags      []string              `json:"tags,omitempty"`
 }
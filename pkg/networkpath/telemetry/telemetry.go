@@ -55,4 +55,10 @@ func SubmitNetworkPathTelemetry(sender metricsender.MetricSender, path payload.N
 		sender.Gauge("datadog.network_path.path.reachable", float64(utils.BoolToFloat64(lastHop.Reachable)), newTags)
 		sender.Gauge("datadog.network_path.path.unreachable", float64(utils.BoolToFloat64(!lastHop.Reachable)), newTags)
 	}
+	if len(path.Paths) > 0 {
+		sender.Gauge("datadog.network_path.path.ecmp_paths", float64(len(path.Paths)), newTags)
+	}
+	if path.Diff != nil {
+		sender.Gauge("datadog.network_path.path.changed", float64(utils.BoolToFloat64(path.Diff.Changed)), newTags)
+	}
 }
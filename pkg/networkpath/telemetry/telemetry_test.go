@@ -125,6 +125,47 @@ func TestSubmitNetworkPathTelemetry(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "with paths and diff",
+			path: payload.NetworkPath{
+				Destination: payload.NetworkPathDestination{Hostname: "abc"},
+				Protocol:    payload.ProtocolUDP,
+				Paths: []payload.NetworkPathECMP{
+					{Flows: []payload.NetworkPathFlow{{SourcePort: 12345}}},
+					{Flows: []payload.NetworkPathFlow{{SourcePort: 12346}}},
+				},
+				Diff: &payload.NetworkPathDiff{Changed: true},
+			},
+			checkDuration: 10 * time.Second,
+			checkInterval: 0,
+			tags:          metricTags,
+			expectedMetrics: []metricsender.MockReceivedMetric{
+				{
+					MetricType: metrics.GaugeType,
+					Name:       "datadog.network_path.check_duration",
+					Value:      float64(10),
+					Tags:       expectedTags,
+				},
+				{
+					MetricType: metrics.GaugeType,
+					Name:       "datadog.network_path.path.monitored",
+					Value:      float64(1),
+					Tags:       expectedTags,
+				},
+				{
+					MetricType: metrics.GaugeType,
+					Name:       "datadog.network_path.path.ecmp_paths",
+					Value:      float64(2),
+					Tags:       expectedTags,
+				},
+				{
+					MetricType: metrics.GaugeType,
+					Name:       "datadog.network_path.path.changed",
+					Value:      float64(1),
+					Tags:       expectedTags,
+				},
+			},
+		},
 		{
 			name: "no hops and no interval",
 			path: payload.NetworkPath{
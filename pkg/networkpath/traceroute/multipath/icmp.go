@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package multipath
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/ipv4"
+)
+
+// icmpPadding pads the ICMP probes after the checksum compensation word
+var icmpPadding = []byte("NSMNC\x00")
+
+// icmpProber sends ICMP echo requests, the flows have different identifiers and the probes are identified by their
+// sequence number. Load balancers hashing the first bytes of the ICMP header see the checksum, it's kept constant in
+// a flow by compensating the sequence number in the payload, like Paris traceroute does.
+type icmpProber struct {
+	source     net.IP
+	target     net.IP
+	identifier uint16
+	numPaths   uint16
+	conn       rawConn
+}
+
+func newICMPProber(source net.IP, params Params) (*icmpProber, error) {
+	conn, err := listenRaw("icmp", source)
+	if err != nil {
+		return nil, err
+	}
+	return &icmpProber{
+		source:     source,
+		target:     params.Target,
+		identifier: params.SourcePort,
+		numPaths:   params.NumPaths,
+		conn:       conn,
+	}, nil
+}
+
+func (i *icmpProber) flow(flow int) FlowResult {
+	return FlowResult{ICMPIdentifier: i.identifier + uint16(flow)}
+}
+
+func (i *icmpProber) send(flow int, ttl uint8, id uint16) error {
+	header, packet, err := i.packet(flow, ttl, id)
+	if err != nil {
+		return err
+	}
+	return i.conn.WriteTo(header, packet, nil)
+}
+
+func (i *icmpProber) packet(flow int, ttl uint8, id uint16) (*ipv4.Header, []byte, error) {
+	ip := newIPv4Layer(i.source, i.target, layers.IPProtocolICMPv4, ttl, id)
+	icmp := &layers.ICMPv4{
+		TypeCode: layers.CreateICMPv4TypeCode(icmpEchoRequest, 0),
+		Id:       i.flow(flow).ICMPIdentifier,
+		Seq:      id,
+	}
+	return serialize(ip, icmp, gopacket.Payload(icmpPayload(id)))
+}
+
+// icmpPayload returns the payload of a probe, its first word is the one's complement of the sequence number so that
+// the ICMP checksum doesn't depend on the sequence number
+func icmpPayload(seq uint16) []byte {
+	payload := make([]byte, 2+len(icmpPadding))
+	binary.BigEndian.PutUint16(payload, ^seq)
+	copy(payload[2:], icmpPadding)
+	return payload
+}
+
+func (i *icmpProber) listen(ctx context.Context, responses chan<- response) error {
+	return readLoop(ctx, i.conn, func(header *ipv4.Header, payload []byte, receivedAt time.Time) {
+		if resp, ok := i.match(header, payload); ok {
+			resp.receivedAt = receivedAt
+			sendResponse(ctx, responses, resp)
+		}
+	})
+}
+
+// match matches the echo replies of the target and the ICMP errors quoting a probe
+func (i *icmpProber) match(header *ipv4.Header, payload []byte) (response, bool) {
+	msg, ok := parseICMP(header, payload)
+	if !ok {
+		return response{}, false
+	}
+	var identifier, seq uint16
+	switch {
+	case msg.typ == icmpEchoReply && msg.from.Equal(i.target):
+		identifier = binary.BigEndian.Uint16(msg.rest[0:2])
+		seq = binary.BigEndian.Uint16(msg.rest[2:4])
+	case msg.isError(ipProtoICMP, i.source, i.target) && msg.quoted.l4[0] == icmpEchoRequest:
+		identifier = binary.BigEndian.Uint16(msg.quoted.l4[4:6])
+		seq = binary.BigEndian.Uint16(msg.quoted.l4[6:8])
+	default:
+		return response{}, false
+	}
+	if identifier-i.identifier >= i.numPaths || seq == 0 {
+		return response{}, false
+	}
+	return response{
+		id:     seq,
+		from:   msg.from,
+		isDest: msg.from.Equal(i.target),
+	}, true
+}
+
+func (i *icmpProber) close() error {
+	return i.conn.Close()
+}
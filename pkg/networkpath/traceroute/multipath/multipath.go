@@ -0,0 +1,311 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package multipath implements a Paris/Dublin traceroute style multipath traceroute.
+//
+// All the probes of a flow keep the same flow identifier (ports, or ICMP identifier and checksum) so that load
+// balancers forward them along the same path, and the flow identifier varies between flows to enumerate the
+// equal-cost paths (ECMP) to the target. Several probes can be sent to each hop to measure its latency and loss.
+package multipath
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// DefaultUDPDestPort is the destination port of the UDP probes when none is configured
+	DefaultUDPDestPort = 33434
+	// DefaultTCPDestPort is the destination port of the TCP probes when none is configured
+	DefaultTCPDestPort = 80
+
+	// minSourcePort and sourcePortRange bound the first source port (or ICMP identifier) of the flows
+	minSourcePort   = 12345
+	sourcePortRange = 10000
+
+	// responsesBufferSize is the number of responses buffered while the probes of a TTL are sent
+	responsesBufferSize = 256
+)
+
+type (
+	// Params are the parameters of a multipath traceroute
+	Params struct {
+		Target    net.IP
+		Protocol  payload.Protocol
+		TCPMethod payload.TCPMethod
+		// DestPort is the destination port of the UDP and TCP probes, a default port is used if it's 0
+		DestPort uint16
+		// SourcePort is the source port (or ICMP identifier) of the first flow, the next flows use the next ports.
+		// A random port is used if it's 0.
+		SourcePort uint16
+		// NumPaths is the number of flows probed to discover the equal-cost paths
+		NumPaths uint16
+		// ProbesPerHop is the number of probes sent to each hop of each flow
+		ProbesPerHop uint8
+		MinTTL       uint8
+		MaxTTL       uint8
+		// Delay is the delay between two probes
+		Delay time.Duration
+		// Timeout is the full timeout of the traceroute, shared between the TTLs
+		Timeout time.Duration
+	}
+
+	// Results are the results of a multipath traceroute
+	Results struct {
+		Source net.IP
+		Target net.IP
+		Flows  []FlowResult
+	}
+
+	// FlowResult are the hops discovered by the probes of a flow
+	FlowResult struct {
+		SourcePort     uint16
+		DestPort       uint16
+		ICMPIdentifier uint16
+		// Hops go from MinTTL to the hop that reached the target, or MaxTTL if the target wasn't reached
+		Hops    []HopResult
+		Reached bool
+	}
+
+	// HopResult are the results of the probes of a flow sent with the same TTL
+	HopResult struct {
+		TTL    uint8
+		Probes []ProbeResult
+	}
+
+	// ProbeResult is the result of a probe, IP is nil if it wasn't answered
+	ProbeResult struct {
+		IP     net.IP
+		RTT    time.Duration
+		IsDest bool
+	}
+
+	// probe is a probe waiting for its response
+	probe struct {
+		flow     int
+		ttl      uint8
+		hop      int
+		index    int
+		sentAt   time.Time
+		answered bool
+	}
+
+	// response is a response matched to a probe by a prober
+	response struct {
+		id         uint16
+		from       net.IP
+		isDest     bool
+		receivedAt time.Time
+	}
+
+	// prober sends the probes of a protocol and matches the responses to them
+	prober interface {
+		// flow returns the flow identifiers of a flow
+		flow(i int) FlowResult
+		// send sends a probe of a flow with the given TTL, id identifies the probe in its response
+		send(flow int, ttl uint8, id uint16) error
+		// listen sends the responses to the probes on responses until ctx is canceled
+		listen(ctx context.Context, responses chan<- response) error
+		close() error
+	}
+)
+
+// Traceroute runs a multipath traceroute
+func Traceroute(ctx context.Context, params Params) (*Results, error) {
+	if params.Target.To4() == nil {
+		return nil, fmt.Errorf("multipath traceroute only supports IPv4 targets, got %s", params.Target)
+	}
+	if params.NumPaths == 0 {
+		params.NumPaths = 1
+	}
+	if params.ProbesPerHop == 0 {
+		params.ProbesPerHop = 1
+	}
+	if params.MinTTL == 0 {
+		params.MinTTL = 1
+	}
+	if params.MaxTTL < params.MinTTL {
+		return nil, fmt.Errorf("max TTL %d is lower than min TTL %d", params.MaxTTL, params.MinTTL)
+	}
+	if params.SourcePort == 0 {
+		params.SourcePort = uint16(minSourcePort + rand.Intn(sourcePortRange))
+	}
+
+	source, err := localAddrForHost(params.Target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get local address for target: %w", err)
+	}
+
+	var p prober
+	switch params.Protocol {
+	case payload.ProtocolUDP:
+		p, err = newUDPProber(source, params)
+	case payload.ProtocolICMP:
+		p, err = newICMPProber(source, params)
+	case payload.ProtocolTCP:
+		switch params.TCPMethod {
+		case payload.TCPMethodSYN, "":
+			p, err = newTCPSYNProber(source, params)
+		case payload.TCPMethodSACK:
+			p, err = newTCPSACKProber(ctx, source, params)
+		default:
+			return nil, fmt.Errorf("unknown TCP method: %s", params.TCPMethod)
+		}
+	default:
+		return nil, fmt.Errorf("unknown protocol: %s", params.Protocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer p.close()
+
+	results, err := run(ctx, p, params)
+	if err != nil {
+		return nil, err
+	}
+	results.Source = source
+	results.Target = params.Target
+	return results, nil
+}
+
+// run sends the probes TTL by TTL, the flows that reached the target are not probed further
+func run(ctx context.Context, p prober, params Params) (*Results, error) {
+	results := &Results{Flows: make([]FlowResult, params.NumPaths)}
+	active := make([]int, 0, params.NumPaths)
+	for i := range results.Flows {
+		results.Flows[i] = p.flow(i)
+		active = append(active, i)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	responses := make(chan response, responsesBufferSize)
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- p.listen(ctx, responses)
+	}()
+
+	ttlTimeout := params.Timeout / time.Duration(int(params.MaxTTL-params.MinTTL)+1)
+	probes := make(map[uint16]*probe)
+	var nextID uint16
+
+	for ttl := int(params.MinTTL); ttl <= int(params.MaxTTL) && len(active) > 0; ttl++ {
+		pending := 0
+		for _, flow := range active {
+			flowResult := &results.Flows[flow]
+			flowResult.Hops = append(flowResult.Hops, HopResult{TTL: uint8(ttl), Probes: make([]ProbeResult, params.ProbesPerHop)})
+			for i := 0; i < int(params.ProbesPerHop); i++ {
+				nextID++
+				if nextID == 0 {
+					// ids are 16 bits and 0 is never used, this only happens with more than 65535 probes
+					return nil, errors.New("too many probes")
+				}
+				probes[nextID] = &probe{flow: flow, ttl: uint8(ttl), hop: len(flowResult.Hops) - 1, index: i, sentAt: time.Now()}
+				if err := p.send(flow, uint8(ttl), nextID); err != nil {
+					return nil, fmt.Errorf("failed to send probe: %w", err)
+				}
+				pending++
+				if err := sleep(ctx, params.Delay); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		timer := time.NewTimer(ttlTimeout)
+	wait:
+		for pending > 0 {
+			select {
+			case resp := <-responses:
+				pr, ok := probes[resp.id]
+				if !ok || pr.answered {
+					continue
+				}
+				pr.answered = true
+				results.Flows[pr.flow].Hops[pr.hop].Probes[pr.index] = ProbeResult{
+					IP:     resp.from,
+					RTT:    resp.receivedAt.Sub(pr.sentAt),
+					IsDest: resp.isDest,
+				}
+				// responses to the probes of the previous TTLs can still arrive
+				if int(pr.ttl) == ttl {
+					pending--
+				}
+			case err := <-listenErr:
+				timer.Stop()
+				if err == nil {
+					// the listener only stops without error when ctx is canceled
+					return nil, ctx.Err()
+				}
+				return nil, fmt.Errorf("failed to listen for responses: %w", err)
+			case <-timer.C:
+				log.Tracef("timed out waiting for %d responses at TTL %d", pending, ttl)
+				break wait
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			}
+		}
+		timer.Stop()
+
+		stillActive := active[:0]
+		for _, flow := range active {
+			flowResult := &results.Flows[flow]
+			if hopReached(flowResult.Hops[len(flowResult.Hops)-1]) {
+				flowResult.Reached = true
+				continue
+			}
+			stillActive = append(stillActive, flow)
+		}
+		active = stillActive
+	}
+
+	return results, nil
+}
+
+func hopReached(hop HopResult) bool {
+	for _, probe := range hop.Probes {
+		if probe.IsDest {
+			return true
+		}
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// localAddrForHost returns the local IP used to reach the target
+func localAddrForHost(target net.IP) (net.IP, error) {
+	// dialing UDP doesn't send any packet, it only lets the OS pick the local address
+	conn, err := net.Dial("udp4", net.JoinHostPort(target.String(), strconv.Itoa(DefaultUDPDestPort)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	localAddr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return nil, fmt.Errorf("invalid address type for %s: got %T", conn.LocalAddr(), conn.LocalAddr())
+	}
+	return localAddr.IP.To4(), nil
+}
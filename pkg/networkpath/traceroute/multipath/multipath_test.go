@@ -0,0 +1,187 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package multipath
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProber answers the probes from a topology where the even flows go through 10.1.0.1 and the odd flows through
+// 10.2.0.1, which answers one probe out of two
+type fakeProber struct {
+	target  net.IP
+	pending chan response
+
+	mu     sync.Mutex
+	sent   map[int][]uint8
+	counts map[string]int
+}
+
+func newFakeProber(target net.IP) *fakeProber {
+	return &fakeProber{
+		target:  target,
+		pending: make(chan response, 1024),
+		sent:    make(map[int][]uint8),
+		counts:  make(map[string]int),
+	}
+}
+
+func (f *fakeProber) route(flow int) []net.IP {
+	if flow%2 == 0 {
+		return []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.1.0.1"), f.target}
+	}
+	return []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.2.0.1"), f.target}
+}
+
+func (f *fakeProber) flow(i int) FlowResult {
+	return FlowResult{SourcePort: 10000 + uint16(i), DestPort: 33434}
+}
+
+func (f *fakeProber) send(flow int, ttl uint8, id uint16) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent[flow] = append(f.sent[flow], ttl)
+
+	route := f.route(flow)
+	if int(ttl) > len(route) {
+		return nil
+	}
+	hop := route[ttl-1]
+	f.counts[hop.String()]++
+	if hop.Equal(net.ParseIP("10.2.0.1")) && f.counts[hop.String()]%2 == 0 {
+		return nil
+	}
+	f.pending <- response{
+		id:         id,
+		from:       hop,
+		isDest:     hop.Equal(f.target),
+		receivedAt: time.Now().Add(time.Duration(ttl) * time.Millisecond),
+	}
+	return nil
+}
+
+func (f *fakeProber) listen(ctx context.Context, responses chan<- response) error {
+	for {
+		select {
+		case resp := <-f.pending:
+			sendResponse(ctx, responses, resp)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (f *fakeProber) close() error {
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	target := net.ParseIP("10.9.0.1")
+	prober := newFakeProber(target)
+	results, err := run(context.Background(), prober, Params{
+		NumPaths:     4,
+		ProbesPerHop: 4,
+		MinTTL:       1,
+		MaxTTL:       10,
+		Timeout:      10 * time.Second,
+	})
+	require.NoError(t, err)
+	require.Len(t, results.Flows, 4)
+
+	for i, flow := range results.Flows {
+		assert.Equal(t, uint16(10000+i), flow.SourcePort)
+		assert.True(t, flow.Reached)
+		require.Len(t, flow.Hops, 3)
+		// the flows are not probed once they reached the target
+		assert.Equal(t, []uint8{1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3}, prober.sent[i])
+
+		route := prober.route(i)
+		for j, hop := range flow.Hops {
+			assert.Equal(t, uint8(j+1), hop.TTL)
+			require.Len(t, hop.Probes, 4)
+			for _, probe := range hop.Probes {
+				if probe.IP == nil {
+					continue
+				}
+				assert.Equal(t, route[j], probe.IP)
+				assert.Equal(t, j == 2, probe.IsDest)
+				assert.Greater(t, probe.RTT, time.Duration(0))
+			}
+		}
+	}
+
+	// 10.2.0.1 answered half of the probes of the odd flows
+	answered := 0
+	for _, flow := range []int{1, 3} {
+		for _, probe := range results.Flows[flow].Hops[1].Probes {
+			if probe.IP != nil {
+				answered++
+			}
+		}
+	}
+	assert.Equal(t, 4, answered)
+	for _, probe := range results.Flows[0].Hops[1].Probes {
+		assert.NotNil(t, probe.IP)
+	}
+}
+
+func TestRunUnreachable(t *testing.T) {
+	// the target is never reached past the max TTL
+	prober := newFakeProber(net.ParseIP("10.9.0.1"))
+	results, err := run(context.Background(), prober, Params{
+		NumPaths:     1,
+		ProbesPerHop: 1,
+		MinTTL:       1,
+		MaxTTL:       2,
+		Timeout:      100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.Len(t, results.Flows, 1)
+	assert.False(t, results.Flows[0].Reached)
+	require.Len(t, results.Flows[0].Hops, 2)
+	assert.Equal(t, net.ParseIP("10.1.0.1"), results.Flows[0].Hops[1].Probes[0].IP)
+}
+
+func TestRunTimeout(t *testing.T) {
+	prober := newFakeProber(net.ParseIP("10.9.0.1"))
+	// no hop answers past the route
+	start := time.Now()
+	results, err := run(context.Background(), prober, Params{
+		NumPaths:     1,
+		ProbesPerHop: 2,
+		MinTTL:       4,
+		MaxTTL:       5,
+		Timeout:      200 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	require.Len(t, results.Flows[0].Hops, 2)
+	for _, hop := range results.Flows[0].Hops {
+		assert.Equal(t, []ProbeResult{{}, {}}, hop.Probes)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	prober := newFakeProber(net.ParseIP("10.9.0.1"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := run(ctx, prober, Params{
+		NumPaths:     1,
+		ProbesPerHop: 1,
+		MinTTL:       4,
+		MaxTTL:       5,
+		Delay:        time.Second,
+		Timeout:      time.Minute,
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
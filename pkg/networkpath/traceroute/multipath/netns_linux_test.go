@@ -0,0 +1,226 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+//go:build linux
+
+package multipath
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netns"
+
+	nettestutil "github.com/DataDog/datadog-agent/pkg/network/testutil"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+)
+
+const (
+	nsTargetPort = 8443
+)
+
+var (
+	nsTarget    = net.ParseIP("10.9.0.1").To4()
+	nsRouter    = net.ParseIP("10.1.0.1").To4()
+	nsLeftHop   = net.ParseIP("10.2.0.2").To4()
+	nsRightHop  = net.ParseIP("10.3.0.2").To4()
+	nsSecondHop = []net.IP{nsLeftHop, nsRightHop}
+)
+
+// ecmpTopology creates network namespaces where the source reaches the target through a router load balancing the
+// flows between two next hops based on their ports:
+//
+//	source 10.1.0.2 -- 10.1.0.1 router -- 10.2.0.2 left  -- target 10.9.0.1
+//	                                   \- 10.3.0.2 right -/
+//
+// It returns the namespaces of the source and the target.
+func ecmpTopology(t *testing.T) (string, string) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating network namespaces requires root")
+	}
+	if _, err := exec.LookPath("ip"); err != nil {
+		t.Skip("ip is not available")
+	}
+
+	prefix := fmt.Sprintf("mp%d", rand.Intn(10000))
+	source, router, left, right, target := prefix+"src", prefix+"rtr", prefix+"l", prefix+"r", prefix+"dst"
+	for _, ns := range []string{source, router, left, right, target} {
+		ns := ns
+		nettestutil.RunCommands(t, []string{"ip netns add " + ns}, false)
+		t.Cleanup(func() {
+			nettestutil.RunCommands(t, []string{"ip netns del " + ns}, true)
+		})
+	}
+
+	link := func(ns1, if1, addr1, ns2, if2, addr2 string) []string {
+		return []string{
+			fmt.Sprintf("ip -n %s link add %s type veth peer name %s netns %s", ns1, if1, if2, ns2),
+			fmt.Sprintf("ip -n %s address add %s dev %s", ns1, addr1, if1),
+			fmt.Sprintf("ip -n %s address add %s dev %s", ns2, addr2, if2),
+			fmt.Sprintf("ip -n %s link set %s up", ns1, if1),
+			fmt.Sprintf("ip -n %s link set %s up", ns2, if2),
+		}
+	}
+	var cmds []string
+	cmds = append(cmds, link(source, "eth0", "10.1.0.2/24", router, "eth0", "10.1.0.1/24")...)
+	cmds = append(cmds, link(router, "eth1", "10.2.0.1/24", left, "eth0", "10.2.0.2/24")...)
+	cmds = append(cmds, link(router, "eth2", "10.3.0.1/24", right, "eth0", "10.3.0.2/24")...)
+	cmds = append(cmds, link(left, "eth1", "10.4.0.1/24", target, "eth0", "10.4.0.2/24")...)
+	cmds = append(cmds, link(right, "eth1", "10.5.0.1/24", target, "eth1", "10.5.0.2/24")...)
+	for _, ns := range []string{router, left, right, target} {
+		cmds = append(cmds,
+			fmt.Sprintf("ip netns exec %s sysctl -qw net.ipv4.ip_forward=1", ns),
+			// the routers must answer all the probes
+			fmt.Sprintf("ip netns exec %s sysctl -qw net.ipv4.icmp_ratelimit=0", ns),
+			fmt.Sprintf("ip netns exec %s sysctl -qw net.ipv4.conf.all.rp_filter=0", ns),
+			fmt.Sprintf("ip netns exec %s sysctl -qw net.ipv4.conf.default.rp_filter=0", ns),
+		)
+	}
+	cmds = append(cmds,
+		fmt.Sprintf("ip -n %s link set lo up", target),
+		fmt.Sprintf("ip -n %s address add %s/32 dev lo", target, nsTarget),
+		fmt.Sprintf("ip -n %s route add default via 10.1.0.1", source),
+		// hash the flows on their ports
+		fmt.Sprintf("ip netns exec %s sysctl -qw net.ipv4.fib_multipath_hash_policy=1", router),
+		fmt.Sprintf("ip -n %s route add 10.9.0.0/24 nexthop via 10.2.0.2 nexthop via 10.3.0.2", router),
+		fmt.Sprintf("ip -n %s route add 10.9.0.0/24 via 10.4.0.2", left),
+		fmt.Sprintf("ip -n %s route add default via 10.2.0.1", left),
+		fmt.Sprintf("ip -n %s route add 10.9.0.0/24 via 10.5.0.2", right),
+		fmt.Sprintf("ip -n %s route add default via 10.3.0.1", right),
+		fmt.Sprintf("ip -n %s route add default via 10.4.0.1", target),
+		// the interface of the right path doesn't answer on its own
+		fmt.Sprintf("ip netns exec %s sysctl -qw net.ipv4.conf.eth1.rp_filter=0", target),
+	)
+	nettestutil.RunCommands(t, cmds, false)
+	return source, target
+}
+
+// startTCPServer starts a TCP server in a namespace, keeping the connections open until the end of the test
+func startTCPServer(t *testing.T, ns string) {
+	handle, err := netns.GetFromName(ns)
+	require.NoError(t, err)
+	defer handle.Close()
+
+	var l net.Listener
+	require.NoError(t, kernel.WithNS(handle, func() error {
+		l, err = net.Listen("tcp4", net.JoinHostPort(nsTarget.String(), fmt.Sprint(nsTargetPort)))
+		return err
+	}))
+
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		l.Close()
+		wg.Wait()
+	})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					t.Logf("accept error: %s", err)
+				}
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer conn.Close()
+				_, _ = io.Copy(io.Discard, conn)
+			}()
+		}
+	}()
+}
+
+// tracerouteInNs runs a traceroute from a namespace
+func tracerouteInNs(t *testing.T, ns string, params Params) *Results {
+	handle, err := netns.GetFromName(ns)
+	require.NoError(t, err)
+	defer handle.Close()
+
+	var results *Results
+	require.NoError(t, kernel.WithNS(handle, func() error {
+		results, err = Traceroute(context.Background(), params)
+		return err
+	}))
+	return results
+}
+
+func TestTracerouteNetns(t *testing.T) {
+	source, target := ecmpTopology(t)
+	startTCPServer(t, target)
+
+	tests := []struct {
+		protocol  payload.Protocol
+		tcpMethod payload.TCPMethod
+		// ICMP probes have no ports, the router hashes all of them to the same next hop
+		balanced bool
+	}{
+		{protocol: payload.ProtocolUDP, balanced: true},
+		{protocol: payload.ProtocolICMP},
+		{protocol: payload.ProtocolTCP, tcpMethod: payload.TCPMethodSYN, balanced: true},
+		{protocol: payload.ProtocolTCP, tcpMethod: payload.TCPMethodSACK, balanced: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.protocol)+string(tt.tcpMethod), func(t *testing.T) {
+			results := tracerouteInNs(t, source, Params{
+				Target:       nsTarget,
+				Protocol:     tt.protocol,
+				TCPMethod:    tt.tcpMethod,
+				DestPort:     nsTargetPort,
+				NumPaths:     16,
+				ProbesPerHop: 3,
+				MaxTTL:       6,
+				// the ICMP errors are rate limited by a budget shared by all the namespaces
+				Delay:   2 * time.Millisecond,
+				Timeout: 6 * time.Second,
+			})
+			require.Len(t, results.Flows, 16)
+
+			secondHops := make(map[string]struct{})
+			for _, flow := range results.Flows {
+				assert.True(t, flow.Reached, "flow %+v didn't reach the target", flow)
+				require.Len(t, flow.Hops, 3)
+				for i, expected := range [][]net.IP{{nsRouter}, nsSecondHop, {nsTarget}} {
+					var flowHop net.IP
+					for _, probe := range flow.Hops[i].Probes {
+						if probe.IP == nil {
+							continue
+						}
+						assert.Contains(t, expected, probe.IP)
+						// the probes of a flow take the same path
+						if flowHop != nil {
+							assert.Equal(t, flowHop, probe.IP)
+						}
+						flowHop = probe.IP
+						assert.Equal(t, i == 2, probe.IsDest)
+						assert.Greater(t, probe.RTT, time.Duration(0))
+					}
+					if i == 1 && flowHop != nil {
+						secondHops[flowHop.String()] = struct{}{}
+					}
+				}
+			}
+			if tt.balanced {
+				assert.Len(t, secondHops, 2, "the flows should take both paths")
+			} else {
+				assert.Len(t, secondHops, 1)
+			}
+		})
+	}
+}
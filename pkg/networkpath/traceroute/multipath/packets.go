@@ -0,0 +1,190 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package multipath
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	ipProtoICMP = 1
+	ipProtoTCP  = 6
+	ipProtoUDP  = 17
+
+	icmpEchoReply       = 0
+	icmpDestUnreachable = 3
+	icmpEchoRequest     = 8
+	icmpTimeExceeded    = 11
+
+	icmpHeaderLen = 8
+	// quotedL4Len is the length of the transport header quoted in ICMP errors, RFC 792 only guarantees 8 bytes
+	quotedL4Len = 8
+
+	// readPollInterval is how often the listeners check if they are canceled
+	readPollInterval = 100 * time.Millisecond
+)
+
+type (
+	// rawConn is a raw IPv4 socket, the IP header of the sent packets is set by the caller
+	rawConn interface {
+		SetReadDeadline(t time.Time) error
+		ReadFrom(b []byte) (*ipv4.Header, []byte, *ipv4.ControlMessage, error)
+		WriteTo(h *ipv4.Header, p []byte, cm *ipv4.ControlMessage) error
+		Close() error
+	}
+
+	// icmpMessage is an ICMP message received by a listener
+	icmpMessage struct {
+		from net.IP
+		typ  uint8
+		code uint8
+		// rest is the rest of the ICMP header, the identifier and sequence number of echo messages
+		rest []byte
+		// quoted is the IP header and the beginning of the transport header of the packet an ICMP error is about
+		quoted *quotedPacket
+	}
+
+	// quotedPacket is the packet quoted in an ICMP error
+	quotedPacket struct {
+		id       uint16
+		protocol int
+		src      net.IP
+		dst      net.IP
+		l4       []byte
+	}
+)
+
+// listenRaw opens a raw socket receiving the packets of a protocol sent to the source address
+func listenRaw(protocol string, source net.IP) (rawConn, error) {
+	conn, err := net.ListenPacket("ip4:"+protocol, source.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s raw socket: %w", protocol, err)
+	}
+	raw, err := ipv4.NewRawConn(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to get %s raw socket: %w", protocol, err)
+	}
+	return raw, nil
+}
+
+// readLoop passes the packets received on conn to handle until ctx is canceled
+func readLoop(ctx context.Context, conn rawConn, handle func(header *ipv4.Header, payload []byte, receivedAt time.Time)) error {
+	buf := make([]byte, 1500)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(readPollInterval)); err != nil {
+			return err
+		}
+		header, payload, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				// the socket was closed by the prober
+				return nil
+			default:
+			}
+			return err
+		}
+		handle(header, payload, time.Now())
+	}
+}
+
+// sendResponse sends a response unless ctx is canceled
+func sendResponse(ctx context.Context, responses chan<- response, resp response) {
+	select {
+	case responses <- resp:
+	case <-ctx.Done():
+	}
+}
+
+// parseICMP parses an ICMP message, ok is false if it's not an ICMP message or if an ICMP error doesn't quote an
+// IPv4 packet
+func parseICMP(header *ipv4.Header, payload []byte) (*icmpMessage, bool) {
+	if header == nil || header.Protocol != ipProtoICMP || len(payload) < icmpHeaderLen {
+		return nil, false
+	}
+	msg := &icmpMessage{
+		from: header.Src.To4(),
+		typ:  payload[0],
+		code: payload[1],
+		rest: payload[4:icmpHeaderLen],
+	}
+	if msg.typ != icmpDestUnreachable && msg.typ != icmpTimeExceeded {
+		return msg, true
+	}
+
+	quoted := payload[icmpHeaderLen:]
+	if len(quoted) < ipv4.HeaderLen || quoted[0]>>4 != 4 {
+		return nil, false
+	}
+	headerLen := int(quoted[0]&0x0f) * 4
+	if headerLen < ipv4.HeaderLen || len(quoted) < headerLen+quotedL4Len {
+		return nil, false
+	}
+	msg.quoted = &quotedPacket{
+		id:       binary.BigEndian.Uint16(quoted[4:6]),
+		protocol: int(quoted[9]),
+		src:      net.IP(quoted[12:16]),
+		dst:      net.IP(quoted[16:20]),
+		l4:       quoted[headerLen:],
+	}
+	return msg, true
+}
+
+// isError returns whether the message is an ICMP error about a packet sent from source to target with protocol
+func (m *icmpMessage) isError(protocol int, source net.IP, target net.IP) bool {
+	return m.quoted != nil &&
+		m.quoted.protocol == protocol &&
+		m.quoted.src.Equal(source) &&
+		m.quoted.dst.Equal(target)
+}
+
+// serialize serializes the layers of a packet and splits it in an IPv4 header and its payload
+func serialize(ip *layers.IPv4, l ...gopacket.SerializableLayer) (*ipv4.Header, []byte, error) {
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, append([]gopacket.SerializableLayer{ip}, l...)...); err != nil {
+		return nil, nil, fmt.Errorf("failed to serialize packet: %w", err)
+	}
+	packet := buf.Bytes()
+	var header ipv4.Header
+	if err := header.Parse(packet[:ipv4.HeaderLen]); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse IP header: %w", err)
+	}
+	return &header, packet[ipv4.HeaderLen:], nil
+}
+
+// newIPv4Layer returns the IP layer of a probe, its ID is the probe id so that it can be matched from the ICMP errors
+// quoting it
+func newIPv4Layer(source net.IP, target net.IP, protocol layers.IPProtocol, ttl uint8, id uint16) *layers.IPv4 {
+	return &layers.IPv4{
+		Version:  4,
+		IHL:      5,
+		TTL:      ttl,
+		Id:       id,
+		Protocol: protocol,
+		SrcIP:    source,
+		DstIP:    target,
+	}
+}
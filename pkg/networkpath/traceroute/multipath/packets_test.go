@@ -0,0 +1,228 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package multipath
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/ipv4"
+)
+
+var (
+	testSource = net.ParseIP("192.168.1.10").To4()
+	testTarget = net.ParseIP("10.9.0.1").To4()
+	testRouter = net.ParseIP("10.0.0.1").To4()
+)
+
+// icmpError returns the ICMP error sent by from about a probe, quoting its IP header and the first 8 bytes of its
+// payload
+func icmpError(t *testing.T, typ uint8, code uint8, from net.IP, probeHeader *ipv4.Header, probePayload []byte) (*ipv4.Header, []byte) {
+	quotedHeader, err := probeHeader.Marshal()
+	require.NoError(t, err)
+	quoted := append(quotedHeader, probePayload[:quotedL4Len]...)
+
+	icmp := &layers.ICMPv4{TypeCode: layers.CreateICMPv4TypeCode(typ, code)}
+	buf := gopacket.NewSerializeBuffer()
+	require.NoError(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{ComputeChecksums: true}, icmp, gopacket.Payload(quoted)))
+	return &ipv4.Header{Version: 4, Protocol: ipProtoICMP, Src: from, Dst: testSource}, buf.Bytes()
+}
+
+func TestParseICMP(t *testing.T) {
+	u := &udpProber{source: testSource, target: testTarget, sourcePort: 20000, destPort: 33434, numPaths: 2}
+	probeHeader, probePayload, err := u.packet(1, 3, 42)
+	require.NoError(t, err)
+
+	header, payload := icmpError(t, icmpTimeExceeded, 0, testRouter, probeHeader, probePayload)
+	msg, ok := parseICMP(header, payload)
+	require.True(t, ok)
+	assert.Equal(t, testRouter, msg.from)
+	assert.Equal(t, uint8(icmpTimeExceeded), msg.typ)
+	require.NotNil(t, msg.quoted)
+	assert.Equal(t, uint16(42), msg.quoted.id)
+	assert.Equal(t, ipProtoUDP, msg.quoted.protocol)
+	assert.True(t, msg.isError(ipProtoUDP, testSource, testTarget))
+	assert.False(t, msg.isError(ipProtoTCP, testSource, testTarget))
+	assert.Equal(t, uint16(20001), binary.BigEndian.Uint16(msg.quoted.l4[0:2]))
+
+	// truncated quote
+	_, ok = parseICMP(header, payload[:icmpHeaderLen+ipv4.HeaderLen])
+	assert.False(t, ok)
+	// not ICMP
+	_, ok = parseICMP(&ipv4.Header{Protocol: ipProtoTCP}, payload)
+	assert.False(t, ok)
+}
+
+func TestUDPProberMatch(t *testing.T) {
+	u := &udpProber{source: testSource, target: testTarget, sourcePort: 20000, destPort: 33434, numPaths: 2}
+	probeHeader, probePayload, err := u.packet(1, 3, 42)
+	require.NoError(t, err)
+	assert.Equal(t, 3, probeHeader.TTL)
+	assert.Equal(t, 42, probeHeader.ID)
+
+	resp, ok := u.match(icmpError(t, icmpTimeExceeded, 0, testRouter, probeHeader, probePayload))
+	require.True(t, ok)
+	assert.Equal(t, response{id: 42, from: testRouter}, resp)
+
+	resp, ok = u.match(icmpError(t, icmpDestUnreachable, 3, testTarget, probeHeader, probePayload))
+	require.True(t, ok)
+	assert.Equal(t, response{id: 42, from: testTarget, isDest: true}, resp)
+
+	// probe of another traceroute
+	other := &udpProber{source: testSource, target: testTarget, sourcePort: 30000, destPort: 33434, numPaths: 2}
+	probeHeader, probePayload, err = other.packet(0, 3, 42)
+	require.NoError(t, err)
+	_, ok = u.match(icmpError(t, icmpTimeExceeded, 0, testRouter, probeHeader, probePayload))
+	assert.False(t, ok)
+}
+
+func TestICMPProber(t *testing.T) {
+	i := &icmpProber{source: testSource, target: testTarget, identifier: 20000, numPaths: 2}
+
+	checksum := func(flow int, id uint16) uint16 {
+		_, packet, err := i.packet(flow, 1, id)
+		require.NoError(t, err)
+		return binary.BigEndian.Uint16(packet[2:4])
+	}
+	// the checksum is constant in a flow and differs between flows
+	assert.Equal(t, checksum(0, 1), checksum(0, 2))
+	assert.Equal(t, checksum(0, 1), checksum(0, 65000))
+	assert.NotEqual(t, checksum(0, 1), checksum(1, 1))
+
+	probeHeader, probePayload, err := i.packet(1, 3, 42)
+	require.NoError(t, err)
+	resp, ok := i.match(icmpError(t, icmpTimeExceeded, 0, testRouter, probeHeader, probePayload))
+	require.True(t, ok)
+	assert.Equal(t, response{id: 42, from: testRouter}, resp)
+
+	// echo reply of the target
+	reply := &layers.ICMPv4{TypeCode: layers.CreateICMPv4TypeCode(icmpEchoReply, 0), Id: 20001, Seq: 42}
+	buf := gopacket.NewSerializeBuffer()
+	require.NoError(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{ComputeChecksums: true}, reply, gopacket.Payload(icmpPayload(42))))
+	resp, ok = i.match(&ipv4.Header{Version: 4, Protocol: ipProtoICMP, Src: testTarget, Dst: testSource}, buf.Bytes())
+	require.True(t, ok)
+	assert.Equal(t, response{id: 42, from: testTarget, isDest: true}, resp)
+
+	// echo reply to another process
+	reply.Id = 1
+	buf = gopacket.NewSerializeBuffer()
+	require.NoError(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{ComputeChecksums: true}, reply))
+	_, ok = i.match(&ipv4.Header{Version: 4, Protocol: ipProtoICMP, Src: testTarget, Dst: testSource}, buf.Bytes())
+	assert.False(t, ok)
+}
+
+// tcpSegment serializes a TCP segment sent by the target
+func tcpSegment(t *testing.T, tcp *layers.TCP) (*ipv4.Header, []byte) {
+	ip := newIPv4Layer(testTarget, testSource, layers.IPProtocolTCP, 64, 1)
+	require.NoError(t, tcp.SetNetworkLayerForChecksum(ip))
+	header, payload, err := serialize(ip, tcp)
+	require.NoError(t, err)
+	return header, payload
+}
+
+func TestTCPSYNProberMatch(t *testing.T) {
+	s := &tcpSYNProber{source: testSource, target: testTarget, sourcePort: 20000, destPort: 443, numPaths: 2, seqBase: 0xfffffff0}
+	probeHeader, probePayload, err := s.packet(1, 3, 42)
+	require.NoError(t, err)
+	tcp, ok := parseTCP(probeHeader, probePayload)
+	require.True(t, ok)
+	assert.True(t, tcp.SYN)
+	assert.Equal(t, layers.TCPPort(20001), tcp.SrcPort)
+	assert.Equal(t, uint32(26), tcp.Seq) // wrapped around
+
+	resp, ok := s.matchICMP(icmpError(t, icmpTimeExceeded, 0, testRouter, probeHeader, probePayload))
+	require.True(t, ok)
+	assert.Equal(t, response{id: 42, from: testRouter}, resp)
+
+	resp, ok = s.matchTCP(tcpSegment(t, &layers.TCP{SrcPort: 443, DstPort: 20001, SYN: true, ACK: true, Ack: tcp.Seq + 1}))
+	require.True(t, ok)
+	assert.Equal(t, response{id: 42, from: testTarget, isDest: true}, resp)
+
+	resp, ok = s.matchTCP(tcpSegment(t, &layers.TCP{SrcPort: 443, DstPort: 20001, RST: true, ACK: true, Ack: tcp.Seq + 1}))
+	require.True(t, ok)
+	assert.Equal(t, response{id: 42, from: testTarget, isDest: true}, resp)
+
+	// not an answer to a SYN
+	_, ok = s.matchTCP(tcpSegment(t, &layers.TCP{SrcPort: 443, DstPort: 20001, ACK: true, Ack: tcp.Seq + 1}))
+	assert.False(t, ok)
+	// another flow
+	_, ok = s.matchTCP(tcpSegment(t, &layers.TCP{SrcPort: 443, DstPort: 20002, SYN: true, ACK: true, Ack: tcp.Seq + 1}))
+	assert.False(t, ok)
+}
+
+func TestTCPSACKProber(t *testing.T) {
+	flow := &sackFlow{localPort: 40000}
+	tsData := make([]byte, 8)
+	binary.BigEndian.PutUint32(tsData[0:4], 7000)
+	binary.BigEndian.PutUint32(tsData[4:8], 5000)
+	require.NoError(t, flow.handshake(&layers.TCP{
+		SYN: true, ACK: true, Seq: 100, Ack: 2000,
+		Options: []layers.TCPOption{
+			{OptionType: layers.TCPOptionKindSACKPermitted, OptionLength: 2},
+			{OptionType: layers.TCPOptionKindTimestamps, OptionLength: 10, OptionData: tsData},
+		},
+	}))
+	assert.Equal(t, uint32(2000), flow.sndNxt)
+	assert.Equal(t, uint32(101), flow.rcvNxt)
+	assert.True(t, flow.timestamps)
+	assert.Equal(t, uint32(7000), flow.tsEcr)
+	assert.Equal(t, uint32(5000), flow.tsValBase)
+
+	assert.ErrorContains(t, (&sackFlow{}).handshake(&layers.TCP{SYN: true, ACK: true}), "doesn't support SACK")
+
+	s := &tcpSACKProber{source: testSource, target: testTarget, destPort: 443, flows: []*sackFlow{{localPort: 39999}, flow}}
+	probeHeader, probePayload, err := s.packet(1, 3, 42)
+	require.NoError(t, err)
+	tcp, ok := parseTCP(probeHeader, probePayload)
+	require.True(t, ok)
+	assert.True(t, tcp.ACK)
+	assert.Equal(t, uint32(2000+84), tcp.Seq)
+	assert.Equal(t, uint32(101), tcp.Ack)
+	assert.Len(t, tcp.Payload, 1)
+	var tsVal uint32
+	for _, opt := range tcp.Options {
+		if opt.OptionType == layers.TCPOptionKindTimestamps {
+			tsVal = binary.BigEndian.Uint32(opt.OptionData[0:4])
+			assert.Equal(t, uint32(7000), binary.BigEndian.Uint32(opt.OptionData[4:8]))
+		}
+	}
+	assert.Greater(t, tsVal, uint32(5000))
+
+	resp, ok := s.matchICMP(icmpError(t, icmpTimeExceeded, 0, testRouter, probeHeader, probePayload))
+	require.True(t, ok)
+	assert.Equal(t, response{id: 42, from: testRouter}, resp)
+
+	sack := func(left uint32) []layers.TCPOption {
+		blocks := make([]byte, 16)
+		binary.BigEndian.PutUint32(blocks[0:4], left)
+		binary.BigEndian.PutUint32(blocks[4:8], left+1)
+		binary.BigEndian.PutUint32(blocks[8:12], 2000+2)
+		binary.BigEndian.PutUint32(blocks[12:16], 2000+3)
+		return []layers.TCPOption{
+			{OptionType: layers.TCPOptionKindNop, OptionLength: 1},
+			{OptionType: layers.TCPOptionKindNop, OptionLength: 1},
+			{OptionType: layers.TCPOptionKindSACK, OptionLength: 18, OptionData: blocks},
+		}
+	}
+	resp, ok = s.matchTCP(tcpSegment(t, &layers.TCP{SrcPort: 443, DstPort: 40000, ACK: true, Ack: 2000, Options: sack(2000 + 84)}))
+	require.True(t, ok)
+	assert.Equal(t, response{id: 42, from: testTarget, isDest: true}, resp)
+
+	// acknowledgement without SACK
+	_, ok = s.matchTCP(tcpSegment(t, &layers.TCP{SrcPort: 443, DstPort: 40000, ACK: true, Ack: 2000}))
+	assert.False(t, ok)
+	// SACK block that isn't a probe
+	_, ok = s.matchTCP(tcpSegment(t, &layers.TCP{SrcPort: 443, DstPort: 40000, ACK: true, Ack: 2000, Options: sack(2000 + 85)}))
+	assert.False(t, ok)
+	// another connection
+	_, ok = s.matchTCP(tcpSegment(t, &layers.TCP{SrcPort: 443, DstPort: 40001, ACK: true, Ack: 2000, Options: sack(2000 + 84)}))
+	assert.False(t, ok)
+}
@@ -0,0 +1,276 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package multipath
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/ipv4"
+)
+
+// handshakeTimeout bounds the connection of a flow to the target
+const handshakeTimeout = 5 * time.Second
+
+// sackProbeData is the data of the SACK probes
+var sackProbeData = []byte{0}
+
+// tcpSACKProber sends data segments on TCP connections established with the target, each flow is a connection.
+// The probes are sent after a hole in the sequence space so that the target acknowledges them with a SACK block
+// starting at their sequence number, and they are spaced so that their SACK blocks are never merged. Unlike SYNs,
+// the probes are part of an established connection, so they go through the stateful firewalls that let the
+// connection in.
+type tcpSACKProber struct {
+	source   net.IP
+	target   net.IP
+	destPort uint16
+	flows    []*sackFlow
+	conn     rawConn
+	icmpConn rawConn
+}
+
+// sackFlow is a connection established with the target
+type sackFlow struct {
+	conn      net.Conn
+	localPort uint16
+	// sndNxt and rcvNxt are the next sequence numbers of the connection after the handshake
+	sndNxt uint32
+	rcvNxt uint32
+	// timestamps is true if the TCP timestamps are enabled on the connection, the probes then carry a timestamp
+	// following the one of the SYN so that the target doesn't drop them
+	timestamps  bool
+	tsValBase   uint32
+	tsEcr       uint32
+	handshakeAt time.Time
+}
+
+func newTCPSACKProber(ctx context.Context, source net.IP, params Params) (_ *tcpSACKProber, err error) {
+	destPort := params.DestPort
+	if destPort == 0 {
+		destPort = DefaultTCPDestPort
+	}
+	t := &tcpSACKProber{
+		source:   source,
+		target:   params.Target,
+		destPort: destPort,
+	}
+	// the raw socket must be opened before connecting to catch the SYN-ACK
+	if t.conn, err = listenRaw("tcp", source); err != nil {
+		return nil, err
+	}
+	if t.icmpConn, err = listenRaw("icmp", source); err != nil {
+		t.conn.Close()
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			t.close()
+		}
+	}()
+
+	for i := 0; i < int(params.NumPaths); i++ {
+		flow, err := t.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		t.flows = append(t.flows, flow)
+	}
+	return t, nil
+}
+
+// connect establishes a connection with the target and reads its SYN-ACK to learn the sequence numbers
+func (t *tcpSACKProber) connect(ctx context.Context) (*sackFlow, error) {
+	dialer := net.Dialer{
+		LocalAddr: &net.TCPAddr{IP: t.source},
+		Timeout:   handshakeTimeout,
+	}
+	handshakeAt := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp4", net.JoinHostPort(t.target.String(), strconv.Itoa(int(t.destPort))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s:%d: %w", t.target, t.destPort, err)
+	}
+	flow := &sackFlow{
+		conn:        conn,
+		localPort:   uint16(conn.LocalAddr().(*net.TCPAddr).Port),
+		handshakeAt: handshakeAt,
+	}
+
+	buf := make([]byte, 1500)
+	deadline := time.Now().Add(handshakeTimeout)
+	for {
+		if err := t.conn.SetReadDeadline(deadline); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		header, payload, _, err := t.conn.ReadFrom(buf)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to read the SYN-ACK of %s:%d: %w", t.target, t.destPort, err)
+		}
+		tcp, ok := parseTCP(header, payload)
+		if !ok || !header.Src.Equal(t.target) || uint16(tcp.SrcPort) != t.destPort ||
+			uint16(tcp.DstPort) != flow.localPort || !tcp.SYN || !tcp.ACK {
+			continue
+		}
+		if err := flow.handshake(tcp); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s:%d: %w", t.target, t.destPort, err)
+		}
+		return flow, nil
+	}
+}
+
+// handshake sets up the flow from the SYN-ACK of the target
+func (f *sackFlow) handshake(synAck *layers.TCP) error {
+	f.sndNxt = synAck.Ack
+	f.rcvNxt = synAck.Seq + 1
+	sackPermitted := false
+	for _, opt := range synAck.Options {
+		switch opt.OptionType {
+		case layers.TCPOptionKindSACKPermitted:
+			sackPermitted = true
+		case layers.TCPOptionKindTimestamps:
+			if len(opt.OptionData) == 8 {
+				f.timestamps = true
+				f.tsEcr = binary.BigEndian.Uint32(opt.OptionData[0:4])
+				// the SYN-ACK echoes the timestamp of the SYN
+				f.tsValBase = binary.BigEndian.Uint32(opt.OptionData[4:8])
+			}
+		}
+	}
+	if !sackPermitted {
+		return fmt.Errorf("the target doesn't support SACK, use the SYN method")
+	}
+	return nil
+}
+
+func (t *tcpSACKProber) flow(i int) FlowResult {
+	return FlowResult{SourcePort: t.flows[i].localPort, DestPort: t.destPort}
+}
+
+func (t *tcpSACKProber) send(flow int, ttl uint8, id uint16) error {
+	header, packet, err := t.packet(flow, ttl, id)
+	if err != nil {
+		return err
+	}
+	return t.conn.WriteTo(header, packet, nil)
+}
+
+func (t *tcpSACKProber) packet(flow int, ttl uint8, id uint16) (*ipv4.Header, []byte, error) {
+	f := t.flows[flow]
+	ip := newIPv4Layer(t.source, t.target, layers.IPProtocolTCP, ttl, id)
+	tcp := &layers.TCP{
+		SrcPort: layers.TCPPort(f.localPort),
+		DstPort: layers.TCPPort(t.destPort),
+		Seq:     f.sndNxt + sackOffset(id),
+		Ack:     f.rcvNxt,
+		ACK:     true,
+		PSH:     true,
+		Window:  1024,
+	}
+	if f.timestamps {
+		tsVal := f.tsValBase + uint32(time.Since(f.handshakeAt).Milliseconds()) + 1
+		tsData := make([]byte, 8)
+		binary.BigEndian.PutUint32(tsData[0:4], tsVal)
+		binary.BigEndian.PutUint32(tsData[4:8], f.tsEcr)
+		tcp.Options = []layers.TCPOption{
+			{OptionType: layers.TCPOptionKindNop, OptionLength: 1},
+			{OptionType: layers.TCPOptionKindNop, OptionLength: 1},
+			{OptionType: layers.TCPOptionKindTimestamps, OptionLength: 10, OptionData: tsData},
+		}
+	}
+	if err := tcp.SetNetworkLayerForChecksum(ip); err != nil {
+		return nil, nil, err
+	}
+	return serialize(ip, tcp, gopacket.Payload(sackProbeData))
+}
+
+// sackOffset returns the offset of a probe from the next sequence number, the byte at the next sequence number is
+// never sent so that the probes are out of order, and there's a hole between the probes so that their SACK blocks
+// are not merged
+func sackOffset(id uint16) uint32 {
+	return 2 * uint32(id)
+}
+
+// sackProbeID returns the id of the probe with the given offset from the next sequence number
+func sackProbeID(offset uint32) (uint16, bool) {
+	if offset == 0 || offset%2 != 0 || offset/2 > 0xffff {
+		return 0, false
+	}
+	return uint16(offset / 2), true
+}
+
+func (t *tcpSACKProber) listen(ctx context.Context, responses chan<- response) error {
+	return listenTCP(ctx, t.conn, t.icmpConn, responses, t.matchTCP, t.matchICMP)
+}
+
+func (t *tcpSACKProber) flowByPort(port uint16) *sackFlow {
+	for _, f := range t.flows {
+		if f.localPort == port {
+			return f
+		}
+	}
+	return nil
+}
+
+// matchTCP matches the acknowledgements of the target, their first SACK block is the last probe received
+func (t *tcpSACKProber) matchTCP(header *ipv4.Header, payload []byte) (response, bool) {
+	tcp, ok := parseTCP(header, payload)
+	if !ok || !header.Src.Equal(t.target) || uint16(tcp.SrcPort) != t.destPort || !tcp.ACK {
+		return response{}, false
+	}
+	f := t.flowByPort(uint16(tcp.DstPort))
+	if f == nil {
+		return response{}, false
+	}
+	for _, opt := range tcp.Options {
+		if opt.OptionType != layers.TCPOptionKindSACK || len(opt.OptionData) < 8 {
+			continue
+		}
+		id, ok := sackProbeID(binary.BigEndian.Uint32(opt.OptionData[0:4]) - f.sndNxt)
+		if !ok {
+			return response{}, false
+		}
+		return response{id: id, from: header.Src.To4(), isDest: true}, true
+	}
+	return response{}, false
+}
+
+// matchICMP matches the ICMP errors quoting a probe
+func (t *tcpSACKProber) matchICMP(header *ipv4.Header, payload []byte) (response, bool) {
+	msg, ok := parseICMP(header, payload)
+	if !ok || !msg.isError(ipProtoTCP, t.source, t.target) ||
+		binary.BigEndian.Uint16(msg.quoted.l4[2:4]) != t.destPort {
+		return response{}, false
+	}
+	f := t.flowByPort(binary.BigEndian.Uint16(msg.quoted.l4[0:2]))
+	if f == nil {
+		return response{}, false
+	}
+	id, ok := sackProbeID(binary.BigEndian.Uint32(msg.quoted.l4[4:8]) - f.sndNxt)
+	if !ok {
+		return response{}, false
+	}
+	return response{id: id, from: msg.from, isDest: msg.from.Equal(t.target)}, true
+}
+
+func (t *tcpSACKProber) close() error {
+	for _, f := range t.flows {
+		if tcpConn, ok := f.conn.(*net.TCPConn); ok {
+			// reset the connection rather than leaving the target with the data it's waiting for
+			_ = tcpConn.SetLinger(0)
+		}
+		f.conn.Close()
+	}
+	t.icmpConn.Close()
+	return t.conn.Close()
+}
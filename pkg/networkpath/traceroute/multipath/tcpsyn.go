@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package multipath
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/ipv4"
+)
+
+// tcpSYNProber sends TCP SYNs, the flows have different source ports and the probes are identified by their sequence
+// number, which the target acknowledges in its SYN-ACK or RST
+type tcpSYNProber struct {
+	source     net.IP
+	target     net.IP
+	sourcePort uint16
+	destPort   uint16
+	numPaths   uint16
+	seqBase    uint32
+	conn       rawConn
+	icmpConn   rawConn
+}
+
+func newTCPSYNProber(source net.IP, params Params) (*tcpSYNProber, error) {
+	destPort := params.DestPort
+	if destPort == 0 {
+		destPort = DefaultTCPDestPort
+	}
+	conn, err := listenRaw("tcp", source)
+	if err != nil {
+		return nil, err
+	}
+	icmpConn, err := listenRaw("icmp", source)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &tcpSYNProber{
+		source:     source,
+		target:     params.Target,
+		sourcePort: params.SourcePort,
+		destPort:   destPort,
+		numPaths:   params.NumPaths,
+		seqBase:    rand.Uint32(),
+		conn:       conn,
+		icmpConn:   icmpConn,
+	}, nil
+}
+
+func (t *tcpSYNProber) flow(i int) FlowResult {
+	return FlowResult{SourcePort: t.sourcePort + uint16(i), DestPort: t.destPort}
+}
+
+func (t *tcpSYNProber) send(flow int, ttl uint8, id uint16) error {
+	header, packet, err := t.packet(flow, ttl, id)
+	if err != nil {
+		return err
+	}
+	return t.conn.WriteTo(header, packet, nil)
+}
+
+func (t *tcpSYNProber) packet(flow int, ttl uint8, id uint16) (*ipv4.Header, []byte, error) {
+	ip := newIPv4Layer(t.source, t.target, layers.IPProtocolTCP, ttl, id)
+	tcp := &layers.TCP{
+		SrcPort: layers.TCPPort(t.flow(flow).SourcePort),
+		DstPort: layers.TCPPort(t.destPort),
+		Seq:     t.seqBase + uint32(id),
+		SYN:     true,
+		Window:  1024,
+	}
+	if err := tcp.SetNetworkLayerForChecksum(ip); err != nil {
+		return nil, nil, err
+	}
+	return serialize(ip, tcp)
+}
+
+func (t *tcpSYNProber) listen(ctx context.Context, responses chan<- response) error {
+	return listenTCP(ctx, t.conn, t.icmpConn, responses, t.matchTCP, t.matchICMP)
+}
+
+// matchTCP matches the SYN-ACKs and RSTs of the target, they acknowledge the sequence number of the probe
+func (t *tcpSYNProber) matchTCP(header *ipv4.Header, payload []byte) (response, bool) {
+	tcp, ok := parseTCP(header, payload)
+	if !ok || !header.Src.Equal(t.target) || uint16(tcp.SrcPort) != t.destPort ||
+		uint16(tcp.DstPort)-t.sourcePort >= t.numPaths {
+		return response{}, false
+	}
+	if !(tcp.SYN && tcp.ACK) && !tcp.RST {
+		return response{}, false
+	}
+	id, ok := probeID(tcp.Ack-1, t.seqBase)
+	if !ok {
+		return response{}, false
+	}
+	return response{id: id, from: header.Src.To4(), isDest: true}, true
+}
+
+// matchICMP matches the ICMP errors quoting a probe
+func (t *tcpSYNProber) matchICMP(header *ipv4.Header, payload []byte) (response, bool) {
+	msg, ok := parseICMP(header, payload)
+	if !ok || !msg.isError(ipProtoTCP, t.source, t.target) {
+		return response{}, false
+	}
+	srcPort := binary.BigEndian.Uint16(msg.quoted.l4[0:2])
+	dstPort := binary.BigEndian.Uint16(msg.quoted.l4[2:4])
+	if dstPort != t.destPort || srcPort-t.sourcePort >= t.numPaths {
+		return response{}, false
+	}
+	id, ok := probeID(binary.BigEndian.Uint32(msg.quoted.l4[4:8]), t.seqBase)
+	if !ok {
+		return response{}, false
+	}
+	return response{id: id, from: msg.from, isDest: msg.from.Equal(t.target)}, true
+}
+
+func (t *tcpSYNProber) close() error {
+	t.icmpConn.Close()
+	return t.conn.Close()
+}
+
+// probeID returns the id of a probe whose sequence number is seqBase+id
+func probeID(seq uint32, seqBase uint32) (uint16, bool) {
+	offset := seq - seqBase
+	if offset == 0 || offset > 0xffff {
+		return 0, false
+	}
+	return uint16(offset), true
+}
+
+// parseTCP parses a TCP segment
+func parseTCP(header *ipv4.Header, payload []byte) (*layers.TCP, bool) {
+	if header == nil || header.Protocol != ipProtoTCP {
+		return nil, false
+	}
+	var tcp layers.TCP
+	if err := tcp.DecodeFromBytes(payload, gopacket.NilDecodeFeedback); err != nil {
+		return nil, false
+	}
+	return &tcp, true
+}
+
+// listenTCP matches the TCP segments and ICMP errors received by the TCP probers
+func listenTCP(ctx context.Context, conn rawConn, icmpConn rawConn, responses chan<- response, matchTCP, matchICMP func(*ipv4.Header, []byte) (response, bool)) error {
+	errs := make(chan error, 2)
+	for _, l := range []struct {
+		conn  rawConn
+		match func(*ipv4.Header, []byte) (response, bool)
+	}{{conn, matchTCP}, {icmpConn, matchICMP}} {
+		l := l
+		go func() {
+			errs <- readLoop(ctx, l.conn, func(header *ipv4.Header, payload []byte, receivedAt time.Time) {
+				if resp, ok := l.match(header, payload); ok {
+					resp.receivedAt = receivedAt
+					sendResponse(ctx, responses, resp)
+				}
+			})
+		}()
+	}
+	// the first listener to fail stops the traceroute, the other one stops when ctx is canceled
+	return <-errs
+}
@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package multipath
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/ipv4"
+)
+
+// udpPayload is the payload of the UDP probes
+var udpPayload = []byte("NSMNC\x00\x00\x00")
+
+// udpProber sends UDP probes, the flows have different source ports and the probes are identified by their IP ID
+type udpProber struct {
+	source     net.IP
+	target     net.IP
+	sourcePort uint16
+	destPort   uint16
+	numPaths   uint16
+	conn       rawConn
+	icmpConn   rawConn
+}
+
+func newUDPProber(source net.IP, params Params) (*udpProber, error) {
+	destPort := params.DestPort
+	if destPort == 0 {
+		destPort = DefaultUDPDestPort
+	}
+	conn, err := listenRaw("udp", source)
+	if err != nil {
+		return nil, err
+	}
+	icmpConn, err := listenRaw("icmp", source)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &udpProber{
+		source:     source,
+		target:     params.Target,
+		sourcePort: params.SourcePort,
+		destPort:   destPort,
+		numPaths:   params.NumPaths,
+		conn:       conn,
+		icmpConn:   icmpConn,
+	}, nil
+}
+
+func (u *udpProber) flow(i int) FlowResult {
+	return FlowResult{SourcePort: u.sourcePort + uint16(i), DestPort: u.destPort}
+}
+
+func (u *udpProber) send(flow int, ttl uint8, id uint16) error {
+	header, packet, err := u.packet(flow, ttl, id)
+	if err != nil {
+		return err
+	}
+	return u.conn.WriteTo(header, packet, nil)
+}
+
+func (u *udpProber) packet(flow int, ttl uint8, id uint16) (*ipv4.Header, []byte, error) {
+	ip := newIPv4Layer(u.source, u.target, layers.IPProtocolUDP, ttl, id)
+	udp := &layers.UDP{
+		SrcPort: layers.UDPPort(u.flow(flow).SourcePort),
+		DstPort: layers.UDPPort(u.destPort),
+	}
+	if err := udp.SetNetworkLayerForChecksum(ip); err != nil {
+		return nil, nil, err
+	}
+	return serialize(ip, udp, gopacket.Payload(udpPayload))
+}
+
+func (u *udpProber) listen(ctx context.Context, responses chan<- response) error {
+	return readLoop(ctx, u.icmpConn, func(header *ipv4.Header, payload []byte, receivedAt time.Time) {
+		if resp, ok := u.match(header, payload); ok {
+			resp.receivedAt = receivedAt
+			sendResponse(ctx, responses, resp)
+		}
+	})
+}
+
+// match matches the ICMP errors quoting a probe, the target answers with a port unreachable error
+func (u *udpProber) match(header *ipv4.Header, payload []byte) (response, bool) {
+	msg, ok := parseICMP(header, payload)
+	if !ok || !msg.isError(ipProtoUDP, u.source, u.target) {
+		return response{}, false
+	}
+	srcPort := binary.BigEndian.Uint16(msg.quoted.l4[0:2])
+	dstPort := binary.BigEndian.Uint16(msg.quoted.l4[2:4])
+	if dstPort != u.destPort || srcPort-u.sourcePort >= u.numPaths || msg.quoted.id == 0 {
+		return response{}, false
+	}
+	return response{
+		id:     msg.quoted.id,
+		from:   msg.from,
+		isDest: msg.from.Equal(u.target) && msg.typ == icmpDestUnreachable,
+	}, true
+}
+
+func (u *udpProber) close() error {
+	u.icmpConn.Close()
+	return u.conn.Close()
+}
@@ -137,15 +137,22 @@ func (r *Runner) RunTraceroute(ctx context.Context, cfg Config) (payload.Network
 	if protocol == "" {
 		protocol = payload.ProtocolUDP
 	}
-	switch protocol {
-	case payload.ProtocolTCP:
+	switch {
+	case useMultipath(cfg, protocol):
+		log.Tracef("Running multipath %s traceroute for: %+v", protocol, cfg)
+		pathResult, err = r.runMultipath(ctx, cfg, protocol, hname, dest, maxTTL, timeout)
+		if err != nil {
+			tracerouteRunnerTelemetry.failedRuns.Inc()
+			return payload.NetworkPath{}, err
+		}
+	case protocol == payload.ProtocolTCP:
 		log.Tracef("Running TCP traceroute for: %+v", cfg)
 		pathResult, err = r.runTCP(cfg, hname, dest, maxTTL, timeout)
 		if err != nil {
 			tracerouteRunnerTelemetry.failedRuns.Inc()
 			return payload.NetworkPath{}, err
 		}
-	case payload.ProtocolUDP:
+	case protocol == payload.ProtocolUDP:
 		log.Tracef("Running UDP traceroute for: %+v", cfg)
 		pathResult, err = r.runUDP(cfg, hname, dest, maxTTL, timeout)
 		if err != nil {
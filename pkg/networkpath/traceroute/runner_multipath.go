@@ -0,0 +1,232 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package traceroute
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/traceroute/multipath"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// DefaultMultipathDelay is the delay between the probes
+// of a multipath traceroute, lower than DefaultDelay
+// since it sends more probes
+const DefaultMultipathDelay = 5 * time.Millisecond
+
+// useMultipath returns whether a traceroute needs the
+// multipath implementation, the other implementations only
+// send one SYN or UDP probe per hop along a single flow
+func useMultipath(cfg Config, protocol payload.Protocol) bool {
+	switch protocol {
+	case payload.ProtocolICMP:
+		return true
+	case payload.ProtocolTCP:
+		if cfg.TCPMethod != "" && cfg.TCPMethod != payload.TCPMethodSYN {
+			return true
+		}
+	case payload.ProtocolUDP:
+	default:
+		return false
+	}
+	return cfg.NumPaths > 1 || cfg.ProbesPerHop > 1
+}
+
+func (r *Runner) runMultipath(ctx context.Context, cfg Config, protocol payload.Protocol, hname string, target net.IP, maxTTL uint8, timeout time.Duration) (payload.NetworkPath, error) {
+	params := multipath.Params{
+		Target:       target,
+		Protocol:     protocol,
+		TCPMethod:    cfg.TCPMethod,
+		DestPort:     cfg.DestPort,
+		NumPaths:     cfg.NumPaths,
+		ProbesPerHop: cfg.ProbesPerHop,
+		MinTTL:       uint8(DefaultMinTTL),
+		MaxTTL:       maxTTL,
+		Delay:        DefaultMultipathDelay,
+		Timeout:      timeout,
+	}
+	if protocol == payload.ProtocolTCP && params.TCPMethod == "" {
+		params.TCPMethod = payload.TCPMethodSYN
+	}
+
+	results, err := multipath.Traceroute(ctx, params)
+	if err != nil {
+		return payload.NetworkPath{}, fmt.Errorf("traceroute run failed: %w", err)
+	}
+
+	pathResult := r.processMultipathResults(results, params, hname, cfg.DestHostname)
+	log.Tracef("Multipath %s Results: %+v", protocol, pathResult)
+
+	return pathResult, nil
+}
+
+func (r *Runner) processMultipathResults(res *multipath.Results, params multipath.Params, hname string, destinationHost string) payload.NetworkPath {
+	traceroutePath := payload.NetworkPath{
+		PathID:    uuid.New().String(),
+		Protocol:  params.Protocol,
+		TCPMethod: params.TCPMethod,
+		Timestamp: time.Now().UnixMilli(),
+		Source: payload.NetworkPathSource{
+			Hostname:  hname,
+			NetworkID: r.networkID,
+		},
+		Destination: payload.NetworkPathDestination{
+			Hostname:  getDestinationHostname(destinationHost),
+			IPAddress: res.Target.String(),
+		},
+	}
+	if len(res.Flows) > 0 {
+		traceroutePath.Destination.Port = res.Flows[0].DestPort
+	}
+
+	if r.gatewayLookup != nil {
+		src := util.AddressFromNetIP(res.Source)
+		dst := util.AddressFromNetIP(res.Target)
+
+		traceroutePath.Source.Via = r.gatewayLookup.LookupWithIPs(src, dst, r.nsIno)
+	}
+
+	// the flows share most of their hops, resolve each of them once
+	hostnames := make(map[string]string)
+	hostnameOf := func(ip string) string {
+		if _, ok := hostnames[ip]; !ok {
+			hostnames[ip] = getHostname(ip)
+		}
+		return hostnames[ip]
+	}
+
+	for _, flow := range res.Flows {
+		hops := multipathHops(flow, params.ProbesPerHop > 1, hostnameOf)
+		npFlow := payload.NetworkPathFlow{
+			SourcePort:      flow.SourcePort,
+			DestinationPort: flow.DestPort,
+			ICMPIdentifier:  flow.ICMPIdentifier,
+		}
+		found := false
+		for i := range traceroutePath.Paths {
+			if sameHops(traceroutePath.Paths[i].Hops, hops) {
+				traceroutePath.Paths[i].Flows = append(traceroutePath.Paths[i].Flows, npFlow)
+				found = true
+				break
+			}
+		}
+		if !found {
+			traceroutePath.Paths = append(traceroutePath.Paths, payload.NetworkPathECMP{
+				Flows: []payload.NetworkPathFlow{npFlow},
+				Hops:  hops,
+			})
+		}
+	}
+
+	if len(traceroutePath.Paths) > 0 {
+		traceroutePath.Hops = traceroutePath.Paths[0].Hops
+	}
+	// the paths are only reported when several flows were probed
+	if params.NumPaths <= 1 {
+		traceroutePath.Paths = nil
+	}
+
+	return traceroutePath
+}
+
+// multipathHops converts the hops of a flow, the IP of a hop
+// is the first IP that answered its probes
+func multipathHops(flow multipath.FlowResult, withStats bool, hostnameOf func(string) string) []payload.NetworkPathHop {
+	hops := make([]payload.NetworkPathHop, 0, len(flow.Hops))
+	for _, hop := range flow.Hops {
+		ttl := int(hop.TTL)
+		hopname := fmt.Sprintf("unknown_hop_%d", ttl)
+		npHop := payload.NetworkPathHop{
+			TTL:       ttl,
+			IPAddress: hopname,
+			Hostname:  hopname,
+		}
+
+		var rtts []float64
+		for _, probe := range hop.Probes {
+			if probe.IP == nil {
+				continue
+			}
+			if !npHop.Reachable {
+				npHop.Reachable = true
+				npHop.IPAddress = probe.IP.String()
+				npHop.Hostname = hostnameOf(npHop.IPAddress)
+			}
+			rtts = append(rtts, float64(probe.RTT.Microseconds())/float64(1000))
+		}
+
+		stats := hopStats(len(hop.Probes), rtts)
+		npHop.RTT = stats.RTTAvg
+		if withStats {
+			npHop.Stats = &stats
+		}
+		hops = append(hops, npHop)
+	}
+	return hops
+}
+
+// hopStats computes the latency distribution and loss of
+// the probes of a hop from the RTTs of the answered ones
+func hopStats(sent int, rtts []float64) payload.NetworkPathHopStats {
+	stats := payload.NetworkPathHopStats{
+		ProbesSent:     sent,
+		ProbesReceived: len(rtts),
+	}
+	if sent > 0 {
+		stats.Loss = float64(sent-len(rtts)) / float64(sent)
+	}
+	if len(rtts) == 0 {
+		return stats
+	}
+
+	sorted := append([]float64(nil), rtts...)
+	sort.Float64s(sorted)
+	stats.RTTMin = sorted[0]
+	stats.RTTMax = sorted[len(sorted)-1]
+
+	var sum float64
+	for _, rtt := range sorted {
+		sum += rtt
+	}
+	stats.RTTAvg = sum / float64(len(sorted))
+
+	var squares float64
+	for _, rtt := range sorted {
+		squares += (rtt - stats.RTTAvg) * (rtt - stats.RTTAvg)
+	}
+	stats.RTTStddev = math.Sqrt(squares / float64(len(sorted)))
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		stats.RTTMedian = (sorted[middle-1] + sorted[middle]) / 2
+	} else {
+		stats.RTTMedian = sorted[middle]
+	}
+	return stats
+}
+
+// sameHops returns whether two flows took the same path,
+// the hops that didn't answer match any hop
+func sameHops(a []payload.NetworkPathHop, b []payload.NetworkPathHop) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Reachable && b[i].Reachable && a[i].IPAddress != b[i].IPAddress {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package traceroute
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/networkpath/payload"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/traceroute/multipath"
+)
+
+func Test_useMultipath(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		protocol payload.Protocol
+		expected bool
+	}{
+		{"udp", Config{}, payload.ProtocolUDP, false},
+		{"tcp", Config{}, payload.ProtocolTCP, false},
+		{"tcp syn", Config{TCPMethod: payload.TCPMethodSYN}, payload.ProtocolTCP, false},
+		{"tcp sack", Config{TCPMethod: payload.TCPMethodSACK}, payload.ProtocolTCP, true},
+		{"icmp", Config{}, payload.ProtocolICMP, true},
+		{"udp paths", Config{NumPaths: 4}, payload.ProtocolUDP, true},
+		{"tcp probes", Config{ProbesPerHop: 3}, payload.ProtocolTCP, true},
+		{"single path and probe", Config{NumPaths: 1, ProbesPerHop: 1}, payload.ProtocolUDP, false},
+		{"invalid protocol", Config{NumPaths: 4}, "SCTP", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, useMultipath(tt.cfg, tt.protocol))
+		})
+	}
+}
+
+func Test_hopStats(t *testing.T) {
+	assert.Equal(t, payload.NetworkPathHopStats{ProbesSent: 3, Loss: 1}, hopStats(3, nil))
+
+	stats := hopStats(4, []float64{4, 1, 2})
+	assert.Equal(t, 4, stats.ProbesSent)
+	assert.Equal(t, 3, stats.ProbesReceived)
+	assert.Equal(t, 0.25, stats.Loss)
+	assert.Equal(t, 1.0, stats.RTTMin)
+	assert.Equal(t, 4.0, stats.RTTMax)
+	assert.Equal(t, 7.0/3, stats.RTTAvg)
+	assert.InDelta(t, 1.247, stats.RTTStddev, 0.001)
+	assert.Equal(t, 2.0, stats.RTTMedian)
+
+	stats = hopStats(2, []float64{1, 2})
+	assert.Equal(t, 1.5, stats.RTTMedian)
+	assert.Equal(t, 0.0, stats.Loss)
+}
+
+func Test_processMultipathResults(t *testing.T) {
+	lookupAddrFn = func(_ context.Context, addr string) ([]string, error) {
+		return []string{"host-" + addr + "."}, nil
+	}
+	defer func() { lookupAddrFn = net.DefaultResolver.LookupAddr }()
+
+	probe := func(ip string, rttMs int, isDest bool) multipath.ProbeResult {
+		return multipath.ProbeResult{IP: net.ParseIP(ip), RTT: time.Duration(rttMs) * time.Millisecond, IsDest: isDest}
+	}
+	lost := multipath.ProbeResult{}
+	res := &multipath.Results{
+		Source: net.ParseIP("10.0.0.1"),
+		Target: net.ParseIP("10.9.0.1"),
+		Flows: []multipath.FlowResult{
+			{SourcePort: 12345, DestPort: 443, Reached: true, Hops: []multipath.HopResult{
+				{TTL: 1, Probes: []multipath.ProbeResult{probe("10.1.0.1", 1, false), probe("10.1.0.1", 3, false)}},
+				{TTL: 2, Probes: []multipath.ProbeResult{probe("10.2.0.1", 2, false), lost}},
+				{TTL: 3, Probes: []multipath.ProbeResult{probe("10.9.0.1", 4, true), probe("10.9.0.1", 4, true)}},
+			}},
+			// same path as the first flow, with a hop that didn't answer
+			{SourcePort: 12346, DestPort: 443, Reached: true, Hops: []multipath.HopResult{
+				{TTL: 1, Probes: []multipath.ProbeResult{probe("10.1.0.1", 1, false), probe("10.1.0.1", 1, false)}},
+				{TTL: 2, Probes: []multipath.ProbeResult{lost, lost}},
+				{TTL: 3, Probes: []multipath.ProbeResult{probe("10.9.0.1", 4, true), probe("10.9.0.1", 4, true)}},
+			}},
+			{SourcePort: 12347, DestPort: 443, Reached: true, Hops: []multipath.HopResult{
+				{TTL: 1, Probes: []multipath.ProbeResult{probe("10.1.0.1", 1, false), probe("10.1.0.1", 1, false)}},
+				{TTL: 2, Probes: []multipath.ProbeResult{probe("10.3.0.1", 2, false), probe("10.3.0.1", 2, false)}},
+				{TTL: 3, Probes: []multipath.ProbeResult{probe("10.9.0.1", 4, true), probe("10.9.0.1", 4, true)}},
+			}},
+		},
+	}
+	params := multipath.Params{
+		Protocol:     payload.ProtocolTCP,
+		TCPMethod:    payload.TCPMethodSACK,
+		NumPaths:     3,
+		ProbesPerHop: 2,
+	}
+
+	r := &Runner{networkID: "vpc-1"}
+	path := r.processMultipathResults(res, params, "agent-host", "10.9.0.1")
+
+	assert.NotEmpty(t, path.PathID)
+	assert.Equal(t, payload.ProtocolTCP, path.Protocol)
+	assert.Equal(t, payload.TCPMethodSACK, path.TCPMethod)
+	assert.Equal(t, payload.NetworkPathSource{Hostname: "agent-host", NetworkID: "vpc-1"}, path.Source)
+	assert.Equal(t, payload.NetworkPathDestination{Hostname: "host-10.9.0.1", IPAddress: "10.9.0.1", Port: 443}, path.Destination)
+
+	require.Len(t, path.Paths, 2)
+	assert.Equal(t, []payload.NetworkPathFlow{
+		{SourcePort: 12345, DestinationPort: 443},
+		{SourcePort: 12346, DestinationPort: 443},
+	}, path.Paths[0].Flows)
+	assert.Equal(t, []payload.NetworkPathFlow{{SourcePort: 12347, DestinationPort: 443}}, path.Paths[1].Flows)
+	assert.Equal(t, path.Paths[0].Hops, path.Hops)
+
+	require.Len(t, path.Hops, 3)
+	assert.Equal(t, payload.NetworkPathHop{
+		TTL:       1,
+		IPAddress: "10.1.0.1",
+		Hostname:  "host-10.1.0.1",
+		RTT:       2,
+		Reachable: true,
+		Stats: &payload.NetworkPathHopStats{
+			ProbesSent:     2,
+			ProbesReceived: 2,
+			RTTMin:         1,
+			RTTMax:         3,
+			RTTAvg:         2,
+			RTTStddev:      1,
+			RTTMedian:      2,
+		},
+	}, path.Hops[0])
+	assert.Equal(t, 0.5, path.Hops[1].Stats.Loss)
+	assert.Equal(t, "10.3.0.1", path.Paths[1].Hops[1].IPAddress)
+
+	// a single flow is only reported in the hops
+	params.NumPaths = 1
+	res.Flows = res.Flows[1:2]
+	path = r.processMultipathResults(res, params, "agent-host", "10.9.0.1")
+	assert.Nil(t, path.Paths)
+	require.Len(t, path.Hops, 3)
+	assert.Equal(t, payload.NetworkPathHop{
+		TTL:       2,
+		IPAddress: "unknown_hop_2",
+		Hostname:  "unknown_hop_2",
+		Stats:     &payload.NetworkPathHopStats{ProbesSent: 2, Loss: 1},
+	}, path.Hops[1])
+}
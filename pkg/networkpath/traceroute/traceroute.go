@@ -34,6 +34,15 @@ type (
 		// Protocol is the protocol to use
 		// for traceroute, default is UDP
 		Protocol payload.Protocol
+		// TCPMethod is how TCP traceroutes probe
+		// the hops, default is SYN
+		TCPMethod payload.TCPMethod
+		// NumPaths is the number of flows probed
+		// to discover the equal-cost paths, default is 1
+		NumPaths uint16
+		// ProbesPerHop is the number of probes sent
+		// to each hop, default is 1
+		ProbesPerHop uint8
 	}
 
 	// Traceroute defines an interface for running
@@ -0,0 +1,391 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package openmetrics exposes the last series and sketches flushed by the aggregator
+// in the OpenMetrics (and Prometheus) text exposition format.
+package openmetrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// OpenMetricsContentType is the content type of the OpenMetrics text format
+	OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	// PrometheusContentType is the content type of the Prometheus text format
+	PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// summaryQuantiles are the quantiles exposed for each sketch
+var summaryQuantiles = []float64{0.5, 0.75, 0.9, 0.95, 0.99}
+
+// sample is a single value of the last flush, detached from the aggregator data structures
+type sample struct {
+	name   string
+	labels string
+	typ    string
+	value  float64
+}
+
+// summary holds the values exposed for a sketch
+type summary struct {
+	name      string
+	labels    string
+	quantiles []float64
+	sum       float64
+	count     float64
+}
+
+// Exporter keeps the series and sketches of the last flush and exposes them in a text format.
+//
+// Series and sketches are recorded by wrapping the sources given to the serializer, see
+// RecordSeries and RecordSketches. Only metrics whose name match the allowlist are kept.
+type Exporter struct {
+	allowlist []string
+
+	m         sync.RWMutex
+	samples   []sample
+	summaries []summary
+}
+
+// NewExporter returns a new Exporter. allowlist holds shell patterns (see path.Match) matched
+// against metric names, an empty allowlist exposes every metric.
+func NewExporter(allowlist []string) *Exporter {
+	for _, pattern := range allowlist {
+		if _, err := path.Match(pattern, ""); err != nil {
+			log.Errorf("Invalid OpenMetrics endpoint allowlist pattern %q: %v", pattern, err)
+		}
+	}
+	return &Exporter{allowlist: allowlist}
+}
+
+func (e *Exporter) allowed(name string) bool {
+	if len(e.allowlist) == 0 {
+		return true
+	}
+	for _, pattern := range e.allowlist {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordSeries returns a SerieSource reading from source and recording every serie it returns.
+// Recorded series replace the previous ones once source is exhausted.
+func (e *Exporter) RecordSeries(source metrics.SerieSource) metrics.SerieSource {
+	return &recordingSerieSource{SerieSource: source, exporter: e}
+}
+
+// RecordSketches returns a SketchesSource reading from source and recording every sketch it returns.
+// Recorded sketches replace the previous ones once source is exhausted.
+func (e *Exporter) RecordSketches(source metrics.SketchesSource) metrics.SketchesSource {
+	return &recordingSketchesSource{SketchesSource: source, exporter: e}
+}
+
+func (e *Exporter) recordSerie(samples []sample, serie *metrics.Serie) []sample {
+	if len(serie.Points) == 0 || !e.allowed(serie.Name) {
+		return samples
+	}
+	typ := "gauge"
+	if serie.MType == metrics.APICountType {
+		// counts are deltas over the flush interval, not monotonic counters
+		typ = "unknown"
+	}
+	return append(samples, sample{
+		name:   sanitizeName(serie.Name),
+		labels: formatLabels(serie.Tags.UnsafeToReadOnlySliceString(), serie.Host),
+		typ:    typ,
+		value:  serie.Points[len(serie.Points)-1].Value,
+	})
+}
+
+func (e *Exporter) recordSketch(summaries []summary, sketch *metrics.SketchSeries) []summary {
+	if len(sketch.Points) == 0 || !e.allowed(sketch.Name) {
+		return summaries
+	}
+	s := sketch.Points[len(sketch.Points)-1].Sketch
+	if s == nil {
+		return summaries
+	}
+	basic := s.Basic
+	quantiles := make([]float64, 0, len(summaryQuantiles))
+	config := quantile.Default()
+	for _, q := range summaryQuantiles {
+		quantiles = append(quantiles, s.Quantile(config, q))
+	}
+	return append(summaries, summary{
+		name:      sanitizeName(sketch.Name),
+		labels:    formatLabels(sketch.Tags.UnsafeToReadOnlySliceString(), sketch.Host),
+		quantiles: quantiles,
+		sum:       basic.Sum,
+		count:     float64(basic.Cnt),
+	})
+}
+
+func (e *Exporter) setSamples(samples []sample) {
+	// stable, so that the first recorded of the series colliding once sanitized is exposed
+	sort.SliceStable(samples, func(i, j int) bool {
+		if samples[i].name != samples[j].name {
+			return samples[i].name < samples[j].name
+		}
+		return samples[i].labels < samples[j].labels
+	})
+	e.m.Lock()
+	e.samples = samples
+	e.m.Unlock()
+}
+
+func (e *Exporter) setSummaries(summaries []summary) {
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].name != summaries[j].name {
+			return summaries[i].name < summaries[j].name
+		}
+		return summaries[i].labels < summaries[j].labels
+	})
+	e.m.Lock()
+	e.summaries = summaries
+	e.m.Unlock()
+}
+
+// WriteText writes the recorded metrics to w in the OpenMetrics text format, or in the
+// Prometheus text format when openMetrics is false.
+func (e *Exporter) WriteText(w io.Writer, openMetrics bool) error {
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	unknownType := "unknown"
+	if !openMetrics {
+		unknownType = "untyped"
+	}
+
+	// Metric names can collide once sanitized (`foo.bar` and `foo_bar`) or between series and
+	// sketches. Each name is exposed as a single family and each series once, as parsers reject
+	// the whole exposition otherwise.
+	sampleNames := make(map[string]struct{})
+	for i := 0; i < len(e.samples); {
+		name := e.samples[i].name
+		end := i + 1
+		typ := e.samples[i].typ
+		for ; end < len(e.samples) && e.samples[end].name == name; end++ {
+			if e.samples[end].typ != typ {
+				// a gauge and a count share the name, neither type holds for the family
+				typ = "unknown"
+			}
+		}
+		if typ == "unknown" {
+			typ = unknownType
+		}
+		writeType(bw, name, typ)
+		sampleNames[name] = struct{}{}
+		for j := i; j < end; j++ {
+			if j > i && e.samples[j].labels == e.samples[j-1].labels {
+				continue
+			}
+			writeSample(bw, name, e.samples[j].labels, "", e.samples[j].value)
+		}
+		i = end
+	}
+
+	for i, s := range e.summaries {
+		if i > 0 && s.name == e.summaries[i-1].name && s.labels == e.summaries[i-1].labels {
+			continue
+		}
+		if collidesWith(sampleNames, s.name, s.name+"_sum", s.name+"_count") {
+			continue
+		}
+		if i == 0 || s.name != e.summaries[i-1].name {
+			writeType(bw, s.name, "summary")
+		}
+		for qi, q := range summaryQuantiles {
+			writeSample(bw, s.name, s.labels, `quantile="`+strconv.FormatFloat(q, 'g', -1, 64)+`"`, s.quantiles[qi])
+		}
+		writeSample(bw, s.name+"_sum", s.labels, "", s.sum)
+		writeSample(bw, s.name+"_count", s.labels, "", s.count)
+	}
+
+	if openMetrics {
+		bw.WriteString("# EOF\n") //nolint:errcheck
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP implements http.Handler, the format is negotiated with the Accept header
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", OpenMetricsContentType)
+	} else {
+		w.Header().Set("Content-Type", PrometheusContentType)
+	}
+	if err := e.WriteText(w, openMetrics); err != nil {
+		log.Debugf("Unable to write OpenMetrics response: %v", err)
+	}
+}
+
+func collidesWith(names map[string]struct{}, candidates ...string) bool {
+	for _, name := range candidates {
+		if _, ok := names[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func writeType(w *bufio.Writer, name, typ string) {
+	w.WriteString("# TYPE ") //nolint:errcheck
+	w.WriteString(name)      //nolint:errcheck
+	w.WriteByte(' ')         //nolint:errcheck
+	w.WriteString(typ)       //nolint:errcheck
+	w.WriteByte('\n')        //nolint:errcheck
+}
+
+func writeSample(w *bufio.Writer, name, labels, extraLabel string, value float64) {
+	w.WriteString(name) //nolint:errcheck
+	if labels != "" || extraLabel != "" {
+		w.WriteByte('{')      //nolint:errcheck
+		w.WriteString(labels) //nolint:errcheck
+		if labels != "" && extraLabel != "" {
+			w.WriteByte(',') //nolint:errcheck
+		}
+		w.WriteString(extraLabel) //nolint:errcheck
+		w.WriteByte('}')          //nolint:errcheck
+	}
+	w.WriteByte(' ')                                       //nolint:errcheck
+	w.WriteString(strconv.FormatFloat(value, 'g', -1, 64)) //nolint:errcheck
+	w.WriteByte('\n')                                      //nolint:errcheck
+}
+
+// formatLabels turns Datadog tags into a sorted list of labels. Tags without a value become
+// labels with an empty value, and values of tags sharing the same key are joined with commas.
+func formatLabels(tags []string, host string) string {
+	values := make(map[string][]string, len(tags)+1)
+	if host != "" {
+		values["host"] = []string{host}
+	}
+	for _, tag := range tags {
+		key, value := tag, ""
+		if sep := strings.IndexByte(tag, ':'); sep >= 0 {
+			key, value = tag[:sep], tag[sep+1:]
+		}
+		key = sanitizeLabelName(key)
+		values[key] = append(values[key], value)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		vs := values[key]
+		sort.Strings(vs)
+		b.WriteString(key)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(strings.Join(vs, ",")))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+// sanitizeName turns a Datadog metric name into a valid OpenMetrics metric name,
+// replacing every invalid character (including dots) with an underscore.
+func sanitizeName(name string) string {
+	return sanitize(name, true)
+}
+
+func sanitizeLabelName(name string) string {
+	return sanitize(name, false)
+}
+
+func sanitize(name string, allowColon bool) string {
+	if name == "" {
+		return "_"
+	}
+	b := []byte(name)
+	for i, c := range b {
+		valid := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(i > 0 && c >= '0' && c <= '9') || (allowColon && c == ':')
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+type recordingSerieSource struct {
+	metrics.SerieSource
+	exporter *Exporter
+	samples  []sample
+	done     bool
+}
+
+func (s *recordingSerieSource) MoveNext() bool {
+	if s.SerieSource.MoveNext() {
+		s.samples = s.exporter.recordSerie(s.samples, s.SerieSource.Current())
+		return true
+	}
+	if !s.done {
+		s.done = true
+		s.exporter.setSamples(s.samples)
+	}
+	return false
+}
+
+type recordingSketchesSource struct {
+	metrics.SketchesSource
+	exporter  *Exporter
+	summaries []summary
+	done      bool
+}
+
+func (s *recordingSketchesSource) WaitForValue() bool {
+	if s.SketchesSource.WaitForValue() {
+		return true
+	}
+	// no sketch this flush, the previous ones are not relevant anymore
+	s.commit()
+	return false
+}
+
+func (s *recordingSketchesSource) MoveNext() bool {
+	if s.SketchesSource.MoveNext() {
+		s.summaries = s.exporter.recordSketch(s.summaries, s.SketchesSource.Current())
+		return true
+	}
+	s.commit()
+	return false
+}
+
+func (s *recordingSketchesSource) commit() {
+	if !s.done {
+		s.done = true
+		s.exporter.setSummaries(s.summaries)
+	}
+}
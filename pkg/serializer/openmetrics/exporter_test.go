@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package openmetrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+type serieSource struct {
+	series  metrics.Series
+	current int
+}
+
+func (s *serieSource) MoveNext() bool          { s.current++; return s.current <= len(s.series) }
+func (s *serieSource) Current() *metrics.Serie { return s.series[s.current-1] }
+func (s *serieSource) Count() uint64           { return uint64(len(s.series)) }
+
+type sketchesSource struct {
+	sketches metrics.SketchSeriesList
+	current  int
+}
+
+func (s *sketchesSource) MoveNext() bool                 { s.current++; return s.current <= len(s.sketches) }
+func (s *sketchesSource) Current() *metrics.SketchSeries { return s.sketches[s.current-1] }
+func (s *sketchesSource) Count() uint64                  { return uint64(len(s.sketches)) }
+func (s *sketchesSource) WaitForValue() bool             { return len(s.sketches) > 0 }
+
+func drainSeries(source metrics.SerieSource) {
+	for source.MoveNext() {
+	}
+}
+
+func drainSketches(source metrics.SketchesSource) {
+	if !source.WaitForValue() {
+		return
+	}
+	for source.MoveNext() {
+	}
+}
+
+func TestExporterSeries(t *testing.T) {
+	e := NewExporter([]string{"my.*", "other"})
+	drainSeries(e.RecordSeries(&serieSource{series: metrics.Series{
+		{Name: "my.gauge", Host: "h", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 1, Value: 1}, {Ts: 2, Value: 2.5}},
+			Tags: tagset.CompositeTagsFromSlice([]string{"env:prod", "team:a", "team:b", "bare", `q:"x"`})},
+		{Name: "my.count", MType: metrics.APICountType, Points: []metrics.Point{{Ts: 1, Value: 3}}},
+		{Name: "filtered.out", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 1, Value: 3}}},
+	}}))
+
+	var buf bytes.Buffer
+	require.NoError(t, e.WriteText(&buf, true))
+	assert.Equal(t, `# TYPE my_count unknown
+my_count 3
+# TYPE my_gauge gauge
+my_gauge{bare="",env="prod",host="h",q="\"x\"",team="a,b"} 2.5
+# EOF
+`, buf.String())
+
+	// a new flush replaces the previous series
+	drainSeries(e.RecordSeries(&serieSource{series: metrics.Series{
+		{Name: "other", MType: metrics.APIRateType, Points: []metrics.Point{{Ts: 1, Value: 1}}},
+	}}))
+	buf.Reset()
+	require.NoError(t, e.WriteText(&buf, false))
+	assert.Equal(t, "# TYPE other gauge\nother 1\n", buf.String())
+}
+
+func TestExporterSketches(t *testing.T) {
+	e := NewExporter(nil)
+	sketch := &quantile.Sketch{}
+	sketch.Insert(quantile.Default(), 1, 2, 3, 4)
+	drainSketches(e.RecordSketches(&sketchesSource{sketches: metrics.SketchSeriesList{
+		{Name: "my.dist", Points: []metrics.SketchPoint{{Ts: 1, Sketch: sketch}}},
+	}}))
+
+	var buf bytes.Buffer
+	require.NoError(t, e.WriteText(&buf, false))
+	assert.Contains(t, buf.String(), "# TYPE my_dist summary\n")
+	assert.Contains(t, buf.String(), `my_dist{quantile="0.5"} `)
+	assert.Contains(t, buf.String(), "my_dist_sum 10\nmy_dist_count 4\n")
+
+	// no sketch in a flush clears the previous ones
+	drainSketches(e.RecordSketches(&sketchesSource{}))
+	buf.Reset()
+	require.NoError(t, e.WriteText(&buf, false))
+	assert.Empty(t, buf.String())
+}
+
+func TestExporterCollisions(t *testing.T) {
+	e := NewExporter(nil)
+	drainSeries(e.RecordSeries(&serieSource{series: metrics.Series{
+		{Name: "foo.bar", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 1, Value: 1}}},
+		{Name: "foo_bar", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 1, Value: 2}}},
+		{Name: "foo_bar", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 1, Value: 3}},
+			Tags: tagset.CompositeTagsFromSlice([]string{"env:prod"})},
+		{Name: "mixed", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 1, Value: 4}}},
+		{Name: "mixed", MType: metrics.APICountType, Points: []metrics.Point{{Ts: 1, Value: 5}},
+			Tags: tagset.CompositeTagsFromSlice([]string{"env:prod"})},
+		{Name: "dist", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 1, Value: 6}}},
+	}}))
+	sketch := &quantile.Sketch{}
+	sketch.Insert(quantile.Default(), 1)
+	drainSketches(e.RecordSketches(&sketchesSource{sketches: metrics.SketchSeriesList{
+		{Name: "dist", Points: []metrics.SketchPoint{{Ts: 1, Sketch: sketch}}},
+		{Name: "other.dist", Points: []metrics.SketchPoint{{Ts: 1, Sketch: sketch}}},
+		{Name: "other_dist", Points: []metrics.SketchPoint{{Ts: 1, Sketch: sketch}}},
+	}}))
+
+	var buf bytes.Buffer
+	require.NoError(t, e.WriteText(&buf, false))
+	assert.Equal(t, `# TYPE dist gauge
+dist 6
+# TYPE foo_bar gauge
+foo_bar 1
+foo_bar{env="prod"} 3
+# TYPE mixed untyped
+mixed 4
+mixed{env="prod"} 5
+# TYPE other_dist summary
+other_dist{quantile="0.5"} 1
+other_dist{quantile="0.75"} 1
+other_dist{quantile="0.9"} 1
+other_dist{quantile="0.95"} 1
+other_dist{quantile="0.99"} 1
+other_dist_sum 1
+other_dist_count 1
+`, buf.String())
+}
+
+func TestExporterServeHTTP(t *testing.T) {
+	e := NewExporter(nil)
+	drainSeries(e.RecordSeries(&serieSource{series: metrics.Series{
+		{Name: "my.gauge", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 1, Value: 1}}},
+	}}))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, OpenMetricsContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "# TYPE my_gauge gauge\nmy_gauge 1\n# EOF\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, PrometheusContentType, rec.Header().Get("Content-Type"))
+}
+
+func TestSanitizeName(t *testing.T) {
+	assert.Equal(t, "my_metric_name:total", sanitizeName("my.metric-name:total"))
+	assert.Equal(t, "_x", sanitizeLabelName("1x"))
+	assert.Equal(t, "_", sanitizeName(""))
+}
@@ -11,12 +11,17 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	"github.com/DataDog/datadog-agent/comp/serializer/compression"
 	"github.com/DataDog/datadog-agent/comp/serializer/compression/compressionimpl"
+	"github.com/DataDog/datadog-agent/comp/serializer/compression/compressionimpl/strategy"
 	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/metrics/event"
 	metricsserializer "github.com/DataDog/datadog-agent/pkg/serializer/internal/metrics"
 	"github.com/DataDog/datadog-agent/pkg/serializer/internal/stream"
+	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
 )
 
@@ -125,3 +130,67 @@ func BenchmarkSplit10000(b *testing.B)    { benchmarkSplit(b, 10000) }
 func BenchmarkSplit100000(b *testing.B)   { benchmarkSplit(b, 100000) }
 func BenchmarkSplit1000000(b *testing.B)  { benchmarkSplit(b, 1000000) }
 func BenchmarkSplit10000000(b *testing.B) { benchmarkSplit(b, 10000000) }
+
+// compressionStrategies are the strategies compared by BenchmarkCompression
+var compressionStrategies = []struct {
+	name     string
+	strategy compression.Component
+}{
+	{"zlib-1", strategy.NewZlibStrategyWithLevel(1)},
+	{"zlib-6", strategy.NewZlibStrategyWithLevel(6)},
+	{"zlib-9", strategy.NewZlibStrategyWithLevel(9)},
+	{"zstd-1", strategy.NewZstdStrategy(1)},
+	{"zstd-3", strategy.NewZstdStrategy(3)},
+	{"zstd-5", strategy.NewZstdStrategy(5)},
+	{"zstd-9", strategy.NewZstdStrategy(9)},
+	{"zstd-19", strategy.NewZstdStrategy(19)},
+}
+
+// BenchmarkCompression compares the CPU cost and the compressed size of metric and event
+// payloads for each compression strategy and level. The "ratio" metric is the uncompressed
+// size divided by the compressed size, it is only reported for JSON payloads.
+func BenchmarkCompression(b *testing.B) {
+	reportSizes := func(b *testing.B, uncompressedSize int, payloads transaction.BytesPayloads) {
+		compressedSize := 0
+		for _, pl := range payloads {
+			compressedSize += pl.Len()
+		}
+		b.ReportMetric(float64(len(payloads)), "payloads")
+		b.ReportMetric(float64(compressedSize), "compressed-payload-bytes")
+		if uncompressedSize > 0 {
+			b.ReportMetric(float64(uncompressedSize)/float64(compressedSize), "ratio")
+		}
+	}
+
+	mockConfig := pkgconfigsetup.Conf()
+	for _, items := range []int{100, 10000} {
+		series := generateData(10, items, 20)
+		events := buildEvents(items)
+		uncompressedEvents, err := split.JSONMarshalFct(events)
+		require.NoError(b, err)
+
+		for _, tc := range compressionStrategies {
+			b.Run(fmt.Sprintf("%05d-items/%s/series", items, tc.name), func(b *testing.B) {
+				bufferContext := marshaler.NewBufferContext()
+				b.ReportAllocs()
+				b.ResetTimer()
+				for n := 0; n < b.N; n++ {
+					iterableSeries := metricsserializer.CreateIterableSeries(metricsserializer.CreateSerieSource(series))
+					results, err = iterableSeries.MarshalSplitCompress(bufferContext, mockConfig, tc.strategy)
+					require.NoError(b, err)
+				}
+				reportSizes(b, 0, results)
+			})
+			b.Run(fmt.Sprintf("%05d-items/%s/events", items, tc.name), func(b *testing.B) {
+				payloadBuilder := stream.NewJSONPayloadBuilder(true, mockConfig, tc.strategy)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for n := 0; n < b.N; n++ {
+					results, err = stream.BuildJSONPayload(payloadBuilder, events.CreateSingleMarshaler())
+					require.NoError(b, err)
+				}
+				reportSizes(b, len(uncompressedEvents), results)
+			})
+		}
+	}
+}
@@ -108,12 +108,7 @@ func Payloads(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFc
 			if e != nil {
 				return smallEnoughPayloads, e
 			}
-			payloadSize := len(payload)
-			compressedSize := len(compressedPayload)
-			// Attempt to account for the compression when estimating the number of chunks that will be needed
-			// This is the same function used in dd-agent
-			compressionRatio := float64(payloadSize) / float64(compressedSize)
-			numChunks := compressedSize/maxPayloadSizeCompressed + 1 + int(compressionRatio/2)
+			numChunks := chunkCount(len(payload), len(compressedPayload), strategy.ContentEncoding())
 			log.Debugf("split the payload into into %d chunks", numChunks)
 			chunks, err := toSplit.SplitPayload(numChunks)
 			log.Debugf("payload was split into %d chunks", len(chunks))
@@ -159,6 +154,32 @@ func Payloads(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFc
 	return smallEnoughPayloads, nil
 }
 
+// chunkCount estimates the number of chunks a payload must be split into for every chunk to
+// fit in the size limits. Chunks compress less efficiently than the whole payload, the estimate
+// adds a margin growing with the compression ratio to account for it.
+func chunkCount(payloadSize, compressedSize int, contentEncoding string) int {
+	if compressedSize == 0 {
+		return 1
+	}
+	compressionRatio := float64(payloadSize) / float64(compressedSize)
+	numChunks := compressedSize/maxPayloadSizeCompressed + 1 + int(compressionRatio/compressionRatioMarginDivisor(contentEncoding))
+	if minChunks := payloadSize/maxPayloadSizeUnCompressed + 1; numChunks < minChunks {
+		numChunks = minChunks
+	}
+	return numChunks
+}
+
+// compressionRatioMarginDivisor returns how much of the compression ratio is added as a margin
+// when estimating the number of chunks. zlib uses the same estimate as dd-agent. zstd reaches
+// about twice the zlib ratio on metric payloads while losing about as much on smaller chunks,
+// so the same margin would split payloads in twice as many chunks as needed.
+func compressionRatioMarginDivisor(contentEncoding string) float64 {
+	if contentEncoding == compression.ZstdEncoding {
+		return 4
+	}
+	return 2
+}
+
 // serializeMarshaller serializes the marshaller and returns both the compressed and uncompressed payloads
 func serializeMarshaller(m marshaler.AbstractMarshaler, compress bool, marshalFct MarshalFct, strategy compression.Component) ([]byte, []byte, error) {
 	var payload []byte
@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	"github.com/DataDog/datadog-agent/comp/serializer/compression"
 	"github.com/DataDog/datadog-agent/comp/serializer/compression/compressionimpl"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/metrics/event"
@@ -322,3 +323,20 @@ func testSplitPayloadsServiceChecks(t *testing.T, numPoints int, compress bool)
 
 	}
 }
+
+func TestChunkCount(t *testing.T) {
+	prevMaxPayloadSizeCompressed := maxPayloadSizeCompressed
+	maxPayloadSizeCompressed = 1024
+	defer func() { maxPayloadSizeCompressed = prevMaxPayloadSizeCompressed }()
+
+	prevMaxPayloadSizeUnCompressed := maxPayloadSizeUnCompressed
+	maxPayloadSizeUnCompressed = 8 * 1024
+	defer func() { maxPayloadSizeUnCompressed = prevMaxPayloadSizeUnCompressed }()
+
+	// ratio of 4: 3 chunks for the compressed size and a margin of 2 for zlib, 1 for zstd
+	require.Equal(t, 5, chunkCount(4*2048, 2048, compression.ZlibEncoding))
+	require.Equal(t, 4, chunkCount(4*2048, 2048, compression.ZstdEncoding))
+	// uncompressed payloads don't get any margin
+	require.Equal(t, 21, chunkCount(20*1024, 20*1024, ""))
+	require.Equal(t, 1, chunkCount(0, 0, compression.ZlibEncoding))
+}
@@ -21,6 +21,7 @@ import (
 	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
 
 	"github.com/DataDog/datadog-agent/pkg/snmp/gosnmplib"
+	"github.com/DataDog/datadog-agent/pkg/snmp/snmpdiscovery"
 	"github.com/DataDog/datadog-agent/pkg/snmp/snmpintegration"
 )
 
@@ -44,6 +45,10 @@ type ListenerConfig struct {
 	Configs               []Config                   `mapstructure:"configs"`
 	PingConfig            snmpintegration.PingConfig `mapstructure:"ping"`
 
+	// Discovery defaults of the configs, see snmpdiscovery
+	DiscoveryMode         string                          `mapstructure:"discovery_mode"`
+	DiscoveryFingerprints []snmpdiscovery.FingerprintRule `mapstructure:"discovery_fingerprints"`
+
 	// legacy
 	AllowedFailuresLegacy int `mapstructure:"allowed_failures"`
 }
@@ -82,6 +87,13 @@ type Config struct {
 
 	PingConfig snmpintegration.PingConfig `mapstructure:"ping"`
 
+	// DiscoveryMode is how the candidate devices of the network are found, see snmpdiscovery.Mode
+	DiscoveryMode  string   `mapstructure:"discovery_mode"`
+	DiscoverySeeds []string `mapstructure:"discovery_seeds"`
+	// DiscoveryCredentials are tried in addition to the credentials of the config
+	DiscoveryCredentials  []snmpdiscovery.Credentials     `mapstructure:"discovery_credentials"`
+	DiscoveryFingerprints []snmpdiscovery.FingerprintRule `mapstructure:"discovery_fingerprints"`
+
 	// Legacy
 	NetworkLegacy      string `mapstructure:"network"`
 	VersionLegacy      string `mapstructure:"version"`
@@ -182,6 +194,11 @@ func NewListenerConfig() (ListenerConfig, error) {
 		config.PingConfig.Timeout = firstNonNil(config.PingConfig.Timeout, snmpConfig.PingConfig.Timeout)
 		config.PingConfig.Count = firstNonNil(config.PingConfig.Count, snmpConfig.PingConfig.Count)
 
+		config.DiscoveryMode = firstNonEmpty(config.DiscoveryMode, snmpConfig.DiscoveryMode)
+		if len(config.DiscoveryFingerprints) == 0 {
+			config.DiscoveryFingerprints = snmpConfig.DiscoveryFingerprints
+		}
+
 		config.Namespace = firstNonEmpty(config.Namespace, snmpConfig.Namespace, coreconfig.Datadog().GetString("network_devices.namespace"))
 		config.Community = firstNonEmpty(config.Community, config.CommunityLegacy)
 		config.AuthKey = firstNonEmpty(config.AuthKey, config.AuthKeyLegacy)
@@ -281,6 +298,25 @@ func (c *Config) BuildSNMPParams(deviceIP string) (*gosnmp.GoSNMP, error) {
 	}, nil
 }
 
+// CredentialSets returns the config for the credentials of the config followed by each of its discovery credentials sets
+func (c *Config) CredentialSets() []Config {
+	configs := make([]Config, 0, 1+len(c.DiscoveryCredentials))
+	configs = append(configs, *c)
+	for _, credentials := range c.DiscoveryCredentials {
+		config := *c
+		config.Version = credentials.Version
+		config.Community = credentials.Community
+		config.User = credentials.User
+		config.AuthKey = credentials.AuthKey
+		config.AuthProtocol = credentials.AuthProtocol
+		config.PrivKey = credentials.PrivKey
+		config.PrivProtocol = credentials.PrivProtocol
+		config.ContextName = credentials.ContextName
+		configs = append(configs, config)
+	}
+	return configs
+}
+
 // IsIPIgnored checks the given IP against IgnoredIPAddresses
 func (c *Config) IsIPIgnored(ip net.IP) bool {
 	ipString := ip.String()
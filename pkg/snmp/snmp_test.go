@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/DataDog/datadog-agent/pkg/snmp/snmpdiscovery"
 	"github.com/DataDog/datadog-agent/pkg/snmp/snmpintegration"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -360,6 +361,56 @@ network_devices:
 
 }
 
+func Test_DiscoveryConfig(t *testing.T) {
+	config.Datadog().SetConfigType("yaml")
+	err := config.Datadog().ReadConfig(strings.NewReader(`
+network_devices:
+  autodiscovery:
+    discovery_mode: hybrid
+    discovery_fingerprints:
+     - sysobjectid: 1.3.6.1.4.1.9.*
+       profile: cisco
+    configs:
+     - network: 127.1.0.0/30
+       community_string: public
+       discovery_seeds:
+        - 127.1.0.1
+       discovery_credentials:
+        - community_string: private
+          snmp_version: 1
+        - user: admin
+          authProtocol: sha
+          authKey: secret
+     - network: 127.2.0.0/30
+       discovery_mode: sweep
+       discovery_fingerprints:
+        - sysdescr: NX-OS
+          profile: cisco-nexus
+`))
+	assert.NoError(t, err)
+
+	conf, err := NewListenerConfig()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "hybrid", conf.Configs[0].DiscoveryMode)
+	assert.Equal(t, []string{"127.1.0.1"}, conf.Configs[0].DiscoverySeeds)
+	assert.Equal(t, []snmpdiscovery.FingerprintRule{{SysObjectID: "1.3.6.1.4.1.9.*", Profile: "cisco"}}, conf.Configs[0].DiscoveryFingerprints)
+	assert.Equal(t, "sweep", conf.Configs[1].DiscoveryMode)
+	assert.Equal(t, []snmpdiscovery.FingerprintRule{{SysDescr: "NX-OS", Profile: "cisco-nexus"}}, conf.Configs[1].DiscoveryFingerprints)
+
+	configs := conf.Configs[0].CredentialSets()
+	assert.Len(t, configs, 3)
+	assert.Equal(t, "public", configs[0].Community)
+	assert.Equal(t, "private", configs[1].Community)
+	assert.Equal(t, "1", configs[1].Version)
+	assert.Equal(t, "127.1.0.0/30", configs[1].Network)
+	assert.Equal(t, "", configs[2].Community)
+	assert.Equal(t, "admin", configs[2].User)
+	assert.Equal(t, "sha", configs[2].AuthProtocol)
+	assert.Equal(t, "secret", configs[2].AuthKey)
+	assert.Len(t, conf.Configs[1].CredentialSets(), 1)
+}
+
 func Test_MinCollectionInterval(t *testing.T) {
 	config.Datadog().SetConfigType("yaml")
 	err := config.Datadog().ReadConfig(strings.NewReader(`
@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package snmpdiscovery
+
+import (
+	"net"
+	"sync"
+)
+
+// Source is how a candidate device was found
+type Source string
+
+const (
+	// SourceSeed is a configured seed
+	SourceSeed Source = "seed"
+	// SourceInventory is a device discovered in a previous run
+	SourceInventory Source = "inventory"
+	// SourceARP is the ARP table of a neighbor
+	SourceARP Source = "arp"
+	// SourceND is the IPv6 neighbor discovery table of a neighbor
+	SourceND Source = "nd"
+	// SourceLLDP is the LLDP remote management addresses of a neighbor
+	SourceLLDP Source = "lldp"
+	// SourceCDP is the CDP cache of a neighbor
+	SourceCDP Source = "cdp"
+	// SourceSweep is the sweep of the subnet
+	SourceSweep Source = "sweep"
+)
+
+// Candidate is an IP to probe
+type Candidate struct {
+	IP     net.IP
+	Source Source
+}
+
+// CandidateQueue is the queue of the candidates of a discovery run, each IP is only queued once.
+// The run is over when the queue is empty and no candidate is being probed, since probing a candidate
+// can add its neighbors to the queue.
+type CandidateQueue struct {
+	mu        sync.Mutex
+	network   net.IPNet
+	isIgnored func(net.IP) bool
+	seen      map[string]struct{}
+	pending   []Candidate
+	inFlight  int
+	notify    chan struct{}
+}
+
+// NewCandidateQueue creates a CandidateQueue accepting the IPs of the network that aren't ignored
+func NewCandidateQueue(network net.IPNet, isIgnored func(net.IP) bool) *CandidateQueue {
+	return &CandidateQueue{
+		network:   network,
+		isIgnored: isIgnored,
+		seen:      make(map[string]struct{}),
+		notify:    make(chan struct{}),
+	}
+}
+
+// Add queues an IP, it returns false if the IP is out of the network, ignored or was already queued
+func (q *CandidateQueue) Add(ip net.IP, source Source) bool {
+	if !q.network.Contains(ip) || (q.isIgnored != nil && q.isIgnored(ip)) {
+		return false
+	}
+	key := ip.String()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.seen[key]; ok {
+		return false
+	}
+	q.seen[key] = struct{}{}
+	q.pending = append(q.pending, Candidate{IP: ip, Source: source})
+	q.signal()
+	return true
+}
+
+// Next pops the next candidate, it must be followed by a call to Done once the candidate is probed.
+// When no candidate is pending, ok is false and wait is closed once the queue changes,
+// wait is nil once the run is over.
+func (q *CandidateQueue) Next() (candidate Candidate, ok bool, wait <-chan struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) > 0 {
+		candidate = q.pending[0]
+		q.pending = q.pending[1:]
+		q.inFlight++
+		return candidate, true, nil
+	}
+	if q.inFlight == 0 {
+		return Candidate{}, false, nil
+	}
+	return Candidate{}, false, q.notify
+}
+
+// Done marks a candidate returned by Next as probed
+func (q *CandidateQueue) Done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.inFlight--
+	q.signal()
+}
+
+// Seen returns whether the IP was queued
+func (q *CandidateQueue) Seen(ip net.IP) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.seen[ip.String()]
+	return ok
+}
+
+// Len returns the number of IPs queued during the run
+func (q *CandidateQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.seen)
+}
+
+func (q *CandidateQueue) signal() {
+	close(q.notify)
+	q.notify = make(chan struct{})
+}
+
+// Run is a discovery run over the network of a subnet
+type Run struct {
+	Network net.IPNet
+	// IsIgnored returns whether an IP of the network must not be probed
+	IsIgnored func(net.IP) bool
+	Config    Config
+	// Known are the IPs of the devices discovered by the previous runs, the neighbors discovery starts from them
+	// and the seeds
+	Known []net.IP
+}
+
+// ScheduleCandidates schedules the candidates of a discovery run. With the neighbors modes, the seeds and the known
+// devices are scheduled first, then the neighbors found while probing them until none is left. With the sweep modes,
+// the IPs of the network that weren't scheduled yet come next.
+//
+// schedule hands a candidate to a worker, with the queue the neighbors of the candidate must be added to, or nil when
+// sweeping. The worker calls Done on the queue once the candidate is probed. schedule returns false when the discovery
+// is stopped, then ScheduleCandidates returns false too. stop interrupts the wait for the candidates being probed.
+func ScheduleCandidates[S any](run Run, stop <-chan S, schedule func(candidate Candidate, candidates *CandidateQueue) bool) bool {
+	var candidates *CandidateQueue
+	if run.Config.Mode.UsesNeighbors() {
+		candidates = NewCandidateQueue(run.Network, run.IsIgnored)
+		for _, seed := range run.Config.Seeds {
+			candidates.Add(seed, SourceSeed)
+		}
+		for _, ip := range run.Known {
+			candidates.Add(ip, SourceInventory)
+		}
+		if !scheduleQueue(candidates, stop, schedule) {
+			return false
+		}
+	}
+	if run.Config.Mode == ModeNeighbors {
+		return true
+	}
+	return sweep(run, candidates, schedule)
+}
+
+// scheduleQueue schedules the candidates until all of them and their neighbors are probed
+func scheduleQueue[S any](candidates *CandidateQueue, stop <-chan S, schedule func(Candidate, *CandidateQueue) bool) bool {
+	for {
+		candidate, ok, wait := candidates.Next()
+		if !ok {
+			if wait == nil {
+				return true
+			}
+			select {
+			case <-wait:
+				continue
+			case <-stop:
+				return false
+			}
+		}
+		if !schedule(candidate, candidates) {
+			return false
+		}
+	}
+}
+
+// sweep schedules the IPs of the network that weren't already scheduled from the candidates
+func sweep(run Run, candidates *CandidateQueue, schedule func(Candidate, *CandidateQueue) bool) bool {
+	currentIP := run.Network.IP.Mask(run.Network.Mask)
+	for ; run.Network.Contains(currentIP); incrementIP(currentIP) {
+		if run.IsIgnored != nil && run.IsIgnored(currentIP) {
+			continue
+		}
+		if candidates != nil && candidates.Seen(currentIP) {
+			continue
+		}
+
+		ip := make(net.IP, len(currentIP))
+		copy(ip, currentIP)
+		if !schedule(Candidate{IP: ip, Source: SourceSweep}, nil) {
+			return false
+		}
+	}
+	return true
+}
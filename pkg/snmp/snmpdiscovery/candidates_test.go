@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package snmpdiscovery
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCandidateQueue(t *testing.T) {
+	_, network, _ := net.ParseCIDR("10.0.0.0/24")
+	q := NewCandidateQueue(*network, func(ip net.IP) bool { return ip.Equal(net.IP{10, 0, 0, 9}) })
+
+	assert.True(t, q.Add(net.ParseIP("10.0.0.1"), SourceSeed))
+	assert.False(t, q.Add(net.ParseIP("10.0.0.1"), SourceARP), "already queued")
+	assert.False(t, q.Add(net.ParseIP("10.0.1.1"), SourceARP), "out of the network")
+	assert.False(t, q.Add(net.ParseIP("10.0.0.9"), SourceARP), "ignored")
+
+	candidate, ok, wait := q.Next()
+	require.True(t, ok)
+	assert.Nil(t, wait)
+	assert.Equal(t, "10.0.0.1", candidate.IP.String())
+	assert.Equal(t, SourceSeed, candidate.Source)
+
+	// the candidate is being probed, the run isn't over
+	_, ok, wait = q.Next()
+	assert.False(t, ok)
+	require.NotNil(t, wait)
+
+	// probing the candidate found a neighbor
+	assert.True(t, q.Add(net.ParseIP("10.0.0.2"), SourceLLDP))
+	<-wait
+	q.Done()
+
+	candidate, ok, _ = q.Next()
+	require.True(t, ok)
+	assert.Equal(t, "10.0.0.2", candidate.IP.String())
+	assert.Equal(t, SourceLLDP, candidate.Source)
+
+	_, ok, wait = q.Next()
+	assert.False(t, ok)
+	require.NotNil(t, wait)
+	q.Done()
+	<-wait
+
+	// nothing pending and nothing being probed, the run is over
+	_, ok, wait = q.Next()
+	assert.False(t, ok)
+	assert.Nil(t, wait)
+
+	assert.True(t, q.Seen(net.ParseIP("10.0.0.2")))
+	assert.False(t, q.Seen(net.ParseIP("10.0.0.3")))
+	assert.Equal(t, 2, q.Len())
+}
+
+func TestScheduleCandidates(t *testing.T) {
+	_, network, _ := net.ParseCIDR("10.0.0.0/29")
+	run := Run{
+		Network:   *network,
+		IsIgnored: func(ip net.IP) bool { return ip.Equal(net.IP{10, 0, 0, 6}) },
+		Config:    Config{Mode: ModeHybrid, Seeds: []net.IP{net.ParseIP("10.0.0.5")}},
+		Known:     []net.IP{net.ParseIP("10.0.0.1")},
+	}
+
+	var scheduled []Candidate
+	schedule := func(candidate Candidate, candidates *CandidateQueue) bool {
+		scheduled = append(scheduled, candidate)
+		if candidates != nil {
+			// probing the seed finds a neighbor
+			if candidate.Source == SourceSeed {
+				candidates.Add(net.ParseIP("10.0.0.3"), SourceLLDP)
+			}
+			candidates.Done()
+		}
+		return true
+	}
+	assert.True(t, ScheduleCandidates(run, make(chan struct{}), schedule))
+	assert.Equal(t, []Candidate{
+		{IP: net.ParseIP("10.0.0.5"), Source: SourceSeed},
+		{IP: net.ParseIP("10.0.0.1"), Source: SourceInventory},
+		{IP: net.ParseIP("10.0.0.3"), Source: SourceLLDP},
+		// the IPs of the network that weren't probed yet
+		{IP: net.IP{10, 0, 0, 0}, Source: SourceSweep},
+		{IP: net.IP{10, 0, 0, 2}, Source: SourceSweep},
+		{IP: net.IP{10, 0, 0, 4}, Source: SourceSweep},
+		{IP: net.IP{10, 0, 0, 7}, Source: SourceSweep},
+	}, scheduled)
+
+	// the network isn't swept in neighbors mode
+	run.Config.Mode = ModeNeighbors
+	scheduled = nil
+	assert.True(t, ScheduleCandidates(run, make(chan struct{}), schedule))
+	assert.Len(t, scheduled, 3)
+
+	// and only swept in sweep mode
+	run.Config.Mode = ModeSweep
+	scheduled = nil
+	assert.True(t, ScheduleCandidates(run, make(chan struct{}), schedule))
+	assert.Len(t, scheduled, 7)
+	for _, candidate := range scheduled {
+		assert.Equal(t, SourceSweep, candidate.Source)
+	}
+
+	// stopping the discovery stops the run
+	scheduled = nil
+	assert.False(t, ScheduleCandidates(run, make(chan struct{}), func(candidate Candidate, _ *CandidateQueue) bool {
+		scheduled = append(scheduled, candidate)
+		return false
+	}))
+	assert.Len(t, scheduled, 1)
+
+	// as well as while waiting for the candidates being probed
+	run.Config.Mode = ModeNeighbors
+	stop := make(chan struct{})
+	close(stop)
+	assert.False(t, ScheduleCandidates(run, stop, func(Candidate, *CandidateQueue) bool { return true }))
+}
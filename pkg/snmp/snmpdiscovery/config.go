@@ -0,0 +1,194 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package snmpdiscovery contains the building blocks of the SNMP device discovery shared by the SNMP check
+// and the SNMP autodiscovery listener: candidate devices from neighbor tables, concurrent credential probing,
+// fingerprinting and the persisted inventory.
+package snmpdiscovery
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+)
+
+// Mode is how the candidate devices of a subnet are found
+type Mode string
+
+const (
+	// ModeSweep probes every IP of the subnet
+	ModeSweep Mode = "sweep"
+	// ModeNeighbors probes the seeds, then the neighbors found in the ARP/ND and LLDP/CDP tables of the discovered devices
+	ModeNeighbors Mode = "neighbors"
+	// ModeHybrid discovers the neighbors first, then sweeps the IPs of the subnet that weren't probed
+	ModeHybrid Mode = "hybrid"
+)
+
+// Settings are the discovery settings of a subnet, as configured on the SNMP check instances and the SNMP
+// autodiscovery configs
+type Settings struct {
+	Mode         string
+	Seeds        []string
+	Credentials  []Credentials
+	Fingerprints []FingerprintRule
+}
+
+// Config is the validated discovery configuration of a subnet
+type Config struct {
+	Mode  Mode
+	Seeds []net.IP
+	// Credentials are tried in addition to the credentials of the subnet
+	Credentials  []Credentials
+	Fingerprints []FingerprintRule
+}
+
+// Parse validates the settings and returns the discovery configuration
+func (s Settings) Parse() (Config, error) {
+	var c Config
+	var err error
+	if c.Mode, err = parseMode(s.Mode); err != nil {
+		return Config{}, err
+	}
+	if c.Seeds, err = parseSeeds(s.Seeds); err != nil {
+		return Config{}, err
+	}
+	if c.Mode == ModeNeighbors && len(c.Seeds) == 0 {
+		return Config{}, fmt.Errorf("`discovery_seeds` must be set when `discovery_mode` is `%s`", ModeNeighbors)
+	}
+	if err = validateCredentials(s.Credentials); err != nil {
+		return Config{}, err
+	}
+	c.Credentials = s.Credentials
+	if c.Fingerprints, err = compileFingerprintRules(s.Fingerprints); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// parseMode parses a discovery mode, the default is ModeSweep
+func parseMode(mode string) (Mode, error) {
+	switch Mode(mode) {
+	case "":
+		return ModeSweep, nil
+	case ModeSweep, ModeNeighbors, ModeHybrid:
+		return Mode(mode), nil
+	}
+	return "", fmt.Errorf("invalid discovery mode %q, must be %q, %q or %q", mode, ModeSweep, ModeNeighbors, ModeHybrid)
+}
+
+// UsesNeighbors returns whether the mode discovers devices from neighbor tables
+func (m Mode) UsesNeighbors() bool {
+	return m == ModeNeighbors || m == ModeHybrid
+}
+
+// parseSeeds parses the IPs the neighbors discovery starts from
+func parseSeeds(seeds []string) ([]net.IP, error) {
+	ips := make([]net.IP, 0, len(seeds))
+	for _, seed := range seeds {
+		ip := net.ParseIP(seed)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid discovery seed %q, must be an IP address", seed)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// Credentials is an additional set of credentials tried on the candidate devices.
+// A set replaces the credentials of the subnet, it isn't merged with them.
+type Credentials struct {
+	Version      string `yaml:"snmp_version" mapstructure:"snmp_version"`
+	Community    string `yaml:"community_string" mapstructure:"community_string"`
+	User         string `yaml:"user" mapstructure:"user"`
+	AuthKey      string `yaml:"authKey" mapstructure:"authKey"`
+	AuthProtocol string `yaml:"authProtocol" mapstructure:"authProtocol"`
+	PrivKey      string `yaml:"privKey" mapstructure:"privKey"`
+	PrivProtocol string `yaml:"privProtocol" mapstructure:"privProtocol"`
+	ContextName  string `yaml:"context_name" mapstructure:"context_name"`
+}
+
+// validateCredentials checks that each credentials set has an authentication method
+func validateCredentials(credentials []Credentials) error {
+	for i, creds := range credentials {
+		if creds.Community == "" && creds.User == "" {
+			return fmt.Errorf("discovery credentials %d: community_string or user must be set", i)
+		}
+	}
+	return nil
+}
+
+// FingerprintRule selects the profile of the devices matching its sysObjectID and sysDescr patterns
+type FingerprintRule struct {
+	// SysObjectID is a glob pattern, e.g. `1.3.6.1.4.1.9.*`
+	SysObjectID string `yaml:"sysobjectid" mapstructure:"sysobjectid"`
+	// SysDescr is a regular expression
+	SysDescr string `yaml:"sysdescr" mapstructure:"sysdescr"`
+	Profile  string `yaml:"profile" mapstructure:"profile"`
+
+	sysDescrPattern *regexp.Regexp
+}
+
+// compileFingerprintRules validates the rules and compiles their patterns, rules are matched in order
+func compileFingerprintRules(rules []FingerprintRule) ([]FingerprintRule, error) {
+	compiled := make([]FingerprintRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Profile == "" {
+			return nil, fmt.Errorf("discovery fingerprint %d: profile must be set", i)
+		}
+		if rule.SysObjectID == "" && rule.SysDescr == "" {
+			return nil, fmt.Errorf("discovery fingerprint %d: sysobjectid or sysdescr must be set", i)
+		}
+		if rule.SysObjectID != "" {
+			if _, err := path.Match(rule.SysObjectID, ""); err != nil {
+				return nil, fmt.Errorf("discovery fingerprint %d: invalid sysobjectid pattern %q: %s", i, rule.SysObjectID, err)
+			}
+		}
+		if rule.SysDescr != "" {
+			pattern, err := regexp.Compile(rule.SysDescr)
+			if err != nil {
+				return nil, fmt.Errorf("discovery fingerprint %d: invalid sysdescr pattern %q: %s", i, rule.SysDescr, err)
+			}
+			rule.sysDescrPattern = pattern
+		}
+		compiled = append(compiled, rule)
+	}
+	return compiled, nil
+}
+
+// Matches returns whether the fingerprint matches all the patterns of the rule
+func (r *FingerprintRule) Matches(fingerprint Fingerprint) bool {
+	if r.SysObjectID != "" {
+		if matched, _ := path.Match(r.SysObjectID, fingerprint.SysObjectID); !matched {
+			return false
+		}
+	}
+	if r.SysDescr != "" {
+		if r.sysDescrPattern == nil || !r.sysDescrPattern.MatchString(fingerprint.SysDescr) {
+			return false
+		}
+	}
+	return true
+}
+
+// UsesSysDescr returns whether a rule matches the sysDescr, which then needs to be fetched with the sysObjectID
+func UsesSysDescr(rules []FingerprintRule) bool {
+	for i := range rules {
+		if rules[i].SysDescr != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchFingerprint returns the profile of the first rule matching the fingerprint
+func MatchFingerprint(rules []FingerprintRule, fingerprint Fingerprint) (string, bool) {
+	for i := range rules {
+		if rules[i].Matches(fingerprint) {
+			return rules[i].Profile, true
+		}
+	}
+	return "", false
+}
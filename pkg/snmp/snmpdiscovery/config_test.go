@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package snmpdiscovery
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileFingerprintRules_errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		rule          FingerprintRule
+		expectedError string
+	}{
+		{"no profile", FingerprintRule{SysObjectID: "1.3.*"}, "discovery fingerprint 0: profile must be set"},
+		{"no pattern", FingerprintRule{Profile: "cisco"}, "discovery fingerprint 0: sysobjectid or sysdescr must be set"},
+		{"invalid glob", FingerprintRule{SysObjectID: "1.3.[", Profile: "cisco"}, `discovery fingerprint 0: invalid sysobjectid pattern "1.3.[": syntax error in pattern`},
+		{"invalid regex", FingerprintRule{SysDescr: "(", Profile: "cisco"}, "discovery fingerprint 0: invalid sysdescr pattern \"(\": error parsing regexp: missing closing ): `(`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileFingerprintRules([]FingerprintRule{tt.rule})
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := parseMode("")
+	require.NoError(t, err)
+	assert.Equal(t, ModeSweep, mode)
+	assert.False(t, mode.UsesNeighbors())
+
+	mode, err = parseMode("hybrid")
+	require.NoError(t, err)
+	assert.True(t, mode.UsesNeighbors())
+
+	_, err = parseMode("arp")
+	assert.EqualError(t, err, `invalid discovery mode "arp", must be "sweep", "neighbors" or "hybrid"`)
+
+	_, err = parseSeeds([]string{"10.0.0.1", "10.0.0"})
+	assert.EqualError(t, err, `invalid discovery seed "10.0.0", must be an IP address`)
+	assert.EqualError(t, validateCredentials([]Credentials{{Community: "public"}, {Version: "3"}}), "discovery credentials 1: community_string or user must be set")
+}
+
+func TestSettingsParse(t *testing.T) {
+	config, err := Settings{}.Parse()
+	require.NoError(t, err)
+	assert.Equal(t, Config{Mode: ModeSweep, Seeds: []net.IP{}, Fingerprints: []FingerprintRule{}}, config)
+
+	config, err = Settings{
+		Mode:         "neighbors",
+		Seeds:        []string{"10.0.0.1"},
+		Credentials:  []Credentials{{Community: "private"}},
+		Fingerprints: []FingerprintRule{{SysDescr: "^Linux", Profile: "generic-linux"}},
+	}.Parse()
+	require.NoError(t, err)
+	assert.Equal(t, ModeNeighbors, config.Mode)
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.0.1")}, config.Seeds)
+	assert.Equal(t, []Credentials{{Community: "private"}}, config.Credentials)
+	require.Len(t, config.Fingerprints, 1)
+	assert.True(t, config.Fingerprints[0].Matches(Fingerprint{SysDescr: "Linux host 5.4"}))
+
+	tests := []struct {
+		name          string
+		settings      Settings
+		expectedError string
+	}{
+		{"invalid mode", Settings{Mode: "arp"}, `invalid discovery mode "arp", must be "sweep", "neighbors" or "hybrid"`},
+		{"invalid seed", Settings{Mode: "hybrid", Seeds: []string{"10.0.0"}}, `invalid discovery seed "10.0.0", must be an IP address`},
+		{"neighbors without seeds", Settings{Mode: "neighbors"}, "`discovery_seeds` must be set when `discovery_mode` is `neighbors`"},
+		{"credentials without authentication", Settings{Credentials: []Credentials{{Version: "3"}}}, "discovery credentials 0: community_string or user must be set"},
+		{"invalid fingerprint", Settings{Fingerprints: []FingerprintRule{{SysObjectID: "1.3.*"}}}, "discovery fingerprint 0: profile must be set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.settings.Parse()
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
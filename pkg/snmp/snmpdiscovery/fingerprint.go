@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package snmpdiscovery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	// SysObjectIDOid is the OID of sysObjectID.0
+	SysObjectIDOid = "1.3.6.1.2.1.1.2.0"
+	sysDescrOid    = "1.3.6.1.2.1.1.1.0"
+	sysNameOid     = "1.3.6.1.2.1.1.5.0"
+)
+
+// Client is the part of an SNMP session used by the discovery, implemented by the SNMP check sessions,
+// see NewClient for gosnmp.GoSNMP
+type Client interface {
+	Get(oids []string) (*gosnmp.SnmpPacket, error)
+	GetBulk(oids []string, bulkMaxRepetitions uint32) (*gosnmp.SnmpPacket, error)
+	GetNext(oids []string) (*gosnmp.SnmpPacket, error)
+	GetVersion() gosnmp.SnmpVersion
+}
+
+// NewClient returns the Client of a gosnmp connection
+func NewClient(conn *gosnmp.GoSNMP) Client {
+	return gosnmpClient{conn}
+}
+
+type gosnmpClient struct {
+	*gosnmp.GoSNMP
+}
+
+func (c gosnmpClient) GetBulk(oids []string, bulkMaxRepetitions uint32) (*gosnmp.SnmpPacket, error) {
+	return c.GoSNMP.GetBulk(oids, 0, bulkMaxRepetitions)
+}
+
+func (c gosnmpClient) GetVersion() gosnmp.SnmpVersion {
+	return c.Version
+}
+
+// Fingerprint identifies the type of a device
+type Fingerprint struct {
+	SysObjectID string
+	SysDescr    string
+	SysName     string
+}
+
+// FetchFingerprint fetches the fingerprint of a device, the sysObjectID is required. sysDescr and sysName are only
+// fetched when withSysDescr is set, on a best effort basis, see UsesSysDescr.
+func FetchFingerprint(client Client, withSysDescr bool) (Fingerprint, error) {
+	// Since the ContextEngineID is empty, `Get` might lead to multiple SNMP GET calls when using SNMP v3
+	// a first call might be needed to retrieve the engineID and then the call to get the oid values.
+	value, err := client.Get([]string{SysObjectIDOid})
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	if len(value.Variables) < 1 || value.Variables[0].Value == nil || isMissing(value.Variables[0]) {
+		return Fingerprint{}, fmt.Errorf("no value for sysObjectID")
+	}
+	fingerprint := Fingerprint{SysObjectID: strings.TrimPrefix(valueToString(value.Variables[0].Value), ".")}
+	if !withSysDescr {
+		return fingerprint, nil
+	}
+
+	value, err = client.Get([]string{sysDescrOid, sysNameOid})
+	if err != nil || value == nil {
+		return fingerprint, nil
+	}
+	for _, variable := range value.Variables {
+		if isMissing(variable) {
+			continue
+		}
+		switch strings.TrimPrefix(variable.Name, ".") {
+		case sysDescrOid:
+			fingerprint.SysDescr = valueToString(variable.Value)
+		case sysNameOid:
+			fingerprint.SysName = valueToString(variable.Value)
+		}
+	}
+	return fingerprint, nil
+}
+
+func isMissing(variable gosnmp.SnmpPDU) bool {
+	return variable.Type == gosnmp.NoSuchObject || variable.Type == gosnmp.NoSuchInstance || variable.Type == gosnmp.EndOfMibView
+}
+
+func valueToString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
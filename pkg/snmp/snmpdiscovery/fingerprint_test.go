@@ -0,0 +1,182 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package snmpdiscovery
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient answers from a set of PDUs, OIDs are without leading dot
+type fakeClient struct {
+	pdus    map[string]gosnmp.SnmpPDU
+	err     error
+	version gosnmp.SnmpVersion
+	// requests counts the GET, GETNEXT and GETBULK requests
+	requests int
+}
+
+func newFakeClient(pdus ...gosnmp.SnmpPDU) *fakeClient {
+	c := &fakeClient{pdus: make(map[string]gosnmp.SnmpPDU), version: gosnmp.Version2c}
+	for _, pdu := range pdus {
+		c.pdus[pdu.Name] = pdu
+	}
+	return c
+}
+
+func (c *fakeClient) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	c.requests++
+	if c.err != nil {
+		return nil, c.err
+	}
+	packet := &gosnmp.SnmpPacket{}
+	for _, oid := range oids {
+		pdu, ok := c.pdus[oid]
+		if !ok {
+			pdu = gosnmp.SnmpPDU{Name: oid, Type: gosnmp.NoSuchObject}
+		}
+		pdu.Name = "." + pdu.Name
+		packet.Variables = append(packet.Variables, pdu)
+	}
+	return packet, nil
+}
+
+func (c *fakeClient) GetNext(oids []string) (*gosnmp.SnmpPacket, error) {
+	c.requests++
+	if c.err != nil {
+		return nil, c.err
+	}
+	packet := &gosnmp.SnmpPacket{}
+	for _, oid := range oids {
+		packet.Variables = append(packet.Variables, c.next(oid))
+	}
+	return packet, nil
+}
+
+func (c *fakeClient) GetBulk(oids []string, bulkMaxRepetitions uint32) (*gosnmp.SnmpPacket, error) {
+	c.requests++
+	if c.err != nil {
+		return nil, c.err
+	}
+	packet := &gosnmp.SnmpPacket{}
+	for _, oid := range oids {
+		for i := uint32(0); i < bulkMaxRepetitions; i++ {
+			next := c.next(oid)
+			packet.Variables = append(packet.Variables, next)
+			if next.Type == gosnmp.EndOfMibView {
+				break
+			}
+			oid = strings.TrimPrefix(next.Name, ".")
+		}
+	}
+	return packet, nil
+}
+
+func (c *fakeClient) GetVersion() gosnmp.SnmpVersion {
+	return c.version
+}
+
+// next returns the PDU following the OID
+func (c *fakeClient) next(oid string) gosnmp.SnmpPDU {
+	names := make([]string, 0, len(c.pdus))
+	for name := range c.pdus {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return oidLess(names[i], names[j]) })
+	next := gosnmp.SnmpPDU{Name: oid, Type: gosnmp.EndOfMibView}
+	for _, name := range names {
+		if oidLess(oid, name) {
+			next = c.pdus[name]
+			break
+		}
+	}
+	next.Name = "." + next.Name
+	return next
+}
+
+func oidLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		ai, _ := strconv.Atoi(as[i])
+		bi, _ := strconv.Atoi(bs[i])
+		if ai != bi {
+			return ai < bi
+		}
+	}
+	return len(as) < len(bs)
+}
+
+func TestFetchFingerprint(t *testing.T) {
+	client := newFakeClient(
+		gosnmp.SnmpPDU{Name: "1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.9.1.1745"},
+		gosnmp.SnmpPDU{Name: "1.3.6.1.2.1.1.1.0", Type: gosnmp.OctetString, Value: []byte("Cisco IOS Software, C3750E")},
+		gosnmp.SnmpPDU{Name: "1.3.6.1.2.1.1.5.0", Type: gosnmp.OctetString, Value: []byte("switch-1")},
+	)
+	fingerprint, err := FetchFingerprint(client, true)
+	require.NoError(t, err)
+	assert.Equal(t, Fingerprint{SysObjectID: "1.3.6.1.4.1.9.1.1745", SysDescr: "Cisco IOS Software, C3750E", SysName: "switch-1"}, fingerprint)
+
+	// sysDescr and sysName are only fetched when needed
+	fingerprint, err = FetchFingerprint(client, false)
+	require.NoError(t, err)
+	assert.Equal(t, Fingerprint{SysObjectID: "1.3.6.1.4.1.9.1.1745"}, fingerprint)
+
+	// sysDescr and sysName are optional
+	client = newFakeClient(gosnmp.SnmpPDU{Name: "1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.4.1.9.1.1745"})
+	fingerprint, err = FetchFingerprint(client, true)
+	require.NoError(t, err)
+	assert.Equal(t, Fingerprint{SysObjectID: "1.3.6.1.4.1.9.1.1745"}, fingerprint)
+
+	// sysObjectID is required
+	_, err = FetchFingerprint(newFakeClient(), false)
+	assert.EqualError(t, err, "no value for sysObjectID")
+
+	client = newFakeClient()
+	client.err = errors.New("timeout")
+	_, err = FetchFingerprint(client, false)
+	assert.EqualError(t, err, "timeout")
+}
+
+func TestUsesSysDescr(t *testing.T) {
+	assert.False(t, UsesSysDescr(nil))
+	assert.False(t, UsesSysDescr([]FingerprintRule{{SysObjectID: "1.3.6.1.4.1.9.*", Profile: "cisco"}}))
+	assert.True(t, UsesSysDescr([]FingerprintRule{{SysObjectID: "1.3.6.1.4.1.9.*", Profile: "cisco"}, {SysDescr: "^Linux", Profile: "generic-linux"}}))
+}
+
+func TestMatchFingerprint(t *testing.T) {
+	rules, err := compileFingerprintRules([]FingerprintRule{
+		{SysObjectID: "1.3.6.1.4.1.9.*", SysDescr: "(?i)nx-os", Profile: "cisco-nexus"},
+		{SysObjectID: "1.3.6.1.4.1.9.*", Profile: "cisco"},
+		{SysDescr: "^Linux", Profile: "generic-linux"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		fingerprint     Fingerprint
+		expectedProfile string
+		expectedMatch   bool
+	}{
+		{"both patterns", Fingerprint{SysObjectID: "1.3.6.1.4.1.9.12.3.1.3.1812", SysDescr: "Cisco NX-OS(tm) n9000"}, "cisco-nexus", true},
+		{"first match wins", Fingerprint{SysObjectID: "1.3.6.1.4.1.9.1.1745", SysDescr: "Cisco IOS Software"}, "cisco", true},
+		{"sysdescr only", Fingerprint{SysObjectID: "1.3.6.1.4.1.8072.3.2.10", SysDescr: "Linux host 5.4"}, "generic-linux", true},
+		{"no match", Fingerprint{SysObjectID: "1.3.6.1.4.1.2636.1.1.1.2.29", SysDescr: "Juniper Networks"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, ok := MatchFingerprint(rules, tt.fingerprint)
+			assert.Equal(t, tt.expectedMatch, ok)
+			assert.Equal(t, tt.expectedProfile, profile)
+		})
+	}
+}
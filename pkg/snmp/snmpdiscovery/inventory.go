@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package snmpdiscovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/persistentcache"
+)
+
+// Device is a discovered device, as persisted in the inventory
+type Device struct {
+	IP          string `json:"ip"`
+	SysObjectID string `json:"sysobjectid,omitempty"`
+	SysDescr    string `json:"sysdescr,omitempty"`
+	SysName     string `json:"sysname,omitempty"`
+	// Profile is the profile selected from the fingerprint, empty if none was
+	Profile string `json:"profile,omitempty"`
+	// Credentials is the index of the credentials set that worked, 0 being the credentials of the subnet
+	Credentials int       `json:"credentials,omitempty"`
+	Source      Source    `json:"source,omitempty"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// ReadInventory reads the devices persisted under the cache key,
+// the cache written by previous versions only contains the device IPs
+func ReadInventory(cacheKey string) ([]Device, error) {
+	cacheValue, err := persistentcache.Read(cacheKey)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read cache for %s: %s", cacheKey, err)
+	}
+	if cacheValue == "" {
+		return []Device{}, nil
+	}
+	var devices []Device
+	if err = json.Unmarshal([]byte(cacheValue), &devices); err == nil {
+		return devices, nil
+	}
+	var ips []net.IP
+	if legacyErr := json.Unmarshal([]byte(cacheValue), &ips); legacyErr != nil {
+		return nil, fmt.Errorf("couldn't unmarshal cache for %s: %s", cacheKey, err)
+	}
+	devices = make([]Device, 0, len(ips))
+	for _, ip := range ips {
+		devices = append(devices, Device{IP: ip.String()})
+	}
+	return devices, nil
+}
+
+// WriteInventory persists the devices under the cache key
+func WriteInventory(cacheKey string, devices []Device) error {
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].IP < devices[j].IP
+	})
+	cacheValue, err := json.Marshal(devices)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal cache: %s", err)
+	}
+	if err = persistentcache.Write(cacheKey, string(cacheValue)); err != nil {
+		return fmt.Errorf("couldn't write cache: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package snmpdiscovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/persistentcache"
+)
+
+func TestInventory(t *testing.T) {
+	mockConfig := configmock.New(t)
+	mockConfig.SetWithoutSource("run_path", t.TempDir())
+
+	devices, err := ReadInventory("snmp:empty")
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+
+	firstSeen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastSeen := firstSeen.Add(time.Hour)
+	written := []Device{
+		{IP: "10.0.0.2", Source: SourceSweep, FirstSeen: firstSeen, LastSeen: firstSeen},
+		{IP: "10.0.0.1", SysObjectID: "1.3.6.1.4.1.9.1.1745", SysDescr: "Cisco IOS", SysName: "switch-1", Profile: "cisco", Credentials: 1, Source: SourceLLDP, FirstSeen: firstSeen, LastSeen: lastSeen},
+	}
+	require.NoError(t, WriteInventory("snmp:inventory", written))
+	devices, err = ReadInventory("snmp:inventory")
+	require.NoError(t, err)
+	assert.Equal(t, []Device{written[0], written[1]}, devices)
+	assert.Equal(t, "10.0.0.1", devices[0].IP, "devices are sorted by IP")
+
+	// cache written by previous versions
+	require.NoError(t, persistentcache.Write("snmp:legacy", `["10.0.0.1","10.0.0.2"]`))
+	devices, err = ReadInventory("snmp:legacy")
+	require.NoError(t, err)
+	assert.Equal(t, []Device{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}, devices)
+
+	require.NoError(t, persistentcache.Write("snmp:invalid", `{`))
+	_, err = ReadInventory("snmp:invalid")
+	assert.EqualError(t, err, "couldn't unmarshal cache for snmp:invalid: unexpected end of JSON input")
+}
@@ -0,0 +1,149 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package snmpdiscovery
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// DefaultMaxNeighborRows bounds the rows walked per neighbor table of a device
+const DefaultMaxNeighborRows = 10000
+
+// walkMaxRepetitions is the number of rows requested by each GETBULK of a walk
+const walkMaxRepetitions = 50
+
+const (
+	// ipNetToMediaNetAddress, indexed by ifIndex.ipv4
+	ipNetToMediaNetAddressOid = "1.3.6.1.2.1.4.22.1.3"
+	// ipNetToPhysicalPhysAddress, indexed by ifIndex.addressType.addressLength.address
+	ipNetToPhysicalPhysAddressOid = "1.3.6.1.2.1.4.35.1.4"
+	// lldpRemManAddrIfSubtype, indexed by timeMark.localPort.remIndex.addressSubtype.addressLength.address
+	lldpRemManAddrIfSubtypeOid = "1.0.8802.1.1.2.1.4.2.1.3"
+	// cdpCacheAddress, the value is the address
+	cdpCacheAddressOid = "1.3.6.1.4.1.9.9.23.1.2.1.1.4"
+)
+
+// Neighbor is a device found in the tables of a discovered device
+type Neighbor struct {
+	IP     net.IP
+	Source Source
+}
+
+// FetchNeighbors walks the ARP/ND and LLDP/CDP tables of a device, each table is walked on a best effort basis
+// and at most maxRows rows are read per table
+func FetchNeighbors(client Client, maxRows int) []Neighbor {
+	var neighbors []Neighbor
+	add := func(ip net.IP, source Source) {
+		if ip != nil && !ip.IsUnspecified() && !ip.IsLoopback() && !ip.IsMulticast() {
+			neighbors = append(neighbors, Neighbor{IP: ip, Source: source})
+		}
+	}
+
+	walk(client, ipNetToMediaNetAddressOid, maxRows, func(index []int, _ gosnmp.SnmpPDU) {
+		if len(index) == 5 {
+			add(indexToIP(index[1:]), SourceARP)
+		}
+	})
+	walk(client, ipNetToPhysicalPhysAddressOid, maxRows, func(index []int, _ gosnmp.SnmpPDU) {
+		if len(index) < 3 {
+			return
+		}
+		ip := lengthPrefixedIP(index[2:])
+		switch index[1] {
+		case 1: // ipv4
+			add(ip, SourceARP)
+		case 2: // ipv6
+			add(ip, SourceND)
+		}
+	})
+	walk(client, lldpRemManAddrIfSubtypeOid, maxRows, func(index []int, _ gosnmp.SnmpPDU) {
+		// the address subtype is an IANA address family number, 1 is ipv4 and 2 is ipv6
+		if len(index) < 5 || (index[3] != 1 && index[3] != 2) {
+			return
+		}
+		add(lengthPrefixedIP(index[4:]), SourceLLDP)
+	})
+	walk(client, cdpCacheAddressOid, maxRows, func(_ []int, variable gosnmp.SnmpPDU) {
+		if address, ok := variable.Value.([]byte); ok && len(address) == net.IPv4len {
+			add(net.IP(address), SourceCDP)
+		}
+	})
+	return neighbors
+}
+
+// walk calls fn with the index of each row of the column until the end of the column, an error or maxRows rows.
+// The rows are read with GETBULK, or GETNEXT with SNMP v1 which doesn't support GETBULK.
+func walk(client Client, column string, maxRows int, fn func(index []int, variable gosnmp.SnmpPDU)) {
+	prefix := column + "."
+	oid := column
+	for rows := 0; rows < maxRows; {
+		var value *gosnmp.SnmpPacket
+		var err error
+		if client.GetVersion() == gosnmp.Version1 {
+			value, err = client.GetNext([]string{oid})
+		} else {
+			value, err = client.GetBulk([]string{oid}, uint32(min(walkMaxRepetitions, maxRows-rows)))
+		}
+		if err != nil || value == nil || len(value.Variables) < 1 {
+			return
+		}
+		for _, variable := range value.Variables {
+			name := strings.TrimPrefix(variable.Name, ".")
+			if !strings.HasPrefix(name, prefix) || isMissing(variable) {
+				return
+			}
+			index, ok := parseIndex(name[len(prefix):])
+			if !ok {
+				return
+			}
+			fn(index, variable)
+			oid = name
+			rows++
+			if rows >= maxRows {
+				return
+			}
+		}
+	}
+}
+
+func parseIndex(index string) ([]int, bool) {
+	parts := strings.Split(index, ".")
+	values := make([]int, 0, len(parts))
+	for _, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+// lengthPrefixedIP returns the IP of an `addressLength.address` index
+func lengthPrefixedIP(index []int) net.IP {
+	if len(index) < 1 || index[0] != len(index)-1 {
+		return nil
+	}
+	return indexToIP(index[1:])
+}
+
+func indexToIP(index []int) net.IP {
+	if len(index) != net.IPv4len && len(index) != net.IPv6len {
+		return nil
+	}
+	ip := make(net.IP, len(index))
+	for i, b := range index {
+		if b < 0 || b > 255 {
+			return nil
+		}
+		ip[i] = byte(b)
+	}
+	return ip
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package snmpdiscovery
+
+import (
+	"net"
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchNeighbors(t *testing.T) {
+	client := newFakeClient(
+		gosnmp.SnmpPDU{Name: "1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.4.1.9.1.1745"},
+		// ipNetToMediaNetAddress
+		gosnmp.SnmpPDU{Name: "1.3.6.1.2.1.4.22.1.3.2.10.0.0.2", Type: gosnmp.IPAddress, Value: "10.0.0.2"},
+		gosnmp.SnmpPDU{Name: "1.3.6.1.2.1.4.22.1.3.2.10.0.0.3", Type: gosnmp.IPAddress, Value: "10.0.0.3"},
+		// ipNetToPhysicalPhysAddress, ipv4 then ipv6
+		gosnmp.SnmpPDU{Name: "1.3.6.1.2.1.4.35.1.4.3.1.4.10.0.1.5", Type: gosnmp.OctetString, Value: []byte{0, 1, 2, 3, 4, 5}},
+		gosnmp.SnmpPDU{Name: "1.3.6.1.2.1.4.35.1.4.3.2.16.32.1.13.184.0.0.0.0.0.0.0.0.0.0.0.1", Type: gosnmp.OctetString, Value: []byte{0, 1, 2, 3, 4, 6}},
+		// ipNetToPhysicalState, next column
+		gosnmp.SnmpPDU{Name: "1.3.6.1.2.1.4.35.1.7.3.1.4.10.0.1.6", Type: gosnmp.Integer, Value: 1},
+		// lldpRemManAddrIfSubtype, ipv4 and a loopback skipped
+		gosnmp.SnmpPDU{Name: "1.0.8802.1.1.2.1.4.2.1.3.0.7.1.1.4.10.0.2.1", Type: gosnmp.Integer, Value: 2},
+		gosnmp.SnmpPDU{Name: "1.0.8802.1.1.2.1.4.2.1.3.0.8.1.1.4.127.0.0.1", Type: gosnmp.Integer, Value: 2},
+		// cdpCacheAddress
+		gosnmp.SnmpPDU{Name: "1.3.6.1.4.1.9.9.23.1.2.1.1.4.5.1", Type: gosnmp.OctetString, Value: []byte{10, 0, 3, 1}},
+	)
+
+	expectedNeighbors := []Neighbor{
+		{IP: net.IP{10, 0, 0, 2}, Source: SourceARP},
+		{IP: net.IP{10, 0, 0, 3}, Source: SourceARP},
+		{IP: net.IP{10, 0, 1, 5}, Source: SourceARP},
+		{IP: net.ParseIP("2001:db8::1"), Source: SourceND},
+		{IP: net.IP{10, 0, 2, 1}, Source: SourceLLDP},
+		{IP: net.IP{10, 0, 3, 1}, Source: SourceCDP},
+	}
+
+	// each table is read with a single GETBULK
+	neighbors := FetchNeighbors(client, DefaultMaxNeighborRows)
+	assert.Equal(t, expectedNeighbors, neighbors)
+	assert.Equal(t, 4, client.requests)
+
+	// SNMP v1 doesn't support GETBULK, the tables are read with GETNEXT
+	client.version = gosnmp.Version1
+	client.requests = 0
+	neighbors = FetchNeighbors(client, DefaultMaxNeighborRows)
+	assert.Equal(t, expectedNeighbors, neighbors)
+	assert.Equal(t, 11, client.requests)
+
+	// the rows walked are bounded per table
+	for _, version := range []gosnmp.SnmpVersion{gosnmp.Version1, gosnmp.Version2c} {
+		client.version = version
+		neighbors = FetchNeighbors(client, 1)
+		assert.Equal(t, []Neighbor{
+			{IP: net.IP{10, 0, 0, 2}, Source: SourceARP},
+			{IP: net.IP{10, 0, 1, 5}, Source: SourceARP},
+			{IP: net.IP{10, 0, 2, 1}, Source: SourceLLDP},
+			{IP: net.IP{10, 0, 3, 1}, Source: SourceCDP},
+		}, neighbors, version.String())
+	}
+
+	assert.Empty(t, FetchNeighbors(newFakeClient(), DefaultMaxNeighborRows))
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package snmpdiscovery
+
+import "errors"
+
+// Probe runs probe with each of the count credential sets concurrently, and returns the index and result of the
+// first credential set, in order, that succeeded. It returns as soon as all the sets before a successful one failed,
+// without waiting for the sets after it. When all of them failed, the error of the first set is returned.
+func Probe[T any](count int, probe func(credentials int) (T, error)) (int, T, error) {
+	var zero T
+	if count < 1 {
+		return -1, zero, errors.New("no credentials to probe")
+	}
+	if count == 1 {
+		result, err := probe(0)
+		if err != nil {
+			return -1, zero, err
+		}
+		return 0, result, nil
+	}
+
+	type probeResult struct {
+		credentials int
+		result      T
+		err         error
+	}
+	// buffered so that the probes still running once a result is returned don't block
+	done := make(chan probeResult, count)
+	for i := 0; i < count; i++ {
+		go func(i int) {
+			result, err := probe(i)
+			done <- probeResult{credentials: i, result: result, err: err}
+		}(i)
+	}
+
+	results := make([]*probeResult, count)
+	// next is the lowest credential set whose result isn't known yet
+	next := 0
+	for next < count {
+		r := <-done
+		results[r.credentials] = &r
+		for ; next < count && results[next] != nil; next++ {
+			if results[next].err == nil {
+				return next, results[next].result, nil
+			}
+		}
+	}
+	return -1, zero, results[0].err
+}
+
+// ProbeOrder returns the order in which the count credential sets of a known device are probed: the credentials
+// that worked last time come first, followed by the others in order, so that the device keeps them while they work.
+func ProbeOrder(count int, previous int) []int {
+	order := make([]int, 0, count)
+	if previous > 0 && previous < count {
+		order = append(order, previous)
+	}
+	for i := 0; i < count; i++ {
+		if len(order) == 0 || i != order[0] {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// defaultCredentialsAllowedFailures is used when the devices are never removed, credentials are always replaced eventually
+const defaultCredentialsAllowedFailures = 3
+
+// CredentialsAllowedFailures returns how many probes in a row the previous credentials of a device can fail while
+// other credentials answer before they are replaced, given the allowed failures of the devices (-1 to keep them forever).
+func CredentialsAllowedFailures(allowedFailures int) int {
+	if allowedFailures > 0 {
+		return allowedFailures
+	}
+	return defaultCredentialsAllowedFailures
+}
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package snmpdiscovery
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbe(t *testing.T) {
+	var running, maxRunning int32
+	probe := func(succeeding ...int) func(int) (string, error) {
+		return func(credentials int) (string, error) {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				current := atomic.LoadInt32(&maxRunning)
+				if n <= current || atomic.CompareAndSwapInt32(&maxRunning, current, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			for _, s := range succeeding {
+				if s == credentials {
+					return fmt.Sprintf("creds %d", credentials), nil
+				}
+			}
+			return "", fmt.Errorf("creds %d failed", credentials)
+		}
+	}
+
+	// the first credentials set that works, in order, wins
+	index, result, err := Probe(3, probe(1, 2))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, index)
+	assert.Equal(t, "creds 1", result)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&maxRunning), "the credentials sets are tried concurrently")
+
+	index, _, err = Probe(3, probe())
+	assert.EqualError(t, err, "creds 0 failed")
+	assert.Equal(t, -1, index)
+
+	index, result, err = Probe(1, probe(0))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, index)
+	assert.Equal(t, "creds 0", result)
+
+	_, _, err = Probe(0, probe(0))
+	assert.EqualError(t, err, "no credentials to probe")
+}
+
+func TestProbeReturnsEarly(t *testing.T) {
+	unblock := make(chan struct{})
+	defer close(unblock)
+	probe := func(credentials int) (string, error) {
+		switch credentials {
+		case 0:
+			return "", fmt.Errorf("creds %d failed", credentials)
+		case 1:
+			return fmt.Sprintf("creds %d", credentials), nil
+		}
+		// the sets after the first successful one don't delay the result
+		<-unblock
+		return fmt.Sprintf("creds %d", credentials), nil
+	}
+
+	index, result, err := Probe(3, probe)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, index)
+	assert.Equal(t, "creds 1", result)
+}
+
+func TestProbeOrder(t *testing.T) {
+	assert.Equal(t, []int{0, 1, 2}, ProbeOrder(3, 0))
+	assert.Equal(t, []int{2, 0, 1}, ProbeOrder(3, 2))
+	assert.Equal(t, []int{1, 0, 2}, ProbeOrder(3, 1))
+	// credentials sets removed from the config since the device was found
+	assert.Equal(t, []int{0, 1}, ProbeOrder(2, 3))
+	assert.Equal(t, []int{0}, ProbeOrder(1, 0))
+}
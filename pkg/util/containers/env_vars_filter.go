@@ -53,12 +53,41 @@ func EnvVarFilterFromConfig() EnvFilter {
 			configEnvVars = append(configEnvVars, envName)
 		}
 
+		configEnvVars = append(configEnvVars, envVarsFromTagRules()...)
+
 		envFilterFromConfig = newEnvFilter(configEnvVars)
 	})
 
 	return envFilterFromConfig
 }
 
+// envVarsFromTagRules returns the environment variables read by the
+// tag_extraction_rules. The rules with env patterns are rejected by the
+// tagger, so they are skipped here too.
+func envVarsFromTagRules() []string {
+	type envKey struct {
+		Field string `mapstructure:"field"`
+		Key   string `mapstructure:"key"`
+	}
+	var rules []struct {
+		From []envKey `mapstructure:"from"`
+		When []envKey `mapstructure:"when"`
+	}
+	if err := config.Datadog().UnmarshalKey("tag_extraction_rules", &rules); err != nil {
+		return nil
+	}
+
+	var envVars []string
+	for _, rule := range rules {
+		for _, key := range append(rule.From, rule.When...) {
+			if key.Field == "env" && key.Key != "" && !strings.ContainsAny(key.Key, "*?[{") {
+				envVars = append(envVars, key.Key)
+			}
+		}
+	}
+	return envVars
+}
+
 // EnvFilter defines a filter for environment variables
 type EnvFilter struct {
 	includeVars map[string]struct{}
@@ -9,6 +9,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
 )
 
 func TestEnvFilter_IsIncluded(t *testing.T) {
@@ -19,3 +21,20 @@ func TestEnvFilter_IsIncluded(t *testing.T) {
 	assert.True(t, filter.IsIncluded("BAR"))
 	assert.False(t, filter.IsIncluded("BAZ"))
 }
+
+func TestEnvVarsFromTagRules(t *testing.T) {
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource("tag_extraction_rules", []map[string]interface{}{
+		{
+			"tag":  "team",
+			"from": []map[string]interface{}{{"field": "env", "key": "TEAM"}, {"field": "labels", "key": "team"}},
+			"when": []map[string]interface{}{{"field": "env", "key": "STAGE"}},
+		},
+		{
+			"tag":  "%%key%%",
+			"from": []map[string]interface{}{{"field": "env", "key": "APP_*"}},
+		},
+	})
+
+	assert.ElementsMatch(t, []string{"TEAM", "STAGE"}, envVarsFromTagRules())
+}
@@ -42,12 +42,14 @@ func init() {
 // the default replacers.
 func AddDefaultReplacers(scrubber *Scrubber) {
 	hintedAPIKeyReplacer := Replacer{
+		Name: "api_key",
 		// If hinted, mask the value regardless if it doesn't match 32-char hexadecimal string
 		Regex: regexp.MustCompile(`(api_?key=)\b[a-zA-Z0-9]+([a-zA-Z0-9]{5})\b`),
 		Hints: []string{"api_key", "apikey"},
 		Repl:  []byte(`$1***************************$2`),
 	}
 	hintedAPPKeyReplacer := Replacer{
+		Name: "app_key",
 		// If hinted, mask the value regardless if it doesn't match 40-char hexadecimal string
 		Regex: regexp.MustCompile(`(ap(?:p|plication)_?key=)\b[a-zA-Z0-9]+([a-zA-Z0-9]{5})\b`),
 		Hints: []string{"app_key", "appkey", "application_key"},
@@ -57,40 +59,48 @@ func AddDefaultReplacers(scrubber *Scrubber) {
 	// replacers are check one by one in order. We first try to scrub 64 bytes token, keeping the last 5 digit. If
 	// the token has a different size we scrub it entirely.
 	hintedBearerReplacer := Replacer{
+		Name:  "bearer_token",
 		Regex: regexp.MustCompile(`\bBearer [a-fA-F0-9]{59}([a-fA-F0-9]{5})\b`),
 		Hints: []string{"Bearer"},
 		Repl:  []byte(`Bearer ***********************************************************$1`),
 	}
 	// For this one we match any characters
 	hintedBearerInvalidReplacer := Replacer{
+		Name:  "bearer_token",
 		Regex: regexp.MustCompile(`\bBearer\s+[^*]+\b`),
 		Hints: []string{"Bearer"},
 		Repl:  []byte("Bearer " + defaultReplacement),
 	}
 
 	apiKeyReplacerYAML := Replacer{
+		Name:  "api_key",
 		Regex: regexp.MustCompile(`(\-|\:|,|\[|\{)(\s+)?\b[a-fA-F0-9]{27}([a-fA-F0-9]{5})\b`),
 		Repl:  []byte(`$1$2"***************************$3"`),
 	}
 	apiKeyReplacer := Replacer{
+		Name:  "api_key",
 		Regex: regexp.MustCompile(`\b[a-fA-F0-9]{27}([a-fA-F0-9]{5})\b`),
 		Repl:  []byte(`***************************$1`),
 	}
 	appKeyReplacerYAML := Replacer{
+		Name:  "app_key",
 		Regex: regexp.MustCompile(`(\-|\:|,|\[|\{)(\s+)?\b[a-fA-F0-9]{35}([a-fA-F0-9]{5})\b`),
 		Repl:  []byte(`$1$2"***********************************$3"`),
 	}
 	appKeyReplacer := Replacer{
+		Name:  "app_key",
 		Regex: regexp.MustCompile(`\b[a-fA-F0-9]{35}([a-fA-F0-9]{5})\b`),
 		Repl:  []byte(`***********************************$1`),
 	}
 	rcAppKeyReplacer := Replacer{
+		Name:  "rc_app_key",
 		Regex: regexp.MustCompile(`\bDDRCM_[A-Z0-9]+([A-Z0-9]{5})\b`),
 		Repl:  []byte(`***********************************$1`),
 	}
 	// URI Generic Syntax
 	// https://tools.ietf.org/html/rfc3986
 	uriPasswordReplacer := Replacer{
+		Name:  "uri_password",
 		Regex: regexp.MustCompile(`(?i)([a-z][a-z0-9+-.]+://|\b)([^:]+):([^\s|"]+)@`),
 		Repl:  []byte(`$1$2:********@`),
 	}
@@ -100,6 +110,7 @@ func AddDefaultReplacers(scrubber *Scrubber) {
 		[]byte(`$1 "********"`),
 	)
 	passwordReplacer := Replacer{
+		Name: "password",
 		// this regex has three parts:
 		// * key: case-insensitive, optionally quoted (pass | password | pswd | pwd), not anchored to match on args like --mysql_password= etc.
 		// * separator: (= or :) with optional opening quote we don't want to match as part of the password
@@ -124,6 +135,7 @@ func AddDefaultReplacers(scrubber *Scrubber) {
 		[]byte(`$1 "********"`),
 	)
 	certReplacer := Replacer{
+		Name: "certificate",
 		/*
 		   Try to match as accurately as possible. RFC 7468's ABNF
 		   Backreferences are not available in go, so we cannot verify
@@ -168,6 +180,13 @@ func AddDefaultReplacers(scrubber *Scrubber) {
 		},
 	)
 
+	yamlPasswordReplacer.Name = "password"
+	tokenReplacer.Name = "token"
+	snmpReplacer.Name = "snmp_credentials"
+	snmpMultilineReplacer.Name = "snmp_credentials"
+	apiKeyYaml.Name = "api_key"
+	appKeyYaml.Name = "app_key"
+
 	scrubber.AddReplacer(SingleLine, hintedAPIKeyReplacer)
 	scrubber.AddReplacer(SingleLine, hintedAPPKeyReplacer)
 	scrubber.AddReplacer(SingleLine, hintedBearerReplacer)
@@ -368,6 +387,7 @@ func AddStrippedKeys(strippedKeys []string) {
 			strippedKeys,
 			[]byte(`$1 "********"`),
 		)
+		replacer.Name = "stripped_key"
 		// We add the new replacer to the default scrubber and to the list of dynamicReplacers so any new
 		// scubber will inherit it.
 		DefaultScrubber.AddReplacer(SingleLine, replacer)
@@ -21,6 +21,9 @@ import (
 
 // Replacer represents a replacement of sensitive information with a "clean" version.
 type Replacer struct {
+	// Name identifies the replacer in the Replacements reported by the scrubber. Replacers sharing a name are
+	// reported together.
+	Name string
 	// Regex must match the sensitive information
 	Regex *regexp.Regexp
 	// YAMLKeyRegex matches the key of sensitive information in a dict/map. This is used when iterating over a
@@ -54,6 +57,23 @@ const (
 	MultiLine
 )
 
+// Replacements counts, by replacer name, how many values were replaced while scrubbing some data.
+type Replacements map[string]int
+
+// unnamedReplacer is the name under which the replacements of replacers without a name are reported
+const unnamedReplacer = "unnamed"
+
+func (r Replacements) add(replacer Replacer, count int) {
+	if r == nil || count == 0 {
+		return
+	}
+	name := replacer.Name
+	if name == "" {
+		name = unnamedReplacer
+	}
+	r[name] += count
+}
+
 var commentRegex = regexp.MustCompile(`^\s*#.*$`)
 var blankRegex = regexp.MustCompile(`^\s*$`)
 
@@ -112,24 +132,32 @@ func (c *Scrubber) ScrubFile(filePath string) ([]byte, error) {
 		sizeHint = int(stats.Size())
 	}
 
-	return c.scrubReader(file, sizeHint)
+	return c.scrubReader(file, sizeHint, nil)
 }
 
 // ScrubBytes scrubs credentials from slice of bytes
 func (c *Scrubber) ScrubBytes(data []byte) ([]byte, error) {
 	r := bytes.NewReader(data)
-	return c.scrubReader(r, r.Len())
+	return c.scrubReader(r, r.Len(), nil)
+}
+
+// ScrubBytesWithReport scrubs credentials from slice of bytes and reports how many values each replacer modified
+func (c *Scrubber) ScrubBytesWithReport(data []byte) ([]byte, Replacements, error) {
+	replacements := Replacements{}
+	r := bytes.NewReader(data)
+	scrubbed, err := c.scrubReader(r, r.Len(), replacements)
+	return scrubbed, replacements, err
 }
 
 // ScrubLine scrubs credentials from a single line of text.  It can be safely
 // applied to URLs or to strings containing URLs. It does not run multi-line
 // replacers, and should not be used on multi-line inputs.
 func (c *Scrubber) ScrubLine(message string) string {
-	return string(c.scrub([]byte(message), c.singleLineReplacers))
+	return string(c.scrub([]byte(message), c.singleLineReplacers, nil))
 }
 
-// scrubReader applies the cleaning algorithm to a Reader
-func (c *Scrubber) scrubReader(file io.Reader, sizeHint int) ([]byte, error) {
+// scrubReader applies the cleaning algorithm to a Reader. Replacements are counted in replacements when it isn't nil.
+func (c *Scrubber) scrubReader(file io.Reader, sizeHint int, replacements Replacements) ([]byte, error) {
 	var cleanedBuffer bytes.Buffer
 	if sizeHint > 0 {
 		cleanedBuffer.Grow(sizeHint)
@@ -149,7 +177,7 @@ func (c *Scrubber) scrubReader(file io.Reader, sizeHint int) ([]byte, error) {
 		if blankRegex.Match(b) {
 			cleanedBuffer.WriteRune('\n')
 		} else if !commentRegex.Match(b) {
-			b = c.scrub(b, c.singleLineReplacers)
+			b = c.scrub(b, c.singleLineReplacers, replacements)
 			if !first {
 				cleanedBuffer.WriteRune('\n')
 			}
@@ -164,13 +192,13 @@ func (c *Scrubber) scrubReader(file io.Reader, sizeHint int) ([]byte, error) {
 	}
 
 	// Then we apply multiline replacers on the cleaned file
-	cleanedFile := c.scrub(cleanedBuffer.Bytes(), c.multiLineReplacers)
+	cleanedFile := c.scrub(cleanedBuffer.Bytes(), c.multiLineReplacers, replacements)
 
 	return cleanedFile, nil
 }
 
-// scrub applies the given replacers to the given data.
-func (c *Scrubber) scrub(data []byte, replacers []Replacer) []byte {
+// scrub applies the given replacers to the given data. Replacements are counted in replacements when it isn't nil.
+func (c *Scrubber) scrub(data []byte, replacers []Replacer, replacements Replacements) []byte {
 	for _, repl := range replacers {
 		if repl.Regex == nil {
 			// ignoring YAML only replacers
@@ -185,6 +213,9 @@ func (c *Scrubber) scrub(data []byte, replacers []Replacer) []byte {
 			}
 		}
 		if len(repl.Hints) == 0 || containsHint {
+			if replacements != nil {
+				replacements.add(repl, countReplacements(data, repl))
+			}
 			if repl.ReplFunc != nil {
 				data = repl.Regex.ReplaceAllFunc(data, repl.ReplFunc)
 			} else {
@@ -194,3 +225,22 @@ func (c *Scrubber) scrub(data []byte, replacers []Replacer) []byte {
 	}
 	return data
 }
+
+// countReplacements returns how many matches of the replacer would actually be modified in data. Matches that are
+// replaced by themselves, like values that are already scrubbed, aren't counted.
+func countReplacements(data []byte, repl Replacer) int {
+	count := 0
+	for _, match := range repl.Regex.FindAllSubmatchIndex(data, -1) {
+		matched := data[match[0]:match[1]]
+		var replaced []byte
+		if repl.ReplFunc != nil {
+			replaced = repl.ReplFunc(bytes.Clone(matched))
+		} else {
+			replaced = repl.Regex.Expand(nil, repl.Repl, data, match)
+		}
+		if !bytes.Equal(matched, replaced) {
+			count++
+		}
+	}
+	return count
+}
@@ -104,3 +104,36 @@ func TestScrubBig(t *testing.T) {
 	_, err := scrubber.ScrubBytes(content)
 	require.NoError(t, err)
 }
+
+func TestScrubBytesWithReport(t *testing.T) {
+	scrubber := New()
+	scrubber.AddReplacer(SingleLine, Replacer{
+		Name:  "foo",
+		Regex: regexp.MustCompile("foo"),
+		Repl:  []byte("bar"),
+	})
+	scrubber.AddReplacer(SingleLine, Replacer{
+		Regex: regexp.MustCompile("b(a)z"),
+		Repl:  []byte("b${1}z"),
+	})
+	scrubber.AddReplacer(MultiLine, Replacer{
+		Name:     "multi",
+		Regex:    regexp.MustCompile(`BEGIN\n.*\nEND`),
+		ReplFunc: func([]byte) []byte { return []byte("********") },
+	})
+
+	res, replacements, err := scrubber.ScrubBytesWithReport([]byte("foo foo baz\nBEGIN\nfoo\nEND"))
+	require.NoError(t, err)
+	require.Equal(t, "bar bar baz\n********", string(res))
+	// the unnamed replacer matched but didn't modify anything
+	require.Equal(t, Replacements{"foo": 3, "multi": 1}, replacements)
+}
+
+func TestScrubBytesWithReportDefaults(t *testing.T) {
+	res, replacements, err := NewWithDefaults().ScrubBytesWithReport([]byte("api_key: aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\npassword: secret\nhost: localhost"))
+	require.NoError(t, err)
+	require.Equal(t, "api_key: \"***************************aaaaa\"\npassword: \"********\"\nhost: localhost", string(res))
+	require.Equal(t, 1, replacements["api_key"])
+	require.Positive(t, replacements["password"])
+	require.NotContains(t, replacements, "token")
+}
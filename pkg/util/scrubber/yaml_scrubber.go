@@ -6,8 +6,10 @@
 package scrubber
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"reflect"
 
 	"gopkg.in/yaml.v2"
 )
@@ -80,16 +82,25 @@ func walk(data *interface{}, callback scrubCallback) {
 
 // ScrubDataObj scrubs credentials from the data interface by recursively walking over all the nodes
 func (c *Scrubber) ScrubDataObj(data *interface{}) {
+	c.scrubDataObj(data, nil)
+}
+
+// scrubDataObj scrubs credentials from the data interface. Replacements are counted in replacements when it isn't nil.
+func (c *Scrubber) scrubDataObj(data *interface{}, replacements Replacements) {
 	walk(data, func(key string, value interface{}) (bool, interface{}) {
 		for _, replacer := range c.singleLineReplacers {
 			if replacer.YAMLKeyRegex == nil {
 				continue
 			}
 			if replacer.YAMLKeyRegex.Match([]byte(key)) {
+				newValue := interface{}(defaultReplacement)
 				if replacer.ProcessValue != nil {
-					return true, replacer.ProcessValue(value)
+					newValue = replacer.ProcessValue(value)
+				}
+				if replacements != nil && !reflect.DeepEqual(value, newValue) {
+					replacements.add(replacer, 1)
 				}
-				return true, defaultReplacement
+				return true, newValue
 			}
 		}
 		return false, ""
@@ -99,12 +110,29 @@ func (c *Scrubber) ScrubDataObj(data *interface{}) {
 // ScrubYaml scrubs credentials from the given YAML by loading the data and scrubbing the object instead of the
 // serialized string.
 func (c *Scrubber) ScrubYaml(input []byte) ([]byte, error) {
+	input = c.scrubYamlObj(input, nil)
+	return c.ScrubBytes(input)
+}
+
+// ScrubYamlWithReport scrubs credentials from the given YAML like ScrubYaml and reports how many values each replacer
+// modified
+func (c *Scrubber) ScrubYamlWithReport(input []byte) ([]byte, Replacements, error) {
+	replacements := Replacements{}
+	input = c.scrubYamlObj(input, replacements)
+	r := bytes.NewReader(input)
+	scrubbed, err := c.scrubReader(r, r.Len(), replacements)
+	return scrubbed, replacements, err
+}
+
+// scrubYamlObj scrubs the YAML object loaded from input and returns it serialized. The input is returned unchanged
+// when it can't be loaded or serialized back.
+func (c *Scrubber) scrubYamlObj(input []byte, replacements Replacements) []byte {
 	var data *interface{}
 	err := yaml.Unmarshal(input, &data)
 
 	// if we can't load the yaml run the default scrubber on the input
 	if len(input) != 0 && err == nil {
-		c.ScrubDataObj(data)
+		c.scrubDataObj(data, replacements)
 		newInput, err := yaml.Marshal(data)
 		if err == nil {
 			input = newInput
@@ -113,5 +141,5 @@ func (c *Scrubber) ScrubYaml(input []byte) ([]byte, error) {
 			fmt.Fprintf(os.Stderr, "error scrubbing YAML, falling back on text scrubber: %s\n", err)
 		}
 	}
-	return c.ScrubBytes(input)
+	return input
 }
@@ -62,3 +62,23 @@ func TestScrubDataObj(t *testing.T) {
 		})
 	}
 }
+
+func TestScrubYamlWithReport(t *testing.T) {
+	input := `api_key: aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+app_key: ""
+instances:
+- host: localhost
+  auth_token: secret
+`
+	res, replacements, err := NewWithDefaults().ScrubYamlWithReport([]byte(input))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(res), "secret")
+	assert.Equal(t, 1, replacements["api_key"])
+	assert.Positive(t, replacements["token"])
+	// empty keys are left untouched
+	assert.NotContains(t, replacements, "app_key")
+
+	expected, err := NewWithDefaults().ScrubYaml([]byte(input))
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(res))
+}
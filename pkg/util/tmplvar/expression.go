@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tmplvar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	filterSeparator    = "|"
+	filterArgSeparator = ":"
+	defaultFilter      = "default"
+)
+
+// Expression is a template variable followed by filters transforming its value,
+// for example `label_app|default:web|upper`.
+type Expression struct {
+	// Variable is the template variable, for example `label_app`
+	Variable string
+	// Filters are applied in order to the value of the variable
+	Filters []Filter
+}
+
+// Filter is a transformation of the value of a template variable
+type Filter struct {
+	Name string
+	Args []string
+}
+
+type filterSpec struct {
+	// the last argument gets the rest of the filter, so that it can contain the
+	// argument separator
+	minArgs, maxArgs int
+	apply            func(value string, args []string) (string, error)
+}
+
+var filters = map[string]filterSpec{
+	// default is handled by Apply, as it applies to unresolved variables
+	defaultFilter: {1, 1, nil},
+	"lower": {0, 0, func(value string, _ []string) (string, error) {
+		return strings.ToLower(value), nil
+	}},
+	"upper": {0, 0, func(value string, _ []string) (string, error) {
+		return strings.ToUpper(value), nil
+	}},
+	"trim": {0, 0, func(value string, _ []string) (string, error) {
+		return strings.TrimSpace(value), nil
+	}},
+	"trimprefix": {1, 1, func(value string, args []string) (string, error) {
+		return strings.TrimPrefix(value, args[0]), nil
+	}},
+	"trimsuffix": {1, 1, func(value string, args []string) (string, error) {
+		return strings.TrimSuffix(value, args[0]), nil
+	}},
+	"replace": {2, 2, func(value string, args []string) (string, error) {
+		return strings.ReplaceAll(value, args[0], args[1]), nil
+	}},
+	"index": {1, 2, applyIndex},
+}
+
+// applyIndex selects an element of a list of values separated by commas, or by
+// the separator given as second argument. Negative indexes count from the end.
+func applyIndex(value string, args []string) (string, error) {
+	idx, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid index %q", args[0])
+	}
+	sep := ","
+	if len(args) == 2 && args[1] != "" {
+		sep = args[1]
+	}
+	elems := strings.Split(value, sep)
+	if idx < 0 {
+		idx += len(elems)
+	}
+	if idx < 0 || idx >= len(elems) {
+		return "", fmt.Errorf("index %s out of range for %d elements", args[0], len(elems))
+	}
+	return strings.TrimSpace(elems[idx]), nil
+}
+
+// ParseExpression parses the content of a template variable, without its `%%`
+// delimiters: the variable name optionally followed by pipe separated filters,
+// with arguments separated by colons, like `label_app|default:web|upper`.
+func ParseExpression(s string) (Expression, error) {
+	parts := strings.Split(s, filterSeparator)
+	expr := Expression{Variable: strings.TrimSpace(parts[0])}
+	if expr.Variable == "" {
+		return expr, fmt.Errorf("missing variable in %q", s)
+	}
+
+	for _, part := range parts[1:] {
+		name, rawArgs, hasArgs := strings.Cut(strings.TrimSpace(part), filterArgSeparator)
+		spec, found := filters[name]
+		if !found {
+			return expr, fmt.Errorf("unknown filter %q in %q", name, s)
+		}
+		var args []string
+		if hasArgs && spec.maxArgs > 0 {
+			args = strings.SplitN(rawArgs, filterArgSeparator, spec.maxArgs)
+		} else if hasArgs {
+			return expr, fmt.Errorf("filter %q takes no argument in %q", name, s)
+		}
+		if len(args) < spec.minArgs {
+			return expr, fmt.Errorf("filter %q requires %d argument(s) in %q", name, spec.minArgs, s)
+		}
+		expr.Filters = append(expr.Filters, Filter{Name: name, Args: args})
+	}
+	return expr, nil
+}
+
+// Apply applies the filters of the expression to the value of its variable.
+// resolveErr is the error that occurred when resolving the variable, if any: the
+// default filter replaces an unresolved or empty value, the other filters are
+// skipped while the variable is unresolved.
+func (e Expression) Apply(value string, resolveErr error) (string, error) {
+	err := resolveErr
+	for _, f := range e.Filters {
+		if f.Name == defaultFilter {
+			if err != nil || value == "" {
+				value, err = f.Args[0], nil
+			}
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if value, err = filters[f.Name].apply(value, f.Args); err != nil {
+			err = fmt.Errorf("filter %q failed: %w", f.Name, err)
+		}
+	}
+	return value, err
+}
@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tmplvar
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpression(t *testing.T) {
+	expr, err := ParseExpression("label_app")
+	require.NoError(t, err)
+	assert.Equal(t, Expression{Variable: "label_app"}, expr)
+
+	expr, err = ParseExpression("label_url | default:http://localhost:80 | replace:http:https | index:-1:/")
+	require.NoError(t, err)
+	assert.Equal(t, Expression{Variable: "label_url", Filters: []Filter{
+		{Name: "default", Args: []string{"http://localhost:80"}},
+		{Name: "replace", Args: []string{"http", "https"}},
+		{Name: "index", Args: []string{"-1", "/"}},
+	}}, expr)
+
+	for in, msg := range map[string]string{
+		"|upper":            `missing variable in "|upper"`,
+		"label_app|unknown": `unknown filter "unknown" in "label_app|unknown"`,
+		"label_app|upper:x": `filter "upper" takes no argument in "label_app|upper:x"`,
+		"label_app|replace": `filter "replace" requires 2 argument(s) in "label_app|replace"`,
+		"label_app|default": `filter "default" requires 1 argument(s) in "label_app|default"`,
+	} {
+		_, err := ParseExpression(in)
+		assert.EqualError(t, err, msg, in)
+	}
+}
+
+func TestExpressionApply(t *testing.T) {
+	for _, tc := range []struct {
+		expr       string
+		value      string
+		resolveErr error
+		expected   string
+		err        string
+	}{
+		{expr: "v", value: "Web", expected: "Web"},
+		{expr: "v|lower", value: "Web", expected: "web"},
+		{expr: "v|upper|trimprefix:W", value: "web", expected: "EB"},
+		{expr: "v|trim|trimsuffix:-svc", value: " web-svc ", expected: "web"},
+		{expr: "v|default:api", value: "", expected: "api"},
+		{expr: "v|default:api|upper", resolveErr: errors.New("not found"), expected: "API"},
+		{expr: "v|upper|default:api", resolveErr: errors.New("not found"), expected: "api"},
+		{expr: "v|upper", resolveErr: errors.New("not found"), err: "not found"},
+		{expr: "v|index:1", value: "a, b, c", expected: "b"},
+		{expr: "v|index:-1:.", value: "redis.prod.svc", expected: "svc"},
+		{expr: "v|index:3", value: "a,b", err: `filter "index" failed: index 3 out of range for 2 elements`},
+		{expr: "v|index:x", value: "a,b", err: `filter "index" failed: invalid index "x"`},
+		{expr: "v|index:3|default:a", value: "a,b", expected: "a"},
+	} {
+		expr, err := ParseExpression(tc.expr)
+		require.NoError(t, err)
+		value, err := expr.Apply(tc.value, tc.resolveErr)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, tc.expr)
+			continue
+		}
+		require.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.expected, value, tc.expr)
+	}
+}
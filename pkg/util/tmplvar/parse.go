@@ -36,8 +36,9 @@ func Parse(b []byte) []TemplateVar {
 }
 
 // parseTemplateVar extracts the name of the var and the key (or index if it can be
-// cast to an int)
+// cast to an int), ignoring the filters of the expression if any
 func parseTemplateVar(v []byte) (name, key []byte) {
+	v, _, _ = bytes.Cut(v, []byte(filterSeparator))
 	stripped := bytes.Map(func(r rune) rune {
 		if unicode.IsSpace(r) || r == '%' {
 			return -1
@@ -51,3 +51,9 @@ func TestParseTemplateVar(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTemplateVarWithFilters(t *testing.T) {
+	name, key := parseTemplateVar([]byte("%%label_app|default:web|upper%%"))
+	assert.Equal(t, "label", string(name))
+	assert.Equal(t, "app", string(key))
+}